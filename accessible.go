@@ -0,0 +1,116 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// ReadingWordsPerMinute is the reading speed used to estimate
+	// AccessibleLine.ReadingTime.
+	ReadingWordsPerMinute = 180
+
+	// MinReadingTime is the shortest ReadingTime estimated for any line.
+	MinReadingTime = time.Second
+
+	// EmotionTagPrefix marks line tags (in the metadata table) that describe
+	// the emotion of a line, e.g. #emotion:angry.
+	EmotionTagPrefix = "emotion:"
+
+	// characterAttribute is the markup attribute marking the speaker's name,
+	// e.g. [character name="Bea"]Bea: [/character]Hello.
+	characterAttribute = "character"
+
+	// maxSpeakerLen limits how long a "Name: " prefix can be before it is
+	// assumed to be part of the line.
+	maxSpeakerLen = 32
+)
+
+// AccessibleLine contains the information about a line needed by screen
+// readers and other assistive features.
+type AccessibleLine struct {
+	// ID is the line ID.
+	ID string `json:"id"`
+
+	// Speaker is the name of the character speaking, if known.
+	Speaker string `json:"speaker,omitempty"`
+
+	// Text is the rendered text, with markup and the speaker's name removed.
+	Text string `json:"text"`
+
+	// Emotions are from line tags starting with EmotionTagPrefix (without
+	// the prefix).
+	Emotions []string `json:"emotions,omitempty"`
+
+	// Tags are all the line's tags, from the metadata table.
+	Tags []string `json:"tags,omitempty"`
+
+	// ReadingTime estimates how long the line takes to read.
+	ReadingTime time.Duration `json:"reading_time"`
+}
+
+// Accessible renders the line using the string table and returns the
+// information needed to present it accessibly. The speaker is found from a
+// "character" attribute with a "name" property, or otherwise from a short
+// "Name: " prefix (the usual Yarn Spinner convention).
+func (l Line) Accessible(st *StringTable) (*AccessibleLine, error) {
+	as, err := st.Render(l)
+	if err != nil {
+		return nil, err
+	}
+	al := &AccessibleLine{ID: l.ID}
+	al.Speaker, al.Text = findSpeaker(as)
+	if row := st.Table[l.ID]; row != nil {
+		al.Tags = row.Tags
+		for _, tag := range row.Tags {
+			if emo, ok := strings.CutPrefix(tag, EmotionTagPrefix); ok {
+				al.Emotions = append(al.Emotions, emo)
+			}
+		}
+	}
+
+	words := len(strings.Fields(al.Text))
+	al.ReadingTime = max(MinReadingTime, time.Duration(words)*time.Minute/ReadingWordsPerMinute)
+	return al, nil
+}
+
+// findSpeaker returns the speaker's name from a "character" attribute with a
+// "name" property, or otherwise from a short "Name: " prefix, along with the
+// text without the name.
+func findSpeaker(as *AttributedString) (speaker, text string) {
+	text = as.String()
+
+	// Prefer an explicit character attribute.
+	found := false
+	as.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if found || a.Name != characterAttribute || a.Start != pos {
+				continue
+			}
+			found = true
+			speaker = a.Props["name"]
+			text = text[:a.Start] + text[a.End:]
+		}
+	})
+	if !found {
+		if name, rest, ok := strings.Cut(text, ": "); ok && name != "" && utf8.RuneCountInString(name) <= maxSpeakerLen && !strings.ContainsAny(name, ".!?\n") {
+			speaker, text = name, rest
+		}
+	}
+	return speaker, strings.TrimSpace(text)
+}
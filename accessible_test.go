@@ -0,0 +1,78 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLineAccessible(t *testing.T) {
+	st := &StringTable{
+		Table: map[string]*StringTableRow{
+			"line:attr": {
+				ID:   "line:attr",
+				Text: `[character name="Bea"]Bea: [/character]I [b]really[/b] can't believe it!`,
+				Tags: []string{"emotion:surprised", "lastline"},
+			},
+			"line:prefix": {ID: "line:prefix", Text: `Old Man: Come closer, {0}.`},
+			"line:none":   {ID: "line:none", Text: `Warning. This is not a speaker: it's a sentence.`},
+		},
+	}
+	tests := []struct {
+		line Line
+		want *AccessibleLine
+	}{
+		{
+			line: Line{ID: "line:attr"},
+			want: &AccessibleLine{
+				ID:          "line:attr",
+				Speaker:     "Bea",
+				Text:        "I really can't believe it!",
+				Emotions:    []string{"surprised"},
+				Tags:        []string{"emotion:surprised", "lastline"},
+				ReadingTime: time.Second * 5 * 60 / ReadingWordsPerMinute,
+			},
+		},
+		{
+			line: Line{ID: "line:prefix", Substitutions: []string{"child"}},
+			want: &AccessibleLine{
+				ID:          "line:prefix",
+				Speaker:     "Old Man",
+				Text:        "Come closer, child.",
+				ReadingTime: MinReadingTime,
+			},
+		},
+		{
+			line: Line{ID: "line:none"},
+			want: &AccessibleLine{
+				ID:          "line:none",
+				Text:        "Warning. This is not a speaker: it's a sentence.",
+				ReadingTime: time.Second * 9 * 60 / ReadingWordsPerMinute,
+			},
+		},
+	}
+	for _, test := range tests {
+		got, err := test.line.Accessible(st)
+		if err != nil {
+			t.Fatalf("Line{%q}.Accessible(st) error = %v", test.line.ID, err)
+		}
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("Line{%q}.Accessible(st) diff (-got +want):\n%s", test.line.ID, diff)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package affinity provides functions and storage conventions for
+// relationship (affinity) systems, a common need in dialogue-heavy games.
+//
+// Each character's affinity is a number stored in an ordinary Yarn variable,
+// named by VarName (e.g. $affinity_Ava), so it is saved and restored along
+// with the other variables, and can be used directly in conditions. The
+// functions in Library let dialogue change and query it:
+//
+//	<<if affinity("Ava") > 10>>
+//	Ava: I'm glad you're here.
+//	<<endif>>
+//	-> Compliment her
+//	    <<set $ignored = raise_affinity("Ava", 2)>>
+package affinity // import "github.com/DrJosh9000/yarn/affinity"
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+// VarPrefix is the prefix of the variables storing affinities.
+const VarPrefix = "$affinity_"
+
+// VarName returns the name of the variable storing the affinity for a
+// character. Characters in the name that can't appear in a Yarn variable name
+// are replaced with underscores.
+func VarName(character string) string {
+	return VarPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		}
+		return '_'
+	}, character)
+}
+
+// DecayFunc returns a new affinity value, given the current value and the
+// time elapsed since the last decay.
+type DecayFunc func(character string, value float32, elapsed time.Duration) float32
+
+// ExponentialDecay returns a DecayFunc that moves values toward a resting
+// value, halving the difference every halfLife.
+func ExponentialDecay(toward float32, halfLife time.Duration) DecayFunc {
+	return func(_ string, value float32, elapsed time.Duration) float32 {
+		f := math.Exp2(-float64(elapsed) / float64(halfLife))
+		return toward + (value-toward)*float32(f)
+	}
+}
+
+// LinearDecay returns a DecayFunc that moves values toward a resting value
+// at a constant rate per hour, without overshooting it.
+func LinearDecay(toward, perHour float32) DecayFunc {
+	return func(_ string, value float32, elapsed time.Duration) float32 {
+		step := perHour * float32(elapsed.Hours())
+		if value > toward {
+			return max(value-step, toward)
+		}
+		return min(value+step, toward)
+	}
+}
+
+// System manages affinities stored in a VariableStorage.
+type System struct {
+	// Vars stores the affinities.
+	Vars yarn.VariableStorage
+
+	// Min and Max clamp affinities. If they are equal, values are not
+	// clamped.
+	Min, Max float32
+
+	// Default is the affinity of characters that have none stored.
+	Default float32
+
+	// Characters lists characters known in advance, so that Decay and State
+	// include them before they are first changed.
+	Characters []string
+
+	// Decay, if not nil, is applied to every known character by ApplyDecay.
+	Decay DecayFunc
+
+	// OnChange, if not nil, is called after an affinity changes (e.g. to show
+	// "Ava will remember that").
+	OnChange func(character string, old, new float32)
+
+	mu    sync.Mutex
+	known map[string]struct{}
+}
+
+// Library returns the functions for use in dialogue:
+//
+//	affinity(name) -> number                 the current affinity
+//	raise_affinity(name, amount) -> number   increase, returning the new value
+//	lower_affinity(name, amount) -> number   decrease, returning the new value
+//	set_affinity(name, value) -> number      set, returning the new value
+func (s *System) Library() yarn.FuncMap {
+	return yarn.FuncMap{
+		"affinity":       s.Get,
+		"raise_affinity": s.Raise,
+		"lower_affinity": func(character string, amount float32) float32 { return s.Raise(character, -amount) },
+		"set_affinity":   s.Set,
+	}
+}
+
+// Get returns the affinity for a character.
+func (s *System) Get(character string) float32 {
+	v, ok := s.Vars.GetValue(VarName(character))
+	if !ok {
+		return s.Default
+	}
+	f, err := yarn.ConvertToFloat32(v)
+	if err != nil {
+		return s.Default
+	}
+	return f
+}
+
+// Set sets the affinity for a character, clamped to [Min, Max], and returns
+// the stored value.
+func (s *System) Set(character string, value float32) float32 {
+	return s.set(character, value, true)
+}
+
+func (s *System) set(character string, value float32, notify bool) float32 {
+	old := s.Get(character)
+	if s.Min != s.Max {
+		value = min(max(value, s.Min), s.Max)
+	}
+	s.Vars.SetValue(VarName(character), value)
+	s.mu.Lock()
+	if s.known == nil {
+		s.known = make(map[string]struct{})
+	}
+	s.known[character] = struct{}{}
+	s.mu.Unlock()
+	if notify && s.OnChange != nil && value != old {
+		s.OnChange(character, old, value)
+	}
+	return value
+}
+
+// Raise adds amount (which may be negative) to the affinity for a character,
+// and returns the new value.
+func (s *System) Raise(character string, amount float32) float32 {
+	return s.Set(character, s.Get(character)+amount)
+}
+
+// Known returns the known characters (those in Characters, or changed since
+// the System was created or restored), sorted.
+func (s *System) Known() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set := make(map[string]struct{}, len(s.known)+len(s.Characters))
+	for c := range s.known {
+		set[c] = struct{}{}
+	}
+	for _, c := range s.Characters {
+		set[c] = struct{}{}
+	}
+	names := make([]string, 0, len(set))
+	for c := range set {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ApplyDecay applies Decay to every known character, e.g. once per in-game
+// day, or with the time elapsed since the game was last played.
+func (s *System) ApplyDecay(elapsed time.Duration) {
+	if s.Decay == nil {
+		return
+	}
+	for _, c := range s.Known() {
+		s.Set(c, s.Decay(c, s.Get(c), elapsed))
+	}
+}
+
+// State returns the affinities of all known characters, for serialization
+// (e.g. to JSON) separately from the other variables.
+func (s *System) State() map[string]float32 {
+	m := make(map[string]float32)
+	for _, c := range s.Known() {
+		m[c] = s.Get(c)
+	}
+	return m
+}
+
+// SetState stores the affinities in state, e.g. when loading a saved game.
+// OnChange is not called.
+func (s *System) SetState(state map[string]float32) {
+	for c, v := range state {
+		s.set(c, v, false)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package affinity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestVarName(t *testing.T) {
+	tests := map[string]string{
+		"Ava":        "$affinity_Ava",
+		"Old Tom":    "$affinity_Old_Tom",
+		"Mary-Kate2": "$affinity_Mary_Kate2",
+	}
+	for in, want := range tests {
+		if got := VarName(in); got != want {
+			t.Errorf("VarName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestLibrary(t *testing.T) {
+	vars := yarn.NewMapVariableStorage()
+	type change struct {
+		Character string
+		Old, New  float32
+	}
+	var changes []change
+	s := &System{
+		Vars: vars,
+		Min:  -10,
+		Max:  10,
+		OnChange: func(c string, old, new float32) {
+			changes = append(changes, change{c, old, new})
+		},
+	}
+	vm := &yarn.VirtualMachine{
+		Program: &yarnpb.Program{},
+		Vars:    vars,
+		FuncMap: s.Library(),
+	}
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{`affinity("Ava")`, float32(0)},
+		{`raise_affinity("Ava", 3)`, float32(3)},
+		{`raise_affinity("Ava", 30)`, float32(10)},
+		{`lower_affinity("Ava", 4)`, float32(6)},
+		{`$affinity_Ava > 5`, true},
+		{`set_affinity("Bo", -50)`, float32(-10)},
+		{`affinity("Bo") + affinity("Ava")`, float32(-4)},
+	}
+	for _, test := range tests {
+		got, err := vm.Evaluate(yarn.MustCompileExpression(test.expr))
+		if err != nil {
+			t.Fatalf("Evaluate(%s) error = %v", test.expr, err)
+		}
+		if got != test.want {
+			t.Errorf("Evaluate(%s) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+
+	wantChanges := []change{
+		{"Ava", 0, 3},
+		{"Ava", 3, 10},
+		{"Ava", 10, 6},
+		{"Bo", 0, -10},
+	}
+	if diff := cmp.Diff(changes, wantChanges); diff != "" {
+		t.Errorf("changes diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestDecayAndState(t *testing.T) {
+	s := &System{
+		Vars:       yarn.NewMapVariableStorage(),
+		Characters: []string{"Cy"},
+		Default:    2,
+		Decay:      ExponentialDecay(0, time.Hour),
+	}
+	s.Set("Ava", 8)
+	s.Set("Bo", -4)
+	s.ApplyDecay(2 * time.Hour)
+
+	data, err := json.Marshal(s.State())
+	if err != nil {
+		t.Fatalf("json.Marshal(State()) = %v", err)
+	}
+	if got, want := string(data), `{"Ava":2,"Bo":-1,"Cy":0.5}`; got != want {
+		t.Errorf("json.Marshal(State()) = %s, want %s", got, want)
+	}
+
+	var state map[string]float32
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("json.Unmarshal = %v", err)
+	}
+	s2 := &System{Vars: yarn.NewMapVariableStorage()}
+	s2.SetState(state)
+	if diff := cmp.Diff(s2.State(), state); diff != "" {
+		t.Errorf("restored State() diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestLinearDecay(t *testing.T) {
+	d := LinearDecay(1, 2)
+	tests := []struct {
+		value   float32
+		elapsed time.Duration
+		want    float32
+	}{
+		{10, time.Hour, 8},
+		{10, 10 * time.Hour, 1},
+		{-3, time.Hour, -1},
+		{-3, 5 * time.Hour, 1},
+	}
+	for _, test := range tests {
+		if got := d("", test.value, test.elapsed); got != test.want {
+			t.Errorf("LinearDecay(1, 2)(%v, %v) = %v, want %v", test.value, test.elapsed, got, test.want)
+		}
+	}
+}
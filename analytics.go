@@ -0,0 +1,103 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "time"
+
+// Kinds of AnalyticsEvent.
+const (
+	// AnalyticsNode is recorded when the VM enters a node.
+	AnalyticsNode = "node"
+
+	// AnalyticsLine is recorded when a line is delivered.
+	AnalyticsLine = "line"
+
+	// AnalyticsChoice is recorded when an option is chosen (by the handler,
+	// or automatically in skip mode).
+	AnalyticsChoice = "choice"
+
+	// AnalyticsVariant is recorded when a VariantSelector selects the variant
+	// of a node to play.
+	AnalyticsVariant = "variant"
+)
+
+// AnalyticsEvent is an event recorded for analytics (see
+// VirtualMachine.Analytics). It is JSON-friendly.
+type AnalyticsEvent struct {
+	Kind string    `json:"kind"`
+	Time time.Time `json:"time"`
+
+	// Node is the node that was entered, or that delivered the line or
+	// options.
+	Node string `json:"node,omitempty"`
+
+	// LineID is the ID of the line (for AnalyticsLine).
+	LineID string `json:"line_id,omitempty"`
+
+	// Substitutions are the line's substitutions (for AnalyticsLine).
+	Substitutions []string `json:"substitutions,omitempty"`
+
+	// Options are the line IDs of the available options, and Choice is the
+	// line ID of the option chosen (for AnalyticsChoice).
+	Options []string `json:"options,omitempty"`
+	Choice  string   `json:"choice,omitempty"`
+
+	// Experiment and Variant are the experiment and the arm selected (for
+	// AnalyticsVariant). Node is the variant node that will play.
+	Experiment string `json:"experiment,omitempty"`
+	Variant    string `json:"variant,omitempty"`
+}
+
+// recordAnalytics passes an event to Analytics, if it is set.
+func (vm *VirtualMachine) recordAnalytics(ev AnalyticsEvent) {
+	if vm.Analytics == nil {
+		return
+	}
+	ev.Time = time.Now()
+	vm.Privacy.scrubAnalytics(&ev)
+	vm.Analytics(ev)
+}
+
+// recordLine records an AnalyticsLine event.
+func (vm *VirtualMachine) recordLine(line Line) {
+	if vm.Analytics == nil {
+		return
+	}
+	vm.recordAnalytics(AnalyticsEvent{
+		Kind:          AnalyticsLine,
+		Node:          vm.state.node.Name,
+		LineID:        line.ID,
+		Substitutions: line.Clone().Substitutions,
+	})
+}
+
+// recordChoice records an AnalyticsChoice event for the options and the
+// index chosen.
+func (vm *VirtualMachine) recordChoice(options []Option, index int) {
+	if vm.Analytics == nil {
+		return
+	}
+	ev := AnalyticsEvent{
+		Kind:   AnalyticsChoice,
+		Node:   vm.state.node.Name,
+		Choice: options[index].Line.ID,
+	}
+	for _, o := range options {
+		if o.IsAvailable {
+			ev.Options = append(ev.Options, o.Line.ID)
+		}
+	}
+	vm.recordAnalytics(ev)
+}
@@ -0,0 +1,90 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// ErrAssertionFailed is returned (wrapped) when an <<assert>> command fails
+// and AssertPolicy is AssertFail.
+const ErrAssertionFailed = virtualMachineError("assertion failed")
+
+// AssertPolicy controls what happens when an <<assert>> command fails.
+type AssertPolicy int
+
+const (
+	// AssertFail stops the VM with an error wrapping ErrAssertionFailed.
+	AssertFail AssertPolicy = iota
+
+	// AssertLog logs the failure to the VM's Logger at slog.LevelWarn, and
+	// continues.
+	AssertLog
+
+	// AssertIgnore skips assertions without evaluating them (e.g. for release
+	// builds).
+	AssertIgnore
+)
+
+func (p AssertPolicy) String() string {
+	switch p {
+	case AssertFail:
+		return "Fail"
+	case AssertLog:
+		return "Log"
+	case AssertIgnore:
+		return "Ignore"
+	}
+	return fmt.Sprintf("(invalid AssertPolicy %d)", p)
+}
+
+// execAssert handles <<assert expr "message">>. The assertion is the text of
+// the command after "assert": an expression (see CompileExpression),
+// optionally followed by a quoted message.
+func (vm *VirtualMachine) execAssert(args string) error {
+	if vm.Asserts == AssertIgnore {
+		return nil
+	}
+	expr, rest, err := compileExpressionPrefix(args)
+	if err != nil {
+		return fmt.Errorf("assert: %w", err)
+	}
+	msg := ""
+	if rest = strings.TrimSpace(rest); rest != "" {
+		p := &exprParser{src: rest}
+		p.next()
+		if p.kind != tokString || p.pos != len(rest) {
+			return fmt.Errorf("assert: %w: want quoted message after expression, got %q", ErrBadExpression, rest)
+		}
+		msg = p.tok
+	}
+	ok, err := vm.EvaluateBool(expr)
+	if err != nil {
+		return fmt.Errorf("assert: %w", err)
+	}
+	if ok {
+		return nil
+	}
+	if vm.Asserts == AssertLog {
+		vm.log(slog.LevelWarn, logMsgAssert, slog.String("expr", expr.Source), slog.String("message", msg))
+		return nil
+	}
+	if msg == "" {
+		return fmt.Errorf("%w: %s", ErrAssertionFailed, expr.Source)
+	}
+	return fmt.Errorf("%w: %s: %s", ErrAssertionFailed, expr.Source, msg)
+}
@@ -0,0 +1,97 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+func assertProgram(cmds ...string) *yarnpb.Program {
+	pb := NewProgramBuilder("Assert")
+	start := pb.Node("Start")
+	for _, cmd := range cmds {
+		start.Command(cmd, 0)
+	}
+	start.Stop()
+	return pb.Program()
+}
+
+func TestAssert(t *testing.T) {
+	vars := NewMapVariableStorage()
+	vars.SetValue("$gold", float32(3))
+
+	prog := assertProgram(
+		`assert $gold > 0`,
+		`assert $gold >= 10 "need at least 10 gold"`,
+		`after`,
+	)
+	tests := []struct {
+		policy   AssertPolicy
+		wantErr  error
+		wantCmds []string
+		wantLog  string
+	}{
+		{policy: AssertFail, wantErr: ErrAssertionFailed},
+		{policy: AssertLog, wantCmds: []string{"after"}, wantLog: "need at least 10 gold"},
+		{policy: AssertIgnore, wantCmds: []string{"after"}},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			var logs bytes.Buffer
+			h := &recordingHandler{}
+			vm := &VirtualMachine{
+				Program: prog,
+				Handler: h,
+				Vars:    vars,
+				Asserts: test.policy,
+				Logger:  slog.New(slog.NewTextHandler(&logs, nil)),
+			}
+			err := vm.Run("Start")
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("vm.Run(Start) = %v, want %v", err, test.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), `RUN_COMMAND "assert $gold >= 10 \"need at least 10 gold\""`) {
+				t.Errorf("vm.Run(Start) = %v, want location of failing assert", err)
+			}
+			if got := strings.Join(h.commands, ","); got != strings.Join(test.wantCmds, ",") {
+				t.Errorf("commands = %q, want %q", h.commands, test.wantCmds)
+			}
+			if !strings.Contains(logs.String(), test.wantLog) {
+				t.Errorf("log = %q, want it to contain %q", logs.String(), test.wantLog)
+			}
+		})
+	}
+
+	vm := &VirtualMachine{Program: assertProgram(`assert ($gold`), Handler: FakeDialogueHandler{}, Vars: vars}
+	if err := vm.Run("Start"); !errors.Is(err, ErrBadExpression) {
+		t.Errorf("vm.Run(Start) with bad assert = %v, want %v", err, ErrBadExpression)
+	}
+}
+
+type recordingHandler struct {
+	FakeDialogueHandler
+	commands []string
+}
+
+func (h *recordingHandler) Command(cmd string) error {
+	h.commands = append(h.commands, cmd)
+	return nil
+}
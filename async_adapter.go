@@ -17,13 +17,23 @@ package yarn
 import (
 	"errors"
 	"fmt"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // ErrAlreadyStopped is returned when the AsyncAdapter cannot
 // stop the virtual machine, because it is already stopped.
 const ErrAlreadyStopped = virtualMachineError("VM already stopped or stopping")
 
+// ErrNothingToRedeliver is returned by AsyncAdapter.Redeliver when the VM is
+// not paused after a Line or Options event.
+const ErrNothingToRedeliver = virtualMachineError("not paused on a line or options")
+
+// ErrStalled is wrapped by StallError, which is returned when an AsyncAdapter
+// with an AckDeadline is not continued in time.
+const ErrStalled = virtualMachineError("dialogue stalled waiting for acknowledgement")
+
 var _ DialogueHandler = &AsyncAdapter{}
 
 // VMState enumerates the different states that AsyncAdapter can be in.
@@ -73,14 +83,105 @@ func (e VMStateMismatchErr) Error() string {
 	return fmt.Sprintf("VM is %v, so cannot transition from %v to %v", e.Got, e.Want, e.Next)
 }
 
+// StallError describes an event that was delivered by an AsyncAdapter, but not
+// acknowledged (by calling Go, GoWithChoice, or Abort) within the AckDeadline.
+type StallError struct {
+	// Event is the name of the DialogueHandler method that was called, e.g.
+	// "Line" or "Options".
+	Event string
+
+	// Node is the node that was running when the event was delivered.
+	Node string
+
+	// Line, Options, and Command are the pending line, options, or command,
+	// for those events.
+	Line    *Line
+	Options []Option
+	Command string
+
+	// Waited is how long the adapter has waited so far.
+	Waited time.Duration
+}
+
+func (e *StallError) Error() string {
+	var what string
+	switch {
+	case e.Line != nil:
+		what = fmt.Sprintf(" %q", e.Line.ID)
+	case e.Options != nil:
+		ids := make([]string, len(e.Options))
+		for i, opt := range e.Options {
+			ids[i] = opt.Line.ID
+		}
+		what = fmt.Sprintf(" %q", ids)
+	case e.Command != "":
+		what = fmt.Sprintf(" %q", e.Command)
+	}
+	return fmt.Sprintf("%v: %s%s in node %q not acknowledged after %v", ErrStalled, e.Event, what, e.Node, e.Waited)
+}
+
+// Unwrap returns ErrStalled.
+func (e *StallError) Unwrap() error { return ErrStalled }
+
 // AsyncAdapter is a DialogueHandler that exposes an interface that is similar
 // to the mainline YarnSpinner VM dialogue handler. Instead of manually blocking
 // inside the DialogueHandler callbacks, AsyncAdapter does this for you, until
 // you call Go, GoWithChoice, or Abort (as appropriate).
+//
+// By default AsyncAdapter waits forever, which can make it hard to notice when
+// a game forgets to continue the dialogue. Setting AckDeadline enables a
+// stricter mode, where each event must be acknowledged within the deadline:
+//
+//	aa := yarn.NewAsyncAdapter(h)
+//	aa.AckDeadline = 5 * time.Second
+//	aa.OnStall = func(se *yarn.StallError) error {
+//		log.Print(se)
+//		return nil // keep waiting
+//	}
+//
+// Lines can be advanced automatically with AutoAdvance, and options can be
+// chosen automatically with OptionTimeout. All of these are timed with Clock,
+// so that they can be paused or scaled along with the rest of the game (see
+// GameClock).
+//
+// The exported fields should be set before the VM is run.
 type AsyncAdapter struct {
+	// Clock times AckDeadline, AutoAdvance, and OptionTimeout. If nil,
+	// SystemClock is used.
+	Clock Clock
+
+	// AutoAdvance, if not nil, is called for each line. If it returns a
+	// positive duration, the adapter continues by itself after that long
+	// (unless Go or Abort is called first).
+	AutoAdvance func(Line) time.Duration
+
+	// OptionTimeout, if positive, is how long to wait for GoWithChoice
+	// before choosing an option automatically, with TimeoutChoice.
+	OptionTimeout time.Duration
+
+	// TimeoutChoice, if not nil, returns the ID of the option to choose when
+	// OptionTimeout passes. If nil, the first available option is chosen.
+	TimeoutChoice func([]Option) int
+
+	// AckDeadline, if positive, is how long each event may go
+	// unacknowledged before the adapter reports a stall.
+	AckDeadline time.Duration
+
+	// OnStall, if not nil, is called each time AckDeadline passes without
+	// the pending event being acknowledged. If it returns nil, the adapter
+	// keeps waiting (for up to another AckDeadline); otherwise the VM stops
+	// with the returned error. If OnStall is nil, the VM stops with the
+	// StallError.
+	OnStall func(*StallError) error
+
 	state   atomic.Int32
 	handler AsyncDialogueHandler
 	msgCh   chan asyncMsg
+
+	mu      sync.Mutex
+	node    string   // current node, for StallError
+	line    *Line    // pending line, for Redeliver
+	options []Option // pending options, for Redeliver
 }
 
 // NewAsyncAdapter returns a new AsyncAdapter.
@@ -153,9 +254,122 @@ func (a *AsyncAdapter) Abort(err error) error {
 	return nil
 }
 
+// Redeliver calls the handler's Line or Options method again with the
+// pending line or options (including their substitutions), without
+// continuing the VM. This is useful after something that affects how they are
+// presented changes mid-conversation, such as the locale (see LocaleSet).
+// Redeliver should be called from the same goroutine as Go and GoWithChoice.
+// It returns ErrNothingToRedeliver if the VM is not paused after a Line or
+// Options event.
+func (a *AsyncAdapter) Redeliver() error {
+	a.mu.Lock()
+	line, options := a.line, a.options
+	a.mu.Unlock()
+	switch a.State() {
+	case VMStatePaused:
+		if line == nil {
+			return ErrNothingToRedeliver
+		}
+		a.handler.Line(*line)
+	case VMStatePausedOptions:
+		if options == nil {
+			return ErrNothingToRedeliver
+		}
+		a.handler.Options(options)
+	default:
+		return ErrNothingToRedeliver
+	}
+	return nil
+}
+
+// timeoutChoice returns the option to choose when OptionTimeout passes.
+func (a *AsyncAdapter) timeoutChoice(options []Option) int {
+	if a.TimeoutChoice != nil {
+		return a.TimeoutChoice(options)
+	}
+	for _, opt := range options {
+		if opt.IsAvailable {
+			return opt.ID
+		}
+	}
+	if len(options) > 0 {
+		return options[0].ID
+	}
+	return -1
+}
+
+// setPending records the pending line or options for Redeliver.
+func (a *AsyncAdapter) setPending(line *Line, options []Option) {
+	a.mu.Lock()
+	a.line, a.options = line, options
+	a.mu.Unlock()
+}
+
+// receive waits for a message. If auto is positive, the adapter continues by
+// itself with autoMsg after that long. If AckDeadline is set, it reports
+// stalls for the event while the adapter remains in the paused state.
+func (a *AsyncAdapter) receive(paused int32, stall StallError, auto time.Duration, autoMsg asyncMsg) (asyncMsg, error) {
+	if a.AckDeadline <= 0 && auto <= 0 {
+		return <-a.msgCh, nil
+	}
+	clock := clockOrSystem(a.Clock)
+
+	var autoC <-chan struct{}
+	if auto > 0 {
+		c, timer := after(clock, auto)
+		defer timer.Stop()
+		autoC = c
+	}
+
+	var stallC <-chan struct{}
+	var stallTimer Timer
+	if a.AckDeadline > 0 {
+		a.mu.Lock()
+		stall.Node = a.node
+		a.mu.Unlock()
+		stallC, stallTimer = after(clock, a.AckDeadline)
+		defer func() { stallTimer.Stop() }()
+	}
+
+	for {
+		select {
+		case msg := <-a.msgCh:
+			return msg, nil
+		case <-autoC:
+			if a.state.CompareAndSwap(paused, VMStateRunning) {
+				return autoMsg, nil
+			}
+			// Go, GoWithChoice, or Abort got in first, so their message is
+			// on its way.
+			return <-a.msgCh, nil
+		case <-stallC:
+		}
+		stall.Waited += a.AckDeadline
+		se := stall
+		var err error = &se
+		if a.OnStall != nil {
+			err = a.OnStall(&se)
+		}
+		if err == nil {
+			stallC, stallTimer = after(clock, a.AckDeadline)
+			continue
+		}
+		if !a.state.CompareAndSwap(paused, VMStateStopped) {
+			// As above.
+			return <-a.msgCh, nil
+		}
+		return nil, err
+	}
+}
+
 // waitForGo waits for Go or Abort to be called.
-func (a *AsyncAdapter) waitForGo() error {
-	switch msg := (<-a.msgCh).(type) {
+func (a *AsyncAdapter) waitForGo(stall StallError, auto time.Duration) error {
+	defer a.setPending(nil, nil)
+	msg, err := a.receive(VMStatePaused, stall, auto, goMsg{})
+	if err != nil {
+		return err
+	}
+	switch msg := msg.(type) {
 	case goMsg:
 		return nil
 	case choiceMsg:
@@ -169,8 +383,13 @@ func (a *AsyncAdapter) waitForGo() error {
 }
 
 // waitForChoice waits for GoWithChoice or Abort to be called.
-func (a *AsyncAdapter) waitForChoice() (int, error) {
-	switch msg := (<-a.msgCh).(type) {
+func (a *AsyncAdapter) waitForChoice(stall StallError, auto time.Duration, choice int) (int, error) {
+	defer a.setPending(nil, nil)
+	msg, err := a.receive(VMStatePausedOptions, stall, auto, choiceMsg{choice})
+	if err != nil {
+		return -1, err
+	}
+	switch msg := msg.(type) {
 	case goMsg:
 		// This is incredibly unlikely, but I check it anyway.
 		return -1, errors.New("AsyncAdapter.Go called, but last event was Options")
@@ -190,8 +409,11 @@ func (a *AsyncAdapter) NodeStart(nodeName string) error {
 	if err := a.stateTransition(VMStateRunning, VMStatePaused); err != nil {
 		return err
 	}
+	a.mu.Lock()
+	a.node = nodeName
+	a.mu.Unlock()
 	a.handler.NodeStart(nodeName)
-	return a.waitForGo()
+	return a.waitForGo(StallError{Event: "NodeStart"}, 0)
 }
 
 // PrepareForLines is called by the VM and blocks until Go or Abort is called.
@@ -200,7 +422,7 @@ func (a *AsyncAdapter) PrepareForLines(lineIDs []string) error {
 		return err
 	}
 	a.handler.PrepareForLines(lineIDs)
-	return a.waitForGo()
+	return a.waitForGo(StallError{Event: "PrepareForLines"}, 0)
 }
 
 // Line is called by the VM and blocks until Go or Abort is called.
@@ -208,8 +430,13 @@ func (a *AsyncAdapter) Line(line Line) error {
 	if err := a.stateTransition(VMStateRunning, VMStatePaused); err != nil {
 		return err
 	}
+	a.setPending(&line, nil)
+	var auto time.Duration
+	if a.AutoAdvance != nil {
+		auto = a.AutoAdvance(line)
+	}
 	a.handler.Line(line)
-	return a.waitForGo()
+	return a.waitForGo(StallError{Event: "Line", Line: &line}, auto)
 }
 
 // Options is called by the VM and blocks until GoWithChoice or Abort is called.
@@ -217,8 +444,13 @@ func (a *AsyncAdapter) Options(options []Option) (int, error) {
 	if err := a.stateTransition(VMStateRunning, VMStatePausedOptions); err != nil {
 		return -1, err
 	}
+	a.setPending(nil, options)
+	choice := -1
+	if a.OptionTimeout > 0 {
+		choice = a.timeoutChoice(options)
+	}
 	a.handler.Options(options)
-	return a.waitForChoice()
+	return a.waitForChoice(StallError{Event: "Options", Options: options}, a.OptionTimeout, choice)
 }
 
 // Command is called by the VM and blocks until Go or Abort is called.
@@ -227,7 +459,7 @@ func (a *AsyncAdapter) Command(command string) error {
 		return err
 	}
 	a.handler.Command(command)
-	return a.waitForGo()
+	return a.waitForGo(StallError{Event: "Command", Command: command}, 0)
 }
 
 // NodeComplete is called by the VM and blocks until Go or Abort is called.
@@ -236,8 +468,7 @@ func (a *AsyncAdapter) NodeComplete(nodeName string) error {
 		return err
 	}
 	a.handler.NodeComplete(nodeName)
-	return a.waitForGo()
-
+	return a.waitForGo(StallError{Event: "NodeComplete"}, 0)
 }
 
 // DialogueComplete is called by the VM and blocks until Go or Abort is called.
@@ -246,7 +477,7 @@ func (a *AsyncAdapter) DialogueComplete() error {
 		return err
 	}
 	a.handler.DialogueComplete()
-	return a.waitForGo()
+	return a.waitForGo(StallError{Event: "DialogueComplete"}, 0)
 }
 
 // --- AsyncAdapter messages --- \\
@@ -20,6 +20,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 )
@@ -436,3 +437,58 @@ func TestAsyncAdapterWithAbortHandler(t *testing.T) {
 		t.Errorf("aa.Abort(errDummy) = %v, want %v", err, ErrAlreadyStopped)
 	}
 }
+
+// forgetfulAsyncHandler never continues after a Line.
+type forgetfulAsyncHandler struct {
+	FakeAsyncDialogueHandler
+}
+
+func (forgetfulAsyncHandler) Line(Line) {}
+
+func TestAsyncAdapterAckDeadline(t *testing.T) {
+	pb := NewProgramBuilder("Stall")
+	pb.Node("Start").Line("line:a", 0).Line("line:b", 0).Stop()
+
+	h := &forgetfulAsyncHandler{}
+	aa := NewAsyncAdapter(h)
+	h.AsyncAdapter = aa
+	aa.AckDeadline = time.Millisecond
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: aa,
+		Vars:    NewMapVariableStorage(),
+	}
+
+	err := vm.Run("Start")
+	var se *StallError
+	if !errors.As(err, &se) {
+		t.Fatalf("vm.Run(Start) = %v, want StallError", err)
+	}
+	if !errors.Is(err, ErrStalled) {
+		t.Errorf("vm.Run(Start) = %v, want %v", err, ErrStalled)
+	}
+	if se.Event != "Line" || se.Node != "Start" || se.Line == nil || se.Line.ID != "line:a" {
+		t.Errorf("StallError = %+v, want Line line:a in Start", se)
+	}
+	if err := aa.Go(); err == nil {
+		t.Errorf("aa.Go() after stall = nil, want error")
+	}
+
+	// With OnStall, stalls are reported and the adapter keeps waiting.
+	h = &forgetfulAsyncHandler{}
+	aa = NewAsyncAdapter(h)
+	h.AsyncAdapter = aa
+	aa.AckDeadline = time.Millisecond
+	var stalled []string
+	aa.OnStall = func(se *StallError) error {
+		stalled = append(stalled, se.Line.ID)
+		return aa.Go()
+	}
+	vm.Handler = aa
+	if err := vm.Run("Start"); err != nil {
+		t.Errorf("vm.Run(Start) with OnStall = %v", err)
+	}
+	if diff := cmp.Diff(stalled, []string{"line:a", "line:b"}); diff != "" {
+		t.Errorf("stalled diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "sync"
+
+// ErrNoAutosave is returned by RestoreAutosave when there are no autosaves.
+const ErrNoAutosave = virtualMachineError("no autosave")
+
+// DefaultAutosaveSize is the number of autosaves kept if Autosave.Size is
+// not positive.
+const DefaultAutosaveSize = 8
+
+// Autosave is a policy for saving automatically at certain points in the
+// dialogue, so that "continue from where you were" works even if the game
+// never saves explicitly. Saves are kept in a ring buffer: once it is full,
+// each new save replaces the oldest. It is safe to read the saves (e.g. to
+// persist them) from another goroutine while the VM runs.
+//
+// Each save is a Bookmark named AutosaveName. If the VM's variable storage
+// can't be copied (see ErrVarsNotSnapshottable), saves don't include
+// variables, and restoring them leaves the variables unchanged.
+type Autosave struct {
+	// NodeStart saves at the start of each node.
+	NodeStart bool
+
+	// Options saves before options are delivered to the handler. Restoring
+	// the save delivers the same options again.
+	Options bool
+
+	// EveryLines, if positive, saves after every EveryLines lines. Restoring
+	// the save continues after the last of those lines.
+	EveryLines int
+
+	// Size is the number of saves to keep. If it is not positive,
+	// DefaultAutosaveSize is used.
+	Size int
+
+	// Clock, if not nil, timestamps the saves. If nil, SystemClock is used.
+	Clock Clock
+
+	// OnSave, if not nil, is called (on the VM's goroutine) with each new
+	// save, e.g. to write it to disk.
+	OnSave func(*Bookmark)
+
+	mu    sync.Mutex
+	saves []*Bookmark // ring buffer
+	next  int         // index in saves of the next save
+	lines int         // lines since the last EveryLines save
+}
+
+// AutosaveName is the name of the bookmarks made by Autosave.
+const AutosaveName = "autosave"
+
+// Saves returns the saves, oldest first.
+func (a *Autosave) Saves() []*Bookmark {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]*Bookmark, 0, len(a.saves))
+	out = append(out, a.saves[a.next:]...)
+	return append(out, a.saves[:a.next]...)
+}
+
+// Latest returns the most recent save, or nil if there are none.
+func (a *Autosave) Latest() *Bookmark {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.saves) == 0 {
+		return nil
+	}
+	return a.saves[(a.next+len(a.saves)-1)%len(a.saves)]
+}
+
+// SetSaves replaces the saves (e.g. with saves loaded from disk), which
+// should be oldest first. If there are more than Size, only the newest are
+// kept.
+func (a *Autosave) SetSaves(saves []*Bookmark) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n := a.size(); len(saves) > n {
+		saves = saves[len(saves)-n:]
+	}
+	a.saves = append([]*Bookmark(nil), saves...)
+	a.next = len(a.saves) % a.size()
+	a.lines = 0
+}
+
+// size returns the capacity of the ring buffer.
+func (a *Autosave) size() int {
+	if a.Size > 0 {
+		return a.Size
+	}
+	return DefaultAutosaveSize
+}
+
+// add adds a save to the ring buffer.
+func (a *Autosave) add(b *Bookmark) {
+	a.mu.Lock()
+	if n := a.size(); len(a.saves) < n {
+		a.saves = append(a.saves, b)
+	} else {
+		a.saves[a.next%n] = b
+	}
+	a.next = (a.next + 1) % a.size()
+	a.lines = 0
+	a.mu.Unlock()
+	if a.OnSave != nil {
+		a.OnSave(b)
+	}
+}
+
+// countLine counts a line, and reports whether it is time to save.
+func (a *Autosave) countLine() bool {
+	if a.EveryLines <= 0 {
+		return false
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.lines++
+	return a.lines >= a.EveryLines
+}
+
+// autosave saves the current state, variables, and history to vm.Autosave.
+func (vm *VirtualMachine) autosave() {
+	b := &Bookmark{
+		Name:     AutosaveName,
+		Created:  clockOrSystem(vm.Autosave.Clock).Now(),
+		Snapshot: vm.Snapshot(),
+		History:  vm.History(),
+	}
+	if cs, ok := vm.Vars.(contentsStorage); ok {
+		b.Vars = cs.Contents()
+	}
+	vm.Autosave.add(b)
+}
+
+// RestoreAutosave restores the execution state, variables, and history from
+// the most recent save in vm.Autosave. The VM must not be running. Call
+// Resume to continue execution from the save.
+func (vm *VirtualMachine) RestoreAutosave() error {
+	if vm.Autosave == nil {
+		return ErrNoAutosave
+	}
+	b := vm.Autosave.Latest()
+	if b == nil {
+		return ErrNoAutosave
+	}
+	if _, ok := vm.Vars.(contentsStorage); !ok {
+		if err := vm.Restore(b.Snapshot); err != nil {
+			return err
+		}
+		vm.SetHistory(b.History)
+		return nil
+	}
+	return vm.restoreBookmark(b)
+}
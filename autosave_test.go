@@ -0,0 +1,138 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func autosaveProgram() *yarnpb.Program {
+	pb := NewProgramBuilder("Autosave")
+	pb.Node("Start").
+		Line("line:1", 0).
+		Line("line:2", 0).
+		Line("line:3", 0).
+		Line("line:4", 0).
+		Option("line:a", "Next", 0, false).
+		Option("line:b", "Next", 0, false).
+		ShowOptions().
+		Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Next").
+		PushFloat(1).
+		StoreVariable("$next").
+		Pop().
+		Line("line:5", 0).
+		Stop()
+	return pb.Program()
+}
+
+func TestAutosave(t *testing.T) {
+	prog := autosaveProgram()
+	saved := 0
+	as := &Autosave{
+		NodeStart:  true,
+		Options:    true,
+		EveryLines: 2,
+		Size:       3,
+		OnSave:     func(*Bookmark) { saved++ },
+	}
+	vm := &VirtualMachine{
+		Program:  prog,
+		Handler:  &choosingHandler{&lineRecorder{}},
+		Vars:     NewMapVariableStorage(),
+		Autosave: as,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+
+	// Saves: Start (node start), after line:2, after line:4, Start
+	// (options), Next (node start). Only the last 3 are kept.
+	if got, want := saved, 5; got != want {
+		t.Errorf("saves made = %d, want %d", got, want)
+	}
+	type point struct {
+		Node    string
+		PC      int
+		Options int
+	}
+	var got []point
+	for _, b := range as.Saves() {
+		if b.Name != AutosaveName {
+			t.Errorf("save name = %q, want %q", b.Name, AutosaveName)
+		}
+		got = append(got, point{b.Snapshot.Node, b.Snapshot.PC, len(b.Snapshot.Options)})
+	}
+	want := []point{
+		{"Start", 4, 0},
+		{"Start", 6, 2},
+		{"Next", 0, 0},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("saves diff (-got +want):\n%s", diff)
+	}
+	if latest := as.Latest(); latest.Snapshot.Node != "Next" {
+		t.Errorf("Latest().Snapshot.Node = %q, want Next", latest.Snapshot.Node)
+	}
+
+	// Continue from the save before the options, in a fresh VM.
+	restored := &Autosave{}
+	restored.SetSaves(as.Saves()[:2])
+	rec := &lineRecorder{}
+	vm2 := &VirtualMachine{
+		Program:  prog,
+		Handler:  &choosingHandler{rec},
+		Vars:     NewMapVariableStorage(),
+		Autosave: restored,
+	}
+	if err := vm2.RestoreAutosave(); err != nil {
+		t.Fatalf("vm2.RestoreAutosave() = %v", err)
+	}
+	if err := vm2.Resume(); err != nil {
+		t.Fatalf("vm2.Resume() = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:5"}); diff != "" {
+		t.Errorf("resumed ids diff (-got +want):\n%s", diff)
+	}
+	if v, ok := vm2.Vars.GetValue("$next"); !ok || v != float32(1) {
+		t.Errorf("$next = %v, %t, want 1, true", v, ok)
+	}
+
+	// Variables are restored from the save.
+	if err := vm2.RestoreAutosave(); err != nil {
+		t.Fatalf("vm2.RestoreAutosave() = %v", err)
+	}
+	if _, ok := vm2.Vars.GetValue("$next"); ok {
+		t.Errorf("$next is set after RestoreAutosave, want unset")
+	}
+}
+
+func TestAutosaveEmpty(t *testing.T) {
+	vm := &VirtualMachine{Program: autosaveProgram(), Vars: NewMapVariableStorage()}
+	if err := vm.RestoreAutosave(); !errors.Is(err, ErrNoAutosave) {
+		t.Errorf("RestoreAutosave() with no Autosave = %v, want %v", err, ErrNoAutosave)
+	}
+	vm.Autosave = &Autosave{}
+	if err := vm.RestoreAutosave(); !errors.Is(err, ErrNoAutosave) {
+		t.Errorf("RestoreAutosave() with no saves = %v, want %v", err, ErrNoAutosave)
+	}
+	if got := vm.Autosave.Latest(); got != nil {
+		t.Errorf("Latest() = %v, want nil", got)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrNoBark is returned by Barks.Bark when no node in the group produced a
+// line.
+const ErrNoBark = virtualMachineError("no eligible bark")
+
+// BarkGroupHeader is the node header that places a node in a bark group (in
+// addition to nodes tagged with the group name).
+const BarkGroupHeader = "group"
+
+// Bark is a single line chosen by Barks.
+type Bark struct {
+	// Node is the node the line came from.
+	Node string
+
+	// Line is the line to show.
+	Line Line
+
+	// Commands contains any commands the node ran before the line (e.g.
+	// animations to play alongside it).
+	Commands []string
+}
+
+// Barks selects and plays ambient one-liners, without entering a full
+// conversation. A bark group is the set of nodes tagged with the group name,
+// or with a "group" header equal to it. Each node expresses its world-state
+// criteria with ordinary Yarn conditions, for example:
+//
+//	title: Guard_Hungry
+//	tags: guard_idle
+//	---
+//	<<if $hunger > 5 and $time_of_day == "night">>
+//	Guard: Can't wait for my shift to end. I'm starving.
+//	<<endif>>
+//
+// To choose a bark, every node in the group is run on a copy-on-write view of
+// the variables until it delivers its first line. Nodes that deliver a line
+// (before any options) are eligible. Among those, the most specific wins:
+// the node whose run read the most distinct variables. Ties are broken by
+// choosing the least recently played, then by node order. Only the chosen
+// node's variable changes are applied.
+type Barks struct {
+	Program *yarnpb.Program
+	Vars    VariableStorage
+	FuncMap FuncMap
+
+	mu     sync.Mutex
+	plays  int
+	played map[string]int // node -> value of plays when last played
+}
+
+// Group returns the names of the nodes in a bark group, sorted.
+func (b *Barks) Group(group string) []string {
+	var nodes []string
+	for _, name := range sortedNodeNames(b.Program) {
+		if inBarkGroup(b.Program.Nodes[name], group) {
+			nodes = append(nodes, name)
+		}
+	}
+	return nodes
+}
+
+func inBarkGroup(node *yarnpb.Node, group string) bool {
+	for _, t := range node.Tags {
+		if t == group {
+			return true
+		}
+	}
+	for _, h := range node.Headers {
+		if h.Key == BarkGroupHeader && h.Value == group {
+			return true
+		}
+	}
+	return false
+}
+
+// Bark chooses a bark from the group, applies its variable changes, and
+// returns it. It returns ErrNoBark if no node in the group is eligible.
+func (b *Barks) Bark(group string) (*Bark, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	type candidate struct {
+		bark        *Bark
+		vars        *overlayStorage
+		specificity int
+	}
+	var best *candidate
+	for _, name := range b.Group(group) {
+		bark, vars, err := b.try(name)
+		if err != nil {
+			return nil, fmt.Errorf("bark node %q: %w", name, err)
+		}
+		if bark == nil {
+			continue
+		}
+		c := &candidate{bark: bark, vars: vars, specificity: len(vars.reads)}
+		switch {
+		case best == nil,
+			c.specificity > best.specificity,
+			c.specificity == best.specificity && b.lastPlayed(name) < b.lastPlayed(best.bark.Node):
+			best = c
+		}
+	}
+	if best == nil {
+		return nil, ErrNoBark
+	}
+	best.vars.commit()
+	b.plays++
+	if b.played == nil {
+		b.played = make(map[string]int)
+	}
+	b.played[best.bark.Node] = b.plays
+	return best.bark, nil
+}
+
+// lastPlayed returns when the node was last played (0 if never).
+func (b *Barks) lastPlayed(node string) int {
+	return b.played[node]
+}
+
+// try runs the node until its first line, on an overlay of the variables.
+// It returns a nil bark if the node is not eligible.
+func (b *Barks) try(node string) (*Bark, *overlayStorage, error) {
+	vars := newOverlayStorage(b.Vars)
+	h := &barkHandler{bark: &Bark{Node: node}}
+	vm := &VirtualMachine{
+		Program: b.Program,
+		Handler: h,
+		Vars:    vars,
+		FuncMap: b.FuncMap,
+	}
+	if err := vm.Run(node); err != nil && !errors.Is(err, errBarkIneligible) {
+		return nil, nil, err
+	}
+	if !h.found {
+		return nil, vars, nil
+	}
+	return h.bark, vars, nil
+}
+
+// errBarkIneligible stops trial runs that reach options.
+const errBarkIneligible = virtualMachineError("bark node has options")
+
+// barkHandler captures the first line, and stops.
+type barkHandler struct {
+	FakeDialogueHandler
+	bark  *Bark
+	found bool
+}
+
+func (h *barkHandler) Line(line Line) error {
+	h.bark.Line = line.Clone()
+	h.found = true
+	return Stop
+}
+
+func (h *barkHandler) Options([]Option) (int, error) {
+	return 0, errBarkIneligible
+}
+
+func (h *barkHandler) Command(command string) error {
+	h.bark.Commands = append(h.bark.Commands, command)
+	return nil
+}
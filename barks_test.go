@@ -0,0 +1,96 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestBarks(t *testing.T) {
+	vars := NewMapVariableStorage()
+	vars.SetValue("$hunger", float32(0))
+	pb := NewProgramBuilder("Barks")
+	pb.Node("Guard_Generic").Tags("guard").
+		Line("line:generic", 0).
+		Stop()
+	pb.Node("Guard_Generic2").Tags("guard").
+		Line("line:generic2", 0).
+		Stop()
+	pb.Node("Guard_Hungry").Header("group", "guard").
+		PushVariable("$hunger").
+		PushFloat(5).
+		Call("Number.GreaterThan", 2).
+		JumpIfFalse("end").
+		Pop().
+		Command("rub_belly", 0).
+		PushBool(true).
+		StoreVariable("$said_hungry").
+		Pop().
+		Line("line:hungry", 0).
+		Stop().
+		Label("end").
+		Pop().
+		Stop()
+	pb.Node("Guard_Menu").Tags("guard").
+		Option("line:menu", "Guard_Menu", 0, false).
+		ShowOptions().
+		Stop()
+	b := &Barks{
+		Program: pb.Program(),
+		Vars:    vars,
+	}
+	if diff := cmp.Diff(b.Group("guard"), []string{"Guard_Generic", "Guard_Generic2", "Guard_Hungry", "Guard_Menu"}); diff != "" {
+		t.Errorf("b.Group(guard) diff (-got +want):\n%s", diff)
+	}
+
+	// Not hungry: alternate between the generic barks.
+	for _, want := range []string{"Guard_Generic", "Guard_Generic2", "Guard_Generic"} {
+		bark, err := b.Bark("guard")
+		if err != nil {
+			t.Fatalf("b.Bark(guard) = %v", err)
+		}
+		if bark.Node != want {
+			t.Errorf("b.Bark(guard).Node = %q, want %q", bark.Node, want)
+		}
+	}
+	if _, found := vars.GetValue("$said_hungry"); found {
+		t.Errorf("$said_hungry set by ineligible bark")
+	}
+
+	// Hungry: the more specific bark wins.
+	vars.SetValue("$hunger", float32(10))
+	bark, err := b.Bark("guard")
+	if err != nil {
+		t.Fatalf("b.Bark(guard) = %v", err)
+	}
+	want := &Bark{
+		Node:     "Guard_Hungry",
+		Line:     Line{ID: "line:hungry"},
+		Commands: []string{"rub_belly"},
+	}
+	if diff := cmp.Diff(bark, want); diff != "" {
+		t.Errorf("b.Bark(guard) diff (-got +want):\n%s", diff)
+	}
+	if said, _ := vars.GetValue("$said_hungry"); said != true {
+		t.Errorf("$said_hungry = %v, want true", said)
+	}
+
+	if _, err := b.Bark("nobody"); !errors.Is(err, ErrNoBark) {
+		t.Errorf("b.Bark(nobody) = %v, want ErrNoBark", err)
+	}
+}
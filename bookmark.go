@@ -0,0 +1,125 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrBookmarkNotFound is returned by RestoreBookmark when there is no
+// bookmark with the given name.
+const ErrBookmarkNotFound = virtualMachineError("bookmark not found")
+
+// ErrVarsNotSnapshottable is returned when creating or restoring a bookmark
+// if the VM's variable storage cannot be copied (it needs Contents and
+// ReplaceContents methods, like MapVariableStorage).
+const ErrVarsNotSnapshottable = virtualMachineError("variable storage does not support Contents and ReplaceContents")
+
+// contentsStorage is implemented by variable storages that can be copied
+// wholesale, such as MapVariableStorage.
+type contentsStorage interface {
+	Contents() map[string]any
+	ReplaceContents(map[string]any)
+}
+
+// Bookmark is a named checkpoint, bundling a snapshot of the execution state
+// with copies of the variables and history. Bookmarks are serializable, and
+// are intended for features like chapter select.
+type Bookmark struct {
+	Name     string         `json:"name"`
+	Created  time.Time      `json:"created"`
+	Snapshot *Snapshot      `json:"snapshot"`
+	Vars     map[string]any `json:"vars,omitempty"`
+	History  History        `json:"history"`
+}
+
+// Bookmark creates a named checkpoint of the current state, variables, and
+// history, replacing any existing bookmark with the same name. Like
+// Snapshot, it can be called from within handler methods (e.g. in response
+// to a <<bookmark before_boss_talk>> command) or while the VM is not running.
+func (vm *VirtualMachine) Bookmark(name string) error {
+	cs, ok := vm.Vars.(contentsStorage)
+	if !ok {
+		return ErrVarsNotSnapshottable
+	}
+	b := &Bookmark{
+		Name:     name,
+		Created:  time.Now(),
+		Snapshot: vm.Snapshot(),
+		Vars:     cs.Contents(),
+		History:  vm.History(),
+	}
+	vm.DeleteBookmark(name)
+	vm.bookmarks = append(vm.bookmarks, b)
+	return nil
+}
+
+// Bookmarks returns the bookmarks, in the order they were created. The
+// returned slice can be saved and later passed to SetBookmarks.
+func (vm *VirtualMachine) Bookmarks() []*Bookmark {
+	return append([]*Bookmark(nil), vm.bookmarks...)
+}
+
+// SetBookmarks replaces all bookmarks (e.g. with bookmarks loaded from a
+// saved game).
+func (vm *VirtualMachine) SetBookmarks(bs []*Bookmark) {
+	vm.bookmarks = append([]*Bookmark(nil), bs...)
+}
+
+// DeleteBookmark removes the bookmark with the given name, if it exists.
+func (vm *VirtualMachine) DeleteBookmark(name string) {
+	for i, b := range vm.bookmarks {
+		if b.Name == name {
+			vm.bookmarks = append(vm.bookmarks[:i:i], vm.bookmarks[i+1:]...)
+			return
+		}
+	}
+}
+
+// RestoreBookmark restores the execution state, variables, and history from
+// the named bookmark. The VM must not be running. Call Resume to continue
+// execution from the bookmark.
+func (vm *VirtualMachine) RestoreBookmark(name string) error {
+	var b *Bookmark
+	for _, c := range vm.bookmarks {
+		if c.Name == name {
+			b = c
+			break
+		}
+	}
+	if b == nil {
+		return fmt.Errorf("%w: %q", ErrBookmarkNotFound, name)
+	}
+	return vm.restoreBookmark(b)
+}
+
+// restoreBookmark implements RestoreBookmark and RestoreAutosave.
+func (vm *VirtualMachine) restoreBookmark(b *Bookmark) error {
+	cs, ok := vm.Vars.(contentsStorage)
+	if !ok {
+		return ErrVarsNotSnapshottable
+	}
+	if err := vm.Restore(b.Snapshot); err != nil {
+		return err
+	}
+	vars := make(map[string]any, len(b.Vars))
+	for k, v := range b.Vars {
+		vars[k] = normalizeValue(v)
+	}
+	cs.ReplaceContents(vars)
+	vm.SetHistory(b.History)
+	return nil
+}
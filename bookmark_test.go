@@ -0,0 +1,106 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// bookmarkingHandler records line IDs, always picks the first option, and
+// creates a bookmark at the given event.
+type bookmarkingHandler struct {
+	FakeDialogueHandler
+	vm         *VirtualMachine
+	events     []string
+	bookmarkAt int
+}
+
+func (h *bookmarkingHandler) event(e string) error {
+	h.events = append(h.events, e)
+	if len(h.events) == h.bookmarkAt {
+		return h.vm.Bookmark("here")
+	}
+	return nil
+}
+
+func (h *bookmarkingHandler) Line(line Line) error { return h.event(line.ID) }
+
+func (h *bookmarkingHandler) Options(opts []Option) (int, error) {
+	return 0, h.event("options:" + opts[0].Line.ID)
+}
+
+func TestBookmarks(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/ShortcutOptions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+
+	// Bookmark after a line, and during options.
+	for _, at := range []int{3, 4} {
+		vm := &VirtualMachine{
+			Program: prog,
+			Vars:    NewMapVariableStorage(),
+		}
+		h := &bookmarkingHandler{vm: vm, bookmarkAt: at}
+		vm.Handler = h
+		if err := vm.Run("Start"); err != nil {
+			t.Fatalf("vm.Run(Start) = %v", err)
+		}
+		full := h.events
+
+		saved, err := json.Marshal(vm.Bookmarks())
+		if err != nil {
+			t.Fatalf("json.Marshal(bookmarks) = %v", err)
+		}
+		var bs []*Bookmark
+		if err := json.Unmarshal(saved, &bs); err != nil {
+			t.Fatalf("json.Unmarshal(bookmarks) = %v", err)
+		}
+		if len(bs) != 1 || bs[0].Name != "here" {
+			t.Fatalf("bookmarks = %v, want one named here", bs)
+		}
+
+		vm2 := &VirtualMachine{
+			Program: prog,
+			Vars:    NewMapVariableStorage(),
+		}
+		h2 := &bookmarkingHandler{vm: vm2}
+		vm2.Handler = h2
+		vm2.SetBookmarks(bs)
+		if err := vm2.RestoreBookmark("here"); err != nil {
+			t.Fatalf("vm2.RestoreBookmark(here) = %v", err)
+		}
+		if err := vm2.Resume(); err != nil {
+			t.Fatalf("vm2.Resume() = %v", err)
+		}
+		// Resuming after a line continues with the next event; resuming
+		// during options delivers the options again.
+		want := full[at:]
+		if full[at-1][:8] == "options:" {
+			want = full[at-1:]
+		}
+		if diff := cmp.Diff(h2.events, want); diff != "" {
+			t.Errorf("bookmark at %d: resumed events diff (-got +want):\n%s", at, diff)
+		}
+	}
+
+	vm := &VirtualMachine{Program: prog, Vars: NewMapVariableStorage()}
+	if err := vm.RestoreBookmark("nope"); err == nil {
+		t.Errorf("vm.RestoreBookmark(nope) = nil error, want error")
+	}
+}
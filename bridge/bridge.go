@@ -0,0 +1,272 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bridge serves the yarn VM over a simple JSON Lines protocol, so that
+// dialogue can be driven by a process written in another language (for
+// example, a Godot project during prototyping - see godot/yarn_bridge.gd).
+//
+// Each message is a single JSON object on its own line. The client sends
+// requests:
+//
+//	{"type":"run","node":"Start"}          start running from a node
+//	{"type":"continue"}                    continue after a line or command
+//	{"type":"choose","option":1}           choose an option (by ID)
+//	{"type":"stop"}                        stop the running dialogue
+//	{"type":"get","name":"$gold"}          get a variable
+//	{"type":"set","name":"$gold","value":5} set a variable
+//
+// and the server sends events:
+//
+//	{"type":"node_start","node":"Start"}
+//	{"type":"line","id":"line:1","text":"Hello","tags":["happy"]}
+//	{"type":"options","options":[{"id":0,"text":"Hi","available":true}]}
+//	{"type":"command","command":"wave left"}
+//	{"type":"node_complete","node":"Start"}
+//	{"type":"dialogue_complete"}
+//	{"type":"value","name":"$gold","value":5}
+//	{"type":"stopped"}                     the VM has finished running
+//	{"type":"error","message":"..."}
+//
+// After each line or command, the server waits for continue (or stop). After
+// options, it waits for choose (or stop). All other events are informational.
+package bridge // import "github.com/DrJosh9000/yarn/bridge"
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// errStopped is used to abort the VM when the client requests stop.
+var errStopped = errors.New("stopped by client")
+
+// Request is a message from the client.
+type Request struct {
+	Type   string `json:"type"`
+	Node   string `json:"node,omitempty"`
+	Option int    `json:"option,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Value  any    `json:"value,omitempty"`
+}
+
+// Event is a message to the client.
+type Event struct {
+	Type    string   `json:"type"`
+	Node    string   `json:"node,omitempty"`
+	ID      string   `json:"id,omitempty"`
+	Text    string   `json:"text,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	Options []Option `json:"options,omitempty"`
+	Command string   `json:"command,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Value   any      `json:"value,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// Option is an option within an options event.
+type Option struct {
+	ID        int      `json:"id"`
+	LineID    string   `json:"line_id"`
+	Text      string   `json:"text"`
+	Tags      []string `json:"tags,omitempty"`
+	Available bool     `json:"available"`
+}
+
+// Server serves a program over the protocol.
+type Server struct {
+	Program     *yarnpb.Program
+	StringTable *yarn.StringTable
+	Vars        yarn.VariableStorage
+	FuncMap     yarn.FuncMap
+
+	mu      sync.Mutex // guards enc
+	enc     *json.Encoder
+	adapter *yarn.AsyncAdapter
+	done    chan struct{} // closed when the current run finishes
+}
+
+// Serve reads requests from r and writes events to w until r is exhausted or
+// fails. Only one connection can be served at a time. Any running dialogue is
+// stopped before Serve returns.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	if s.Vars == nil {
+		s.Vars = yarn.NewMapVariableStorage()
+	}
+	s.enc = json.NewEncoder(w)
+	defer s.stop()
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var req Request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			s.send(Event{Type: "error", Message: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+		if err := s.handle(req); err != nil {
+			s.send(Event{Type: "error", Message: err.Error()})
+		}
+	}
+	return sc.Err()
+}
+
+func (s *Server) handle(req Request) error {
+	switch req.Type {
+	case "run":
+		return s.run(req.Node)
+	case "continue":
+		if s.adapter == nil {
+			return errors.New("not running")
+		}
+		return s.adapter.Go()
+	case "choose":
+		if s.adapter == nil {
+			return errors.New("not running")
+		}
+		return s.adapter.GoWithChoice(req.Option)
+	case "stop":
+		if s.adapter == nil {
+			return errors.New("not running")
+		}
+		s.stop()
+		return nil
+	case "get":
+		v, _ := s.Vars.GetValue(req.Name)
+		s.send(Event{Type: "value", Name: req.Name, Value: v})
+		return nil
+	case "set":
+		if f, ok := req.Value.(float64); ok {
+			// Yarn Spinner numbers are float32.
+			req.Value = float32(f)
+		}
+		s.Vars.SetValue(req.Name, req.Value)
+		return nil
+	}
+	return fmt.Errorf("unknown request type %q", req.Type)
+}
+
+// run starts the VM in a new goroutine.
+func (s *Server) run(node string) error {
+	if s.done != nil {
+		select {
+		case <-s.done:
+		default:
+			return errors.New("already running")
+		}
+	}
+	h := &handler{s: s}
+	s.adapter = yarn.NewAsyncAdapter(h)
+	h.adapter = s.adapter
+	s.done = make(chan struct{})
+	vm := &yarn.VirtualMachine{
+		Program: s.Program,
+		Handler: s.adapter,
+		Vars:    s.Vars,
+		FuncMap: yarn.CombineLibraries(s.FuncMap),
+	}
+	go func(done chan struct{}) {
+		defer close(done)
+		if err := vm.Run(node); err != nil && !errors.Is(err, errStopped) {
+			s.send(Event{Type: "error", Message: err.Error()})
+		}
+		s.send(Event{Type: "stopped"})
+	}(s.done)
+	return nil
+}
+
+// stop aborts the running VM (if any) and waits for it to finish.
+func (s *Server) stop() {
+	if s.adapter == nil || s.done == nil {
+		return
+	}
+	s.adapter.Abort(errStopped)
+	<-s.done
+}
+
+// send writes an event.
+func (s *Server) send(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enc.Encode(e)
+}
+
+// handler converts VM events into protocol events.
+type handler struct {
+	s       *Server
+	adapter *yarn.AsyncAdapter
+}
+
+func (h *handler) render(line yarn.Line) (string, []string) {
+	if h.s.StringTable == nil {
+		return "", nil
+	}
+	row := h.s.StringTable.Table[line.ID]
+	if row == nil {
+		return "", nil
+	}
+	text, err := h.s.StringTable.Render(line)
+	if err != nil {
+		return "", row.Tags
+	}
+	return text.String(), row.Tags
+}
+
+func (h *handler) NodeStart(nodeName string) {
+	h.s.send(Event{Type: "node_start", Node: nodeName})
+	h.adapter.Go()
+}
+
+func (h *handler) PrepareForLines([]string) { h.adapter.Go() }
+
+func (h *handler) Line(line yarn.Line) {
+	text, tags := h.render(line)
+	h.s.send(Event{Type: "line", ID: line.ID, Text: text, Tags: tags})
+}
+
+func (h *handler) Options(options []yarn.Option) {
+	opts := make([]Option, len(options))
+	for i, o := range options {
+		text, tags := h.render(o.Line)
+		opts[i] = Option{
+			ID:        o.ID,
+			LineID:    o.Line.ID,
+			Text:      text,
+			Tags:      tags,
+			Available: o.IsAvailable,
+		}
+	}
+	h.s.send(Event{Type: "options", Options: opts})
+}
+
+func (h *handler) Command(command string) {
+	h.s.send(Event{Type: "command", Command: command})
+}
+
+func (h *handler) NodeComplete(nodeName string) {
+	h.s.send(Event{Type: "node_complete", Node: nodeName})
+	h.adapter.Go()
+}
+
+func (h *handler) DialogueComplete() {
+	h.s.send(Event{Type: "dialogue_complete"})
+	h.adapter.Go()
+}
@@ -0,0 +1,95 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+func TestServe(t *testing.T) {
+	prog, st, err := yarn.LoadFiles("../testdata/ShortcutOptions.yarnc", "en")
+	if err != nil {
+		t.Fatalf("LoadFiles = %v", err)
+	}
+	s := &Server{Program: prog, StringTable: st}
+
+	reqR, reqW := io.Pipe()
+	evR, evW := io.Pipe()
+	served := make(chan error)
+	go func() {
+		served <- s.Serve(reqR, evW)
+		evW.Close()
+	}()
+
+	dec := json.NewDecoder(evR)
+	send := func(req string) { fmt.Fprintln(reqW, req) }
+	// next skips to the next event of the given type.
+	next := func(typ string) Event {
+		t.Helper()
+		for {
+			var e Event
+			if err := dec.Decode(&e); err != nil {
+				t.Fatalf("Decode = %v, waiting for %q", err, typ)
+			}
+			if e.Type == "error" && typ != "error" {
+				t.Fatalf("got error event %q, waiting for %q", e.Message, typ)
+			}
+			if e.Type == typ {
+				return e
+			}
+		}
+	}
+
+	send(`{"type":"set","name":"$x","value":3}`)
+	send(`{"type":"get","name":"$x"}`)
+	if got := next("value"); got.Value != 3.0 {
+		t.Errorf("value event Value = %v, want 3", got.Value)
+	}
+
+	send(`{"type":"run","node":"Start"}`)
+	if got := next("node_start"); got.Node != "Start" {
+		t.Errorf("node_start event Node = %q, want Start", got.Node)
+	}
+	opts := next("options").Options
+	if len(opts) != 3 {
+		t.Fatalf("len(options) = %d, want 3", len(opts))
+	}
+	if opts[0].Text != "Option 1" || !opts[0].Available || opts[1].Available {
+		t.Errorf("options = %+v, want Option 1 available and Option 2 unavailable", opts)
+	}
+
+	send(`{"type":"run","node":"Start"}`)
+	if got := next("error"); got.Message != "already running" {
+		t.Errorf("error event Message = %q, want already running", got.Message)
+	}
+
+	send(fmt.Sprintf(`{"type":"choose","option":%d}`, opts[0].ID))
+	if got := next("line"); got.Text != "This line should appear." {
+		t.Errorf("line event Text = %q, want %q", got.Text, "This line should appear.")
+	}
+
+	send(`{"type":"stop"}`)
+	next("stopped")
+
+	reqW.Close()
+	if err := <-served; err != nil {
+		t.Errorf("Serve = %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+# Copyright 2026 Josh Deprez
+#
+# Licensed under the Apache License, Version 2.0 (the "License");
+# you may not use this file except in compliance with the License.
+# You may obtain a copy of the License at
+#
+#     http://www.apache.org/licenses/LICENSE-2.0
+#
+# Unless required by applicable law or agreed to in writing, software
+# distributed under the License is distributed on an "AS IS" BASIS,
+# WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+# See the License for the specific language governing permissions and
+# limitations under the License.
+
+# Reference Godot 4 client for the yarn bridge protocol. Start the server with
+#
+#     go run -tags example cmd/yarnserve/yarnserve.go \
+#         --program=path/to/Dialogue.yarn.yarnc --listen=localhost:7777
+#
+# then add this script as a node (or autoload), connect to its signals, and
+# call run("Start"). After each line or command, call advance(); after options,
+# call choose(id).
+extends Node
+
+signal node_started(node_name: String)
+signal line(id: String, text: String, tags: Array)
+signal options(options: Array) # of Dictionary: id, line_id, text, tags, available
+signal command(command: String)
+signal node_completed(node_name: String)
+signal dialogue_completed
+signal variable(name: String, value: Variant)
+signal stopped
+signal error(message: String)
+
+@export var host := "127.0.0.1"
+@export var port := 7777
+
+var _peer := StreamPeerTCP.new()
+var _buffer := ""
+
+
+func _ready() -> void:
+	var err := _peer.connect_to_host(host, port)
+	if err != OK:
+		push_error("yarn bridge: connect_to_host failed: %s" % error_string(err))
+
+
+func _process(_delta: float) -> void:
+	_peer.poll()
+	if _peer.get_status() != StreamPeerTCP.STATUS_CONNECTED:
+		return
+	var n := _peer.get_available_bytes()
+	if n <= 0:
+		return
+	_buffer += _peer.get_utf8_string(n)
+	var nl := _buffer.find("\n")
+	while nl >= 0:
+		var msg = JSON.parse_string(_buffer.substr(0, nl))
+		_buffer = _buffer.substr(nl + 1)
+		if msg is Dictionary:
+			_dispatch(msg)
+		nl = _buffer.find("\n")
+
+
+func run(node_name: String) -> void:
+	_send({"type": "run", "node": node_name})
+
+
+func advance() -> void:
+	_send({"type": "continue"})
+
+
+func choose(option_id: int) -> void:
+	_send({"type": "choose", "option": option_id})
+
+
+func stop() -> void:
+	_send({"type": "stop"})
+
+
+func get_variable(name: String) -> void:
+	_send({"type": "get", "name": name})
+
+
+func set_variable(name: String, value: Variant) -> void:
+	_send({"type": "set", "name": name, "value": value})
+
+
+func _send(msg: Dictionary) -> void:
+	_peer.put_data((JSON.stringify(msg) + "\n").to_utf8_buffer())
+
+
+func _dispatch(msg: Dictionary) -> void:
+	match msg.get("type", ""):
+		"node_start":
+			node_started.emit(msg.get("node", ""))
+		"line":
+			line.emit(msg.get("id", ""), msg.get("text", ""), msg.get("tags", []))
+		"options":
+			options.emit(msg.get("options", []))
+		"command":
+			command.emit(msg.get("command", ""))
+		"node_complete":
+			node_completed.emit(msg.get("node", ""))
+		"dialogue_complete":
+			dialogue_completed.emit()
+		"value":
+			variable.emit(msg.get("name", ""), msg.get("value"))
+		"stopped":
+			stopped.emit()
+		"error":
+			error.emit(msg.get("message", ""))
@@ -0,0 +1,152 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// DefaultSubstitutionLength is the number of characters assumed for each
+// substitution when checking lengths, if LengthBudget.SubstitutionLength is
+// zero.
+const DefaultSubstitutionLength = 8
+
+// LengthBudget limits the length of lines, menus, and nodes, e.g. so that
+// lines fit in a text box or can be read aloud in time. Zero limits are not
+// checked.
+type LengthBudget struct {
+	// MaxChars and MaxWords limit each line and option, after rendering, not
+	// counting markup or the speaker's name.
+	MaxChars int `json:"max_chars,omitempty"`
+	MaxWords int `json:"max_words,omitempty"`
+
+	// MaxOptions limits the number of options shown at once.
+	MaxOptions int `json:"max_options,omitempty"`
+
+	// MaxNodeLines and MaxNodeWords limit the total lines, and words in
+	// those lines, in each node (not counting options).
+	MaxNodeLines int `json:"max_node_lines,omitempty"`
+	MaxNodeWords int `json:"max_node_words,omitempty"`
+
+	// SubstitutionLength is the number of characters assumed for each
+	// substitution, since their values aren't known until the dialogue runs.
+	// If zero, DefaultSubstitutionLength is used.
+	SubstitutionLength int `json:"substitution_length,omitempty"`
+
+	// Severity is the severity of the diagnostics reported. The zero value
+	// is SeverityError, so that exceeding the budget fails CI.
+	Severity Severity `json:"severity"`
+}
+
+// CheckLengths checks the lines, options, and nodes of a program against the
+// budget. Menus are counted as every option added before each SHOW_OPTIONS,
+// so conditional options count even when they wouldn't be available. Lines
+// that are missing from the string table or can't be rendered are skipped
+// (CheckStringTable reports those).
+func CheckLengths(prog *yarnpb.Program, st *StringTable, budget *LengthBudget) []Diagnostic {
+	substLen := budget.SubstitutionLength
+	if substLen == 0 {
+		substLen = DefaultSubstitutionLength
+	}
+	// Substitutions are rendered as digits so that they work with plural
+	// and ordinal format functions.
+	placeholder := strings.Repeat("0", substLen)
+
+	var diags []Diagnostic
+	report := func(node string, pc int, id, format string, args ...any) {
+		diags = append(diags, Diagnostic{
+			Severity: budget.Severity,
+			Node:     node,
+			PC:       pc,
+			LineID:   id,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	for _, name := range sortedNodeNames(prog) {
+		node := prog.Nodes[name]
+		if node == nil {
+			continue
+		}
+		lines, words, options := 0, 0, 0
+		for pc, inst := range node.Instructions {
+			if inst.GetOpcode() == yarnpb.Instruction_SHOW_OPTIONS {
+				if budget.MaxOptions > 0 && options > budget.MaxOptions {
+					report(name, pc, "", "%d options, budget is %d", options, budget.MaxOptions)
+				}
+				options = 0
+				continue
+			}
+			id := lineIDOf(inst)
+			if id == "" {
+				continue
+			}
+			isLine := inst.GetOpcode() == yarnpb.Instruction_RUN_LINE
+			if isLine {
+				lines++
+			} else {
+				options++
+			}
+
+			row := st.row(id)
+			if row == nil {
+				continue
+			}
+			substs := make([]string, substitutionCount(inst))
+			for i := range substs {
+				substs[i] = placeholder
+			}
+			as, err := row.render(substs, st.Language, st.FormSelectors)
+			if err != nil {
+				continue
+			}
+			_, text := findSpeaker(as)
+			n := len(strings.Fields(text))
+			if isLine {
+				words += n
+			}
+			if c := utf8.RuneCountInString(text); budget.MaxChars > 0 && c > budget.MaxChars {
+				report(name, pc, id, "%d characters, budget is %d", c, budget.MaxChars)
+			}
+			if budget.MaxWords > 0 && n > budget.MaxWords {
+				report(name, pc, id, "%d words, budget is %d", n, budget.MaxWords)
+			}
+		}
+		if budget.MaxNodeLines > 0 && lines > budget.MaxNodeLines {
+			report(name, -1, "", "%d lines, node budget is %d", lines, budget.MaxNodeLines)
+		}
+		if budget.MaxNodeWords > 0 && words > budget.MaxNodeWords {
+			report(name, -1, "", "%d words, node budget is %d", words, budget.MaxNodeWords)
+		}
+	}
+	return diags
+}
+
+// substitutionCount returns the number of substitutions used by a RUN_LINE
+// or ADD_OPTION instruction.
+func substitutionCount(inst *yarnpb.Instruction) int {
+	i := 1 // RUN_LINE id substs
+	if inst.GetOpcode() == yarnpb.Instruction_ADD_OPTION {
+		i = 2 // ADD_OPTION id dest substs hasCond
+	}
+	if len(inst.Operands) <= i {
+		return 0
+	}
+	return int(inst.Operands[i].GetFloatValue())
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCheckLengths(t *testing.T) {
+	pb := NewProgramBuilder("Budget")
+	pb.Node("Start").
+		Line("line:short", 0).
+		PushString("Ava").
+		Line("line:subst", 1).
+		Line("line:long", 0).
+		Option("line:yes", "end", 0, false).
+		Option("line:no", "end", 0, false).
+		Option("line:maybe", "end", 0, false).
+		ShowOptions().
+		Option("line:yes", "end", 0, false).
+		ShowOptions()
+	pb.Node("Quiet").Line("line:short", 0)
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:short": {ID: "line:short", Text: "Bo: [b]Hi[/b] there."},
+		"line:subst": {ID: "line:subst", Text: "Hello {0}, I have {0} [plural value={0} one=\"% apple\" other=\"% apples\"/]."},
+		"line:long":  {ID: "line:long", Text: "Bo: This line is rather too long to read aloud comfortably."},
+		"line:yes":   {ID: "line:yes", Text: "Yes"},
+		"line:no":    {ID: "line:no", Text: "No"},
+		"line:maybe": {ID: "line:maybe", Text: "Maybe"},
+	}}
+
+	budget := &LengthBudget{
+		MaxChars:           30,
+		MaxWords:           8,
+		MaxOptions:         2,
+		MaxNodeLines:       2,
+		MaxNodeWords:       12,
+		SubstitutionLength: 4,
+		Severity:           SeverityWarning,
+	}
+	got := CheckLengths(pb.Program(), st, budget)
+	want := []Diagnostic{
+		{Severity: SeverityWarning, Node: "Start", PC: 2, LineID: "line:subst", Message: "36 characters, budget is 30"},
+		{Severity: SeverityWarning, Node: "Start", PC: 3, LineID: "line:long", Message: "55 characters, budget is 30"},
+		{Severity: SeverityWarning, Node: "Start", PC: 3, LineID: "line:long", Message: "10 words, budget is 8"},
+		{Severity: SeverityWarning, Node: "Start", PC: 7, Message: "3 options, budget is 2"},
+		{Severity: SeverityWarning, Node: "Start", PC: -1, Message: "3 lines, node budget is 2"},
+		{Severity: SeverityWarning, Node: "Start", PC: -1, Message: "19 words, node budget is 12"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("CheckLengths diff (-got +want):\n%s", diff)
+	}
+
+	if got := CheckLengths(pb.Program(), st, &LengthBudget{}); len(got) != 0 {
+		t.Errorf("CheckLengths with no limits = %v, want none", got)
+	}
+}
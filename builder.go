@@ -0,0 +1,255 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ProgramBuilder builds a program at runtime, e.g. for tests or procedurally
+// generated content. See also NodeBuilder.
+type ProgramBuilder struct {
+	prog  *yarnpb.Program
+	nodes []*NodeBuilder
+}
+
+// NewProgramBuilder starts building a program.
+func NewProgramBuilder(name string) *ProgramBuilder {
+	return &ProgramBuilder{prog: &yarnpb.Program{
+		Name:          name,
+		Nodes:         make(map[string]*yarnpb.Node),
+		InitialValues: make(map[string]*yarnpb.Operand),
+	}}
+}
+
+// Node adds a node to the program, and returns a builder for it.
+func (p *ProgramBuilder) Node(name string) *NodeBuilder {
+	b := NewNodeBuilder(name)
+	p.prog.Nodes[name] = b.node
+	p.nodes = append(p.nodes, b)
+	return b
+}
+
+// InitialValue declares a variable with an initial value, which must be a
+// bool, float32, or string.
+func (p *ProgramBuilder) InitialValue(name string, value any) *ProgramBuilder {
+	switch x := value.(type) {
+	case bool:
+		p.prog.InitialValues[name] = boolOperand(x)
+	case float32:
+		p.prog.InitialValues[name] = floatOperand(x)
+	case string:
+		p.prog.InitialValues[name] = stringOperand(x)
+	default:
+		panic(fmt.Sprintf("InitialValue(%q): unsupported type %T", name, value))
+	}
+	return p
+}
+
+// Program returns the program.
+func (p *ProgramBuilder) Program() *yarnpb.Program { return p.prog }
+
+// Texts returns the line texts recorded with NodeBuilder.Text in all the
+// nodes, by line ID.
+func (p *ProgramBuilder) Texts() map[string]string {
+	texts := make(map[string]string)
+	for _, b := range p.nodes {
+		for id, text := range b.texts {
+			texts[id] = text
+		}
+	}
+	return texts
+}
+
+// NodeBuilder builds a node one instruction at a time. The methods follow the
+// instructions emitted by the Yarn Spinner compiler, e.g.:
+//
+//	// Ava: Hello, {$name}!
+//	// <<if $gold >= 10>>
+//	//     -> Buy the sword
+//	// <<endif>>
+//	// -> Leave
+//	node := yarn.NewNodeBuilder("Start").
+//		PushVariable("$name").Line("line:hello", 1).
+//		PushVariable("$gold").PushFloat(10).Call("Number.GreaterThanOrEqualTo", 2).
+//		Option("line:buy", "buy", 0, true).
+//		Option("line:leave", "end", 0, false).
+//		ShowOptions().Jump().
+//		Label("buy").Command("give_item sword", 0).
+//		Label("end").Stop().
+//		Build()
+type NodeBuilder struct {
+	node  *yarnpb.Node
+	texts map[string]string
+}
+
+// NewNodeBuilder starts building a node.
+func NewNodeBuilder(name string) *NodeBuilder {
+	return &NodeBuilder{node: &yarnpb.Node{
+		Name:   name,
+		Labels: make(map[string]int32),
+	}}
+}
+
+// Build returns the node.
+func (b *NodeBuilder) Build() *yarnpb.Node { return b.node }
+
+// Tags adds tags to the node.
+func (b *NodeBuilder) Tags(tags ...string) *NodeBuilder {
+	b.node.Tags = append(b.node.Tags, tags...)
+	return b
+}
+
+// Header adds a header to the node.
+func (b *NodeBuilder) Header(key, value string) *NodeBuilder {
+	b.node.Headers = append(b.node.Headers, &yarnpb.Header{Key: key, Value: value})
+	return b
+}
+
+// Label labels the next instruction.
+func (b *NodeBuilder) Label(name string) *NodeBuilder {
+	b.node.Labels[name] = int32(len(b.node.Instructions))
+	return b
+}
+
+// Inst adds an arbitrary instruction.
+func (b *NodeBuilder) Inst(op yarnpb.Instruction_OpCode, operands ...*yarnpb.Operand) *NodeBuilder {
+	b.node.Instructions = append(b.node.Instructions, &yarnpb.Instruction{Opcode: op, Operands: operands})
+	return b
+}
+
+// PushString pushes a string.
+func (b *NodeBuilder) PushString(s string) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_PUSH_STRING, stringOperand(s))
+}
+
+// PushFloat pushes a number.
+func (b *NodeBuilder) PushFloat(f float32) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_PUSH_FLOAT, floatOperand(f))
+}
+
+// PushBool pushes a bool.
+func (b *NodeBuilder) PushBool(x bool) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_PUSH_BOOL, boolOperand(x))
+}
+
+// PushNull pushes null.
+func (b *NodeBuilder) PushNull() *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_PUSH_NULL)
+}
+
+// Pop pops the top of the stack.
+func (b *NodeBuilder) Pop() *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_POP)
+}
+
+// PushVariable pushes the value of a variable.
+func (b *NodeBuilder) PushVariable(name string) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_PUSH_VARIABLE, stringOperand(name))
+}
+
+// StoreVariable stores the top of the stack in a variable (without popping
+// it, as the compiler follows it with a Pop).
+func (b *NodeBuilder) StoreVariable(name string) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_STORE_VARIABLE, stringOperand(name))
+}
+
+// Call calls a function with argc arguments from the stack. Like the
+// compiler, it pushes argc first.
+func (b *NodeBuilder) Call(funcname string, argc int) *NodeBuilder {
+	return b.PushFloat(float32(argc)).Inst(yarnpb.Instruction_CALL_FUNC, stringOperand(funcname))
+}
+
+// Expr evaluates an expression, pushing its value.
+func (b *NodeBuilder) Expr(e *Expression) *NodeBuilder {
+	b.node.Instructions = append(b.node.Instructions, e.insts...)
+	return b
+}
+
+// Text records the text of a line, for lines that are not in the string
+// table (such as those in generated nodes). See Texts.
+func (b *NodeBuilder) Text(id, text string) *NodeBuilder {
+	if b.texts == nil {
+		b.texts = make(map[string]string)
+	}
+	b.texts[id] = text
+	return b
+}
+
+// Texts returns the line texts recorded with Text, by line ID.
+func (b *NodeBuilder) Texts() map[string]string { return b.texts }
+
+// Line runs a line, with substs substitutions from the stack.
+func (b *NodeBuilder) Line(id string, substs int) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_RUN_LINE, stringOperand(id), floatOperand(float32(substs)))
+}
+
+// Command runs a command, with substs substitutions from the stack.
+func (b *NodeBuilder) Command(text string, substs int) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_RUN_COMMAND, stringOperand(text), floatOperand(float32(substs)))
+}
+
+// Option adds an option with substs substitutions from the stack, that jumps
+// to the label dest when chosen. If hasCond is true, the option's
+// availability is popped from the stack (after the substitutions).
+func (b *NodeBuilder) Option(lineID, dest string, substs int, hasCond bool) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_ADD_OPTION, stringOperand(lineID), stringOperand(dest), floatOperand(float32(substs)), boolOperand(hasCond))
+}
+
+// ShowOptions shows the options added so far, and pushes the destination of
+// the chosen option.
+func (b *NodeBuilder) ShowOptions() *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_SHOW_OPTIONS)
+}
+
+// Jump pops a label from the stack and jumps to it.
+func (b *NodeBuilder) Jump() *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_JUMP)
+}
+
+// JumpTo jumps to a label.
+func (b *NodeBuilder) JumpTo(label string) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_JUMP_TO, stringOperand(label))
+}
+
+// JumpIfFalse jumps to a label if the top of the stack is false (without
+// popping it, as the compiler follows it with a Pop).
+func (b *NodeBuilder) JumpIfFalse(label string) *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_JUMP_IF_FALSE, stringOperand(label))
+}
+
+// RunNode runs another node (<<jump node>>).
+func (b *NodeBuilder) RunNode(node string) *NodeBuilder {
+	return b.PushString(node).Inst(yarnpb.Instruction_RUN_NODE)
+}
+
+// Stop stops the dialogue.
+func (b *NodeBuilder) Stop() *NodeBuilder {
+	return b.Inst(yarnpb.Instruction_STOP)
+}
+
+func stringOperand(s string) *yarnpb.Operand {
+	return &yarnpb.Operand{Value: &yarnpb.Operand_StringValue{StringValue: s}}
+}
+
+func floatOperand(f float32) *yarnpb.Operand {
+	return &yarnpb.Operand{Value: &yarnpb.Operand_FloatValue{FloatValue: f}}
+}
+
+func boolOperand(x bool) *yarnpb.Operand {
+	return &yarnpb.Operand{Value: &yarnpb.Operand_BoolValue{BoolValue: x}}
+}
@@ -0,0 +1,281 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// WhenHeader is the node header holding a candidate condition: an expression
+// (see CompileExpression) that must be true for the node to be available.
+// A node may have several when: headers, all of which must be true. Two
+// values are special: "always" is always true, and "once" is true until the
+// node has been visited.
+const WhenHeader = "when"
+
+// Candidate is the result of evaluating a node's when: conditions.
+type Candidate struct {
+	// Node is the name of the node.
+	Node string
+
+	// Available is true if all the node's conditions are true.
+	Available bool
+
+	// Complexity is the number of conditions the node has, not counting
+	// "always". More complex nodes are more specific to the current state.
+	Complexity int
+}
+
+// Candidates evaluates the when: conditions of groups of nodes (storylets).
+// A group is the set of nodes tagged with the group name, or with a "group"
+// header equal to it (the same as for Barks).
+//
+// For large pools, conditions are evaluated concurrently by a bounded number
+// of workers, each with its own VirtualMachine and a read-only view of the
+// variables. The variable storage must therefore be safe for concurrent reads
+// (MapVariableStorage is), as must any functions in FuncMap. Results do not
+// depend on the order in which the workers finish.
+type Candidates struct {
+	Program *yarnpb.Program
+	FuncMap FuncMap
+
+	// Workers limits the number of concurrent evaluations. If it is zero,
+	// runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	once  sync.Once
+	conds map[string][]*Expression
+	err   error
+}
+
+// Group returns the names of the nodes in a group, sorted.
+func (c *Candidates) Group(group string) []string {
+	var nodes []string
+	for _, name := range sortedNodeNames(c.Program) {
+		if inBarkGroup(c.Program.Nodes[name], group) {
+			nodes = append(nodes, name)
+		}
+	}
+	return nodes
+}
+
+// Conditions returns the compiled when: conditions of a node. Conditions for
+// all nodes are compiled the first time Conditions or Evaluate is called.
+func (c *Candidates) Conditions(node string) ([]*Expression, error) {
+	c.once.Do(c.compile)
+	if c.err != nil {
+		return nil, c.err
+	}
+	if _, ok := c.Program.Nodes[node]; !ok {
+		return nil, fmt.Errorf("%w: %q", ErrNodeNotFound, node)
+	}
+	return c.conds[node], nil
+}
+
+func (c *Candidates) compile() {
+	c.conds = make(map[string][]*Expression)
+	for _, name := range sortedNodeNames(c.Program) {
+		for _, h := range c.Program.Nodes[name].Headers {
+			if h.Key != WhenHeader {
+				continue
+			}
+			var src string
+			switch v := strings.TrimSpace(h.Value); v {
+			case "always":
+				continue
+			case "once":
+				src = `not visited("` + quoteExprString(name) + `")`
+			default:
+				src = v
+			}
+			e, err := CompileExpression(src)
+			if err != nil {
+				c.err = fmt.Errorf("node %q: %s header: %w", name, WhenHeader, err)
+				return
+			}
+			c.conds[name] = append(c.conds[name], e)
+		}
+	}
+}
+
+// quoteExprString escapes s for use within a quoted expression string.
+func quoteExprString(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// Evaluate evaluates the conditions of the nodes using vars, which is not
+// modified. The results are in the same order as nodes. If any condition
+// can't be evaluated, Evaluate returns the error for the earliest such node.
+func (c *Candidates) Evaluate(vars VariableStorage, nodes []string) ([]Candidate, error) {
+	conds := make([][]*Expression, len(nodes))
+	for i, node := range nodes {
+		cs, err := c.Conditions(node)
+		if err != nil {
+			return nil, err
+		}
+		conds[i] = cs
+	}
+
+	results := make([]Candidate, len(nodes))
+	errs := make([]error, len(nodes))
+	eval := func(vm *VirtualMachine, i int) {
+		results[i] = Candidate{Node: nodes[i], Available: true, Complexity: len(conds[i])}
+		for _, e := range conds[i] {
+			ok, err := vm.EvaluateBool(e)
+			if err != nil {
+				errs[i] = fmt.Errorf("node %q: %w", nodes[i], err)
+				results[i].Available = false
+				return
+			}
+			if !ok {
+				results[i].Available = false
+				return
+			}
+		}
+	}
+
+	workers := c.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(nodes))
+	view := readOnlyStorage{base: vars}
+	if workers <= 1 {
+		vm := c.newVM(view)
+		for i := range nodes {
+			eval(vm, i)
+		}
+	} else {
+		next := make(chan int)
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				vm := c.newVM(view)
+				for i := range next {
+					eval(vm, i)
+				}
+			}()
+		}
+		for i := range nodes {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
+
+func (c *Candidates) newVM(vars VariableStorage) *VirtualMachine {
+	return &VirtualMachine{
+		Program: c.Program,
+		Vars:    vars,
+		FuncMap: c.FuncMap,
+	}
+}
+
+// EvaluateGroup evaluates the conditions of the nodes in a group.
+func (c *Candidates) EvaluateGroup(vars VariableStorage, group string) ([]Candidate, error) {
+	return c.Evaluate(vars, c.Group(group))
+}
+
+// BestCandidate returns the available candidate with the greatest
+// complexity. Ties are broken deterministically by choosing the earliest in
+// the slice (for the results of EvaluateGroup, the first by node name). It
+// returns false if no candidate is available.
+func BestCandidate(cands []Candidate) (Candidate, bool) {
+	best, found := Candidate{}, false
+	for _, c := range cands {
+		if c.Available && (!found || c.Complexity > best.Complexity) {
+			best, found = c, true
+		}
+	}
+	return best, found
+}
+
+// CandidateNode is a candidate reported by VirtualMachine.CandidateNodes.
+type CandidateNode struct {
+	Candidate
+
+	// Ready is false if the node is cooling down (see VirtualMachine.Limits).
+	Ready bool
+
+	// Saliency is the node's score for being chosen: its Complexity if it is
+	// available and ready, or -1 otherwise.
+	Saliency int
+
+	// Best is true for the candidate that would be chosen now: the first
+	// with the greatest saliency (as for BestCandidate).
+	Best bool
+}
+
+// CandidateNodes evaluates the when: conditions of the nodes in a group (see
+// Candidates) using the VM's program, variables, and functions, for example
+// so that a map screen can show that someone has something to say. It does
+// not change the VM's state, so it can be called while the VM is not
+// running, or from within handler methods.
+func (vm *VirtualMachine) CandidateNodes(group string) ([]CandidateNode, error) {
+	if vm.Program == nil {
+		return nil, ErrMissingProgram
+	}
+	if vm.Vars == nil {
+		return nil, ErrNilVariableStorage
+	}
+	if vm.candidates == nil || vm.candidates.Program != vm.Program {
+		// The variable storage might not be safe for concurrent reads, so
+		// use one worker.
+		vm.candidates = &Candidates{Program: vm.Program, FuncMap: vm.FuncMap, Workers: 1}
+	}
+	cands, err := vm.candidates.EvaluateGroup(vm.Vars, group)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]CandidateNode, len(cands))
+	best := -1
+	for i, c := range cands {
+		nodes[i] = CandidateNode{Candidate: c, Ready: true, Saliency: -1}
+		if vm.Limits != nil {
+			switch err := vm.Limits.Check(c.Node); {
+			case errors.Is(err, ErrNodeCoolingDown):
+				nodes[i].Ready = false
+			case err != nil:
+				return nil, err
+			}
+		}
+		if c.Available && nodes[i].Ready {
+			nodes[i].Saliency = c.Complexity
+			if best < 0 || nodes[i].Saliency > nodes[best].Saliency {
+				best = i
+			}
+		}
+	}
+	if best >= 0 {
+		nodes[best].Best = true
+	}
+	return nodes, nil
+}
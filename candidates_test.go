@@ -0,0 +1,195 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+// lineNode adds a node that runs the line "line:<name>".
+func lineNode(pb *ProgramBuilder, name string) *NodeBuilder {
+	return pb.Node(name).Line("line:"+name, 0).Stop()
+}
+
+// storyletProgram returns a program with nodes in the "tavern" group, with
+// the given when: headers.
+func storyletProgram(whens map[string][]string) *yarnpb.Program {
+	pb := NewProgramBuilder("Storylets")
+	for name, ws := range whens {
+		node := lineNode(pb, name).Tags("tavern")
+		for _, w := range ws {
+			node.Header(WhenHeader, w)
+		}
+	}
+	return pb.Program()
+}
+
+func TestCandidatesEvaluateGroup(t *testing.T) {
+	prog := storyletProgram(map[string][]string{
+		"Bard":      {"always"},
+		"Brawl":     {"$drunk > 3", `$mood == "angry"`},
+		"Gossip":    {"$drunk > 1"},
+		"Intro":     {"once"},
+		"Innkeeper": nil,
+	})
+	prog.Nodes["Other"] = &yarnpb.Node{Name: "Other"}
+	c := &Candidates{Program: prog}
+
+	vars := NewMapVariableStorage()
+	vars.SetValue("$drunk", float32(4))
+	vars.SetValue("$mood", "calm")
+
+	got, err := c.EvaluateGroup(vars, "tavern")
+	if err != nil {
+		t.Fatalf("EvaluateGroup(vars, tavern) error = %v", err)
+	}
+	want := []Candidate{
+		{Node: "Bard", Available: true},
+		{Node: "Brawl", Complexity: 2},
+		{Node: "Gossip", Available: true, Complexity: 1},
+		{Node: "Innkeeper", Available: true},
+		{Node: "Intro", Available: true, Complexity: 1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("EvaluateGroup(vars, tavern) diff (-got +want):\n%s", diff)
+	}
+	if best, ok := BestCandidate(got); !ok || best.Node != "Gossip" {
+		t.Errorf("BestCandidate(got) = %v, %t, want Gossip, true", best, ok)
+	}
+
+	vars.SetValue("$mood", "angry")
+	vars.SetValue(visitingVar("Intro"), 1)
+	got, err = c.EvaluateGroup(vars, "tavern")
+	if err != nil {
+		t.Fatalf("EvaluateGroup(vars, tavern) error = %v", err)
+	}
+	if best, ok := BestCandidate(got); !ok || best.Node != "Brawl" {
+		t.Errorf("BestCandidate(got) = %v, %t, want Brawl, true", best, ok)
+	}
+	if got[4].Available {
+		t.Errorf("Intro available after visiting, want unavailable")
+	}
+}
+
+func TestCandidatesParallel(t *testing.T) {
+	whens := make(map[string][]string)
+	var nodes []string
+	for i := 0; i < 200; i++ {
+		name := fmt.Sprintf("Storylet%03d", i)
+		whens[name] = []string{fmt.Sprintf("$x %% %d == 0", i%7+1), "is_even($x)"}
+		nodes = append(nodes, name)
+	}
+	prog := storyletProgram(whens)
+	funcs := FuncMap{"is_even": func(x float32) bool { return int(x)%2 == 0 }}
+
+	vars := NewMapVariableStorage()
+	vars.SetValue("$x", float32(12))
+
+	serial, err := (&Candidates{Program: prog, FuncMap: funcs, Workers: 1}).Evaluate(vars, nodes)
+	if err != nil {
+		t.Fatalf("Evaluate(serial) error = %v", err)
+	}
+	for _, workers := range []int{0, 3, 16} {
+		got, err := (&Candidates{Program: prog, FuncMap: funcs, Workers: workers}).Evaluate(vars, nodes)
+		if err != nil {
+			t.Fatalf("Evaluate(workers=%d) error = %v", workers, err)
+		}
+		if diff := cmp.Diff(got, serial); diff != "" {
+			t.Errorf("Evaluate(workers=%d) diff (-got +want):\n%s", workers, diff)
+		}
+	}
+	if best, ok := BestCandidate(serial); !ok || best.Node != "Storylet000" {
+		t.Errorf("BestCandidate(serial) = %v, %t, want Storylet000, true", best, ok)
+	}
+}
+
+func TestCandidatesErrors(t *testing.T) {
+	c := &Candidates{Program: storyletProgram(map[string][]string{"A": {"$x >"}})}
+	if _, err := c.EvaluateGroup(NewMapVariableStorage(), "tavern"); !errors.Is(err, ErrBadExpression) {
+		t.Errorf("EvaluateGroup with bad header = %v, want %v", err, ErrBadExpression)
+	}
+
+	c = &Candidates{Program: storyletProgram(map[string][]string{
+		"A": {"missing_a()"},
+		"B": {"missing_b()"},
+	})}
+	for i := 0; i < 10; i++ {
+		_, err := c.EvaluateGroup(NewMapVariableStorage(), "tavern")
+		if !errors.Is(err, ErrFunctionNotFound) {
+			t.Fatalf("EvaluateGroup with missing func = %v, want %v", err, ErrFunctionNotFound)
+		}
+		if want := `node "A"`; err.Error()[:len(want)] != want {
+			t.Errorf("EvaluateGroup error = %v, want error for node A", err)
+		}
+	}
+
+	if _, err := c.Evaluate(NewMapVariableStorage(), []string{"Nope"}); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("Evaluate(Nope) = %v, want %v", err, ErrNodeNotFound)
+	}
+}
+
+func TestVMCandidateNodes(t *testing.T) {
+	prog := storyletProgram(map[string][]string{
+		"Brawl":  {"$drunk > 3", `$mood == "angry"`},
+		"Gossip": {"$drunk > 1"},
+		"Intro":  {"once"},
+		"Rumour": {"$drunk > 1"},
+	})
+	prog.Nodes["Gossip"].Headers = append(prog.Nodes["Gossip"].Headers, &yarnpb.Header{Key: CooldownHeader, Value: "300"})
+	vars := NewMapVariableStorage()
+	vars.SetValue("$drunk", float32(2))
+	vars.SetValue("$mood", "calm")
+	vm := &VirtualMachine{
+		Program: prog,
+		Vars:    vars,
+		Limits:  &NodeLimits{Program: prog},
+	}
+	if err := vm.Limits.Enter("Gossip"); err != nil {
+		t.Fatalf("Limits.Enter(Gossip) = %v", err)
+	}
+
+	got, err := vm.CandidateNodes("tavern")
+	if err != nil {
+		t.Fatalf("vm.CandidateNodes(tavern) = %v", err)
+	}
+	want := []CandidateNode{
+		{Candidate: Candidate{Node: "Brawl", Complexity: 2}, Ready: true, Saliency: -1},
+		{Candidate: Candidate{Node: "Gossip", Available: true, Complexity: 1}, Saliency: -1},
+		{Candidate: Candidate{Node: "Intro", Available: true, Complexity: 1}, Ready: true, Saliency: 1, Best: true},
+		{Candidate: Candidate{Node: "Rumour", Available: true, Complexity: 1}, Ready: true, Saliency: 1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("vm.CandidateNodes(tavern) diff (-got +want):\n%s", diff)
+	}
+
+	vars.SetValue("$drunk", float32(5))
+	vars.SetValue("$mood", "angry")
+	got, err = vm.CandidateNodes("tavern")
+	if err != nil {
+		t.Fatalf("vm.CandidateNodes(tavern) = %v", err)
+	}
+	if !got[0].Best || got[0].Saliency != 2 {
+		t.Errorf("vm.CandidateNodes(tavern)[0] = %+v, want Brawl best with saliency 2", got[0])
+	}
+
+	if got, err := vm.CandidateNodes("nowhere"); err != nil || len(got) != 0 {
+		t.Errorf("vm.CandidateNodes(nowhere) = %v, %v, want empty, nil", got, err)
+	}
+}
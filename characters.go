@@ -0,0 +1,238 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Character contains presentation data for a character.
+type Character struct {
+	// Name is the character's name as it appears in scripts (in a "Name: "
+	// prefix, or the name property of the character attribute).
+	Name string `json:"name"`
+
+	// DisplayName is the name to show. If empty, Name is shown.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Color is the colour for the character's name or text, in whatever
+	// form the UI understands (e.g. "#ff8800").
+	Color string `json:"color,omitempty"`
+
+	// Portrait is the key of the character's portrait asset.
+	Portrait string `json:"portrait,omitempty"`
+
+	// Voice is the key of the character's voice (e.g. a voice bank or TTS
+	// voice).
+	Voice string `json:"voice,omitempty"`
+}
+
+// Display returns DisplayName, or Name if DisplayName is empty.
+func (c *Character) Display() string {
+	if c.DisplayName != "" {
+		return c.DisplayName
+	}
+	return c.Name
+}
+
+// CharacterRegistry maps character names to presentation data. It is safe for
+// concurrent use.
+type CharacterRegistry struct {
+	mu    sync.RWMutex
+	chars map[string]*Character
+}
+
+// NewCharacterRegistry returns a registry containing the characters.
+func NewCharacterRegistry(chars ...Character) *CharacterRegistry {
+	r := &CharacterRegistry{}
+	for _, c := range chars {
+		r.Add(c)
+	}
+	return r
+}
+
+// ReadCharacterRegistryJSON reads a registry from a JSON array of characters,
+// e.g.
+//
+//	[{"name": "Ava", "display_name": "Ava Reyes", "color": "#ff8800"}]
+func ReadCharacterRegistryJSON(r io.Reader) (*CharacterRegistry, error) {
+	var chars []Character
+	if err := json.NewDecoder(r).Decode(&chars); err != nil {
+		return nil, fmt.Errorf("decoding characters: %w", err)
+	}
+	return NewCharacterRegistry(chars...), nil
+}
+
+// ReadCharacterRegistryCSV reads a registry from CSV. The first row is a
+// header naming the columns, which may be in any order: name (required),
+// display_name, color, portrait, and voice. Other columns are ignored.
+func ReadCharacterRegistryCSV(r io.Reader) (*CharacterRegistry, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("csv read: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := cols["name"]; !ok {
+		return nil, fmt.Errorf("csv header %q has no name column", header)
+	}
+	get := func(rec []string, col string) string {
+		if i, ok := cols[col]; ok && i < len(rec) {
+			return rec[i]
+		}
+		return ""
+	}
+	reg := &CharacterRegistry{}
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("csv read: %w", err)
+		}
+		reg.Add(Character{
+			Name:        get(rec, "name"),
+			DisplayName: get(rec, "display_name"),
+			Color:       get(rec, "color"),
+			Portrait:    get(rec, "portrait"),
+			Voice:       get(rec, "voice"),
+		})
+	}
+	return reg, nil
+}
+
+// Add adds or replaces a character.
+func (r *CharacterRegistry) Add(c Character) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.chars == nil {
+		r.chars = make(map[string]*Character)
+	}
+	r.chars[c.Name] = &c
+}
+
+// Lookup returns the character with the given name.
+func (r *CharacterRegistry) Lookup(name string) (*Character, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.chars[name]
+	if !ok {
+		return nil, false
+	}
+	cc := *c
+	return &cc, true
+}
+
+// Names returns the names of all characters, sorted.
+func (r *CharacterRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.chars))
+	for n := range r.chars {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CharacterLine is a rendered line with the speaker's presentation data.
+type CharacterLine struct {
+	// Line is the line as delivered by the VM.
+	Line Line
+
+	// Text is the rendered text, without the speaker's name.
+	Text string
+
+	// Speaker is the speaker's name as found in the line (see
+	// Line.Accessible), or empty if the line has no speaker.
+	Speaker string
+
+	// Character is the speaker's presentation data, or nil if the line has
+	// no speaker or the speaker is not in the registry.
+	Character *Character
+}
+
+// Resolve renders the line and looks up its speaker.
+func (r *CharacterRegistry) Resolve(line Line, st *StringTable) (*CharacterLine, error) {
+	as, err := st.Render(line)
+	if err != nil {
+		return nil, err
+	}
+	cl := &CharacterLine{Line: line}
+	cl.Speaker, cl.Text = findSpeaker(as)
+	if cl.Speaker != "" {
+		cl.Character, _ = r.Lookup(cl.Speaker)
+	}
+	return cl, nil
+}
+
+// CharacterLineHandler is an optional interface for dialogue handlers wrapped
+// by CharacterHandler. Lines are delivered to CharacterLine instead of Line.
+type CharacterLineHandler interface {
+	CharacterLine(line *CharacterLine) error
+}
+
+var (
+	_ DialogueHandler      = &CharacterHandler{}
+	_ CommandResultHandler = &CharacterHandler{}
+	_ SkipHandler          = &CharacterHandler{}
+)
+
+// CharacterHandler is a DialogueHandler that resolves the speaker of each line
+// with a CharacterRegistry, so that lines arrive at the UI with presentation
+// data. If the embedded DialogueHandler implements CharacterLineHandler, each
+// line is delivered to CharacterLine; otherwise it is delivered to Line as
+// usual (which is only useful for checking that lines resolve). All other
+// events are passed to the embedded handler.
+type CharacterHandler struct {
+	DialogueHandler
+	Registry    *CharacterRegistry
+	StringTable *StringTable
+}
+
+// Line resolves the line and delivers it to the embedded handler.
+func (h *CharacterHandler) Line(line Line) error {
+	clh, ok := h.DialogueHandler.(CharacterLineHandler)
+	cl, err := h.Registry.Resolve(line, h.StringTable)
+	if err != nil {
+		return fmt.Errorf("resolving character for line %q: %w", line.ID, err)
+	}
+	if !ok {
+		return h.DialogueHandler.Line(line)
+	}
+	return clh.CharacterLine(cl)
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *CharacterHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// CommandResult passes the command to the embedded handler's CommandResult
+// method, if it has one.
+func (h *CharacterHandler) CommandResult(command string) (any, error) {
+	return forwardCommandResult(h.DialogueHandler, command)
+}
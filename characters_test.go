@@ -0,0 +1,124 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestReadCharacterRegistry(t *testing.T) {
+	want := []Character{
+		{Name: "Ava", DisplayName: "Ava Reyes", Color: "#ff8800", Portrait: "ava_neutral"},
+		{Name: "Bo", Voice: "bo_voice"},
+	}
+
+	fromJSON, err := ReadCharacterRegistryJSON(strings.NewReader(`[
+		{"name": "Ava", "display_name": "Ava Reyes", "color": "#ff8800", "portrait": "ava_neutral"},
+		{"name": "Bo", "voice": "bo_voice"}
+	]`))
+	if err != nil {
+		t.Fatalf("ReadCharacterRegistryJSON = %v", err)
+	}
+	fromCSV, err := ReadCharacterRegistryCSV(strings.NewReader(
+		"Voice,name,display_name,color,portrait,notes\n" +
+			",Ava,Ava Reyes,#ff8800,ava_neutral,the protagonist\n" +
+			"bo_voice,Bo,,,,\n"))
+	if err != nil {
+		t.Fatalf("ReadCharacterRegistryCSV = %v", err)
+	}
+
+	for _, reg := range []*CharacterRegistry{fromJSON, fromCSV} {
+		var got []Character
+		for _, name := range reg.Names() {
+			c, ok := reg.Lookup(name)
+			if !ok {
+				t.Fatalf("Lookup(%q) = _, false", name)
+			}
+			got = append(got, *c)
+		}
+		if diff := cmp.Diff(got, want); diff != "" {
+			t.Errorf("characters diff (-got +want):\n%s", diff)
+		}
+	}
+
+	if _, err := ReadCharacterRegistryCSV(strings.NewReader("display_name\nAva\n")); err == nil {
+		t.Errorf("ReadCharacterRegistryCSV without name column: error = nil, want error")
+	}
+}
+
+// characterRecorder records CharacterLines.
+type characterRecorder struct {
+	FakeDialogueHandler
+	lines []*CharacterLine
+}
+
+func (r *characterRecorder) CharacterLine(line *CharacterLine) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func TestCharacterHandler(t *testing.T) {
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: "Ava: Hello, {0}."},
+		"line:2": {ID: "line:2", Text: `[character name="Bo"]Bo the Brave: [/character]Hi.`},
+		"line:3": {ID: "line:3", Text: "Cy: Who, me?"},
+		"line:4": {ID: "line:4", Text: "It was a dark and stormy night."},
+	}}
+	reg := NewCharacterRegistry(
+		Character{Name: "Ava", DisplayName: "Ava Reyes", Color: "#ff8800"},
+		Character{Name: "Bo", Portrait: "bo"},
+	)
+	rec := &characterRecorder{}
+	h := &CharacterHandler{DialogueHandler: rec, Registry: reg, StringTable: st}
+	for _, line := range []Line{
+		{ID: "line:1", Substitutions: []string{"Bo"}},
+		{ID: "line:2"},
+		{ID: "line:3"},
+		{ID: "line:4"},
+	} {
+		if err := h.Line(line); err != nil {
+			t.Fatalf("Line(%v) = %v", line, err)
+		}
+	}
+	want := []*CharacterLine{
+		{
+			Line:      Line{ID: "line:1", Substitutions: []string{"Bo"}},
+			Text:      "Hello, Bo.",
+			Speaker:   "Ava",
+			Character: &Character{Name: "Ava", DisplayName: "Ava Reyes", Color: "#ff8800"},
+		},
+		{
+			Line:      Line{ID: "line:2"},
+			Text:      "Hi.",
+			Speaker:   "Bo",
+			Character: &Character{Name: "Bo", Portrait: "bo"},
+		},
+		{Line: Line{ID: "line:3"}, Text: "Who, me?", Speaker: "Cy"},
+		{Line: Line{ID: "line:4"}, Text: "It was a dark and stormy night."},
+	}
+	if diff := cmp.Diff(rec.lines, want); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if got, want := rec.lines[1].Character.Display(), "Bo"; got != want {
+		t.Errorf("Display() = %q, want %q", got, want)
+	}
+
+	if err := h.Line(Line{ID: "line:nope"}); err == nil {
+		t.Errorf("Line(line:nope) = nil, want error")
+	}
+}
@@ -0,0 +1,308 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the source of time for time-based dialogue behaviour (the wait
+// command, auto-advance, option timeouts, and acknowledgement deadlines).
+// Using the same Clock everywhere lets a game pause or scale all dialogue
+// timing at once (see GameClock), and lets tests control time (see
+// FakeClock).
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// AfterFunc arranges for f to be called once d has elapsed, unless the
+	// Timer is stopped first. f may be called from any goroutine.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is returned by Clock.AfterFunc.
+type Timer interface {
+	// Stop prevents the function from being called. It returns false if the
+	// function has already been called or the timer was already stopped.
+	Stop() bool
+}
+
+// SystemClock is the Clock implemented by the time package. It is used
+// wherever a Clock is nil.
+var SystemClock Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+// clockOrSystem returns c, or SystemClock if c is nil.
+func clockOrSystem(c Clock) Clock {
+	if c == nil {
+		return SystemClock
+	}
+	return c
+}
+
+// after returns a channel that receives once d has elapsed on the clock, and
+// the underlying timer.
+func after(c Clock, d time.Duration) (<-chan struct{}, Timer) {
+	ch := make(chan struct{}, 1)
+	return ch, c.AfterFunc(d, func() { ch <- struct{}{} })
+}
+
+// GameClock is a Clock that can be paused and scaled, e.g. for pause menus
+// or bullet-time. Game time starts at the Base time when the GameClock is
+// first used, and then advances at Scale times the rate of the Base clock
+// while not paused. Timers are measured in game time, so they are delayed by
+// pausing and slowed or sped up by scaling. The zero value runs at normal
+// speed on SystemClock. It is safe for concurrent use.
+type GameClock struct {
+	// Base is the underlying clock. If nil, SystemClock is used.
+	Base Clock
+
+	mu      sync.Mutex
+	started bool
+	gameAt  time.Time // game time at the last change
+	baseAt  time.Time // base time at the last change
+	scale   float64
+	paused  bool
+	timers  map[*gameTimer]struct{}
+}
+
+// gameTimer is a GameClock timer.
+type gameTimer struct {
+	clock    *GameClock
+	deadline time.Time // in game time
+	f        func()
+	base     Timer // armed on the base clock, or nil while paused
+	gen      int   // incremented each time base is re-armed
+}
+
+// start initialises the clock on first use. g.mu must be held.
+func (g *GameClock) start() {
+	if g.started {
+		return
+	}
+	g.started = true
+	g.baseAt = clockOrSystem(g.Base).Now()
+	g.gameAt = g.baseAt
+	g.scale = 1
+	g.timers = make(map[*gameTimer]struct{})
+}
+
+// now returns the game time. g.mu must be held.
+func (g *GameClock) now() time.Time {
+	g.start()
+	if g.paused || g.scale == 0 {
+		return g.gameAt
+	}
+	elapsed := clockOrSystem(g.Base).Now().Sub(g.baseAt)
+	return g.gameAt.Add(time.Duration(float64(elapsed) * g.scale))
+}
+
+// rebase records the current game and base times, so that the pause state
+// or scale can change. g.mu must be held.
+func (g *GameClock) rebase() {
+	g.gameAt = g.now()
+	g.baseAt = clockOrSystem(g.Base).Now()
+}
+
+// arm (re-)arms the base timer for t. g.mu must be held.
+func (g *GameClock) arm(t *gameTimer) {
+	if t.base != nil {
+		t.base.Stop()
+		t.base = nil
+	}
+	t.gen++
+	if g.paused || g.scale == 0 {
+		return
+	}
+	wait := time.Duration(float64(t.deadline.Sub(g.now())) / g.scale)
+	gen := t.gen
+	t.base = clockOrSystem(g.Base).AfterFunc(max(wait, 0), func() { g.fire(t, gen) })
+}
+
+// fire calls the timer function, unless the timer was stopped or re-armed.
+func (g *GameClock) fire(t *gameTimer, gen int) {
+	g.mu.Lock()
+	if _, ok := g.timers[t]; !ok || t.gen != gen {
+		g.mu.Unlock()
+		return
+	}
+	delete(g.timers, t)
+	g.mu.Unlock()
+	t.f()
+}
+
+// Now returns the current game time.
+func (g *GameClock) Now() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.now()
+}
+
+// AfterFunc calls f after d has elapsed in game time.
+func (g *GameClock) AfterFunc(d time.Duration, f func()) Timer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	t := &gameTimer{clock: g, deadline: g.now().Add(d), f: f}
+	g.timers[t] = struct{}{}
+	g.arm(t)
+	return t
+}
+
+// Stop stops the timer.
+func (t *gameTimer) Stop() bool {
+	g := t.clock
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if _, ok := g.timers[t]; !ok {
+		return false
+	}
+	delete(g.timers, t)
+	if t.base != nil {
+		t.base.Stop()
+	}
+	return true
+}
+
+// Pause stops game time, and with it, all timers.
+func (g *GameClock) Pause() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rebase()
+	g.paused = true
+	g.rearm()
+}
+
+// Resume restarts game time after Pause.
+func (g *GameClock) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rebase()
+	g.paused = false
+	g.rearm()
+}
+
+// Paused reports whether the clock is paused.
+func (g *GameClock) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
+
+// SetScale changes the rate at which game time passes relative to the base
+// clock, e.g. 0.5 for half speed. Negative scales are treated as 0, which
+// stops game time (like Pause).
+func (g *GameClock) SetScale(scale float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.rebase()
+	g.scale = max(scale, 0)
+	g.rearm()
+}
+
+// Scale returns the current scale.
+func (g *GameClock) Scale() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.start()
+	return g.scale
+}
+
+// rearm re-arms all timers. g.mu must be held.
+func (g *GameClock) rearm() {
+	for t := range g.timers {
+		g.arm(t)
+	}
+}
+
+// FakeClock is a Clock for tests, where time only passes when Advance is
+// called. The zero value starts at the zero time. It is safe for concurrent
+// use.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers map[*fakeTimer]struct{}
+}
+
+// fakeTimer is a FakeClock timer.
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	f        func()
+}
+
+// Now returns the fake time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// AfterFunc calls f from within Advance, once d has elapsed.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.timers == nil {
+		c.timers = make(map[*fakeTimer]struct{})
+	}
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), f: f}
+	c.timers[t] = struct{}{}
+	return t
+}
+
+// Stop stops the timer.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	if _, ok := t.clock.timers[t]; !ok {
+		return false
+	}
+	delete(t.clock.timers, t)
+	return true
+}
+
+// Advance moves the fake time forward by d, calling the functions of any
+// timers that become due, in deadline order.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	var due []*fakeTimer
+	for t := range c.timers {
+		if !t.deadline.After(c.now) {
+			due = append(due, t)
+			delete(c.timers, t)
+		}
+	}
+	c.mu.Unlock()
+	sort.SliceStable(due, func(i, j int) bool { return due[i].deadline.Before(due[j].deadline) })
+	for _, t := range due {
+		t.f()
+	}
+}
+
+// Waiting returns the number of timers that have not yet fired or been
+// stopped. This is useful in tests for waiting until something is blocked on
+// the clock.
+func (c *FakeClock) Waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
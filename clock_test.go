@@ -0,0 +1,181 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGameClock(t *testing.T) {
+	fc := &FakeClock{}
+	g := &GameClock{Base: fc}
+	start := g.Now()
+	fired := false
+	g.AfterFunc(10*time.Second, func() { fired = true })
+
+	fc.Advance(5 * time.Second)
+	if got, want := g.Now().Sub(start), 5*time.Second; got != want {
+		t.Errorf("game time elapsed = %v, want %v", got, want)
+	}
+
+	g.Pause()
+	fc.Advance(time.Minute)
+	if got, want := g.Now().Sub(start), 5*time.Second; got != want {
+		t.Errorf("game time elapsed while paused = %v, want %v", got, want)
+	}
+	if fired {
+		t.Errorf("timer fired while paused")
+	}
+
+	g.Resume()
+	g.SetScale(0.5)
+	fc.Advance(9 * time.Second)
+	if fired {
+		t.Errorf("timer fired after 9.5s of game time, want 10s")
+	}
+	fc.Advance(time.Second)
+	if !fired {
+		t.Errorf("timer did not fire after 10s of game time")
+	}
+
+	stopped := g.AfterFunc(time.Second, func() { t.Errorf("stopped timer fired") })
+	if !stopped.Stop() {
+		t.Errorf("Stop() = false, want true")
+	}
+	fc.Advance(time.Minute)
+	if stopped.Stop() {
+		t.Errorf("second Stop() = true, want false")
+	}
+}
+
+// timedAsyncHandler records lines and options, and leaves them for the
+// adapter to continue automatically.
+type timedAsyncHandler struct {
+	FakeAsyncDialogueHandler
+	mu     sync.Mutex
+	events []string
+}
+
+func (h *timedAsyncHandler) record(ev string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, ev)
+}
+
+func (h *timedAsyncHandler) Line(line Line) { h.record(line.ID) }
+
+func (h *timedAsyncHandler) Options([]Option) { h.record("options") }
+
+func (h *timedAsyncHandler) Command(command string) { h.record(command) }
+
+func TestAsyncAdapterClock(t *testing.T) {
+	pb := NewProgramBuilder("Timed")
+	pb.Node("Start").
+		Command("wait 2", 0).
+		Line("line:a", 0).
+		Option("line:x", "end", 0, false).
+		Option("line:y", "end", 0, false).
+		ShowOptions().
+		Jump().
+		Label("end").
+		Line("line:b", 0).
+		Stop()
+
+	fc := &FakeClock{}
+	h := &timedAsyncHandler{}
+	aa := NewAsyncAdapter(h)
+	h.AsyncAdapter = aa
+	aa.Clock = fc
+	aa.AutoAdvance = func(Line) time.Duration { return 3 * time.Second }
+	aa.OptionTimeout = 5 * time.Second
+	var chosen []int
+	aa.TimeoutChoice = func(options []Option) int {
+		chosen = append(chosen, options[1].ID)
+		return options[1].ID
+	}
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: &WaitHandler{DialogueHandler: aa, Clock: fc},
+		Vars:    NewMapVariableStorage(),
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- vm.Run("Start") }()
+
+	// advance waits until something is waiting on the clock, then advances
+	// it.
+	advance := func(d time.Duration) {
+		for fc.Waiting() == 0 {
+			runtime.Gosched()
+		}
+		fc.Advance(d)
+	}
+	advance(time.Second) // wait 2
+	h.mu.Lock()
+	if len(h.events) != 0 {
+		t.Errorf("events after 1s = %q, want none", h.events)
+	}
+	h.mu.Unlock()
+	advance(time.Second)     // wait 2
+	advance(3 * time.Second) // line:a
+	advance(5 * time.Second) // options
+	advance(3 * time.Second) // line:b
+
+	if err := <-errc; err != nil {
+		t.Errorf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(h.events, []string{"line:a", "options", "line:b"}); diff != "" {
+		t.Errorf("events diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(chosen, []int{1}); diff != "" {
+		t.Errorf("chosen diff (-got +want):\n%s", diff)
+	}
+
+	wh := &WaitHandler{DialogueHandler: FakeDialogueHandler{}, Clock: fc}
+	for _, cmd := range []string{"wait", "wait 1 2", "wait soon", "wait -1"} {
+		if err := wh.Command(cmd); err == nil {
+			t.Errorf("wh.Command(%q) = nil, want error", cmd)
+		}
+	}
+}
+
+func TestWaitHandlerSkipMode(t *testing.T) {
+	pb := NewProgramBuilder("Wait")
+	pb.Node("Start").Command("wait 10", 0).Line("line:a", 0).Stop()
+	fc := &FakeClock{}
+	c := &skipCounter{}
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Vars:    NewMapVariableStorage(),
+	}
+	vm.Handler = &WaitHandler{DialogueHandler: c, Clock: fc, VM: vm}
+	vm.SetSkipMode(true)
+
+	// The clock never advances, so this would block if the wait weren't
+	// skipped.
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got := fc.Waiting(); got != 0 {
+		t.Errorf("fc.Waiting() = %d, want 0", got)
+	}
+	if c.lines != 0 || c.skipped != 1 {
+		t.Errorf("lines, skipped = %d, %d, want 0, 1", c.lines, c.skipped)
+	}
+}
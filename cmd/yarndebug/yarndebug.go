@@ -0,0 +1,352 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarndebug binary is an interactive terminal debugger for yarnc+string
+// table combos. It shows the current node's disassembly, the stack,
+// variables, and a transcript of the dialogue side by side, and supports
+// stepping and breakpoints.
+//
+// It is a separate module, so that the yarn module doesn't depend on its
+// terminal UI libraries. Quick usage from this directory:
+//
+//	go run -tags example . --program=../yarnrunner/terminal.yarn.yarnc
+//
+// Keys:
+//
+//	s, n        step one instruction
+//	c           continue until the next breakpoint
+//	p           pause
+//	up/k, down/j  move the cursor in the disassembly
+//	b           toggle a breakpoint at the cursor
+//	1-9         choose an option
+//	q, ctrl+c   quit
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	disasmHeight     = 20
+	transcriptHeight = 10
+)
+
+var (
+	boxStyle    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 1)
+	titleStyle  = lipgloss.NewStyle().Bold(true)
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	pcStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("10")).Bold(true)
+	bpStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	helpStyle   = lipgloss.NewStyle().Faint(true)
+)
+
+// Messages sent to the model from the VM goroutine.
+type (
+	pausedMsg     struct{ state *yarn.DebugState }
+	transcriptMsg string
+	optionsMsg    struct {
+		options []yarn.Option
+		texts   []string
+	}
+	doneMsg struct{ err error }
+)
+
+func main() {
+	yarncFilename := flag.String("program", "", "File name of program (e.g. Example.yarn.yarnc)")
+	startNode := flag.String("start", "Start", "Name of the node to run")
+	langCode := flag.String("lang", "en-AU", "Language tag (BCP 47)")
+	flag.Parse()
+
+	program, stringTable, err := yarn.LoadFiles(*yarncFilename, *langCode)
+	if err != nil {
+		log.Fatalf("Loading files: %v", err)
+	}
+
+	dbg := yarn.NewDebugger()
+	dbg.Pause() // start paused at the first instruction
+	vars := yarn.NewMapVariableStorage()
+	h := &handler{
+		stringTable: stringTable,
+		choice:      make(chan int),
+	}
+	vm := &yarn.VirtualMachine{
+		Program:  program,
+		Handler:  h,
+		Vars:     vars,
+		Debugger: dbg,
+	}
+	m := &model{
+		dbg:    dbg,
+		vars:   vars,
+		choice: h.choice,
+	}
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	h.send = p.Send
+
+	go func() {
+		for s := range dbg.Paused() {
+			p.Send(pausedMsg{s})
+		}
+	}()
+	go func() {
+		p.Send(doneMsg{vm.Run(*startNode)})
+	}()
+
+	if _, err := p.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "yarndebug: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// handler delivers dialogue to the model, and waits for option choices.
+type handler struct {
+	stringTable *yarn.StringTable
+	send        func(tea.Msg)
+	choice      chan int
+
+	yarn.FakeDialogueHandler
+}
+
+func (h *handler) render(line yarn.Line) string {
+	text, err := h.stringTable.Render(line)
+	if err != nil {
+		return fmt.Sprintf("[%s: %v]", line.ID, err)
+	}
+	return text.String()
+}
+
+func (h *handler) NodeStart(nodeName string) error {
+	h.send(transcriptMsg(fmt.Sprintf("--- %s ---", nodeName)))
+	return nil
+}
+
+func (h *handler) Line(line yarn.Line) error {
+	h.send(transcriptMsg(h.render(line)))
+	return nil
+}
+
+func (h *handler) Command(command string) error {
+	h.send(transcriptMsg("<<" + command + ">>"))
+	return nil
+}
+
+func (h *handler) Options(opts []yarn.Option) (int, error) {
+	texts := make([]string, len(opts))
+	for i, opt := range opts {
+		texts[i] = h.render(opt.Line)
+	}
+	h.send(optionsMsg{opts, texts})
+	return <-h.choice, nil
+}
+
+// model is the bubbletea model.
+type model struct {
+	dbg    *yarn.Debugger
+	vars   *yarn.MapVariableStorage
+	choice chan int
+
+	state      *yarn.DebugState // nil while running
+	cursor     int
+	transcript []string
+	options    []yarn.Option // pending options, if any
+	optTexts   []string      // rendered text of pending options
+	done       bool
+	err        error
+}
+
+func (m *model) Init() tea.Cmd { return nil }
+
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case pausedMsg:
+		m.state = msg.state
+		m.cursor = msg.state.PC
+	case transcriptMsg:
+		m.transcript = append(m.transcript, string(msg))
+	case optionsMsg:
+		m.options, m.optTexts = msg.options, msg.texts
+	case doneMsg:
+		m.done, m.err, m.state = true, msg.err, nil
+		m.transcript = append(m.transcript, "=== dialogue complete ===")
+	case tea.KeyMsg:
+		return m, m.key(msg.String())
+	}
+	return m, nil
+}
+
+func (m *model) key(k string) tea.Cmd {
+	switch k {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "s", "n":
+		if m.state != nil {
+			m.state = nil
+			m.dbg.Step()
+		}
+	case "c":
+		if m.state != nil {
+			m.state = nil
+			m.dbg.Continue()
+		}
+	case "p":
+		if m.state == nil && !m.done {
+			m.dbg.Pause()
+		}
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.state != nil && m.cursor < len(m.state.Node.Instructions)-1 {
+			m.cursor++
+		}
+	case "b":
+		if m.state != nil {
+			m.dbg.ToggleBreakpoint(m.state.Node.Name, m.cursor)
+		}
+	default:
+		if len(k) == 1 && k[0] >= '1' && k[0] <= '9' && m.options != nil {
+			n := int(k[0] - '1')
+			if n < len(m.options) && m.options[n].IsAvailable {
+				id := m.options[n].ID
+				m.transcript = append(m.transcript, "> "+m.optTexts[n])
+				m.options, m.optTexts = nil, nil
+				m.choice <- id
+			}
+		}
+	}
+	return nil
+}
+
+func (m *model) View() string {
+	top := lipgloss.JoinHorizontal(lipgloss.Top, m.disasmView(), m.stateView())
+	return lipgloss.JoinVertical(lipgloss.Left, top, m.transcriptView(), m.statusView())
+}
+
+func (m *model) disasmView() string {
+	var b strings.Builder
+	if m.state == nil {
+		b.WriteString(titleStyle.Render("(running)"))
+		return boxStyle.Width(70).Height(disasmHeight + 1).Render(b.String())
+	}
+	node := m.state.Node
+	b.WriteString(titleStyle.Render(fmt.Sprintf("%s — paused (%v)", node.Name, m.state.Reason)))
+	b.WriteByte('\n')
+
+	labels := make(map[int]string)
+	for l, pc := range node.Labels {
+		labels[int(pc)] = l
+	}
+	start := m.cursor - disasmHeight/2
+	if start < 0 {
+		start = 0
+	}
+	end := start + disasmHeight
+	if end > len(node.Instructions) {
+		end = len(node.Instructions)
+	}
+	for pc := start; pc < end; pc++ {
+		marker := "  "
+		if m.dbg.HasBreakpoint(node.Name, pc) {
+			marker = bpStyle.Render("● ")
+		}
+		row := fmt.Sprintf("%8s %06d %s", labels[pc], pc, yarn.FormatInstruction(node.Instructions[pc]))
+		if len(row) > 64 {
+			row = row[:63] + "…"
+		}
+		switch {
+		case pc == m.cursor:
+			row = cursorStyle.Render(row)
+		case pc == m.state.PC:
+			row = pcStyle.Render(row)
+		}
+		if pc == m.state.PC {
+			marker = pcStyle.Render("▶ ")
+		}
+		b.WriteString(marker + row + "\n")
+	}
+	return boxStyle.Width(70).Height(disasmHeight + 1).Render(b.String())
+}
+
+func (m *model) stateView() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Stack"))
+	b.WriteByte('\n')
+	if m.state != nil {
+		for i := len(m.state.Stack) - 1; i >= 0; i-- {
+			fmt.Fprintf(&b, "  %s\n", formatValue(m.state.Stack[i]))
+		}
+	}
+	b.WriteString(titleStyle.Render("Variables"))
+	b.WriteByte('\n')
+	vars := m.vars.Contents()
+	names := make([]string, 0, len(vars))
+	for n := range vars {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		fmt.Fprintf(&b, "  %s = %s\n", n, formatValue(vars[n]))
+	}
+	return boxStyle.Width(40).Height(disasmHeight + 1).Render(b.String())
+}
+
+func (m *model) transcriptView() string {
+	lines := m.transcript
+	if len(lines) > transcriptHeight {
+		lines = lines[len(lines)-transcriptHeight:]
+	}
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Transcript"))
+	for _, l := range lines {
+		b.WriteString("\n" + l)
+	}
+	for i, opt := range m.options {
+		text := m.optTexts[i]
+		if !opt.IsAvailable {
+			text = helpStyle.Render(text + " [unavailable]")
+		}
+		fmt.Fprintf(&b, "\n  %d: %s", i+1, text)
+	}
+	return boxStyle.Width(112).Render(b.String())
+}
+
+func (m *model) statusView() string {
+	if m.err != nil {
+		return bpStyle.Render(fmt.Sprintf("VM error: %v", m.err))
+	}
+	return helpStyle.Render("s/n step · c continue · p pause · ↑/↓ move · b breakpoint · 1-9 choose · q quit")
+}
+
+func formatValue(x any) string {
+	switch x.(type) {
+	case string:
+		return fmt.Sprintf("%q", x)
+	case nil:
+		return "null"
+	}
+	return yarn.ConvertToString(x)
+}
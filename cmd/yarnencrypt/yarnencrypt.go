@@ -0,0 +1,82 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnencrypt binary encrypts a file (usually a .yarnc) in the format
+// read by yarn.LoadEncryptedProgram. The key is given in hex, and must be 16,
+// 24, or 32 bytes long.
+//
+// Quick usage from the root of the repo:
+//
+//	go run -tags example cmd/yarnencrypt/yarnencrypt.go \
+//	    --key=000102030405060708090a0b0c0d0e0f \
+//	    testdata/Example.yarnc > Example.yarnc.enc
+//
+// Use --decrypt to reverse the process.
+package main
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+func main() {
+	keyHex := flag.String("key", "", "Encryption key, in hex")
+	decrypt := flag.Bool("decrypt", false, "Decrypt instead of encrypting")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "Usage: yarnencrypt --key=HEX [--decrypt] FILE")
+		os.Exit(1)
+	}
+	key, err := hex.DecodeString(*keyHex)
+	if err != nil {
+		log.Fatalf("Invalid key: %v", err)
+	}
+	in, err := os.Open(flag.Arg(0))
+	if err != nil {
+		log.Fatalf("Couldn't open input: %v", err)
+	}
+	defer in.Close()
+
+	if *decrypt {
+		r, err := yarn.NewDecryptReader(in, key)
+		if err != nil {
+			log.Fatalf("Couldn't decrypt: %v", err)
+		}
+		if _, err := io.Copy(os.Stdout, r); err != nil {
+			log.Fatalf("Couldn't decrypt: %v", err)
+		}
+		return
+	}
+
+	w, err := yarn.NewEncryptWriter(os.Stdout, key)
+	if err != nil {
+		log.Fatalf("Couldn't encrypt: %v", err)
+	}
+	if _, err := io.Copy(w, in); err != nil {
+		log.Fatalf("Couldn't encrypt: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatalf("Couldn't encrypt: %v", err)
+	}
+}
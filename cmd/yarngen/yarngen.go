@@ -0,0 +1,69 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarngen binary generates a command handler from a Go interface (see
+// package yarngen for the supported methods). It is intended for use with go
+// generate, next to the interface:
+//
+//	//go:generate go run -tags example github.com/DrJosh9000/yarn/cmd/yarngen -type=Commands
+//	type Commands interface {
+//		Give(item string, count int) error
+//	}
+//
+// This writes commands_yarngen.go, containing CommandsHandler.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrJosh9000/yarn/yarngen"
+)
+
+func main() {
+	typeName := flag.String("type", "", "Name of the interface describing the commands")
+	output := flag.String("output", "", "Output file (default: <type>_yarngen.go in lower case, in the package directory)")
+	flag.Parse()
+
+	if *typeName == "" || flag.NArg() > 1 {
+		fmt.Fprintln(os.Stderr, "Usage: yarngen -type=NAME [-output=FILE] [PACKAGE_DIR]")
+		os.Exit(1)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	pkg, cmds, err := yarngen.Commands(dir, *typeName)
+	if err != nil {
+		log.Fatalf("Couldn't read commands: %v", err)
+	}
+	src, err := yarngen.Generate(pkg, *typeName, cmds)
+	if err != nil {
+		log.Fatalf("Couldn't generate handler: %v", err)
+	}
+	out := *output
+	if out == "" {
+		out = filepath.Join(dir, strings.ToLower(*typeName)+"_yarngen.go")
+	}
+	if err := os.WriteFile(out, src, 0o644); err != nil {
+		log.Fatalf("Couldn't write handler: %v", err)
+	}
+}
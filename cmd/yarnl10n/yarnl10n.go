@@ -0,0 +1,149 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnl10n binary syncs string tables with Crowdin or Weblate, for use
+// in a content pipeline. "push" uploads the base string table, and "pull"
+// downloads translations, writing a string table for each locale next to
+// the base table (e.g. Example-fr-Lines.csv, with a copy of the metadata
+// table as Example-fr-Metadata.csv, so it can be loaded with
+// yarn.LoadStringTableFile).
+//
+// The API token is read from the YARNL10N_TOKEN environment variable.
+//
+// Quick usage from the root of the repo:
+//
+//	YARNL10N_TOKEN=... go run -tags example ./cmd/yarnl10n \
+//	    --backend=weblate --url=https://hosted.weblate.org/api/ --project=game \
+//	    push testdata/Example-Lines.csv
+//
+//	YARNL10N_TOKEN=... go run -tags example ./cmd/yarnl10n \
+//	    --backend=weblate --url=https://hosted.weblate.org/api/ --project=game \
+//	    --locales=fr,de --min-state=reviewed \
+//	    pull testdata/Example-Lines.csv
+//
+// The resource (Crowdin file, or Weblate component) is named after the
+// string table, e.g. "Example"; use --resource to override it.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/DrJosh9000/yarn/l10nsync"
+)
+
+var states = map[string]yarn.TranslationState{
+	"new":        yarn.TranslationNew,
+	"translated": yarn.TranslationTranslated,
+	"reviewed":   yarn.TranslationReviewed,
+	"final":      yarn.TranslationFinal,
+}
+
+func main() {
+	backendName := flag.String("backend", "crowdin", "Translation service: crowdin or weblate")
+	baseURL := flag.String("url", "", "API base URL (optional for Crowdin)")
+	project := flag.String("project", "", "Project: the numeric ID for Crowdin, or the slug for Weblate")
+	resource := flag.String("resource", "", "Resource name (default: the string table name)")
+	langCode := flag.String("lang", "en", "Language code of the base string table")
+	locales := flag.String("locales", "", "Comma-separated locales to pull")
+	minState := flag.String("min-state", "translated", "Least translation state to pull: new, translated, reviewed, or final")
+	xliff2 := flag.Bool("xliff2", false, "Push XLIFF 2.0 instead of 1.2")
+	flag.Parse()
+
+	if flag.NArg() != 2 || (flag.Arg(0) != "push" && flag.Arg(0) != "pull") {
+		fmt.Fprintln(os.Stderr, "Usage: yarnl10n [flags] push|pull STRINGTABLE-Lines.csv")
+		os.Exit(1)
+	}
+	token := os.Getenv("YARNL10N_TOKEN")
+
+	var backend l10nsync.Backend
+	switch *backendName {
+	case "crowdin":
+		id, err := strconv.Atoi(*project)
+		if err != nil {
+			log.Fatalf("Couldn't parse Crowdin project ID: %v", err)
+		}
+		backend = &l10nsync.Crowdin{BaseURL: *baseURL, Token: token, ProjectID: id}
+	case "weblate":
+		backend = &l10nsync.Weblate{BaseURL: *baseURL, Token: token, Project: *project, SourceLanguage: *langCode}
+	default:
+		log.Fatalf("Unknown backend %q", *backendName)
+	}
+	state, ok := states[*minState]
+	if !ok {
+		log.Fatalf("Unknown translation state %q", *minState)
+	}
+	s := &l10nsync.Syncer{Backend: backend, MinState: state}
+	if *xliff2 {
+		s.Version = yarn.XLIFF20
+	}
+
+	stPath := flag.Arg(1)
+	base := strings.TrimSuffix(stPath, "-Lines.csv")
+	if *resource == "" {
+		*resource = filepath.Base(base)
+	}
+	st, err := yarn.LoadStringTableFile(stPath, *langCode)
+	if err != nil {
+		log.Fatalf("Couldn't load string table: %v", err)
+	}
+
+	ctx := context.Background()
+	if flag.Arg(0) == "push" {
+		if err := s.Push(ctx, *resource, st); err != nil {
+			log.Fatalf("Couldn't push: %v", err)
+		}
+		return
+	}
+
+	if *locales == "" {
+		log.Fatal("No --locales to pull")
+	}
+	tables, err := s.Pull(ctx, *resource, st, strings.Split(*locales, ",")...)
+	if err != nil {
+		log.Fatalf("Couldn't pull: %v", err)
+	}
+	metadata, err := os.ReadFile(base + "-Metadata.csv")
+	if err != nil {
+		log.Fatalf("Couldn't read metadata table: %v", err)
+	}
+	for locale, lst := range tables {
+		out := fmt.Sprintf("%s-%s", base, locale)
+		f, err := os.Create(out + "-Lines.csv")
+		if err != nil {
+			log.Fatalf("Couldn't create string table: %v", err)
+		}
+		if err := yarn.WriteStringTable(f, lst); err != nil {
+			log.Fatalf("Couldn't write string table: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatalf("Couldn't write string table: %v", err)
+		}
+		if err := os.WriteFile(out+"-Metadata.csv", metadata, 0o644); err != nil {
+			log.Fatalf("Couldn't write metadata table: %v", err)
+		}
+		cov := lst.Coverage(st)
+		log.Printf("Wrote %s-Lines.csv: %.1f%% translated, %d outdated, %d missing", out, cov.Percent(cov.Translated), cov.Outdated, cov.Missing)
+	}
+}
@@ -0,0 +1,123 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnplaydiff binary compares playthroughs, for QA of content changes.
+// Playthroughs are event logs, as written by yarn.EventLogHandler. It prints
+// a diff of the lines, options, and commands shown, and exits with status 1
+// if there are any differences.
+//
+// To compare two recorded playthroughs:
+//
+//	go run -tags example cmd/yarnplaydiff/yarnplaydiff.go \
+//	    --strings=Dialogue-Lines.csv old.jsonl new.jsonl
+//
+// To replay the choices of one playthrough against two content builds (each
+// with a -Lines.csv file next to it):
+//
+//	go run -tags example cmd/yarnplaydiff/yarnplaydiff.go \
+//	    --old=v1/Dialogue.yarnc --new=v2/Dialogue.yarnc play.jsonl
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+func main() {
+	oldPath := flag.String("old", "", "Old compiled program to replay the playthrough against")
+	newPath := flag.String("new", "", "New compiled program to replay the playthrough against")
+	stringsPath := flag.String("strings", "", "String table for rendering two recorded playthroughs (optional)")
+	langCode := flag.String("lang", "en", "Language tag (BCP 47) of the string tables")
+	context := flag.Int("context", 3, "Number of unchanged lines to show around each change")
+	flag.Parse()
+
+	replay := *oldPath != "" || *newPath != ""
+	if (replay && (*oldPath == "" || *newPath == "" || flag.NArg() != 1)) || (!replay && flag.NArg() != 2) {
+		fmt.Fprintln(os.Stderr, "Usage: yarnplaydiff [--strings=LINES_CSV] OLD_LOG NEW_LOG")
+		fmt.Fprintln(os.Stderr, "       yarnplaydiff --old=OLD_YARNC --new=NEW_YARNC LOG")
+		os.Exit(1)
+	}
+
+	var oldTr, newTr []string
+	if replay {
+		recorded := readLog(flag.Arg(0))
+		oldTr = replayAgainst(*oldPath, *langCode, recorded)
+		newTr = replayAgainst(*newPath, *langCode, recorded)
+	} else {
+		var st *yarn.StringTable
+		if *stringsPath != "" {
+			var err error
+			st, err = yarn.LoadStringTableFile(*stringsPath, *langCode)
+			if err != nil {
+				log.Fatalf("Couldn't load string table: %v", err)
+			}
+		}
+		oldTr = yarn.PlaythroughTranscript(readLog(flag.Arg(0)), st)
+		newTr = yarn.PlaythroughTranscript(readLog(flag.Arg(1)), st)
+	}
+
+	edits := yarn.DiffTranscripts(oldTr, newTr)
+	if err := yarn.WriteTranscriptDiff(os.Stdout, edits, *context); err != nil {
+		log.Fatalf("Couldn't write diff: %v", err)
+	}
+	for _, e := range edits {
+		if e.Op != ' ' {
+			os.Exit(1)
+		}
+	}
+}
+
+func readLog(path string) []yarn.EventLogEntry {
+	f, err := os.Open(path)
+	if err != nil {
+		log.Fatalf("Couldn't open event log: %v", err)
+	}
+	defer f.Close()
+	entries, err := yarn.ReadEventLog(f)
+	if err != nil {
+		log.Fatalf("Couldn't read event log: %v", err)
+	}
+	return entries
+}
+
+// replayAgainst replays the playthrough against a program, and returns the
+// transcript. If the replay fails, the transcript ends with the error.
+func replayAgainst(progPath, langCode string, recorded []yarn.EventLogEntry) []string {
+	prog, st, err := yarn.LoadFiles(progPath, langCode)
+	if err != nil {
+		log.Fatalf("Couldn't load program: %v", err)
+	}
+	vm := &yarn.VirtualMachine{
+		Program: prog,
+		Vars:    yarn.NewMapVariableStorage(),
+	}
+	replayed, err := yarn.ReplayPlaythrough(vm, recorded)
+	if err != nil && replayed == nil {
+		log.Fatalf("Couldn't replay playthrough against %s: %v", progPath, err)
+	}
+	tr := yarn.PlaythroughTranscript(replayed, st)
+	if err != nil && !errors.Is(err, yarn.ErrReplayDiverged) {
+		// Divergence is already in the transcript, as an Options error.
+		tr = append(tr, "! "+err.Error())
+	}
+	return tr
+}
@@ -0,0 +1,111 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnrename binary renames a variable or node throughout a compiled
+// program and its string table (the -Lines.csv file next to it, if there is
+// one), and rewrites both files. It prints each edit as it goes.
+//
+// Quick usage from the root of the repo:
+//
+//	go run -tags example cmd/yarnrename/yarnrename.go \
+//	    --node=Start --to=Beginning --dry-run testdata/Example.yarnc
+//
+// Use --var instead of --node to rename a variable. With --dry-run, the edits
+// are printed but no files are written.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+	"google.golang.org/protobuf/proto"
+)
+
+func main() {
+	varName := flag.String("var", "", "Variable to rename (including the $)")
+	nodeName := flag.String("node", "", "Node to rename")
+	to := flag.String("to", "", "New name")
+	langCode := flag.String("lang", "en", "Language tag (BCP 47) of the string table")
+	dryRun := flag.Bool("dry-run", false, "Print the edits without writing any files")
+	flag.Parse()
+
+	if flag.NArg() != 1 || *to == "" || (*varName == "") == (*nodeName == "") {
+		fmt.Fprintln(os.Stderr, "Usage: yarnrename (--var=$NAME | --node=NAME) --to=NEWNAME [--dry-run] YARNC_FILE")
+		os.Exit(1)
+	}
+	progPath := flag.Arg(0)
+	prog, err := yarn.LoadProgramFile(progPath)
+	if err != nil {
+		log.Fatalf("Couldn't load program: %v", err)
+	}
+
+	stPath := strings.TrimSuffix(progPath, ".yarnc") + "-Lines.csv"
+	var st *yarn.StringTable
+	switch csv, err := os.ReadFile(stPath); {
+	case errors.Is(err, fs.ErrNotExist):
+		// No string table, so only the program is renamed.
+	case err != nil:
+		log.Fatalf("Couldn't read string table: %v", err)
+	default:
+		st, err = yarn.ReadStringTable(bytes.NewReader(csv), *langCode)
+		if err != nil {
+			log.Fatalf("Couldn't read string table: %v", err)
+		}
+	}
+
+	var res *yarn.RenameResult
+	if *varName != "" {
+		res, err = yarn.RenameVariable(prog, st, *varName, *to)
+	} else {
+		res, err = yarn.RenameNode(prog, st, *nodeName, *to)
+	}
+	if err != nil {
+		log.Fatalf("Couldn't rename: %v", err)
+	}
+	for _, e := range res.Edits {
+		fmt.Println(e)
+	}
+	fmt.Printf("%d edits\n", len(res.Edits))
+	if *dryRun || len(res.Edits) == 0 {
+		return
+	}
+
+	yarnc, err := proto.Marshal(res.Program)
+	if err != nil {
+		log.Fatalf("Couldn't marshal program: %v", err)
+	}
+	if err := os.WriteFile(progPath, yarnc, 0o644); err != nil {
+		log.Fatalf("Couldn't write program: %v", err)
+	}
+	if res.Strings == nil {
+		return
+	}
+	var buf bytes.Buffer
+	if err := yarn.WriteStringTable(&buf, res.Strings); err != nil {
+		log.Fatalf("Couldn't write string table: %v", err)
+	}
+	if err := os.WriteFile(stPath, buf.Bytes(), 0o644); err != nil {
+		log.Fatalf("Couldn't write string table: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// action is something the player can do.
+type action int
+
+const (
+	actNone     action = iota
+	actContinue        // go on to the next line
+	actUp              // move the option cursor up
+	actDown            // move the option cursor down
+	actConfirm         // choose the option at the cursor
+	actRepeat          // show the current line or options again
+	actQuit            // stop the dialogue
+)
+
+var actionNames = map[string]action{
+	"continue": actContinue,
+	"up":       actUp,
+	"down":     actDown,
+	"confirm":  actConfirm,
+	"repeat":   actRepeat,
+	"quit":     actQuit,
+}
+
+// defaultKeys are the default bindings, in the same format as the --keys
+// flag. Keyboard keys and controller buttons can be mixed freely.
+const defaultKeys = "continue=enter,space,a;up=k,w,dpad_up;down=j,s,dpad_down;confirm=enter,space,a;repeat=r,y;quit=q,start"
+
+// keymap maps input names (keys or buttons) to actions. An input can be bound
+// to one action while choosing options, and another while reading lines
+// (e.g. enter both continues and confirms).
+type keymap map[string][]action
+
+// parseKeymap parses bindings of the form "action=input,input;action=...".
+func parseKeymap(s string) (keymap, error) {
+	km := make(keymap)
+	for _, binding := range strings.Split(s, ";") {
+		binding = strings.TrimSpace(binding)
+		if binding == "" {
+			continue
+		}
+		name, inputs, ok := strings.Cut(binding, "=")
+		if !ok {
+			return nil, fmt.Errorf("binding %q is not of the form action=input,...", binding)
+		}
+		act, ok := actionNames[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown action %q", name)
+		}
+		for _, in := range strings.Split(inputs, ",") {
+			in = strings.ToLower(strings.TrimSpace(in))
+			if in == "" {
+				continue
+			}
+			km[in] = append(km[in], act)
+		}
+	}
+	return km, nil
+}
+
+// lookup returns the action bound to an input, preferring one of the wanted
+// actions (those that make sense right now).
+func (km keymap) lookup(input string, wanted ...action) action {
+	acts := km[input]
+	for _, a := range acts {
+		for _, w := range wanted {
+			if a == w {
+				return a
+			}
+		}
+	}
+	return actNone
+}
+
+// inputSource is a source of player input. Each input is a name: a key such
+// as "enter" or "k", or a controller button such as "dpad_up". Numbers
+// choose options directly.
+type inputSource interface {
+	Next() (string, error)
+}
+
+// lineInput reads one input per line. It works for a terminal (where each key
+// is followed by ENTER, and an empty line is "enter"), and for a stream of
+// button names from a controller bridge (e.g. a named pipe).
+type lineInput struct {
+	sc *bufio.Scanner
+}
+
+func newLineInput(r io.Reader) *lineInput {
+	return &lineInput{sc: bufio.NewScanner(r)}
+}
+
+func (in *lineInput) Next() (string, error) {
+	if !in.sc.Scan() {
+		if err := in.sc.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	s := strings.ToLower(strings.TrimSpace(in.sc.Text()))
+	if s == "" {
+		return "enter", nil
+	}
+	return s, nil
+}
+
+// mergedInput takes input from several sources, e.g. keyboard and
+// controller, whichever comes first. It returns io.EOF once every source
+// has ended.
+type mergedInput struct {
+	ch      chan inputResult
+	running int
+}
+
+type inputResult struct {
+	input string
+	err   error
+}
+
+func mergeInputs(srcs ...inputSource) *mergedInput {
+	m := &mergedInput{ch: make(chan inputResult), running: len(srcs)}
+	for _, src := range srcs {
+		go func(src inputSource) {
+			for {
+				s, err := src.Next()
+				m.ch <- inputResult{s, err}
+				if err != nil {
+					return
+				}
+			}
+		}(src)
+	}
+	return m
+}
+
+func (m *mergedInput) Next() (string, error) {
+	for m.running > 0 {
+		r := <-m.ch
+		if r.err == io.EOF {
+			m.running--
+			continue
+		}
+		return r.input, r.err
+	}
+	return "", io.EOF
+}
+
+// choiceNumber returns the option number typed, if the input is a number.
+func choiceNumber(input string) (int, bool) {
+	n, err := strconv.Atoi(input)
+	return n, err == nil
+}
@@ -20,18 +20,37 @@
 //
 // Quick usage from the root of the repo:
 //
-//	go run -tags example cmd/yarnrunner/yarnrunner.go \
+//	go run -tags example ./cmd/yarnrunner \
 //	    --program=cmd/yarnrunner/terminal.yarn.yarnc
 //
+// Input is read a line at a time: press ENTER to continue, type a number to
+// choose an option, or move the option cursor with k/j (up/down) and ENTER
+// to confirm. The bindings can be changed with --keys, e.g.
+//
+//	--keys='continue=enter;up=w;down=s;confirm=enter;repeat=r;quit=q'
+//
+// A controller can be used through a bridge program that writes button names
+// (e.g. "dpad_up", "a"), one per line, to a file or named pipe given with
+// --controller. The default bindings include common button names.
+//
+// --wrap wraps lines and options to a number of columns (e.g. --wrap=80).
+//
+// --screenreader prints plain text without escape sequences or cursor
+// movement, announces speakers and option positions, and marks the default
+// option (tagged #default), which is also where the option cursor starts.
+//
 // The "example" build tag is used to prevent this being installed to ~/go/bin
 // if you use the go get command. If for some reason you want to install it to
-// your ~/go/bin, use `go install -tags example cmd/yarnrunner.go` or similar.
+// your ~/go/bin, use `go install -tags example ./cmd/yarnrunner` or similar.
 package main
 
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"strings"
 
 	"github.com/DrJosh9000/yarn"
 )
@@ -40,17 +59,41 @@ func main() {
 	yarncFilename := flag.String("program", "", "File name of program (e.g. Example.yarn.yarnc)")
 	startNode := flag.String("start", "Start", "Name of the node to run")
 	langCode := flag.String("lang", "en-AU", "Language tag (BCP 47)")
+	keys := flag.String("keys", defaultKeys, "Key and button bindings, as action=input,...;action=... (actions: continue, up, down, confirm, repeat, quit)")
+	controller := flag.String("controller", "", "File or named pipe to read controller button names from, one per line")
+	screenReader := flag.Bool("screenreader", false, "Print plain, screen-reader-friendly output")
+	wrap := flag.Int("wrap", 0, "Wrap lines and options to this many columns (0 for no wrapping)")
 	flag.Parse()
 
 	program, stringTable, err := yarn.LoadFiles(*yarncFilename, *langCode)
 	if err != nil {
 		log.Fatalf("Loading files: %v", err)
 	}
+	km, err := parseKeymap(*keys)
+	if err != nil {
+		log.Fatalf("Couldn't parse --keys: %v", err)
+	}
+	inputs := []inputSource{newLineInput(os.Stdin)}
+	if *controller != "" {
+		f, err := os.Open(*controller)
+		if err != nil {
+			log.Fatalf("Couldn't open controller input: %v", err)
+		}
+		defer f.Close()
+		inputs = append(inputs, newLineInput(f))
+	}
 
 	vm := &yarn.VirtualMachine{
 		Program: program,
-		Handler: &dialogueHandler{
-			stringTable: stringTable,
+		Handler: &yarn.DefaultOptionHandler{
+			DialogueHandler: &dialogueHandler{
+				stringTable:  stringTable,
+				keymap:       km,
+				input:        mergeInputs(inputs...),
+				screenReader: *screenReader,
+				wrap:         *wrap,
+			},
+			StringTable: stringTable,
 		},
 		Vars: yarn.NewMapVariableStorage(),
 	}
@@ -62,44 +105,170 @@ func main() {
 // dialogueHandler implements yarn.DialogueHandler by playing the lines and
 // options on the terminal.
 type dialogueHandler struct {
-	stringTable *yarn.StringTable
+	stringTable  *yarn.StringTable
+	keymap       keymap
+	input        inputSource
+	screenReader bool
+	wrap         int
 
 	yarn.FakeDialogueHandler // implements remaining methods
 }
 
+// next waits for an input bound to one of the wanted actions, or a number if
+// numbers is true. The number is -1 if no number was entered. It returns
+// yarn.Stop when the player quits or the input ends.
+func (h *dialogueHandler) next(numbers bool, wanted ...action) (action, int, error) {
+	wanted = append(wanted, actQuit)
+	for {
+		in, err := h.input.Next()
+		if err == io.EOF {
+			return actQuit, -1, yarn.Stop
+		}
+		if err != nil {
+			return actNone, -1, err
+		}
+		if n, ok := choiceNumber(in); ok && numbers {
+			return actConfirm, n, nil
+		}
+		switch act := h.keymap.lookup(in, wanted...); act {
+		case actNone:
+			if h.screenReader {
+				fmt.Printf("%q does nothing here.\n", in)
+			}
+		case actQuit:
+			return act, -1, yarn.Stop
+		default:
+			return act, -1, nil
+		}
+	}
+}
+
 func (h *dialogueHandler) Line(line yarn.Line) error {
-	text, err := h.stringTable.Render(line)
+	for {
+		if err := h.printLine(line); err != nil {
+			return err
+		}
+		if !h.screenReader {
+			fmt.Print("(Press ENTER to continue)")
+		}
+		act, _, err := h.next(false, actContinue, actRepeat)
+		if err != nil {
+			return err
+		}
+		if !h.screenReader {
+			// This next string is VT100 for "move to the first column, go up a
+			// line, and erase it" (erasing the Press ENTER message).
+			fmt.Print("\r\033[A\033[2K")
+		}
+		if act == actContinue {
+			return nil
+		}
+	}
+}
+
+func (h *dialogueHandler) printLine(line yarn.Line) error {
+	if !h.screenReader {
+		text, err := h.stringTable.Render(line)
+		if err != nil {
+			return err
+		}
+		h.printWrapped(text, "")
+		return nil
+	}
+	al, err := line.Accessible(h.stringTable)
 	if err != nil {
 		return err
 	}
-	fancyPrintln(text)
-	fmt.Print("(Press ENTER to continue)")
-	fmt.Scanln()
-	// This next string is VT100 for "move to the first column, go up a line,
-	// and erase it" (erasing the Press ENTER message).
-	fmt.Print("\r\033[A\033[2K")
+	if al.Speaker != "" {
+		fmt.Printf("%s says: %s\n", al.Speaker, al.Text)
+		return nil
+	}
+	fmt.Println(al.Text)
 	return nil
 }
 
 func (h *dialogueHandler) Options(opts []yarn.Option) (int, error) {
+	cursor := max(0, yarn.DefaultOption(opts))
+	for {
+		if err := h.printOptions(opts, cursor); err != nil {
+			return 0, err
+		}
+		act, n, err := h.next(true, actUp, actDown, actConfirm, actRepeat)
+		if err != nil {
+			return 0, err
+		}
+		switch act {
+		case actUp:
+			cursor = (cursor + len(opts) - 1) % len(opts)
+		case actDown:
+			cursor = (cursor + 1) % len(opts)
+		case actConfirm:
+			id := opts[cursor].ID
+			if n >= 0 {
+				// Options are numbered from 1 on screen.
+				if n < 1 || n > len(opts) {
+					fmt.Printf("There is no option %d.\n", n)
+					continue
+				}
+				id = opts[n-1].ID
+			}
+			return id, nil
+		}
+	}
+}
+
+func (h *dialogueHandler) printOptions(opts []yarn.Option, cursor int) error {
+	if h.screenReader {
+		al, err := opts[cursor].Line.Accessible(h.stringTable)
+		if err != nil {
+			return err
+		}
+		var notes []string
+		if opts[cursor].IsDefault {
+			notes = append(notes, "default")
+		}
+		if !opts[cursor].IsAvailable {
+			notes = append(notes, "unavailable")
+		}
+		note := ""
+		if len(notes) > 0 {
+			note = " (" + strings.Join(notes, ", ") + ")"
+		}
+		fmt.Printf("Option %d of %d%s: %s\n", cursor+1, len(opts), note, al.Text)
+		return nil
+	}
 	fmt.Println("Choose:")
-	for _, opt := range opts {
+	for i, opt := range opts {
 		text, err := h.stringTable.Render(opt.Line)
 		if err != nil {
-			return 0, err
+			return err
+		}
+		marker := "  "
+		if i == cursor {
+			marker = "> "
 		}
-		fmt.Printf("%d: ", opt.ID)
+		prefix := fmt.Sprintf("%s%d: ", marker, i+1)
+		fmt.Print(prefix)
+		h.printWrapped(text, strings.Repeat(" ", len(prefix)))
+	}
+	fmt.Print("Enter a number, or move with up/down and confirm: ")
+	return nil
+}
+
+// printWrapped prints an attributed string with fancyPrintln, wrapped to
+// h.wrap columns (if set). The first line is assumed to follow something as
+// wide as indent, which is printed before each subsequent line.
+func (h *dialogueHandler) printWrapped(text *yarn.AttributedString, indent string) {
+	if h.wrap <= 0 {
 		fancyPrintln(text)
+		return
 	}
-	var choice int
-	for {
-		fmt.Print("Enter the number corresponding to your choice: ")
-		if _, err := fmt.Scanln(&choice); err != nil {
-			continue
+	for i, line := range text.Wrap(max(1, h.wrap-len(indent))) {
+		if i > 0 {
+			fmt.Print(indent)
 		}
-		break
+		fancyPrintln(line)
 	}
-	return choice, nil
 }
 
 // fancyPrintln prints an attributed string with ANSI escape sequences that
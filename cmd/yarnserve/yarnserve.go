@@ -0,0 +1,82 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnserve binary serves a yarnc+string table combo as an external
+// dialogue service, using the JSON Lines protocol from the bridge package.
+// By default it speaks the protocol on stdin/stdout; with --listen it accepts
+// TCP connections instead (one at a time, each with fresh variable storage).
+//
+// Quick usage from the root of the repo:
+//
+//	go run -tags example cmd/yarnserve/yarnserve.go \
+//	    --program=cmd/yarnrunner/terminal.yarn.yarnc \
+//	    --listen=localhost:7777
+//
+// A reference Godot client is in bridge/godot/yarn_bridge.gd.
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+	"os"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/DrJosh9000/yarn/bridge"
+)
+
+func main() {
+	yarncFilename := flag.String("program", "", "File name of program (e.g. Example.yarn.yarnc)")
+	langCode := flag.String("lang", "en-AU", "Language tag (BCP 47)")
+	listen := flag.String("listen", "", "TCP address to listen on (if empty, use stdin/stdout)")
+	flag.Parse()
+
+	program, stringTable, err := yarn.LoadFiles(*yarncFilename, *langCode)
+	if err != nil {
+		log.Fatalf("Loading files: %v", err)
+	}
+	newServer := func() *bridge.Server {
+		return &bridge.Server{
+			Program:     program,
+			StringTable: stringTable,
+		}
+	}
+
+	if *listen == "" {
+		if err := newServer().Serve(os.Stdin, os.Stdout); err != nil {
+			log.Fatalf("Serving stdin/stdout: %v", err)
+		}
+		return
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		log.Fatalf("Listening: %v", err)
+	}
+	log.Printf("Listening on %v", ln.Addr())
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			log.Fatalf("Accepting connection: %v", err)
+		}
+		log.Printf("Serving %v", conn.RemoteAddr())
+		if err := newServer().Serve(conn, conn); err != nil {
+			log.Printf("Serving %v: %v", conn.RemoteAddr(), err)
+		}
+		conn.Close()
+	}
+}
@@ -0,0 +1,106 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarntimeline binary dry-runs a node and writes the commands it runs
+// (with timing from <<wait>> commands) as a JSON timeline, for baking into
+// engine-native cutscene timelines. With --format=srt or --format=vtt, it
+// writes the lines as subtitles instead (run it once per locale, with
+// --strings and --lang).
+//
+// Quick usage from the root of the repo:
+//
+//	go run -tags example cmd/yarntimeline/yarntimeline.go \
+//	    --program=testdata/Commands.yarnc \
+//	    --node=Start
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+func main() {
+	yarncFilename := flag.String("program", "", "File name of program (e.g. Example.yarnc)")
+	csvFilename := flag.String("strings", "", "File name of string table (optional; default is derived from --program)")
+	langCode := flag.String("lang", "en", "Language tag (BCP 47) of the string table")
+	startNode := flag.String("node", "Start", "Name of the node to export")
+	waitCmd := flag.String("wait", yarn.DefaultWaitCommand, "Name of the command that advances time")
+	lineSecs := flag.Float64("line-duration", 0, "Seconds taken by each line")
+	format := flag.String("format", "json", "Output format: json, srt, or vtt")
+	flag.Parse()
+
+	prog, err := yarn.LoadProgramFile(*yarncFilename)
+	if err != nil {
+		log.Fatalf("Couldn't load program: %v", err)
+	}
+
+	e := &yarn.TimelineExporter{
+		Program:     prog,
+		WaitCommand: *waitCmd,
+		FuncMap:     make(yarn.FuncMap),
+	}
+	// Stub out any custom functions, since there is no game to provide them.
+	for _, name := range yarn.UndefinedFunctions(prog, nil) {
+		e.FuncMap[name] = func(...any) any { return nil }
+	}
+	if *lineSecs > 0 {
+		e.LineDuration = func(yarn.Line) float64 { return *lineSecs }
+	}
+
+	stPath := *csvFilename
+	if stPath == "" {
+		stPath = strings.TrimSuffix(*yarncFilename, ".yarnc") + "-Lines.csv"
+	}
+	if st, err := yarn.LoadStringTableFile(stPath, *langCode); err == nil {
+		e.StringTable = st
+	} else if *csvFilename != "" {
+		log.Fatalf("Couldn't load string table: %v", err)
+	}
+
+	writeSubs := map[string]func(io.Writer, []yarn.Cue, *yarn.StringTable) error{
+		"srt": yarn.WriteSRT,
+		"vtt": yarn.WriteWebVTT,
+	}[*format]
+	if *format != "json" && writeSubs == nil {
+		log.Fatalf("Unknown format %q", *format)
+	}
+	if writeSubs != nil && e.StringTable == nil {
+		log.Fatalf("Format %q needs a string table", *format)
+	}
+
+	tl, err := e.Export(*startNode)
+	if err != nil {
+		log.Fatalf("Couldn't export timeline: %v", err)
+	}
+	if writeSubs != nil {
+		if err := writeSubs(os.Stdout, yarn.TimelineCues(tl), e.StringTable); err != nil {
+			log.Fatalf("Couldn't write subtitles: %v", err)
+		}
+		return
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(tl); err != nil {
+		log.Fatalf("Couldn't encode timeline: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"plugin"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+	// Packages providing passes register them with yarn.RegisterPass when
+	// imported. To build yarnverify with your own passes, import them here:
+	//
+	//	_ "example.com/studio/yarnpasses"
+)
+
+// stringList is a flag that can be repeated.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
+}
+
+// loadPlugins opens Go plugins (built with -buildmode=plugin), whose init
+// functions register their passes.
+func loadPlugins(paths []string) error {
+	for _, path := range paths {
+		if _, err := plugin.Open(path); err != nil {
+			return fmt.Errorf("loading plugin: %w", err)
+		}
+	}
+	return nil
+}
+
+// selectPasses returns the registered passes named in a comma-separated
+// list, or all of them if the list is "all".
+func selectPasses(list string) ([]yarn.Pass, error) {
+	if list == "all" {
+		return yarn.RegisteredPasses(), nil
+	}
+	var ps []yarn.Pass
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		p := yarn.LookupPass(name)
+		if p == nil {
+			return nil, fmt.Errorf("no pass named %q is registered", name)
+		}
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
@@ -0,0 +1,255 @@
+//go:build example
+// +build example
+
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The yarnverify binary checks every compiled program in a directory tree,
+// for use in CI pipelines. For each .yarnc file it:
+//
+//   - validates the program structure,
+//   - checks node headers against a schema (if one is given),
+//   - checks the corresponding string table (-Lines.csv and -Metadata.csv),
+//   - checks translated string tables next to it (e.g. -fr-Lines.csv) for
+//     stale lines, whose source text has changed since they were translated,
+//   - checks line, menu, and node lengths against a budget (if one is set),
+//   - runs any registered passes (see yarn.Pass), such as studio-specific
+//     lint rules,
+//   - plays the program a number of times with random choices.
+//
+// Passes are registered by packages imported into this binary (see
+// passes.go), or by Go plugins given with --plugin. --passes chooses which
+// registered passes run.
+//
+// It writes a JSON report to stdout, and exits with status 1 if any errors
+// were found (or 2 if it couldn't run at all).
+//
+// Quick usage from the root of the repo:
+//
+//	go run -tags example ./cmd/yarnverify testdata
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+// Report is the machine-readable output.
+type Report struct {
+	OK    bool          `json:"ok"`
+	Files []*FileReport `json:"files"`
+}
+
+// FileReport contains the diagnostics for one program.
+type FileReport struct {
+	Path        string            `json:"path"`
+	Diagnostics []yarn.Diagnostic `json:"diagnostics"`
+}
+
+func main() {
+	langCode := flag.String("lang", "en", "Language tag (BCP 47) of the string tables")
+	startNode := flag.String("start", "Start", "Name of the node to begin random walks from")
+	walks := flag.Int("walks", 20, "Number of random walks per program")
+	seed := flag.Int64("seed", 1, "Seed for the first random walk (incremented for each walk)")
+	maxEvents := flag.Int("max-events", yarn.DefaultMaxWalkEvents, "Maximum events per random walk")
+	stubFuncs := flag.Bool("stub-funcs", true, "Stub out custom functions (they return null) during random walks")
+	budget := new(yarn.LengthBudget)
+	flag.IntVar(&budget.MaxChars, "max-chars", 0, "Maximum characters per line or option (0 for no limit)")
+	flag.IntVar(&budget.MaxWords, "max-words", 0, "Maximum words per line or option (0 for no limit)")
+	flag.IntVar(&budget.MaxOptions, "max-options", 0, "Maximum options per menu (0 for no limit)")
+	flag.IntVar(&budget.MaxNodeLines, "max-node-lines", 0, "Maximum lines per node (0 for no limit)")
+	flag.IntVar(&budget.MaxNodeWords, "max-node-words", 0, "Maximum words per node (0 for no limit)")
+	flag.TextVar(&budget.Severity, "budget-severity", yarn.SeverityError, "Severity of length budget diagnostics")
+	schemaPath := flag.String("header-schema", "", "JSON file describing the allowed node headers (optional)")
+	passList := flag.String("passes", "all", "Comma-separated names of registered passes to run, or \"all\"")
+	var plugins stringList
+	flag.Var(&plugins, "plugin", "Go plugin (.so) registering additional passes (may be repeated)")
+	flag.Parse()
+
+	if err := loadPlugins(plugins); err != nil {
+		fmt.Fprintf(os.Stderr, "yarnverify: %v\n", err)
+		os.Exit(2)
+	}
+	passes, err := selectPasses(*passList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "yarnverify: %v\n", err)
+		os.Exit(2)
+	}
+	pipeline := &yarn.Pipeline{Passes: passes, StopOnError: true}
+
+	if *schemaPath != "" {
+		f, err := os.Open(*schemaPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "yarnverify: %v\n", err)
+			os.Exit(2)
+		}
+		schema, err := yarn.ReadHeaderSchemaJSON(f)
+		f.Close()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "yarnverify: %v\n", err)
+			os.Exit(2)
+		}
+		yarn.RegisterHeaderSchema(schema)
+	}
+
+	root := "."
+	if flag.NArg() > 0 {
+		root = flag.Arg(0)
+	}
+
+	report := &Report{OK: true}
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".yarnc") {
+			return nil
+		}
+		fr := &FileReport{Path: path}
+		verify(fr, *langCode, *startNode, *walks, *seed, *maxEvents, *stubFuncs, budget, pipeline)
+		if yarn.HasErrors(fr.Diagnostics) {
+			report.OK = false
+		}
+		report.Files = append(report.Files, fr)
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "yarnverify: %v\n", err)
+		os.Exit(2)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "yarnverify: encoding report: %v\n", err)
+		os.Exit(2)
+	}
+	if !report.OK {
+		os.Exit(1)
+	}
+}
+
+func verify(fr *FileReport, langCode, startNode string, walks int, seed int64, maxEvents int, stubFuncs bool, budget *yarn.LengthBudget, pipeline *yarn.Pipeline) {
+	fileErr := func(sev yarn.Severity, format string, args ...any) {
+		fr.Diagnostics = append(fr.Diagnostics, yarn.Diagnostic{
+			Severity: sev,
+			PC:       -1,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	prog, err := yarn.LoadProgramFile(fr.Path)
+	var hse *yarn.HeaderSchemaError
+	if errors.As(err, &hse) {
+		fr.Diagnostics = append(fr.Diagnostics, hse.Diagnostics...)
+		return
+	}
+	if err != nil {
+		fileErr(yarn.SeverityError, "loading program: %v", err)
+		return
+	}
+	fr.Diagnostics = append(fr.Diagnostics, yarn.ValidateProgram(prog)...)
+
+	stPath := strings.TrimSuffix(fr.Path, ".yarnc") + "-Lines.csv"
+	st, err := yarn.LoadStringTableFile(stPath, langCode)
+	if err != nil {
+		fileErr(yarn.SeverityError, "loading string table: %v", err)
+	} else {
+		fr.Diagnostics = append(fr.Diagnostics, yarn.CheckStringTable(prog, st)...)
+		fr.Diagnostics = append(fr.Diagnostics, yarn.CheckLengths(prog, st, budget)...)
+		checkTranslations(fr, st)
+	}
+
+	if len(pipeline.Passes) > 0 {
+		res, err := pipeline.Run(prog, st)
+		fr.Diagnostics = append(fr.Diagnostics, res.Diagnostics...)
+		if err != nil {
+			fileErr(yarn.SeverityError, "running passes: %v", err)
+		}
+	}
+
+	if yarn.HasErrors(fr.Diagnostics) {
+		// Walking a broken program will only produce the same errors.
+		return
+	}
+	if prog.Nodes[startNode] == nil {
+		fileErr(yarn.SeverityInfo, "no %q node, skipping random walks", startNode)
+		return
+	}
+
+	fm := make(yarn.FuncMap)
+	for _, name := range yarn.UndefinedFunctions(prog, nil) {
+		if !stubFuncs {
+			fileErr(yarn.SeverityWarning, "function %q is not provided; random walks may fail", name)
+			continue
+		}
+		fm[name] = func(...any) any { return nil }
+	}
+
+	for i := 0; i < walks; i++ {
+		s := seed + int64(i)
+		w := &yarn.RandomWalker{
+			Program:   prog,
+			FuncMap:   fm,
+			Rand:      rand.New(rand.NewSource(s)),
+			MaxEvents: maxEvents,
+		}
+		res := w.Walk(startNode)
+		switch {
+		case res.Err == nil:
+			// ok
+		case res.IsWalkLimit():
+			fileErr(yarn.SeverityWarning, "random walk (seed %d): %v", s, res.Err)
+		default:
+			fileErr(yarn.SeverityError, "random walk (seed %d): %v", s, res.Err)
+		}
+	}
+}
+
+// checkTranslations checks the translated string tables next to the program
+// (named like Example-fr-Lines.csv) against the base string table.
+func checkTranslations(fr *FileReport, base *yarn.StringTable) {
+	prefix := strings.TrimSuffix(fr.Path, ".yarnc") + "-"
+	paths, err := filepath.Glob(prefix + "*-Lines.csv")
+	if err != nil {
+		return
+	}
+	for _, path := range paths {
+		locale := strings.TrimSuffix(strings.TrimPrefix(path, prefix), "-Lines.csv")
+		f, err := os.Open(path)
+		if err != nil {
+			fr.Diagnostics = append(fr.Diagnostics, yarn.Diagnostic{Severity: yarn.SeverityError, PC: -1, Message: fmt.Sprintf("opening %s string table: %v", locale, err)})
+			continue
+		}
+		st, err := yarn.ReadStringTable(f, locale)
+		f.Close()
+		if err != nil {
+			fr.Diagnostics = append(fr.Diagnostics, yarn.Diagnostic{Severity: yarn.SeverityError, PC: -1, Message: fmt.Sprintf("reading %s string table: %v", locale, err)})
+			continue
+		}
+		for _, d := range yarn.CheckTranslation(base, st) {
+			d.Message = locale + ": " + d.Message
+			fr.Diagnostics = append(fr.Diagnostics, d)
+		}
+	}
+}
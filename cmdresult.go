@@ -0,0 +1,109 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// DefaultCommandResultVariable is the variable that receives command results
+// when VirtualMachine.CommandResultVariable is empty.
+const DefaultCommandResultVariable = "$command_result"
+
+// CommandResultHandler is an optional interface for dialogue handlers.
+// Commands are delivered to CommandResult instead of Command, and the result
+// (if not nil) is stored in a variable, so that the script can act on it
+// without a separate function:
+//
+//	<<roll_dice 20>>
+//	<<if $command_result >= 15>>
+//	    You hit!
+//	<<endif>>
+//
+// A command can name the variable that receives its result by ending with
+// "-> $variable" (the arrow and variable are not passed to the handler):
+//
+//	<<roll_dice 20 -> $roll>>
+//
+// Otherwise the result is stored in VirtualMachine.CommandResultVariable.
+// Numbers are stored as float32 (the Yarn number type); bools and strings
+// are stored as-is. Results of other types are an error.
+//
+// The compiled program has no way to consume a value left on the stack by a
+// command, so results are only available through variables. If the
+// variable is declared in the script, its declared type should match the
+// results.
+//
+// The handlers in this package that wrap another DialogueHandler (such as
+// WaitHandler) forward CommandResult to the wrapped handler, so wrapping a
+// CommandResultHandler doesn't lose results.
+type CommandResultHandler interface {
+	CommandResult(command string) (any, error)
+}
+
+// runCommandWithResult delivers the command to CommandResult and stores the
+// result.
+func (vm *VirtualMachine) runCommandWithResult(h CommandResultHandler, cmd string) error {
+	variable := vm.CommandResultVariable
+	if variable == "" {
+		variable = DefaultCommandResultVariable
+	}
+	if c, v, ok := strings.Cut(cmd, "->"); ok {
+		if v = strings.TrimSpace(v); strings.HasPrefix(v, "$") && !strings.ContainsAny(v, " \t") {
+			cmd, variable = strings.TrimSpace(c), v
+		}
+	}
+	vm.logEvent("Command", slog.String("command", cmd), slog.String("result_variable", variable))
+	vm.state.resultVariable = variable
+	result, err := h.CommandResult(cmd)
+	if err != nil {
+		return vm.handlerError("CommandResult", err)
+	}
+	return vm.storeCommandResult(cmd, variable, result)
+}
+
+// storeCommandResult stores the result of a command in the variable.
+func (vm *VirtualMachine) storeCommandResult(cmd, variable string, result any) error {
+	if result == nil {
+		return nil
+	}
+	switch x := result.(type) {
+	case bool, string, float32:
+		// Already a Yarn type.
+	case int, float64:
+		var err error
+		result, err = ConvertToFloat32(x)
+		if err != nil {
+			return fmt.Errorf("command %q result: %w", cmd, err)
+		}
+	default:
+		return fmt.Errorf("command %q result %v: unsupported type %T", cmd, result, result)
+	}
+	vm.setVariable(variable, result)
+	return nil
+}
+
+// forwardCommandResult passes the command to h's CommandResult method, or its
+// Command method if h doesn't implement CommandResultHandler (in which case
+// there is no result). Handlers that wrap another use it to forward
+// CommandResultHandler.
+func forwardCommandResult(h DialogueHandler, command string) (any, error) {
+	if crh, ok := h.(CommandResultHandler); ok {
+		return crh.CommandResult(command)
+	}
+	return nil, h.Command(command)
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// diceHandler "rolls" the highest number on the die.
+type diceHandler struct {
+	lineRecorder
+}
+
+func (h *diceHandler) CommandResult(command string) (any, error) {
+	h.commands = append(h.commands, command)
+	fields := strings.Fields(command)
+	switch fields[0] {
+	case "roll_dice":
+		return strconv.Atoi(fields[1])
+	case "name":
+		return "Ava", nil
+	case "wave":
+		return nil, nil
+	case "complex":
+		return 1i, nil
+	}
+	return nil, errors.New("unknown command")
+}
+
+func TestCommandResult(t *testing.T) {
+	// <<roll_dice 20>> <<roll_dice 6 -> $d6>> <<name>> <<wave>>
+	// <<if $command_result == "Ava" and $d6 + 20 >= 26>> yes <<endif>>
+	prog := condProgram(func(b *NodeBuilder) {
+		b.Command("roll_dice 20", 0).
+			Command("roll_dice 6 -> $d6", 0).
+			Command("name", 0).
+			Command("wave", 0).
+			PushVariable("$command_result").
+			PushString("Ava").
+			Call("String.EqualTo", 2).
+			PushVariable("$d6").
+			PushFloat(20).
+			Call("Number.Add", 2).
+			PushFloat(26).
+			Call("Number.GreaterThanOrEqualTo", 2).
+			Call("Bool.And", 2)
+	})
+	h := &diceHandler{}
+	vars := NewMapVariableStorage()
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: h,
+		Vars:    vars,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(h.ids, []string{"yes"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(h.commands, []string{"roll_dice 20", "roll_dice 6", "name", "wave"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+	if got, ok := vars.GetValue("$d6"); !ok || got != float32(6) {
+		t.Errorf("vars.GetValue($d6) = %v, %t, want float32(6), true", got, ok)
+	}
+
+	vm = &VirtualMachine{
+		Program: condProgram(func(b *NodeBuilder) {
+			b.Command("roll_dice 4", 0).PushBool(true)
+		}),
+		Handler:               h,
+		Vars:                  vars,
+		CommandResultVariable: "$roll",
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got, ok := vars.GetValue("$roll"); !ok || got != float32(4) {
+		t.Errorf("vars.GetValue($roll) = %v, %t, want float32(4), true", got, ok)
+	}
+
+	for _, cmd := range []string{"complex", "unknown"} {
+		vm = &VirtualMachine{
+			Program: condProgram(func(b *NodeBuilder) {
+				b.Command(cmd, 0).PushBool(true)
+			}),
+			Handler: h,
+			Vars:    vars,
+		}
+		if err := vm.Run("Start"); err == nil {
+			t.Errorf("vm.Run(Start) with <<%s>> = nil, want error", cmd)
+		}
+	}
+}
+
+func TestCommandResultThroughWrappers(t *testing.T) {
+	wrappers := map[string]func(DialogueHandler) DialogueHandler{
+		"CharacterHandler":     func(h DialogueHandler) DialogueHandler { return &CharacterHandler{DialogueHandler: h} },
+		"CodexHandler":         func(h DialogueHandler) DialogueHandler { return &CodexHandler{DialogueHandler: h} },
+		"DefaultOptionHandler": func(h DialogueHandler) DialogueHandler { return &DefaultOptionHandler{DialogueHandler: h} },
+		"DirectionHandler":     func(h DialogueHandler) DialogueHandler { return &DirectionHandler{DialogueHandler: h} },
+		"EmotionHandler":       func(h DialogueHandler) DialogueHandler { return &EmotionHandler{DialogueHandler: h} },
+		"EventLogHandler":      func(h DialogueHandler) DialogueHandler { return &EventLogHandler{DialogueHandler: h, W: io.Discard} },
+		"InventoryHandler":     func(h DialogueHandler) DialogueHandler { return &InventoryHandler{DialogueHandler: h} },
+		"JournalHandler":       func(h DialogueHandler) DialogueHandler { return &JournalHandler{DialogueHandler: h, W: io.Discard} },
+		"MemoryHandler":        func(h DialogueHandler) DialogueHandler { return &MemoryHandler{DialogueHandler: h, Memory: &Memory{}} },
+		"ParagraphHandler":     func(h DialogueHandler) DialogueHandler { return &ParagraphHandler{DialogueHandler: h} },
+		"TimingHandler":        func(h DialogueHandler) DialogueHandler { return &TimingHandler{DialogueHandler: h} },
+		"TriggerHandler":       func(h DialogueHandler) DialogueHandler { return &TriggerHandler{DialogueHandler: h} },
+		"WaitHandler":          func(h DialogueHandler) DialogueHandler { return &WaitHandler{DialogueHandler: h} },
+	}
+	pb := NewProgramBuilder("Wrapped")
+	pb.Node("Start").Command("roll_dice 6", 0).Stop()
+	for name, wrap := range wrappers {
+		h := &diceHandler{}
+		vars := NewMapVariableStorage()
+		vm := &VirtualMachine{
+			Program: pb.Program(),
+			Handler: wrap(h),
+			Vars:    vars,
+		}
+		if err := vm.Run("Start"); err != nil {
+			t.Errorf("%s: vm.Run(Start) = %v", name, err)
+			continue
+		}
+		if diff := cmp.Diff(h.commands, []string{"roll_dice 6"}); diff != "" {
+			t.Errorf("%s: commands diff (-got +want):\n%s", name, diff)
+		}
+		if got, ok := vars.GetValue(DefaultCommandResultVariable); !ok || got != float32(6) {
+			t.Errorf("%s: vars.GetValue(%s) = %v, %t, want float32(6), true", name, DefaultCommandResultVariable, got, ok)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// CodexTagPrefix marks line tags (in the metadata table) and node tags that
+// unlock codex entries, e.g. #codex:Dragons.
+const CodexTagPrefix = "codex:"
+
+// Codex is a persistent set of unlocked codex (or lore, or encyclopedia)
+// entries. It marshals to and from a JSON array of entry names, for saving.
+// It is safe for concurrent use.
+type Codex struct {
+	// OnUnlock, if not nil, is called when an entry is unlocked for the
+	// first time.
+	OnUnlock func(entry string)
+
+	mu       sync.Mutex
+	unlocked map[string]bool
+}
+
+// Unlock unlocks an entry. It reports whether the entry was newly unlocked.
+func (c *Codex) Unlock(entry string) bool {
+	c.mu.Lock()
+	if c.unlocked[entry] {
+		c.mu.Unlock()
+		return false
+	}
+	if c.unlocked == nil {
+		c.unlocked = make(map[string]bool)
+	}
+	c.unlocked[entry] = true
+	c.mu.Unlock()
+	if c.OnUnlock != nil {
+		c.OnUnlock(entry)
+	}
+	return true
+}
+
+// Unlocked reports whether an entry has been unlocked.
+func (c *Codex) Unlocked(entry string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.unlocked[entry]
+}
+
+// Entries returns the unlocked entries, sorted.
+func (c *Codex) Entries() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return sortedKeys(c.unlocked)
+}
+
+// MarshalJSON marshals the unlocked entries as a sorted JSON array.
+func (c *Codex) MarshalJSON() ([]byte, error) {
+	entries := c.Entries()
+	if entries == nil {
+		entries = []string{}
+	}
+	return json.Marshal(entries)
+}
+
+// UnmarshalJSON replaces the unlocked entries with those in a JSON array.
+// OnUnlock is not called.
+func (c *Codex) UnmarshalJSON(b []byte) error {
+	var entries []string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unlocked = make(map[string]bool, len(entries))
+	for _, e := range entries {
+		c.unlocked[e] = true
+	}
+	return nil
+}
+
+// codexTags returns the codex entries named by tags.
+func codexTags(tags []string) []string {
+	var entries []string
+	for _, t := range tags {
+		if e, ok := strings.CutPrefix(t, CodexTagPrefix); ok && e != "" {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// CodexEntries returns every codex entry named by the tags of the nodes in
+// the program and the lines in the string table (either may be nil), sorted.
+// This is useful for showing how much of the codex has been discovered.
+func CodexEntries(prog *yarnpb.Program, st *StringTable) []string {
+	set := make(map[string]bool)
+	if prog != nil {
+		for _, node := range prog.Nodes {
+			for _, e := range codexTags(node.Tags) {
+				set[e] = true
+			}
+		}
+	}
+	if st != nil {
+		for _, row := range st.Table {
+			for _, e := range codexTags(row.Tags) {
+				set[e] = true
+			}
+		}
+	}
+	return sortedKeys(set)
+}
+
+var (
+	_ DialogueHandler      = &CodexHandler{}
+	_ CommandResultHandler = &CodexHandler{}
+	_ SkipHandler          = &CodexHandler{}
+)
+
+// CodexHandler is a DialogueHandler that unlocks codex entries named by the
+// tags of nodes as they start (if Program is set) and of lines as they are
+// delivered (if StringTable is set). All events are passed to the embedded
+// handler.
+type CodexHandler struct {
+	DialogueHandler
+	Codex       *Codex
+	Program     *yarnpb.Program
+	StringTable *StringTable
+}
+
+// NodeStart unlocks the node's entries, then calls the embedded handler.
+func (h *CodexHandler) NodeStart(nodeName string) error {
+	if node := h.Program.GetNodes()[nodeName]; node != nil {
+		for _, e := range codexTags(node.Tags) {
+			h.Codex.Unlock(e)
+		}
+	}
+	return h.DialogueHandler.NodeStart(nodeName)
+}
+
+// Line unlocks the line's entries, then calls the embedded handler.
+func (h *CodexHandler) Line(line Line) error {
+	h.unlockLine(line.ID)
+	return h.DialogueHandler.Line(line)
+}
+
+// SkipLine unlocks the line's entries, then calls the embedded handler's
+// SkipLine method, if it has one.
+func (h *CodexHandler) SkipLine(line Line) error {
+	h.unlockLine(line.ID)
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// CommandResult passes the command to the embedded handler's CommandResult
+// method, if it has one.
+func (h *CodexHandler) CommandResult(command string) (any, error) {
+	return forwardCommandResult(h.DialogueHandler, command)
+}
+
+func (h *CodexHandler) unlockLine(id string) {
+	if row := h.StringTable.row(id); row != nil {
+		for _, e := range codexTags(row.Tags) {
+			h.Codex.Unlock(e)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCodexHandler(t *testing.T) {
+	pb := NewProgramBuilder("Codex")
+	pb.Node("Start").Tags("codex:Dragons", "intro").Line("line:a", 0).Line("line:b", 0).RunNode("Lair")
+	pb.Node("Lair").Tags("codex:Dragons").Line("line:c", 0).Stop()
+	pb.Node("Unvisited").Tags("codex:Secrets").Stop()
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:a": {ID: "line:a", Text: "Dragons hoard gold.", Tags: []string{"codex:Gold"}},
+		"line:b": {ID: "line:b", Text: "The Queen rules here.", Tags: []string{"codex:Queen", "codex:Gold", "codex:"}},
+		"line:c": {ID: "line:c", Text: "Hot in here."},
+		"line:d": {ID: "line:d", Text: "Never said.", Tags: []string{"codex:Moon"}},
+	}}
+
+	if diff := cmp.Diff(CodexEntries(pb.Program(), st), []string{"Dragons", "Gold", "Moon", "Queen", "Secrets"}); diff != "" {
+		t.Errorf("CodexEntries diff (-got +want):\n%s", diff)
+	}
+
+	var unlocks []string
+	codex := &Codex{OnUnlock: func(e string) { unlocks = append(unlocks, e) }}
+	h := &CodexHandler{
+		DialogueHandler: FakeDialogueHandler{},
+		Codex:           codex,
+		Program:         pb.Program(),
+		StringTable:     st,
+	}
+	vm := &VirtualMachine{Program: pb.Program(), Handler: h, Vars: NewMapVariableStorage()}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(unlocks, []string{"Dragons", "Gold", "Queen"}); diff != "" {
+		t.Errorf("unlocks diff (-got +want):\n%s", diff)
+	}
+	if !codex.Unlocked("Queen") || codex.Unlocked("Moon") {
+		t.Errorf("Unlocked(Queen), Unlocked(Moon) = %t, %t, want true, false", codex.Unlocked("Queen"), codex.Unlocked("Moon"))
+	}
+
+	saved, err := json.Marshal(codex)
+	if err != nil {
+		t.Fatalf("json.Marshal(codex) = %v", err)
+	}
+	if got, want := string(saved), `["Dragons","Gold","Queen"]`; got != want {
+		t.Errorf("json.Marshal(codex) = %s, want %s", got, want)
+	}
+	loaded := &Codex{}
+	if err := json.Unmarshal(saved, loaded); err != nil {
+		t.Fatalf("json.Unmarshal = %v", err)
+	}
+	if diff := cmp.Diff(loaded.Entries(), codex.Entries()); diff != "" {
+		t.Errorf("loaded.Entries() diff (-got +want):\n%s", diff)
+	}
+	if loaded.Unlock("Gold") {
+		t.Errorf("loaded.Unlock(Gold) = true, want false")
+	}
+	if empty, _ := json.Marshal(&Codex{}); string(empty) != "[]" {
+		t.Errorf("json.Marshal(empty codex) = %s, want []", empty)
+	}
+}
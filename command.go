@@ -0,0 +1,61 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ErrCommandArgs is returned (wrapped) by command handlers when a command
+// has the wrong number or type of arguments.
+const ErrCommandArgs = virtualMachineError("bad command arguments")
+
+// SplitCommand splits a command into its name and arguments, separated by
+// spaces. Arguments may be double-quoted to include spaces, as in
+//
+//	<<say Ava "Hello there">>
+//
+// Within quotes, \" and \\ are a literal quote and backslash.
+func SplitCommand(command string) []string {
+	var fields []string
+	var b strings.Builder
+	inField, quoted, escaped := false, false, false
+	for _, r := range command {
+		switch {
+		case escaped:
+			b.WriteRune(r)
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+			inField = true
+		case !quoted && unicode.IsSpace(r):
+			if inField {
+				fields = append(fields, b.String())
+				b.Reset()
+				inField = false
+			}
+		default:
+			b.WriteRune(r)
+			inField = true
+		}
+	}
+	if inField {
+		fields = append(fields, b.String())
+	}
+	return fields
+}
@@ -0,0 +1,40 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+	}{
+		{command: "", want: nil},
+		{command: "wave", want: []string{"wave"}},
+		{command: "  give  gold 5 ", want: []string{"give", "gold", "5"}},
+		{command: `say Ava "Hello there"`, want: []string{"say", "Ava", "Hello there"}},
+		{command: `say "" "a \"b\" \\c"`, want: []string{"say", "", `a "b" \c`}},
+		{command: `path C:\dir`, want: []string{"path", `C:\dir`}},
+	}
+	for _, test := range tests {
+		if diff := cmp.Diff(SplitCommand(test.command), test.want); diff != "" {
+			t.Errorf("SplitCommand(%q) diff (-got +want):\n%s", test.command, diff)
+		}
+	}
+}
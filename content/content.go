@@ -0,0 +1,251 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package content fetches compiled dialogue over HTTP, so that story updates
+// can be delivered to a running game without patching it.
+//
+// Content is published as bundles: a bundle named "chapter2" is the files
+//
+//	chapter2.yarnc
+//	chapter2-Lines.csv
+//
+// under the client's base URL (the same naming as yarn.LoadFiles). If the
+// client has a public key, each file must also have a detached Ed25519
+// signature, in raw or base64 form, at the same URL with ".sig" appended.
+//
+// Responses are cached with their ETags, so that checking for updates only
+// transfers files that have changed. Client.Update applies the changed
+// programs to a yarn.Router all at once:
+//
+//	c := &content.Client{BaseURL: "https://cdn.example.com/story/", PublicKey: key, Lang: "en"}
+//	updated, err := c.Update(ctx, router, "chapter1", "chapter2")
+//	for _, b := range updated {
+//		locales.Add(b.Name, b.Strings) // or however string tables are kept
+//	}
+package content // import "github.com/DrJosh9000/yarn/content"
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrBadSignature is returned when a file's signature is missing or doesn't
+// verify.
+var ErrBadSignature = errors.New("bad signature")
+
+// DefaultMaxFileSize is the largest file a Client fetches when its
+// MaxFileSize is zero.
+const DefaultMaxFileSize = 64 << 20
+
+// Bundle is a program and its string table.
+type Bundle struct {
+	Name    string
+	Program *yarnpb.Program
+	Strings *yarn.StringTable
+}
+
+// Client fetches bundles. It is safe for concurrent use.
+type Client struct {
+	// BaseURL is the URL that bundle file names are resolved against. It
+	// should usually end with "/".
+	BaseURL string
+
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+
+	// PublicKey, if not nil, is used to verify the signature of every file.
+	PublicKey ed25519.PublicKey
+
+	// Lang is the language code of the string tables.
+	Lang string
+
+	// MaxFileSize limits the size of each file fetched. If zero,
+	// DefaultMaxFileSize is used.
+	MaxFileSize int64
+
+	mu    sync.Mutex
+	cache map[string]*cachedFile
+}
+
+// cachedFile is a cached response body.
+type cachedFile struct {
+	etag string
+	body []byte
+}
+
+// Fetch fetches a bundle. changed reports whether any of its files differ
+// from when it was last fetched successfully by this client.
+func (c *Client) Fetch(ctx context.Context, name string) (b *Bundle, changed bool, err error) {
+	staged := make(map[string]*cachedFile)
+	b, changed, err = c.fetchBundle(ctx, name, staged)
+	if err != nil {
+		return nil, false, err
+	}
+	c.commit(staged)
+	return b, changed, nil
+}
+
+// fetchBundle fetches a bundle, staging new responses for the cache in
+// staged.
+func (c *Client) fetchBundle(ctx context.Context, name string, staged map[string]*cachedFile) (*Bundle, bool, error) {
+	yarnc, progChanged, err := c.fetchVerified(ctx, name+".yarnc", staged)
+	if err != nil {
+		return nil, false, err
+	}
+	csv, linesChanged, err := c.fetchVerified(ctx, name+"-Lines.csv", staged)
+	if err != nil {
+		return nil, false, err
+	}
+	prog, err := yarn.LoadProgramBytes(yarnc)
+	if err != nil {
+		return nil, false, fmt.Errorf("bundle %q: %w", name, err)
+	}
+	st, err := yarn.ReadStringTable(bytes.NewReader(csv), c.Lang)
+	if err != nil {
+		return nil, false, fmt.Errorf("bundle %q: %w", name, err)
+	}
+	return &Bundle{Name: name, Program: prog, Strings: st}, progChanged || linesChanged, nil
+}
+
+// Update fetches the bundles, and if all of them are fetched and verified
+// successfully, replaces the changed programs in the router at once (see
+// yarn.Router.Replace). It returns the bundles that changed, so that their
+// string tables can be swapped in too. If any bundle fails, the router is not
+// changed. If any bundle fails, the router is not changed, and neither is
+// the cache, so the bundles that did change are reported again by the next
+// Update.
+func (c *Client) Update(ctx context.Context, r *yarn.Router, names ...string) ([]*Bundle, error) {
+	staged := make(map[string]*cachedFile)
+	var updated []*Bundle
+	for _, name := range names {
+		b, changed, err := c.fetchBundle(ctx, name, staged)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			updated = append(updated, b)
+		}
+	}
+	c.commit(staged)
+	if len(updated) == 0 {
+		return nil, nil
+	}
+	progs := make(map[string]*yarnpb.Program, len(updated))
+	for _, b := range updated {
+		progs[b.Name] = b.Program
+	}
+	r.Replace(progs)
+	return updated, nil
+}
+
+// fetchVerified fetches a file and checks its signature (if there is a
+// public key).
+func (c *Client) fetchVerified(ctx context.Context, file string, staged map[string]*cachedFile) ([]byte, bool, error) {
+	body, changed, err := c.fetch(ctx, file, staged)
+	if err != nil {
+		return nil, false, err
+	}
+	if c.PublicKey == nil {
+		return body, changed, nil
+	}
+	sig, sigChanged, err := c.fetch(ctx, file+".sig", staged)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	if len(sig) != ed25519.SignatureSize {
+		dec, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			return nil, false, fmt.Errorf("%w: %q: decoding signature: %v", ErrBadSignature, file, err)
+		}
+		sig = dec
+	}
+	if !ed25519.Verify(c.PublicKey, body, sig) {
+		return nil, false, fmt.Errorf("%w: %q", ErrBadSignature, file)
+	}
+	return body, changed || sigChanged, nil
+}
+
+// fetch fetches a file, using the cached copy if the server reports that it
+// hasn't changed. A new response is staged in staged, rather than cached
+// immediately (see commit).
+func (c *Client) fetch(ctx context.Context, file string, staged map[string]*cachedFile) ([]byte, bool, error) {
+	u, err := url.JoinPath(c.BaseURL, file)
+	if err != nil {
+		return nil, false, fmt.Errorf("resolving %q: %w", file, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	c.mu.Lock()
+	cached := c.cache[u]
+	c.mu.Unlock()
+	if cached != nil && cached.etag != "" {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching %q: %w", file, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		return cached.body, false, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, false, fmt.Errorf("fetching %q: %s", file, resp.Status)
+	}
+	limit := c.MaxFileSize
+	if limit <= 0 {
+		limit = DefaultMaxFileSize
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, false, fmt.Errorf("fetching %q: %w", file, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, false, fmt.Errorf("fetching %q: larger than %d bytes", file, limit)
+	}
+	staged[u] = &cachedFile{etag: resp.Header.Get("ETag"), body: body}
+	return body, cached == nil || !bytes.Equal(cached.body, body), nil
+}
+
+// commit adds the staged responses to the cache.
+func (c *Client) commit(staged map[string]*cachedFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]*cachedFile)
+	}
+	for u, f := range staged {
+		c.cache[u] = f
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package content
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileServer serves files with ETags, and counts full responses.
+type fileServer struct {
+	mu    sync.Mutex
+	files map[string][]byte
+	full  int
+}
+
+func (s *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	body, ok := s.files[strings.TrimPrefix(r.URL.Path, "/story/")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	etag := fmt.Sprintf(`"%x"`, sha256.Sum256(body))
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.full++
+	w.Write(body)
+}
+
+func (s *fileServer) publish(t *testing.T, key ed25519.PrivateKey, name, line string) {
+	t.Helper()
+	pb := yarn.NewProgramBuilder(name)
+	pb.Node("Start").Line("line:"+line, 0)
+	yarnc, err := proto.Marshal(pb.Program())
+	if err != nil {
+		t.Fatalf("proto.Marshal = %v", err)
+	}
+	csv := []byte("id,text,file,node,lineNumber\nline:" + line + "," + line + ",a.yarn,Start,1\n")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name+".yarnc"] = yarnc
+	s.files[name+".yarnc.sig"] = ed25519.Sign(key, yarnc)
+	s.files[name+"-Lines.csv"] = csv
+	s.files[name+"-Lines.csv.sig"] = []byte(base64.StdEncoding.EncodeToString(ed25519.Sign(key, csv)))
+}
+
+func TestClientUpdate(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("ed25519.GenerateKey = %v", err)
+	}
+	fs := &fileServer{files: make(map[string][]byte)}
+	fs.publish(t, priv, "chapter1", "hello")
+	fs.publish(t, priv, "chapter2", "goodbye")
+	srv := httptest.NewServer(fs)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := &Client{BaseURL: srv.URL + "/story/", PublicKey: pub, Lang: "en"}
+	router := &yarn.Router{}
+
+	updated, err := c.Update(ctx, router, "chapter1", "chapter2")
+	if err != nil {
+		t.Fatalf("c.Update = %v", err)
+	}
+	if len(updated) != 2 {
+		t.Errorf("len(updated) = %d, want 2", len(updated))
+	}
+	prog, err := router.Program("chapter2")
+	if err != nil {
+		t.Fatalf("router.Program(chapter2) = %v", err)
+	}
+	if prog.Nodes["Start"] == nil {
+		t.Errorf("chapter2 has no Start node")
+	}
+	if got, want := updated[1].Strings.Table["line:goodbye"].Text, "goodbye"; got != want {
+		t.Errorf("line:goodbye text = %q, want %q", got, want)
+	}
+
+	// Nothing has changed, so nothing is transferred or updated.
+	full := fs.full
+	if updated, err := c.Update(ctx, router, "chapter1", "chapter2"); err != nil || len(updated) != 0 {
+		t.Errorf("c.Update (unchanged) = %d bundles, %v, want 0, nil", len(updated), err)
+	}
+	if fs.full != full {
+		t.Errorf("server sent %d full responses for unchanged content, want 0", fs.full-full)
+	}
+
+	fs.publish(t, priv, "chapter2", "farewell")
+	updated, err = c.Update(ctx, router, "chapter1", "chapter2")
+	if err != nil {
+		t.Fatalf("c.Update (changed) = %v", err)
+	}
+	if len(updated) != 1 || updated[0].Name != "chapter2" {
+		t.Errorf("c.Update (changed) = %v, want [chapter2]", updated)
+	}
+	if p, _ := router.Program("chapter2"); p == prog {
+		t.Errorf("router.Program(chapter2) was not replaced")
+	}
+
+	// A tampered file is rejected, and the router is not changed.
+	prog, _ = router.Program("chapter1")
+	fs.publish(t, priv, "chapter1", "tampered")
+	fs.mu.Lock()
+	fs.files["chapter1-Lines.csv"] = append(fs.files["chapter1-Lines.csv"], "line:evil,evil,a.yarn,Start,2\n"...)
+	fs.mu.Unlock()
+	if _, err := c.Update(ctx, router, "chapter1"); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("c.Update (tampered) = %v, want %v", err, ErrBadSignature)
+	}
+	if p, _ := router.Program("chapter1"); p != prog {
+		t.Errorf("router.Program(chapter1) was replaced by tampered content")
+	}
+
+	// When an update fails, the bundles that did change aren't cached, so
+	// the next update applies them.
+	fs.publish(t, priv, "chapter1", "hello again")
+	fs.mu.Lock()
+	delete(fs.files, "chapter2-Lines.csv")
+	fs.mu.Unlock()
+	if _, err := c.Update(ctx, router, "chapter1", "chapter2"); err == nil {
+		t.Errorf("c.Update (chapter2 missing) = nil error, want error")
+	}
+	if p, _ := router.Program("chapter1"); p != prog {
+		t.Errorf("router.Program(chapter1) was replaced by a failed update")
+	}
+	fs.publish(t, priv, "chapter2", "farewell")
+	updated, err = c.Update(ctx, router, "chapter1", "chapter2")
+	if err != nil {
+		t.Fatalf("c.Update (after failure) = %v", err)
+	}
+	if len(updated) != 1 || updated[0].Name != "chapter1" {
+		t.Errorf("c.Update (after failure) = %v, want [chapter1]", updated)
+	}
+	if p, _ := router.Program("chapter1"); p == prog {
+		t.Errorf("router.Program(chapter1) was not replaced")
+	}
+
+	if _, _, err := c.Fetch(ctx, "chapter3"); err == nil {
+		t.Errorf("c.Fetch(chapter3) = nil error, want error")
+	}
+
+	small := &Client{BaseURL: srv.URL + "/story/", Lang: "en", MaxFileSize: 10}
+	if _, _, err := small.Fetch(ctx, "chapter1"); err == nil {
+		t.Errorf("small.Fetch(chapter1) = nil error, want error for file larger than MaxFileSize")
+	}
+}
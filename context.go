@@ -0,0 +1,73 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"context"
+	"fmt"
+)
+
+// ErrCanceled is returned (wrapped, along with the context's error) by
+// RunContext and ResumeContext when the context is canceled or its deadline
+// passes. Both can be tested for:
+//
+//	if errors.Is(err, yarn.ErrCanceled) { ... }
+//	if errors.Is(err, context.DeadlineExceeded) { ... }
+const ErrCanceled = virtualMachineError("dialogue canceled")
+
+// RunContext is like Run, but stops when ctx is done (e.g. when the player
+// walks away, or the scene unloads), returning an error wrapping both
+// ErrCanceled and ctx.Err(). The context is checked between instructions;
+// a handler method that blocks (e.g. waiting for input) should also watch
+// the context, which it can get with Context.
+func (vm *VirtualMachine) RunContext(ctx context.Context, startNode string) error {
+	defer vm.setContext(ctx)()
+	return vm.Run(startNode)
+}
+
+// ResumeContext is like Resume, but stops when ctx is done (see
+// RunContext).
+func (vm *VirtualMachine) ResumeContext(ctx context.Context) error {
+	defer vm.setContext(ctx)()
+	return vm.Resume()
+}
+
+// Context returns the context passed to RunContext or ResumeContext, while
+// it is running. Otherwise it returns context.Background().
+func (vm *VirtualMachine) Context() context.Context {
+	if vm.ctx == nil {
+		return context.Background()
+	}
+	return vm.ctx
+}
+
+// setContext sets the context for a run, and returns a function that
+// restores the previous one.
+func (vm *VirtualMachine) setContext(ctx context.Context) (restore func()) {
+	oldCtx, oldDone := vm.ctx, vm.ctxDone
+	vm.ctx, vm.ctxDone = ctx, ctx.Done()
+	return func() { vm.ctx, vm.ctxDone = oldCtx, oldDone }
+}
+
+// checkContext returns an error if the run's context is done. It is cheap
+// enough to call before every instruction.
+func (vm *VirtualMachine) checkContext() error {
+	select {
+	case <-vm.ctxDone: // never ready if nil
+		return fmt.Errorf("%w: %w", ErrCanceled, vm.ctx.Err())
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunContext(t *testing.T) {
+	// A node that loops forever without delivering anything, calling tick
+	// each time around.
+	pb := NewProgramBuilder("Loop")
+	pb.Node("Start").
+		Line("line:1", 0).
+		Label("loop").
+		Call("tick", 0).
+		Pop().
+		JumpTo("loop")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ticks := 0
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: rec,
+		Vars:    NewMapVariableStorage(),
+		FuncMap: FuncMap{
+			"tick": func() bool {
+				if ticks++; ticks == 3 {
+					cancel()
+				}
+				return true
+			},
+		},
+	}
+	err := vm.RunContext(ctx, "Start")
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("vm.RunContext() = %v, want %v and %v", err, ErrCanceled, context.Canceled)
+	}
+	if got, want := ticks, 3; got != want {
+		t.Errorf("ticks = %d, want %d", got, want)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:1"}); diff != "" {
+		t.Errorf("ids diff (-got +want):\n%s", diff)
+	}
+	if got := vm.Context(); got != context.Background() {
+		t.Errorf("vm.Context() after RunContext = %v, want context.Background()", got)
+	}
+
+	// The dialogue doesn't start if the context is already done.
+	ctx, cancel = context.WithTimeout(context.Background(), -time.Second)
+	defer cancel()
+	rec.ids = nil
+	if err := vm.RunContext(ctx, "Start"); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("vm.RunContext(expired) = %v, want %v and %v", err, ErrCanceled, context.DeadlineExceeded)
+	}
+	if len(rec.ids) != 0 {
+		t.Errorf("ids = %v, want none", rec.ids)
+	}
+}
+
+// contextHandler records the VM's context when a line is delivered.
+type contextHandler struct {
+	FakeDialogueHandler
+	vm  *VirtualMachine
+	got context.Context
+}
+
+func (h *contextHandler) Line(Line) error {
+	h.got = h.vm.Context()
+	return nil
+}
+
+func TestResumeContext(t *testing.T) {
+	pb := NewProgramBuilder("Resume")
+	pb.Node("Start").Line("line:1", 0).Stop()
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	vm := &VirtualMachine{Program: pb.Program(), Vars: NewMapVariableStorage()}
+	h := &contextHandler{vm: vm}
+	vm.Handler = h
+	if err := vm.Restore(&Snapshot{Node: "Start"}); err != nil {
+		t.Fatalf("vm.Restore() = %v", err)
+	}
+	if err := vm.ResumeContext(ctx); err != nil {
+		t.Fatalf("vm.ResumeContext() = %v", err)
+	}
+	if h.got != ctx {
+		t.Errorf("vm.Context() during ResumeContext = %v, want %v", h.got, ctx)
+	}
+}
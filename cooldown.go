@@ -0,0 +1,277 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrNodeCoolingDown is returned (wrapped) when entering a node would violate
+// its cooldown or frequency constraint.
+const ErrNodeCoolingDown = virtualMachineError("node is cooling down")
+
+// Node metadata used by NodeLimits.
+const (
+	// CooldownHeader is the minimum time between entries to a node, either
+	// in seconds ("300") or as a Go duration ("5m").
+	CooldownHeader = "cooldown"
+
+	// FrequencyHeader limits the number of entries to a node within a
+	// window of time, written as count/window, e.g. "3/1h".
+	FrequencyHeader = "frequency"
+
+	// CooldownTagPrefix and FrequencyTagPrefix are alternatives to the
+	// headers, as node tags (e.g. "cooldown:5m", "frequency:3/1h").
+	CooldownTagPrefix  = "cooldown:"
+	FrequencyTagPrefix = "frequency:"
+)
+
+// NodeLimitsState is the serializable state of NodeLimits, for saving across
+// game sessions.
+type NodeLimitsState struct {
+	// Entries maps node names to the times each node was recently entered,
+	// oldest first. Entries too old to affect any constraint are dropped.
+	Entries map[string][]time.Time `json:"entries,omitempty"`
+}
+
+// NodeLimits enforces cooldown and frequency constraints on nodes, which are
+// common for ambient dialogue. For example:
+//
+//	title: Merchant_Greeting
+//	cooldown: 300
+//	frequency: 3/1h
+//	---
+//	Merchant: Back again?
+//
+// can't be entered within 5 minutes of the last time, or more than 3 times
+// in any hour. Set VirtualMachine.Limits to enforce the constraints whenever
+// the VM enters a node; NodeLimits can be shared between VMs.
+type NodeLimits struct {
+	Program *yarnpb.Program
+
+	// Now, if not nil, is used instead of time.Now (e.g. to use game time
+	// rather than wall time).
+	Now func() time.Time
+
+	once   sync.Once
+	limits map[string]nodeLimit
+	err    error
+
+	mu      sync.Mutex
+	entries map[string][]time.Time
+}
+
+// nodeLimit is the constraints for one node.
+type nodeLimit struct {
+	cooldown time.Duration
+	count    int // 0 if there is no frequency constraint
+	window   time.Duration
+}
+
+// keep is how long entries are relevant for.
+func (l nodeLimit) keep() time.Duration {
+	if l.count > 0 {
+		return max(l.cooldown, l.window)
+	}
+	return l.cooldown
+}
+
+func (l *NodeLimits) parse() {
+	l.limits = make(map[string]nodeLimit)
+	for _, name := range sortedNodeNames(l.Program) {
+		node := l.Program.Nodes[name]
+		var lim nodeLimit
+		var found bool
+		set := func(key, value string) error {
+			found = true
+			switch key {
+			case CooldownHeader:
+				d, err := parseSeconds(value)
+				if err != nil {
+					return err
+				}
+				lim.cooldown = d
+			case FrequencyHeader:
+				n, w, ok := strings.Cut(value, "/")
+				if !ok {
+					return fmt.Errorf("want count/window, got %q", value)
+				}
+				count, err := strconv.Atoi(strings.TrimSpace(n))
+				if err != nil || count < 1 {
+					return fmt.Errorf("invalid count %q", n)
+				}
+				window, err := parseSeconds(w)
+				if err != nil {
+					return err
+				}
+				lim.count, lim.window = count, window
+			}
+			return nil
+		}
+		for _, h := range node.Headers {
+			if h.Key != CooldownHeader && h.Key != FrequencyHeader {
+				continue
+			}
+			if err := set(h.Key, h.Value); err != nil {
+				l.err = fmt.Errorf("node %q: %s header: %w", name, h.Key, err)
+				return
+			}
+		}
+		for _, t := range node.Tags {
+			key := ""
+			switch {
+			case strings.HasPrefix(t, CooldownTagPrefix):
+				key = CooldownHeader
+			case strings.HasPrefix(t, FrequencyTagPrefix):
+				key = FrequencyHeader
+			default:
+				continue
+			}
+			_, value, _ := strings.Cut(t, ":")
+			if err := set(key, value); err != nil {
+				l.err = fmt.Errorf("node %q: tag %q: %w", name, t, err)
+				return
+			}
+		}
+		if found {
+			l.limits[name] = lim
+		}
+	}
+}
+
+// parseSeconds parses a number of seconds or a Go duration.
+func parseSeconds(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(secs * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
+
+func (l *NodeLimits) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// Check returns an error wrapping ErrNodeCoolingDown if the node can't be
+// entered now.
+func (l *NodeLimits) Check(node string) error {
+	_, err := l.Ready(node)
+	return err
+}
+
+// Ready returns the earliest time the node can be entered (which is now, if
+// it can be entered now). If the node can't be entered now, it also returns
+// an error wrapping ErrNodeCoolingDown.
+func (l *NodeLimits) Ready(node string) (time.Time, error) {
+	l.once.Do(l.parse)
+	if l.err != nil {
+		return time.Time{}, l.err
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.ready(node, now)
+}
+
+func (l *NodeLimits) ready(node string, now time.Time) (time.Time, error) {
+	lim, ok := l.limits[node]
+	if !ok {
+		return now, nil
+	}
+	entries := l.entries[node]
+	ready := now
+	if len(entries) > 0 {
+		if t := entries[len(entries)-1].Add(lim.cooldown); t.After(ready) {
+			ready = t
+		}
+	}
+	if lim.count > 0 {
+		var recent []time.Time
+		for _, e := range entries {
+			if now.Sub(e) < lim.window {
+				recent = append(recent, e)
+			}
+		}
+		if len(recent) >= lim.count {
+			// The window must slide past enough entries to make room.
+			if t := recent[len(recent)-lim.count].Add(lim.window); t.After(ready) {
+				ready = t
+			}
+		}
+	}
+	if ready.After(now) {
+		return ready, fmt.Errorf("%w: %q available in %v", ErrNodeCoolingDown, node, ready.Sub(now))
+	}
+	return now, nil
+}
+
+// Enter checks the node can be entered, and if so, records an entry.
+func (l *NodeLimits) Enter(node string) error {
+	l.once.Do(l.parse)
+	if l.err != nil {
+		return l.err
+	}
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.ready(node, now); err != nil {
+		return err
+	}
+	lim, ok := l.limits[node]
+	if !ok {
+		return nil
+	}
+	entries := append(l.entries[node], now)
+	for len(entries) > 0 && now.Sub(entries[0]) >= lim.keep() {
+		entries = entries[1:]
+	}
+	if l.entries == nil {
+		l.entries = make(map[string][]time.Time)
+	}
+	l.entries[node] = entries
+	return nil
+}
+
+// State returns a copy of the recorded entries.
+func (l *NodeLimits) State() NodeLimitsState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s := NodeLimitsState{Entries: make(map[string][]time.Time, len(l.entries))}
+	for node, entries := range l.entries {
+		if len(entries) > 0 {
+			s.Entries[node] = append([]time.Time(nil), entries...)
+		}
+	}
+	return s
+}
+
+// SetState replaces the recorded entries, e.g. when loading a saved game.
+func (l *NodeLimits) SetState(s NodeLimitsState) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = make(map[string][]time.Time, len(s.Entries))
+	for node, entries := range s.Entries {
+		l.entries[node] = append([]time.Time(nil), entries...)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNodeLimits(t *testing.T) {
+	pb := NewProgramBuilder("Limits")
+	lineNode(pb, "Cooldown").Header(CooldownHeader, "300")
+	lineNode(pb, "Frequency").Tags("frequency:2/1h")
+	lineNode(pb, "Free")
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	l := &NodeLimits{
+		Program: pb.Program(),
+		Now:     func() time.Time { return now },
+	}
+	enter := func(node string, wantErr bool) {
+		t.Helper()
+		err := l.Enter(node)
+		if gotErr := errors.Is(err, ErrNodeCoolingDown); gotErr != wantErr || (err != nil && !gotErr) {
+			t.Errorf("at %v: Enter(%q) = %v, want cooling down %t", now.Format(time.Kitchen), node, err, wantErr)
+		}
+	}
+
+	enter("Cooldown", false)
+	enter("Cooldown", true)
+	if got, err := l.Ready("Cooldown"); !got.Equal(now.Add(5*time.Minute)) || err == nil {
+		t.Errorf("Ready(Cooldown) = %v, %v, want %v, error", got, err, now.Add(5*time.Minute))
+	}
+	enter("Frequency", false)
+	enter("Free", false)
+	enter("Free", false)
+
+	now = now.Add(20 * time.Minute)
+	enter("Cooldown", false)
+	enter("Frequency", false)
+	enter("Frequency", true)
+	if got, _ := l.Ready("Frequency"); !got.Equal(time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("Ready(Frequency) = %v, want 13:00", got)
+	}
+
+	// Save and restore.
+	data, err := json.Marshal(l.State())
+	if err != nil {
+		t.Fatalf("json.Marshal(State()) = %v", err)
+	}
+	var s NodeLimitsState
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("json.Unmarshal = %v", err)
+	}
+	l2 := &NodeLimits{Program: l.Program, Now: l.Now}
+	l2.SetState(s)
+	if err := l2.Check("Frequency"); !errors.Is(err, ErrNodeCoolingDown) {
+		t.Errorf("restored Check(Frequency) = %v, want %v", err, ErrNodeCoolingDown)
+	}
+
+	now = now.Add(40 * time.Minute)
+	enter("Frequency", false)
+	enter("Frequency", true)
+}
+
+func TestNodeLimitsVM(t *testing.T) {
+	pb := NewProgramBuilder("Limits")
+	lineNode(pb, "Cooldown").Header(CooldownHeader, "300")
+	prog := pb.Program()
+	l := &NodeLimits{Program: prog}
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: &lineRecorder{},
+		Vars:    NewMapVariableStorage(),
+		Limits:  l,
+	}
+	if err := vm.Run("Cooldown"); err != nil {
+		t.Fatalf("vm.Run(Cooldown) = %v", err)
+	}
+	if err := vm.Run("Cooldown"); !errors.Is(err, ErrNodeCoolingDown) {
+		t.Errorf("second vm.Run(Cooldown) = %v, want %v", err, ErrNodeCoolingDown)
+	}
+}
+
+func TestNodeLimitsBadHeader(t *testing.T) {
+	pb := NewProgramBuilder("Limits")
+	lineNode(pb, "Free").Header(FrequencyHeader, "often")
+	l := &NodeLimits{Program: pb.Program()}
+	if err := l.Check("Free"); err == nil {
+		t.Errorf("Check(Free) error = nil, want error for bad frequency header")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+)
+
+// LineHash returns a short hash of a line's source text, for recording which
+// version of a line was translated (see StringTableRow.SourceHash). It is the
+// first 8 hex digits of the SHA-256 of the text, as used by Yarn Spinner's
+// "lock" column.
+func LineHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:4])
+}
+
+// LineCounts counts lines by translation status.
+type LineCounts struct {
+	Total      int `json:"total"`
+	Translated int `json:"translated"` // translated from the current source
+	Outdated   int `json:"outdated"`   // translated, but the source has changed since
+	Missing    int `json:"missing"`    // not translated
+}
+
+// Percent returns n as a percentage of Total (0 if there are no lines).
+func (c LineCounts) Percent(n int) float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(n) / float64(c.Total)
+}
+
+func (c *LineCounts) add(status string) {
+	c.Total++
+	switch status {
+	case "translated":
+		c.Translated++
+	case "outdated":
+		c.Outdated++
+	case "missing":
+		c.Missing++
+	}
+}
+
+// CoverageReport describes how completely a string table translates a base
+// string table.
+type CoverageReport struct {
+	Locale string `json:"locale"`
+	LineCounts
+
+	// Nodes breaks the counts down by node (of the base string table).
+	Nodes map[string]*LineCounts `json:"nodes"`
+
+	// OutdatedIDs and MissingIDs are the IDs of lines that need
+	// translating, sorted by file, line number, and ID.
+	OutdatedIDs []string `json:"outdated_ids,omitempty"`
+	MissingIDs  []string `json:"missing_ids,omitempty"`
+}
+
+// Coverage compares the string table, as a translation, against the base
+// (source) string table. Each line of the base table is:
+//
+//   - missing, if the translation has no row for it, the row's text is
+//     empty, or the row has no SourceHash and the same text as the base (an
+//     untranslated copy of the source, such as those made by
+//     XLIFF.StringTable);
+//   - outdated, if the row's SourceHash doesn't match the LineHash of the
+//     base text;
+//   - otherwise translated. Rows without a SourceHash can't be checked, so
+//     are assumed to be up to date.
+//
+// Rows in the translation that aren't in the base table are ignored.
+func (t *StringTable) Coverage(base *StringTable) *CoverageReport {
+	rows := make([]*StringTableRow, 0, len(base.Table))
+	for _, row := range base.Table {
+		if row != nil {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rowLess(rows[i], rows[j]) })
+
+	r := &CoverageReport{
+		Locale: t.Language.String(),
+		Nodes:  make(map[string]*LineCounts),
+	}
+	for _, src := range rows {
+		status := translationStatus(src, t.row(src.ID))
+		switch status {
+		case "outdated":
+			r.OutdatedIDs = append(r.OutdatedIDs, src.ID)
+		case "missing":
+			r.MissingIDs = append(r.MissingIDs, src.ID)
+		}
+		r.add(status)
+		nc := r.Nodes[src.Node]
+		if nc == nil {
+			nc = new(LineCounts)
+			r.Nodes[src.Node] = nc
+		}
+		nc.add(status)
+	}
+	return r
+}
+
+// translationStatus returns "translated", "outdated", or "missing" (see
+// StringTable.Coverage) for a base row and its translation (which may be nil).
+func translationStatus(src, tr *StringTableRow) string {
+	switch {
+	case tr == nil || tr.Text == "":
+		return "missing"
+	case tr.SourceHash != "":
+		if tr.SourceHash != LineHash(src.Text) {
+			return "outdated"
+		}
+	case tr.Text == src.Text:
+		return "missing"
+	}
+	return "translated"
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+func TestCoverage(t *testing.T) {
+	base := &StringTable{Table: map[string]*StringTableRow{
+		"line:a": {ID: "line:a", Text: "Hello", File: "a.yarn", Node: "Start", LineNumber: 1},
+		"line:b": {ID: "line:b", Text: "Goodbye, friend", File: "a.yarn", Node: "Start", LineNumber: 2},
+		"line:c": {ID: "line:c", Text: "OK", File: "a.yarn", Node: "Start", LineNumber: 3},
+		"line:d": {ID: "line:d", Text: "Run!", File: "a.yarn", Node: "Chase", LineNumber: 8},
+		"line:e": {ID: "line:e", Text: "Hide!", File: "a.yarn", Node: "Chase", LineNumber: 9},
+	}}
+	fr := &StringTable{
+		Language: language.French,
+		Table: map[string]*StringTableRow{
+			"line:a": {ID: "line:a", Text: "Bonjour", SourceHash: LineHash("Hello")},
+			"line:b": {ID: "line:b", Text: "Au revoir", SourceHash: LineHash("Goodbye")},
+			"line:c": {ID: "line:c", Text: "OK", SourceHash: LineHash("OK")},
+			"line:d": {ID: "line:d", Text: "Run!"},    // untranslated copy
+			"line:z": {ID: "line:z", Text: "Inconnu"}, // not in base
+		},
+	}
+	got := fr.Coverage(base)
+	want := &CoverageReport{
+		Locale:     "fr",
+		LineCounts: LineCounts{Total: 5, Translated: 2, Outdated: 1, Missing: 2},
+		Nodes: map[string]*LineCounts{
+			"Start": {Total: 3, Translated: 2, Outdated: 1},
+			"Chase": {Total: 2, Missing: 2},
+		},
+		OutdatedIDs: []string{"line:b"},
+		MissingIDs:  []string{"line:d", "line:e"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("fr.Coverage(base) diff (-got +want):\n%s", diff)
+	}
+	if got, want := got.Percent(got.Translated), 40.0; got != want {
+		t.Errorf("got.Percent(got.Translated) = %v, want %v", got, want)
+	}
+	if got := (LineCounts{}).Percent(0); got != 0 {
+		t.Errorf("LineCounts{}.Percent(0) = %v, want 0", got)
+	}
+}
+
+func TestStringTableSourceHash(t *testing.T) {
+	const csv = "id,text,file,node,lineNumber,sourceHash\n" +
+		"line:a,Bonjour,a.yarn,Start,1,185f8db3\n" +
+		"line:b,Au revoir,a.yarn,Start,2,\n"
+	st, err := ReadStringTable(strings.NewReader(csv), "fr")
+	if err != nil {
+		t.Fatalf("ReadStringTable = %v", err)
+	}
+	if got, want := st.Table["line:a"].SourceHash, LineHash("Hello"); got != want {
+		t.Errorf("st.Table[line:a].SourceHash = %q, want %q", got, want)
+	}
+	var buf strings.Builder
+	if err := WriteStringTable(&buf, st); err != nil {
+		t.Fatalf("WriteStringTable = %v", err)
+	}
+	if diff := cmp.Diff(buf.String(), csv); diff != "" {
+		t.Errorf("WriteStringTable diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := ReadStringTable(strings.NewReader("id,text,file,node\nline:a,Hi,a.yarn,Start\n"), "en"); err == nil {
+		t.Error("ReadStringTable(4 columns) = nil error, want error")
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultMaxNodeChain is the default value of VirtualMachine.MaxNodeChain.
+const DefaultMaxNodeChain = 1000
+
+// ErrNodeCycle is wrapped by NodeCycleError.
+const ErrNodeCycle = virtualMachineError("runaway node cycle")
+
+// NodeCycleError is returned when the dialogue enters too many nodes in a
+// row without delivering anything to the handler (see
+// VirtualMachine.MaxNodeChain), which usually means that content jumps back
+// to itself unconditionally.
+type NodeCycleError struct {
+	// Path is the cycle: the nodes entered since the previous entry to the
+	// last node, starting and ending with that node. If no node was entered
+	// twice, Path is the whole chain of nodes.
+	Path []string
+}
+
+func (e *NodeCycleError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNodeCycle, strings.Join(e.Path, " -> "))
+}
+
+// Unwrap returns ErrNodeCycle.
+func (e *NodeCycleError) Unwrap() error { return ErrNodeCycle }
+
+// NodeChain returns the nodes entered since the dialogue last delivered a
+// line, options, or a command to the handler (or since Run began), in order.
+// It is empty if MaxNodeChain is negative.
+func (vm *VirtualMachine) NodeChain() []string {
+	return slices.Clone(vm.chain)
+}
+
+// enterChain adds the node to the chain, and returns a NodeCycleError if the
+// chain is too long.
+func (vm *VirtualMachine) enterChain(name string) error {
+	max := vm.MaxNodeChain
+	if max == 0 {
+		max = DefaultMaxNodeChain
+	}
+	if max < 0 {
+		return nil
+	}
+	prev := -1
+	for i := len(vm.chain) - 1; i >= 0; i-- {
+		if vm.chain[i] == name {
+			prev = i
+			break
+		}
+	}
+	vm.chain = append(vm.chain, name)
+	if len(vm.chain) <= max {
+		return nil
+	}
+	if prev < 0 {
+		return &NodeCycleError{Path: slices.Clone(vm.chain)}
+	}
+	return &NodeCycleError{Path: slices.Clone(vm.chain[prev:])}
+}
+
+// resetChain empties the chain, because the dialogue delivered something.
+func (vm *VirtualMachine) resetChain() {
+	vm.chain = vm.chain[:0]
+}
@@ -0,0 +1,93 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// stopAfter stops the dialogue after n lines.
+type stopAfter struct {
+	FakeDialogueHandler
+	n int
+}
+
+func (h *stopAfter) Line(Line) error {
+	if h.n--; h.n <= 0 {
+		return Stop
+	}
+	return nil
+}
+
+func TestNodeCycle(t *testing.T) {
+	pb := NewProgramBuilder("Cycle")
+	pb.Node("Start").Line("line:start", 0).RunNode("A")
+	pb.Node("A").RunNode("B")
+	pb.Node("B").RunNode("C")
+	pb.Node("C").RunNode("A")
+	pb.Node("Talk").Line("line:talk", 0).RunNode("Talk")
+	prog := pb.Program()
+
+	tests := []struct {
+		start    string
+		max      int
+		wantPath []string
+	}{
+		{start: "Start", wantPath: []string{"B", "C", "A", "B"}},
+		{start: "Start", max: 2, wantPath: []string{"A", "B", "C"}},
+		{start: "A", max: 5, wantPath: []string{"C", "A", "B", "C"}},
+		{start: "Talk", max: 1},
+	}
+	for _, test := range tests {
+		vm := &VirtualMachine{
+			Program:      prog,
+			Handler:      &stopAfter{n: 2000},
+			Vars:         NewMapVariableStorage(),
+			MaxNodeChain: test.max,
+		}
+		err := vm.Run(test.start)
+		if test.wantPath == nil {
+			if err != nil {
+				t.Errorf("MaxNodeChain = %d: vm.Run(%s) = %v, want nil", test.max, test.start, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrNodeCycle) {
+			t.Errorf("MaxNodeChain = %d: vm.Run(%s) = %v, want %v", test.max, test.start, err, ErrNodeCycle)
+			continue
+		}
+		var nce *NodeCycleError
+		if !errors.As(err, &nce) {
+			t.Fatalf("errors.As(%v, *NodeCycleError) = false", err)
+		}
+		if diff := cmp.Diff(nce.Path, test.wantPath); diff != "" {
+			t.Errorf("MaxNodeChain = %d: vm.Run(%s) cycle diff (-got +want):\n%s", test.max, test.start, diff)
+		}
+	}
+
+	vm := &VirtualMachine{Program: prog, Handler: FakeDialogueHandler{}, Vars: NewMapVariableStorage()}
+	if err := vm.SetNode("Start"); err != nil {
+		t.Fatalf("vm.SetNode(Start) = %v", err)
+	}
+	if err := vm.SetNode("A"); err != nil {
+		t.Fatalf("vm.SetNode(A) = %v", err)
+	}
+	if diff := cmp.Diff(vm.NodeChain(), []string{"Start", "A"}); diff != "" {
+		t.Errorf("vm.NodeChain() diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// PauseReason explains why the debugger paused the VM.
+type PauseReason int
+
+// Reasons for pausing.
+const (
+	PauseBreakpoint PauseReason = iota // a breakpoint was reached
+	PauseStep                          // a single step completed
+	PauseRequested                     // Pause was called
+)
+
+func (r PauseReason) String() string {
+	switch r {
+	case PauseBreakpoint:
+		return "breakpoint"
+	case PauseStep:
+		return "step"
+	case PauseRequested:
+		return "pause"
+	}
+	return fmt.Sprintf("(invalid PauseReason %d)", int(r))
+}
+
+// Breakpoint identifies an instruction within a node.
+type Breakpoint struct {
+	Node string
+	PC   int
+}
+
+// DebugState is a snapshot of the VM taken when the debugger pauses it.
+type DebugState struct {
+	Reason PauseReason
+
+	// Node is the current node. It is shared with the program, so don't
+	// modify it.
+	Node *yarnpb.Node
+
+	// PC is the index of the instruction about to be executed.
+	PC int
+
+	// Stack and Options are copies of the VM's stack and pending options.
+	Stack   []any
+	Options []Option
+}
+
+// Instruction returns the instruction about to be executed.
+func (s *DebugState) Instruction() *yarnpb.Instruction {
+	if s.Node == nil || s.PC < 0 || s.PC >= len(s.Node.Instructions) {
+		return nil
+	}
+	return s.Node.Instructions[s.PC]
+}
+
+// Debugger pauses a VM at breakpoints or after single steps. To use it, set
+// the VM's Debugger field, run the VM in its own goroutine, and receive from
+// Paused in another. Each time a DebugState is received, the VM is blocked
+// until Continue, Step, or Abort is called.
+//
+// Breakpoints can be set and cleared at any time.
+type Debugger struct {
+	mu          sync.Mutex
+	breakpoints map[Breakpoint]bool
+	stepping    bool
+	pauseReq    bool
+
+	pausedCh chan *DebugState
+	resumeCh chan error
+}
+
+// NewDebugger creates a new debugger.
+func NewDebugger() *Debugger {
+	return &Debugger{
+		breakpoints: make(map[Breakpoint]bool),
+		pausedCh:    make(chan *DebugState),
+		resumeCh:    make(chan error, 1),
+	}
+}
+
+// SetBreakpoint sets a breakpoint before the instruction at pc in the node.
+func (d *Debugger) SetBreakpoint(node string, pc int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.breakpoints[Breakpoint{node, pc}] = true
+}
+
+// ClearBreakpoint removes a breakpoint.
+func (d *Debugger) ClearBreakpoint(node string, pc int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.breakpoints, Breakpoint{node, pc})
+}
+
+// ToggleBreakpoint sets the breakpoint if it wasn't set, or clears it if it
+// was. It returns true if the breakpoint is now set.
+func (d *Debugger) ToggleBreakpoint(node string, pc int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bp := Breakpoint{node, pc}
+	if d.breakpoints[bp] {
+		delete(d.breakpoints, bp)
+		return false
+	}
+	d.breakpoints[bp] = true
+	return true
+}
+
+// HasBreakpoint reports whether a breakpoint is set.
+func (d *Debugger) HasBreakpoint(node string, pc int) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.breakpoints[Breakpoint{node, pc}]
+}
+
+// Breakpoints returns all the breakpoints, sorted by node and pc.
+func (d *Debugger) Breakpoints() []Breakpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	bps := make([]Breakpoint, 0, len(d.breakpoints))
+	for bp := range d.breakpoints {
+		bps = append(bps, bp)
+	}
+	sort.Slice(bps, func(i, j int) bool {
+		if bps[i].Node != bps[j].Node {
+			return bps[i].Node < bps[j].Node
+		}
+		return bps[i].PC < bps[j].PC
+	})
+	return bps
+}
+
+// Pause asks the debugger to pause before the next instruction.
+func (d *Debugger) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pauseReq = true
+}
+
+// Paused returns a channel that receives the VM state each time it pauses.
+func (d *Debugger) Paused() <-chan *DebugState { return d.pausedCh }
+
+// Continue resumes execution until the next breakpoint (or pause request).
+// It must only be called while the VM is paused.
+func (d *Debugger) Continue() {
+	d.mu.Lock()
+	d.stepping = false
+	d.mu.Unlock()
+	d.resumeCh <- nil
+}
+
+// Step executes one instruction and then pauses again. It must only be called
+// while the VM is paused.
+func (d *Debugger) Step() {
+	d.mu.Lock()
+	d.stepping = true
+	d.mu.Unlock()
+	d.resumeCh <- nil
+}
+
+// Abort stops the VM with an error (or Stop, if err is nil). It must only be
+// called while the VM is paused.
+func (d *Debugger) Abort(err error) {
+	if err == nil {
+		err = Stop
+	}
+	d.resumeCh <- err
+}
+
+// before is called by the VM before each instruction. If the VM should pause,
+// it sends the state to the paused channel and blocks until resumed.
+func (d *Debugger) before(vm *VirtualMachine) error {
+	d.mu.Lock()
+	var reason PauseReason
+	switch {
+	case d.pauseReq:
+		reason = PauseRequested
+	case d.stepping:
+		reason = PauseStep
+	case d.breakpoints[Breakpoint{vm.state.node.Name, vm.state.pc}]:
+		reason = PauseBreakpoint
+	default:
+		d.mu.Unlock()
+		return nil
+	}
+	d.pauseReq = false
+	d.mu.Unlock()
+
+	d.pausedCh <- &DebugState{
+		Reason:  reason,
+		Node:    vm.state.node,
+		PC:      vm.state.pc,
+		Stack:   append([]any(nil), vm.state.stack...),
+		Options: CloneOptions(vm.state.options),
+	}
+	return <-d.resumeCh
+}
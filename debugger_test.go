@@ -0,0 +1,98 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDebugger(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/Jumps.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+	dbg := NewDebugger()
+	dbg.SetBreakpoint("NodeNameDestination", 1)
+	vm := &VirtualMachine{
+		Program:  prog,
+		Handler:  FakeDialogueHandler{},
+		Vars:     NewMapVariableStorage(),
+		Debugger: dbg,
+	}
+	done := make(chan error)
+	go func() { done <- vm.Run("Start") }()
+
+	s := <-dbg.Paused()
+	if s.Reason != PauseBreakpoint || s.Node.Name != "NodeNameDestination" || s.PC != 1 {
+		t.Fatalf("first pause = (%v, %s, %d), want (breakpoint, NodeNameDestination, 1)", s.Reason, s.Node.Name, s.PC)
+	}
+	if got, want := s.Instruction().Opcode, yarnpb.Instruction_PUSH_STRING; got != want {
+		t.Errorf("first pause instruction = %v, want %v", got, want)
+	}
+	dbg.Step()
+
+	s = <-dbg.Paused()
+	if s.Reason != PauseStep || s.PC != 2 {
+		t.Fatalf("second pause = (%v, %d), want (step, 2)", s.Reason, s.PC)
+	}
+	if len(s.Stack) != 1 || s.Stack[0] != "NodeNameConstantExpression" {
+		t.Errorf("second pause stack = %v, want [NodeNameConstantExpression]", s.Stack)
+	}
+	dbg.Abort(errors.New("enough"))
+
+	if err := <-done; err == nil {
+		t.Errorf("vm.Run(Start) = nil, want error")
+	}
+}
+
+func TestDebuggerOptionsReuseEvents(t *testing.T) {
+	pb := NewProgramBuilder("Debug")
+	pb.Node("Start").
+		PushString("Ava").Option("line:a", "next", 1, false).
+		ShowOptions().Jump().
+		Label("next").
+		PushString("Zed").Option("line:b", "end", 1, false).
+		ShowOptions().Jump().
+		Label("end").Stop()
+
+	dbg := NewDebugger()
+	dbg.SetBreakpoint("Start", 2)
+	vm := &VirtualMachine{
+		Program:     pb.Program(),
+		Handler:     &choosingHandler{&lineRecorder{}},
+		Vars:        NewMapVariableStorage(),
+		Debugger:    dbg,
+		ReuseEvents: true,
+	}
+	done := make(chan error)
+	go func() { done <- vm.Run("Start") }()
+
+	s := <-dbg.Paused()
+	dbg.Continue()
+	if err := <-done; err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	// The second menu has reused the VM's buffers by now.
+	want := []Option{
+		{ID: 0, Line: Line{ID: "line:a", Substitutions: []string{"Ava"}}, DestinationNode: "next", IsAvailable: true},
+	}
+	if diff := cmp.Diff(s.Options, want); diff != "" {
+		t.Errorf("paused options diff (-got +want):\n%s", diff)
+	}
+}
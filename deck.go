@@ -0,0 +1,273 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrDeckEmpty is returned by Deck.Draw when no storylet can be drawn.
+const ErrDeckEmpty = virtualMachineError("no storylet available")
+
+// Node headers used by Deck.
+const (
+	// WeightHeader sets the relative likelihood of drawing a storylet. The
+	// default weight is 1. Storylets with weight 0 are never drawn.
+	WeightHeader = "weight"
+
+	// UsesHeader limits the number of times a storylet can be drawn, after
+	// which it is exhausted.
+	UsesHeader = "uses"
+)
+
+// Storylet is a node in a Deck that could be drawn.
+type Storylet struct {
+	// Node is the name of the node.
+	Node string
+
+	// Weight is the node's relative likelihood of being drawn.
+	Weight float64
+
+	// Complexity is the number of when: conditions the node has.
+	Complexity int
+
+	// Draws is the number of times the node has been drawn.
+	Draws int
+}
+
+// DeckState is the serializable state of a Deck, for saving across game
+// sessions.
+type DeckState struct {
+	// Draws is the total number of draws from the deck.
+	Draws int `json:"draws,omitempty"`
+
+	// Drawn maps node names to the number of times each has been drawn.
+	Drawn map[string]int `json:"drawn,omitempty"`
+
+	// Last maps node names to the value of Draws when each was last drawn.
+	Last map[string]int `json:"last,omitempty"`
+}
+
+// Deck is a quality-based narrative layer: it draws storylets from a group of
+// nodes (see Candidates), according to the current variables. A storylet is
+// available if its when: conditions are true, it has not been used up (see
+// UsesHeader), and it is not cooling down. Available storylets are drawn at
+// random in proportion to their weights (see WeightHeader). For example:
+//
+//	title: Tavern_Brawl
+//	tags: tavern
+//	when: $drunk > 3
+//	weight: 2
+//	uses: 1
+//	---
+//	Patron: You looking at me?
+type Deck struct {
+	Program *yarnpb.Program
+	Vars    VariableStorage
+	FuncMap FuncMap
+
+	// Group is the group of nodes in the deck.
+	Group string
+
+	// Cooldown is the number of draws after a storylet is drawn before it is
+	// available again. For example, with Cooldown 1 the same storylet is
+	// never drawn twice in a row.
+	Cooldown int
+
+	// MostSpecific, if true, limits each draw to the available storylets with
+	// the most when: conditions.
+	MostSpecific bool
+
+	// Rand is the source of randomness. If nil, the top-level functions of
+	// math/rand are used.
+	Rand *rand.Rand
+
+	// Workers is passed to Candidates.
+	Workers int
+
+	mu    sync.Mutex
+	cands *Candidates
+	state DeckState
+}
+
+// Available returns the storylets that could be drawn now, in node name
+// order.
+func (d *Deck) Available() ([]Storylet, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.available()
+}
+
+func (d *Deck) available() ([]Storylet, error) {
+	if d.cands == nil {
+		d.cands = &Candidates{Program: d.Program, FuncMap: d.FuncMap, Workers: d.Workers}
+	}
+	var nodes []string
+	var weights []float64
+	for _, name := range d.cands.Group(d.Group) {
+		node := d.Program.Nodes[name]
+		if uses, ok, err := deckHeaderFloat(node, UsesHeader); err != nil {
+			return nil, err
+		} else if ok && float64(d.state.Drawn[name]) >= uses {
+			continue
+		}
+		if last, ok := d.state.Last[name]; ok && d.state.Draws-last < d.Cooldown {
+			continue
+		}
+		w, ok, err := deckHeaderFloat(node, WeightHeader)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			w = 1
+		}
+		if w <= 0 {
+			continue
+		}
+		nodes = append(nodes, name)
+		weights = append(weights, w)
+	}
+
+	cands, err := d.cands.Evaluate(d.Vars, nodes)
+	if err != nil {
+		return nil, err
+	}
+	var out []Storylet
+	for i, c := range cands {
+		if !c.Available {
+			continue
+		}
+		out = append(out, Storylet{
+			Node:       c.Node,
+			Weight:     weights[i],
+			Complexity: c.Complexity,
+			Draws:      d.state.Drawn[c.Node],
+		})
+	}
+	if d.MostSpecific && len(out) > 0 {
+		best, _ := BestCandidate(cands)
+		specific := out[:0]
+		for _, s := range out {
+			if s.Complexity == best.Complexity {
+				specific = append(specific, s)
+			}
+		}
+		out = specific
+	}
+	return out, nil
+}
+
+// deckHeaderFloat returns the value of a numeric header, if present.
+func deckHeaderFloat(node *yarnpb.Node, key string) (float64, bool, error) {
+	for _, h := range node.Headers {
+		if h.Key != key {
+			continue
+		}
+		v, err := strconv.ParseFloat(h.Value, 64)
+		if err != nil {
+			return 0, false, fmt.Errorf("node %q: %s header: %w", node.Name, key, err)
+		}
+		return v, true, nil
+	}
+	return 0, false, nil
+}
+
+// Draw chooses an available storylet at random, records it as drawn, and
+// returns its node name. It returns ErrDeckEmpty if none are available.
+func (d *Deck) Draw() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	avail, err := d.available()
+	if err != nil {
+		return "", err
+	}
+	if len(avail) == 0 {
+		return "", ErrDeckEmpty
+	}
+	total := 0.0
+	for _, s := range avail {
+		total += s.Weight
+	}
+	x := d.float64() * total
+	chosen := avail[len(avail)-1].Node
+	for _, s := range avail {
+		if x < s.Weight {
+			chosen = s.Node
+			break
+		}
+		x -= s.Weight
+	}
+
+	d.state.Draws++
+	if d.state.Drawn == nil {
+		d.state.Drawn = make(map[string]int)
+		d.state.Last = make(map[string]int)
+	}
+	d.state.Drawn[chosen]++
+	d.state.Last[chosen] = d.state.Draws
+	return chosen, nil
+}
+
+func (d *Deck) float64() float64 {
+	if d.Rand == nil {
+		return rand.Float64()
+	}
+	return d.Rand.Float64()
+}
+
+// Run draws a storylet and runs its node to completion with the handler,
+// using the deck's program, variables, and functions. It returns the name of
+// the node.
+func (d *Deck) Run(handler DialogueHandler) (string, error) {
+	node, err := d.Draw()
+	if err != nil {
+		return "", err
+	}
+	vm := &VirtualMachine{
+		Program: d.Program,
+		Handler: handler,
+		Vars:    d.Vars,
+		FuncMap: d.FuncMap,
+	}
+	return node, vm.Run(node)
+}
+
+// State returns a copy of the deck's draw history.
+func (d *Deck) State() DeckState {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DeckState{
+		Draws: d.state.Draws,
+		Drawn: copyMap(d.state.Drawn),
+		Last:  copyMap(d.state.Last),
+	}
+}
+
+// SetState replaces the deck's draw history, e.g. when loading a saved game.
+func (d *Deck) SetState(s DeckState) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.state = DeckState{
+		Draws: s.Draws,
+		Drawn: copyMap(s.Drawn),
+		Last:  copyMap(s.Last),
+	}
+}
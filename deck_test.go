@@ -0,0 +1,146 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func deckProgram() *yarnpb.Program {
+	pb := NewProgramBuilder("Storylets")
+	lineNode(pb, "Bard").Tags("tavern")
+	lineNode(pb, "Brawl").Tags("tavern").Header(WhenHeader, "$drunk > 3").Header(UsesHeader, "1")
+	lineNode(pb, "Quiet").Tags("tavern").Header(WeightHeader, "0")
+	lineNode(pb, "Rumor").Tags("tavern").Header(WhenHeader, "$drunk > 1").Header(WeightHeader, "3")
+	return pb.Program()
+}
+
+func TestDeckAvailable(t *testing.T) {
+	vars := NewMapVariableStorage()
+	vars.SetValue("$drunk", float32(5))
+	d := &Deck{Program: deckProgram(), Vars: vars, Group: "tavern"}
+
+	got, err := d.Available()
+	if err != nil {
+		t.Fatalf("Available() error = %v", err)
+	}
+	want := []Storylet{
+		{Node: "Bard", Weight: 1},
+		{Node: "Brawl", Weight: 1, Complexity: 1},
+		{Node: "Rumor", Weight: 3, Complexity: 1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Available() diff (-got +want):\n%s", diff)
+	}
+
+	d.MostSpecific = true
+	got, err = d.Available()
+	if err != nil {
+		t.Fatalf("Available() error = %v", err)
+	}
+	if diff := cmp.Diff(got, want[1:]); diff != "" {
+		t.Errorf("Available() with MostSpecific diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestDeckDraw(t *testing.T) {
+	vars := NewMapVariableStorage()
+	vars.SetValue("$drunk", float32(5))
+	d := &Deck{
+		Program:  deckProgram(),
+		Vars:     vars,
+		Group:    "tavern",
+		Cooldown: 1,
+		Rand:     rand.New(rand.NewSource(1)),
+	}
+
+	counts := make(map[string]int)
+	prev := ""
+	for i := 0; i < 100; i++ {
+		node, err := d.Draw()
+		if err != nil {
+			t.Fatalf("Draw() error = %v", err)
+		}
+		if node == prev {
+			t.Errorf("Draw() = %q twice in a row despite Cooldown", node)
+		}
+		prev = node
+		counts[node]++
+	}
+	if counts["Brawl"] != 1 {
+		t.Errorf("Brawl drawn %d times, want 1 (uses: 1)", counts["Brawl"])
+	}
+	if counts["Quiet"] != 0 {
+		t.Errorf("Quiet drawn %d times, want 0 (weight: 0)", counts["Quiet"])
+	}
+	if counts["Bard"] == 0 || counts["Rumor"] == 0 {
+		t.Errorf("counts = %v, want Bard and Rumor drawn", counts)
+	}
+
+	// Save and restore into a new deck.
+	d2 := &Deck{Program: d.Program, Vars: vars, Group: "tavern"}
+	d2.SetState(d.State())
+	vars.SetValue("$drunk", float32(0))
+	for i := 0; i < 10; i++ {
+		node, err := d2.Draw()
+		if err != nil {
+			t.Fatalf("Draw() error = %v", err)
+		}
+		if node != "Bard" {
+			t.Errorf("Draw() = %q, want Bard", node)
+		}
+	}
+	if got, want := d2.State().Draws, 110; got != want {
+		t.Errorf("State().Draws = %d, want %d", got, want)
+	}
+
+	d2.Cooldown = 1
+	if _, err := d2.Draw(); !errors.Is(err, ErrDeckEmpty) {
+		t.Errorf("Draw() = %v, want %v", err, ErrDeckEmpty)
+	}
+}
+
+func TestDeckRun(t *testing.T) {
+	d := &Deck{
+		Program: deckProgram(),
+		Vars:    NewMapVariableStorage(),
+		Group:   "tavern",
+	}
+	rec := &lineRecorder{}
+	node, err := d.Run(rec)
+	if err != nil {
+		t.Fatalf("Run(rec) error = %v", err)
+	}
+	if node != "Bard" {
+		t.Errorf("Run(rec) = %q, want Bard", node)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:Bard"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestDeckBadHeader(t *testing.T) {
+	pb := NewProgramBuilder("Storylets")
+	lineNode(pb, "A").Tags("tavern").Header(WeightHeader, "heavy")
+	d := &Deck{Program: pb.Program(), Vars: NewMapVariableStorage(), Group: "tavern"}
+	if _, err := d.Draw(); err == nil {
+		t.Errorf("Draw() error = nil, want error for bad weight header")
+	}
+}
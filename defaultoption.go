@@ -0,0 +1,91 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// DefaultOptionTag marks an option's line tag (in the metadata table) as the
+// default option, e.g. #default.
+const DefaultOptionTag = "default"
+
+// DefaultOption returns the index of the default option: the first
+// available option with IsDefault set. It returns -1 if there isn't one.
+func DefaultOption(options []Option) int {
+	for i, o := range options {
+		if o.IsAvailable && o.IsDefault {
+			return i
+		}
+	}
+	return -1
+}
+
+var (
+	_ DialogueHandler      = &DefaultOptionHandler{}
+	_ CommandResultHandler = &DefaultOptionHandler{}
+	_ SkipHandler          = &DefaultOptionHandler{}
+)
+
+// DefaultOptionHandler is a DialogueHandler that sets IsDefault on options
+// tagged with DefaultOptionTag, before passing them to the embedded handler.
+// Since IsDefault is part of the options, it is kept in snapshots taken
+// during Options, so a restored game highlights the same option.
+//
+// In auto-pick mode (see SetAutoPick), the default option is chosen without
+// asking the embedded handler, e.g. for accessibility auto-play. Options
+// without an available default are still delivered.
+type DefaultOptionHandler struct {
+	DialogueHandler
+	StringTable *StringTable
+
+	autoPick atomic.Bool
+}
+
+// SetAutoPick turns auto-pick mode on or off. It is safe to call from any
+// goroutine, including from within a handler method.
+func (h *DefaultOptionHandler) SetAutoPick(on bool) { h.autoPick.Store(on) }
+
+// AutoPick reports whether auto-pick mode is on.
+func (h *DefaultOptionHandler) AutoPick() bool { return h.autoPick.Load() }
+
+// Options marks the default option, then either chooses it (in auto-pick
+// mode) or calls the embedded handler.
+func (h *DefaultOptionHandler) Options(options []Option) (int, error) {
+	for i := range options {
+		if row := h.StringTable.row(options[i].Line.ID); row != nil && slices.Contains(row.Tags, DefaultOptionTag) {
+			options[i].IsDefault = true
+		}
+	}
+	if h.AutoPick() {
+		if i := DefaultOption(options); i >= 0 {
+			return options[i].ID, nil
+		}
+	}
+	return h.DialogueHandler.Options(options)
+}
+
+// CommandResult passes the command to the embedded handler's CommandResult
+// method, if it has one.
+func (h *DefaultOptionHandler) CommandResult(command string) (any, error) {
+	return forwardCommandResult(h.DialogueHandler, command)
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *DefaultOptionHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+// defaultsRecorder records the options it is given, and chooses the first.
+type defaultsRecorder struct {
+	*lineRecorder
+	options [][]Option
+}
+
+func (h *defaultsRecorder) Options(options []Option) (int, error) {
+	h.options = append(h.options, CloneOptions(options))
+	return options[0].ID, nil
+}
+
+func TestDefaultOptionHandler(t *testing.T) {
+	pb := NewProgramBuilder("Defaults")
+	pb.Node("Start").
+		Option("line:fight", "Fight", 0, false).
+		Option("line:talk", "Talk", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Fight").Line("line:fought", 0)
+	pb.Node("Talk").Line("line:talked", 0)
+	prog := pb.Program()
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:talk": {ID: "line:talk", Tags: []string{"friendly", DefaultOptionTag}},
+	}}
+
+	rec := &defaultsRecorder{lineRecorder: &lineRecorder{}}
+	h := &DefaultOptionHandler{DialogueHandler: rec, StringTable: st}
+	vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got, want := len(rec.options), 1; got != want {
+		t.Fatalf("handler got %d Options events, want %d", got, want)
+	}
+	if got, want := DefaultOption(rec.options[0]), 1; got != want {
+		t.Errorf("DefaultOption(options) = %d, want %d", got, want)
+	}
+	if rec.options[0][0].IsDefault {
+		t.Error("options[0].IsDefault = true, want false")
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:fought"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+
+	// In auto-pick mode, the handler isn't asked.
+	rec = &defaultsRecorder{lineRecorder: &lineRecorder{}}
+	h.DialogueHandler = rec
+	h.SetAutoPick(true)
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got := len(rec.options); got != 0 {
+		t.Errorf("handler got %d Options events, want 0", got)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:talked"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+
+	// Without a default, options are delivered even in auto-pick mode.
+	h.StringTable = nil
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got := len(rec.options); got != 1 {
+		t.Errorf("handler got %d Options events, want 1", got)
+	}
+}
+
+func TestDefaultOption(t *testing.T) {
+	options := []Option{
+		{ID: 0, IsAvailable: true},
+		{ID: 1, IsDefault: true},
+		{ID: 2, IsAvailable: true, IsDefault: true},
+	}
+	if got, want := DefaultOption(options), 2; got != want {
+		t.Errorf("DefaultOption(options) = %d, want %d", got, want)
+	}
+	if got, want := DefaultOption(options[:2]), -1; got != want {
+		t.Errorf("DefaultOption(options[:2]) = %d, want %d", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity indicates how serious a Diagnostic is.
+type Severity int
+
+// Diagnostic severities.
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return fmt.Sprintf("(invalid Severity %d)", int(s))
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) { return []byte(s.String()), nil }
+
+// UnmarshalText decodes a severity name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "error":
+		*s = SeverityError
+	case "warning":
+		*s = SeverityWarning
+	case "info":
+		*s = SeverityInfo
+	default:
+		return fmt.Errorf("unknown severity %q", text)
+	}
+	return nil
+}
+
+// Diagnostic describes a problem found in a program or string table by one of
+// the checks (ValidateProgram, CheckStringTable, and so on).
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+
+	// Node is the name of the node containing the problem, if any.
+	Node string `json:"node,omitempty"`
+
+	// PC is the index of the offending instruction within the node, or -1 if
+	// the problem isn't about a particular instruction.
+	PC int `json:"pc"`
+
+	// LineID is the string ID of the offending line, if any.
+	LineID string `json:"line_id,omitempty"`
+
+	// Message describes the problem.
+	Message string `json:"message"`
+
+	// Pass is the name of the Pass that reported the problem, if it came
+	// from a Pipeline.
+	Pass string `json:"pass,omitempty"`
+}
+
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	if d.Node != "" {
+		b.WriteString(d.Node)
+		if d.PC >= 0 {
+			fmt.Fprintf(&b, ":%06d", d.PC)
+		}
+		b.WriteString(": ")
+	}
+	if d.LineID != "" {
+		fmt.Fprintf(&b, "[%s] ", d.LineID)
+	}
+	fmt.Fprintf(&b, "%v: %s", d.Severity, d.Message)
+	if d.Pass != "" {
+		fmt.Fprintf(&b, " (%s)", d.Pass)
+	}
+	return b.String()
+}
+
+// HasErrors reports whether any of the diagnostics have SeverityError.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -20,6 +20,16 @@ type Line struct {
 	ID string
 	// Values that should be interpolated into the user-facing text.
 	Substitutions []string
+	// The emotion of the line, if known. The VM does not set this, since it
+	// comes from line metadata or markup; see EmotionHandler.
+	Emotion string
+}
+
+// Clone returns a copy of the line that does not share memory with the
+// original. See VirtualMachine.ReuseEvents.
+func (l Line) Clone() Line {
+	l.Substitutions = append([]string(nil), l.Substitutions...)
+	return l
 }
 
 // Option represents one option (among others) that the player could
@@ -39,6 +49,25 @@ type Option struct {
 	// This is false for options that the player _could_ have taken if they had
 	// satisfied some prerequisite earlier in the game.
 	IsAvailable bool
+
+	// Indicates whether the option is the one to pre-select, e.g. to
+	// highlight it for gamepad players. This is set by DefaultOptionHandler
+	// for options tagged with DefaultOptionTag.
+	IsDefault bool
+}
+
+// CloneOptions returns a deep copy of a slice of options, that does not share
+// memory with the original. See VirtualMachine.ReuseEvents.
+func CloneOptions(options []Option) []Option {
+	if options == nil {
+		return nil
+	}
+	c := make([]Option, len(options))
+	for i, o := range options {
+		o.Line = o.Line.Clone()
+		c[i] = o
+	}
+	return c
 }
 
 // DialogueHandler receives events from the virtual machine.
@@ -0,0 +1,151 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"sort"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProgramDiff describes the differences between two compiled programs. All
+// the slices are sorted.
+type ProgramDiff struct {
+	AddedNodes   []string `json:"added_nodes,omitempty"`
+	RemovedNodes []string `json:"removed_nodes,omitempty"`
+	ChangedNodes []string `json:"changed_nodes,omitempty"` // nodes in both, but different
+
+	AddedLines   []string `json:"added_lines,omitempty"`   // line IDs only used in the new program
+	RemovedLines []string `json:"removed_lines,omitempty"` // line IDs only used in the old program
+
+	AddedVariables   []string `json:"added_variables,omitempty"`   // variables only used in the new program
+	RemovedVariables []string `json:"removed_variables,omitempty"` // variables only used in the old program
+}
+
+// IsEmpty reports whether there are no differences.
+func (d *ProgramDiff) IsEmpty() bool {
+	return len(d.AddedNodes) == 0 && len(d.RemovedNodes) == 0 && len(d.ChangedNodes) == 0 &&
+		len(d.AddedLines) == 0 && len(d.RemovedLines) == 0 &&
+		len(d.AddedVariables) == 0 && len(d.RemovedVariables) == 0
+}
+
+// DiffPrograms compares two programs (e.g. from two content builds). Nodes
+// are matched by name. Lines are compared by ID, so a line that has moved
+// between nodes is not reported as added or removed; to find lines whose text
+// has changed, use DiffStringTables. Variables include both those with initial
+// values (declarations) and those used by any instruction.
+func DiffPrograms(oldProg, newProg *yarnpb.Program) *ProgramDiff {
+	d := new(ProgramDiff)
+	for name, node := range newProg.GetNodes() {
+		oldNode, ok := oldProg.GetNodes()[name]
+		switch {
+		case !ok:
+			d.AddedNodes = append(d.AddedNodes, name)
+		case !proto.Equal(oldNode, node):
+			d.ChangedNodes = append(d.ChangedNodes, name)
+		}
+	}
+	for name := range oldProg.GetNodes() {
+		if _, ok := newProg.GetNodes()[name]; !ok {
+			d.RemovedNodes = append(d.RemovedNodes, name)
+		}
+	}
+
+	d.AddedLines, d.RemovedLines = setDiff(programLineIDs(oldProg), programLineIDs(newProg))
+	d.AddedVariables, d.RemovedVariables = setDiff(programVariables(oldProg), programVariables(newProg))
+
+	sort.Strings(d.AddedNodes)
+	sort.Strings(d.RemovedNodes)
+	sort.Strings(d.ChangedNodes)
+	return d
+}
+
+// StringTableDiff describes the differences between two string tables. All
+// the slices are sorted.
+type StringTableDiff struct {
+	Added   []string `json:"added,omitempty"`   // IDs only in the new table
+	Removed []string `json:"removed,omitempty"` // IDs only in the old table
+	Changed []string `json:"changed,omitempty"` // IDs in both, with different text
+}
+
+// DiffStringTables compares the rows of two string tables by ID.
+func DiffStringTables(oldST, newST *StringTable) *StringTableDiff {
+	d := new(StringTableDiff)
+	oldIDs, newIDs := make(map[string]bool), make(map[string]bool)
+	for id := range oldST.Table {
+		oldIDs[id] = true
+	}
+	for id, row := range newST.Table {
+		newIDs[id] = true
+		oldRow := oldST.Table[id]
+		if oldRow == nil || row == nil {
+			continue
+		}
+		if oldRow.Text != row.Text {
+			d.Changed = append(d.Changed, id)
+		}
+	}
+	d.Added, d.Removed = setDiff(oldIDs, newIDs)
+	sort.Strings(d.Changed)
+	return d
+}
+
+// setDiff returns the sorted keys only in b (added) and only in a (removed).
+func setDiff(a, b map[string]bool) (added, removed []string) {
+	for k := range b {
+		if !a[k] {
+			added = append(added, k)
+		}
+	}
+	for k := range a {
+		if !b[k] {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
+
+// programLineIDs returns the set of line IDs used in a program.
+func programLineIDs(prog *yarnpb.Program) map[string]bool {
+	ids := make(map[string]bool)
+	for _, node := range prog.GetNodes() {
+		for _, id := range lineIDs(node) {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// programVariables returns the set of variable names used in a program.
+func programVariables(prog *yarnpb.Program) map[string]bool {
+	vars := make(map[string]bool)
+	for k := range prog.GetInitialValues() {
+		vars[k] = true
+	}
+	for _, node := range prog.GetNodes() {
+		for _, inst := range node.GetInstructions() {
+			switch inst.GetOpcode() {
+			case yarnpb.Instruction_PUSH_VARIABLE, yarnpb.Instruction_STORE_VARIABLE:
+				if len(inst.Operands) > 0 {
+					vars[inst.Operands[0].GetStringValue()] = true
+				}
+			}
+		}
+	}
+	return vars
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDiffPrograms(t *testing.T) {
+	oldProg, err := LoadProgramFile("testdata/Jumps.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+	newProg := proto.Clone(oldProg).(*yarnpb.Program)
+
+	// Remove one node, add another, and change a third.
+	delete(newProg.Nodes, "NodeNameVariableExpression")
+	newProg.Nodes["Extra"] = &yarnpb.Node{
+		Name: "Extra",
+		Instructions: []*yarnpb.Instruction{
+			{Opcode: yarnpb.Instruction_RUN_LINE, Operands: []*yarnpb.Operand{
+				{Value: &yarnpb.Operand_StringValue{StringValue: "line:extra"}},
+			}},
+		},
+	}
+	newProg.Nodes["NodeNameConstantExpression"].Instructions[1].Operands[0] = &yarnpb.Operand{
+		Value: &yarnpb.Operand_StringValue{StringValue: "$otherNodeName"},
+	}
+
+	got := DiffPrograms(oldProg, newProg)
+	want := &ProgramDiff{
+		AddedNodes:     []string{"Extra"},
+		RemovedNodes:   []string{"NodeNameVariableExpression"},
+		ChangedNodes:   []string{"NodeNameConstantExpression"},
+		AddedLines:     []string{"line:extra"},
+		RemovedLines:   []string{"line:/Users/kalexmills/repos/personal/yarn/testdata/Jumps.yarn-NodeNameVariableExpression-4"},
+		AddedVariables: []string{"$otherNodeName"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("DiffPrograms diff:\n%s", diff)
+	}
+	if !DiffPrograms(oldProg, oldProg).IsEmpty() {
+		t.Errorf("DiffPrograms(oldProg, oldProg).IsEmpty() = false, want true")
+	}
+}
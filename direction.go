@@ -0,0 +1,298 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StageDirection is a parsed stage-direction command. It is one of
+// *CameraShake, *CameraFocus, *CameraZoom, *CameraReset, *Enter, *Exit, *Move,
+// *Face, or *Fade. See ParseStageDirection for the vocabulary.
+type StageDirection interface {
+	// Command returns the command the direction was parsed from.
+	Command() string
+}
+
+// CameraShake shakes the camera: <<camera shake intensity [duration]>>.
+type CameraShake struct {
+	Cmd       string
+	Intensity float64
+	Duration  time.Duration // zero if not given
+}
+
+// CameraFocus points the camera at something: <<camera focus target>>.
+type CameraFocus struct {
+	Cmd    string
+	Target string
+}
+
+// CameraZoom zooms the camera: <<camera zoom factor [duration]>>.
+type CameraZoom struct {
+	Cmd      string
+	Factor   float64
+	Duration time.Duration // zero if not given
+}
+
+// CameraReset returns the camera to its default: <<camera reset>>.
+type CameraReset struct {
+	Cmd string
+}
+
+// Enter brings a character on stage: <<enter character [position]>>.
+type Enter struct {
+	Cmd       string
+	Character string
+	Position  string // empty if not given
+}
+
+// Exit takes a character off stage: <<exit character>>.
+type Exit struct {
+	Cmd       string
+	Character string
+}
+
+// Move moves a character: <<move character position>>.
+type Move struct {
+	Cmd       string
+	Character string
+	Position  string
+}
+
+// Face turns a character towards something: <<face character target>>.
+type Face struct {
+	Cmd       string
+	Character string
+	Target    string
+}
+
+// Fade fades the screen: <<fade in [duration]>> or <<fade out [duration]>>.
+type Fade struct {
+	Cmd      string
+	In       bool // fade in if true, out if false
+	Duration time.Duration
+}
+
+func (d *CameraShake) Command() string { return d.Cmd }
+func (d *CameraFocus) Command() string { return d.Cmd }
+func (d *CameraZoom) Command() string  { return d.Cmd }
+func (d *CameraReset) Command() string { return d.Cmd }
+func (d *Enter) Command() string       { return d.Cmd }
+func (d *Exit) Command() string        { return d.Cmd }
+func (d *Move) Command() string        { return d.Cmd }
+func (d *Face) Command() string        { return d.Cmd }
+func (d *Fade) Command() string        { return d.Cmd }
+
+// ParseStageDirection parses a stage-direction command. The vocabulary is:
+//
+//	<<camera shake 0.5>>         intensity, and optional duration
+//	<<camera shake 0.5 2s>>
+//	<<camera focus Ava>>
+//	<<camera zoom 1.5>>          factor, and optional duration
+//	<<camera reset>>
+//	<<enter Ava>>                optional position
+//	<<enter Ava left>>
+//	<<exit Ava>>
+//	<<move Ava left>>
+//	<<face Ava Bo>>
+//	<<fade out>>                 optional duration
+//	<<fade in 1.5>>
+//
+// Durations are a number of seconds or a Go duration (e.g. "500ms").
+// Positions and targets are single words, and are up to the game to
+// interpret. If the command is not in the vocabulary, ParseStageDirection
+// returns nil and no error. If it is in the vocabulary but the arguments
+// are wrong, it returns an error.
+func ParseStageDirection(command string) (StageDirection, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	verb, args := fields[0], fields[1:]
+	if verb == "camera" {
+		if len(args) == 0 {
+			return nil, fmt.Errorf("camera: missing action in %q", command)
+		}
+		verb, args = "camera "+args[0], args[1:]
+	}
+
+	// nargs checks the number of arguments.
+	nargs := func(min, max int, want string) error {
+		if len(args) < min || len(args) > max {
+			return fmt.Errorf("%s: want %s, got %q", verb, want, command)
+		}
+		return nil
+	}
+	// optDuration parses the optional duration argument at index i.
+	optDuration := func(i int) (time.Duration, error) {
+		if len(args) <= i {
+			return 0, nil
+		}
+		d, err := parseSeconds(args[i])
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("%s: invalid duration %q", verb, args[i])
+		}
+		return d, nil
+	}
+	// number parses the number argument at index i.
+	number := func(i int) (float64, error) {
+		x, err := strconv.ParseFloat(args[i], 64)
+		if err != nil {
+			return 0, fmt.Errorf("%s: invalid number %q", verb, args[i])
+		}
+		return x, nil
+	}
+
+	switch verb {
+	case "camera shake":
+		if err := nargs(1, 2, "intensity and optional duration"); err != nil {
+			return nil, err
+		}
+		intensity, err := number(0)
+		if err != nil {
+			return nil, err
+		}
+		dur, err := optDuration(1)
+		if err != nil {
+			return nil, err
+		}
+		return &CameraShake{Cmd: command, Intensity: intensity, Duration: dur}, nil
+
+	case "camera focus":
+		if err := nargs(1, 1, "target"); err != nil {
+			return nil, err
+		}
+		return &CameraFocus{Cmd: command, Target: args[0]}, nil
+
+	case "camera zoom":
+		if err := nargs(1, 2, "factor and optional duration"); err != nil {
+			return nil, err
+		}
+		factor, err := number(0)
+		if err != nil {
+			return nil, err
+		}
+		dur, err := optDuration(1)
+		if err != nil {
+			return nil, err
+		}
+		return &CameraZoom{Cmd: command, Factor: factor, Duration: dur}, nil
+
+	case "camera reset":
+		if err := nargs(0, 0, "no arguments"); err != nil {
+			return nil, err
+		}
+		return &CameraReset{Cmd: command}, nil
+
+	case "enter":
+		if err := nargs(1, 2, "character and optional position"); err != nil {
+			return nil, err
+		}
+		d := &Enter{Cmd: command, Character: args[0]}
+		if len(args) == 2 {
+			d.Position = args[1]
+		}
+		return d, nil
+
+	case "exit":
+		if err := nargs(1, 1, "character"); err != nil {
+			return nil, err
+		}
+		return &Exit{Cmd: command, Character: args[0]}, nil
+
+	case "move":
+		if err := nargs(2, 2, "character and position"); err != nil {
+			return nil, err
+		}
+		return &Move{Cmd: command, Character: args[0], Position: args[1]}, nil
+
+	case "face":
+		if err := nargs(2, 2, "character and target"); err != nil {
+			return nil, err
+		}
+		return &Face{Cmd: command, Character: args[0], Target: args[1]}, nil
+
+	case "fade":
+		if err := nargs(1, 2, "in or out, and optional duration"); err != nil {
+			return nil, err
+		}
+		if args[0] != "in" && args[0] != "out" {
+			return nil, fmt.Errorf("fade: want in or out, got %q", args[0])
+		}
+		dur, err := optDuration(1)
+		if err != nil {
+			return nil, err
+		}
+		return &Fade{Cmd: command, In: args[0] == "in", Duration: dur}, nil
+
+	default:
+		if strings.HasPrefix(verb, "camera ") {
+			return nil, fmt.Errorf("%s: unknown camera action in %q", verb, command)
+		}
+	}
+	return nil, nil
+}
+
+// StageDirectionHandler is an optional interface for dialogue handlers wrapped
+// by DirectionHandler. Stage-direction commands are delivered to
+// StageDirection instead of Command.
+type StageDirectionHandler interface {
+	StageDirection(dir StageDirection) error
+}
+
+var (
+	_ DialogueHandler      = &DirectionHandler{}
+	_ CommandResultHandler = &DirectionHandler{}
+	_ SkipHandler          = &DirectionHandler{}
+)
+
+// DirectionHandler is a DialogueHandler that parses stage-direction commands
+// (see ParseStageDirection). If the embedded DialogueHandler implements
+// StageDirectionHandler, they are delivered to StageDirection; otherwise they
+// are delivered to Command as usual, once they have parsed successfully. All
+// other commands and events are passed to the embedded handler.
+type DirectionHandler struct {
+	DialogueHandler
+}
+
+// Command parses stage directions, and passes other commands to the embedded
+// handler.
+func (h *DirectionHandler) Command(command string) error {
+	_, err := h.CommandResult(command)
+	return err
+}
+
+// CommandResult is like Command, but passes commands to the embedded
+// handler's CommandResult method, if it has one.
+func (h *DirectionHandler) CommandResult(command string) (any, error) {
+	dir, err := ParseStageDirection(command)
+	if err != nil {
+		return nil, err
+	}
+	sdh, ok := h.DialogueHandler.(StageDirectionHandler)
+	if dir == nil || !ok {
+		return forwardCommandResult(h.DialogueHandler, command)
+	}
+	return nil, sdh.StageDirection(dir)
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *DirectionHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseStageDirection(t *testing.T) {
+	tests := []struct {
+		command string
+		want    StageDirection
+	}{
+		{"camera shake 0.5", &CameraShake{Cmd: "camera shake 0.5", Intensity: 0.5}},
+		{"camera shake 1 250ms", &CameraShake{Cmd: "camera shake 1 250ms", Intensity: 1, Duration: 250 * time.Millisecond}},
+		{"camera focus Ava", &CameraFocus{Cmd: "camera focus Ava", Target: "Ava"}},
+		{"camera zoom 1.5 2", &CameraZoom{Cmd: "camera zoom 1.5 2", Factor: 1.5, Duration: 2 * time.Second}},
+		{"camera reset", &CameraReset{Cmd: "camera reset"}},
+		{"enter Ava", &Enter{Cmd: "enter Ava", Character: "Ava"}},
+		{"enter Ava left", &Enter{Cmd: "enter Ava left", Character: "Ava", Position: "left"}},
+		{"exit Bo", &Exit{Cmd: "exit Bo", Character: "Bo"}},
+		{"move Ava left", &Move{Cmd: "move Ava left", Character: "Ava", Position: "left"}},
+		{"face Ava Bo", &Face{Cmd: "face Ava Bo", Character: "Ava", Target: "Bo"}},
+		{"fade in 1.5", &Fade{Cmd: "fade in 1.5", In: true, Duration: 1500 * time.Millisecond}},
+		{"fade out", &Fade{Cmd: "fade out"}},
+		{"wave Ava", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got, err := ParseStageDirection(test.command)
+		if err != nil {
+			t.Errorf("ParseStageDirection(%q) error = %v", test.command, err)
+			continue
+		}
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("ParseStageDirection(%q) diff (-got +want):\n%s", test.command, diff)
+		}
+	}
+
+	for _, command := range []string{
+		"camera",
+		"camera spin",
+		"camera shake",
+		"camera shake lots",
+		"camera shake 0.5 forever",
+		"camera reset now",
+		"move Ava",
+		"exit",
+		"fade sideways",
+	} {
+		if _, err := ParseStageDirection(command); err == nil {
+			t.Errorf("ParseStageDirection(%q) error = nil, want error", command)
+		}
+	}
+}
+
+// directionRecorder records stage directions.
+type directionRecorder struct {
+	lineRecorder
+	dirs []StageDirection
+}
+
+func (r *directionRecorder) StageDirection(dir StageDirection) error {
+	r.dirs = append(r.dirs, dir)
+	return nil
+}
+
+func TestDirectionHandler(t *testing.T) {
+	rec := &directionRecorder{}
+	h := &DirectionHandler{DialogueHandler: rec}
+	for _, cmd := range []string{"move Ava left", "wave", "camera shake 0.5"} {
+		if err := h.Command(cmd); err != nil {
+			t.Fatalf("Command(%q) = %v", cmd, err)
+		}
+	}
+	want := []StageDirection{
+		&Move{Cmd: "move Ava left", Character: "Ava", Position: "left"},
+		&CameraShake{Cmd: "camera shake 0.5", Intensity: 0.5},
+	}
+	if diff := cmp.Diff(rec.dirs, want); diff != "" {
+		t.Errorf("directions diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.commands, []string{"wave"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+	if err := h.Command("move Ava"); err == nil {
+		t.Errorf("Command(move Ava) = nil, want error")
+	}
+
+	// Without StageDirection, directions are passed through to Command.
+	lr := &lineRecorder{}
+	h = &DirectionHandler{DialogueHandler: lr}
+	if err := h.Command("exit Bo"); err != nil {
+		t.Fatalf("Command(exit Bo) = %v", err)
+	}
+	if diff := cmp.Diff(lr.commands, []string{"exit Bo"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// DuplicateLines is a group of lines with the same, or nearly the same, text.
+type DuplicateLines struct {
+	// Canonical is the ID of the line to keep: the first of the group by
+	// file, line number, and ID.
+	Canonical string `json:"canonical"`
+
+	// Duplicates are the IDs of the other lines in the group, in the same
+	// order.
+	Duplicates []string `json:"duplicates"`
+
+	// Text is the text of the canonical line.
+	Text string `json:"text"`
+
+	// Exact reports whether the texts are all identical. Otherwise they
+	// differ only in case, punctuation, or spacing.
+	Exact bool `json:"exact"`
+}
+
+// FindDuplicateLines finds lines in the string table with identical or
+// near-identical text, e.g. to record voice-over or translate them once. Texts
+// are near-identical if they are the same after ignoring case, punctuation,
+// and runs of spaces. Substitutions ({0}) and markup are kept, so lines with
+// different substitutions are not duplicates. The groups are sorted by
+// canonical line (file, line number, and ID).
+func FindDuplicateLines(st *StringTable) []DuplicateLines {
+	groups := make(map[string][]*StringTableRow)
+	for _, row := range st.Table {
+		if row == nil || strings.TrimSpace(row.Text) == "" {
+			continue
+		}
+		key := normalizeLineText(row.Text)
+		groups[key] = append(groups[key], row)
+	}
+
+	var dupes []DuplicateLines
+	for _, rows := range groups {
+		if len(rows) < 2 {
+			continue
+		}
+		sort.Slice(rows, func(i, j int) bool { return rowLess(rows[i], rows[j]) })
+		d := DuplicateLines{Canonical: rows[0].ID, Text: rows[0].Text, Exact: true}
+		for _, row := range rows[1:] {
+			d.Duplicates = append(d.Duplicates, row.ID)
+			if row.Text != rows[0].Text {
+				d.Exact = false
+			}
+		}
+		dupes = append(dupes, d)
+	}
+	sort.Slice(dupes, func(i, j int) bool {
+		return rowLess(st.Table[dupes[i].Canonical], st.Table[dupes[j].Canonical])
+	})
+	return dupes
+}
+
+// normalizeLineText lowercases text, removes punctuation (other than that
+// used by substitutions and markup), and collapses spaces.
+func normalizeLineText(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsSpace(r):
+			space = b.Len() > 0
+			continue
+		case unicode.IsPunct(r) && !strings.ContainsRune("{}[]/=\"", r):
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// WriteDuplicatesCSV writes a report of duplicate lines as CSV, with a header
+// row and one row per duplicate line.
+func WriteDuplicatesCSV(w io.Writer, dupes []DuplicateLines) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"canonical", "duplicate", "exact", "text"})
+	for _, d := range dupes {
+		for _, id := range d.Duplicates {
+			cw.Write([]string{d.Canonical, id, strconv.FormatBool(d.Exact), d.Text})
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// DedupeLines returns a copy of the program in which lines and options use
+// the canonical line of each group instead of its duplicates, and the aliases
+// that were applied (duplicate ID to canonical ID). The program passed in is
+// not modified. Near-identical groups change the text of the aliased lines,
+// so callers will usually pass only the groups that are Exact.
+func DedupeLines(prog *yarnpb.Program, dupes []DuplicateLines) (*yarnpb.Program, map[string]string) {
+	aliases := make(map[string]string)
+	for _, d := range dupes {
+		for _, id := range d.Duplicates {
+			aliases[id] = d.Canonical
+		}
+	}
+	prog = proto.Clone(prog).(*yarnpb.Program)
+	for _, node := range prog.Nodes {
+		for _, inst := range node.Instructions {
+			if canon, ok := aliases[lineIDOf(inst)]; ok {
+				inst.Operands[0] = stringOperand(canon)
+			}
+		}
+	}
+	return prog, aliases
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDuplicateLines(t *testing.T) {
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:a1": {ID: "line:a1", Text: "Ava: Hello there!", File: "a.yarn", LineNumber: 3},
+		"line:a2": {ID: "line:a2", Text: "Ava: Hello there!", File: "a.yarn", LineNumber: 9},
+		"line:b1": {ID: "line:b1", Text: "ava:  hello there", File: "b.yarn", LineNumber: 1},
+		"line:a3": {ID: "line:a3", Text: "Bo: Hello there!", File: "a.yarn", LineNumber: 4},
+		"line:a4": {ID: "line:a4", Text: "You have {0} gold.", File: "a.yarn", LineNumber: 5},
+		"line:b2": {ID: "line:b2", Text: "You have {1} gold.", File: "b.yarn", LineNumber: 2},
+		"line:b3": {ID: "line:b3", Text: "Goodbye", File: "b.yarn", LineNumber: 3},
+		"line:a5": {ID: "line:a5", Text: "Goodbye", File: "a.yarn", LineNumber: 7},
+	}}
+	got := FindDuplicateLines(st)
+	want := []DuplicateLines{
+		{Canonical: "line:a1", Duplicates: []string{"line:a2", "line:b1"}, Text: "Ava: Hello there!", Exact: false},
+		{Canonical: "line:a5", Duplicates: []string{"line:b3"}, Text: "Goodbye", Exact: true},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("FindDuplicateLines diff (-got +want):\n%s", diff)
+	}
+
+	var buf strings.Builder
+	if err := WriteDuplicatesCSV(&buf, got); err != nil {
+		t.Fatalf("WriteDuplicatesCSV = %v", err)
+	}
+	wantCSV := "canonical,duplicate,exact,text\n" +
+		"line:a1,line:a2,false,Ava: Hello there!\n" +
+		"line:a1,line:b1,false,Ava: Hello there!\n" +
+		"line:a5,line:b3,true,Goodbye\n"
+	if diff := cmp.Diff(buf.String(), wantCSV); diff != "" {
+		t.Errorf("WriteDuplicatesCSV diff (-got +want):\n%s", diff)
+	}
+
+	pb := NewProgramBuilder("Dupes")
+	pb.Node("Start").
+		Line("line:a1", 0).
+		Line("line:b3", 0).
+		Option("line:a5", "end", 0, false).
+		Option("line:b3", "end", 0, false)
+	prog := pb.Program()
+	deduped, aliases := DedupeLines(prog, want[1:])
+	if diff := cmp.Diff(lineIDs(deduped.Nodes["Start"]), []string{"line:a1", "line:a5", "line:a5", "line:a5"}); diff != "" {
+		t.Errorf("deduped line IDs diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(aliases, map[string]string{"line:b3": "line:a5"}); diff != "" {
+		t.Errorf("aliases diff (-got +want):\n%s", diff)
+	}
+	if got := lineIDs(prog.Nodes["Start"])[1]; got != "line:b3" {
+		t.Errorf("DedupeLines modified its input: line 1 = %q", got)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EmotionAttribute is the name of the markup attribute that sets the emotion
+// of a line, e.g. [emotion=angry/] or [emotion name="angry"/].
+const EmotionAttribute = "emotion"
+
+// LineEmotion renders the line and returns its emotion and speaker. Markup
+// takes precedence over line tags starting with EmotionTagPrefix. The emotion
+// is empty if neither is present.
+func LineEmotion(line Line, st *StringTable) (emotion, speaker string, err error) {
+	as, err := st.Render(line)
+	if err != nil {
+		return "", "", err
+	}
+	speaker, _ = findSpeaker(as)
+	as.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if emotion != "" || a.Name != EmotionAttribute || a.Start != pos {
+				continue
+			}
+			emotion = a.Props[EmotionAttribute]
+			if emotion == "" {
+				emotion = a.Props["name"]
+			}
+		}
+	})
+	if emotion != "" {
+		return emotion, speaker, nil
+	}
+	for _, tag := range st.Table[line.ID].Tags {
+		if e, ok := strings.CutPrefix(tag, EmotionTagPrefix); ok && e != "" {
+			return e, speaker, nil
+		}
+	}
+	return "", speaker, nil
+}
+
+// EmotionChange describes a change in a speaker's emotion.
+type EmotionChange struct {
+	// Speaker is the speaker's name (see Line.Accessible), or empty for
+	// lines without a speaker.
+	Speaker string
+
+	// From is the speaker's previous emotion, or empty if the speaker has
+	// not had one yet.
+	From string
+
+	// To is the speaker's new emotion.
+	To string
+}
+
+// EmotionChangeHandler is an optional interface for dialogue handlers wrapped
+// by EmotionHandler. EmotionChanged is called before the Line that changes a
+// speaker's emotion, e.g. to swap their portrait.
+type EmotionChangeHandler interface {
+	EmotionChanged(change EmotionChange) error
+}
+
+var (
+	_ DialogueHandler      = &EmotionHandler{}
+	_ CommandResultHandler = &EmotionHandler{}
+	_ SkipHandler          = &EmotionHandler{}
+)
+
+// EmotionHandler is a DialogueHandler that sets Line.Emotion from markup
+// ([emotion=angry/]) or line tags (#emotion:angry) before passing each line
+// to the embedded handler. If the embedded DialogueHandler implements
+// EmotionChangeHandler, it is notified whenever a speaker's emotion changes.
+// Lines without an emotion leave the speaker's emotion unchanged. All other
+// events are passed to the embedded handler.
+type EmotionHandler struct {
+	DialogueHandler
+	StringTable *StringTable
+
+	mu      sync.Mutex
+	current map[string]string
+}
+
+// Emotion returns the current emotion of a speaker.
+func (h *EmotionHandler) Emotion(speaker string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.current[speaker]
+}
+
+// Line sets the line's emotion and delivers it to the embedded handler.
+func (h *EmotionHandler) Line(line Line) error {
+	return h.deliver(line, h.DialogueHandler.Line)
+}
+
+// SkipLine sets the line's emotion and delivers it to the embedded handler's
+// SkipLine method, if it has one.
+func (h *EmotionHandler) SkipLine(line Line) error {
+	return h.deliver(line, func(line Line) error {
+		return forwardSkipLine(h.DialogueHandler, line)
+	})
+}
+
+// CommandResult passes the command to the embedded handler's CommandResult
+// method, if it has one.
+func (h *EmotionHandler) CommandResult(command string) (any, error) {
+	return forwardCommandResult(h.DialogueHandler, command)
+}
+
+// deliver sets the line's emotion, notifies the embedded handler of any
+// change, and passes the line to f.
+func (h *EmotionHandler) deliver(line Line, f func(Line) error) error {
+	emotion, speaker, err := LineEmotion(line, h.StringTable)
+	if err != nil {
+		return fmt.Errorf("finding emotion for line %q: %w", line.ID, err)
+	}
+	line.Emotion = emotion
+	if emotion == "" {
+		return f(line)
+	}
+
+	h.mu.Lock()
+	if h.current == nil {
+		h.current = make(map[string]string)
+	}
+	from := h.current[speaker]
+	h.current[speaker] = emotion
+	h.mu.Unlock()
+
+	if ech, ok := h.DialogueHandler.(EmotionChangeHandler); ok && from != emotion {
+		change := EmotionChange{Speaker: speaker, From: from, To: emotion}
+		if err := ech.EmotionChanged(change); err != nil {
+			return err
+		}
+	}
+	return f(line)
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// emotionRecorder records lines and emotion changes.
+type emotionRecorder struct {
+	FakeDialogueHandler
+	lines   []Line
+	changes []EmotionChange
+}
+
+func (r *emotionRecorder) Line(line Line) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func (r *emotionRecorder) EmotionChanged(change EmotionChange) error {
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+func TestEmotionHandler(t *testing.T) {
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: "Ava: Hello.", Tags: []string{"emotion:happy"}},
+		"line:2": {ID: "line:2", Text: "Ava: [emotion=angry/]Get out!", Tags: []string{"emotion:happy"}},
+		"line:3": {ID: "line:3", Text: "Bo: Fine, fine."},
+		"line:4": {ID: "line:4", Text: `Ava: [emotion name="angry"/]And stay out!`},
+		"line:5": {ID: "line:5", Text: "Bo: [emotion=sad]Sigh.[/emotion]"},
+	}}
+	rec := &emotionRecorder{}
+	h := &EmotionHandler{DialogueHandler: rec, StringTable: st}
+	for _, id := range []string{"line:1", "line:2", "line:3", "line:4", "line:5"} {
+		if err := h.Line(Line{ID: id}); err != nil {
+			t.Fatalf("Line(%s) = %v", id, err)
+		}
+	}
+
+	wantLines := []Line{
+		{ID: "line:1", Emotion: "happy"},
+		{ID: "line:2", Emotion: "angry"},
+		{ID: "line:3"},
+		{ID: "line:4", Emotion: "angry"},
+		{ID: "line:5", Emotion: "sad"},
+	}
+	if diff := cmp.Diff(rec.lines, wantLines); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	wantChanges := []EmotionChange{
+		{Speaker: "Ava", To: "happy"},
+		{Speaker: "Ava", From: "happy", To: "angry"},
+		{Speaker: "Bo", To: "sad"},
+	}
+	if diff := cmp.Diff(rec.changes, wantChanges); diff != "" {
+		t.Errorf("changes diff (-got +want):\n%s", diff)
+	}
+	if got, want := h.Emotion("Ava"), "angry"; got != want {
+		t.Errorf("Emotion(Ava) = %q, want %q", got, want)
+	}
+
+	if err := h.Line(Line{ID: "line:nope"}); err == nil {
+		t.Errorf("Line(line:nope) = nil, want error")
+	}
+}
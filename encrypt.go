@@ -0,0 +1,263 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// Encrypted files are intended to protect shipped story files against casual
+// datamining, not determined attackers (the key has to ship with the game).
+//
+// The format is a header:
+//
+//	magic      [4]byte  "YRNE"
+//	version    uint8    1
+//	chunk size uint32   (big endian) plaintext bytes per chunk
+//	nonce      [8]byte  random nonce prefix
+//
+// followed by chunks, each sealed with AES-GCM. The nonce for chunk i is the
+// nonce prefix followed by i as a big-endian uint32. The additional data for
+// each chunk is the header followed by a byte that is 1 for the final chunk
+// and 0 otherwise. Every chunk except the last contains exactly chunk size
+// bytes of plaintext; the last contains fewer (possibly zero). This
+// authenticates the header, chunk order, and the end of the stream.
+const (
+	encMagic          = "YRNE"
+	encVersion        = 1
+	encHeaderLen      = 4 + 1 + 4 + 8
+	encNoncePrefixLen = 8
+
+	// DefaultEncryptChunkSize is the plaintext chunk size used by
+	// NewEncryptWriter.
+	DefaultEncryptChunkSize = 64 << 10
+
+	// Upper bound on chunk size accepted by NewDecryptReader, to avoid
+	// allocating huge buffers for corrupt headers.
+	maxEncryptChunkSize = 16 << 20
+)
+
+// ErrBadEncryptedHeader is returned when an encrypted stream does not begin
+// with a valid header (or uses an unsupported version).
+const ErrBadEncryptedHeader = virtualMachineError("bad or unsupported encrypted header")
+
+// LoadEncryptedProgram decrypts and loads a program encrypted with
+// NewEncryptWriter (e.g. by the yarnencrypt tool). key must be 16, 24, or 32
+// bytes (AES-128, AES-192, or AES-256). Decryption is streamed, so only the
+// plaintext is held in memory in full.
+func LoadEncryptedProgram(r io.Reader, key []byte) (*yarnpb.Program, error) {
+	dr, err := NewDecryptReader(r, key)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(dr); err != nil {
+		return nil, fmt.Errorf("decrypting program: %w", err)
+	}
+	return unmarshalBytes(buf.Bytes())
+}
+
+// NewEncryptWriter returns a writer that encrypts everything written to it
+// and writes the result to w. Close must be called to write the final chunk;
+// it does not close w.
+func NewEncryptWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	aead, err := newEncryptAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderLen)
+	copy(header, encMagic)
+	header[4] = encVersion
+	binary.BigEndian.PutUint32(header[5:], DefaultEncryptChunkSize)
+	if _, err := rand.Read(header[9:]); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{
+		chunker: newChunker(aead, header),
+		w:       w,
+		buf:     make([]byte, 0, DefaultEncryptChunkSize),
+	}, nil
+}
+
+// NewDecryptReader reads the header from r and returns a reader that
+// decrypts the remainder of r. Read returns an error if any chunk fails to
+// authenticate, or the stream is truncated.
+func NewDecryptReader(r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := newEncryptAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, encHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	if string(header[:4]) != encMagic || header[4] != encVersion {
+		return nil, ErrBadEncryptedHeader
+	}
+	size := binary.BigEndian.Uint32(header[5:])
+	if size == 0 || size > maxEncryptChunkSize {
+		return nil, ErrBadEncryptedHeader
+	}
+	return &decryptReader{
+		chunker: newChunker(aead, header),
+		r:       r,
+		in:      make([]byte, int(size)+aead.Overhead()),
+	}, nil
+}
+
+func newEncryptAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunker holds the state common to encryption and decryption.
+type chunker struct {
+	aead  cipher.AEAD
+	nonce []byte // prefix + counter
+	ad    []byte // header + final flag
+	count uint32
+}
+
+func newChunker(aead cipher.AEAD, header []byte) chunker {
+	nonce := make([]byte, aead.NonceSize())
+	copy(nonce, header[9:9+encNoncePrefixLen])
+	ad := make([]byte, len(header)+1)
+	copy(ad, header)
+	return chunker{aead: aead, nonce: nonce, ad: ad}
+}
+
+// next updates the nonce and additional data for the next chunk.
+func (c *chunker) next(final bool) error {
+	if c.count == ^uint32(0) {
+		return errors.New("too many chunks")
+	}
+	binary.BigEndian.PutUint32(c.nonce[encNoncePrefixLen:], c.count)
+	c.count++
+	c.ad[len(c.ad)-1] = 0
+	if final {
+		c.ad[len(c.ad)-1] = 1
+	}
+	return nil
+}
+
+type encryptWriter struct {
+	chunker
+	w      io.Writer
+	buf    []byte // pending plaintext
+	out    []byte // reused ciphertext buffer
+	closed bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	if e.closed {
+		return 0, errors.New("write after close")
+	}
+	n := 0
+	for len(p) > 0 {
+		m := copy(e.buf[len(e.buf):cap(e.buf)], p)
+		e.buf = e.buf[:len(e.buf)+m]
+		p = p[m:]
+		n += m
+		// Only flush a full chunk once more data arrives, because the final
+		// chunk must be shorter than a full chunk.
+		if len(e.buf) == cap(e.buf) && len(p) > 0 {
+			if err := e.flush(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) flush(final bool) error {
+	if err := e.next(final); err != nil {
+		return err
+	}
+	e.out = e.aead.Seal(e.out[:0], e.nonce, e.buf, e.ad)
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	if len(e.buf) == cap(e.buf) {
+		if err := e.flush(false); err != nil {
+			return err
+		}
+	}
+	return e.flush(true)
+}
+
+type decryptReader struct {
+	chunker
+	r    io.Reader
+	in   []byte // ciphertext buffer, one full chunk
+	out  []byte // decrypted but unread plaintext
+	done bool
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		if err := d.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decryptReader) readChunk() error {
+	n, err := io.ReadFull(d.r, d.in)
+	final := false
+	switch {
+	case err == io.ErrUnexpectedEOF || err == io.EOF:
+		// A short chunk must be the final chunk.
+		final = true
+	case err != nil:
+		return err
+	}
+	if err := d.next(final); err != nil {
+		return err
+	}
+	out, err := d.aead.Open(d.in[:0], d.nonce, d.in[:n], d.ad)
+	if err != nil {
+		return fmt.Errorf("chunk %d: %w", d.count-1, err)
+	}
+	d.out, d.done = out, final
+	return nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	for _, size := range []int{0, 1, DefaultEncryptChunkSize, DefaultEncryptChunkSize + 1, 3*DefaultEncryptChunkSize - 7} {
+		plain := bytes.Repeat([]byte{'y'}, size)
+		var enc bytes.Buffer
+		w, err := NewEncryptWriter(&enc, key)
+		if err != nil {
+			t.Fatalf("NewEncryptWriter = %v", err)
+		}
+		if _, err := w.Write(plain); err != nil {
+			t.Fatalf("w.Write = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("w.Close = %v", err)
+		}
+		ciphertext := enc.Bytes()
+
+		r, err := NewDecryptReader(bytes.NewReader(ciphertext), key)
+		if err != nil {
+			t.Fatalf("NewDecryptReader = %v", err)
+		}
+		got, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("size %d: io.ReadAll(decrypt) = %v", size, err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("size %d: decrypted %d bytes, want %d matching bytes", size, len(got), size)
+		}
+
+		// Truncating at a chunk boundary must be detected.
+		if size > DefaultEncryptChunkSize {
+			trunc := ciphertext[:encHeaderLen+DefaultEncryptChunkSize+16]
+			r, err := NewDecryptReader(bytes.NewReader(trunc), key)
+			if err != nil {
+				t.Fatalf("NewDecryptReader(truncated) = %v", err)
+			}
+			if _, err := io.ReadAll(r); err == nil {
+				t.Errorf("size %d: io.ReadAll(truncated) = nil error, want error", size)
+			}
+		}
+	}
+}
+
+func TestLoadEncryptedProgram(t *testing.T) {
+	yarnc, err := os.ReadFile("testdata/Example.yarnc")
+	if err != nil {
+		t.Fatalf("os.ReadFile = %v", err)
+	}
+	want, err := unmarshalBytes(yarnc)
+	if err != nil {
+		t.Fatalf("unmarshalBytes = %v", err)
+	}
+	key := bytes.Repeat([]byte{42}, 32)
+	var enc bytes.Buffer
+	w, err := NewEncryptWriter(&enc, key)
+	if err != nil {
+		t.Fatalf("NewEncryptWriter = %v", err)
+	}
+	w.Write(yarnc)
+	w.Close()
+
+	got, err := LoadEncryptedProgram(bytes.NewReader(enc.Bytes()), key)
+	if err != nil {
+		t.Fatalf("LoadEncryptedProgram = %v", err)
+	}
+	if !proto.Equal(got, want) {
+		t.Errorf("LoadEncryptedProgram program differs from original")
+	}
+
+	wrongKey := bytes.Repeat([]byte{43}, 32)
+	if _, err := LoadEncryptedProgram(bytes.NewReader(enc.Bytes()), wrongKey); err == nil {
+		t.Errorf("LoadEncryptedProgram(wrong key) = nil error, want error")
+	}
+	if _, err := LoadEncryptedProgram(bytes.NewReader(yarnc), key); err == nil {
+		t.Errorf("LoadEncryptedProgram(plaintext) = nil error, want error")
+	}
+}
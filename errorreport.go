@@ -0,0 +1,152 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// ErrorReportTranscriptLen is the number of recent handler events kept
+	// for ErrorReport.Transcript.
+	ErrorReportTranscriptLen = 32
+
+	// ErrorReportContext is the number of instructions either side of the
+	// current pc included in ErrorReport.Disassembly.
+	ErrorReportContext = 8
+)
+
+// ErrorReport is a diagnostic bundle describing the state of the VM when it
+// stopped with an error. It is intended to be serialized (e.g. to JSON) and
+// attached to bug reports from players and testers. See
+// VirtualMachine.ErrorReports.
+type ErrorReport struct {
+	// Time is when the report was made.
+	Time time.Time `json:"time"`
+
+	// Error is the error returned by the VM.
+	Error string `json:"error"`
+
+	// Program, Node, and PC identify where the error happened.
+	Program string `json:"program"`
+	Node    string `json:"node,omitempty"`
+	PC      int    `json:"pc"`
+
+	// Disassembly contains the instructions around PC (formatted as by
+	// FormatInstruction), with the instruction at PC marked with "=>".
+	Disassembly []string `json:"disassembly,omitempty"`
+
+	// Stack and Options are the VM's stack (bottom first) and pending
+	// options.
+	Stack   []any    `json:"stack"`
+	Options []Option `json:"options,omitempty"`
+
+	// Transcript contains the most recent handler events, oldest first.
+	Transcript []TranscriptEvent `json:"transcript"`
+
+	// Vars is a copy of the variables, if the variable storage supports it
+	// (e.g. MapVariableStorage).
+	Vars map[string]any `json:"vars,omitempty"`
+}
+
+// TranscriptEvent records one handler event for ErrorReport.
+type TranscriptEvent struct {
+	Node  string         `json:"node,omitempty"`
+	PC    int            `json:"pc"`
+	Event string         `json:"event"`
+	Attrs map[string]any `json:"attrs,omitempty"`
+}
+
+// errorTranscript is a ring buffer of recent handler events.
+type errorTranscript struct {
+	events []TranscriptEvent
+	next   int
+}
+
+func (t *errorTranscript) add(e TranscriptEvent) {
+	if len(t.events) < ErrorReportTranscriptLen {
+		t.events = append(t.events, e)
+		return
+	}
+	t.events[t.next] = e
+	t.next = (t.next + 1) % ErrorReportTranscriptLen
+}
+
+func (t *errorTranscript) list() []TranscriptEvent {
+	out := make([]TranscriptEvent, 0, len(t.events))
+	out = append(out, t.events[t.next:]...)
+	return append(out, t.events[:t.next]...)
+}
+
+// recordEvent adds a handler event to the transcript, if error reports are
+// enabled.
+func (vm *VirtualMachine) recordEvent(event string, attrs []slog.Attr) {
+	if vm.ErrorReports == nil {
+		return
+	}
+	e := TranscriptEvent{Event: event, PC: vm.state.pc}
+	if vm.state.node != nil {
+		e.Node = vm.state.node.Name
+	}
+	if len(attrs) > 0 {
+		e.Attrs = make(map[string]any, len(attrs))
+		for _, a := range attrs {
+			v := a.Value.Any()
+			// Copy slices, since events may reuse memory (see ReuseEvents).
+			if ss, ok := v.([]string); ok {
+				v = append([]string(nil), ss...)
+			}
+			e.Attrs[a.Key] = v
+		}
+	}
+	vm.transcript.add(e)
+}
+
+// reportError builds an ErrorReport and passes it to ErrorReports, if set.
+func (vm *VirtualMachine) reportError(err error) {
+	if vm.ErrorReports == nil {
+		return
+	}
+	r := &ErrorReport{
+		Time:       time.Now(),
+		Error:      err.Error(),
+		PC:         vm.state.pc,
+		Stack:      append([]any{}, vm.state.stack...),
+		Options:    CloneOptions(vm.state.options),
+		Transcript: vm.transcript.list(),
+	}
+	if vm.Program != nil {
+		r.Program = vm.Program.Name
+	}
+	if node := vm.state.node; node != nil {
+		r.Node = node.Name
+		lo := max(0, vm.state.pc-ErrorReportContext)
+		hi := min(len(node.Instructions), vm.state.pc+ErrorReportContext+1)
+		for pc := lo; pc < hi; pc++ {
+			marker := "  "
+			if pc == vm.state.pc {
+				marker = "=>"
+			}
+			r.Disassembly = append(r.Disassembly, fmt.Sprintf("%s %06d %s", marker, pc, FormatInstruction(node.Instructions[pc])))
+		}
+	}
+	if cs, ok := vm.Vars.(contentsStorage); ok {
+		r.Vars = cs.Contents()
+	}
+	vm.Privacy.scrubReport(r)
+	vm.ErrorReports(r)
+}
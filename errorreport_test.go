@@ -0,0 +1,121 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestErrorReport(t *testing.T) {
+	pb := NewProgramBuilder("Buggy")
+	pb.Node("Start").
+		PushString("Bea").
+		Line("line:1", 1).
+		Command("wave", 0).
+		Call("missing", 0).
+		Stop()
+	prog := pb.Program()
+	vars := NewMapVariableStorage()
+	vars.SetValue("$gold", float32(3))
+
+	var report *ErrorReport
+	vm := &VirtualMachine{
+		Program:      prog,
+		Handler:      FakeDialogueHandler{},
+		Vars:         vars,
+		ErrorReports: func(r *ErrorReport) { report = r },
+	}
+	if err := vm.Run("Start"); !errors.Is(err, ErrFunctionNotFound) {
+		t.Fatalf("vm.Run(Start) = %v, want %v", err, ErrFunctionNotFound)
+	}
+	if report == nil {
+		t.Fatal("ErrorReports was not called")
+	}
+
+	want := &ErrorReport{
+		Program: "Buggy",
+		Node:    "Start",
+		PC:      4,
+		Disassembly: []string{
+			`   000000 PUSH_STRING "Bea"`,
+			`   000001 RUN_LINE "line:1" 1`,
+			`   000002 RUN_COMMAND "wave" 0`,
+			`   000003 PUSH_FLOAT 0.000000`,
+			`=> 000004 CALL_FUNC "missing"`,
+			`   000005 STOP`,
+		},
+		Stack: []any{float32(0)},
+		Transcript: []TranscriptEvent{
+			{Node: "Start", PC: 0, Event: "NodeStart"},
+			{Node: "Start", PC: 0, Event: "PrepareForLines", Attrs: map[string]any{"line_ids": []string{"line:1"}}},
+			{Node: "Start", PC: 2, Event: "Line", Attrs: map[string]any{"line_id": "line:1", "substitutions": []string{"Bea"}}},
+			{Node: "Start", PC: 3, Event: "Command", Attrs: map[string]any{"command": "wave"}},
+		},
+		Vars: map[string]any{"$gold": float32(3)},
+	}
+	opts := cmpopts.IgnoreFields(ErrorReport{}, "Time", "Error")
+	if diff := cmp.Diff(report, want, opts); diff != "" {
+		t.Errorf("error report diff (-got +want):\n%s", diff)
+	}
+	if _, err := json.Marshal(report); err != nil {
+		t.Errorf("json.Marshal(report) = %v", err)
+	}
+}
+
+// failingOptionsHandler fails when options are shown.
+type failingOptionsHandler struct {
+	FakeDialogueHandler
+}
+
+func (failingOptionsHandler) Options([]Option) (int, error) {
+	return -1, errors.New("no buttons")
+}
+
+func TestErrorReportReuseEvents(t *testing.T) {
+	pb := NewProgramBuilder("Buggy")
+	pb.Node("Start").
+		PushString("Bea").Option("line:hi", "hi", 1, false).
+		Option("line:bye", "bye", 0, false).
+		ShowOptions().Jump().
+		Label("hi").Stop().
+		Label("bye").Stop()
+
+	var report *ErrorReport
+	vm := &VirtualMachine{
+		Program:      pb.Program(),
+		Handler:      failingOptionsHandler{},
+		Vars:         NewMapVariableStorage(),
+		ReuseEvents:  true,
+		ErrorReports: func(r *ErrorReport) { report = r },
+	}
+	if err := vm.Run("Start"); err == nil {
+		t.Fatal("vm.Run(Start) = nil, want an error")
+	}
+	if report == nil {
+		t.Fatal("ErrorReports was not called")
+	}
+	want := []Option{
+		{ID: 0, Line: Line{ID: "line:hi", Substitutions: []string{"Bea"}}, DestinationNode: "hi", IsAvailable: true},
+		{ID: 1, Line: Line{ID: "line:bye"}, DestinationNode: "bye", IsAvailable: true},
+	}
+	if diff := cmp.Diff(report.Options, want, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("report.Options diff (-got +want):\n%s", diff)
+	}
+}
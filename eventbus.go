@@ -0,0 +1,189 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultCommandTopic is the topic used by EventBusHandler when Topic is
+// empty.
+const DefaultCommandTopic = "yarn.command"
+
+// ErrAckTimeout is returned by EventBusHandler.Command when an
+// acknowledgement does not arrive within AckTimeout.
+const ErrAckTimeout = virtualMachineError("timed out waiting for command ack")
+
+// ErrUnknownAck is returned by EventBusHandler.Ack when there is no command
+// waiting for an acknowledgement with the given ID.
+const ErrUnknownAck = virtualMachineError("no command waiting for ack")
+
+var (
+	_ DialogueHandler = &EventBusHandler{}
+	_ SkipHandler     = &EventBusHandler{}
+)
+
+// EventBus is the minimal interface to an engine's messaging system needed by
+// EventBusHandler.
+type EventBus interface {
+	Publish(topic string, payload any) error
+}
+
+// CommandMessage is the payload published by EventBusHandler for each
+// command.
+type CommandMessage struct {
+	// ID identifies the command for Ack. IDs are unique per EventBusHandler.
+	ID uint64 `json:"id"`
+
+	// Node is the name of the node that ran the command.
+	Node string `json:"node"`
+
+	// Command is the whole command text, e.g. "flip Harley3 +1".
+	Command string `json:"command"`
+
+	// Name and Args are the whitespace-separated fields of Command, e.g.
+	// "flip" and ["Harley3", "+1"].
+	Name string   `json:"name"`
+	Args []string `json:"args,omitempty"`
+
+	// AwaitingAck reports whether the VM is blocked until Ack is called with
+	// ID.
+	AwaitingAck bool `json:"awaiting_ack,omitempty"`
+}
+
+// EventBusHandler is a DialogueHandler that publishes commands to an EventBus,
+// so engines with existing messaging (e.g. ECS-style engines) can route
+// commands to systems that subscribe to the topic. All other events are
+// passed to the embedded DialogueHandler.
+//
+// If AwaitAck is set, Command blocks until Ack is called with the message ID
+// (typically by a subscriber, once the command has been carried out). Ack may
+// be called from any goroutine, including from within Publish.
+type EventBusHandler struct {
+	DialogueHandler
+
+	// Bus receives a CommandMessage for each command.
+	Bus EventBus
+
+	// Topic is the topic to publish to. If empty, DefaultCommandTopic is used.
+	Topic string
+
+	// AwaitAck makes Command wait for Ack before returning.
+	AwaitAck bool
+
+	// AckTimeout limits how long Command waits for Ack. Zero means wait
+	// forever.
+	AckTimeout time.Duration
+
+	// Clock times AckTimeout. If nil, SystemClock is used.
+	Clock Clock
+
+	mu      sync.Mutex
+	nextID  uint64
+	node    string
+	pending map[uint64]chan error
+}
+
+// NodeStart records the node name for later command messages, and then calls
+// the embedded handler.
+func (h *EventBusHandler) NodeStart(nodeName string) error {
+	h.mu.Lock()
+	h.node = nodeName
+	h.mu.Unlock()
+	return h.DialogueHandler.NodeStart(nodeName)
+}
+
+// Command publishes the command to the bus. If AwaitAck is set, it then waits
+// for Ack, and returns the error passed to Ack.
+func (h *EventBusHandler) Command(command string) error {
+	fields := strings.Fields(command)
+	msg := &CommandMessage{
+		Command:     command,
+		AwaitingAck: h.AwaitAck,
+	}
+	if len(fields) > 0 {
+		msg.Name, msg.Args = fields[0], fields[1:]
+	}
+
+	// Register the pending ack before publishing, since Publish may deliver
+	// synchronously.
+	var ack chan error
+	h.mu.Lock()
+	h.nextID++
+	msg.ID, msg.Node = h.nextID, h.node
+	if h.AwaitAck {
+		ack = make(chan error, 1)
+		if h.pending == nil {
+			h.pending = make(map[uint64]chan error)
+		}
+		h.pending[msg.ID] = ack
+	}
+	h.mu.Unlock()
+
+	topic := h.Topic
+	if topic == "" {
+		topic = DefaultCommandTopic
+	}
+	if err := h.Bus.Publish(topic, msg); err != nil {
+		h.forget(msg.ID)
+		return fmt.Errorf("publishing command %d: %w", msg.ID, err)
+	}
+	if ack == nil {
+		return nil
+	}
+
+	if h.AckTimeout <= 0 {
+		return <-ack
+	}
+	timeout, timer := after(clockOrSystem(h.Clock), h.AckTimeout)
+	defer timer.Stop()
+	select {
+	case err := <-ack:
+		return err
+	case <-timeout:
+		h.forget(msg.ID)
+		return fmt.Errorf("%w: command %d %q", ErrAckTimeout, msg.ID, command)
+	}
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one. (Commands are published rather than passed to the embedded
+// handler, so EventBusHandler doesn't implement CommandResultHandler.)
+func (h *EventBusHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// Ack acknowledges the command with the given ID, unblocking Command. If err
+// is non-nil, Command returns it (which stops the VM).
+func (h *EventBusHandler) Ack(id uint64, err error) error {
+	h.mu.Lock()
+	ack := h.pending[id]
+	delete(h.pending, id)
+	h.mu.Unlock()
+	if ack == nil {
+		return fmt.Errorf("%w: %d", ErrUnknownAck, id)
+	}
+	ack <- err
+	return nil
+}
+
+func (h *EventBusHandler) forget(id uint64) {
+	h.mu.Lock()
+	delete(h.pending, id)
+	h.mu.Unlock()
+}
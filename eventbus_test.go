@@ -0,0 +1,123 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+type busFunc func(topic string, payload any) error
+
+func (f busFunc) Publish(topic string, payload any) error { return f(topic, payload) }
+
+func TestEventBusHandler(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/Commands.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+
+	t.Run("no ack", func(t *testing.T) {
+		var got []*CommandMessage
+		h := &EventBusHandler{
+			DialogueHandler: FakeDialogueHandler{},
+			Bus: busFunc(func(topic string, payload any) error {
+				if topic != DefaultCommandTopic {
+					t.Errorf("Publish topic = %q, want %q", topic, DefaultCommandTopic)
+				}
+				got = append(got, payload.(*CommandMessage))
+				return nil
+			}),
+		}
+		vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+		if err := vm.Run("Start"); err != nil {
+			t.Fatalf("vm.Run(Start) = %v", err)
+		}
+		if len(got) != 11 {
+			t.Fatalf("published %d messages, want 11", len(got))
+		}
+		want := &CommandMessage{ID: 1, Node: "Start", Command: "flip Harley3 +1", Name: "flip", Args: []string{"Harley3", "+1"}}
+		if diff := cmp.Diff(got[0], want); diff != "" {
+			t.Errorf("first message diff (-got +want):\n%s", diff)
+		}
+	})
+
+	t.Run("ack", func(t *testing.T) {
+		h := &EventBusHandler{
+			DialogueHandler: FakeDialogueHandler{},
+			Topic:           "dialogue",
+			AwaitAck:        true,
+		}
+		count := 0
+		h.Bus = busFunc(func(_ string, payload any) error {
+			msg := payload.(*CommandMessage)
+			count++
+			if !msg.AwaitingAck {
+				t.Errorf("msg.AwaitingAck = false, want true")
+			}
+			// Some acks happen during Publish, others later.
+			if msg.ID%2 == 0 {
+				return h.Ack(msg.ID, nil)
+			}
+			go func() {
+				if err := h.Ack(msg.ID, nil); err != nil {
+					t.Errorf("h.Ack(%d, nil) = %v", msg.ID, err)
+				}
+			}()
+			return nil
+		})
+		vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+		if err := vm.Run("Start"); err != nil {
+			t.Fatalf("vm.Run(Start) = %v", err)
+		}
+		if count != 11 {
+			t.Errorf("published %d messages, want 11", count)
+		}
+		if err := h.Ack(1, nil); !errors.Is(err, ErrUnknownAck) {
+			t.Errorf("h.Ack(1, nil) = %v, want %v", err, ErrUnknownAck)
+		}
+	})
+
+	t.Run("ack error", func(t *testing.T) {
+		errNope := errors.New("nope")
+		h := &EventBusHandler{DialogueHandler: FakeDialogueHandler{}, AwaitAck: true}
+		h.Bus = busFunc(func(_ string, payload any) error {
+			return h.Ack(payload.(*CommandMessage).ID, errNope)
+		})
+		vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+		if err := vm.Run("Start"); !errors.Is(err, errNope) {
+			t.Errorf("vm.Run(Start) = %v, want %v", err, errNope)
+		}
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		h := &EventBusHandler{
+			DialogueHandler: FakeDialogueHandler{},
+			Bus:             busFunc(func(string, any) error { return nil }),
+			AwaitAck:        true,
+			AckTimeout:      time.Millisecond,
+		}
+		vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+		if err := vm.Run("Start"); !errors.Is(err, ErrAckTimeout) {
+			t.Errorf("vm.Run(Start) = %v, want %v", err, ErrAckTimeout)
+		}
+		if err := h.Ack(1, nil); !errors.Is(err, ErrUnknownAck) {
+			t.Errorf("h.Ack(1, nil) after timeout = %v, want %v", err, ErrUnknownAck)
+		}
+	})
+}
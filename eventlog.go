@@ -0,0 +1,214 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// EventLogEntry is one event in an event log. Event is the name of the
+// DialogueHandler method (the same names the VM uses when logging events);
+// the other fields are set as relevant to the event.
+type EventLogEntry struct {
+	Time  time.Time `json:"time"`
+	Event string    `json:"event"`
+	Node  string    `json:"node,omitempty"`
+
+	LineID        string           `json:"line_id,omitempty"`
+	Substitutions []string         `json:"substitutions,omitempty"`
+	LineIDs       []string         `json:"line_ids,omitempty"` // PrepareForLines
+	Options       []EventLogOption `json:"options,omitempty"`
+	Choice        *int             `json:"choice,omitempty"` // option ID
+	Command       string           `json:"command,omitempty"`
+
+	// Error is the error returned by the embedded handler, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// EventLogOption is an option in an event log.
+type EventLogOption struct {
+	ID            int      `json:"id"`
+	LineID        string   `json:"line_id"`
+	Substitutions []string `json:"substitutions,omitempty"`
+	Destination   string   `json:"destination,omitempty"`
+	Available     bool     `json:"available"`
+}
+
+var (
+	_ DialogueHandler      = &EventLogHandler{}
+	_ CommandResultHandler = &EventLogHandler{}
+	_ SkipHandler          = &EventLogHandler{}
+)
+
+// EventLogHandler is a DialogueHandler that writes every event to W as JSON
+// Lines (one EventLogEntry per line), e.g. for analytics pipelines or replay
+// tools, and passes it to the embedded handler. Events are logged after the
+// embedded handler returns, so that Options can be logged with the choice. It
+// is safe for concurrent use.
+//
+// Errors writing to W don't interrupt the dialogue; the first is returned by
+// Err.
+type EventLogHandler struct {
+	DialogueHandler
+	W io.Writer
+
+	// Now, if not nil, is used instead of time.Now for timestamps.
+	Now func() time.Time
+
+	mu   sync.Mutex
+	enc  *json.Encoder
+	node string
+	err  error
+}
+
+// Err returns the first error writing to W, if any.
+func (h *EventLogHandler) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// write writes an entry, filling in the time and node.
+func (h *EventLogHandler) write(e EventLogEntry, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.Now != nil {
+		e.Time = h.Now()
+	} else {
+		e.Time = time.Now()
+	}
+	if e.Node == "" {
+		e.Node = h.node
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if h.err != nil {
+		return
+	}
+	if h.enc == nil {
+		h.enc = json.NewEncoder(h.W)
+	}
+	if werr := h.enc.Encode(e); werr != nil {
+		h.err = fmt.Errorf("writing event log: %w", werr)
+	}
+}
+
+// NodeStart logs the event.
+func (h *EventLogHandler) NodeStart(nodeName string) error {
+	h.mu.Lock()
+	h.node = nodeName
+	h.mu.Unlock()
+	err := h.DialogueHandler.NodeStart(nodeName)
+	h.write(EventLogEntry{Event: "NodeStart"}, err)
+	return err
+}
+
+// PrepareForLines logs the event.
+func (h *EventLogHandler) PrepareForLines(lineIDs []string) error {
+	err := h.DialogueHandler.PrepareForLines(lineIDs)
+	h.write(EventLogEntry{Event: "PrepareForLines", LineIDs: lineIDs}, err)
+	return err
+}
+
+// Line logs the event.
+func (h *EventLogHandler) Line(line Line) error {
+	err := h.DialogueHandler.Line(line)
+	h.write(EventLogEntry{Event: "Line", LineID: line.ID, Substitutions: line.Substitutions}, err)
+	return err
+}
+
+// SkipLine logs the event. The embedded handler's SkipLine method is called,
+// if it has one.
+func (h *EventLogHandler) SkipLine(line Line) error {
+	err := forwardSkipLine(h.DialogueHandler, line)
+	h.write(EventLogEntry{Event: "SkipLine", LineID: line.ID, Substitutions: line.Substitutions}, err)
+	return err
+}
+
+// Options logs the event, including the chosen option.
+func (h *EventLogHandler) Options(options []Option) (int, error) {
+	choice, err := h.DialogueHandler.Options(options)
+	e := EventLogEntry{Event: "Options", Options: make([]EventLogOption, len(options))}
+	for i, o := range options {
+		e.Options[i] = EventLogOption{
+			ID:            o.ID,
+			LineID:        o.Line.ID,
+			Substitutions: o.Line.Substitutions,
+			Destination:   o.DestinationNode,
+			Available:     o.IsAvailable,
+		}
+	}
+	if err == nil {
+		e.Choice = &choice
+	}
+	h.write(e, err)
+	return choice, err
+}
+
+// Command logs the event.
+func (h *EventLogHandler) Command(command string) error {
+	err := h.DialogueHandler.Command(command)
+	h.write(EventLogEntry{Event: "Command", Command: command}, err)
+	return err
+}
+
+// CommandResult logs the event, as for Command. The embedded handler's
+// CommandResult method is called, if it has one.
+func (h *EventLogHandler) CommandResult(command string) (any, error) {
+	result, err := forwardCommandResult(h.DialogueHandler, command)
+	h.write(EventLogEntry{Event: "Command", Command: command}, err)
+	return result, err
+}
+
+// NodeComplete logs the event.
+func (h *EventLogHandler) NodeComplete(nodeName string) error {
+	err := h.DialogueHandler.NodeComplete(nodeName)
+	h.write(EventLogEntry{Event: "NodeComplete", Node: nodeName}, err)
+	return err
+}
+
+// DialogueComplete logs the event.
+func (h *EventLogHandler) DialogueComplete() error {
+	err := h.DialogueHandler.DialogueComplete()
+	h.write(EventLogEntry{Event: "DialogueComplete"}, err)
+	return err
+}
+
+// ReadEventLog reads an event log written by EventLogHandler.
+func ReadEventLog(r io.Reader) ([]EventLogEntry, error) {
+	var entries []EventLogEntry
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var e EventLogEntry
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("event log line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading event log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestEventLogHandler(t *testing.T) {
+	pb := NewProgramBuilder("EventLog")
+	pb.Node("Start").
+		PushString("Ava").
+		Line("line:hi", 1).
+		Command("wave", 0).
+		Option("line:a", "pick", 0, false).
+		Option("line:b", "pick", 0, false).
+		ShowOptions().
+		Jump().
+		Label("pick").
+		RunNode("End")
+	pb.Node("End").Line("line:bye", 0)
+
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	h := &EventLogHandler{
+		DialogueHandler: &choosingHandler{&lineRecorder{}},
+		W:               &buf,
+		Now:             func() time.Time { return now },
+	}
+	vm := &VirtualMachine{Program: pb.Program(), Handler: h, Vars: NewMapVariableStorage()}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if err := h.Err(); err != nil {
+		t.Errorf("h.Err() = %v", err)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 11 {
+		t.Errorf("event log has %d lines, want 11", got)
+	}
+
+	got, err := ReadEventLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadEventLog = %v", err)
+	}
+	zero := 0
+	want := []EventLogEntry{
+		{Time: now, Event: "NodeStart", Node: "Start"},
+		{Time: now, Event: "PrepareForLines", Node: "Start", LineIDs: []string{"line:hi", "line:a", "line:b"}},
+		{Time: now, Event: "Line", Node: "Start", LineID: "line:hi", Substitutions: []string{"Ava"}},
+		{Time: now, Event: "Command", Node: "Start", Command: "wave"},
+		{Time: now, Event: "Options", Node: "Start", Options: []EventLogOption{
+			{ID: 0, LineID: "line:a", Destination: "pick", Available: true},
+			{ID: 1, LineID: "line:b", Destination: "pick", Available: true},
+		}, Choice: &zero},
+		{Time: now, Event: "NodeComplete", Node: "Start"},
+		{Time: now, Event: "NodeStart", Node: "End"},
+		{Time: now, Event: "PrepareForLines", Node: "End", LineIDs: []string{"line:bye"}},
+		{Time: now, Event: "Line", Node: "End", LineID: "line:bye"},
+		{Time: now, Event: "NodeComplete", Node: "End"},
+		{Time: now, Event: "DialogueComplete", Node: "End"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("event log diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := ReadEventLog(strings.NewReader("{\"event\":\"Line\"}\nnope\n")); err == nil {
+		t.Errorf("ReadEventLog(malformed) = nil error, want error")
+	}
+}
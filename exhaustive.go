@@ -0,0 +1,277 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// Default limits for ExhaustiveWalker.
+const (
+	DefaultMaxExploreDepth  = 64
+	DefaultMaxExploreStates = 100000
+)
+
+// ExhaustiveWalker plays every combination of choices in a program, up to a
+// bound, to verify that no path leads to an error. Unlike RandomWalker, it
+// gives a guarantee (within the bounds), so it suits small-to-medium stories.
+//
+// Paths that reach the same state at a choice (the same node, position,
+// stack, options, and variables, including visit counts) are explored only
+// once, so dialogue that converges after branching doesn't multiply the
+// work. History (see LineSeen) is not part of the state.
+type ExhaustiveWalker struct {
+	// Program is the program to explore.
+	Program *yarnpb.Program
+
+	// FuncMap provides any custom functions the program needs. They should be
+	// deterministic, or exploration may miss paths.
+	FuncMap FuncMap
+
+	// MaxDepth limits the number of choices in a path. Paths are cut off
+	// (and counted as Truncated) when they reach it. If zero,
+	// DefaultMaxExploreDepth is used.
+	MaxDepth int
+
+	// MaxStates limits the number of distinct choice states explored. If
+	// zero, DefaultMaxExploreStates is used.
+	MaxStates int
+
+	// MaxEvents limits the number of lines, options, and commands that can be
+	// delivered between two choices. If zero, DefaultMaxWalkEvents is used.
+	MaxEvents int
+
+	// NewVars, if not nil, is called to create the variable storage. It must
+	// support copying its contents (with Contents and ReplaceContents methods,
+	// like MapVariableStorage). Otherwise a new empty MapVariableStorage is
+	// used.
+	NewVars func() VariableStorage
+}
+
+// ExploreResult summarizes an exhaustive exploration.
+type ExploreResult struct {
+	States    int            // distinct choice states explored
+	Pruned    int            // choices skipped because their state was already explored
+	Paths     int            // paths that ran to completion without error
+	Truncated int            // paths cut off by MaxDepth
+	Endings   map[string]int // number of completed paths ending at each node
+	Errors    []*PathError   // paths that ended with an error
+
+	// Limited is true if exploration stopped early, at MaxStates.
+	Limited bool
+}
+
+// PathError is an error found by following a path of choices from the start
+// node.
+type PathError struct {
+	Choices []Option // options chosen, in order
+	Err     error
+}
+
+func (e *PathError) Error() string {
+	ids := make([]string, len(e.Choices))
+	for i, c := range e.Choices {
+		ids[i] = c.Line.ID
+	}
+	return fmt.Sprintf("after choosing [%s]: %v", strings.Join(ids, ", "), e.Err)
+}
+
+// Unwrap returns Err.
+func (e *PathError) Unwrap() error { return e.Err }
+
+// Explore explores every path from startNode. The returned error is only for
+// problems setting up; errors found along paths are in the result.
+func (w *ExhaustiveWalker) Explore(startNode string) (*ExploreResult, error) {
+	maxDepth := w.MaxDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxExploreDepth
+	}
+	maxStates := w.MaxStates
+	if maxStates <= 0 {
+		maxStates = DefaultMaxExploreStates
+	}
+	maxEvents := w.MaxEvents
+	if maxEvents <= 0 {
+		maxEvents = DefaultMaxWalkEvents
+	}
+	var vars VariableStorage = NewMapVariableStorage()
+	if w.NewVars != nil {
+		vars = w.NewVars()
+	}
+	cs, ok := vars.(contentsStorage)
+	if !ok {
+		return nil, ErrVarsNotSnapshottable
+	}
+
+	res := &ExploreResult{Endings: make(map[string]int)}
+	h := &exploreHandler{
+		res:       res,
+		seen:      make(map[StateHash]bool),
+		maxDepth:  maxDepth,
+		maxStates: maxStates,
+		maxEvents: maxEvents,
+	}
+	fm := make(FuncMap, len(w.FuncMap))
+	fm.merge(w.FuncMap)
+	vm := &VirtualMachine{
+		Program: w.Program,
+		Handler: h,
+		Vars:    vars,
+		FuncMap: fm,
+	}
+	h.vm, h.vars = vm, cs
+
+	// Depth-first, so that the frontier stays small.
+	h.frontier = []*exploreItem{{choice: -1, vars: cs.Contents()}}
+	for len(h.frontier) > 0 {
+		item := h.frontier[len(h.frontier)-1]
+		h.frontier = h.frontier[:len(h.frontier)-1]
+		h.start(item)
+
+		var err error
+		if item.snap == nil {
+			err = vm.Run(startNode)
+		} else {
+			if err := vm.Restore(item.snap); err != nil {
+				return nil, err
+			}
+			err = vm.Resume()
+		}
+		switch {
+		case err != nil:
+			res.Errors = append(res.Errors, &PathError{Choices: h.path, Err: err})
+		case h.stopped:
+			// Branched, pruned, or truncated.
+		default:
+			res.Paths++
+			res.Endings[h.node]++
+		}
+		if res.Limited {
+			break
+		}
+	}
+	return res, nil
+}
+
+// exploreItem is a choice yet to be explored.
+type exploreItem struct {
+	snap   *Snapshot // nil for the start
+	vars   map[string]any
+	hist   History
+	node   string   // the current node
+	path   []Option // choices made before this one
+	choice int      // option ID to choose, or -1 for the start
+}
+
+// exploreHandler is the DialogueHandler used by ExhaustiveWalker.
+type exploreHandler struct {
+	FakeDialogueHandler
+
+	vm        *VirtualMachine
+	vars      contentsStorage
+	res       *ExploreResult
+	seen      map[StateHash]bool
+	frontier  []*exploreItem
+	maxDepth  int
+	maxStates int
+	maxEvents int
+
+	// Per path segment.
+	choice  int
+	path    []Option
+	node    string
+	events  int
+	stopped bool
+}
+
+// start prepares to run an item.
+func (h *exploreHandler) start(item *exploreItem) {
+	h.vars.ReplaceContents(item.vars)
+	h.vm.SetHistory(item.hist)
+	h.choice = item.choice
+	h.node = item.node
+	h.path = item.path
+	h.events = 0
+	h.stopped = false
+}
+
+func (h *exploreHandler) event() error {
+	h.events++
+	if h.events > h.maxEvents {
+		return ErrWalkLimit
+	}
+	return nil
+}
+
+func (h *exploreHandler) NodeStart(nodeName string) error {
+	h.node = nodeName
+	return nil
+}
+
+func (h *exploreHandler) Line(Line) error      { return h.event() }
+func (h *exploreHandler) Command(string) error { return h.event() }
+
+func (h *exploreHandler) Options(options []Option) (int, error) {
+	if h.choice >= 0 {
+		// Resuming from a snapshot: make the choice for this item.
+		id := h.choice
+		h.choice = -1
+		for _, opt := range options {
+			if opt.ID == id {
+				h.path = append(h.path[:len(h.path):len(h.path)], opt)
+			}
+		}
+		return id, nil
+	}
+	if err := h.event(); err != nil {
+		return -1, err
+	}
+	avail := availableOptions(options)
+	if len(avail) == 0 {
+		return -1, ErrNoAvailableOptions
+	}
+	h.stopped = true
+	snap, vars := h.vm.Snapshot(), h.vars.Contents()
+	key := HashState(snap, vars)
+	switch {
+	case h.seen[key]:
+		h.res.Pruned++
+		return -1, Stop
+	case len(h.path) >= h.maxDepth:
+		h.res.Truncated++
+		return -1, Stop
+	case len(h.seen) >= h.maxStates:
+		h.res.Limited = true
+		return -1, Stop
+	}
+	h.seen[key] = true
+	h.res.States++
+
+	hist := h.vm.History()
+	for i := len(avail) - 1; i >= 0; i-- {
+		h.frontier = append(h.frontier, &exploreItem{
+			snap:   snap,
+			vars:   vars,
+			hist:   hist,
+			node:   h.node,
+			path:   h.path,
+			choice: avail[i].ID,
+		})
+	}
+	return -1, Stop
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestExhaustiveWalker(t *testing.T) {
+	pb := NewProgramBuilder("Explore")
+	pb.Node("Start").
+		Option("line:a", "Mid", 0, false).
+		Option("line:b", "Mid", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Mid").
+		Option("line:c", "End", 0, false).
+		Option("line:d", "Broken", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Broken").RunNode("Missing")
+	pb.Node("Hub").
+		Option("line:again", "Hub", 0, false).
+		Option("line:leave", "End", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Laps").
+		PushVariable("$laps").PushFloat(1).Call("Number.Add", 2).StoreVariable("$laps").Pop().
+		Option("line:again", "Laps", 0, false).
+		Option("line:leave", "End", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("End").Line("line:end", 0)
+	prog := pb.Program()
+
+	w := &ExhaustiveWalker{Program: prog}
+	got, err := w.Explore("Start")
+	if err != nil {
+		t.Fatalf("w.Explore(Start) = %v", err)
+	}
+	// Choosing a or b reaches the same state at Mid, so b is pruned.
+	want := &ExploreResult{
+		States:  2,
+		Pruned:  1,
+		Paths:   1,
+		Endings: map[string]int{"End": 1},
+	}
+	if diff := cmp.Diff(got, want, cmpopts.IgnoreFields(ExploreResult{}, "Errors")); diff != "" {
+		t.Errorf("w.Explore(Start) diff (-got +want):\n%s", diff)
+	}
+	if len(got.Errors) != 1 {
+		t.Fatalf("w.Explore(Start) errors = %v, want 1 error", got.Errors)
+	}
+	perr := got.Errors[0]
+	if !errors.Is(perr, ErrNodeNotFound) {
+		t.Errorf("path error = %v, want %v", perr, ErrNodeNotFound)
+	}
+	var choices []string
+	for _, c := range perr.Choices {
+		choices = append(choices, c.Line.ID)
+	}
+	if diff := cmp.Diff(choices, []string{"line:a", "line:d"}); diff != "" {
+		t.Errorf("path error choices diff (-got +want):\n%s", diff)
+	}
+
+	// Going around the hub again returns to the same state.
+	got, err = w.Explore("Hub")
+	if err != nil {
+		t.Fatalf("w.Explore(Hub) = %v", err)
+	}
+	want = &ExploreResult{
+		States:  1,
+		Pruned:  1,
+		Paths:   1,
+		Endings: map[string]int{"End": 1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("w.Explore(Hub) diff (-got +want):\n%s", diff)
+	}
+
+	// $laps changes on every lap, so only MaxDepth bounds it.
+	w = &ExhaustiveWalker{
+		Program:  prog,
+		MaxDepth: 5,
+		NewVars: func() VariableStorage {
+			return NewMapVariableStorageFromMap(map[string]any{"$laps": float32(0)})
+		},
+	}
+	got, err = w.Explore("Laps")
+	if err != nil {
+		t.Fatalf("w.Explore(Laps) = %v", err)
+	}
+	want = &ExploreResult{
+		States:    5,
+		Paths:     5,
+		Truncated: 1,
+		Endings:   map[string]int{"End": 5},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("w.Explore(Laps) diff (-got +want):\n%s", diff)
+	}
+}
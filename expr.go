@@ -0,0 +1,369 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrBadExpression is returned when an expression cannot be compiled.
+const ErrBadExpression = virtualMachineError("bad expression")
+
+// Expression is a compiled Yarn expression, such as `$gold >= 10 and
+// visited("Shop")`. Expressions are compiled to the same instructions the Yarn
+// Spinner compiler would emit, and evaluated by a VirtualMachine using its
+// variables and functions.
+//
+// Because expressions are compiled without type information, operators use
+// the untyped functions (e.g. "Add" rather than "Number.Add").
+type Expression struct {
+	// Source is the original text of the expression.
+	Source string
+
+	insts []*yarnpb.Instruction
+}
+
+func (e *Expression) String() string { return e.Source }
+
+// CompileExpression compiles an expression. The syntax is that of Yarn
+// Spinner expressions: number, string, and boolean literals, null,
+// $variables, function calls, parentheses, and the operators
+//
+//	or || xor ^ and && == != is eq neq < > <= >= lt gt lte gte + - * / % not !
+//
+// in order of increasing precedence.
+func CompileExpression(src string) (*Expression, error) {
+	e, rest, err := compileExpressionPrefix(src)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(rest) != "" {
+		return nil, fmt.Errorf("%w: unexpected %q after expression", ErrBadExpression, rest)
+	}
+	return e, nil
+}
+
+// MustCompileExpression is like CompileExpression, but panics on error. It is
+// intended for expressions that are constant in the source code.
+func MustCompileExpression(src string) *Expression {
+	e, err := CompileExpression(src)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// compileExpressionPrefix compiles the longest expression at the start of
+// src, and returns the remaining text.
+func compileExpressionPrefix(src string) (*Expression, string, error) {
+	p := &exprParser{src: src}
+	p.next()
+	if err := p.parseBinary(0); err != nil {
+		return nil, "", err
+	}
+	return &Expression{Source: strings.TrimSpace(src[:p.tokStart]), insts: p.insts}, src[p.tokStart:], nil
+}
+
+// Evaluate evaluates the expression using the VM's variables and functions.
+// It can be called while the VM is not running, or from within handler
+// methods; the VM's execution state is not changed.
+func (vm *VirtualMachine) Evaluate(e *Expression) (any, error) {
+	if vm.Program == nil {
+		return nil, ErrMissingProgram
+	}
+	if vm.Vars == nil {
+		return nil, ErrNilVariableStorage
+	}
+	if vm.internalFuncs == nil {
+		// Not yet run, so set up functions the same way run does.
+		vm.FuncMap = vm.defaultFuncMap().merge(vm.FuncMap)
+		vm.internalFuncs = vm.internalFuncMap()
+	}
+	saved := vm.state
+	defer func() { vm.state = saved }()
+	node := &yarnpb.Node{Instructions: e.insts}
+	if saved.node != nil {
+		node.Name = saved.node.Name
+	}
+	vm.state = state{node: node, locals: saved.locals, declared: saved.declared}
+	for vm.state.pc < len(e.insts) {
+		if err := vm.executeExpr(e.insts[vm.state.pc]); err != nil {
+			return nil, fmt.Errorf("evaluating %q: %w", e.Source, err)
+		}
+	}
+	return vm.state.pop()
+}
+
+// executeExpr executes one of the instructions emitted for expressions. (It
+// doesn't use the dispatch table, since that would create an initialization
+// cycle when expressions are evaluated by instructions.)
+func (vm *VirtualMachine) executeExpr(inst *yarnpb.Instruction) error {
+	switch inst.Opcode {
+	case yarnpb.Instruction_PUSH_STRING:
+		return vm.execPushString(inst.Operands)
+	case yarnpb.Instruction_PUSH_FLOAT:
+		return vm.execPushFloat(inst.Operands)
+	case yarnpb.Instruction_PUSH_BOOL:
+		return vm.execPushBool(inst.Operands)
+	case yarnpb.Instruction_PUSH_NULL:
+		return vm.execPushNull(inst.Operands)
+	case yarnpb.Instruction_PUSH_VARIABLE:
+		return vm.execPushVariable(inst.Operands)
+	case yarnpb.Instruction_CALL_FUNC:
+		return vm.execCallFunc(inst.Operands)
+	}
+	return fmt.Errorf("invalid opcode %v in expression", inst.Opcode)
+}
+
+// EvaluateBool evaluates the expression and converts the result to bool.
+func (vm *VirtualMachine) EvaluateBool(e *Expression) (bool, error) {
+	x, err := vm.Evaluate(e)
+	if err != nil {
+		return false, err
+	}
+	return ConvertToBool(x)
+}
+
+type exprTokenKind int
+
+const (
+	tokEOF exprTokenKind = iota
+	tokNumber
+	tokString
+	tokVariable
+	tokIdent
+	tokOperator
+)
+
+// Binary operators, by precedence level (lowest first).
+var exprBinaryOps = []map[string]string{
+	{"or": "Or", "||": "Or"},
+	{"xor": "Xor", "^": "Xor"},
+	{"and": "And", "&&": "And"},
+	{"==": "EqualTo", "is": "EqualTo", "eq": "EqualTo", "!=": "NotEqualTo", "neq": "NotEqualTo"},
+	{"<": "LessThan", ">": "GreaterThan", "<=": "LessThanOrEqualTo", ">=": "GreaterThanOrEqualTo",
+		"lt": "LessThan", "gt": "GreaterThan", "lte": "LessThanOrEqualTo", "gte": "GreaterThanOrEqualTo"},
+	{"+": "Add", "-": "Minus"},
+	{"*": "Multiply", "/": "Divide", "%": "Modulo"},
+}
+
+var exprUnaryOps = map[string]string{"-": "UnaryMinus", "!": "Not", "not": "Not"}
+
+// exprParser is a recursive descent parser that emits instructions as it
+// goes.
+type exprParser struct {
+	src      string
+	pos      int // position after the current token
+	tokStart int // position of the current token
+	kind     exprTokenKind
+	tok      string // text of the current token (unquoted, for strings)
+	err      error
+	insts    []*yarnpb.Instruction
+}
+
+func (p *exprParser) emit(op yarnpb.Instruction_OpCode, operands ...*yarnpb.Operand) {
+	p.insts = append(p.insts, &yarnpb.Instruction{Opcode: op, Operands: operands})
+}
+
+func (p *exprParser) emitCall(name string, argc int) {
+	p.emit(yarnpb.Instruction_PUSH_FLOAT, &yarnpb.Operand{Value: &yarnpb.Operand_FloatValue{FloatValue: float32(argc)}})
+	p.emit(yarnpb.Instruction_CALL_FUNC, &yarnpb.Operand{Value: &yarnpb.Operand_StringValue{StringValue: name}})
+}
+
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at offset %d in %q", ErrBadExpression, fmt.Sprintf(format, args...), p.tokStart, p.src)
+}
+
+// next advances to the next token.
+func (p *exprParser) next() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	p.tokStart = p.pos
+	if p.pos >= len(p.src) {
+		p.kind, p.tok = tokEOF, ""
+		return
+	}
+	rest := p.src[p.pos:]
+	r, _ := utf8.DecodeRuneInString(rest)
+	switch {
+	case r >= '0' && r <= '9', r == '.' && len(rest) > 1 && rest[1] >= '0' && rest[1] <= '9':
+		n := strings.IndexFunc(rest, func(r rune) bool { return !(r >= '0' && r <= '9' || r == '.') })
+		if n < 0 {
+			n = len(rest)
+		}
+		p.kind, p.tok = tokNumber, rest[:n]
+
+	case r == '"':
+		var sb strings.Builder
+		i := 1
+		for ; i < len(rest) && rest[i] != '"'; i++ {
+			if rest[i] == '\\' && i+1 < len(rest) {
+				i++
+			}
+			sb.WriteByte(rest[i])
+		}
+		if i >= len(rest) {
+			p.err = p.errorf("unterminated string")
+			p.kind, p.tok = tokEOF, ""
+			return
+		}
+		p.kind, p.tok = tokString, sb.String()
+		p.pos += i + 1
+		return
+
+	case r == '$', r == '_', unicode.IsLetter(r):
+		n := strings.IndexFunc(rest[1:], func(r rune) bool {
+			return !(r == '_' || r == '.' || unicode.IsLetter(r) || unicode.IsDigit(r))
+		})
+		if n < 0 {
+			n = len(rest) - 1
+		}
+		p.kind, p.tok = tokIdent, rest[:n+1]
+		if r == '$' {
+			p.kind = tokVariable
+		}
+
+	default:
+		p.kind, p.tok = tokOperator, rest[:1]
+		for _, op := range []string{"==", "!=", "<=", ">=", "&&", "||"} {
+			if strings.HasPrefix(rest, op) {
+				p.tok = op
+				break
+			}
+		}
+	}
+	p.pos += len(p.tok)
+}
+
+// isOp reports whether the current token is one of the operators or
+// keywords in ops, returning the function name.
+func (p *exprParser) isOp(ops map[string]string) (string, bool) {
+	if p.kind != tokOperator && p.kind != tokIdent {
+		return "", false
+	}
+	f, ok := ops[strings.ToLower(p.tok)]
+	return f, ok
+}
+
+func (p *exprParser) parseBinary(level int) error {
+	if level == len(exprBinaryOps) {
+		return p.parseUnary()
+	}
+	if err := p.parseBinary(level + 1); err != nil {
+		return err
+	}
+	for {
+		f, ok := p.isOp(exprBinaryOps[level])
+		if !ok {
+			return p.err
+		}
+		p.next()
+		if err := p.parseBinary(level + 1); err != nil {
+			return err
+		}
+		p.emitCall(f, 2)
+	}
+}
+
+func (p *exprParser) parseUnary() error {
+	if f, ok := p.isOp(exprUnaryOps); ok {
+		p.next()
+		if err := p.parseUnary(); err != nil {
+			return err
+		}
+		p.emitCall(f, 1)
+		return nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *exprParser) parsePrimary() error {
+	if p.err != nil {
+		return p.err
+	}
+	switch p.kind {
+	case tokEOF:
+		return p.errorf("unexpected end of expression")
+
+	case tokNumber:
+		f, err := strconv.ParseFloat(p.tok, 32)
+		if err != nil {
+			return p.errorf("invalid number %q", p.tok)
+		}
+		p.emit(yarnpb.Instruction_PUSH_FLOAT, &yarnpb.Operand{Value: &yarnpb.Operand_FloatValue{FloatValue: float32(f)}})
+
+	case tokString:
+		p.emit(yarnpb.Instruction_PUSH_STRING, &yarnpb.Operand{Value: &yarnpb.Operand_StringValue{StringValue: p.tok}})
+
+	case tokVariable:
+		p.emit(yarnpb.Instruction_PUSH_VARIABLE, &yarnpb.Operand{Value: &yarnpb.Operand_StringValue{StringValue: p.tok}})
+
+	case tokIdent:
+		switch strings.ToLower(p.tok) {
+		case "true", "false":
+			p.emit(yarnpb.Instruction_PUSH_BOOL, &yarnpb.Operand{Value: &yarnpb.Operand_BoolValue{BoolValue: strings.EqualFold(p.tok, "true")}})
+		case "null":
+			p.emit(yarnpb.Instruction_PUSH_NULL)
+		default:
+			return p.parseCall()
+		}
+
+	case tokOperator:
+		if p.tok != "(" {
+			return p.errorf("unexpected %q", p.tok)
+		}
+		p.next()
+		if err := p.parseBinary(0); err != nil {
+			return err
+		}
+		if p.kind != tokOperator || p.tok != ")" {
+			return p.errorf("want ), got %q", p.tok)
+		}
+	}
+	p.next()
+	return p.err
+}
+
+func (p *exprParser) parseCall() error {
+	name := p.tok
+	p.next()
+	if p.kind != tokOperator || p.tok != "(" {
+		return p.errorf("want ( after function name %q", name)
+	}
+	p.next()
+	argc := 0
+	for !(p.kind == tokOperator && p.tok == ")") {
+		if argc > 0 {
+			if p.kind != tokOperator || p.tok != "," {
+				return p.errorf("want , or ) in call to %q, got %q", name, p.tok)
+			}
+			p.next()
+		}
+		if err := p.parseBinary(0); err != nil {
+			return err
+		}
+		argc++
+	}
+	p.emitCall(name, argc)
+	p.next()
+	return p.err
+}
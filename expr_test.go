@@ -0,0 +1,76 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+func TestEvaluate(t *testing.T) {
+	vars := NewMapVariableStorage()
+	vars.SetValue("$gold", float32(12))
+	vars.SetValue("$name", "Bea")
+	vars.SetValue("$met_bea", true)
+	vm := &VirtualMachine{
+		Program: &yarnpb.Program{},
+		Vars:    vars,
+		FuncMap: FuncMap{"double": func(x float32) float32 { return 2 * x }},
+	}
+
+	tests := []struct {
+		src  string
+		want any
+	}{
+		{`1 + 2 * 3`, float32(7)},
+		{`(1 + 2) * 3`, float32(9)},
+		{`-$gold + 2`, float32(-10)},
+		{`10 % 4`, float32(2)},
+		{`$gold >= 10 and $met_bea`, true},
+		{`$gold gte 20 || not $met_bea`, false},
+		{`$gold > 10 xor $gold < 20`, false},
+		{`$name == "Bea"`, true},
+		{`$name is "Al" or $name neq "Al"`, true},
+		{`"Hi, " + $name + "!"`, "Hi, Bea!"},
+		{`"say \"hi\""`, `say "hi"`},
+		{`double($gold) - double(.5)`, float32(23)},
+		{`round(3.7) == 4`, true},
+		{`$missing == null`, true},
+		{`!True`, false},
+	}
+	for _, test := range tests {
+		e, err := CompileExpression(test.src)
+		if err != nil {
+			t.Errorf("CompileExpression(%q) error = %v", test.src, err)
+			continue
+		}
+		got, err := vm.Evaluate(e)
+		if err != nil {
+			t.Errorf("vm.Evaluate(%q) error = %v", test.src, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("vm.Evaluate(%q) = %v (%T), want %v (%T)", test.src, got, got, test.want, test.want)
+		}
+	}
+
+	for _, src := range []string{``, `1 +`, `(1`, `"open`, `f(1 2)`, `1 2`, `and`, `nope`} {
+		if _, err := CompileExpression(src); !errors.Is(err, ErrBadExpression) {
+			t.Errorf("CompileExpression(%q) error = %v, want %v", src, err, ErrBadExpression)
+		}
+	}
+}
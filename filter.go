@@ -0,0 +1,190 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// Content flags allow one source to produce different builds (demo or full,
+// mobile or desktop, and so on). Nodes and lines are marked with conditions:
+//
+//   - A node header "when_KEY: VALUE" keeps the node only if flag KEY is set to
+//     VALUE. VALUE may be a comma-separated list of alternatives, e.g.
+//     "when_platform: mobile, console".
+//   - A node or line tag "if:FLAG" keeps it only if FLAG is set (to any value),
+//     "if:!FLAG" keeps it only if FLAG is not set, and "if:KEY=VALUE" keeps it
+//     only if flag KEY is set to VALUE. Line tags are set in the metadata
+//     table, e.g. #if:demo.
+//
+// A node or line must satisfy all of its conditions to be kept.
+const (
+	whenHeaderPrefix = "when_"
+	ifTagPrefix      = "if:"
+)
+
+// ContentFlags are the flags for one build, e.g.
+// ContentFlags{"platform": "mobile", "demo": ""}.
+type ContentFlags map[string]string
+
+// keep reports whether the tags and headers are satisfied by the flags.
+func (f ContentFlags) keep(tags []string, headers []*yarnpb.Header) bool {
+	for _, h := range headers {
+		key, ok := strings.CutPrefix(h.GetKey(), whenHeaderPrefix)
+		if !ok {
+			continue
+		}
+		val, set := f[key]
+		if !set || !containsTrimmed(strings.Split(h.GetValue(), ","), val) {
+			return false
+		}
+	}
+	for _, t := range tags {
+		cond, ok := strings.CutPrefix(t, ifTagPrefix)
+		if !ok {
+			continue
+		}
+		if neg, ok := strings.CutPrefix(cond, "!"); ok {
+			if _, set := f[neg]; set {
+				return false
+			}
+			continue
+		}
+		key, want, hasVal := strings.Cut(cond, "=")
+		val, set := f[key]
+		if !set || (hasVal && val != want) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsTrimmed(list []string, s string) bool {
+	for _, x := range list {
+		if strings.TrimSpace(x) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterProgram returns a copy of the program with content that doesn't
+// match the flags removed, so it can be stripped before shipping. Nodes are
+// filtered using their headers and tags. Lines and options are filtered using
+// their tags in st, if st is not nil; removed lines and options are skipped
+// entirely (any substitutions and conditions are still evaluated, and then
+// discarded).
+//
+// Jumps to removed nodes are left in place; use ValidateProgram on the result
+// to find them.
+func FilterProgram(prog *yarnpb.Program, flags ContentFlags, st *StringTable) (*yarnpb.Program, error) {
+	out := proto.Clone(prog).(*yarnpb.Program)
+	for name, node := range out.Nodes {
+		if !flags.keep(node.Tags, node.Headers) {
+			delete(out.Nodes, name)
+			continue
+		}
+		if st == nil {
+			continue
+		}
+		if err := filterLines(node, flags, st); err != nil {
+			return nil, fmt.Errorf("node %q: %w", name, err)
+		}
+	}
+	return out, nil
+}
+
+// filterLines replaces each RUN_LINE and ADD_OPTION instruction for a removed
+// line with enough POPs to discard its stack operands.
+func filterLines(node *yarnpb.Node, flags ContentFlags, st *StringTable) error {
+	// Work backwards so that replacing an instruction doesn't change the
+	// positions of those yet to be examined.
+	for pc := len(node.Instructions) - 1; pc >= 0; pc-- {
+		inst := node.Instructions[pc]
+		var n int
+		switch inst.Opcode {
+		case yarnpb.Instruction_RUN_LINE:
+			if len(inst.Operands) > 1 {
+				c, err := operandToInt(inst.Operands[1])
+				if err != nil {
+					return fmt.Errorf("pc %d: %w", pc, err)
+				}
+				n = c
+			}
+		case yarnpb.Instruction_ADD_OPTION:
+			if len(inst.Operands) > 2 {
+				c, err := operandToInt(inst.Operands[2])
+				if err != nil {
+					return fmt.Errorf("pc %d: %w", pc, err)
+				}
+				n = c
+			}
+			if len(inst.Operands) > 3 && inst.Operands[3].GetBoolValue() {
+				n++
+			}
+		default:
+			continue
+		}
+		row := st.Table[inst.Operands[0].GetStringValue()]
+		if row == nil || flags.keep(row.Tags, nil) {
+			continue
+		}
+		pops := make([]*yarnpb.Instruction, n)
+		for i := range pops {
+			pops[i] = &yarnpb.Instruction{Opcode: yarnpb.Instruction_POP}
+		}
+		replaceInstruction(node, pc, pops)
+	}
+	return nil
+}
+
+// replaceInstruction replaces the instruction at pc with zero or more
+// instructions, updating labels that point after it.
+func replaceInstruction(node *yarnpb.Node, pc int, with []*yarnpb.Instruction) {
+	insts := make([]*yarnpb.Instruction, 0, len(node.Instructions)-1+len(with))
+	insts = append(insts, node.Instructions[:pc]...)
+	insts = append(insts, with...)
+	insts = append(insts, node.Instructions[pc+1:]...)
+	node.Instructions = insts
+	delta := int32(len(with) - 1)
+	for label, lpc := range node.Labels {
+		if lpc > int32(pc) {
+			node.Labels[label] = lpc + delta
+		}
+	}
+}
+
+// FilterStringTable returns a copy of the string table without the rows
+// whose tags don't match the flags, or whose node is not in prog (e.g. because
+// it was removed by FilterProgram). prog may be nil, in which case rows are
+// only filtered by their tags.
+func FilterStringTable(st *StringTable, flags ContentFlags, prog *yarnpb.Program) *StringTable {
+	out := *st
+	out.Table = make(map[string]*StringTableRow, len(st.Table))
+	for id, row := range st.Table {
+		if !flags.keep(row.Tags, nil) {
+			continue
+		}
+		if prog != nil && row.Node != "" && prog.Nodes[row.Node] == nil {
+			continue
+		}
+		out.Table[id] = row
+	}
+	return &out
+}
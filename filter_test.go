@@ -0,0 +1,104 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestFilterProgram(t *testing.T) {
+	pb := NewProgramBuilder("Filter")
+	pb.Node("Start").
+		Line("line:demo", 0).
+		PushString("x").
+		PushBool(true).
+		Option("line:full", "L0", 1, true).
+		Label("L0").
+		Line("line:always", 0).
+		JumpTo("end").
+		Label("end").
+		Stop()
+	pb.Node("Demo").Tags("if:demo")
+	pb.Node("Full").Tags("if:!demo")
+	pb.Node("Mobile").Header("when_platform", "mobile, console")
+	pb.Node("Steam").Tags("if:store=steam")
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:demo":   {ID: "line:demo", Node: "Start", Tags: []string{"if:demo"}},
+		"line:full":   {ID: "line:full", Node: "Start", Tags: []string{"if:!demo"}},
+		"line:always": {ID: "line:always", Node: "Start", Tags: []string{"lastline"}},
+		"line:steam":  {ID: "line:steam", Node: "Steam"},
+	}}
+
+	tests := []struct {
+		flags     ContentFlags
+		wantNodes []string
+		wantLines []string
+	}{
+		{
+			flags:     ContentFlags{"demo": ""},
+			wantNodes: []string{"Demo", "Start"},
+			wantLines: []string{"line:always", "line:demo"},
+		},
+		{
+			flags:     ContentFlags{"platform": "console", "store": "steam"},
+			wantNodes: []string{"Full", "Mobile", "Start", "Steam"},
+			wantLines: []string{"line:always", "line:full", "line:steam"},
+		},
+		{
+			flags:     ContentFlags{"platform": "desktop", "store": "gog"},
+			wantNodes: []string{"Full", "Start"},
+			wantLines: []string{"line:always", "line:full"},
+		},
+	}
+	for _, test := range tests {
+		prog, err := FilterProgram(pb.Program(), test.flags, st)
+		if err != nil {
+			t.Fatalf("FilterProgram(prog, %v, st) error = %v", test.flags, err)
+		}
+		gotNodes := sortedKeys(prog.Nodes)
+		if diff := cmp.Diff(gotNodes, test.wantNodes); diff != "" {
+			t.Errorf("FilterProgram(prog, %v, st) nodes diff (-got +want):\n%s", test.flags, diff)
+		}
+		gotLines := sortedKeys(FilterStringTable(st, test.flags, prog).Table)
+		if diff := cmp.Diff(gotLines, test.wantLines); diff != "" {
+			t.Errorf("FilterStringTable(st, %v, prog) lines diff (-got +want):\n%s", test.flags, diff)
+		}
+	}
+
+	// In the demo, the option (with one substitution and a condition) is
+	// replaced by two POPs, and the labels after it move along.
+	prog, err := FilterProgram(pb.Program(), ContentFlags{"demo": ""}, st)
+	if err != nil {
+		t.Fatalf("FilterProgram(prog, demo, st) error = %v", err)
+	}
+	want := NewNodeBuilder("Start").
+		Line("line:demo", 0).
+		PushString("x").
+		PushBool(true).
+		Pop().
+		Pop().
+		Label("L0").
+		Line("line:always", 0).
+		JumpTo("end").
+		Label("end").
+		Stop().
+		Build()
+	if diff := cmp.Diff(prog.Nodes["Start"], want, protocmp.Transform()); diff != "" {
+		t.Errorf("filtered Start node diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// OptionStats are the statistics of one option, aggregated over sessions.
+type OptionStats struct {
+	// Node is the node that presents the option.
+	Node string `json:"node"`
+
+	// LineID is the option's line ID.
+	LineID string `json:"line_id"`
+
+	// Shown is the number of times the option was available when options
+	// were presented, and Picked is the number of times it was chosen.
+	Shown  int `json:"shown"`
+	Picked int `json:"picked"`
+}
+
+// PickRate returns Picked / Shown, or 0 if the option was never shown.
+func (s OptionStats) PickRate() float64 {
+	if s.Shown == 0 {
+		return 0
+	}
+	return float64(s.Picked) / float64(s.Shown)
+}
+
+// FunnelStep is one step of a funnel (see AnalyticsReport.Funnel).
+type FunnelStep struct {
+	// Node is the node of this step.
+	Node string `json:"node"`
+
+	// Reached is the number of sessions that entered this node after
+	// reaching all the previous steps, in order.
+	Reached int `json:"reached"`
+}
+
+// AnalyticsReport aggregates analytics events (see AnalyticsEvent) from many
+// play sessions into per-option pick rates and node funnels, so that
+// narrative designers can see which options are rarely (or never) picked,
+// and where players leave a branch. It is safe for concurrent use.
+type AnalyticsReport struct {
+	mu       sync.Mutex
+	sessions int
+	options  map[optionKey]*OptionStats
+	paths    [][]string // node entries of each session
+}
+
+type optionKey struct{ node, lineID string }
+
+// AddSession adds the events from one play session. Events other than
+// AnalyticsChoice and AnalyticsNode are ignored.
+func (r *AnalyticsReport) AddSession(events []AnalyticsEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.options == nil {
+		r.options = make(map[optionKey]*OptionStats)
+	}
+	r.sessions++
+	var path []string
+	for _, ev := range events {
+		switch ev.Kind {
+		case AnalyticsNode:
+			path = append(path, ev.Node)
+		case AnalyticsChoice:
+			for _, id := range ev.Options {
+				r.option(ev.Node, id).Shown++
+			}
+			r.option(ev.Node, ev.Choice).Picked++
+		}
+	}
+	r.paths = append(r.paths, path)
+}
+
+// option returns the stats for an option, creating them if necessary.
+func (r *AnalyticsReport) option(node, lineID string) *OptionStats {
+	k := optionKey{node, lineID}
+	s := r.options[k]
+	if s == nil {
+		s = &OptionStats{Node: node, LineID: lineID}
+		r.options[k] = s
+	}
+	return s
+}
+
+// Sessions returns the number of sessions added.
+func (r *AnalyticsReport) Sessions() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.sessions
+}
+
+// Options returns the stats of every option, sorted by node and line ID.
+func (r *AnalyticsReport) Options() []OptionStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := make([]OptionStats, 0, len(r.options))
+	for _, s := range r.options {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Node != stats[j].Node {
+			return stats[i].Node < stats[j].Node
+		}
+		return stats[i].LineID < stats[j].LineID
+	})
+	return stats
+}
+
+// Unpicked returns the stats of options that were shown but never picked.
+func (r *AnalyticsReport) Unpicked() []OptionStats {
+	var unpicked []OptionStats
+	for _, s := range r.Options() {
+		if s.Shown > 0 && s.Picked == 0 {
+			unpicked = append(unpicked, s)
+		}
+	}
+	return unpicked
+}
+
+// Funnel counts the sessions that entered each of the nodes in order (not
+// necessarily consecutively): the first step counts the sessions that
+// entered steps[0], the second counts those that then entered steps[1], and
+// so on.
+func (r *AnalyticsReport) Funnel(steps ...string) []FunnelStep {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	funnel := make([]FunnelStep, len(steps))
+	for i, node := range steps {
+		funnel[i].Node = node
+	}
+	for _, path := range r.paths {
+		step := 0
+		for _, node := range path {
+			if step == len(steps) {
+				break
+			}
+			if node == steps[step] {
+				funnel[step].Reached++
+				step++
+			}
+		}
+	}
+	return funnel
+}
+
+// WriteCSV writes the option stats as CSV, with a header row.
+func (r *AnalyticsReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"node", "line_id", "shown", "picked", "pick_rate"})
+	for _, s := range r.Options() {
+		cw.Write([]string{
+			s.Node,
+			s.LineID,
+			strconv.Itoa(s.Shown),
+			strconv.Itoa(s.Picked),
+			strconv.FormatFloat(s.PickRate(), 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// WriteFunnelCSV writes a funnel as CSV, with a header row. The rate of each
+// step is relative to the first step.
+func WriteFunnelCSV(w io.Writer, funnel []FunnelStep) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"step", "node", "reached", "rate"})
+	for i, s := range funnel {
+		rate := 0.0
+		if funnel[0].Reached > 0 {
+			rate = float64(s.Reached) / float64(funnel[0].Reached)
+		}
+		cw.Write([]string{
+			strconv.Itoa(i + 1),
+			s.Node,
+			strconv.Itoa(s.Reached),
+			strconv.FormatFloat(rate, 'f', 4, 64),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// WriteJSON writes the number of sessions and the option stats (including
+// pick rates) as JSON.
+func (r *AnalyticsReport) WriteJSON(w io.Writer) error {
+	type optionJSON struct {
+		OptionStats
+		PickRate float64 `json:"pick_rate"`
+	}
+	out := struct {
+		Sessions int          `json:"sessions"`
+		Options  []optionJSON `json:"options"`
+	}{Sessions: r.Sessions()}
+	for _, s := range r.Options() {
+		out.Options = append(out.Options, optionJSON{s, s.PickRate()})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return fmt.Errorf("encoding json: %w", err)
+	}
+	return nil
+}
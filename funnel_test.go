@@ -0,0 +1,102 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAnalyticsReport(t *testing.T) {
+	session := func(choice, branch string) []AnalyticsEvent {
+		evs := []AnalyticsEvent{
+			{Kind: AnalyticsNode, Node: "Start"},
+			{Kind: AnalyticsLine, Node: "Start", LineID: "line:hi"},
+			{Kind: AnalyticsChoice, Node: "Start", Options: []string{"line:buy", "line:haggle", "line:leave"}, Choice: choice},
+		}
+		if branch != "" {
+			evs = append(evs, AnalyticsEvent{Kind: AnalyticsNode, Node: branch})
+		}
+		return append(evs, AnalyticsEvent{Kind: AnalyticsNode, Node: "End"})
+	}
+	r := &AnalyticsReport{}
+	r.AddSession(session("line:buy", "Shop"))
+	r.AddSession(session("line:buy", "Shop"))
+	r.AddSession(session("line:leave", ""))
+	r.AddSession([]AnalyticsEvent{{Kind: AnalyticsNode, Node: "Shop"}})
+
+	want := []OptionStats{
+		{Node: "Start", LineID: "line:buy", Shown: 3, Picked: 2},
+		{Node: "Start", LineID: "line:haggle", Shown: 3},
+		{Node: "Start", LineID: "line:leave", Shown: 3, Picked: 1},
+	}
+	if diff := cmp.Diff(r.Options(), want); diff != "" {
+		t.Errorf("r.Options() diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(r.Unpicked(), want[1:2]); diff != "" {
+		t.Errorf("r.Unpicked() diff (-got +want):\n%s", diff)
+	}
+
+	funnel := r.Funnel("Start", "Shop", "End")
+	wantFunnel := []FunnelStep{{"Start", 3}, {"Shop", 2}, {"End", 2}}
+	if diff := cmp.Diff(funnel, wantFunnel); diff != "" {
+		t.Errorf("r.Funnel diff (-got +want):\n%s", diff)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteCSV(&buf); err != nil {
+		t.Fatalf("r.WriteCSV = %v", err)
+	}
+	wantCSV := "node,line_id,shown,picked,pick_rate\n" +
+		"Start,line:buy,3,2,0.6667\n" +
+		"Start,line:haggle,3,0,0.0000\n" +
+		"Start,line:leave,3,1,0.3333\n"
+	if diff := cmp.Diff(buf.String(), wantCSV); diff != "" {
+		t.Errorf("r.WriteCSV diff (-got +want):\n%s", diff)
+	}
+
+	buf.Reset()
+	if err := WriteFunnelCSV(&buf, funnel); err != nil {
+		t.Fatalf("WriteFunnelCSV = %v", err)
+	}
+	wantCSV = "step,node,reached,rate\n" +
+		"1,Start,3,1.0000\n" +
+		"2,Shop,2,0.6667\n" +
+		"3,End,2,0.6667\n"
+	if diff := cmp.Diff(buf.String(), wantCSV); diff != "" {
+		t.Errorf("WriteFunnelCSV diff (-got +want):\n%s", diff)
+	}
+
+	buf.Reset()
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatalf("r.WriteJSON = %v", err)
+	}
+	var got struct {
+		Sessions int
+		Options  []struct {
+			LineID   string  `json:"line_id"`
+			PickRate float64 `json:"pick_rate"`
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("json.Unmarshal = %v", err)
+	}
+	if got.Sessions != 4 || len(got.Options) != 3 || got.Options[2].PickRate != 1.0/3 {
+		t.Errorf("r.WriteJSON = %s", buf.String())
+	}
+}
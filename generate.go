@@ -0,0 +1,81 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// GeneratedTag marks nodes whose content is generated at runtime by the VM's
+// Generator. A node is generated if it has this tag, or a header with this
+// key and the value "true":
+//
+//	title: Rumours
+//	tags: generated
+//	---
+//	(placeholder content, used if there is no Generator)
+//	===
+const GeneratedTag = "generated"
+
+// NodeGenerator builds the content of a generated node (see GeneratedTag)
+// with b, which starts empty (apart from the tags and headers of the node in
+// the program). Since a generated node is built afresh each time it is
+// entered (and when a snapshot in the node is restored), generators that
+// need to support snapshots should be deterministic given the node name and
+// game state.
+//
+// Text for new lines can be recorded with b.Text; see
+// VirtualMachine.GeneratedStrings.
+type NodeGenerator func(node string, b *NodeBuilder) error
+
+// isGenerated reports whether the node is marked as generated.
+func isGenerated(node *yarnpb.Node) bool {
+	for _, t := range node.Tags {
+		if t == GeneratedTag {
+			return true
+		}
+	}
+	for _, h := range node.Headers {
+		if h.Key == GeneratedTag && h.Value == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+// generateNode returns the generated replacement for node, or node itself
+// if it is not generated or there is no generator.
+func (vm *VirtualMachine) generateNode(node *yarnpb.Node) (*yarnpb.Node, error) {
+	if vm.Generator == nil || !isGenerated(node) {
+		return node, nil
+	}
+	b := NewNodeBuilder(node.Name)
+	b.node.Tags = append(b.node.Tags, node.Tags...)
+	b.node.Headers = append(b.node.Headers, node.Headers...)
+	if err := vm.Generator(node.Name, b); err != nil {
+		return nil, fmt.Errorf("generating node %q: %w", node.Name, err)
+	}
+	if st := vm.GeneratedStrings; st != nil && len(b.texts) > 0 {
+		if st.Table == nil {
+			st.Table = make(map[string]*StringTableRow)
+		}
+		for id, text := range b.texts {
+			st.Table[id] = &StringTableRow{ID: id, Text: text, Node: node.Name}
+		}
+	}
+	return b.node, nil
+}
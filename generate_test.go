@@ -0,0 +1,103 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// renderRecorder records rendered lines.
+type renderRecorder struct {
+	FakeDialogueHandler
+	st    *StringTable
+	texts []string
+}
+
+func (r *renderRecorder) Line(line Line) error {
+	as, err := r.st.Render(line)
+	if err != nil {
+		return err
+	}
+	r.texts = append(r.texts, as.String())
+	return nil
+}
+
+func TestGenerator(t *testing.T) {
+	pb := NewProgramBuilder("Generated").InitialValue("$rumours", float32(2))
+	pb.Node("Start").
+		Text("line:intro", "Heard any rumours?").
+		Line("line:intro", 0).
+		RunNode("Rumours")
+	pb.Node("Rumours").Tags(GeneratedTag).
+		Text("line:placeholder", "Nope.").
+		Line("line:placeholder", 0)
+	prog := pb.Program()
+
+	st := &StringTable{Table: make(map[string]*StringTableRow)}
+	for id, text := range pb.Texts() {
+		st.Table[id] = &StringTableRow{ID: id, Text: text}
+	}
+
+	// Without a generator, the placeholder runs.
+	rec := &renderRecorder{st: st}
+	vm := &VirtualMachine{Program: prog, Handler: rec, Vars: NewMapVariableStorage()}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.texts, []string{"Heard any rumours?", "Nope."}); diff != "" {
+		t.Errorf("texts without generator diff (-got +want):\n%s", diff)
+	}
+
+	rec = &renderRecorder{st: st}
+	vm = &VirtualMachine{
+		Program:          prog,
+		Handler:          rec,
+		Vars:             NewMapVariableStorage(),
+		GeneratedStrings: st,
+		Generator: func(node string, b *NodeBuilder) error {
+			if diff := cmp.Diff(b.Build().Tags, []string{GeneratedTag}); diff != "" {
+				t.Errorf("generated node tags diff (-got +want):\n%s", diff)
+			}
+			for i, r := range []string{"The mayor is a {0}.", "The well is haunted."} {
+				id := fmt.Sprintf("line:%s-%d", node, i)
+				b.Text(id, r)
+				if i == 0 {
+					b.PushString("werewolf").Line(id, 1)
+					continue
+				}
+				b.Expr(MustCompileExpression("$rumours > 1")).JumpIfFalse("end").Pop().Line(id, 0)
+			}
+			b.Label("end").Stop()
+			return nil
+		},
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := []string{"Heard any rumours?", "The mayor is a werewolf.", "The well is haunted."}
+	if diff := cmp.Diff(rec.texts, want); diff != "" {
+		t.Errorf("generated texts diff (-got +want):\n%s", diff)
+	}
+
+	errGen := errors.New("out of ideas")
+	vm.Generator = func(string, *NodeBuilder) error { return errGen }
+	if err := vm.Run("Rumours"); !errors.Is(err, errGen) {
+		t.Errorf("vm.Run(Rumours) = %v, want %v", err, errGen)
+	}
+}
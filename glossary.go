@@ -0,0 +1,196 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GlossaryTerm is a term that must be written consistently.
+type GlossaryTerm struct {
+	// Term is the term as written in the reference locale, e.g. "Mana Core".
+	Term string `json:"term"`
+
+	// Translations are the required forms of the term in other locales,
+	// keyed by language code. Locales without a translation are only
+	// checked for consistent case.
+	Translations map[string]string `json:"translations,omitempty"`
+}
+
+// Glossary is a list of terms.
+type Glossary []GlossaryTerm
+
+// ReadGlossaryCSV reads a glossary from CSV. The header row must have a
+// "term" column; the other columns are language codes, containing the
+// translations of each term. Empty cells are ignored.
+func ReadGlossaryCSV(r io.Reader) (Glossary, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading glossary header: %w", err)
+	}
+	termCol := -1
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(h), "term") {
+			termCol = i
+		}
+	}
+	if termCol < 0 {
+		return nil, fmt.Errorf("glossary has no term column")
+	}
+	var g Glossary
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading glossary: %w", err)
+		}
+		gt := GlossaryTerm{Term: rec[termCol]}
+		if gt.Term == "" {
+			continue
+		}
+		for i, v := range rec {
+			if i == termCol || v == "" {
+				continue
+			}
+			if gt.Translations == nil {
+				gt.Translations = make(map[string]string)
+			}
+			gt.Translations[strings.TrimSpace(header[i])] = v
+		}
+		g = append(g, gt)
+	}
+	return g, nil
+}
+
+// GlossaryViolation is a line that uses a glossary term inconsistently.
+type GlossaryViolation struct {
+	LineID string `json:"line_id"`
+	Node   string `json:"node,omitempty"`
+
+	// Term is the form of the term required in the locale.
+	Term string `json:"term"`
+
+	// Found is the form found in the line, or empty if the term is missing.
+	Found string `json:"found,omitempty"`
+
+	Message string `json:"message"`
+}
+
+// Check checks the string table of every locale against the glossary, and
+// returns the violations by language code. reference is the language code of
+// the locale the terms are written in. In each locale, the required form of a
+// term (the term itself in the reference locale, otherwise its translation)
+// must always have the same case. In locales other than the reference, lines
+// using a term in the reference locale must use its translation. Lines are
+// rendered first, as for SpellCheck, and are checked in order of line ID.
+func (g Glossary) Check(locales *LocaleSet, reference string) map[string][]GlossaryViolation {
+	ref := locales.Table(reference)
+	found := make(map[string][]GlossaryViolation)
+	for _, lang := range locales.Locales() {
+		st := locales.Table(lang)
+		if st == nil {
+			continue
+		}
+		var vs []GlossaryViolation
+		for _, id := range sortedKeys(st.Table) {
+			row := st.Table[id]
+			text, ok := glossaryText(st, row)
+			if !ok {
+				continue
+			}
+			for _, gt := range g {
+				want := gt.Term
+				if lang != reference {
+					want = gt.Translations[lang]
+				}
+				if want == "" {
+					continue
+				}
+				uses := findFold(text, want)
+				for _, i := range uses {
+					if got := text[i : i+len(want)]; got != want {
+						vs = append(vs, GlossaryViolation{
+							LineID:  id,
+							Node:    row.Node,
+							Term:    want,
+							Found:   got,
+							Message: fmt.Sprintf("%q should be written %q", got, want),
+						})
+					}
+				}
+				if lang == reference || len(uses) > 0 || ref == nil {
+					continue
+				}
+				refText, ok := glossaryText(ref, ref.Table[id])
+				if ok && len(findFold(refText, gt.Term)) > 0 {
+					vs = append(vs, GlossaryViolation{
+						LineID:  id,
+						Node:    row.Node,
+						Term:    want,
+						Message: fmt.Sprintf("%q is not translated as %q", gt.Term, want),
+					})
+				}
+			}
+		}
+		if len(vs) > 0 {
+			found[lang] = vs
+		}
+	}
+	return found
+}
+
+// glossaryText renders a row for checking against a glossary.
+func glossaryText(st *StringTable, row *StringTableRow) (string, bool) {
+	if row == nil {
+		return "", false
+	}
+	as, err := row.render(spellCheckSubsts, st.Language, st.FormSelectors)
+	if err != nil {
+		return "", false
+	}
+	return as.String(), true
+}
+
+// findFold returns the byte offsets of whole-word occurrences of term in text,
+// ignoring case.
+func findFold(text, term string) []int {
+	var offsets []int
+	for i := range text {
+		end := i + len(term)
+		if end > len(text) || !strings.EqualFold(text[i:end], term) {
+			continue
+		}
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+		offsets = append(offsets, i)
+	}
+	return offsets
+}
+
+// isWordRune reports whether r is part of a word.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
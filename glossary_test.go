@@ -0,0 +1,70 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+func TestGlossaryCheck(t *testing.T) {
+	g, err := ReadGlossaryCSV(strings.NewReader("term,fr,de\nMana Core,Noyau de Mana,\nAva,,\n"))
+	if err != nil {
+		t.Fatalf("ReadGlossaryCSV = %v", err)
+	}
+	if diff := cmp.Diff(g, Glossary{
+		{Term: "Mana Core", Translations: map[string]string{"fr": "Noyau de Mana"}},
+		{Term: "Ava"},
+	}); diff != "" {
+		t.Errorf("ReadGlossaryCSV diff (-got +want):\n%s", diff)
+	}
+
+	locales := &LocaleSet{}
+	locales.Add("en", &StringTable{Language: language.English, Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Node: "Start", Text: "The [b]Mana Core[/b] hums."},
+		"line:2": {ID: "line:2", Node: "Start", Text: "Is the mana core safe, ava?"},
+		"line:3": {ID: "line:3", Node: "Start", Text: "Mana Cores are rare. Avalanche!"},
+	}})
+	locales.Add("fr", &StringTable{Language: language.French, Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Node: "Start", Text: "Le noyau de mana vibre."},
+		"line:2": {ID: "line:2", Node: "Start", Text: "Le cœur de mana est-il sûr, Ava ?"},
+		"line:3": {ID: "line:3", Node: "Start", Text: "Les Noyaux de Mana sont rares."},
+	}})
+	locales.Add("de", &StringTable{Language: language.German, Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Node: "Start", Text: "Der Manakern summt."},
+	}})
+
+	got := g.Check(locales, "en")
+	want := map[string][]GlossaryViolation{
+		"en": {
+			{LineID: "line:2", Node: "Start", Term: "Mana Core", Found: "mana core", Message: `"mana core" should be written "Mana Core"`},
+			{LineID: "line:2", Node: "Start", Term: "Ava", Found: "ava", Message: `"ava" should be written "Ava"`},
+		},
+		"fr": {
+			{LineID: "line:1", Node: "Start", Term: "Noyau de Mana", Found: "noyau de mana", Message: `"noyau de mana" should be written "Noyau de Mana"`},
+			{LineID: "line:2", Node: "Start", Term: "Noyau de Mana", Message: `"Mana Core" is not translated as "Noyau de Mana"`},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Check diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := ReadGlossaryCSV(strings.NewReader("fr,de\nx,y\n")); err == nil {
+		t.Errorf("ReadGlossaryCSV without term column: error = nil, want error")
+	}
+}
@@ -1,12 +1,13 @@
 module github.com/DrJosh9000/yarn
 
-go 1.20
+go 1.21
 
 require (
 	github.com/alecthomas/participle/v2 v2.0.0
 	github.com/razor-1/localizer-cldr v0.2.0
+	github.com/rivo/uniseg v0.2.0
 	golang.org/x/text v0.9.0
 	google.golang.org/protobuf v1.30.0
 )
 
-require github.com/google/go-cmp v0.5.6
+require github.com/google/go-cmp v0.6.0
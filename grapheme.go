@@ -0,0 +1,88 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "github.com/rivo/uniseg"
+
+// Grapheme is a grapheme cluster: what a reader sees as one character, such
+// as a letter with combining accents, an emoji (including sequences joined
+// with zero-width joiners, and flags), or a CJK character. Graphemes are the
+// units to reveal one at a time in typewriter effects, so that no glyph is
+// ever shown half-formed.
+type Grapheme struct {
+	// Text is the text of the grapheme.
+	Text string
+
+	// Start and End are the byte range of the grapheme in the string.
+	Start, End int
+
+	// Width is the number of columns the grapheme occupies on a fixed-width
+	// display (0, 1, or 2).
+	Width int
+}
+
+// Graphemes splits s into grapheme clusters, following the Unicode text
+// segmentation rules (UAX #29).
+func Graphemes(s string) []Grapheme {
+	var gs []Grapheme
+	g := uniseg.NewGraphemes(s)
+	for g.Next() {
+		start, end := g.Positions()
+		gs = append(gs, Grapheme{
+			Text:  s[start:end],
+			Start: start,
+			End:   end,
+			Width: graphemeWidth(s[start:end]),
+		})
+	}
+	return gs
+}
+
+// graphemeWidth returns the number of columns a grapheme cluster occupies:
+// the widest rune in it, or 2 for emoji presentation sequences and flags.
+func graphemeWidth(cluster string) int {
+	w, ri := 0, 0
+	for _, r := range cluster {
+		switch {
+		case r == '\uFE0F': // variation selector 16: emoji presentation
+			return 2
+		case r >= 0x1F1E6 && r <= 0x1F1FF: // regional indicators
+			if ri++; ri == 2 {
+				return 2
+			}
+		}
+		w = max(w, RuneWidth(r))
+	}
+	return w
+}
+
+// Graphemes splits the string into grapheme clusters (see Graphemes).
+func (s *AttributedString) Graphemes() []Grapheme { return Graphemes(s.str) }
+
+// Reveal returns the first n grapheme clusters of the string, for typewriter
+// effects: reveal one more each tick until n reaches the total (the length
+// of Graphemes). The attributes are clipped to the revealed text. Attributes
+// that mark up nothing (e.g. [pause/]) are included once the text before
+// them has been revealed, so they can be acted on as they are reached.
+func (s *AttributedString) Reveal(n int) *AttributedString {
+	end := 0
+	for i, g := range s.Graphemes() {
+		if i >= n {
+			break
+		}
+		end = g.End
+	}
+	return s.slice(0, end, end+1)
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		text string
+		want []Grapheme
+	}{
+		{
+			text: "Cafe\u0301!", // e + combining acute accent
+			want: []Grapheme{
+				{Text: "C", Start: 0, End: 1, Width: 1},
+				{Text: "a", Start: 1, End: 2, Width: 1},
+				{Text: "f", Start: 2, End: 3, Width: 1},
+				{Text: "e\u0301", Start: 3, End: 6, Width: 1},
+				{Text: "!", Start: 6, End: 7, Width: 1},
+			},
+		},
+		{
+			text: "こんにちは",
+			want: []Grapheme{
+				{Text: "こ", Start: 0, End: 3, Width: 2},
+				{Text: "ん", Start: 3, End: 6, Width: 2},
+				{Text: "に", Start: 6, End: 9, Width: 2},
+				{Text: "ち", Start: 9, End: 12, Width: 2},
+				{Text: "は", Start: 12, End: 15, Width: 2},
+			},
+		},
+		{
+			// family (ZWJ sequence), flag, heart with emoji presentation
+			text: "\U0001F468\u200d\U0001F469\u200d\U0001F467\U0001F1EB\U0001F1F7\u2764\uFE0F",
+			want: []Grapheme{
+				{Text: "\U0001F468\u200d\U0001F469\u200d\U0001F467", Start: 0, End: 18, Width: 2},
+				{Text: "\U0001F1EB\U0001F1F7", Start: 18, End: 26, Width: 2},
+				{Text: "\u2764\uFE0F", Start: 26, End: 32, Width: 2},
+			},
+		},
+		{
+			text: "",
+			want: nil,
+		},
+	}
+	for _, test := range tests {
+		got := Graphemes(test.text)
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("Graphemes(%q) diff (-got +want):\n%s", test.text, diff)
+		}
+	}
+}
+
+func TestAttributedStringReveal(t *testing.T) {
+	as := renderMarkup(t, "[b]Ne\u0301e[/b][pause/] \U0001F468\u200d\U0001F469\u200d\U0001F467!")
+	if got, want := len(as.Graphemes()), 6; got != want {
+		t.Fatalf("len(as.Graphemes()) = %d, want %d", got, want)
+	}
+	tests := []struct {
+		n    int
+		want wrappedLine
+	}{
+		{n: 0, want: wrappedLine{Text: ""}},
+		{n: 1, want: wrappedLine{Text: "N", Attrs: []Attribute{{Start: 0, End: 1, Name: "b"}}}},
+		{n: 2, want: wrappedLine{Text: "Ne\u0301", Attrs: []Attribute{{Start: 0, End: 4, Name: "b"}}}},
+		{n: 3, want: wrappedLine{
+			Text: "Ne\u0301e",
+			Attrs: []Attribute{
+				{Start: 0, End: 5, Name: "b"},
+				{Start: 5, End: 5, Name: "pause"},
+			},
+		}},
+		{n: 5, want: wrappedLine{
+			Text: "Ne\u0301e \U0001F468\u200d\U0001F469\u200d\U0001F467",
+			Attrs: []Attribute{
+				{Start: 0, End: 5, Name: "b"},
+				{Start: 5, End: 5, Name: "pause"},
+			},
+		}},
+		{n: 100, want: wrappedLine{
+			Text: "Ne\u0301e \U0001F468\u200d\U0001F469\u200d\U0001F467!",
+			Attrs: []Attribute{
+				{Start: 0, End: 5, Name: "b"},
+				{Start: 5, End: 5, Name: "pause"},
+			},
+		}},
+	}
+	for _, test := range tests {
+		got := toWrappedLine(as.Reveal(test.n))
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("as.Reveal(%d) diff (-got +want):\n%s", test.n, diff)
+		}
+	}
+}
+
+func TestWrapKeepsGraphemes(t *testing.T) {
+	as := renderMarkup(t, "\U0001F468\u200d\U0001F469\u200d\U0001F467\U0001F468\u200d\U0001F469\u200d\U0001F467")
+	var got []string
+	for _, l := range as.Wrap(3) {
+		got = append(got, l.String())
+	}
+	want := []string{
+		"\U0001F468\u200d\U0001F469\u200d\U0001F467",
+		"\U0001F468\u200d\U0001F469\u200d\U0001F467",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Wrap(3) diff (-got +want):\n%s", diff)
+	}
+}
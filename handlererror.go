@@ -0,0 +1,90 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// HandlerErrorPolicy controls what happens when a handler method returns an
+// error (other than Stop). Errors from Options always stop the VM, since it
+// can't continue without a choice.
+type HandlerErrorPolicy int
+
+const (
+	// HandlerErrorFail stops the VM, which returns the error (wrapped).
+	HandlerErrorFail HandlerErrorPolicy = iota
+
+	// HandlerErrorLog logs the error to the VM's Logger at slog.LevelWarn,
+	// and continues.
+	HandlerErrorLog
+
+	// HandlerErrorCallback passes the error to the VM's ErrorHandler, which
+	// decides whether to continue. If ErrorHandler is nil, the VM stops.
+	HandlerErrorCallback
+)
+
+func (p HandlerErrorPolicy) String() string {
+	switch p {
+	case HandlerErrorFail:
+		return "Fail"
+	case HandlerErrorLog:
+		return "Log"
+	case HandlerErrorCallback:
+		return "Callback"
+	}
+	return fmt.Sprintf("(invalid HandlerErrorPolicy %d)", p)
+}
+
+// HandlerError is an error returned by a handler method.
+type HandlerError struct {
+	Method string // e.g. "Line"
+	Node   string // the current node
+	Err    error
+}
+
+func (e *HandlerError) Error() string {
+	return fmt.Sprintf("handler.%s: %v", e.Method, e.Err)
+}
+
+// Unwrap returns Err.
+func (e *HandlerError) Unwrap() error { return e.Err }
+
+// handlerError applies the HandlerErrors policy to an error returned by a
+// handler method. It returns nil if the VM should continue.
+func (vm *VirtualMachine) handlerError(method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	he := &HandlerError{Method: method, Err: err}
+	if vm.state.node != nil {
+		he.Node = vm.state.node.Name
+	}
+	if errors.Is(err, Stop) || method == "Options" {
+		return he
+	}
+	switch vm.HandlerErrors {
+	case HandlerErrorLog:
+		vm.log(slog.LevelWarn, logMsgHandlerError, slog.String("method", method), slog.Any("error", err))
+		return nil
+	case HandlerErrorCallback:
+		if vm.ErrorHandler != nil {
+			return vm.ErrorHandler(he)
+		}
+	}
+	return he
+}
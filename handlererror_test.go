@@ -0,0 +1,128 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var errMissingPortrait = errors.New("missing portrait")
+
+// flakyHandler fails to show portraits.
+type flakyHandler struct {
+	lineRecorder
+}
+
+func (h *flakyHandler) Command(command string) error {
+	h.commands = append(h.commands, command)
+	if command == "portrait ava" {
+		return errMissingPortrait
+	}
+	return nil
+}
+
+func (h *flakyHandler) Options([]Option) (int, error) {
+	return -1, errMissingPortrait
+}
+
+func TestHandlerErrorPolicy(t *testing.T) {
+	pb := NewProgramBuilder("Errors")
+	pb.Node("Start").
+		Command("portrait ava", 0).
+		Line("line:hi", 0).
+		Command("portrait bob", 0).
+		Line("line:bye", 0)
+	pb.Node("Options").
+		Option("line:a", "A", 0, false).
+		ShowOptions()
+	prog := pb.Program()
+
+	tests := []struct {
+		policy       HandlerErrorPolicy
+		errorHandler func(*HandlerError) error
+		wantErr      error
+		wantLines    []string
+		wantLog      bool
+	}{
+		{
+			policy:  HandlerErrorFail,
+			wantErr: errMissingPortrait,
+		},
+		{
+			policy:    HandlerErrorLog,
+			wantLines: []string{"line:hi", "line:bye"},
+			wantLog:   true,
+		},
+		{
+			policy: HandlerErrorCallback,
+			errorHandler: func(he *HandlerError) error {
+				if he.Method != "Command" || he.Node != "Start" {
+					return he
+				}
+				return nil
+			},
+			wantLines: []string{"line:hi", "line:bye"},
+		},
+		{
+			policy:       HandlerErrorCallback,
+			errorHandler: func(he *HandlerError) error { return Stop },
+		},
+		{
+			// No ErrorHandler, so it fails.
+			policy:  HandlerErrorCallback,
+			wantErr: errMissingPortrait,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.policy.String(), func(t *testing.T) {
+			var logs strings.Builder
+			h := &flakyHandler{}
+			vm := &VirtualMachine{
+				Program:       prog,
+				Handler:       h,
+				Vars:          NewMapVariableStorage(),
+				Logger:        slog.New(slog.NewTextHandler(&logs, nil)),
+				HandlerErrors: test.policy,
+				ErrorHandler:  test.errorHandler,
+			}
+			err := vm.Run("Start")
+			if !errors.Is(err, test.wantErr) || (err == nil) != (test.wantErr == nil) {
+				t.Errorf("vm.Run(Start) = %v, want %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				var he *HandlerError
+				if !errors.As(err, &he) || he.Method != "Command" {
+					t.Errorf("vm.Run(Start) = %v, want *HandlerError from Command", err)
+				}
+			}
+			if diff := cmp.Diff(h.ids, test.wantLines); diff != "" {
+				t.Errorf("lines diff (-got +want):\n%s", diff)
+			}
+			if got := strings.Contains(logs.String(), logMsgHandlerError); got != test.wantLog {
+				t.Errorf("logged %q = %t, want %t\nlogs:\n%s", logMsgHandlerError, got, test.wantLog, logs.String())
+			}
+
+			// Errors from Options always stop the VM.
+			if err := vm.Run("Options"); !errors.Is(err, errMissingPortrait) {
+				t.Errorf("vm.Run(Options) = %v, want %v", err, errMissingPortrait)
+			}
+		})
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrInvalidHeaders is wrapped by HeaderSchemaError.
+const ErrInvalidHeaders = virtualMachineError("node headers don't match schema")
+
+// HeaderType is the type of a header value in a HeaderSchema.
+type HeaderType int
+
+// Header types.
+const (
+	HeaderString   HeaderType = iota // any value
+	HeaderNumber                     // a number, e.g. "1.5"
+	HeaderBool                       // "true" or "false"
+	HeaderDuration                   // seconds, or a Go duration, e.g. "500ms"
+)
+
+func (t HeaderType) String() string {
+	switch t {
+	case HeaderString:
+		return "string"
+	case HeaderNumber:
+		return "number"
+	case HeaderBool:
+		return "bool"
+	case HeaderDuration:
+		return "duration"
+	}
+	return fmt.Sprintf("(invalid HeaderType %d)", int(t))
+}
+
+// MarshalText encodes the type as its name.
+func (t HeaderType) MarshalText() ([]byte, error) { return []byte(t.String()), nil }
+
+// UnmarshalText decodes a type name.
+func (t *HeaderType) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "string", "":
+		*t = HeaderString
+	case "number":
+		*t = HeaderNumber
+	case "bool":
+		*t = HeaderBool
+	case "duration":
+		*t = HeaderDuration
+	default:
+		return fmt.Errorf("unknown header type %q", text)
+	}
+	return nil
+}
+
+// check checks that a value has the type.
+func (t HeaderType) check(value string) error {
+	var err error
+	switch t {
+	case HeaderNumber:
+		_, err = strconv.ParseFloat(strings.TrimSpace(value), 64)
+	case HeaderBool:
+		_, err = strconv.ParseBool(strings.TrimSpace(value))
+	case HeaderDuration:
+		_, err = parseSeconds(value)
+	}
+	if err != nil {
+		return fmt.Errorf("value %q is not a %v", value, t)
+	}
+	return nil
+}
+
+// HeaderField describes one header key in a HeaderSchema.
+type HeaderField struct {
+	Key      string     `json:"key"`
+	Required bool       `json:"required,omitempty"`
+	Type     HeaderType `json:"type,omitempty"`
+
+	// Allowed, if not empty, lists the allowed values.
+	Allowed []string `json:"allowed,omitempty"`
+}
+
+// standardHeaders are headers written by the compiler or used by this
+// package, which are never unknown.
+var standardHeaders = []string{
+	"title", "tags", "position", "colorID", "color", "style",
+	BarkGroupHeader, WhenHeader, CooldownHeader, FrequencyHeader,
+	WeightHeader, UsesHeader, LocalsHeader, ParamsHeader,
+	VariantOfHeader, VariantHeader,
+}
+
+// HeaderSchema describes the node headers a team uses for metadata, so that
+// typos in keys or values are caught instead of failing silently. Schemas
+// can be checked explicitly (with Check), or registered (with
+// RegisterHeaderSchema) so that every program is checked as it is loaded.
+type HeaderSchema struct {
+	Fields []HeaderField `json:"fields"`
+
+	// Strict makes headers that are neither in Fields nor standard (written
+	// by the compiler, or used by this package) errors.
+	Strict bool `json:"strict,omitempty"`
+}
+
+// ReadHeaderSchemaJSON reads a schema from JSON, e.g.
+//
+//	{
+//		"strict": true,
+//		"fields": [
+//			{"key": "chapter", "required": true, "type": "number"},
+//			{"key": "mood", "allowed": ["happy", "sad", "tense"]}
+//		]
+//	}
+func ReadHeaderSchemaJSON(r io.Reader) (*HeaderSchema, error) {
+	s := new(HeaderSchema)
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, fmt.Errorf("decoding header schema: %w", err)
+	}
+	return s, nil
+}
+
+// Check checks the headers of every node in the program against the schema.
+// The diagnostics are sorted by node name.
+func (s *HeaderSchema) Check(prog *yarnpb.Program) []Diagnostic {
+	fields := make(map[string]*HeaderField, len(s.Fields))
+	for i := range s.Fields {
+		fields[s.Fields[i].Key] = &s.Fields[i]
+	}
+
+	var diags []Diagnostic
+	for _, name := range sortedNodeNames(prog) {
+		node := prog.Nodes[name]
+		errorf := func(format string, args ...any) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Node:     name,
+				PC:       -1,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+		seen := make(map[string]bool)
+		for _, h := range node.GetHeaders() {
+			seen[h.Key] = true
+			f := fields[h.Key]
+			if f == nil {
+				if s.Strict && !slices.Contains(standardHeaders, h.Key) {
+					errorf("unknown header %q", h.Key)
+				}
+				continue
+			}
+			if err := f.Type.check(h.Value); err != nil {
+				errorf("header %q: %v", h.Key, err)
+				continue
+			}
+			if len(f.Allowed) > 0 && !slices.Contains(f.Allowed, strings.TrimSpace(h.Value)) {
+				errorf("header %q: value %q is not one of %q", h.Key, h.Value, f.Allowed)
+			}
+		}
+		for _, f := range s.Fields {
+			if f.Required && !seen[f.Key] {
+				errorf("missing required header %q", f.Key)
+			}
+		}
+	}
+	return diags
+}
+
+// HeaderSchemaError is returned when loading a program whose headers don't
+// match a registered schema.
+type HeaderSchemaError struct {
+	Diagnostics []Diagnostic
+}
+
+func (e *HeaderSchemaError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrInvalidHeaders.Error())
+	for _, d := range e.Diagnostics {
+		b.WriteString("\n\t")
+		b.WriteString(d.String())
+	}
+	return b.String()
+}
+
+// Unwrap returns ErrInvalidHeaders.
+func (e *HeaderSchemaError) Unwrap() error { return ErrInvalidHeaders }
+
+var (
+	headerSchemasMu sync.RWMutex
+	headerSchemas   []*HeaderSchema
+)
+
+// RegisterHeaderSchema registers a schema that every program is checked
+// against when it is loaded (by LoadProgramFile, LoadFiles, and the other
+// Load functions). Loading fails with a *HeaderSchemaError listing the
+// offending nodes if any headers don't match. The returned function
+// unregisters the schema.
+func RegisterHeaderSchema(s *HeaderSchema) (unregister func()) {
+	headerSchemasMu.Lock()
+	defer headerSchemasMu.Unlock()
+	headerSchemas = append(headerSchemas, s)
+	return func() {
+		headerSchemasMu.Lock()
+		defer headerSchemasMu.Unlock()
+		headerSchemas = slices.DeleteFunc(headerSchemas, func(x *HeaderSchema) bool { return x == s })
+	}
+}
+
+// checkHeaderSchemas checks a newly loaded program against the registered
+// schemas.
+func checkHeaderSchemas(prog *yarnpb.Program) error {
+	headerSchemasMu.RLock()
+	defer headerSchemasMu.RUnlock()
+	var diags []Diagnostic
+	for _, s := range headerSchemas {
+		diags = append(diags, s.Check(prog)...)
+	}
+	if len(diags) == 0 {
+		return nil
+	}
+	return &HeaderSchemaError{Diagnostics: diags}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestHeaderSchema(t *testing.T) {
+	schema, err := ReadHeaderSchemaJSON(strings.NewReader(`{
+		"strict": true,
+		"fields": [
+			{"key": "chapter", "required": true, "type": "number"},
+			{"key": "mood", "allowed": ["happy", "sad"]},
+			{"key": "skippable", "type": "bool"}
+		]
+	}`))
+	if err != nil {
+		t.Fatalf("ReadHeaderSchemaJSON = %v", err)
+	}
+
+	pb := NewProgramBuilder("Headers")
+	pb.Node("Start").Header("title", "Start").Header("chapter", "1").Header("mood", "happy").Stop()
+	pb.Node("Typo").Header("chapter", "one").Header("mood", "hapy").Header("skipable", "true").Stop()
+	pb.Node("Missing").Header("skippable", "maybe").Header(CooldownHeader, "10").Stop()
+
+	got := schema.Check(pb.Program())
+	want := []Diagnostic{
+		{Node: "Missing", PC: -1, Message: `header "skippable": value "maybe" is not a bool`},
+		{Node: "Missing", PC: -1, Message: `missing required header "chapter"`},
+		{Node: "Typo", PC: -1, Message: `header "chapter": value "one" is not a number`},
+		{Node: "Typo", PC: -1, Message: `header "mood": value "hapy" is not one of ["happy" "sad"]`},
+		{Node: "Typo", PC: -1, Message: `unknown header "skipable"`},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("schema.Check diff (-got +want):\n%s", diff)
+	}
+
+	yarnc, err := proto.Marshal(pb.Program())
+	if err != nil {
+		t.Fatalf("proto.Marshal = %v", err)
+	}
+	unregister := RegisterHeaderSchema(schema)
+	_, err = LoadProgramBytes(yarnc)
+	var hse *HeaderSchemaError
+	if !errors.As(err, &hse) || !errors.Is(err, ErrInvalidHeaders) {
+		t.Fatalf("LoadProgramBytes with schema = %v, want HeaderSchemaError", err)
+	}
+	if diff := cmp.Diff(hse.Diagnostics, want); diff != "" {
+		t.Errorf("HeaderSchemaError.Diagnostics diff (-got +want):\n%s", diff)
+	}
+	unregister()
+	if _, err := LoadProgramBytes(yarnc); err != nil {
+		t.Errorf("LoadProgramBytes after unregister = %v", err)
+	}
+
+	if _, err := ReadHeaderSchemaJSON(strings.NewReader(`{"fields": [{"key": "x", "type": "colour"}]}`)); err == nil {
+		t.Errorf("ReadHeaderSchemaJSON(unknown type) = nil error, want error")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"sort"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// History is a serializable record of what the player has seen and chosen,
+// for saving across game sessions. It is only recorded while
+// VirtualMachine.RecordHistory is set. Use VirtualMachine.History to obtain
+// it, and VirtualMachine.SetHistory to restore it.
+type History struct {
+	// SeenLines contains the IDs of lines that have been delivered to the
+	// handler (including option lines, and lines delivered to SkipLine),
+	// sorted.
+	SeenLines []string `json:"seen_lines,omitempty"`
+
+	// ChosenOptions contains the line IDs of options that have been chosen,
+	// sorted.
+	ChosenOptions []string `json:"chosen_options,omitempty"`
+}
+
+// Progress returns the number of lines in prog that have been seen, and the
+// total number of lines in prog. This is useful for completion statistics.
+func (h History) Progress(prog *yarnpb.Program) (seen, total int) {
+	ids := programLineIDs(prog)
+	for _, id := range h.SeenLines {
+		if ids[id] {
+			seen++
+		}
+	}
+	return seen, len(ids)
+}
+
+// LineSeen reports whether the line (or option line) with the given ID has
+// ever been delivered to the handler. It is safe to call from any goroutine.
+func (vm *VirtualMachine) LineSeen(lineID string) bool {
+	return vm.history.lineSeen(lineID)
+}
+
+// OptionChosen reports whether the player has ever chosen an option with the
+// given line ID. It is safe to call from any goroutine.
+func (vm *VirtualMachine) OptionChosen(lineID string) bool {
+	return vm.history.optionChosen(lineID)
+}
+
+// History returns a copy of the seen-line and chosen-option history.
+func (vm *VirtualMachine) History() History {
+	return vm.history.contents()
+}
+
+// SetHistory replaces the seen-line and chosen-option history, e.g. when
+// loading a saved game.
+func (vm *VirtualMachine) SetHistory(h History) {
+	vm.history.replace(h)
+}
+
+// markSeen records that a line was delivered, if RecordHistory is set.
+func (vm *VirtualMachine) markSeen(lineID string) {
+	if vm.RecordHistory {
+		vm.history.see(lineID)
+	}
+}
+
+// markChosen records that an option was chosen, if RecordHistory is set.
+func (vm *VirtualMachine) markChosen(lineID string) {
+	if vm.RecordHistory {
+		vm.history.choose(lineID)
+	}
+}
+
+// history records what the player has done across runs.
+type history struct {
+	mu     sync.RWMutex
+	seen   map[string]struct{} // line IDs
+	chosen map[string]struct{} // option line IDs
+}
+
+func (h *history) lineSeen(lineID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.seen[lineID]
+	return ok
+}
+
+func (h *history) optionChosen(lineID string) bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	_, ok := h.chosen[lineID]
+	return ok
+}
+
+func (h *history) see(lineID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.seen == nil {
+		h.seen = make(map[string]struct{})
+	}
+	h.seen[lineID] = struct{}{}
+}
+
+func (h *history) choose(lineID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.chosen == nil {
+		h.chosen = make(map[string]struct{})
+	}
+	h.chosen[lineID] = struct{}{}
+}
+
+func (h *history) contents() History {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return History{
+		SeenLines:     sortedKeys(h.seen),
+		ChosenOptions: sortedKeys(h.chosen),
+	}
+}
+
+func (h *history) replace(c History) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seen = make(map[string]struct{}, len(c.SeenLines))
+	for _, id := range c.SeenLines {
+		h.seen[id] = struct{}{}
+	}
+	h.chosen = make(map[string]struct{}, len(c.ChosenOptions))
+	for _, id := range c.ChosenOptions {
+		h.chosen[id] = struct{}{}
+	}
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	if len(m) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
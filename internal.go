@@ -0,0 +1,122 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// InternalPrefix is the prefix of names reserved by the compiler for internal
+// variables and functions (for example, "$Yarn.Internal.Visiting.Start").
+// Functions with this prefix are only ever resolved by the VM, never by
+// FuncMap.
+const InternalPrefix = "Yarn.Internal."
+
+// internalFuncMap returns the functions that the compiler emits calls to on
+// its own account (rather than because an author wrote them), so they don't
+// need to be provided in FuncMap. Functions in FuncMap with the same names
+// take precedence, except for those with InternalPrefix.
+func (vm *VirtualMachine) internalFuncMap() FuncMap {
+	return FuncMap{
+		// Type conversion functions, used when inline expressions are
+		// converted explicitly.
+		"string": ConvertToString,
+		"number": ConvertToFloat32,
+		"bool":   ConvertToBool,
+
+		// Used for formatting numbers in lines independently of locale.
+		"format_invariant": func(x float32) string {
+			return strconv.FormatFloat(float64(x), 'f', -1, 32)
+		},
+
+		InternalPrefix + "visited":       vm.visited,
+		InternalPrefix + "visited_count": vm.visitedCount,
+	}
+}
+
+// lookupFunc finds the implementation of a function, checking FuncMap first,
+// then the internal functions. Names with InternalPrefix are reserved, so
+// they are never looked up in FuncMap.
+func (vm *VirtualMachine) lookupFunc(name string) (any, bool) {
+	if !strings.HasPrefix(name, InternalPrefix) {
+		if f, ok := vm.FuncMap[name]; ok {
+			return f, true
+		}
+	}
+	f, ok := vm.internalFuncs[name]
+	return f, ok
+}
+
+// visitingVar returns the name of the variable used to count visits to a
+// node.
+func visitingVar(nodeName string) string {
+	return "$" + InternalPrefix + "Visiting." + nodeName
+}
+
+func (vm *VirtualMachine) visited(nodeName string) bool {
+	_, ok := vm.Vars.GetValue(visitingVar(nodeName))
+	return ok
+}
+
+func (vm *VirtualMachine) visitedCount(nodeName string) float32 {
+	count, ok := vm.Vars.GetValue(visitingVar(nodeName))
+	if !ok {
+		return 0
+	}
+	n, err := ConvertToFloat32(count)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// execInternalCommand handles commands that older compilers emitted for
+// built-in statements (e.g. Yarn Spinner 1.x compiled <<jump Node>> and
+// <<stop>> as commands), and commands implemented by the VM itself
+// (<<assert>>, and <<jump Node(args)>> for nodes with parameters). It reports
+// whether the command was handled; if not, the command should be delivered to
+// the handler as usual. Commands named in HandlerCommands are never handled.
+func (vm *VirtualMachine) execInternalCommand(cmd string) (bool, error) {
+	fields := strings.Fields(cmd)
+	if len(fields) == 0 || slices.Contains(vm.HandlerCommands, fields[0]) {
+		return false, nil
+	}
+	switch fields[0] {
+	case "assert":
+		args := strings.TrimPrefix(strings.TrimSpace(cmd), "assert")
+		return true, vm.execAssert(args)
+	case "jump":
+		rest := strings.TrimPrefix(strings.TrimSpace(cmd), "jump")
+		if name, args, ok := parseNodeCall(rest); ok {
+			return true, vm.execNodeCall(name, args)
+		}
+		if len(fields) != 2 {
+			return false, nil
+		}
+		if err := vm.SetNode(fields[1]); err != nil {
+			return true, fmt.Errorf("SetNode: %w", err)
+		}
+		return true, nil
+	case "stop":
+		if len(fields) != 1 {
+			return false, nil
+		}
+		return true, Stop
+	}
+	return false, nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInternalCommands(t *testing.T) {
+	tests := []struct {
+		desc            string
+		start           func(*NodeBuilder)
+		handlerCommands []string
+		wantIDs         []string
+		wantCommands    []string
+	}{
+		{
+			desc: "jump",
+			start: func(b *NodeBuilder) {
+				b.Line("line:a", 0).Command("jump Other", 0).Line("line:b", 0)
+			},
+			wantIDs: []string{"line:a", "line:other"},
+		},
+		{
+			desc: "jump with arguments",
+			start: func(b *NodeBuilder) {
+				b.Command(`jump Greet("Ava", 1 + 2)`, 0)
+			},
+			wantIDs: []string{"line:greet"},
+		},
+		{
+			desc: "stop",
+			start: func(b *NodeBuilder) {
+				b.Line("line:a", 0).Command("stop", 0).Line("line:b", 0)
+			},
+			wantIDs: []string{"line:a"},
+		},
+		{
+			desc: "jump with extra words is delivered",
+			start: func(b *NodeBuilder) {
+				b.Command("jump over the fence", 0).Line("line:a", 0)
+			},
+			wantIDs:      []string{"line:a"},
+			wantCommands: []string{"jump over the fence"},
+		},
+		{
+			desc: "stop with extra words is delivered",
+			start: func(b *NodeBuilder) {
+				b.Command("stop music", 0).Line("line:a", 0)
+			},
+			wantIDs:      []string{"line:a"},
+			wantCommands: []string{"stop music"},
+		},
+		{
+			desc: "handler commands are delivered",
+			start: func(b *NodeBuilder) {
+				b.Command("jump Other", 0).Command("assert false", 0).Command("stop", 0).Line("line:a", 0)
+			},
+			handlerCommands: []string{"jump", "assert", "stop"},
+			wantIDs:         []string{"line:a"},
+			wantCommands:    []string{"jump Other", "assert false", "stop"},
+		},
+		{
+			desc: "other commands are delivered",
+			start: func(b *NodeBuilder) {
+				b.Command("wave", 0)
+			},
+			wantCommands: []string{"wave"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			pb := NewProgramBuilder("Internal")
+			test.start(pb.Node("Start"))
+			pb.Node("Other").Line("line:other", 0)
+			pb.Node("Greet").Header(ParamsHeader, "$name, $count").Line("line:greet", 0)
+
+			rec := &lineRecorder{}
+			vm := &VirtualMachine{
+				Program:         pb.Program(),
+				Handler:         rec,
+				Vars:            NewMapVariableStorage(),
+				HandlerCommands: test.handlerCommands,
+			}
+			if err := vm.Run("Start"); err != nil {
+				t.Fatalf("vm.Run(Start) = %v", err)
+			}
+			if diff := cmp.Diff(rec.ids, test.wantIDs); diff != "" {
+				t.Errorf("line IDs diff (-got +want):\n%s", diff)
+			}
+			if diff := cmp.Diff(rec.commands, test.wantCommands); diff != "" {
+				t.Errorf("commands diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestInternalFuncs(t *testing.T) {
+	tests := []struct {
+		desc    string
+		expr    func(*NodeBuilder)
+		funcMap FuncMap
+		want    any
+	}{
+		{
+			desc: "string",
+			expr: func(b *NodeBuilder) { b.PushFloat(2.5).Call("string", 1) },
+			want: "2.5",
+		},
+		{
+			desc: "number",
+			expr: func(b *NodeBuilder) { b.PushString("3").Call("number", 1) },
+			want: float32(3),
+		},
+		{
+			desc: "bool",
+			expr: func(b *NodeBuilder) { b.PushFloat(1).Call("bool", 1) },
+			want: true,
+		},
+		{
+			desc: "format_invariant",
+			expr: func(b *NodeBuilder) { b.PushFloat(1234.5).Call("format_invariant", 1) },
+			want: "1234.5",
+		},
+		{
+			desc: "visited",
+			expr: func(b *NodeBuilder) { b.PushString("Other").Call(InternalPrefix+"visited", 1) },
+			want: true,
+		},
+		{
+			desc: "not visited",
+			expr: func(b *NodeBuilder) { b.PushString("Start").Call(InternalPrefix+"visited", 1) },
+			want: false,
+		},
+		{
+			desc: "visited_count",
+			expr: func(b *NodeBuilder) { b.PushString("Other").Call(InternalPrefix+"visited_count", 1) },
+			want: float32(2),
+		},
+		{
+			desc: "FuncMap overrides string",
+			expr: func(b *NodeBuilder) { b.PushFloat(2.5).Call("string", 1) },
+			funcMap: FuncMap{
+				"string": func(x float32) string { return "custom" },
+			},
+			want: "custom",
+		},
+		{
+			desc: "FuncMap can't override reserved names",
+			expr: func(b *NodeBuilder) { b.PushString("Other").Call(InternalPrefix+"visited_count", 1) },
+			funcMap: FuncMap{
+				InternalPrefix + "visited_count": func(string) float32 { return 99 },
+			},
+			want: float32(2),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			pb := NewProgramBuilder("Internal")
+			b := pb.Node("Start")
+			test.expr(b)
+			b.StoreVariable("$out").Pop()
+
+			vars := NewMapVariableStorage()
+			vars.SetValue(visitingVar("Other"), float32(2))
+			vm := &VirtualMachine{
+				Program: pb.Program(),
+				Handler: &lineRecorder{},
+				Vars:    vars,
+				FuncMap: test.funcMap,
+			}
+			if err := vm.Run("Start"); err != nil {
+				t.Fatalf("vm.Run(Start) = %v", err)
+			}
+			got, _ := vars.GetValue("$out")
+			if diff := cmp.Diff(got, test.want); diff != "" {
+				t.Errorf("$out diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ErrNotEnoughItems is returned (wrapped) by InventoryProvider.TakeItem when
+// there are fewer items than requested.
+const ErrNotEnoughItems = virtualMachineError("not enough items")
+
+// InventoryProvider is the single integration point between dialogue and a
+// game's inventory system. See InventoryLibrary and InventoryHandler.
+type InventoryProvider interface {
+	// ItemCount returns how many of the item the player has.
+	ItemCount(item string) int
+
+	// GiveItem adds n of the item.
+	GiveItem(item string, n int) error
+
+	// TakeItem removes n of the item. It should return an error wrapping
+	// ErrNotEnoughItems (and remove nothing) if there are fewer than n.
+	TakeItem(item string, n int) error
+}
+
+// InventoryLibrary returns functions for gating dialogue on items:
+//
+//	has_item(item) -> bool          whether the player has at least one
+//	has_item(item, n) -> bool       whether the player has at least n
+//	item_count(item) -> number
+func InventoryLibrary(inv InventoryProvider) FuncMap {
+	return FuncMap{
+		"has_item": func(item string, n ...int) (bool, error) {
+			switch len(n) {
+			case 0:
+				return inv.ItemCount(item) > 0, nil
+			case 1:
+				return inv.ItemCount(item) >= n[0], nil
+			}
+			return false, fmt.Errorf("%w: has_item takes 1 or 2 arguments, got %d", ErrFunctionArgMismatch, 1+len(n))
+		},
+		"item_count": func(item string) float32 {
+			return float32(inv.ItemCount(item))
+		},
+	}
+}
+
+var (
+	_ DialogueHandler      = &InventoryHandler{}
+	_ CommandResultHandler = &InventoryHandler{}
+	_ SkipHandler          = &InventoryHandler{}
+)
+
+// InventoryHandler is a DialogueHandler that carries out inventory commands:
+//
+//	<<give_item sword>>
+//	<<give_item arrow 10>>
+//	<<take_item key>>
+//	<<take_item coin 5>>
+//
+// Item names are single words. All other commands and events are passed to
+// the embedded DialogueHandler.
+type InventoryHandler struct {
+	DialogueHandler
+	Inventory InventoryProvider
+}
+
+// Command handles give_item and take_item, and passes other commands to the
+// embedded handler.
+func (h *InventoryHandler) Command(command string) error {
+	_, err := h.CommandResult(command)
+	return err
+}
+
+// CommandResult is like Command, but passes other commands to the embedded
+// handler's CommandResult method, if it has one.
+func (h *InventoryHandler) CommandResult(command string) (any, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 || (fields[0] != "give_item" && fields[0] != "take_item") {
+		return forwardCommandResult(h.DialogueHandler, command)
+	}
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("%s: want item and optional count, got %q", fields[0], command)
+	}
+	n := 1
+	if len(fields) == 3 {
+		c, err := strconv.Atoi(fields[2])
+		if err != nil || c < 0 {
+			return nil, fmt.Errorf("%s: invalid count %q", fields[0], fields[2])
+		}
+		n = c
+	}
+	if fields[0] == "give_item" {
+		return nil, h.Inventory.GiveItem(fields[1], n)
+	}
+	return nil, h.Inventory.TakeItem(fields[1], n)
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *InventoryHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// MapInventory is a simple in-memory InventoryProvider, useful for prototyping
+// and tests. The zero value is an empty inventory.
+type MapInventory struct {
+	mu sync.RWMutex
+	m  map[string]int
+}
+
+// ItemCount returns how many of the item are in the inventory.
+func (m *MapInventory) ItemCount(item string) int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.m[item]
+}
+
+// GiveItem adds n of the item.
+func (m *MapInventory) GiveItem(item string, n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m == nil {
+		m.m = make(map[string]int)
+	}
+	m.m[item] += n
+	return nil
+}
+
+// TakeItem removes n of the item.
+func (m *MapInventory) TakeItem(item string, n int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.m[item] < n {
+		return fmt.Errorf("%w: have %d %s, want %d", ErrNotEnoughItems, m.m[item], item, n)
+	}
+	m.m[item] -= n
+	if m.m[item] == 0 {
+		delete(m.m, item)
+	}
+	return nil
+}
+
+// Items returns the names of the items in the inventory, sorted.
+func (m *MapInventory) Items() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	items := make([]string, 0, len(m.m))
+	for item := range m.m {
+		items = append(items, item)
+	}
+	sort.Strings(items)
+	return items
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestInventory(t *testing.T) {
+	inv := &MapInventory{}
+	inv.GiveItem("coin", 3)
+
+	// <<give_item key>> <<take_item coin 2>> <<wave>>
+	// <<if has_item("key") and item_count("coin") == 1>> line:yes <<endif>>
+	prog := condProgram(func(b *NodeBuilder) {
+		b.Command("give_item key", 0).
+			Command("take_item coin 2", 0).
+			Command("wave", 0).
+			PushString("key").
+			Call("has_item", 1).
+			PushString("coin").
+			Call("item_count", 1).
+			PushFloat(1).
+			Call("EqualTo", 2).
+			Call("And", 2)
+	})
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: &InventoryHandler{
+			DialogueHandler: rec,
+			Inventory:       inv,
+		},
+		Vars:    NewMapVariableStorage(),
+		FuncMap: InventoryLibrary(inv),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"yes"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.commands, []string{"wave"}); diff != "" {
+		t.Errorf("commands passed through diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(inv.Items(), []string{"coin", "key"}); diff != "" {
+		t.Errorf("inv.Items() diff (-got +want):\n%s", diff)
+	}
+
+	h := &InventoryHandler{DialogueHandler: FakeDialogueHandler{}, Inventory: inv}
+	if err := h.Command("take_item coin 5"); !errors.Is(err, ErrNotEnoughItems) {
+		t.Errorf("Command(take_item coin 5) = %v, want %v", err, ErrNotEnoughItems)
+	}
+	if err := h.Command("give_item"); err == nil {
+		t.Errorf("Command(give_item) = nil, want error")
+	}
+	if got := inv.ItemCount("coin"); got != 1 {
+		t.Errorf("ItemCount(coin) = %d, want 1", got)
+	}
+}
@@ -0,0 +1,416 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Errors returned by RecoverJournal.
+const (
+	// ErrJournalComplete is returned by RecoverJournal when the last
+	// dialogue in the journal ran to completion, so there is nothing to
+	// recover.
+	ErrJournalComplete = virtualMachineError("journal records a completed dialogue")
+
+	// ErrJournalDiverged is returned (wrapped) by RecoverJournal when the
+	// program doesn't produce the events in the journal, e.g. because the
+	// program has changed.
+	ErrJournalDiverged = virtualMachineError("replay diverged from journal")
+)
+
+// JournalKind is the kind of a JournalRecord.
+type JournalKind byte
+
+// Journal record kinds.
+const (
+	JournalNodeStart        JournalKind = 'S' // Value is the node name
+	JournalLine             JournalKind = 'L' // Value is the line ID
+	JournalChoice           JournalKind = 'O' // Value is the chosen option ID
+	JournalCommand          JournalKind = 'C' // Value is the JSON-encoded result, if any
+	JournalDialogueComplete JournalKind = 'E' // Value is empty
+)
+
+// JournalRecord is one event in a journal.
+type JournalRecord struct {
+	Kind  JournalKind
+	Value string
+}
+
+func (r JournalRecord) String() string {
+	if r.Value == "" {
+		return string(r.Kind)
+	}
+	return string(r.Kind) + " " + r.Value
+}
+
+var (
+	_ DialogueHandler      = &JournalHandler{}
+	_ CommandResultHandler = &JournalHandler{}
+	_ SkipHandler          = &JournalHandler{}
+)
+
+// JournalHandler is a DialogueHandler that appends a tiny record to W for
+// each event that advances the dialogue (node starts, lines, choices,
+// commands, and the end of the dialogue), after the embedded handler has
+// handled it. If the game crashes, RecoverJournal can replay the journal
+// against the program to reconstruct the VM's state, so no progress is lost
+// in long branching sequences. Several dialogues can be appended to the same
+// journal; only the last one is recovered.
+//
+// Each record is one line of text written with a single Write, so a crash
+// leaves at most one incomplete record at the end, which ReadJournal
+// ignores. It is safe for concurrent use. Errors writing to W don't
+// interrupt the dialogue; the first is returned by Err.
+type JournalHandler struct {
+	DialogueHandler
+	W io.Writer
+
+	// Sync, if true, calls W's Sync method (if it has one, like *os.File)
+	// after each record, so that records survive the operating system
+	// crashing too.
+	Sync bool
+
+	mu  sync.Mutex
+	err error
+}
+
+// Err returns the first error writing to W, if any.
+func (h *JournalHandler) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// write appends a record to the journal.
+func (h *JournalHandler) write(kind JournalKind, value string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err != nil {
+		return
+	}
+	rec := JournalRecord{Kind: kind, Value: value}.String() + "\n"
+	if _, err := io.WriteString(h.W, rec); err != nil {
+		h.err = fmt.Errorf("writing journal: %w", err)
+		return
+	}
+	if s, ok := h.W.(interface{ Sync() error }); ok && h.Sync {
+		if err := s.Sync(); err != nil {
+			h.err = fmt.Errorf("syncing journal: %w", err)
+		}
+	}
+}
+
+// NodeStart records the event.
+func (h *JournalHandler) NodeStart(nodeName string) error {
+	if err := h.DialogueHandler.NodeStart(nodeName); err != nil {
+		return err
+	}
+	h.write(JournalNodeStart, nodeName)
+	return nil
+}
+
+// Line records the event.
+func (h *JournalHandler) Line(line Line) error {
+	if err := h.DialogueHandler.Line(line); err != nil {
+		return err
+	}
+	h.write(JournalLine, line.ID)
+	return nil
+}
+
+// SkipLine records the event, as for Line. The embedded handler's SkipLine
+// method is called, if it has one.
+func (h *JournalHandler) SkipLine(line Line) error {
+	if err := forwardSkipLine(h.DialogueHandler, line); err != nil {
+		return err
+	}
+	h.write(JournalLine, line.ID)
+	return nil
+}
+
+// Options records the choice.
+func (h *JournalHandler) Options(options []Option) (int, error) {
+	choice, err := h.DialogueHandler.Options(options)
+	if err != nil {
+		return choice, err
+	}
+	h.write(JournalChoice, strconv.Itoa(choice))
+	return choice, nil
+}
+
+// Command records the event.
+func (h *JournalHandler) Command(command string) error {
+	if err := h.DialogueHandler.Command(command); err != nil {
+		return err
+	}
+	h.write(JournalCommand, "")
+	return nil
+}
+
+// CommandResult records the event and the result (see CommandResultHandler),
+// so that RecoverJournal can replay it. The embedded handler's CommandResult
+// method is called, if it has one.
+func (h *JournalHandler) CommandResult(command string) (any, error) {
+	result, err := forwardCommandResult(h.DialogueHandler, command)
+	if err != nil {
+		return nil, err
+	}
+	value := ""
+	if result != nil {
+		b, err := json.Marshal(result)
+		if err != nil {
+			return nil, fmt.Errorf("journalling command %q result: %w", command, err)
+		}
+		value = string(b)
+	}
+	h.write(JournalCommand, value)
+	return result, nil
+}
+
+// DialogueComplete records the event.
+func (h *JournalHandler) DialogueComplete() error {
+	if err := h.DialogueHandler.DialogueComplete(); err != nil {
+		return err
+	}
+	h.write(JournalDialogueComplete, "")
+	return nil
+}
+
+// ReadJournal reads a journal written by JournalHandler. An incomplete last
+// record (without a newline, as left by a crash while writing) is ignored.
+func ReadJournal(r io.Reader) ([]JournalRecord, error) {
+	br := bufio.NewReader(r)
+	var recs []JournalRecord
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err == io.EOF {
+			return recs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading journal: %w", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			continue
+		}
+		rec := JournalRecord{Kind: JournalKind(line[0])}
+		if len(line) > 1 {
+			if line[1] != ' ' {
+				return nil, fmt.Errorf("journal line %d: malformed record %q", n, line)
+			}
+			rec.Value = line[2:]
+		}
+		switch rec.Kind {
+		case JournalNodeStart, JournalLine, JournalChoice, JournalCommand, JournalDialogueComplete:
+		default:
+			return nil, fmt.Errorf("journal line %d: unknown record kind %q", n, line[0])
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// RecoverJournal reconstructs the VM's state (execution state, variables,
+// and history) at the end of the last dialogue in a journal, by replaying
+// it against vm.Program. Call Resume to continue the dialogue from just
+// after the last recorded event.
+//
+// No events are delivered to vm.Handler during the replay (it is replaced
+// for the duration), and vm.Limits, vm.Analytics, and vm.Autosave are
+// disabled, so that the replay doesn't record node entries, analytics
+// events, or saves a second time. Command results are replayed from the
+// journal. Since the journal doesn't record variables, the replay assumes
+// the dialogue is otherwise deterministic: vm.Vars should hold the variables
+// as they were when the dialogue started, and functions (including random
+// ones) should behave as they did originally. The game is responsible for
+// restoring its own state, such as the effects of commands.
+//
+// If the journal's last dialogue completed, RecoverJournal returns
+// ErrJournalComplete. If the program doesn't produce the recorded events, it
+// returns an error wrapping ErrJournalDiverged.
+func RecoverJournal(vm *VirtualMachine, records []JournalRecord) error {
+	// Find the start of the last dialogue.
+	start := 0
+	for i, r := range records {
+		if r.Kind == JournalDialogueComplete {
+			start = i + 1
+		}
+	}
+	records = records[start:]
+	if len(records) == 0 {
+		if start > 0 {
+			return ErrJournalComplete
+		}
+		return errors.New("journal is empty")
+	}
+	if records[0].Kind != JournalNodeStart {
+		return fmt.Errorf("%w: journal starts with %q, not a node start", ErrJournalDiverged, records[0])
+	}
+
+	rh := &journalReplayHandler{vm: vm, records: records}
+	handler, limits, analytics, autosave := vm.Handler, vm.Limits, vm.Analytics, vm.Autosave
+	vm.Handler, vm.Limits, vm.Analytics, vm.Autosave = rh, nil, nil, nil
+	err := vm.Run(records[0].Value)
+	vm.Handler, vm.Limits, vm.Analytics, vm.Autosave = handler, limits, analytics, autosave
+	if rh.err != nil {
+		return rh.err
+	}
+	if err != nil && !errors.Is(err, Stop) {
+		return err
+	}
+	if rh.snap == nil {
+		return fmt.Errorf("%w: dialogue ended after %d of %d records", ErrJournalDiverged, rh.next, len(records))
+	}
+	if err := vm.Restore(rh.snap); err != nil {
+		return err
+	}
+	if rh.chose != "" {
+		vm.markChosen(rh.chose)
+	}
+	return nil
+}
+
+// journalReplayHandler replays a journal, and snapshots the state after the
+// last record.
+type journalReplayHandler struct {
+	FakeDialogueHandler
+	vm      *VirtualMachine
+	records []JournalRecord
+	next    int
+
+	snap  *Snapshot // state after the last record
+	chose string    // line ID of the option chosen by the last record, if any
+	err   error     // divergence
+}
+
+// match consumes the next record, which should be of the given kind. It
+// returns Stop once the state after the last record has been captured.
+func (h *journalReplayHandler) match(kind JournalKind, value string) error {
+	if h.err != nil {
+		return h.err
+	}
+	if h.next >= len(h.records) {
+		// Unreachable: the replay stops at the last record.
+		return Stop
+	}
+	want := h.records[h.next]
+	got := JournalRecord{Kind: kind, Value: value}
+	if got != want {
+		h.err = fmt.Errorf("%w: record %d is %q, but replay produced %q", ErrJournalDiverged, h.next+1, want, got)
+		return h.err
+	}
+	h.next++
+	if h.next < len(h.records) {
+		return nil
+	}
+	if h.snap == nil {
+		h.snap = h.vm.Snapshot()
+	}
+	return Stop
+}
+
+func (h *journalReplayHandler) NodeStart(nodeName string) error {
+	return h.match(JournalNodeStart, nodeName)
+}
+
+func (h *journalReplayHandler) Line(line Line) error {
+	return h.match(JournalLine, line.ID)
+}
+
+func (h *journalReplayHandler) SkipLine(line Line) error {
+	return h.match(JournalLine, line.ID)
+}
+
+// CommandResult returns the recorded result of the command.
+func (h *journalReplayHandler) CommandResult(command string) (any, error) {
+	if h.next >= len(h.records) || h.records[h.next].Kind != JournalCommand {
+		return nil, h.match(JournalCommand, "")
+	}
+	value := h.records[h.next].Value
+	var result any
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &result); err != nil {
+			h.err = fmt.Errorf("%w: record %d has invalid command result %q", ErrJournalDiverged, h.next+1, value)
+			return nil, h.err
+		}
+		// Store the result now, so that the snapshot taken after the last
+		// record includes it.
+		if err := h.vm.storeCommandResult(command, h.vm.state.resultVariable, result); err != nil {
+			return nil, err
+		}
+	}
+	return result, h.match(JournalCommand, value)
+}
+
+func (h *journalReplayHandler) Options(options []Option) (int, error) {
+	if h.next >= len(h.records) || h.records[h.next].Kind != JournalChoice {
+		return -1, h.match(JournalChoice, "")
+	}
+	value := h.records[h.next].Value
+	choice, err := strconv.Atoi(value)
+	if err != nil {
+		h.err = fmt.Errorf("%w: record %d has invalid choice %q", ErrJournalDiverged, h.next+1, value)
+		return -1, h.err
+	}
+	var chosen *Option
+	for i := range options {
+		if options[i].ID == choice {
+			chosen = &options[i]
+		}
+	}
+	if chosen == nil {
+		h.err = fmt.Errorf("%w: record %d chooses option %d, which isn't offered", ErrJournalDiverged, h.next+1, choice)
+		return -1, h.err
+	}
+	if h.next == len(h.records)-1 {
+		// A snapshot taken now would deliver the options again. Instead,
+		// capture the state as it will be once the VM has made the choice.
+		s := h.vm.Snapshot()
+		s.Stack = append(s.Stack, chosen.DestinationNode)
+		s.Options = nil
+		s.PC++
+		h.snap, h.chose = s, chosen.Line.ID
+	}
+	return choice, h.match(JournalChoice, value)
+}
+
+func (h *journalReplayHandler) DialogueComplete() error {
+	if h.err != nil || h.snap != nil {
+		return nil
+	}
+	return h.match(JournalDialogueComplete, "")
+}
+
+// WriteJournal writes records in the format written by JournalHandler, e.g.
+// to compact a journal to its last dialogue.
+func WriteJournal(w io.Writer, records []JournalRecord) error {
+	var buf bytes.Buffer
+	for _, r := range records {
+		buf.WriteString(r.String())
+		buf.WriteByte('\n')
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	return nil
+}
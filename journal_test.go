@@ -0,0 +1,246 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func journalProgram() *yarnpb.Program {
+	pb := NewProgramBuilder("Journal")
+	pb.Node("Start").
+		Line("line:1", 0).
+		Line("line:2", 0).
+		Option("line:a", "Next", 0, false).
+		Option("line:b", "Other", 0, false).
+		ShowOptions().
+		Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Next").
+		PushVariable("$count").
+		PushFloat(1).
+		Call("Number.Add", 2).
+		StoreVariable("$count").
+		Pop().
+		Line("line:3", 0).
+		Command("wave", 0).
+		Line("line:4", 0).
+		Stop()
+	pb.Node("Other").Line("line:other", 0).Stop()
+	return pb.Program()
+}
+
+var errCrash = errors.New("crash")
+
+// crashingHandler records events, chooses the last option, and fails at
+// the crashAt'th event.
+type crashingHandler struct {
+	FakeDialogueHandler
+	events  []string
+	crashAt int
+}
+
+func (h *crashingHandler) event(e string) error {
+	if len(h.events)+1 == h.crashAt {
+		return errCrash
+	}
+	h.events = append(h.events, e)
+	return nil
+}
+
+func (h *crashingHandler) Line(line Line) error { return h.event(line.ID) }
+
+func (h *crashingHandler) Command(command string) error { return h.event("<<" + command + ">>") }
+
+func (h *crashingHandler) Options(opts []Option) (int, error) {
+	return 0, h.event("options")
+}
+
+func TestJournalRecovery(t *testing.T) {
+	prog := journalProgram()
+	full := []string{"line:1", "line:2", "options", "line:3", "<<wave>>", "line:4"}
+
+	for crashAt := 1; crashAt <= len(full); crashAt++ {
+		var journal bytes.Buffer
+		before := &crashingHandler{crashAt: crashAt}
+		vm := &VirtualMachine{
+			Program: prog,
+			Handler: &JournalHandler{DialogueHandler: before, W: &journal, Sync: true},
+			Vars:    NewMapVariableStorage(),
+		}
+		vm.Vars.SetValue("$count", float32(0))
+		if err := vm.Run("Start"); !errors.Is(err, errCrash) {
+			t.Fatalf("crashAt=%d: vm.Run(Start) = %v, want %v", crashAt, err, errCrash)
+		}
+
+		// Simulate a crash partway through writing a record.
+		journal.WriteString("L line:partial")
+		recs, err := ReadJournal(&journal)
+		if err != nil {
+			t.Fatalf("crashAt=%d: ReadJournal = %v", crashAt, err)
+		}
+
+		after := &crashingHandler{}
+		vm2 := &VirtualMachine{
+			Program: prog,
+			Handler: after,
+			Vars:    NewMapVariableStorage(),
+		}
+		vm2.Vars.SetValue("$count", float32(0))
+		if err := RecoverJournal(vm2, recs); err != nil {
+			t.Fatalf("crashAt=%d: RecoverJournal = %v", crashAt, err)
+		}
+		if err := vm2.Resume(); err != nil {
+			t.Fatalf("crashAt=%d: vm2.Resume() = %v", crashAt, err)
+		}
+		got := append(before.events, after.events...)
+		if diff := cmp.Diff(got, full); diff != "" {
+			t.Errorf("crashAt=%d: events diff (-got +want):\n%s", crashAt, diff)
+		}
+		if v, _ := vm2.Vars.GetValue("$count"); v != float32(1) {
+			t.Errorf("crashAt=%d: $count = %v, want 1", crashAt, v)
+		}
+	}
+}
+
+func TestJournalComplete(t *testing.T) {
+	prog := journalProgram()
+	var journal bytes.Buffer
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: &JournalHandler{DialogueHandler: &crashingHandler{}, W: &journal},
+		Vars:    NewMapVariableStorage(),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := "S Start\nL line:1\nL line:2\nO 0\nS Next\nL line:3\nC\nL line:4\nE\n"
+	if got := journal.String(); got != want {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+	recs, err := ReadJournal(strings.NewReader(want))
+	if err != nil {
+		t.Fatalf("ReadJournal = %v", err)
+	}
+	var rewritten bytes.Buffer
+	if err := WriteJournal(&rewritten, recs); err != nil {
+		t.Fatalf("WriteJournal = %v", err)
+	}
+	if got := rewritten.String(); got != want {
+		t.Errorf("WriteJournal wrote %q, want %q", got, want)
+	}
+	if err := RecoverJournal(vm, recs); !errors.Is(err, ErrJournalComplete) {
+		t.Errorf("RecoverJournal(complete) = %v, want %v", err, ErrJournalComplete)
+	}
+
+	// A second dialogue in the same journal is recovered on its own.
+	recs = append(recs, JournalRecord{JournalNodeStart, "Other"})
+	if err := RecoverJournal(vm, recs); err != nil {
+		t.Errorf("RecoverJournal(second dialogue) = %v", err)
+	}
+}
+
+func TestJournalDiverged(t *testing.T) {
+	prog := journalProgram()
+	for _, journal := range []string{
+		"S Start\nL line:2\n",
+		"S Start\nL line:1\nL line:2\nO 5\n",
+		"L line:1\n",
+	} {
+		recs, err := ReadJournal(strings.NewReader(journal))
+		if err != nil {
+			t.Fatalf("ReadJournal(%q) = %v", journal, err)
+		}
+		vm := &VirtualMachine{Program: prog, Handler: &crashingHandler{}, Vars: NewMapVariableStorage()}
+		if err := RecoverJournal(vm, recs); !errors.Is(err, ErrJournalDiverged) {
+			t.Errorf("RecoverJournal(%q) = %v, want %v", journal, err, ErrJournalDiverged)
+		}
+	}
+	if _, err := ReadJournal(strings.NewReader("X nope\n")); err == nil {
+		t.Errorf("ReadJournal(unknown kind) error = nil, want error")
+	}
+}
+
+func TestJournalReplayCommandResults(t *testing.T) {
+	pb := NewProgramBuilder("Journal")
+	pb.Node("Start").
+		Header(CooldownHeader, "1h").
+		Command("roll_dice 6 -> $roll", 0).
+		Line("line:1", 0).
+		Command("roll_dice 20", 0).
+		Line("line:2", 0).
+		Stop()
+	prog := pb.Program()
+
+	var journal bytes.Buffer
+	limits := &NodeLimits{Program: prog}
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: &JournalHandler{DialogueHandler: &diceHandler{}, W: &journal},
+		Vars:    NewMapVariableStorage(),
+		Limits:  limits,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := "S Start\nC 6\nL line:1\nC 20\nL line:2\nE\n"
+	if got := journal.String(); got != want {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+	recs, err := ReadJournal(&journal)
+	if err != nil {
+		t.Fatalf("ReadJournal = %v", err)
+	}
+
+	// Recover up to and including the second command, with the same limits
+	// (so Start is cooling down).
+	h := &diceHandler{}
+	analytics := 0
+	vm2 := &VirtualMachine{
+		Program:   prog,
+		Handler:   h,
+		Vars:      NewMapVariableStorage(),
+		Limits:    limits,
+		Analytics: func(AnalyticsEvent) { analytics++ },
+	}
+	if err := RecoverJournal(vm2, recs[:4]); err != nil {
+		t.Fatalf("RecoverJournal = %v", err)
+	}
+	if len(h.commands) != 0 || analytics != 0 {
+		t.Errorf("replay delivered commands %q and %d analytics events, want none", h.commands, analytics)
+	}
+	if got := len(limits.State().Entries["Start"]); got != 1 {
+		t.Errorf("len(limits entries for Start) = %d, want 1", got)
+	}
+	for name, want := range map[string]float32{"$roll": 6, DefaultCommandResultVariable: 20} {
+		if got, _ := vm2.Vars.GetValue(name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	if err := vm2.Resume(); err != nil {
+		t.Fatalf("vm2.Resume() = %v", err)
+	}
+	if diff := cmp.Diff(h.ids, []string{"line:2"}); diff != "" {
+		t.Errorf("resumed lines diff (-got +want):\n%s", diff)
+	}
+	if analytics == 0 {
+		t.Errorf("Analytics not restored after replay")
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l10nsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultCrowdinURL is the base URL of the Crowdin API.
+const DefaultCrowdinURL = "https://api.crowdin.com/api/v2"
+
+// crowdinPageSize is the number of files listed per request (the API
+// maximum).
+const crowdinPageSize = 500
+
+// Crowdin is a Backend for the Crowdin API (v2). Each resource is a file in
+// the project, named after the resource with an ".xliff" extension. Locales
+// are Crowdin language IDs, e.g. "fr" or "pt-BR".
+type Crowdin struct {
+	// BaseURL is the API URL. If empty, DefaultCrowdinURL is used. For
+	// Crowdin Enterprise it is https://{organization}.api.crowdin.com/api/v2.
+	BaseURL string
+
+	// Token is a personal access token.
+	Token string
+
+	// ProjectID is the numeric ID of the project.
+	ProjectID int
+
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+}
+
+func (c *Crowdin) client() apiClient {
+	return apiClient{hc: c.HTTPClient, auth: "Bearer " + c.Token}
+}
+
+func (c *Crowdin) url(path string, args ...any) string {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultCrowdinURL
+	}
+	return strings.TrimSuffix(base, "/") + fmt.Sprintf(path, args...)
+}
+
+// crowdinFileName returns the name of the file for a resource.
+func crowdinFileName(resource string) string { return resource + ".xliff" }
+
+// PushSource uploads the XLIFF to storage, then updates the resource's file,
+// or adds it if it doesn't exist.
+func (c *Crowdin) PushSource(ctx context.Context, resource string, xliff []byte) error {
+	name := crowdinFileName(resource)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url("/storages"), bytes.NewReader(xliff))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Crowdin-API-FileName", url.PathEscape(name))
+	var storage struct {
+		Data struct {
+			ID int `json:"id"`
+		} `json:"data"`
+	}
+	if _, err := c.client().do(req, &storage); err != nil {
+		return err
+	}
+
+	fileID, err := c.fileID(ctx, name)
+	if err != nil {
+		return err
+	}
+	if fileID == 0 {
+		in := map[string]any{"storageId": storage.Data.ID, "name": name}
+		return c.client().doJSON(ctx, http.MethodPost, c.url("/projects/%d/files", c.ProjectID), in, nil)
+	}
+	in := map[string]any{"storageId": storage.Data.ID}
+	return c.client().doJSON(ctx, http.MethodPut, c.url("/projects/%d/files/%d", c.ProjectID, fileID), in, nil)
+}
+
+// PullTranslation builds the translation of the resource's file, and
+// downloads it.
+func (c *Crowdin) PullTranslation(ctx context.Context, resource, locale string) ([]byte, error) {
+	name := crowdinFileName(resource)
+	fileID, err := c.fileID(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if fileID == 0 {
+		return nil, fmt.Errorf("crowdin project %d has no file %q", c.ProjectID, name)
+	}
+	var build struct {
+		Data struct {
+			URL string `json:"url"`
+		} `json:"data"`
+	}
+	in := map[string]any{"targetLanguageId": locale}
+	if err := c.client().doJSON(ctx, http.MethodPost, c.url("/projects/%d/translations/builds/files/%d", c.ProjectID, fileID), in, &build); err != nil {
+		return nil, err
+	}
+
+	// The download URL is pre-signed, so it is fetched without the token.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, build.Data.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return apiClient{hc: c.HTTPClient}.do(req, nil)
+}
+
+// fileID finds the ID of a file in the project by name. It returns 0 if
+// there is no such file.
+func (c *Crowdin) fileID(ctx context.Context, name string) (int, error) {
+	for offset := 0; ; offset += crowdinPageSize {
+		var page struct {
+			Data []struct {
+				Data struct {
+					ID   int    `json:"id"`
+					Name string `json:"name"`
+				} `json:"data"`
+			} `json:"data"`
+		}
+		u := c.url("/projects/%d/files?limit=%d&offset=%d", c.ProjectID, crowdinPageSize, offset)
+		if err := c.client().doJSON(ctx, http.MethodGet, u, nil, &page); err != nil {
+			return 0, err
+		}
+		for _, f := range page.Data {
+			if f.Data.Name == name {
+				return f.Data.ID, nil
+			}
+		}
+		if len(page.Data) < crowdinPageSize {
+			return 0, nil
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l10nsync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCrowdin implements enough of the Crowdin API for the backend.
+type fakeCrowdin struct {
+	mu       sync.Mutex
+	srv      *httptest.Server
+	storages map[int][]byte
+	files    map[int]string // file ID to name
+	contents map[int][]byte // file ID to content
+	nextID   int
+}
+
+func newFakeCrowdin(t *testing.T) *fakeCrowdin {
+	f := &fakeCrowdin{
+		storages: make(map[int][]byte),
+		files:    make(map[int]string),
+		contents: make(map[int][]byte),
+		nextID:   100,
+	}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeCrowdin) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if path[0] == "download" {
+		// Pre-signed, so no token is needed.
+		if r.Header.Get("Authorization") != "" {
+			http.Error(w, "unexpected Authorization", http.StatusBadRequest)
+			return
+		}
+		id, _ := strconv.Atoi(path[1])
+		w.Write(f.contents[id])
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch p := strings.Join(path, "/"); {
+	case p == "api/v2/storages":
+		body, _ := io.ReadAll(r.Body)
+		f.nextID++
+		f.storages[f.nextID] = body
+		fmt.Fprintf(w, `{"data":{"id":%d,"fileName":%q}}`, f.nextID, r.Header.Get("Crowdin-API-FileName"))
+
+	case p == "api/v2/projects/7/files" && r.Method == http.MethodGet:
+		var page struct {
+			Data []map[string]any `json:"data"`
+		}
+		for id, name := range f.files {
+			page.Data = append(page.Data, map[string]any{"data": map[string]any{"id": id, "name": name}})
+		}
+		json.NewEncoder(w).Encode(page)
+
+	case p == "api/v2/projects/7/files" && r.Method == http.MethodPost:
+		var in struct {
+			StorageID int    `json:"storageId"`
+			Name      string `json:"name"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		f.nextID++
+		f.files[f.nextID] = in.Name
+		f.contents[f.nextID] = f.storages[in.StorageID]
+		fmt.Fprintf(w, `{"data":{"id":%d}}`, f.nextID)
+
+	case strings.HasPrefix(p, "api/v2/projects/7/files/") && r.Method == http.MethodPut:
+		id, _ := strconv.Atoi(path[len(path)-1])
+		var in struct {
+			StorageID int `json:"storageId"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		f.contents[id] = f.storages[in.StorageID]
+		fmt.Fprintf(w, `{"data":{"id":%d}}`, id)
+
+	case strings.HasPrefix(p, "api/v2/projects/7/translations/builds/files/"):
+		var in struct {
+			TargetLanguageID string `json:"targetLanguageId"`
+		}
+		json.NewDecoder(r.Body).Decode(&in)
+		fmt.Fprintf(w, `{"data":{"url":"%s/download/%s/%s"}}`, f.srv.URL, path[len(path)-1], in.TargetLanguageID)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestCrowdin(t *testing.T) {
+	ctx := context.Background()
+	f := newFakeCrowdin(t)
+	c := &Crowdin{BaseURL: f.srv.URL + "/api/v2/", Token: "secret", ProjectID: 7}
+
+	if _, err := c.PullTranslation(ctx, "chapter1", "fr"); err == nil {
+		t.Error("c.PullTranslation before PushSource = nil error, want error")
+	}
+	if err := c.PushSource(ctx, "chapter1", []byte("v1")); err != nil {
+		t.Fatalf("c.PushSource = %v", err)
+	}
+	if err := c.PushSource(ctx, "chapter1", []byte("v2")); err != nil {
+		t.Fatalf("c.PushSource = %v", err)
+	}
+	if got, want := len(f.files), 1; got != want {
+		t.Errorf("len(f.files) = %d, want %d", got, want)
+	}
+	got, err := c.PullTranslation(ctx, "chapter1", "fr")
+	if err != nil {
+		t.Fatalf("c.PullTranslation = %v", err)
+	}
+	if string(got) != "v2" {
+		t.Errorf("c.PullTranslation = %q, want %q", got, "v2")
+	}
+
+	bad := &Crowdin{BaseURL: f.srv.URL + "/api/v2", Token: "wrong", ProjectID: 7}
+	if err := bad.PushSource(ctx, "chapter1", []byte("v3")); err == nil {
+		t.Error("bad.PushSource = nil error, want error")
+	}
+}
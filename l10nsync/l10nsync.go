@@ -0,0 +1,160 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package l10nsync keeps string tables in sync with a translation management
+// service, such as Crowdin or Weblate, as part of a content pipeline. The
+// base string table is pushed as XLIFF (with context for translators, see
+// yarn.NewXLIFF), and translations are pulled back as XLIFF and turned into
+// a string table for each locale:
+//
+//	s := &l10nsync.Syncer{Backend: &l10nsync.Weblate{...}, MinState: yarn.TranslationReviewed}
+//	if err := s.Push(ctx, "chapter1", base); err != nil { ... }
+//	tables, err := s.Pull(ctx, "chapter1", base, "fr", "de")
+//
+// A resource is one string table, e.g. one compiled program. Each backend
+// maps resources onto its own idea of a file or component.
+package l10nsync // import "github.com/DrJosh9000/yarn/l10nsync"
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+// Backend is a translation management service.
+type Backend interface {
+	// PushSource uploads the source strings of a resource as XLIFF,
+	// replacing any previous version.
+	PushSource(ctx context.Context, resource string, xliff []byte) error
+
+	// PullTranslation downloads the translation of a resource into a locale,
+	// as XLIFF.
+	PullTranslation(ctx context.Context, resource, locale string) ([]byte, error)
+}
+
+// Syncer pushes and pulls string tables using a Backend.
+type Syncer struct {
+	Backend Backend
+
+	// Version is the XLIFF version pushed. The default is XLIFF 1.2, which
+	// is the most widely supported.
+	Version yarn.XLIFFVersion
+
+	// MinState is the least translation state pulled. Lines whose
+	// translation hasn't reached it keep the source text. The default
+	// accepts any translation.
+	MinState yarn.TranslationState
+}
+
+// Push uploads the source string table of a resource.
+func (s *Syncer) Push(ctx context.Context, resource string, source *yarn.StringTable) error {
+	var buf bytes.Buffer
+	if err := yarn.NewXLIFF(s.Version, source, nil).Write(&buf); err != nil {
+		return err
+	}
+	if err := s.Backend.PushSource(ctx, resource, buf.Bytes()); err != nil {
+		return fmt.Errorf("pushing %q: %w", resource, err)
+	}
+	return nil
+}
+
+// Pull downloads the translations of a resource, and returns a string table
+// for each locale, keyed by locale. Rows are copied from source, with the
+// translated text (see yarn.XLIFF.StringTable).
+func (s *Syncer) Pull(ctx context.Context, resource string, source *yarn.StringTable, locales ...string) (map[string]*yarn.StringTable, error) {
+	tables := make(map[string]*yarn.StringTable, len(locales))
+	for _, locale := range locales {
+		b, err := s.Backend.PullTranslation(ctx, resource, locale)
+		if err != nil {
+			return nil, fmt.Errorf("pulling %q for %q: %w", resource, locale, err)
+		}
+		x, err := yarn.ReadXLIFF(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("pulling %q for %q: %w", resource, locale, err)
+		}
+		if x.TargetLanguage == "" {
+			x.TargetLanguage = locale
+		}
+		st, err := x.StringTable(source, s.MinState)
+		if err != nil {
+			return nil, fmt.Errorf("pulling %q for %q: %w", resource, locale, err)
+		}
+		tables[locale] = st
+	}
+	return tables, nil
+}
+
+// apiClient makes authenticated requests to a REST API.
+type apiClient struct {
+	hc   *http.Client
+	auth string // Authorization header
+}
+
+// do makes a request and checks the status. If out is not nil, the response
+// body is decoded into it as JSON; otherwise it is returned.
+func (c apiClient) do(req *http.Request, out any) ([]byte, error) {
+	if c.auth != "" {
+		req.Header.Set("Authorization", c.auth)
+	}
+	hc := c.hc
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: %s: %s", req.Method, req.URL, resp.Status, bytes.TrimSpace(body))
+	}
+	if out == nil {
+		return body, nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return nil, fmt.Errorf("%s %s: decoding response: %w", req.Method, req.URL, err)
+	}
+	return body, nil
+}
+
+// doJSON makes a request with a JSON body (if in is not nil), and decodes a
+// JSON response into out (if not nil).
+func (c apiClient) doJSON(ctx context.Context, method, url string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	_, err = c.do(req, out)
+	return err
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l10nsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+// memBackend is a Backend that "translates" by uppercasing the source.
+type memBackend struct {
+	sources map[string][]byte
+}
+
+func (b *memBackend) PushSource(_ context.Context, resource string, xliff []byte) error {
+	if b.sources == nil {
+		b.sources = make(map[string][]byte)
+	}
+	b.sources[resource] = xliff
+	return nil
+}
+
+func (b *memBackend) PullTranslation(_ context.Context, resource, locale string) ([]byte, error) {
+	src, ok := b.sources[resource]
+	if !ok {
+		return nil, fmt.Errorf("no resource %q", resource)
+	}
+	x, err := yarn.ReadXLIFF(bytes.NewReader(src))
+	if err != nil {
+		return nil, err
+	}
+	x.TargetLanguage = locale
+	for i := range x.Units {
+		if x.Units[i].ID == "line:b" {
+			continue // not translated yet
+		}
+		x.Units[i].Target = string(bytes.ToUpper([]byte(x.Units[i].Source)))
+		x.Units[i].State = yarn.TranslationReviewed
+	}
+	var buf bytes.Buffer
+	if err := x.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func baseTable() *yarn.StringTable {
+	return &yarn.StringTable{
+		Language: language.English,
+		Table: map[string]*yarn.StringTableRow{
+			"line:a": {ID: "line:a", Text: "Hello", File: "a.yarn", Node: "Start", LineNumber: 1},
+			"line:b": {ID: "line:b", Text: "Goodbye", File: "a.yarn", Node: "Start", LineNumber: 2},
+		},
+	}
+}
+
+func tableTexts(st *yarn.StringTable) map[string]string {
+	texts := make(map[string]string)
+	for id, row := range st.Table {
+		texts[id] = row.Text
+	}
+	return texts
+}
+
+func TestSyncer(t *testing.T) {
+	ctx := context.Background()
+	s := &Syncer{Backend: &memBackend{}, MinState: yarn.TranslationReviewed}
+	if _, err := s.Pull(ctx, "chapter1", baseTable(), "fr"); err == nil {
+		t.Error("s.Pull before Push = nil error, want error")
+	}
+	if err := s.Push(ctx, "chapter1", baseTable()); err != nil {
+		t.Fatalf("s.Push = %v", err)
+	}
+	tables, err := s.Pull(ctx, "chapter1", baseTable(), "fr", "de")
+	if err != nil {
+		t.Fatalf("s.Pull = %v", err)
+	}
+	for _, locale := range []string{"fr", "de"} {
+		st := tables[locale]
+		if st == nil {
+			t.Fatalf("tables[%q] = nil", locale)
+		}
+		if got, want := st.Language, language.Make(locale); got != want {
+			t.Errorf("tables[%q].Language = %v, want %v", locale, got, want)
+		}
+		want := map[string]string{"line:a": "HELLO", "line:b": "Goodbye"}
+		if diff := cmp.Diff(tableTexts(st), want); diff != "" {
+			t.Errorf("tables[%q] texts diff (-got +want):\n%s", locale, diff)
+		}
+	}
+}
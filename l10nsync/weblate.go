@@ -0,0 +1,90 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l10nsync
+
+import (
+	"bytes"
+	"context"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Weblate is a Backend for the Weblate REST API. Each resource is a
+// component of the project (with the resource as its slug), which should use
+// the XLIFF file format. Locales are Weblate language codes, e.g. "fr" or
+// "pt_BR".
+type Weblate struct {
+	// BaseURL is the API URL, e.g. "https://hosted.weblate.org/api/".
+	BaseURL string
+
+	// Token is an API token.
+	Token string
+
+	// Project is the project slug.
+	Project string
+
+	// SourceLanguage is the language code of the components' source
+	// language, e.g. "en".
+	SourceLanguage string
+
+	// HTTPClient is used to make requests. If nil, http.DefaultClient is
+	// used.
+	HTTPClient *http.Client
+}
+
+func (w *Weblate) client() apiClient {
+	return apiClient{hc: w.HTTPClient, auth: "Token " + w.Token}
+}
+
+// fileURL returns the URL of the file of a translation.
+func (w *Weblate) fileURL(resource, locale string) string {
+	return strings.TrimSuffix(w.BaseURL, "/") + "/translations/" +
+		url.PathEscape(w.Project) + "/" + url.PathEscape(resource) + "/" + url.PathEscape(locale) + "/file/"
+}
+
+// PushSource uploads the XLIFF to the source language translation of the
+// resource's component, replacing its strings.
+func (w *Weblate) PushSource(ctx context.Context, resource string, xliff []byte) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	mw.WriteField("method", "replace")
+	fw, err := mw.CreateFormFile("file", resource+".xliff")
+	if err != nil {
+		return err
+	}
+	fw.Write(xliff)
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.fileURL(resource, w.SourceLanguage), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	_, err = w.client().do(req, nil)
+	return err
+}
+
+// PullTranslation downloads the file of the resource's translation into the
+// locale.
+func (w *Weblate) PullTranslation(ctx context.Context, resource, locale string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.fileURL(resource, locale), nil)
+	if err != nil {
+		return nil, err
+	}
+	return w.client().do(req, nil)
+}
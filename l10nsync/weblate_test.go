@@ -0,0 +1,88 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package l10nsync
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeWeblate stores translation files by URL path.
+type fakeWeblate struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func (f *fakeWeblate) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Token secret" {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodGet:
+		body, ok := f.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body)
+	case http.MethodPost:
+		if got := r.FormValue("method"); got != "replace" {
+			http.Error(w, "method = "+got, http.StatusBadRequest)
+			return
+		}
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(file)
+		f.files[r.URL.Path] = body
+		w.Write([]byte(`{"accepted":1}`))
+	}
+}
+
+func TestWeblate(t *testing.T) {
+	ctx := context.Background()
+	f := &fakeWeblate{files: map[string][]byte{
+		"/api/translations/game/chapter1/fr/file/": []byte("bonjour"),
+	}}
+	srv := httptest.NewServer(f)
+	defer srv.Close()
+	wb := &Weblate{BaseURL: srv.URL + "/api/", Token: "secret", Project: "game", SourceLanguage: "en"}
+
+	if err := wb.PushSource(ctx, "chapter1", []byte("hello")); err != nil {
+		t.Fatalf("wb.PushSource = %v", err)
+	}
+	if got, want := string(f.files["/api/translations/game/chapter1/en/file/"]), "hello"; got != want {
+		t.Errorf("source file = %q, want %q", got, want)
+	}
+	got, err := wb.PullTranslation(ctx, "chapter1", "fr")
+	if err != nil {
+		t.Fatalf("wb.PullTranslation = %v", err)
+	}
+	if string(got) != "bonjour" {
+		t.Errorf("wb.PullTranslation = %q, want %q", got, "bonjour")
+	}
+	if _, err := wb.PullTranslation(ctx, "chapter1", "de"); err == nil {
+		t.Error("wb.PullTranslation(de) = nil error, want error")
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "sort"
+
+// Library is a collection of named functions that can be made available to
+// programs. FuncMap is the usual implementation, but anything that can
+// enumerate and look up functions will do.
+type Library interface {
+	// Function returns the implementation of the named function, and whether
+	// or not it was found.
+	Function(name string) (any, bool)
+
+	// Names returns the names of all functions in the library, sorted.
+	Names() []string
+}
+
+var _ Library = FuncMap(nil)
+
+// Function returns the function called name, if it is in the map.
+func (m FuncMap) Function(name string) (any, bool) {
+	f, ok := m[name]
+	return f, ok
+}
+
+// Names returns the names of all functions in the map, sorted.
+func (m FuncMap) Names() []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StandardLibrary returns a new FuncMap containing the standard Yarn Spinner
+// operators and built-in functions (dice, round, floor, etc). It does not
+// include visited and visited_count, which are provided by each
+// VirtualMachine because they depend on its variable storage.
+//
+// The VM always layers FuncMap over the standard library, so there is no
+// need to include StandardLibrary when combining libraries for a VM. It is
+// useful for enumerating the built-ins, or as the base layer of a FuncMap used
+// somewhere else.
+func StandardLibrary() FuncMap { return defaultFuncMap() }
+
+// CombineLibraries layers libraries into a single new FuncMap. The layering
+// rules are:
+//
+//   - Libraries are applied in order, so a function in a later library
+//     shadows a function with the same name in any earlier library.
+//   - Shadowing is all-or-nothing: there is no way to call the shadowed
+//     function through the combined map.
+//   - Nil libraries are skipped.
+//
+// For example, to layer per-scene functions over game-specific functions:
+//
+//	vm.FuncMap = yarn.CombineLibraries(gameFuncs, sceneFuncs)
+//
+// None of the inputs are modified. Use Shadowed to find out which names were
+// overridden.
+func CombineLibraries(libs ...Library) FuncMap {
+	m := make(FuncMap)
+	for _, lib := range libs {
+		if lib == nil {
+			continue
+		}
+		for _, name := range lib.Names() {
+			f, ok := lib.Function(name)
+			if !ok {
+				continue
+			}
+			m[name] = f
+		}
+	}
+	return m
+}
+
+// Shadowed reports the names that appear in more than one of the libraries,
+// i.e. the functions that CombineLibraries would override. Each name maps to
+// the indexes (within libs) of the libraries defining it, in order; the last
+// index is the one that wins.
+func Shadowed(libs ...Library) map[string][]int {
+	defs := make(map[string][]int)
+	for i, lib := range libs {
+		if lib == nil {
+			continue
+		}
+		for _, name := range lib.Names() {
+			defs[name] = append(defs[name], i)
+		}
+	}
+	for name, idx := range defs {
+		if len(idx) < 2 {
+			delete(defs, name)
+		}
+	}
+	return defs
+}
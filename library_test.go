@@ -0,0 +1,49 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCombineLibraries(t *testing.T) {
+	game := FuncMap{
+		"greeting": func() string { return "hello" },
+		"gold":     func() float32 { return 10 },
+	}
+	scene := FuncMap{
+		"greeting": func() string { return "g'day" },
+		"weather":  func() string { return "sunny" },
+	}
+
+	got := CombineLibraries(game, nil, scene)
+
+	if diff := cmp.Diff(got.Names(), []string{"gold", "greeting", "weather"}); diff != "" {
+		t.Errorf("CombineLibraries(game, nil, scene).Names() diff:\n%s", diff)
+	}
+	f, ok := got.Function("greeting")
+	if !ok {
+		t.Fatalf("CombineLibraries(game, nil, scene).Function(greeting) not found")
+	}
+	if got, want := f.(func() string)(), "g'day"; got != want {
+		t.Errorf("greeting() = %q, want %q", got, want)
+	}
+
+	if diff := cmp.Diff(Shadowed(game, nil, scene), map[string][]int{"greeting": {0, 2}}); diff != "" {
+		t.Errorf("Shadowed(game, nil, scene) diff:\n%s", diff)
+	}
+}
@@ -0,0 +1,206 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+)
+
+// TimedSpan is a span of a voiced line: a word, or a phoneme (or mouth
+// shape). Times are in seconds from the start of the line's audio. The JSON
+// form is the same as the mouth cues written by lip sync tools such as
+// Rhubarb.
+type TimedSpan struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Value string  `json:"value"`
+}
+
+// LineTiming is the timing of a line's audio, for lip sync and caption
+// highlighting.
+type LineTiming struct {
+	// Duration is the length of the audio, in seconds.
+	Duration float64 `json:"duration,omitempty"`
+
+	// Words are the words spoken, in order.
+	Words []TimedSpan `json:"words,omitempty"`
+
+	// Phonemes are the phonemes or mouth shapes, in order.
+	Phonemes []TimedSpan `json:"phonemes,omitempty"`
+}
+
+// spanAt returns the index of the span containing t, or -1.
+func spanAt(spans []TimedSpan, t float64) int {
+	for i, s := range spans {
+		if t >= s.Start && t < s.End {
+			return i
+		}
+	}
+	return -1
+}
+
+// WordAt returns the index of the word being spoken t seconds into the line,
+// or -1 if there isn't one (e.g. in a pause).
+func (lt *LineTiming) WordAt(t float64) int { return spanAt(lt.Words, t) }
+
+// PhonemeAt returns the phoneme or mouth shape t seconds into the line, or ""
+// if there isn't one.
+func (lt *LineTiming) PhonemeAt(t float64) string {
+	if i := spanAt(lt.Phonemes, t); i >= 0 {
+		return lt.Phonemes[i].Value
+	}
+	return ""
+}
+
+// ReadLineTimingsJSON reads a timing sidecar: a JSON object mapping line IDs
+// to timings, e.g.
+//
+//	{
+//	  "line:hello": {
+//	    "duration": 1.2,
+//	    "words": [{"start": 0.1, "end": 0.6, "value": "Hello"}, ...],
+//	    "phonemes": [{"start": 0.1, "end": 0.2, "value": "C"}, ...]
+//	  }
+//	}
+func ReadLineTimingsJSON(r io.Reader) (map[string]*LineTiming, error) {
+	var timings map[string]*LineTiming
+	if err := json.NewDecoder(r).Decode(&timings); err != nil {
+		return nil, fmt.Errorf("decoding line timings: %w", err)
+	}
+	return timings, nil
+}
+
+// LineTimings holds the timing sidecars for each locale, since voiced lines
+// differ between languages. It is safe for concurrent use.
+type LineTimings struct {
+	mu       sync.RWMutex
+	byLocale map[string]map[string]*LineTiming
+}
+
+// Add adds timings for a locale, replacing any existing timings for the same
+// lines.
+func (t *LineTimings) Add(locale string, timings map[string]*LineTiming) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.byLocale == nil {
+		t.byLocale = make(map[string]map[string]*LineTiming)
+	}
+	m := t.byLocale[locale]
+	if m == nil {
+		m = make(map[string]*LineTiming, len(timings))
+		t.byLocale[locale] = m
+	}
+	for id, lt := range timings {
+		m[id] = lt
+	}
+}
+
+// LoadFile reads a timing sidecar file and adds it for a locale.
+func (t *LineTimings) LoadFile(path, locale string) error {
+	return t.LoadFileFS(os.DirFS("."), path, locale)
+}
+
+// LoadFileFS is like LoadFile, but reads from fsys.
+func (t *LineTimings) LoadFileFS(fsys fs.FS, path, locale string) error {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening line timings: %w", err)
+	}
+	defer f.Close()
+	timings, err := ReadLineTimingsJSON(f)
+	if err != nil {
+		return err
+	}
+	t.Add(locale, timings)
+	return nil
+}
+
+// Lookup returns the timing of a line in a locale, or nil if there is none.
+// If the locale has a region or script (e.g. "fr-CA") without timing for the
+// line, the base language ("fr") is tried.
+func (t *LineTimings) Lookup(locale, lineID string) *LineTiming {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for {
+		if lt := t.byLocale[locale][lineID]; lt != nil {
+			return lt
+		}
+		i := strings.LastIndexAny(locale, "-_")
+		if i < 0 {
+			return nil
+		}
+		locale = locale[:i]
+	}
+}
+
+// TimedLineHandler is an optional interface for dialogue handlers wrapped by
+// TimingHandler. Lines are delivered to TimedLine instead of Line.
+type TimedLineHandler interface {
+	// TimedLine is called with each line and its timing, which is nil if
+	// the line has none (e.g. it isn't voiced).
+	TimedLine(line Line, timing *LineTiming) error
+}
+
+var (
+	_ DialogueHandler      = &TimingHandler{}
+	_ CommandResultHandler = &TimingHandler{}
+	_ SkipHandler          = &TimingHandler{}
+)
+
+// TimingHandler is a DialogueHandler that looks up the timing of each line,
+// so that lip sync and caption highlighting can be driven from data. If the
+// embedded DialogueHandler implements TimedLineHandler, each line is
+// delivered to TimedLine with its timing; otherwise it is delivered to Line
+// as usual. All other events are passed to the embedded handler.
+type TimingHandler struct {
+	DialogueHandler
+	Timings *LineTimings
+
+	// Locale is the locale of the timings to use. If Locales is set, its
+	// active locale is used instead.
+	Locale  string
+	Locales *LocaleSet
+}
+
+// Line looks up the line's timing and delivers it to the embedded handler.
+func (h *TimingHandler) Line(line Line) error {
+	tlh, ok := h.DialogueHandler.(TimedLineHandler)
+	if !ok {
+		return h.DialogueHandler.Line(line)
+	}
+	locale := h.Locale
+	if h.Locales != nil {
+		locale = h.Locales.Locale()
+	}
+	return tlh.TimedLine(line, h.Timings.Lookup(locale, line.ID))
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *TimingHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// CommandResult passes the command to the embedded handler's CommandResult
+// method, if it has one.
+func (h *TimingHandler) CommandResult(command string) (any, error) {
+	return forwardCommandResult(h.DialogueHandler, command)
+}
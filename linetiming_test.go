@@ -0,0 +1,175 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTimingsJSON = `{
+	"line:1": {
+		"duration": 1.5,
+		"words": [
+			{"start": 0.1, "end": 0.5, "value": "Hello"},
+			{"start": 0.7, "end": 1.4, "value": "there"}
+		],
+		"phonemes": [
+			{"start": 0.1, "end": 0.3, "value": "C"},
+			{"start": 0.3, "end": 0.5, "value": "E"}
+		]
+	}
+}`
+
+func TestReadLineTimingsJSON(t *testing.T) {
+	got, err := ReadLineTimingsJSON(strings.NewReader(testTimingsJSON))
+	if err != nil {
+		t.Fatalf("ReadLineTimingsJSON error = %v", err)
+	}
+	want := map[string]*LineTiming{
+		"line:1": {
+			Duration: 1.5,
+			Words: []TimedSpan{
+				{Start: 0.1, End: 0.5, Value: "Hello"},
+				{Start: 0.7, End: 1.4, Value: "there"},
+			},
+			Phonemes: []TimedSpan{
+				{Start: 0.1, End: 0.3, Value: "C"},
+				{Start: 0.3, End: 0.5, Value: "E"},
+			},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("timings diff (-got +want):\n%s", diff)
+	}
+
+	lt := got["line:1"]
+	for _, test := range []struct {
+		t       float64
+		word    int
+		phoneme string
+	}{
+		{0, -1, ""},
+		{0.2, 0, "C"},
+		{0.3, 0, "E"},
+		{0.6, -1, ""},
+		{1, 1, ""},
+	} {
+		if got := lt.WordAt(test.t); got != test.word {
+			t.Errorf("WordAt(%v) = %d, want %d", test.t, got, test.word)
+		}
+		if got := lt.PhonemeAt(test.t); got != test.phoneme {
+			t.Errorf("PhonemeAt(%v) = %q, want %q", test.t, got, test.phoneme)
+		}
+	}
+
+	if _, err := ReadLineTimingsJSON(strings.NewReader("[")); err == nil {
+		t.Error("ReadLineTimingsJSON(invalid) error = nil, want error")
+	}
+}
+
+func TestLineTimingsLookup(t *testing.T) {
+	fsys := fstest.MapFS{
+		"fr.json": {Data: []byte(testTimingsJSON)},
+	}
+	var lts LineTimings
+	if err := lts.LoadFileFS(fsys, "fr.json", "fr"); err != nil {
+		t.Fatalf("LoadFileFS error = %v", err)
+	}
+	frLine1 := lts.Lookup("fr", "line:1")
+	if frLine1 == nil || frLine1.Duration != 1.5 {
+		t.Fatalf(`Lookup("fr", "line:1") = %v, want loaded timing`, frLine1)
+	}
+	if err := lts.LoadFileFS(fsys, "missing.json", "de"); err == nil {
+		t.Error("LoadFileFS(missing.json) error = nil, want error")
+	}
+	caLine2 := &LineTiming{Duration: 2}
+	lts.Add("fr-CA", map[string]*LineTiming{"line:2": caLine2})
+
+	for _, test := range []struct {
+		locale, id string
+		want       *LineTiming
+	}{
+		{"fr", "line:1", frLine1},
+		{"fr-CA", "line:1", frLine1},
+		{"fr-CA", "line:2", caLine2},
+		{"fr", "line:2", nil},
+		{"en", "line:1", nil},
+	} {
+		if got := lts.Lookup(test.locale, test.id); got != test.want {
+			t.Errorf("Lookup(%q, %q) = %v, want %v", test.locale, test.id, got, test.want)
+		}
+	}
+}
+
+// timedRecorder records lines delivered with timing.
+type timedRecorder struct {
+	FakeDialogueHandler
+	ids     []string
+	timings []*LineTiming
+}
+
+func (r *timedRecorder) TimedLine(line Line, timing *LineTiming) error {
+	r.ids = append(r.ids, line.ID)
+	r.timings = append(r.timings, timing)
+	return nil
+}
+
+func TestTimingHandler(t *testing.T) {
+	lt1 := &LineTiming{Duration: 1}
+	lt2 := &LineTiming{Duration: 2}
+	var lts LineTimings
+	lts.Add("en", map[string]*LineTiming{"line:1": lt1})
+	lts.Add("de", map[string]*LineTiming{"line:1": lt2})
+
+	rec := &timedRecorder{}
+	h := &TimingHandler{DialogueHandler: rec, Timings: &lts, Locale: "en"}
+	for _, id := range []string{"line:1", "line:2"} {
+		if err := h.Line(Line{ID: id}); err != nil {
+			t.Fatalf("Line(%q) = %v", id, err)
+		}
+	}
+
+	ls := &LocaleSet{}
+	ls.Add("en", &StringTable{})
+	ls.Add("de", &StringTable{})
+	if err := ls.SetLocale("de"); err != nil {
+		t.Fatalf("SetLocale(de) = %v", err)
+	}
+	h.Locales = ls
+	if err := h.Line(Line{ID: "line:1"}); err != nil {
+		t.Fatalf("Line(line:1) = %v", err)
+	}
+
+	if diff := cmp.Diff(rec.ids, []string{"line:1", "line:2", "line:1"}); diff != "" {
+		t.Errorf("ids diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.timings, []*LineTiming{lt1, nil, lt2}); diff != "" {
+		t.Errorf("timings diff (-got +want):\n%s", diff)
+	}
+
+	// Handlers without TimedLine get lines as usual.
+	lr := &lineRecorder{}
+	h = &TimingHandler{DialogueHandler: lr, Timings: &lts, Locale: "en"}
+	if err := h.Line(Line{ID: "line:1"}); err != nil {
+		t.Fatalf("Line(line:1) = %v", err)
+	}
+	if diff := cmp.Diff(lr.ids, []string{"line:1"}); diff != "" {
+		t.Errorf("ids diff (-got +want):\n%s", diff)
+	}
+}
@@ -15,6 +15,7 @@
 package yarn
 
 import (
+	"bytes"
 	"fmt"
 	"io/fs"
 	"os"
@@ -68,11 +69,74 @@ func LoadProgramFile(programPath string) (*yarnpb.Program, error) {
 	return unmarshalBytes(yarnc)
 }
 
+// LoadProgramBytes unmarshals a compiled Yarn Spinner program from a byte
+// slice provided by the caller, without copying it first. The slice is not
+// retained, so it may be reused (or unmapped) once LoadProgramBytes returns.
+func LoadProgramBytes(yarnc []byte) (*yarnpb.Program, error) {
+	return unmarshalBytes(yarnc)
+}
+
+// LoadProgramFileMmap is like LoadProgramFile, but maps the file into memory
+// (where supported) instead of reading it into a buffer on the heap. This
+// reduces peak memory when loading large programs on constrained platforms.
+func LoadProgramFileMmap(programPath string) (*yarnpb.Program, error) {
+	yarnc, unmap, err := mmapFile(programPath)
+	if err != nil {
+		return nil, fmt.Errorf("mapping program file: %w", err)
+	}
+	defer unmap()
+	return unmarshalBytes(yarnc)
+}
+
+// LoadFilesMmap is like LoadFiles, but maps the files into memory (where
+// supported), and defers parsing each line in the string table until it is
+// first rendered. This cuts load time and peak memory for large programs and
+// string tables, at the cost of reporting malformed lines later (from Render)
+// rather than when loading.
+func LoadFilesMmap(programPath, langCode string) (*yarnpb.Program, *StringTable, error) {
+	prog, err := LoadProgramFileMmap(programPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	st, err := loadStringTableFileMmap(stringTablePath(programPath), langCode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return prog, st, nil
+}
+
+func loadStringTableFileMmap(stringTablePath, langCode string) (*StringTable, error) {
+	csv, unmap, err := mmapFile(stringTablePath)
+	if err != nil {
+		return nil, fmt.Errorf("mapping string table file: %w", err)
+	}
+	defer unmap()
+	st, err := readStringTable(bytes.NewReader(csv), langCode, false)
+	if err != nil {
+		return nil, fmt.Errorf("reading string table: %w", err)
+	}
+	csv, unmapMeta, err := mmapFile(metadataTablePath(stringTablePath))
+	if err != nil {
+		return nil, fmt.Errorf("mapping metadata file: %w", err)
+	}
+	defer unmapMeta()
+	if err := st.readMetadata(bytes.NewReader(csv)); err != nil {
+		return nil, fmt.Errorf("reading metadata file: %w", err)
+	}
+	return st, nil
+}
+
 func unmarshalBytes(yarnc []byte) (*yarnpb.Program, error) {
 	prog := new(yarnpb.Program)
 	if err := proto.Unmarshal(yarnc, prog); err != nil {
 		return nil, fmt.Errorf("unmarshaling program: %w", err)
 	}
+	if err := checkHeaderSchemas(prog); err != nil {
+		return nil, err
+	}
+	if optimizeOnLoad.Load() {
+		optimizeProgram(prog)
+	}
 	return prog, nil
 }
 
@@ -0,0 +1,57 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+)
+
+func TestLoadFilesMmap(t *testing.T) {
+	wantProg, wantST, err := LoadFiles("testdata/Example.yarnc", "en")
+	if err != nil {
+		t.Fatalf("LoadFiles = %v", err)
+	}
+	gotProg, gotST, err := LoadFilesMmap("testdata/Example.yarnc", "en")
+	if err != nil {
+		t.Fatalf("LoadFilesMmap = %v", err)
+	}
+	if !proto.Equal(gotProg, wantProg) {
+		t.Errorf("LoadFilesMmap program differs from LoadFiles program")
+	}
+	if got, want := len(gotST.Table), len(wantST.Table); got != want {
+		t.Fatalf("len(LoadFilesMmap string table) = %d, want %d", got, want)
+	}
+	for id, wantRow := range wantST.Table {
+		gotRow := gotST.Table[id]
+		if gotRow == nil {
+			t.Errorf("LoadFilesMmap string table missing %q", id)
+			continue
+		}
+		if gotRow.parsedText != nil {
+			t.Errorf("LoadFilesMmap row %q parsed before Render", id)
+		}
+		got, err := gotST.Render(Line{ID: id})
+		if err != nil {
+			t.Errorf("Render(%q) = %v", id, err)
+			continue
+		}
+		want, _ := wantST.Render(Line{ID: id})
+		if got.String() != want.String() || len(gotRow.Tags) != len(wantRow.Tags) {
+			t.Errorf("row %q = (%q, %v), want (%q, %v)", id, got, gotRow.Tags, want, wantRow.Tags)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrUnknownLocale is returned by LocaleSet.SetLocale when the locale has no
+// string table.
+const ErrUnknownLocale = virtualMachineError("unknown locale")
+
+// LocaleSet holds string tables for several locales, one of which is active.
+// The active locale can be switched at any time (e.g. from an in-game
+// settings menu), including while a conversation is in progress. It is safe
+// for concurrent use.
+//
+// Since the VM delivers lines and options by ID, with substitutions, it is
+// unaffected by the switch; only the rendering changes. To show the line or
+// options currently on screen in the new language, render them again with
+// the new table. With an AsyncAdapter, this can be done with Redeliver:
+//
+//	if err := locales.SetLocale("fr"); err != nil { ... }
+//	if err := adapter.Redeliver(); err != nil && !errors.Is(err, yarn.ErrNothingToRedeliver) { ... }
+type LocaleSet struct {
+	// Load, if not nil, is called by SetLocale for locales that have not
+	// been added, e.g. to load string tables on demand:
+	//
+	//	Load: func(lang string) (*yarn.StringTable, error) {
+	//		return yarn.LoadStringTableFile("Dialogue-"+lang+".csv", lang)
+	//	}
+	Load func(langCode string) (*StringTable, error)
+
+	// OnSwitch, if not nil, is called after the active locale changes.
+	OnSwitch func(langCode string, st *StringTable)
+
+	// Base, if not nil, is the string table that the others translate.
+	// Tables are checked against it with MarkStale as they are added or
+	// loaded, so that stale translations are flagged.
+	Base *StringTable
+
+	// OnStale, if not nil, is called with the IDs of any stale lines found
+	// when a table is checked against Base, e.g. to log them.
+	OnStale func(langCode string, ids []string)
+
+	mu      sync.RWMutex
+	tables  map[string]*StringTable
+	current string
+}
+
+// Add adds (or replaces) the string table for a locale. The first locale added
+// becomes the active locale. If Base is set, the table is checked for stale
+// lines.
+func (l *LocaleSet) Add(langCode string, st *StringTable) {
+	l.checkStale(langCode, st)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.tables == nil {
+		l.tables = make(map[string]*StringTable)
+	}
+	l.tables[langCode] = st
+	if l.current == "" {
+		l.current = langCode
+	}
+}
+
+// Locale returns the active locale.
+func (l *LocaleSet) Locale() string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.current
+}
+
+// Locales returns all the locales that have been added or loaded, sorted.
+func (l *LocaleSet) Locales() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	langs := make([]string, 0, len(l.tables))
+	for lang := range l.tables {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Table returns the string table for a locale, or nil if it has not been
+// added or loaded.
+func (l *LocaleSet) Table(langCode string) *StringTable {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tables[langCode]
+}
+
+// StringTable returns the string table of the active locale, or nil if there
+// is none.
+func (l *LocaleSet) StringTable() *StringTable {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tables[l.current]
+}
+
+// SetLocale switches the active locale, loading its string table with Load
+// if necessary.
+func (l *LocaleSet) SetLocale(langCode string) error {
+	l.mu.RLock()
+	st := l.tables[langCode]
+	l.mu.RUnlock()
+	if st == nil {
+		if l.Load == nil {
+			return fmt.Errorf("%w %q", ErrUnknownLocale, langCode)
+		}
+		loaded, err := l.Load(langCode)
+		if err != nil {
+			return fmt.Errorf("loading locale %q: %w", langCode, err)
+		}
+		st = loaded
+		l.checkStale(langCode, st)
+	}
+
+	l.mu.Lock()
+	if l.tables == nil {
+		l.tables = make(map[string]*StringTable)
+	}
+	l.tables[langCode] = st
+	l.current = langCode
+	l.mu.Unlock()
+
+	if l.OnSwitch != nil {
+		l.OnSwitch(langCode, st)
+	}
+	return nil
+}
+
+// Render renders the line using the string table of the active locale.
+func (l *LocaleSet) Render(line Line) (*AttributedString, error) {
+	st := l.StringTable()
+	if st == nil {
+		return nil, fmt.Errorf("%w: no active locale", ErrUnknownLocale)
+	}
+	return st.Render(line)
+}
+
+// checkStale marks stale rows in a table, if there is a Base.
+func (l *LocaleSet) checkStale(langCode string, st *StringTable) {
+	if l.Base == nil || st == l.Base {
+		return
+	}
+	if ids := st.MarkStale(l.Base); len(ids) > 0 && l.OnStale != nil {
+		l.OnStale(langCode, ids)
+	}
+}
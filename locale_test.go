@@ -0,0 +1,208 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+// renderingAsyncHandler renders each line and set of options, and sends the
+// text to a channel.
+type renderingAsyncHandler struct {
+	FakeAsyncDialogueHandler
+	locales *LocaleSet
+	texts   chan string
+}
+
+func (h *renderingAsyncHandler) Line(line Line) {
+	as, err := h.locales.Render(line)
+	if err != nil {
+		h.texts <- err.Error()
+		return
+	}
+	h.texts <- as.String()
+}
+
+func (h *renderingAsyncHandler) Options(options []Option) {
+	var texts []string
+	for _, opt := range options {
+		as, err := h.locales.Render(opt.Line)
+		if err != nil {
+			h.texts <- err.Error()
+			return
+		}
+		texts = append(texts, as.String())
+	}
+	h.texts <- strings.Join(texts, " | ")
+}
+
+func (h *renderingAsyncHandler) DialogueComplete() {
+	close(h.texts)
+	h.AsyncAdapter.Go()
+}
+
+func TestLocaleHotSwitch(t *testing.T) {
+	en := &StringTable{
+		Language: language.English,
+		Table: map[string]*StringTableRow{
+			"line:hi":  {ID: "line:hi", Text: "Hello, {0}!"},
+			"line:bye": {ID: "line:bye", Text: "Goodbye"},
+			"line:hmm": {ID: "line:hmm", Text: "Hmm"},
+		},
+	}
+	fr := &StringTable{
+		Language: language.French,
+		Table: map[string]*StringTableRow{
+			"line:hi":  {ID: "line:hi", Text: "Bonjour, {0} !"},
+			"line:bye": {ID: "line:bye", Text: "Au revoir"},
+			"line:hmm": {ID: "line:hmm", Text: "Euh"},
+		},
+	}
+	var switched []string
+	locales := &LocaleSet{
+		OnSwitch: func(lang string, _ *StringTable) { switched = append(switched, lang) },
+	}
+	locales.Add("en", en)
+	locales.Load = func(lang string) (*StringTable, error) {
+		if lang == "fr" {
+			return fr, nil
+		}
+		return nil, errors.New("no such file")
+	}
+
+	pb := NewProgramBuilder("Locale")
+	pb.Node("Start").
+		PushString("Ava").
+		Line("line:hi", 1).
+		Option("line:bye", "end", 0, false).
+		Option("line:hmm", "end", 0, false).
+		ShowOptions().
+		Jump().
+		Label("end").
+		Stop()
+	prog := pb.Program()
+
+	h := &renderingAsyncHandler{locales: locales, texts: make(chan string)}
+	aa := NewAsyncAdapter(h)
+	h.AsyncAdapter = aa
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: aa,
+		Vars:    NewMapVariableStorage(),
+	}
+	errc := make(chan error, 1)
+	go func() { errc <- vm.Run("Start") }()
+
+	expect := func(want string) {
+		t.Helper()
+		if got := <-h.texts; got != want {
+			t.Errorf("rendered %q, want %q", got, want)
+		}
+	}
+
+	expect("Hello, Ava!")
+	if err := locales.SetLocale("fr"); err != nil {
+		t.Fatalf("SetLocale(fr) = %v", err)
+	}
+	go func() {
+		if err := aa.Redeliver(); err != nil {
+			t.Errorf("Redeliver() = %v", err)
+		}
+	}()
+	expect("Bonjour, Ava !")
+	if err := aa.Go(); err != nil {
+		t.Fatalf("Go() = %v", err)
+	}
+
+	expect("Au revoir | Euh")
+	if err := locales.SetLocale("en"); err != nil {
+		t.Fatalf("SetLocale(en) = %v", err)
+	}
+	go func() {
+		if err := aa.Redeliver(); err != nil {
+			t.Errorf("Redeliver() = %v", err)
+		}
+	}()
+	expect("Goodbye | Hmm")
+	if err := aa.GoWithChoice(0); err != nil {
+		t.Fatalf("GoWithChoice(0) = %v", err)
+	}
+
+	for range h.texts {
+	}
+	if err := <-errc; err != nil {
+		t.Errorf("vm.Run(Start) = %v", err)
+	}
+	if err := aa.Redeliver(); !errors.Is(err, ErrNothingToRedeliver) {
+		t.Errorf("Redeliver() after dialogue = %v, want %v", err, ErrNothingToRedeliver)
+	}
+	if got, want := strings.Join(switched, ","), "fr,en"; got != want {
+		t.Errorf("switched = %q, want %q", got, want)
+	}
+	if err := locales.SetLocale("de"); err == nil {
+		t.Errorf("SetLocale(de) = nil, want error")
+	}
+	if got, want := locales.Locale(), "en"; got != want {
+		t.Errorf("Locale() = %q, want %q", got, want)
+	}
+}
+
+func TestLocaleHarness(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/Example.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+	locales := &LocaleSet{}
+	for _, lang := range []string{"en", "fr", "de"} {
+		st, err := LoadStringTableFile("testdata/Example-Lines.csv", "en")
+		if err != nil {
+			t.Fatalf("LoadStringTableFile = %v", err)
+		}
+		locales.Add(lang, st)
+	}
+	const prefix = "line:/Users/kalexmills/repos/personal/yarn/testdata/Example.yarn-"
+	fr := locales.Table("fr").Table
+	delete(fr, prefix+"Start-5")
+	fr[prefix+"Start-3"].Text = "Pourquoi, c'est une démo du système de script !"
+	fr[prefix+"Start-4"].Text = "B: [b]Et[/b] tu y es !"
+
+	h := &LocaleHarness{Program: prog, Locales: locales}
+	got, err := h.Run("Start")
+	if err != nil {
+		t.Fatalf("h.Run(Start) = %v", err)
+	}
+	var problems []string
+	for _, m := range got {
+		problems = append(problems, m.String())
+	}
+	want := []string{
+		`locale "fr", step 2, line "` + prefix + `Start-5": string table row for id "` + prefix + `Start-5" not found or nil`,
+		`locale "fr", step 3, line "` + prefix + `Start-3": has speaker = false, want true`,
+		`locale "fr", step 4, line "` + prefix + `Start-4": markup attributes [b], want []`,
+	}
+	if diff := cmp.Diff(problems, want); diff != "" {
+		t.Errorf("mismatches diff (-got +want):\n%s", diff)
+	}
+
+	h.Reference = "es"
+	if _, err := h.Run("Start"); !errors.Is(err, ErrUnknownLocale) {
+		t.Errorf("h.Run(Start) with Reference = es: error = %v, want %v", err, ErrUnknownLocale)
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// LocaleMismatch describes a structural difference between a locale and the
+// reference locale, found by LocaleHarness.
+type LocaleMismatch struct {
+	Locale  string // the locale that differs from the reference
+	Step    int    // index of the line or set of options in the playthrough
+	LineID  string // the line that differs, if any
+	Problem string
+}
+
+func (m LocaleMismatch) String() string {
+	if m.LineID == "" {
+		return fmt.Sprintf("locale %q, step %d: %s", m.Locale, m.Step, m.Problem)
+	}
+	return fmt.Sprintf("locale %q, step %d, line %q: %s", m.Locale, m.Step, m.LineID, m.Problem)
+}
+
+// LocaleHarness plays the same choice sequence in every locale of a LocaleSet
+// and checks that the playthroughs are structurally equivalent to the one in
+// the reference locale: the same number of lines and options, with the same
+// IDs, and lines that render with the same markup attributes and the same
+// presence of a speaker. This catches content divergence caused by bad
+// merges of translated string tables, such as missing rows, broken markup, or
+// a speaker's name lost in translation.
+type LocaleHarness struct {
+	// Program is the program to play.
+	Program *yarnpb.Program
+
+	// FuncMap provides any custom functions the program needs.
+	FuncMap FuncMap
+
+	// Locales contains the string tables to compare.
+	Locales *LocaleSet
+
+	// Reference is the locale that the others are compared against. If
+	// empty, the active locale of Locales is used.
+	Reference string
+
+	// Policy chooses options. It should be deterministic (such as a
+	// ScriptedPolicy), and it is reset before each playthrough if it
+	// implements PolicyResetter. If nil, the first available option is
+	// always chosen.
+	Policy ChoicePolicy
+
+	// MaxEvents limits the number of lines, options, and commands in each
+	// playthrough. If zero, DefaultMaxWalkEvents is used.
+	MaxEvents int
+
+	// NewVars, if not nil, is called to create the variable storage for each
+	// playthrough. Otherwise each gets a new empty MapVariableStorage.
+	NewVars func() VariableStorage
+}
+
+// localeStep is a line or set of options delivered during a playthrough.
+type localeStep struct {
+	options bool
+	lines   []localeLine
+}
+
+// localeLine is the structure of a rendered line.
+type localeLine struct {
+	id      string
+	speaker bool
+	attribs string // sorted, comma-separated attribute names
+	err     error
+}
+
+// Run plays the program from startNode once per locale and returns any
+// mismatches. The error is non-nil if a playthrough fails for reasons other
+// than rendering (which are reported as mismatches), or if the reference
+// locale is unknown.
+func (h *LocaleHarness) Run(startNode string) ([]LocaleMismatch, error) {
+	ref := h.Reference
+	if ref == "" {
+		ref = h.Locales.Locale()
+	}
+	if h.Locales.Table(ref) == nil {
+		return nil, fmt.Errorf("%w %q", ErrUnknownLocale, ref)
+	}
+	want, err := h.play(startNode, h.Locales.Table(ref))
+	if err != nil {
+		return nil, fmt.Errorf("locale %q: %w", ref, err)
+	}
+	var mismatches []LocaleMismatch
+	for i, s := range want {
+		for _, l := range s.lines {
+			if l.err != nil {
+				mismatches = append(mismatches, LocaleMismatch{
+					Locale:  ref,
+					Step:    i,
+					LineID:  l.id,
+					Problem: l.err.Error(),
+				})
+			}
+		}
+	}
+
+	for _, lang := range h.Locales.Locales() {
+		if lang == ref {
+			continue
+		}
+		got, err := h.play(startNode, h.Locales.Table(lang))
+		if err != nil {
+			return mismatches, fmt.Errorf("locale %q: %w", lang, err)
+		}
+		mismatches = append(mismatches, compareLocaleSteps(lang, got, want)...)
+	}
+	return mismatches, nil
+}
+
+// play plays the program once, rendering with st.
+func (h *LocaleHarness) play(startNode string, st *StringTable) ([]localeStep, error) {
+	policy := h.Policy
+	if policy == nil {
+		policy = firstPolicy{}
+	}
+	if r, ok := policy.(PolicyResetter); ok {
+		r.Reset()
+	}
+	max := h.MaxEvents
+	if max <= 0 {
+		max = DefaultMaxWalkEvents
+	}
+	vars := VariableStorage(nil)
+	if h.NewVars != nil {
+		vars = h.NewVars()
+	} else {
+		vars = NewMapVariableStorage()
+	}
+	lh := &localeHandler{
+		walkHandler: walkHandler{policy: policy, max: max, res: new(WalkResult)},
+		st:          st,
+	}
+	fm := make(FuncMap, len(h.FuncMap))
+	fm.merge(h.FuncMap)
+	vm := &VirtualMachine{
+		Program: h.Program,
+		Handler: lh,
+		Vars:    vars,
+		FuncMap: fm,
+	}
+	if err := vm.Run(startNode); err != nil {
+		return nil, err
+	}
+	return lh.steps, nil
+}
+
+// compareLocaleSteps compares a playthrough in one locale with the reference.
+func compareLocaleSteps(lang string, got, want []localeStep) []LocaleMismatch {
+	var ms []LocaleMismatch
+	report := func(step int, id, format string, args ...any) {
+		ms = append(ms, LocaleMismatch{Locale: lang, Step: step, LineID: id, Problem: fmt.Sprintf(format, args...)})
+	}
+	for i := 0; i < min(len(got), len(want)); i++ {
+		g, w := got[i], want[i]
+		if g.options != w.options || len(g.lines) != len(w.lines) {
+			report(i, "", "got %s, want %s", g, w)
+			// The playthroughs have diverged, so later steps can't be
+			// meaningfully compared.
+			return ms
+		}
+		for j, gl := range g.lines {
+			wl := w.lines[j]
+			switch {
+			case gl.id != wl.id:
+				report(i, gl.id, "want line %q", wl.id)
+			case gl.err != nil:
+				report(i, gl.id, "%v", gl.err)
+			case wl.err != nil:
+				// Already reported for the reference locale.
+			case gl.speaker != wl.speaker:
+				report(i, gl.id, "has speaker = %t, want %t", gl.speaker, wl.speaker)
+			case gl.attribs != wl.attribs:
+				report(i, gl.id, "markup attributes [%s], want [%s]", gl.attribs, wl.attribs)
+			}
+		}
+	}
+	if len(got) != len(want) {
+		report(min(len(got), len(want)), "", "got %d steps, want %d", len(got), len(want))
+	}
+	return ms
+}
+
+func (s localeStep) String() string {
+	ids := make([]string, len(s.lines))
+	for i, l := range s.lines {
+		ids[i] = l.id
+	}
+	if s.options {
+		return fmt.Sprintf("options %v", ids)
+	}
+	return fmt.Sprintf("line %v", ids)
+}
+
+// localeHandler is the DialogueHandler used by LocaleHarness.
+type localeHandler struct {
+	walkHandler
+	st    *StringTable
+	steps []localeStep
+}
+
+func (h *localeHandler) render(line Line) localeLine {
+	ll := localeLine{id: line.ID}
+	as, err := h.st.Render(line)
+	if err != nil {
+		ll.err = err
+		return ll
+	}
+	speaker, _ := findSpeaker(as)
+	ll.speaker = speaker != ""
+	var names []string
+	as.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if a.Start == pos {
+				names = append(names, a.Name)
+			}
+		}
+	})
+	sort.Strings(names)
+	ll.attribs = strings.Join(names, ",")
+	return ll
+}
+
+func (h *localeHandler) Line(line Line) error {
+	h.steps = append(h.steps, localeStep{lines: []localeLine{h.render(line)}})
+	return h.walkHandler.Line(line)
+}
+
+func (h *localeHandler) Options(options []Option) (int, error) {
+	step := localeStep{options: true}
+	for _, opt := range options {
+		step.lines = append(step.lines, h.render(opt.Line))
+	}
+	h.steps = append(h.steps, step)
+	return h.walkHandler.Options(options)
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// LocalPrefix is the prefix of node-local variables. Variables whose names
+// begin with LocalPrefix (e.g. $_count) are never read from or written to
+// the variable storage. Instead they live only while the current node runs,
+// and are discarded when the VM moves to another node (including when the
+// node jumps to itself).
+const LocalPrefix = "$_"
+
+// LocalsHeader is the node header that declares other variables to be local
+// to the node, as a comma-separated list of variable names:
+//
+//	title: Haggle
+//	locals: $offer, $rounds
+//	---
+//	...
+//	===
+//
+// Within the node, declared locals behave like $_ variables. Until they are
+// set, they read as the program's initial value for the variable (if any),
+// not the value in the variable storage.
+const LocalsHeader = "locals"
+
+// NodeLocals returns the variables declared local by the node's locals
+// header. Variables with LocalPrefix are local whether declared or not.
+func NodeLocals(node *yarnpb.Node) []string {
+	return headerList(node, LocalsHeader)
+}
+
+// headerList returns the comma-separated values of all headers in the node
+// with the key.
+func headerList(node *yarnpb.Node, key string) []string {
+	var list []string
+	for _, h := range node.GetHeaders() {
+		if h.Key != key {
+			continue
+		}
+		for _, v := range strings.Split(h.Value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				list = append(list, v)
+			}
+		}
+	}
+	return list
+}
+
+// declaredLocals returns the set of variables declared by the node's locals
+// header, or nil if there are none. It is computed once on entering the node
+// (see state.declared), rather than on each variable access.
+func declaredLocals(node *yarnpb.Node) map[string]struct{} {
+	names := NodeLocals(node)
+	if len(names) == 0 {
+		return nil
+	}
+	set := make(map[string]struct{}, len(names))
+	for _, k := range names {
+		set[k] = struct{}{}
+	}
+	return set
+}
+
+// isLocal reports whether the variable is local to the current node: it has
+// LocalPrefix, is a bound parameter, or is declared in the locals header.
+func (vm *VirtualMachine) isLocal(k string) bool {
+	if strings.HasPrefix(k, LocalPrefix) {
+		return true
+	}
+	if _, ok := vm.state.locals[k]; ok {
+		return true
+	}
+	_, ok := vm.state.declared[k]
+	return ok
+}
+
+// setVariable sets a variable, either in the current node's locals or in
+// Vars, applying FixedPoint.
+func (vm *VirtualMachine) setVariable(k string, v any) {
+	v = vm.quantize(v)
+	if !vm.isLocal(k) {
+		vm.Vars.SetValue(k, v)
+		return
+	}
+	if vm.state.locals == nil {
+		vm.state.locals = make(map[string]any)
+	}
+	vm.state.locals[k] = v
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLocalVariables(t *testing.T) {
+	pb := NewProgramBuilder("Locals").InitialValue("$offer", float32(1))
+	pb.Node("Start").Header(LocalsHeader, "$offer").
+		PushVariable("$offer").Line("line:before", 1).
+		PushFloat(2).StoreVariable("$_n").Pop().
+		PushFloat(5).StoreVariable("$offer").Pop().
+		PushVariable("$_n").PushVariable("$offer").Line("line:start", 2).
+		Command("count -> $_result", 0).
+		PushVariable("$_result").Line("line:result", 1).
+		RunNode("Next")
+	pb.Node("Next").
+		PushVariable("$_n").PushVariable("$offer").Line("line:next", 2)
+	prog := pb.Program()
+
+	rec := &substRecorder{}
+	vars := NewMapVariableStorage()
+	vars.SetValue("$offer", float32(3))
+	vars.SetValue("$_n", float32(4))
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: &commandResults{substRecorder: rec, results: map[string]any{"count": 6}},
+		Vars:    vars,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := []Line{
+		{ID: "line:before", Substitutions: []string{"1"}},
+		{ID: "line:start", Substitutions: []string{"2", "5"}},
+		{ID: "line:result", Substitutions: []string{"6"}},
+		{ID: "line:next", Substitutions: []string{"null", "3"}},
+	}
+	if diff := cmp.Diff(rec.lines, want); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	wantVars := map[string]any{"$offer": float32(3), "$_n": float32(4)}
+	got := vars.Contents()
+	for k := range got {
+		if strings.HasPrefix(k, InternalPrefix) {
+			delete(got, k)
+		}
+	}
+	if diff := cmp.Diff(got, wantVars); diff != "" {
+		t.Errorf("vars diff (-got +want):\n%s", diff)
+	}
+}
+
+// commandResults returns fixed results for commands.
+type commandResults struct {
+	*substRecorder
+	results map[string]any
+}
+
+func (h *commandResults) CommandResult(cmd string) (any, error) {
+	return h.results[cmd], nil
+}
+
+func TestLocalVariablesRestore(t *testing.T) {
+	pb := NewProgramBuilder("Locals")
+	pb.Node("Start").Header(LocalsHeader, "$offer").
+		Line("line:pause", 0).
+		PushFloat(7).StoreVariable("$offer").Pop().
+		PushVariable("$offer").Line("line:after", 1)
+	prog := pb.Program()
+
+	h := &snapshotRecorder{at: "line:pause"}
+	vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+	h.vm = vm
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if h.snap == nil {
+		t.Fatal("no snapshot taken")
+	}
+
+	rec := &substRecorder{}
+	vars := NewMapVariableStorage()
+	vm = &VirtualMachine{Program: prog, Handler: rec, Vars: vars}
+	if err := vm.Restore(h.snap); err != nil {
+		t.Fatalf("vm.Restore(snap) = %v", err)
+	}
+	if err := vm.Resume(); err != nil {
+		t.Fatalf("vm.Resume() = %v", err)
+	}
+	want := []Line{{ID: "line:after", Substitutions: []string{"7"}}}
+	if diff := cmp.Diff(rec.lines, want); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if v, ok := vars.GetValue("$offer"); ok {
+		t.Errorf("vars.GetValue($offer) = %v, true, want the local not stored", v)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"context"
+	"log/slog"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// LevelTrace is the slog level used for per-instruction trace records. It is
+// below slog.LevelDebug, because there are a lot of them.
+const LevelTrace = slog.LevelDebug - 4
+
+// Messages used for records logged by the VM. These are constant so that log
+// processors can match on them.
+const (
+	logMsgInstruction  = "yarn instruction"
+	logMsgEvent        = "yarn handler event"
+	logMsgError        = "yarn error"
+	logMsgAssert       = "yarn assertion failed"
+	logMsgHandlerError = "yarn handler error"
+)
+
+// logEnabled reports whether the VM has a Logger that would log at level.
+func (vm *VirtualMachine) logEnabled(level slog.Level) bool {
+	return vm.Logger != nil && vm.Logger.Enabled(context.Background(), level)
+}
+
+// log emits a record to the Logger (if any), adding node and pc attributes
+// for the current position of the VM.
+func (vm *VirtualMachine) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if !vm.logEnabled(level) {
+		return
+	}
+	if vm.state.node != nil {
+		attrs = append(attrs,
+			slog.String("node", vm.state.node.Name),
+			slog.Int("pc", vm.state.pc),
+		)
+	}
+	vm.Logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// logInstruction logs the instruction about to be executed, along with the
+// stack and options.
+func (vm *VirtualMachine) logInstruction(inst *yarnpb.Instruction) {
+	if !vm.logEnabled(LevelTrace) {
+		return
+	}
+	vm.log(LevelTrace, logMsgInstruction,
+		slog.String("inst", FormatInstruction(inst)),
+		slog.Any("stack", vm.state.stack),
+		slog.Any("options", vm.state.options),
+	)
+}
+
+// logEvent logs a handler event at debug level, and records it for error
+// reports. Attributes are scrubbed according to Privacy.
+func (vm *VirtualMachine) logEvent(event string, attrs ...slog.Attr) {
+	attrs = vm.Privacy.scrubAttrs(attrs)
+	vm.recordEvent(event, attrs)
+	if !vm.logEnabled(slog.LevelDebug) {
+		return
+	}
+	vm.log(slog.LevelDebug, logMsgEvent, append([]slog.Attr{slog.String("event", event)}, attrs...)...)
+}
+
+// logError logs an error that is about to stop the VM.
+func (vm *VirtualMachine) logError(err error) {
+	vm.log(slog.LevelError, logMsgError, slog.Any("error", err))
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// logRecorder is a slog.Handler that keeps the records it handles.
+type logRecorder struct {
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *logRecorder) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+func (h *logRecorder) WithAttrs([]slog.Attr) slog.Handler               { return h }
+func (h *logRecorder) WithGroup(string) slog.Handler                    { return h }
+
+func (h *logRecorder) Handle(_ context.Context, r slog.Record) error {
+	h.records = append(h.records, r.Clone())
+	return nil
+}
+
+// summary summarises each record as its level, message, node and pc, and
+// one other attribute (the instruction, event, or error).
+func (h *logRecorder) summary() []string {
+	var out []string
+	for _, r := range h.records {
+		attrs := make(map[string]string)
+		r.Attrs(func(a slog.Attr) bool {
+			attrs[a.Key] = a.Value.String()
+			return true
+		})
+		s := fmt.Sprintf("%v %s %s:%s", r.Level, r.Message, attrs["node"], attrs["pc"])
+		for _, k := range []string{"inst", "event", "error"} {
+			if v, ok := attrs[k]; ok {
+				s += " " + v
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func TestLogger(t *testing.T) {
+	pb := NewProgramBuilder("Logged")
+	pb.Node("Start").
+		Line("line:1", 0).
+		Command("wave", 0).
+		Call("missing", 0)
+
+	tests := []struct {
+		level slog.Level
+		want  []string
+	}{
+		{
+			level: LevelTrace,
+			want: []string{
+				"DEBUG yarn handler event Start:0 NodeStart",
+				"DEBUG yarn handler event Start:0 PrepareForLines",
+				`DEBUG-4 yarn instruction Start:0 RUN_LINE "line:1" 0`,
+				"DEBUG yarn handler event Start:1 Line",
+				`DEBUG-4 yarn instruction Start:1 RUN_COMMAND "wave" 0`,
+				"DEBUG yarn handler event Start:2 Command",
+				`DEBUG-4 yarn instruction Start:2 PUSH_FLOAT 0.000000`,
+				`DEBUG-4 yarn instruction Start:3 CALL_FUNC "missing"`,
+				`ERROR yarn error Start:3 Start 000003 CALL_FUNC "missing": "missing" function not found`,
+			},
+		},
+		{
+			level: slog.LevelDebug,
+			want: []string{
+				"DEBUG yarn handler event Start:0 NodeStart",
+				"DEBUG yarn handler event Start:0 PrepareForLines",
+				"DEBUG yarn handler event Start:1 Line",
+				"DEBUG yarn handler event Start:2 Command",
+				`ERROR yarn error Start:3 Start 000003 CALL_FUNC "missing": "missing" function not found`,
+			},
+		},
+		{
+			level: slog.LevelError,
+			want: []string{
+				`ERROR yarn error Start:3 Start 000003 CALL_FUNC "missing": "missing" function not found`,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.level.String(), func(t *testing.T) {
+			logs := &logRecorder{level: test.level}
+			vm := &VirtualMachine{
+				Program: pb.Program(),
+				Handler: FakeDialogueHandler{},
+				Vars:    NewMapVariableStorage(),
+				Logger:  slog.New(logs),
+			}
+			if err := vm.Run("Start"); !errors.Is(err, ErrFunctionNotFound) {
+				t.Fatalf("vm.Run(Start) = %v, want %v", err, ErrFunctionNotFound)
+			}
+			if diff := cmp.Diff(logs.summary(), test.want); diff != "" {
+				t.Errorf("log records diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoggerEventAttrs(t *testing.T) {
+	pb := NewProgramBuilder("Logged")
+	pb.Node("Start").
+		PushString("Bea").Line("line:1", 1).
+		Command("wave", 0)
+
+	logs := &logRecorder{level: slog.LevelDebug}
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: FakeDialogueHandler{},
+		Vars:    NewMapVariableStorage(),
+		Logger:  slog.New(logs),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	var got []string
+	for _, r := range logs.records {
+		var attrs []string
+		r.Attrs(func(a slog.Attr) bool {
+			attrs = append(attrs, a.String())
+			return true
+		})
+		got = append(got, strings.Join(attrs, " "))
+	}
+	want := []string{
+		"event=NodeStart node=Start pc=0",
+		"event=PrepareForLines line_ids=[line:1] node=Start pc=0",
+		"event=Line line_id=line:1 substitutions=[Bea] node=Start pc=2",
+		"event=Command command=wave node=Start pc=3",
+		"event=NodeComplete node=Start pc=3",
+		"event=DialogueComplete node=Start pc=3",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("event attributes diff (-got +want):\n%s", diff)
+	}
+}
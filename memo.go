@@ -0,0 +1,98 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MemoScope controls how long the VM remembers the results of pure functions.
+type MemoScope int
+
+const (
+	// MemoPerNode forgets results whenever a node starts.
+	MemoPerNode MemoScope = iota
+
+	// MemoPerRun forgets results only when Run (or Resume) is called.
+	MemoPerRun
+)
+
+// PureFunc marks a function in a FuncMap as pure: for the same arguments, it
+// returns the same result (at least for the MemoScope of the VM), and has no
+// side effects worth repeating. The VM remembers its results, keyed by the
+// arguments, instead of calling it again. Use Pure to create one.
+type PureFunc struct {
+	Func any
+}
+
+// Pure marks fn as a pure function, so that the VM can remember its results.
+// This is useful for expensive queries of game state that don't change during
+// a conversation (or a node), particularly in condition-heavy nodes:
+//
+//	vm.FuncMap = yarn.FuncMap{
+//		"reputation": yarn.Pure(func(faction string) float32 { ... }),
+//	}
+//
+// Errors are not remembered. If the game state that the function depends on
+// changes, call VirtualMachine.ClearMemo.
+func Pure(fn any) PureFunc { return PureFunc{Func: fn} }
+
+// memoResult is a remembered result of a pure function.
+type memoResult struct {
+	value any
+	ok    bool // whether the function returned a value
+}
+
+// ClearMemo forgets all remembered results of pure functions (see Pure).
+func (vm *VirtualMachine) ClearMemo() {
+	clear(vm.memo)
+}
+
+// remember stores the result of a pure function.
+func (vm *VirtualMachine) remember(key string, value any, ok bool) {
+	if vm.memo == nil {
+		vm.memo = make(map[string]memoResult)
+	}
+	vm.memo[key] = memoResult{value: value, ok: ok}
+}
+
+// memoKey returns the key for remembering the result of a call. Arguments of
+// different types are distinguished, since functions may treat them
+// differently (e.g. "1" and 1).
+func memoKey(funcname string, args []any) string {
+	var sb strings.Builder
+	sb.WriteString(funcname)
+	for _, arg := range args {
+		sb.WriteByte(0)
+		switch x := arg.(type) {
+		case nil:
+			sb.WriteByte('n')
+		case bool:
+			sb.WriteByte('b')
+			sb.WriteString(strconv.FormatBool(x))
+		case float32:
+			sb.WriteByte('f')
+			sb.WriteString(strconv.FormatFloat(float64(x), 'g', -1, 32))
+		case string:
+			sb.WriteByte('s')
+			sb.WriteString(strconv.Quote(x))
+		default:
+			fmt.Fprintf(&sb, "%T:%#v", x, x)
+		}
+	}
+	return sb.String()
+}
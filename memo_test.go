@@ -0,0 +1,118 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPure(t *testing.T) {
+	// reputation("guild") >= 10 and reputation("guild") < reputation(1)
+	prog := condProgram(func(b *NodeBuilder) {
+		b.PushString("guild").
+			Call("reputation", 1).
+			PushFloat(10).
+			Call("Number.GreaterThanOrEqualTo", 2).
+			PushString("guild").
+			Call("reputation", 1).
+			PushFloat(1).
+			Call("reputation", 1).
+			Call("Number.LessThan", 2).
+			Call("Bool.And", 2)
+	})
+
+	for _, precompiled := range []*Precompiled{nil, Precompile(prog)} {
+		var calls []string
+		rep := func(faction string) float32 {
+			calls = append(calls, faction)
+			if faction == "guild" {
+				return 10
+			}
+			return 20
+		}
+		rec := &lineRecorder{}
+		vm := &VirtualMachine{
+			Program:     prog,
+			Handler:     rec,
+			Vars:        NewMapVariableStorage(),
+			FuncMap:     FuncMap{"reputation": Pure(rep)},
+			Precompiled: precompiled,
+		}
+		for i := 0; i < 2; i++ {
+			if err := vm.Run("Start"); err != nil {
+				t.Fatalf("vm.Run(Start) = %v", err)
+			}
+		}
+		if diff := cmp.Diff(rec.ids, []string{"yes", "yes"}); diff != "" {
+			t.Errorf("lines diff (-got +want):\n%s", diff)
+		}
+		// Once per argument per Run. The string "1" and number 1 are
+		// different arguments.
+		if diff := cmp.Diff(calls, []string{"guild", "1", "guild", "1"}); diff != "" {
+			t.Errorf("calls (precompiled = %t) diff (-got +want):\n%s", precompiled != nil, diff)
+		}
+	}
+}
+
+func TestMemoScope(t *testing.T) {
+	calls := 0
+	pb := NewProgramBuilder("Memo")
+	pb.Node("A")
+	pb.Node("B")
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: FakeDialogueHandler{},
+		Vars:    NewMapVariableStorage(),
+		FuncMap: FuncMap{"count": Pure(func() float32 {
+			calls++
+			return float32(calls)
+		})},
+	}
+	call := func() {
+		t.Helper()
+		if _, err := vm.Evaluate(MustCompileExpression("count()")); err != nil {
+			t.Fatalf("vm.Evaluate(count()) = %v", err)
+		}
+	}
+	setNode := func(name string) {
+		t.Helper()
+		if err := vm.SetNode(name); err != nil {
+			t.Fatalf("vm.SetNode(%s) = %v", name, err)
+		}
+	}
+
+	setNode("A")
+	call()
+	call()
+	setNode("B")
+	call()
+	if calls != 2 {
+		t.Errorf("MemoPerNode: calls = %d, want 2", calls)
+	}
+
+	vm.MemoScope = MemoPerRun
+	setNode("A")
+	call()
+	if calls != 2 {
+		t.Errorf("MemoPerRun: calls = %d, want 2", calls)
+	}
+	vm.ClearMemo()
+	call()
+	if calls != 3 {
+		t.Errorf("after ClearMemo: calls = %d, want 3", calls)
+	}
+}
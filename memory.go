@@ -0,0 +1,294 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+const (
+	// RememberTagPrefix marks line tags (in the metadata table) that record
+	// a fact in Memory when the line is delivered, or when the option is
+	// chosen, e.g. #remember:betrayed_ava.
+	RememberTagPrefix = "remember:"
+
+	// RememberCommand records a fact in Memory, e.g.
+	// <<remember betrayed_ava>>.
+	RememberCommand = "remember"
+)
+
+// MemoryFact is something remembered by Memory.
+type MemoryFact struct {
+	// Name is the name of the fact: a node name, an option's line ID, a
+	// variable name (including the $), or a name given by a tag or command.
+	Name string `json:"name"`
+
+	// Count is how many times the fact has been recorded.
+	Count int `json:"count"`
+
+	// Value is the latest value, for variables.
+	Value any `json:"value,omitempty"`
+}
+
+// Memory is a long-term memory of salient facts, which persists across
+// conversations, and can be queried from scripts with MemoryLibrary. Facts
+// are recorded by MemoryHandler (according to the Record fields), or by
+// calling Remember. Memory marshals to and from JSON, for saving. It is safe
+// for concurrent use.
+type Memory struct {
+	// RecordNodes records a fact, named after the node, each time a node
+	// completes.
+	RecordNodes bool
+
+	// RecordOptions records a fact, named after the option's line ID, each
+	// time an option is chosen.
+	RecordOptions bool
+
+	// RecordVariables are variables (e.g. "$gold") to record, as facts named
+	// after the variable, each time their values change.
+	RecordVariables []string
+
+	mu    sync.Mutex
+	facts map[string]*MemoryFact
+}
+
+// Remember records a fact. value is only kept for variables, and may be nil.
+func (m *Memory) Remember(name string, value any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.facts == nil {
+		m.facts = make(map[string]*MemoryFact)
+	}
+	f := m.facts[name]
+	if f == nil {
+		f = &MemoryFact{Name: name}
+		m.facts[name] = f
+	}
+	f.Count++
+	f.Value = value
+}
+
+// Remembered reports whether a fact has been recorded.
+func (m *Memory) Remembered(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.facts[name] != nil
+}
+
+// Fact returns a fact.
+func (m *Memory) Fact(name string) (MemoryFact, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	f := m.facts[name]
+	if f == nil {
+		return MemoryFact{}, false
+	}
+	return *f, true
+}
+
+// Facts returns all the facts, sorted by name.
+func (m *Memory) Facts() []MemoryFact {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	facts := make([]MemoryFact, 0, len(m.facts))
+	for _, name := range sortedKeys(m.facts) {
+		facts = append(facts, *m.facts[name])
+	}
+	return facts
+}
+
+// Forget forgets a fact.
+func (m *Memory) Forget(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.facts, name)
+}
+
+// MarshalJSON marshals the facts as a JSON array, sorted by name.
+func (m *Memory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Facts())
+}
+
+// UnmarshalJSON replaces the facts with those in a JSON array.
+func (m *Memory) UnmarshalJSON(b []byte) error {
+	var facts []MemoryFact
+	if err := json.Unmarshal(b, &facts); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.facts = make(map[string]*MemoryFact, len(facts))
+	for i := range facts {
+		m.facts[facts[i].Name] = &facts[i]
+	}
+	return nil
+}
+
+// MemoryLibrary returns functions for querying memory from scripts:
+//
+//	remembered(fact) -> bool          whether the fact has been recorded
+//	remembered_count(fact) -> number  how many times it has been recorded
+func MemoryLibrary(m *Memory) FuncMap {
+	return FuncMap{
+		"remembered": func(name string) bool {
+			return m.Remembered(name)
+		},
+		"remembered_count": func(name string) float32 {
+			f, _ := m.Fact(name)
+			return float32(f.Count)
+		},
+	}
+}
+
+var (
+	_ DialogueHandler      = &MemoryHandler{}
+	_ CommandResultHandler = &MemoryHandler{}
+	_ SkipHandler          = &MemoryHandler{}
+)
+
+// MemoryHandler is a DialogueHandler that records facts in Memory: completed
+// nodes, chosen options, and changes to variables (as configured in Memory),
+// lines and chosen options tagged with RememberTagPrefix (if StringTable is
+// set), and remember commands. Variable changes are noticed at the next
+// event. All other commands and events are passed to the embedded handler.
+type MemoryHandler struct {
+	DialogueHandler
+	Memory      *Memory
+	StringTable *StringTable
+
+	// Vars is used to check RecordVariables. It should be the same storage
+	// the VM uses.
+	Vars VariableStorage
+
+	mu   sync.Mutex
+	vars map[string]any // last seen values of RecordVariables
+}
+
+// checkVars records changes to RecordVariables since the last event. The
+// first check records the current values without remembering them.
+func (h *MemoryHandler) checkVars() {
+	if h.Vars == nil || len(h.Memory.RecordVariables) == 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	first := h.vars == nil
+	if first {
+		h.vars = make(map[string]any)
+	}
+	for _, name := range h.Memory.RecordVariables {
+		v, _ := h.Vars.GetValue(name)
+		old, seen := h.vars[name]
+		h.vars[name] = v
+		if first || (seen && reflect.DeepEqual(old, v)) {
+			continue
+		}
+		h.Memory.Remember(name, v)
+	}
+}
+
+// rememberTags records facts named by the line's tags.
+func (h *MemoryHandler) rememberTags(lineID string) {
+	row := h.StringTable.row(lineID)
+	if row == nil {
+		return
+	}
+	for _, t := range row.Tags {
+		if name, ok := strings.CutPrefix(t, RememberTagPrefix); ok && name != "" {
+			h.Memory.Remember(name, nil)
+		}
+	}
+}
+
+// NodeStart checks variables, then calls the embedded handler.
+func (h *MemoryHandler) NodeStart(nodeName string) error {
+	h.checkVars()
+	return h.DialogueHandler.NodeStart(nodeName)
+}
+
+// Line records the line's tags, then calls the embedded handler.
+func (h *MemoryHandler) Line(line Line) error {
+	h.checkVars()
+	h.rememberTags(line.ID)
+	return h.DialogueHandler.Line(line)
+}
+
+// SkipLine records the line's tags, then calls the embedded handler's
+// SkipLine method, if it has one.
+func (h *MemoryHandler) SkipLine(line Line) error {
+	h.checkVars()
+	h.rememberTags(line.ID)
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// Options calls the embedded handler, then records the chosen option.
+func (h *MemoryHandler) Options(options []Option) (int, error) {
+	h.checkVars()
+	id, err := h.DialogueHandler.Options(options)
+	if err != nil {
+		return id, err
+	}
+	for _, opt := range options {
+		if opt.ID != id {
+			continue
+		}
+		if h.Memory.RecordOptions {
+			h.Memory.Remember(opt.Line.ID, nil)
+		}
+		h.rememberTags(opt.Line.ID)
+	}
+	return id, nil
+}
+
+// Command handles remember commands, and passes other commands to the
+// embedded handler.
+func (h *MemoryHandler) Command(command string) error {
+	_, err := h.CommandResult(command)
+	return err
+}
+
+// CommandResult is like Command, but passes other commands to the embedded
+// handler's CommandResult method, if it has one.
+func (h *MemoryHandler) CommandResult(command string) (any, error) {
+	h.checkVars()
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] != RememberCommand {
+		return forwardCommandResult(h.DialogueHandler, command)
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("%s: want exactly 1 fact, got %q", RememberCommand, command)
+	}
+	h.Memory.Remember(fields[1], nil)
+	return nil, nil
+}
+
+// NodeComplete records the node, then calls the embedded handler.
+func (h *MemoryHandler) NodeComplete(nodeName string) error {
+	h.checkVars()
+	if h.Memory.RecordNodes {
+		h.Memory.Remember(nodeName, nil)
+	}
+	return h.DialogueHandler.NodeComplete(nodeName)
+}
+
+// DialogueComplete checks variables, then calls the embedded handler.
+func (h *MemoryHandler) DialogueComplete() error {
+	h.checkVars()
+	return h.DialogueHandler.DialogueComplete()
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMemoryHandler(t *testing.T) {
+	pb := NewProgramBuilder("Memory")
+	pb.Node("Start").
+		Line("line:rumour", 0).
+		PushFloat(3).
+		StoreVariable("$trust").
+		Option("line:betray", "end", 0, false).
+		Option("line:help", "end", 0, false).
+		ShowOptions().
+		Jump().
+		Label("end").
+		Command("remember met_ava", 0).
+		Command("wave", 0).
+		Stop()
+	pb.Node("Later").
+		PushString("betrayed_ava").
+		Call("remembered", 1).
+		JumpIfFalse("friendly").
+		Pop().
+		Line("line:cold", 0).
+		Stop().
+		Label("friendly").
+		Pop().
+		Line("line:warm", 0).
+		Stop()
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:rumour": {ID: "line:rumour", Tags: []string{"remember:heard_rumour"}},
+		"line:betray": {ID: "line:betray", Tags: []string{"remember:betrayed_ava"}},
+		"line:help":   {ID: "line:help", Tags: []string{"remember:helped_ava"}},
+	}}
+
+	mem := &Memory{RecordNodes: true, RecordOptions: true, RecordVariables: []string{"$trust"}}
+	vars := NewMapVariableStorage()
+	rec := &lineRecorder{}
+	h := &MemoryHandler{
+		DialogueHandler: &choosingHandler{rec},
+		Memory:          mem,
+		StringTable:     st,
+		Vars:            vars,
+	}
+	vm := &VirtualMachine{Program: pb.Program(), Handler: h, Vars: vars, FuncMap: MemoryLibrary(mem)}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := []MemoryFact{
+		{Name: "$trust", Count: 1, Value: float32(3)},
+		{Name: "Start", Count: 1},
+		{Name: "betrayed_ava", Count: 1},
+		{Name: "heard_rumour", Count: 1},
+		{Name: "line:betray", Count: 1},
+		{Name: "met_ava", Count: 1},
+	}
+	if diff := cmp.Diff(mem.Facts(), want); diff != "" {
+		t.Errorf("mem.Facts() diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.commands, []string{"wave"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+
+	// A later conversation, after saving and loading.
+	saved, err := json.Marshal(mem)
+	if err != nil {
+		t.Fatalf("json.Marshal(mem) = %v", err)
+	}
+	loaded := &Memory{}
+	if err := json.Unmarshal(saved, loaded); err != nil {
+		t.Fatalf("json.Unmarshal = %v", err)
+	}
+	rec.ids = nil
+	vm.FuncMap = MemoryLibrary(loaded)
+	if err := vm.Run("Later"); err != nil {
+		t.Fatalf("vm.Run(Later) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:cold"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+
+	loaded.Forget("betrayed_ava")
+	rec.ids = nil
+	if err := vm.Run("Later"); err != nil {
+		t.Fatalf("vm.Run(Later) after Forget = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:warm"}); diff != "" {
+		t.Errorf("lines after Forget diff (-got +want):\n%s", diff)
+	}
+
+	if err := h.Command("remember"); err == nil {
+		t.Errorf("h.Command(remember) = nil, want error")
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrNodeGated is returned (wrapped) when a NodeGate denies entry to a node
+// without providing a fallback, or when fallbacks lead back to a node that
+// was already denied.
+const ErrNodeGated = virtualMachineError("node entry denied")
+
+// NodeGate decides whether a node can be entered, based on game state (e.g.
+// chapter locks, or content the player hasn't unlocked). It is consulted
+// before entering any node: with Run, SetNode, or a jump within the
+// dialogue (including after choosing an option). node is the name as given
+// (which may be namespaced, see Router).
+//
+// To allow entry, return true. To deny entry, return false, and either the
+// name of a fallback node to enter instead (which is also checked), or ""
+// to fail with an error wrapping ErrNodeGated:
+//
+//	vm.NodeGate = func(node string) (bool, string) {
+//		if strings.HasPrefix(node, "Chapter2_") && !game.Chapter2Unlocked() {
+//			return false, "Chapter2_Locked"
+//		}
+//		return true, ""
+//	}
+type NodeGate func(node string) (allow bool, fallback string)
+
+// gateNode returns the node to enter in place of name, according to
+// vm.NodeGate.
+func (vm *VirtualMachine) gateNode(name string) (string, error) {
+	if vm.NodeGate == nil {
+		return name, nil
+	}
+	var denied []string
+	for {
+		allow, fallback := vm.NodeGate(name)
+		if allow {
+			return name, nil
+		}
+		denied = append(denied, name)
+		if fallback == "" {
+			return "", fmt.Errorf("%w: %q", ErrNodeGated, name)
+		}
+		if slices.Contains(denied, fallback) {
+			return "", fmt.Errorf("%w: fallbacks loop: %s -> %s", ErrNodeGated, strings.Join(denied, " -> "), fallback)
+		}
+		name = fallback
+	}
+}
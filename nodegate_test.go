@@ -0,0 +1,98 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNodeGate(t *testing.T) {
+	pb := NewProgramBuilder("Gated")
+	pb.Node("Start").Line("line:start", 0).RunNode("Chapter2")
+	pb.Node("Chapter2").Line("line:chapter2", 0).Stop()
+	pb.Node("Locked").Line("line:locked", 0).Stop()
+	pb.Node("Secret").Line("line:secret", 0).Stop()
+
+	unlocked := false
+	var asked []string
+	gate := func(node string) (bool, string) {
+		asked = append(asked, node)
+		switch node {
+		case "Chapter2":
+			if !unlocked {
+				return false, "Locked"
+			}
+		case "Secret":
+			return false, ""
+		case "LoopA":
+			return false, "LoopB"
+		case "LoopB":
+			return false, "LoopA"
+		}
+		return true, ""
+	}
+
+	for _, test := range []struct {
+		unlocked  bool
+		start     string
+		wantIDs   []string
+		wantAsked []string
+		wantErr   bool
+	}{
+		{
+			start:     "Start",
+			wantIDs:   []string{"line:start", "line:locked"},
+			wantAsked: []string{"Start", "Chapter2", "Locked"},
+		},
+		{
+			unlocked:  true,
+			start:     "Start",
+			wantIDs:   []string{"line:start", "line:chapter2"},
+			wantAsked: []string{"Start", "Chapter2"},
+		},
+		{
+			start:     "Secret",
+			wantAsked: []string{"Secret"},
+			wantErr:   true,
+		},
+		{
+			start:     "LoopA",
+			wantAsked: []string{"LoopA", "LoopB"},
+			wantErr:   true,
+		},
+	} {
+		unlocked, asked = test.unlocked, nil
+		rec := &lineRecorder{}
+		vm := &VirtualMachine{
+			Program:  pb.Program(),
+			Handler:  rec,
+			Vars:     NewMapVariableStorage(),
+			NodeGate: gate,
+		}
+		err := vm.Run(test.start)
+		if gotErr := errors.Is(err, ErrNodeGated); gotErr != test.wantErr {
+			t.Errorf("unlocked=%t: vm.Run(%q) = %v, want gated error %t", test.unlocked, test.start, err, test.wantErr)
+		}
+		if diff := cmp.Diff(rec.ids, test.wantIDs); diff != "" {
+			t.Errorf("unlocked=%t: vm.Run(%q) ids diff (-got +want):\n%s", test.unlocked, test.start, diff)
+		}
+		if diff := cmp.Diff(asked, test.wantAsked); diff != "" {
+			t.Errorf("unlocked=%t: vm.Run(%q) asked diff (-got +want):\n%s", test.unlocked, test.start, diff)
+		}
+	}
+}
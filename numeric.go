@@ -0,0 +1,49 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "math"
+
+// MaxFixedPoint is the largest useful value of VirtualMachine.FixedPoint:
+// float32 has about 7 significant decimal digits.
+const MaxFixedPoint = 6
+
+// Quantize rounds x to the given number of decimal places (clamped to
+// [0, MaxFixedPoint]), as VirtualMachine.FixedPoint does. The rounding is
+// done in float64 with math.Round, so it gives the same result on every
+// platform.
+func Quantize(x float32, places int) float32 {
+	places = min(max(places, 0), MaxFixedPoint)
+	scale := math.Pow10(places)
+	// The explicit conversion stops the multiplication being fused with
+	// anything else.
+	scaled := float64(float64(x) * scale)
+	return float32(math.Round(scaled) / scale)
+}
+
+// quantize applies FixedPoint to a number produced by a function or stored
+// in a variable. Values of other types are returned unchanged.
+func (vm *VirtualMachine) quantize(x any) any {
+	if vm.FixedPoint <= 0 {
+		return x
+	}
+	switch f := x.(type) {
+	case float32:
+		return Quantize(f, vm.FixedPoint)
+	case float64:
+		return Quantize(float32(f), vm.FixedPoint)
+	}
+	return x
+}
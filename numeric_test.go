@@ -0,0 +1,73 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestQuantize(t *testing.T) {
+	tests := []struct {
+		x      float32
+		places int
+		want   float32
+	}{
+		{x: 1.0 / 3, places: 2, want: 0.33},
+		{x: 2.0 / 3, places: 2, want: 0.67},
+		{x: -2.5, places: 0, want: -3},
+		{x: 0.30000001, places: 3, want: 0.3},
+		{x: 1.23456789, places: 10, want: 1.234568},
+		{x: 7.5, places: -1, want: 8},
+	}
+	for _, test := range tests {
+		if got := Quantize(test.x, test.places); got != test.want {
+			t.Errorf("Quantize(%v, %d) = %v, want %v", test.x, test.places, got, test.want)
+		}
+	}
+}
+
+func TestFixedPoint(t *testing.T) {
+	// $third = 1 / 3; $sum = 0.1 + 0.2 (+ 0.1 ...); line:sum {$third} {$sum}
+	b := NewProgramBuilder("Fixed")
+	n := b.Node("Start").
+		PushFloat(1).PushFloat(3).Call("Number.Divide", 2).StoreVariable("$third").Pop().
+		PushFloat(0.1)
+	for i := 0; i < 9; i++ {
+		n.PushFloat(0.1).Call("Number.Add", 2)
+	}
+	n.StoreVariable("$sum").Pop().
+		PushVariable("$third").PushVariable("$sum").Line("line:sum", 2)
+	prog := b.Program()
+
+	for _, test := range []struct {
+		fixedPoint int
+		want       []string
+	}{
+		{fixedPoint: 0, want: []string{"0.33333334", "1.0000001"}},
+		{fixedPoint: 2, want: []string{"0.33", "1"}},
+	} {
+		rec := &substRecorder{}
+		vars := NewMapVariableStorage()
+		vm := &VirtualMachine{Program: prog, Handler: rec, Vars: vars, FixedPoint: test.fixedPoint}
+		if err := vm.Run("Start"); err != nil {
+			t.Fatalf("FixedPoint = %d: vm.Run(Start) = %v", test.fixedPoint, err)
+		}
+		if diff := cmp.Diff(rec.lines[0].Substitutions, test.want); diff != "" {
+			t.Errorf("FixedPoint = %d: substitutions diff (-got +want):\n%s", test.fixedPoint, diff)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ErrBuiltinOpcode is returned by RegisterOpcode when the opcode is already
+// implemented by the VM.
+const ErrBuiltinOpcode = virtualMachineError("opcode is built in")
+
+// OpcodeFunc implements a custom opcode (see RegisterOpcode). It is called
+// with the instruction's operands. After it returns nil, the VM moves on to
+// the next instruction, unless the function jumped elsewhere with
+// OpcodeContext.JumpTo. Returning Stop stops the VM without error.
+type OpcodeFunc func(ctx *OpcodeContext, operands []*yarnpb.Operand) error
+
+// OpcodeContext gives custom opcodes access to the VM's state while they
+// execute. It is only valid during the call to the OpcodeFunc.
+type OpcodeContext struct {
+	vm     *VirtualMachine
+	jumped bool
+}
+
+// VM returns the virtual machine, e.g. for access to Vars or Handler.
+func (c *OpcodeContext) VM() *VirtualMachine { return c.vm }
+
+// Node returns the name of the current node.
+func (c *OpcodeContext) Node() string { return c.vm.state.node.Name }
+
+// Push pushes a value onto the stack. Values should be one of the standard
+// Yarn Spinner VM types: bool, float32, string, or nil.
+func (c *OpcodeContext) Push(x any) { c.vm.state.push(x) }
+
+// Pop removes the top value from the stack and returns it.
+func (c *OpcodeContext) Pop() (any, error) { return c.vm.state.pop() }
+
+// Peek returns the top value from the stack, without removing it.
+func (c *OpcodeContext) Peek() (any, error) { return c.vm.state.peek() }
+
+// JumpTo continues execution at a label in the current node, instead of the
+// next instruction.
+func (c *OpcodeContext) JumpTo(label string) error {
+	pc, ok := c.vm.state.node.Labels[label]
+	if !ok {
+		return fmt.Errorf("%q %w in node %q", label, ErrLabelNotFound, c.vm.state.node.Name)
+	}
+	c.vm.state.pc = int(pc)
+	c.jumped = true
+	return nil
+}
+
+// RegisterOpcode registers a function to execute instructions with an opcode
+// that the VM doesn't implement, such as vendor-specific instructions
+// emitted by a fork of the compiler. It returns an error wrapping
+// ErrBuiltinOpcode if the opcode is one of the standard opcodes. Registering
+// a nil function unregisters the opcode.
+//
+// Note that ValidateProgram still reports custom opcodes as invalid.
+func (vm *VirtualMachine) RegisterOpcode(code yarnpb.Instruction_OpCode, fn OpcodeFunc) error {
+	if code >= 0 && int(code) < len(dispatchTable) && dispatchTable[code] != nil {
+		return fmt.Errorf("%w: %v", ErrBuiltinOpcode, code)
+	}
+	if fn == nil {
+		delete(vm.opcodes, code)
+		return nil
+	}
+	if vm.opcodes == nil {
+		vm.opcodes = make(map[yarnpb.Instruction_OpCode]OpcodeFunc)
+	}
+	vm.opcodes[code] = fn
+	return nil
+}
+
+// execCustom executes an instruction with a registered opcode.
+func (vm *VirtualMachine) execCustom(inst *yarnpb.Instruction) error {
+	fn := vm.opcodes[inst.Opcode]
+	if fn == nil {
+		return fmt.Errorf("invalid opcode %v", inst.Opcode)
+	}
+	ctx := &OpcodeContext{vm: vm}
+	if err := fn(ctx, inst.Operands); err != nil {
+		return err
+	}
+	if !ctx.jumped {
+		vm.state.pc++
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	opDouble yarnpb.Instruction_OpCode = 100
+	opGoto   yarnpb.Instruction_OpCode = 101
+)
+
+func TestRegisterOpcode(t *testing.T) {
+	pb := NewProgramBuilder("Custom")
+	pb.Node("Start").
+		PushFloat(21).
+		Inst(opDouble).
+		StoreVariable("$x").
+		Pop().
+		Inst(opGoto, stringOperand("end")).
+		Line("line:skipped", 0).
+		Label("end").
+		Line("line:end", 0).
+		Stop()
+	prog := pb.Program()
+
+	rec := &lineRecorder{}
+	vars := NewMapVariableStorage()
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: rec,
+		Vars:    vars,
+	}
+
+	if err := vm.Run("Start"); err == nil {
+		t.Fatal("vm.Run(Start) = nil error before registering opcodes")
+	}
+
+	if err := vm.RegisterOpcode(yarnpb.Instruction_JUMP_TO, nil); !errors.Is(err, ErrBuiltinOpcode) {
+		t.Errorf("vm.RegisterOpcode(JUMP_TO) = %v, want %v", err, ErrBuiltinOpcode)
+	}
+	if err := vm.RegisterOpcode(opDouble, func(ctx *OpcodeContext, _ []*yarnpb.Operand) error {
+		x, err := ctx.Pop()
+		if err != nil {
+			return err
+		}
+		ctx.Push(x.(float32) * 2)
+		return nil
+	}); err != nil {
+		t.Fatalf("vm.RegisterOpcode(opDouble) = %v", err)
+	}
+	if err := vm.RegisterOpcode(opGoto, func(ctx *OpcodeContext, operands []*yarnpb.Operand) error {
+		return ctx.JumpTo(operands[0].GetStringValue())
+	}); err != nil {
+		t.Fatalf("vm.RegisterOpcode(opGoto) = %v", err)
+	}
+
+	rec.ids = nil
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got, _ := vars.GetValue("$x"); got != float32(42) {
+		t.Errorf("$x = %v, want 42", got)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:end"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+}
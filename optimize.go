@@ -0,0 +1,299 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"sync/atomic"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// OptimizeStats counts the changes made by Optimize.
+type OptimizeStats struct {
+	// Before and After are the total number of instructions in the program.
+	Before, After int
+
+	LabelsRemoved      int // labels that nothing referred to
+	JumpsThreaded      int // jumps retargeted past jump-to-jump chains
+	JumpsRemoved       int // jumps to the next instruction
+	BranchesFolded     int // JUMP_IF_FALSE instructions with constant conditions
+	UnreachableRemoved int // instructions that could never run
+}
+
+var optimizeOnLoad atomic.Bool
+
+// OptimizeOnLoad sets whether programs are optimized (see Optimize) as they
+// are loaded (by LoadProgramFile, LoadFiles, and the other Load functions).
+// It is off by default.
+func OptimizeOnLoad(on bool) { optimizeOnLoad.Store(on) }
+
+// Optimize returns a copy of the program with fewer instructions, but the
+// same behaviour. It is a peephole optimizer that, in each node:
+//
+//   - removes labels that no instruction refers to,
+//   - retargets jumps to unconditional jumps (JUMP_TO) at their final
+//     destination, and removes jumps to the next instruction,
+//   - folds JUMP_IF_FALSE instructions that test a constant (PUSH_BOOL), and
+//   - removes instructions that can't be reached from the start of the node,
+//     or from any label.
+//
+// Since instructions move, saved VM state (snapshots and bookmarks) from an
+// unoptimized program can't be restored with the optimized program, or vice
+// versa. Precompile (if used) should be given the optimized program.
+func Optimize(prog *yarnpb.Program) (*yarnpb.Program, *OptimizeStats) {
+	out := proto.Clone(prog).(*yarnpb.Program)
+	return out, optimizeProgram(out)
+}
+
+// optimizeProgram optimizes a program in place.
+func optimizeProgram(prog *yarnpb.Program) *OptimizeStats {
+	stats := new(OptimizeStats)
+	for _, node := range prog.Nodes {
+		stats.Before += len(node.Instructions)
+		optimizeNode(node, stats)
+		stats.After += len(node.Instructions)
+	}
+	return stats
+}
+
+// optimizeNode optimizes a node in place, until nothing changes.
+func optimizeNode(node *yarnpb.Node, stats *OptimizeStats) {
+	for {
+		removeUnusedLabels(node, stats)
+		changed := threadJumps(node, stats)
+		changed = foldBranches(node, stats) || changed
+		changed = removeJumpsToNext(node, stats) || changed
+		changed = removeUnreachable(node, stats) || changed
+		if !changed {
+			return
+		}
+	}
+}
+
+// removeUnusedLabels removes labels not named by any string operand in the
+// node. (Labels are named by jumps and options, but also by strings pushed
+// for JUMP, or used by custom opcodes.)
+func removeUnusedLabels(node *yarnpb.Node, stats *OptimizeStats) {
+	used := make(map[string]bool)
+	for _, inst := range node.Instructions {
+		for _, op := range inst.GetOperands() {
+			if s, ok := op.GetValue().(*yarnpb.Operand_StringValue); ok {
+				used[s.StringValue] = true
+			}
+		}
+	}
+	for l := range node.Labels {
+		if !used[l] {
+			delete(node.Labels, l)
+			stats.LabelsRemoved++
+		}
+	}
+}
+
+// labelTarget returns the pc of a label, and whether it is within the node.
+func labelTarget(node *yarnpb.Node, label string) (int, bool) {
+	pc, ok := node.Labels[label]
+	if !ok || pc < 0 || int(pc) >= len(node.Instructions) {
+		return 0, false
+	}
+	return int(pc), true
+}
+
+// jumpTarget returns the label of an unconditional jump, if inst is one.
+func jumpTarget(inst *yarnpb.Instruction) (string, bool) {
+	if inst.GetOpcode() != yarnpb.Instruction_JUMP_TO || len(inst.Operands) == 0 {
+		return "", false
+	}
+	return inst.Operands[0].GetStringValue(), true
+}
+
+// threadJumps retargets jumps to unconditional jumps.
+func threadJumps(node *yarnpb.Node, stats *OptimizeStats) bool {
+	changed := false
+	for _, inst := range node.Instructions {
+		switch inst.GetOpcode() {
+		case yarnpb.Instruction_JUMP_TO, yarnpb.Instruction_JUMP_IF_FALSE:
+		default:
+			continue
+		}
+		if len(inst.Operands) == 0 {
+			continue
+		}
+		label := inst.Operands[0].GetStringValue()
+		seen := map[string]bool{label: true}
+		for {
+			pc, ok := labelTarget(node, label)
+			if !ok {
+				break
+			}
+			next, ok := jumpTarget(node.Instructions[pc])
+			if !ok || seen[next] {
+				break
+			}
+			seen[next] = true
+			label = next
+		}
+		if label != inst.Operands[0].GetStringValue() {
+			inst.Operands[0] = stringOperand(label)
+			stats.JumpsThreaded++
+			changed = true
+		}
+	}
+	return changed
+}
+
+// foldBranches folds PUSH_BOOL followed by JUMP_IF_FALSE.
+func foldBranches(node *yarnpb.Node, stats *OptimizeStats) bool {
+	targets := labelsByPC(node)
+	del := make([]bool, len(node.Instructions))
+	changed := false
+	insts := node.Instructions
+	for pc := 0; pc+1 < len(insts); pc++ {
+		if insts[pc].GetOpcode() != yarnpb.Instruction_PUSH_BOOL || len(insts[pc].Operands) == 0 {
+			continue
+		}
+		jif := insts[pc+1]
+		if jif.GetOpcode() != yarnpb.Instruction_JUMP_IF_FALSE || len(targets[pc+1]) > 0 {
+			continue
+		}
+		stats.BranchesFolded++
+		changed = true
+		if !insts[pc].Operands[0].GetBoolValue() {
+			// Always jumps. The value stays on the stack, as before.
+			jif.Opcode = yarnpb.Instruction_JUMP_TO
+			pc++
+			continue
+		}
+		// Never jumps. The value can only be removed along with the POP
+		// that follows.
+		del[pc+1] = true
+		if pc+2 < len(insts) && insts[pc+2].GetOpcode() == yarnpb.Instruction_POP && len(targets[pc+2]) == 0 {
+			del[pc], del[pc+2] = true, true
+		}
+		pc += 2
+	}
+	if changed {
+		deleteInstructions(node, del)
+	}
+	return changed
+}
+
+// removeJumpsToNext removes jumps to the next instruction.
+func removeJumpsToNext(node *yarnpb.Node, stats *OptimizeStats) bool {
+	del := make([]bool, len(node.Instructions))
+	changed := false
+	for pc, inst := range node.Instructions {
+		label, ok := jumpTarget(inst)
+		if !ok {
+			continue
+		}
+		if dest, ok := node.Labels[label]; !ok || int(dest) != pc+1 {
+			continue
+		}
+		del[pc] = true
+		stats.JumpsRemoved++
+		changed = true
+	}
+	if changed {
+		deleteInstructions(node, del)
+	}
+	return changed
+}
+
+// removeUnreachable removes instructions that can't be reached from the
+// start of the node or any label.
+func removeUnreachable(node *yarnpb.Node, stats *OptimizeStats) bool {
+	n := len(node.Instructions)
+	reached := make([]bool, n)
+	work := []int{0}
+	for _, pc := range node.Labels {
+		work = append(work, int(pc))
+	}
+	for len(work) > 0 {
+		pc := work[len(work)-1]
+		work = work[:len(work)-1]
+		if pc < 0 || pc >= n || reached[pc] {
+			continue
+		}
+		reached[pc] = true
+		inst := node.Instructions[pc]
+		switch inst.GetOpcode() {
+		case yarnpb.Instruction_JUMP_TO:
+			if len(inst.Operands) > 0 {
+				if dest, ok := labelTarget(node, inst.Operands[0].GetStringValue()); ok {
+					work = append(work, dest)
+				}
+			}
+		case yarnpb.Instruction_JUMP_IF_FALSE:
+			if len(inst.Operands) > 0 {
+				if dest, ok := labelTarget(node, inst.Operands[0].GetStringValue()); ok {
+					work = append(work, dest)
+				}
+			}
+			work = append(work, pc+1)
+		case yarnpb.Instruction_JUMP, yarnpb.Instruction_STOP:
+			// Destinations of JUMP are labels, which are already reached.
+		default:
+			work = append(work, pc+1)
+		}
+	}
+	changed := false
+	for _, r := range reached {
+		if !r {
+			stats.UnreachableRemoved++
+			changed = true
+		}
+	}
+	if !changed {
+		return false
+	}
+	for pc := range reached {
+		reached[pc] = !reached[pc]
+	}
+	deleteInstructions(node, reached)
+	return true
+}
+
+// deleteInstructions deletes instructions from a node. Labels referring to a
+// deleted instruction then refer to the next instruction. Since running off
+// the end of a node stops the VM, a STOP is added for labels that would
+// otherwise refer to the end of the node.
+func deleteInstructions(node *yarnpb.Node, del []bool) {
+	newPC := make([]int32, len(node.Instructions)+1)
+	insts := node.Instructions[:0]
+	for pc, inst := range node.Instructions {
+		newPC[pc] = int32(len(insts))
+		if !del[pc] {
+			insts = append(insts, inst)
+		}
+	}
+	end := int32(len(insts))
+	newPC[len(node.Instructions)] = end
+	clear(node.Instructions[len(insts):])
+	node.Instructions = insts
+
+	needStop := false
+	for l, pc := range node.Labels {
+		if pc < 0 || int(pc) >= len(newPC) {
+			continue
+		}
+		node.Labels[l] = newPC[pc]
+		needStop = needStop || newPC[pc] == end
+	}
+	if needStop {
+		node.Instructions = append(node.Instructions, &yarnpb.Instruction{Opcode: yarnpb.Instruction_STOP})
+	}
+}
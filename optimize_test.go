@@ -0,0 +1,124 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+func optimizerProgram() *yarnpb.Program {
+	pb := NewProgramBuilder("Optimize")
+	pb.Node("True").
+		PushBool(true).
+		JumpIfFalse("else").
+		Pop().
+		Line("line:a", 0).
+		JumpTo("mid").
+		Label("else").
+		Pop().
+		Line("line:b", 0).
+		JumpTo("end").
+		Label("mid").
+		JumpTo("end").
+		Label("end").
+		Label("unused").
+		Line("line:c", 0).
+		Stop().
+		Line("line:dead", 0).
+		JumpTo("end")
+	pb.Node("False").
+		PushBool(false).
+		JumpIfFalse("x").
+		Pop().
+		Line("line:skipped", 0).
+		Label("x").
+		Pop().
+		Line("line:y", 0)
+	return pb.Program()
+}
+
+func runLines(t *testing.T, prog *yarnpb.Program, node string) []string {
+	t.Helper()
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: rec,
+		Vars:    NewMapVariableStorage(),
+	}
+	if err := vm.Run(node); err != nil {
+		t.Fatalf("vm.Run(%s) = %v", node, err)
+	}
+	return rec.ids
+}
+
+func TestOptimize(t *testing.T) {
+	prog := optimizerProgram()
+	opt, stats := Optimize(prog)
+
+	want := &OptimizeStats{
+		Before:             19,
+		After:              6,
+		LabelsRemoved:      5,
+		JumpsThreaded:      1,
+		JumpsRemoved:       4,
+		BranchesFolded:     2,
+		UnreachableRemoved: 6,
+	}
+	if diff := cmp.Diff(stats, want); diff != "" {
+		t.Errorf("Optimize stats diff (-got +want):\n%s", diff)
+	}
+	if diffs := ValidateProgram(opt); len(diffs) != 0 {
+		t.Errorf("ValidateProgram(optimized) = %v", diffs)
+	}
+
+	for _, node := range []string{"True", "False"} {
+		if diff := cmp.Diff(runLines(t, opt, node), runLines(t, prog, node)); diff != "" {
+			t.Errorf("%s: optimized lines diff (-got +want):\n%s", node, diff)
+		}
+	}
+
+	wantTrue := []string{
+		`RUN_LINE "line:a" 0`,
+		`RUN_LINE "line:c" 0`,
+		`STOP`,
+	}
+	var gotTrue []string
+	for _, inst := range opt.Nodes["True"].Instructions {
+		gotTrue = append(gotTrue, FormatInstruction(inst))
+	}
+	if diff := cmp.Diff(gotTrue, wantTrue); diff != "" {
+		t.Errorf("optimized True node diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestOptimizeOnLoad(t *testing.T) {
+	yarnc, err := proto.Marshal(optimizerProgram())
+	if err != nil {
+		t.Fatalf("proto.Marshal = %v", err)
+	}
+	OptimizeOnLoad(true)
+	defer OptimizeOnLoad(false)
+	prog, err := LoadProgramBytes(yarnc)
+	if err != nil {
+		t.Fatalf("LoadProgramBytes = %v", err)
+	}
+	if got, want := len(prog.Nodes["True"].Instructions), 3; got != want {
+		t.Errorf("loaded True node has %d instructions, want %d", got, want)
+	}
+}
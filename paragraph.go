@@ -0,0 +1,159 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "fmt"
+
+// Paragraph is a batch of consecutive lines from the same speaker.
+type Paragraph struct {
+	// Speaker is the speaker's name (see Line.Accessible), or empty for
+	// lines without a speaker.
+	Speaker string
+
+	// Lines are the lines in the order they were delivered by the VM.
+	Lines []Line
+}
+
+// ParagraphLineHandler is an optional interface for dialogue handlers wrapped
+// by ParagraphHandler. Batches of lines are delivered to Paragraph instead of
+// Line.
+type ParagraphLineHandler interface {
+	Paragraph(p *Paragraph) error
+}
+
+var (
+	_ DialogueHandler      = &ParagraphHandler{}
+	_ CommandResultHandler = &ParagraphHandler{}
+	_ SkipHandler          = &ParagraphHandler{}
+)
+
+// ParagraphHandler is a DialogueHandler that coalesces consecutive lines from
+// the same speaker into a Paragraph, for UIs that show paragraphs rather than
+// one line at a time. Lines are buffered until the speaker changes, or any
+// other event (options, a command, the start or end of a node, or the end of
+// the dialogue) arrives, at which point the buffered lines are delivered
+// before the event. PrepareForLines is passed through immediately.
+//
+// If the embedded DialogueHandler implements ParagraphLineHandler, each batch
+// is delivered to Paragraph; otherwise the lines in the batch are delivered to
+// Line one at a time.
+type ParagraphHandler struct {
+	DialogueHandler
+	StringTable *StringTable
+
+	// MaxLines, if positive, limits the number of lines in a paragraph.
+	MaxLines int
+
+	pending *Paragraph
+}
+
+// Flush delivers any buffered lines.
+func (h *ParagraphHandler) Flush() error {
+	p := h.pending
+	if p == nil {
+		return nil
+	}
+	h.pending = nil
+	if plh, ok := h.DialogueHandler.(ParagraphLineHandler); ok {
+		return plh.Paragraph(p)
+	}
+	for _, line := range p.Lines {
+		if err := h.DialogueHandler.Line(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Line buffers the line, first delivering any buffered lines from a
+// different speaker.
+func (h *ParagraphHandler) Line(line Line) error {
+	as, err := h.StringTable.Render(line)
+	if err != nil {
+		return fmt.Errorf("finding speaker for line %q: %w", line.ID, err)
+	}
+	speaker, _ := findSpeaker(as)
+	if h.pending != nil && (h.pending.Speaker != speaker || (h.MaxLines > 0 && len(h.pending.Lines) >= h.MaxLines)) {
+		if err := h.Flush(); err != nil {
+			return err
+		}
+	}
+	if h.pending == nil {
+		h.pending = &Paragraph{Speaker: speaker}
+	}
+	// The line is kept after Line returns, so it must be copied (see
+	// VirtualMachine.ReuseEvents).
+	h.pending.Lines = append(h.pending.Lines, line.Clone())
+	return nil
+}
+
+// NodeStart delivers any buffered lines, then passes the event on.
+func (h *ParagraphHandler) NodeStart(nodeName string) error {
+	if err := h.Flush(); err != nil {
+		return err
+	}
+	return h.DialogueHandler.NodeStart(nodeName)
+}
+
+// Options delivers any buffered lines, then passes the options on.
+func (h *ParagraphHandler) Options(options []Option) (int, error) {
+	if err := h.Flush(); err != nil {
+		return -1, err
+	}
+	return h.DialogueHandler.Options(options)
+}
+
+// Command delivers any buffered lines, then passes the command on.
+func (h *ParagraphHandler) Command(command string) error {
+	if err := h.Flush(); err != nil {
+		return err
+	}
+	return h.DialogueHandler.Command(command)
+}
+
+// CommandResult delivers any buffered lines, then passes the command to the
+// embedded handler's CommandResult method, if it has one.
+func (h *ParagraphHandler) CommandResult(command string) (any, error) {
+	if err := h.Flush(); err != nil {
+		return nil, err
+	}
+	return forwardCommandResult(h.DialogueHandler, command)
+}
+
+// SkipLine delivers any buffered lines, then passes the skipped line to the
+// embedded handler's SkipLine method, if it has one. Skipped lines are not
+// coalesced.
+func (h *ParagraphHandler) SkipLine(line Line) error {
+	if err := h.Flush(); err != nil {
+		return err
+	}
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// NodeComplete delivers any buffered lines, then passes the event on.
+func (h *ParagraphHandler) NodeComplete(nodeName string) error {
+	if err := h.Flush(); err != nil {
+		return err
+	}
+	return h.DialogueHandler.NodeComplete(nodeName)
+}
+
+// DialogueComplete delivers any buffered lines, then passes the event on.
+func (h *ParagraphHandler) DialogueComplete() error {
+	if err := h.Flush(); err != nil {
+		return err
+	}
+	return h.DialogueHandler.DialogueComplete()
+}
@@ -0,0 +1,125 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// paragraphRecorder records paragraphs and other events as strings.
+type paragraphRecorder struct {
+	FakeDialogueHandler
+	events []string
+}
+
+func (r *paragraphRecorder) Paragraph(p *Paragraph) error {
+	var ids []string
+	for _, l := range p.Lines {
+		ids = append(ids, l.ID)
+	}
+	r.events = append(r.events, fmt.Sprintf("paragraph %q: %s", p.Speaker, strings.Join(ids, " ")))
+	return nil
+}
+
+func (r *paragraphRecorder) Line(line Line) error {
+	r.events = append(r.events, "line "+line.ID)
+	return nil
+}
+
+func (r *paragraphRecorder) Command(command string) error {
+	r.events = append(r.events, "command "+command)
+	return nil
+}
+
+func (r *paragraphRecorder) DialogueComplete() error {
+	r.events = append(r.events, "complete")
+	return nil
+}
+
+// paragraphLinesOnly hides the Paragraph method of a paragraphRecorder.
+type paragraphLinesOnly struct {
+	DialogueHandler
+}
+
+func TestParagraphHandler(t *testing.T) {
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"1": {ID: "1", Text: "Ava: Hello."},
+		"2": {ID: "2", Text: "Ava: Lovely weather."},
+		"3": {ID: "3", Text: "Bo: Is it?"},
+		"4": {ID: "4", Text: "Ava: Well, no."},
+		"5": {ID: "5", Text: "It rains."},
+		"6": {ID: "6", Text: "It pours."},
+	}}
+	play := func(h *ParagraphHandler) {
+		t.Helper()
+		for _, id := range []string{"1", "2", "3", "4"} {
+			if err := h.Line(Line{ID: id}); err != nil {
+				t.Fatalf("Line(%s) = %v", id, err)
+			}
+		}
+		if err := h.Command("rain"); err != nil {
+			t.Fatalf("Command(rain) = %v", err)
+		}
+		for _, id := range []string{"5", "6"} {
+			if err := h.Line(Line{ID: id}); err != nil {
+				t.Fatalf("Line(%s) = %v", id, err)
+			}
+		}
+		if err := h.DialogueComplete(); err != nil {
+			t.Fatalf("DialogueComplete() = %v", err)
+		}
+	}
+
+	rec := &paragraphRecorder{}
+	play(&ParagraphHandler{DialogueHandler: rec, StringTable: st})
+	want := []string{
+		`paragraph "Ava": 1 2`,
+		`paragraph "Bo": 3`,
+		`paragraph "Ava": 4`,
+		`command rain`,
+		`paragraph "": 5 6`,
+		`complete`,
+	}
+	if diff := cmp.Diff(rec.events, want); diff != "" {
+		t.Errorf("events diff (-got +want):\n%s", diff)
+	}
+
+	rec = &paragraphRecorder{}
+	play(&ParagraphHandler{DialogueHandler: paragraphLinesOnly{rec}, StringTable: st})
+	want = []string{
+		"line 1", "line 2", "line 3", "line 4",
+		"command rain",
+		"line 5", "line 6",
+		"complete",
+	}
+	if diff := cmp.Diff(rec.events, want); diff != "" {
+		t.Errorf("events without Paragraph diff (-got +want):\n%s", diff)
+	}
+
+	rec = &paragraphRecorder{}
+	play(&ParagraphHandler{DialogueHandler: rec, StringTable: st, MaxLines: 1})
+	if diff := cmp.Diff(rec.events[:2], []string{`paragraph "Ava": 1`, `paragraph "Ava": 2`}); diff != "" {
+		t.Errorf("events with MaxLines = 1 diff (-got +want):\n%s", diff)
+	}
+
+	h := &ParagraphHandler{DialogueHandler: rec, StringTable: st}
+	if err := h.Line(Line{ID: "nope"}); err == nil {
+		t.Errorf("Line(nope) = nil, want error")
+	}
+}
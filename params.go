@@ -0,0 +1,147 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ParamsHeader is the node header that declares a node's parameters, as a
+// comma-separated list of variable names:
+//
+//	title: ShopGreeting
+//	params: $customer, $visits
+//	---
+//	Shopkeeper: Welcome back, {$customer}! That's visit number {$visits}.
+//	===
+//
+// Such a node can be entered with arguments, using SetNodeWithArgs or
+// RunWithArgs, or from dialogue with the command
+//
+//	<<jump ShopGreeting("Ava", 3)>>
+//
+// The arguments are bound to the parameters for as long as the node runs:
+// reading or setting a parameter uses the argument, rather than the
+// variable storage, and the argument is discarded when the node ends.
+// Entering the node without arguments (e.g. with an ordinary jump) leaves
+// the parameters unbound, so they read and write variables as usual.
+const ParamsHeader = "params"
+
+// ErrNodeArgs is returned when the arguments given to a node don't match its
+// parameters.
+const ErrNodeArgs = virtualMachineError("wrong number of node arguments")
+
+// NodeParams returns the parameters declared by the node's params header.
+func NodeParams(node *yarnpb.Node) []string {
+	return headerList(node, ParamsHeader)
+}
+
+// bindParams binds args to the node's parameters. If args is nil, the node
+// was entered without arguments, and there are no bindings.
+func bindParams(node *yarnpb.Node, args []any) (map[string]any, error) {
+	if args == nil {
+		return nil, nil
+	}
+	params := NodeParams(node)
+	if len(args) != len(params) {
+		return nil, fmt.Errorf("%w: node %q takes %d (%s), got %d", ErrNodeArgs, node.Name, len(params), strings.Join(params, ", "), len(args))
+	}
+	locals := make(map[string]any, len(params))
+	for i, p := range params {
+		locals[p] = normalizeValue(args[i])
+	}
+	return locals, nil
+}
+
+// SetNodeWithArgs is like SetNode, but binds the arguments to the node's
+// parameters (see ParamsHeader). Arguments should be bool, float32 (or
+// float64), or string.
+func (vm *VirtualMachine) SetNodeWithArgs(name string, args ...any) error {
+	if args == nil {
+		args = []any{}
+	}
+	return vm.setNode(name, args)
+}
+
+// RunWithArgs is like Run, but binds the arguments to the start node's
+// parameters (see ParamsHeader).
+func (vm *VirtualMachine) RunWithArgs(startNode string, args ...any) error {
+	return vm.run(func() error { return vm.SetNodeWithArgs(startNode, args...) })
+}
+
+// parseNodeCall parses a node call of the form Name(arg1, arg2, ...) into
+// the node name and the source of each argument expression.
+func parseNodeCall(s string) (name string, args []string, ok bool) {
+	s = strings.TrimSpace(s)
+	name, rest, found := strings.Cut(s, "(")
+	if !found || !strings.HasSuffix(rest, ")") {
+		return "", nil, false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsAny(name, " \t\"") {
+		return "", nil, false
+	}
+	inner := strings.TrimSpace(rest[:len(rest)-1])
+	args = []string{}
+	if inner == "" {
+		return name, args, true
+	}
+	// Split on commas that aren't inside strings or parentheses.
+	depth, quoted, escaped, start := 0, false, false, 0
+	for i, r := range inner {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case quoted:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			args = append(args, strings.TrimSpace(inner[start:i]))
+			start = i + 1
+		}
+	}
+	if quoted || depth != 0 {
+		return "", nil, false
+	}
+	return name, append(args, strings.TrimSpace(inner[start:])), true
+}
+
+// execNodeCall evaluates the arguments of a node call (from parseNodeCall)
+// and jumps to the node.
+func (vm *VirtualMachine) execNodeCall(name string, argSrcs []string) error {
+	args := make([]any, len(argSrcs))
+	for i, src := range argSrcs {
+		e, err := CompileExpression(src)
+		if err != nil {
+			return fmt.Errorf("node %q argument %d: %w", name, i, err)
+		}
+		if args[i], err = vm.Evaluate(e); err != nil {
+			return fmt.Errorf("node %q argument %d: %w", name, i, err)
+		}
+	}
+	if err := vm.SetNodeWithArgs(name, args...); err != nil {
+		return fmt.Errorf("SetNode: %w", err)
+	}
+	return nil
+}
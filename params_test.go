@@ -0,0 +1,119 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// substRecorder records lines with their substitutions.
+type substRecorder struct {
+	FakeDialogueHandler
+	lines []Line
+}
+
+func (r *substRecorder) Line(line Line) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func TestNodeParams(t *testing.T) {
+	pb := NewProgramBuilder("Params").InitialValue("$visits", float32(99))
+	pb.Node("Start").
+		PushString("Ava").StoreVariable("$name").Pop().
+		Command(`jump ShopGreeting($name, 1 + 2)`, 0)
+	pb.Node("ShopGreeting").Header(ParamsHeader, "$customer, $visits").
+		PushVariable("$customer").PushVariable("$visits").Line("line:greet", 2).
+		PushFloat(4).StoreVariable("$visits").Pop().
+		PushVariable("$visits").Line("line:again", 1).
+		RunNode("End")
+	pb.Node("End").
+		PushVariable("$visits").Line("line:end", 1)
+	prog := pb.Program()
+
+	if diff := cmp.Diff(NodeParams(prog.Nodes["ShopGreeting"]), []string{"$customer", "$visits"}); diff != "" {
+		t.Errorf("NodeParams diff (-got +want):\n%s", diff)
+	}
+
+	rec := &substRecorder{}
+	vars := NewMapVariableStorage()
+	vm := &VirtualMachine{Program: prog, Handler: rec, Vars: vars}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := []Line{
+		{ID: "line:greet", Substitutions: []string{"Ava", "3"}},
+		{ID: "line:again", Substitutions: []string{"4"}},
+		{ID: "line:end", Substitutions: []string{"99"}},
+	}
+	if diff := cmp.Diff(rec.lines, want); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if _, ok := vars.GetValue("$visits"); ok {
+		t.Errorf("vars.GetValue($visits) = _, true, want false (only the parameter was set)")
+	}
+
+	// Entering without arguments leaves the parameters unbound.
+	rec = &substRecorder{}
+	vm.Handler = rec
+	if err := vm.Run("ShopGreeting"); err != nil {
+		t.Fatalf("vm.Run(ShopGreeting) = %v", err)
+	}
+	if got, ok := vars.GetValue("$visits"); !ok || got != float32(4) {
+		t.Errorf("vars.GetValue($visits) = %v, %t, want 4, true", got, ok)
+	}
+
+	if err := vm.RunWithArgs("ShopGreeting", "Bo"); !errors.Is(err, ErrNodeArgs) {
+		t.Errorf("vm.RunWithArgs(ShopGreeting, Bo) = %v, want %v", err, ErrNodeArgs)
+	}
+
+	// Parameters are saved in snapshots.
+	if err := vm.SetNodeWithArgs("ShopGreeting", "Cy", float32(7)); err != nil {
+		t.Fatalf("vm.SetNodeWithArgs(ShopGreeting, Cy, 7) = %v", err)
+	}
+	snap := vm.Snapshot()
+	if diff := cmp.Diff(snap.Locals, map[string]any{"$customer": "Cy", "$visits": float32(7)}); diff != "" {
+		t.Errorf("snap.Locals diff (-got +want):\n%s", diff)
+	}
+	if got, err := vm.Evaluate(MustCompileExpression("$visits * 2")); err != nil || got != float32(14) {
+		t.Errorf("vm.Evaluate($visits * 2) = %v, %v, want 14, nil", got, err)
+	}
+}
+
+func TestParseNodeCall(t *testing.T) {
+	tests := []struct {
+		in   string
+		name string
+		args []string
+		ok   bool
+	}{
+		{in: " Shop()", name: "Shop", args: []string{}, ok: true},
+		{in: `Shop("Ava", 3)`, name: "Shop", args: []string{`"Ava"`, "3"}, ok: true},
+		{in: `Shop("a, \"b\")", f(1, 2) + 1, $x)`, name: "Shop", args: []string{`"a, \"b\")"`, "f(1, 2) + 1", "$x"}, ok: true},
+		{in: "Shop"},
+		{in: `Shop("unterminated)`},
+		{in: "Shop(f(1)"},
+		{in: "(1)"},
+	}
+	for _, test := range tests {
+		name, args, ok := parseNodeCall(test.in)
+		if name != test.name || ok != test.ok || !cmp.Equal(args, test.args) {
+			t.Errorf("parseNodeCall(%q) = %q, %q, %t, want %q, %q, %t", test.in, name, args, ok, test.name, test.args, test.ok)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// Pass is an analysis or transformation of a program and its string table,
+// such as a studio-specific lint rule. Passes can be run in sequence with a
+// Pipeline, and registered (with RegisterPass) so that tools such as
+// yarnverify run them.
+type Pass interface {
+	// Name identifies the pass, e.g. "studio/no-empty-nodes".
+	Name() string
+
+	// Run analyses (and optionally transforms) a program and string table.
+	// The string table may be nil. Run must not modify prog or st; to
+	// transform them, return modified copies in the result. An error means
+	// the pass couldn't run at all; problems found in the program should be
+	// reported as diagnostics instead.
+	Run(prog *yarnpb.Program, st *StringTable) (*PassResult, error)
+}
+
+// PassResult is the outcome of running a Pass.
+type PassResult struct {
+	Diagnostics []Diagnostic
+
+	// Program and StringTable, if not nil, replace the program and string
+	// table given to later passes in a Pipeline.
+	Program     *yarnpb.Program
+	StringTable *StringTable
+}
+
+// CheckPass returns a Pass that only reports diagnostics, for the common
+// case of lint rules:
+//
+//	yarn.RegisterPass(yarn.CheckPass("studio/todo", func(prog *yarnpb.Program, st *yarn.StringTable) []yarn.Diagnostic {
+//		...
+//	}))
+func CheckPass(name string, check func(prog *yarnpb.Program, st *StringTable) []Diagnostic) Pass {
+	return checkPass{name: name, check: check}
+}
+
+type checkPass struct {
+	name  string
+	check func(*yarnpb.Program, *StringTable) []Diagnostic
+}
+
+func (p checkPass) Name() string { return p.name }
+
+func (p checkPass) Run(prog *yarnpb.Program, st *StringTable) (*PassResult, error) {
+	return &PassResult{Diagnostics: p.check(prog, st)}, nil
+}
+
+// Pipeline runs passes in order, giving each the program and string table
+// as transformed by the passes before it.
+type Pipeline struct {
+	Passes []Pass
+
+	// StopOnError stops the pipeline after any pass that reports a
+	// diagnostic with SeverityError, since later passes may depend on a
+	// well-formed program.
+	StopOnError bool
+}
+
+// PipelineResult is the outcome of running a Pipeline.
+type PipelineResult struct {
+	// Diagnostics from all passes that ran, in order. Each has Pass set to
+	// the name of the pass that reported it.
+	Diagnostics []Diagnostic
+
+	// Program and StringTable are the final (possibly transformed) program
+	// and string table.
+	Program     *yarnpb.Program
+	StringTable *StringTable
+}
+
+// Run runs the passes. If a pass fails, Run returns the results so far, and
+// an error naming the pass.
+func (p *Pipeline) Run(prog *yarnpb.Program, st *StringTable) (*PipelineResult, error) {
+	res := &PipelineResult{Program: prog, StringTable: st}
+	for _, pass := range p.Passes {
+		pr, err := pass.Run(res.Program, res.StringTable)
+		if err != nil {
+			return res, fmt.Errorf("pass %q: %w", pass.Name(), err)
+		}
+		if pr == nil {
+			continue
+		}
+		for _, d := range pr.Diagnostics {
+			d.Pass = pass.Name()
+			res.Diagnostics = append(res.Diagnostics, d)
+		}
+		if pr.Program != nil {
+			res.Program = pr.Program
+		}
+		if pr.StringTable != nil {
+			res.StringTable = pr.StringTable
+		}
+		if p.StopOnError && HasErrors(pr.Diagnostics) {
+			break
+		}
+	}
+	return res, nil
+}
+
+var (
+	passesMu sync.RWMutex
+	passes   = make(map[string]*registeredPass)
+)
+
+// registeredPass wraps a registered pass, so that unregistering doesn't need
+// to compare passes (which may not be comparable).
+type registeredPass struct{ Pass }
+
+// RegisterPass registers a pass so that tools can find it by name. It is
+// typically called from an init function in the package providing the pass,
+// so that importing the package (or loading it as a plugin) is enough to
+// make the pass available. It panics if a pass with the same name is
+// already registered. The returned function unregisters the pass.
+func RegisterPass(p Pass) (unregister func()) {
+	name := p.Name()
+	passesMu.Lock()
+	defer passesMu.Unlock()
+	if _, dup := passes[name]; dup {
+		panic(fmt.Sprintf("yarn: RegisterPass called twice for pass %q", name))
+	}
+	rp := &registeredPass{p}
+	passes[name] = rp
+	return func() {
+		passesMu.Lock()
+		defer passesMu.Unlock()
+		if passes[name] == rp {
+			delete(passes, name)
+		}
+	}
+}
+
+// LookupPass returns the registered pass with the given name, or nil.
+func LookupPass(name string) Pass {
+	passesMu.RLock()
+	defer passesMu.RUnlock()
+	if rp := passes[name]; rp != nil {
+		return rp.Pass
+	}
+	return nil
+}
+
+// RegisteredPasses returns the registered passes, sorted by name.
+func RegisteredPasses() []Pass {
+	passesMu.RLock()
+	defer passesMu.RUnlock()
+	names := make([]string, 0, len(passes))
+	for name := range passes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := make([]Pass, 0, len(names))
+	for _, name := range names {
+		out = append(out, passes[name].Pass)
+	}
+	return out
+}
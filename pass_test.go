@@ -0,0 +1,131 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+// dropNodePass is a transforming pass that removes a node.
+type dropNodePass struct{ node string }
+
+func (p dropNodePass) Name() string { return "test/drop-" + p.node }
+
+func (p dropNodePass) Run(prog *yarnpb.Program, st *StringTable) (*PassResult, error) {
+	if prog.Nodes[p.node] == nil {
+		return nil, errors.New("no such node")
+	}
+	out := proto.Clone(prog).(*yarnpb.Program)
+	delete(out.Nodes, p.node)
+	return &PassResult{Program: out}, nil
+}
+
+// countNodes reports the number of nodes, so transforms can be observed.
+var countNodes = CheckPass("test/count", func(prog *yarnpb.Program, st *StringTable) []Diagnostic {
+	sev := SeverityInfo
+	if len(prog.Nodes) < 2 {
+		sev = SeverityError
+	}
+	return []Diagnostic{{Severity: sev, PC: -1, Message: fmt.Sprintf("%d nodes", len(prog.Nodes))}}
+})
+
+func TestPipeline(t *testing.T) {
+	pb := NewProgramBuilder("Passes")
+	pb.Node("Start").Stop()
+	pb.Node("Middle").Stop()
+	pb.Node("End").Stop()
+	prog := pb.Program()
+
+	p := &Pipeline{Passes: []Pass{countNodes, dropNodePass{"Middle"}, countNodes}}
+	res, err := p.Run(prog, nil)
+	if err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	want := []Diagnostic{
+		{Severity: SeverityInfo, PC: -1, Message: "3 nodes", Pass: "test/count"},
+		{Severity: SeverityInfo, PC: -1, Message: "2 nodes", Pass: "test/count"},
+	}
+	if diff := cmp.Diff(res.Diagnostics, want); diff != "" {
+		t.Errorf("diagnostics diff (-got +want):\n%s", diff)
+	}
+	if got, want := len(res.Program.Nodes), 2; got != want {
+		t.Errorf("len(res.Program.Nodes) = %d, want %d", got, want)
+	}
+	if got, want := len(prog.Nodes), 3; got != want {
+		t.Errorf("len(prog.Nodes) = %d, want %d (input modified)", got, want)
+	}
+	if got, want := res.Diagnostics[0].String(), "info: 3 nodes (test/count)"; got != want {
+		t.Errorf("Diagnostic.String() = %q, want %q", got, want)
+	}
+
+	// StopOnError stops after the first pass with errors.
+	p = &Pipeline{
+		Passes:      []Pass{dropNodePass{"Middle"}, dropNodePass{"End"}, countNodes, dropNodePass{"Start"}},
+		StopOnError: true,
+	}
+	res, err = p.Run(prog, nil)
+	if err != nil {
+		t.Fatalf("Run = %v", err)
+	}
+	if got, want := len(res.Program.Nodes), 1; got != want {
+		t.Errorf("len(res.Program.Nodes) = %d, want %d", got, want)
+	}
+	if !HasErrors(res.Diagnostics) {
+		t.Errorf("HasErrors(res.Diagnostics) = false, want true")
+	}
+
+	// Failing passes are named in the error.
+	p = &Pipeline{Passes: []Pass{dropNodePass{"Nowhere"}}}
+	if _, err := p.Run(prog, nil); err == nil || err.Error() != `pass "test/drop-Nowhere": no such node` {
+		t.Errorf("Run error = %v, want pass error", err)
+	}
+}
+
+func TestRegisterPass(t *testing.T) {
+	unregister := RegisterPass(countNodes)
+	unregisterDrop := RegisterPass(dropNodePass{"Middle"})
+	if got := LookupPass("test/count"); got == nil {
+		t.Errorf("LookupPass(test/count) = nil, want pass")
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("RegisterPass(duplicate) did not panic")
+			}
+		}()
+		RegisterPass(CheckPass("test/count", nil))
+	}()
+	var names []string
+	for _, p := range RegisteredPasses() {
+		names = append(names, p.Name())
+	}
+	if diff := cmp.Diff(names, []string{"test/count", "test/drop-Middle"}); diff != "" {
+		t.Errorf("RegisteredPasses names diff (-got +want):\n%s", diff)
+	}
+	unregister()
+	unregisterDrop()
+	if got := LookupPass("test/count"); got != nil {
+		t.Errorf("LookupPass(test/count) after unregister = %v, want nil", got)
+	}
+	if got := RegisteredPasses(); len(got) != 0 {
+		t.Errorf("RegisteredPasses after unregister = %v, want none", got)
+	}
+}
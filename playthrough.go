@@ -0,0 +1,256 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrReplayDiverged is returned (wrapped) by ReplayPlaythrough when the
+// program offers options that the recorded playthrough doesn't have a choice
+// for.
+const ErrReplayDiverged = virtualMachineError("replay diverged from recorded playthrough")
+
+// PlaythroughTranscript renders a recorded playthrough (an event log written
+// by EventLogHandler) as readable lines, suitable for diffing. Lines and
+// options are rendered with the string table, if it is not nil, and are
+// prefixed with their IDs so that changes to either are visible:
+//
+//	== Start ==
+//	[line:hi] Ava: Hello!
+//	? [line:bye] Goodbye
+//	> [line:stay] Stay a while
+//	<<wave>>
+//
+// In options, "?" marks an option that was offered, ">" the chosen option,
+// and "x" an unavailable option.
+func PlaythroughTranscript(entries []EventLogEntry, st *StringTable) []string {
+	render := func(id string, substs []string) string {
+		if row := st.row(id); row != nil {
+			if as, err := row.Render(substs, st.Language); err == nil {
+				return fmt.Sprintf("[%s] %s", id, as)
+			}
+		}
+		if len(substs) > 0 {
+			return fmt.Sprintf("[%s] %q", id, substs)
+		}
+		return fmt.Sprintf("[%s]", id)
+	}
+
+	var out []string
+	for _, e := range entries {
+		switch e.Event {
+		case "NodeStart":
+			out = append(out, fmt.Sprintf("== %s ==", e.Node))
+		case "Line", "SkipLine":
+			out = append(out, render(e.LineID, e.Substitutions))
+		case "Options":
+			for _, opt := range e.Options {
+				mark := "?"
+				switch {
+				case e.Choice != nil && *e.Choice == opt.ID:
+					mark = ">"
+				case !opt.Available:
+					mark = "x"
+				}
+				out = append(out, mark+" "+render(opt.LineID, opt.Substitutions))
+			}
+		case "Command":
+			out = append(out, "<<"+e.Command+">>")
+		}
+		if e.Error != "" {
+			out = append(out, "! "+e.Error)
+		}
+	}
+	return out
+}
+
+// ReplayPlaythrough replays the choices of a recorded playthrough with the VM
+// (e.g. one with a newer build of the program), starting at the first node
+// of the playthrough, and returns the new event log. Choices are matched by
+// the line ID of the chosen option (so that replays survive options being
+// reordered), falling back to the option ID (so that they survive line IDs
+// changing). If the program offers options
+// that can't be matched, the replay stops with an error wrapping
+// ErrReplayDiverged, and the events up to that point are returned.
+//
+// The VM's Handler is replaced for the duration of the replay.
+func ReplayPlaythrough(vm *VirtualMachine, entries []EventLogEntry) ([]EventLogEntry, error) {
+	start := ""
+	rh := &replayHandler{}
+	for _, e := range entries {
+		if e.Event == "NodeStart" && start == "" {
+			start = e.Node
+		}
+		if e.Event != "Options" || e.Choice == nil {
+			continue
+		}
+		c := replayChoice{id: *e.Choice}
+		for _, opt := range e.Options {
+			if opt.ID == *e.Choice {
+				c.lineID = opt.LineID
+			}
+		}
+		rh.choices = append(rh.choices, c)
+	}
+	if start == "" {
+		return nil, errors.New("playthrough has no NodeStart event")
+	}
+
+	var buf bytes.Buffer
+	elh := &EventLogHandler{DialogueHandler: rh, W: &buf}
+	old := vm.Handler
+	vm.Handler = elh
+	defer func() { vm.Handler = old }()
+	runErr := vm.Run(start)
+	if err := elh.Err(); err != nil {
+		return nil, err
+	}
+	log, err := ReadEventLog(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return log, runErr
+}
+
+// replayChoice is a recorded choice.
+type replayChoice struct {
+	id     int
+	lineID string
+}
+
+// replayHandler makes recorded choices.
+type replayHandler struct {
+	FakeDialogueHandler
+	choices []replayChoice
+	next    int
+}
+
+func (h *replayHandler) Options(options []Option) (int, error) {
+	if h.next >= len(h.choices) {
+		return -1, fmt.Errorf("%w: no recorded choice for options %d", ErrReplayDiverged, h.next+1)
+	}
+	c := h.choices[h.next]
+	h.next++
+	for _, opt := range options {
+		if c.lineID != "" && opt.Line.ID == c.lineID {
+			return opt.ID, nil
+		}
+	}
+	for _, opt := range options {
+		if opt.ID == c.id {
+			return opt.ID, nil
+		}
+	}
+	return -1, fmt.Errorf("%w: recorded choice %q (option %d) not offered", ErrReplayDiverged, c.lineID, c.id)
+}
+
+// TranscriptEdit is one line of a transcript diff. Op is ' ' for lines in
+// both transcripts, '-' for lines only in the old transcript, and '+' for
+// lines only in the new transcript.
+type TranscriptEdit struct {
+	Op   byte
+	Text string
+}
+
+func (e TranscriptEdit) String() string {
+	return string(e.Op) + " " + e.Text
+}
+
+// DiffTranscripts compares two transcripts (see PlaythroughTranscript) line
+// by line, returning a minimal sequence of edits from the old transcript to
+// the new one.
+func DiffTranscripts(from, to []string) []TranscriptEdit {
+	// lcs[i][j] is the length of the longest common subsequence of from[i:]
+	// and to[j:].
+	lcs := make([][]int, len(from)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(to)+1)
+	}
+	for i := len(from) - 1; i >= 0; i-- {
+		for j := len(to) - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var edits []TranscriptEdit
+	i, j := 0, 0
+	for i < len(from) && j < len(to) {
+		switch {
+		case from[i] == to[j]:
+			edits = append(edits, TranscriptEdit{' ', from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, TranscriptEdit{'-', from[i]})
+			i++
+		default:
+			edits = append(edits, TranscriptEdit{'+', to[j]})
+			j++
+		}
+	}
+	for ; i < len(from); i++ {
+		edits = append(edits, TranscriptEdit{'-', from[i]})
+	}
+	for ; j < len(to); j++ {
+		edits = append(edits, TranscriptEdit{'+', to[j]})
+	}
+	return edits
+}
+
+// WriteTranscriptDiff writes the changed parts of a transcript diff, with up
+// to context unchanged lines around each change. Each hunk starts with a
+// header giving the line numbers in the old and new transcripts. It writes
+// nothing if there are no changes.
+func WriteTranscriptDiff(w io.Writer, edits []TranscriptEdit, context int) error {
+	// Mark the edits to show.
+	show := make([]bool, len(edits))
+	for k, e := range edits {
+		if e.Op == ' ' {
+			continue
+		}
+		for c := max(k-context, 0); c <= min(k+context, len(edits)-1); c++ {
+			show[c] = true
+		}
+	}
+
+	var b strings.Builder
+	oldLine, newLine := 1, 1
+	for k, e := range edits {
+		if show[k] && (k == 0 || !show[k-1]) {
+			fmt.Fprintf(&b, "@@ old line %d, new line %d @@\n", oldLine, newLine)
+		}
+		if show[k] {
+			b.WriteString(e.String())
+			b.WriteByte('\n')
+		}
+		if e.Op != '+' {
+			oldLine++
+		}
+		if e.Op != '-' {
+			newLine++
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
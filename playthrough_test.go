@@ -0,0 +1,136 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlaythroughDiff(t *testing.T) {
+	oldPB := NewProgramBuilder("Old")
+	oldPB.Node("Start").
+		Line("line:hi", 0).
+		Option("line:bye", "end", 0, false).
+		Option("line:stay", "stay", 0, false).
+		ShowOptions().
+		Jump().
+		Label("stay").
+		Line("line:tea", 0).
+		Stop().
+		Label("end").
+		Line("line:later", 0).
+		Stop()
+	oldST := &StringTable{Table: map[string]*StringTableRow{
+		"line:hi":    {ID: "line:hi", Text: "Ava: Hello!"},
+		"line:bye":   {ID: "line:bye", Text: "Goodbye"},
+		"line:stay":  {ID: "line:stay", Text: "Stay a while"},
+		"line:later": {ID: "line:later", Text: "See you later."},
+	}}
+
+	// Record a playthrough of the old build.
+	var buf bytes.Buffer
+	vm := &VirtualMachine{
+		Program: oldPB.Program(),
+		Handler: &EventLogHandler{DialogueHandler: &choosingHandler{&lineRecorder{}}, W: &buf},
+		Vars:    NewMapVariableStorage(),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	recorded, err := ReadEventLog(&buf)
+	if err != nil {
+		t.Fatalf("ReadEventLog = %v", err)
+	}
+
+	// The new build reorders the options, adds a command, and changes a line.
+	newPB := NewProgramBuilder("New")
+	newPB.Node("Start").
+		Line("line:hi", 0).
+		Option("line:stay", "stay", 0, false).
+		Option("line:bye", "end", 0, false).
+		ShowOptions().
+		Jump().
+		Label("stay").
+		Line("line:tea", 0).
+		Stop().
+		Label("end").
+		Command("wave", 0).
+		Line("line:later", 0).
+		Stop()
+	newST := &StringTable{Table: map[string]*StringTableRow{}}
+	for id, row := range oldST.Table {
+		r := *row
+		newST.Table[id] = &r
+	}
+	newST.Table["line:later"].Text = "See ya."
+
+	vm = &VirtualMachine{Program: newPB.Program(), Vars: NewMapVariableStorage()}
+	replayed, err := ReplayPlaythrough(vm, recorded)
+	if err != nil {
+		t.Fatalf("ReplayPlaythrough = %v", err)
+	}
+
+	edits := DiffTranscripts(PlaythroughTranscript(recorded, oldST), PlaythroughTranscript(replayed, newST))
+	var out strings.Builder
+	if err := WriteTranscriptDiff(&out, edits, 1); err != nil {
+		t.Fatalf("WriteTranscriptDiff = %v", err)
+	}
+	want := `@@ old line 2, new line 2 @@
+  [line:hi] Ava: Hello!
+- > [line:bye] Goodbye
+  ? [line:stay] Stay a while
+- [line:later] See you later.
++ > [line:bye] Goodbye
++ <<wave>>
++ [line:later] See ya.
+`
+	if diff := cmp.Diff(out.String(), want); diff != "" {
+		t.Errorf("transcript diff diff (-got +want):\n%s", diff)
+	}
+
+	// Replaying against the same build gives no differences.
+	edits = DiffTranscripts(PlaythroughTranscript(recorded, oldST), PlaythroughTranscript(recorded, oldST))
+	out.Reset()
+	if err := WriteTranscriptDiff(&out, edits, 3); err != nil || out.Len() != 0 {
+		t.Errorf("WriteTranscriptDiff(no changes) wrote %q, %v, want nothing", out.String(), err)
+	}
+
+	// A build that asks for more choices than were recorded diverges.
+	morePB := NewProgramBuilder("More")
+	morePB.Node("Start").
+		Option("line:bye", "next", 0, false).
+		ShowOptions().
+		Jump().
+		Label("next").
+		Option("line:stay", "end", 0, false).
+		ShowOptions().
+		Jump().
+		Label("end").
+		Stop()
+	vm = &VirtualMachine{Program: morePB.Program(), Vars: NewMapVariableStorage()}
+	replayed, err = ReplayPlaythrough(vm, recorded)
+	if !errors.Is(err, ErrReplayDiverged) {
+		t.Errorf("ReplayPlaythrough(more choices) = %v, want %v", err, ErrReplayDiverged)
+	}
+	tr := PlaythroughTranscript(replayed, oldST)
+	if len(tr) == 0 || !strings.HasPrefix(tr[len(tr)-1], "! ") {
+		t.Errorf("transcript of diverged replay = %q, want it to end with the error", tr)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "sync"
+
+// eventBuffers holds the memory reused between events when
+// VirtualMachine.ReuseEvents is set. One is taken from eventBufferPool for
+// the duration of each Run, so it works like an arena per Run.
+type eventBuffers struct {
+	strs    []string // backing for line substitutions
+	options []Option // backing for the options slice
+}
+
+var eventBufferPool = sync.Pool{
+	New: func() any { return new(eventBuffers) },
+}
+
+// allocStrings returns a slice of n strings from the arena. The slice is
+// capped, so appending to it cannot overwrite another slice.
+func (b *eventBuffers) allocStrings(n int) []string {
+	l := len(b.strs)
+	if l+n > cap(b.strs) {
+		// Substitutions for pending options may still refer to the old
+		// backing array, so start a new one rather than copying.
+		b.strs = make([]string, 0, max(2*cap(b.strs), n, 16))
+		l = 0
+	}
+	b.strs = b.strs[:l+n]
+	return b.strs[l : l+n : l+n]
+}
+
+// clear drops references to strings so that pooled buffers don't keep them
+// alive, and empties the buffers.
+func (b *eventBuffers) clear() {
+	clear(b.strs[:cap(b.strs)])
+	clear(b.options[:cap(b.options)])
+	b.strs, b.options = b.strs[:0], b.options[:0]
+}
+
+// allocStrings returns a slice of n strings, from the arena if there is one.
+func (s *state) allocStrings(n int) []string {
+	if s.bufs == nil {
+		return make([]string, n)
+	}
+	return s.bufs.allocStrings(n)
+}
+
+// addOption appends an option to the pending options.
+func (s *state) addOption(o Option) {
+	if s.options == nil && s.bufs != nil {
+		s.options = s.bufs.options[:0]
+	}
+	s.options = append(s.options, o)
+}
+
+// releaseOptions empties the pending options, once the handler is finished
+// with them.
+func (s *state) releaseOptions() {
+	if s.bufs != nil {
+		s.bufs.options = s.options[:0]
+	}
+	s.options = nil
+	s.releaseStrings()
+}
+
+// releaseStrings allows the arena to reuse strings, once the handler is
+// finished with them (and no pending options refer to them).
+func (s *state) releaseStrings() {
+	if s.bufs != nil && len(s.options) == 0 {
+		s.bufs.strs = s.bufs.strs[:0]
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"reflect"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// Precompiled contains closures for the conditions in a program: the chains
+// of PUSH_* and CALL_FUNC instructions that compute a value for
+// JUMP_IF_FALSE. When a VM with Precompiled set reaches the start of such a
+// chain, it evaluates the closure instead of interpreting each instruction,
+// which avoids most of the stack traffic and instruction dispatch (and, for
+// the common operators, reflection). This helps content that evaluates many
+// conditions, e.g. saliency or when: clauses checked every frame.
+//
+// Precompiled is created once (e.g. at load time) with Precompile, and can be
+// shared by many VMs. Functions and variables are still looked up when the
+// closures run, so changes to FuncMap and Vars behave the same as without
+// precompilation.
+type Precompiled struct {
+	nodes map[*yarnpb.Node][]*compiledCond
+	count int
+}
+
+// compiledCond is a precompiled instruction sequence.
+type compiledCond struct {
+	end  int // pc of the JUMP_IF_FALSE following the sequence
+	eval exprFunc
+}
+
+// exprFunc evaluates (part of) an expression.
+type exprFunc func(vm *VirtualMachine) (any, error)
+
+// Precompile finds and compiles the conditions in the program. Only the
+// nodes in prog at the time are used; a VM running a different program (even
+// a copy) gets no benefit.
+func Precompile(prog *yarnpb.Program) *Precompiled {
+	p := &Precompiled{nodes: make(map[*yarnpb.Node][]*compiledCond)}
+	for _, node := range prog.Nodes {
+		var conds []*compiledCond
+		for j, inst := range node.Instructions {
+			if inst.Opcode != yarnpb.Instruction_JUMP_IF_FALSE {
+				continue
+			}
+			// Find the longest chain ending at j that computes exactly one
+			// value.
+			r := j
+			for r > 0 && isExprOpcode(node.Instructions[r-1].Opcode) {
+				r--
+			}
+			for s := r; s < j; s++ {
+				eval := compileCond(node.Instructions[s:j])
+				if eval == nil {
+					continue
+				}
+				if conds == nil {
+					conds = make([]*compiledCond, len(node.Instructions))
+				}
+				conds[s] = &compiledCond{end: j, eval: eval}
+				p.count++
+				break
+			}
+		}
+		if conds != nil {
+			p.nodes[node] = conds
+		}
+	}
+	return p
+}
+
+// Len returns the number of precompiled conditions.
+func (p *Precompiled) Len() int { return p.count }
+
+func isExprOpcode(op yarnpb.Instruction_OpCode) bool {
+	switch op {
+	case yarnpb.Instruction_PUSH_STRING, yarnpb.Instruction_PUSH_FLOAT,
+		yarnpb.Instruction_PUSH_BOOL, yarnpb.Instruction_PUSH_NULL,
+		yarnpb.Instruction_PUSH_VARIABLE, yarnpb.Instruction_CALL_FUNC:
+		return true
+	}
+	return false
+}
+
+// compileCond compiles a sequence of expression instructions into a closure,
+// or returns nil if the sequence doesn't leave exactly one value on the stack,
+// needs values from below it on the stack, or contains no function calls
+// (and so isn't worth compiling).
+func compileCond(insts []*yarnpb.Instruction) exprFunc {
+	type value struct {
+		eval  exprFunc
+		konst any
+		isK   bool
+	}
+	var stack []value
+	calls := 0
+	for _, inst := range insts {
+		switch inst.Opcode {
+		case yarnpb.Instruction_PUSH_STRING, yarnpb.Instruction_PUSH_FLOAT, yarnpb.Instruction_PUSH_BOOL, yarnpb.Instruction_PUSH_NULL:
+			var k any
+			if len(inst.Operands) > 0 {
+				switch inst.Opcode {
+				case yarnpb.Instruction_PUSH_STRING:
+					k = inst.Operands[0].GetStringValue()
+				case yarnpb.Instruction_PUSH_FLOAT:
+					k = inst.Operands[0].GetFloatValue()
+				case yarnpb.Instruction_PUSH_BOOL:
+					k = inst.Operands[0].GetBoolValue()
+				}
+			} else if inst.Opcode != yarnpb.Instruction_PUSH_NULL {
+				return nil
+			}
+			stack = append(stack, value{eval: func(*VirtualMachine) (any, error) { return k, nil }, konst: k, isK: true})
+
+		case yarnpb.Instruction_PUSH_VARIABLE:
+			if len(inst.Operands) == 0 {
+				return nil
+			}
+			name := inst.Operands[0].GetStringValue()
+			stack = append(stack, value{eval: func(vm *VirtualMachine) (any, error) { return vm.variableValue(name), nil }})
+
+		case yarnpb.Instruction_CALL_FUNC:
+			if len(inst.Operands) == 0 || len(stack) == 0 {
+				return nil
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if !top.isK {
+				return nil
+			}
+			argc, err := ConvertToInt(top.konst)
+			if err != nil || argc < 0 || argc > len(stack) {
+				return nil
+			}
+			args := make([]exprFunc, argc)
+			for i, v := range stack[len(stack)-argc:] {
+				args[i] = v.eval
+			}
+			stack = stack[:len(stack)-argc]
+			stack = append(stack, value{eval: compileCall(inst.Operands[0].GetStringValue(), args)})
+			calls++
+
+		default:
+			return nil
+		}
+	}
+	if len(stack) != 1 || calls == 0 {
+		return nil
+	}
+	return stack[0].eval
+}
+
+// compileCall returns a closure that evaluates the args and calls the named
+// function.
+func compileCall(funcname string, args []exprFunc) exprFunc {
+	return func(vm *VirtualMachine) (any, error) {
+		vals := make([]any, len(args))
+		for i, arg := range args {
+			v, err := arg(vm)
+			if err != nil {
+				return nil, err
+			}
+			vals[i] = v
+		}
+		function, found := vm.lookupFunc(funcname)
+		if !found {
+			return nil, fmt.Errorf("%q %w", funcname, ErrFunctionNotFound)
+		}
+		pure, isPure := function.(PureFunc)
+		var key string
+		if isPure {
+			function = pure.Func
+			key = memoKey(funcname, vals)
+			if r, hit := vm.memo[key]; hit {
+				if !r.ok {
+					return nil, fmt.Errorf("%w: function %q returned no value for condition", ErrFunctionArgMismatch, funcname)
+				}
+				return r.value, nil
+			}
+		}
+		result, ok, err := callFunc(funcname, function, vals)
+		if err != nil {
+			return nil, err
+		}
+		result = vm.quantize(result)
+		if isPure {
+			vm.remember(key, result, ok)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: function %q returned no value for condition", ErrFunctionArgMismatch, funcname)
+		}
+		return result, nil
+	}
+}
+
+// callFunc calls a function with args, converting them as execCallFunc does.
+// Functions with the signatures used by the standard operators are called
+// directly, without reflection.
+func callFunc(funcname string, function any, args []any) (any, bool, error) {
+	switch f := function.(type) {
+	case func(float32, float32) bool:
+		if len(args) == 2 {
+			x, y, err := convert2(ConvertToFloat32, args)
+			if err != nil {
+				return nil, false, err
+			}
+			return f(x, y), true, nil
+		}
+	case func(float32, float32) float32:
+		if len(args) == 2 {
+			x, y, err := convert2(ConvertToFloat32, args)
+			if err != nil {
+				return nil, false, err
+			}
+			return f(x, y), true, nil
+		}
+	case func(bool, bool) bool:
+		if len(args) == 2 {
+			x, y, err := convert2(ConvertToBool, args)
+			if err != nil {
+				return nil, false, err
+			}
+			return f(x, y), true, nil
+		}
+	case func(string, string) bool:
+		if len(args) == 2 {
+			return f(convertString(args[0]), convertString(args[1])), true, nil
+		}
+	case func(any, any) bool:
+		if len(args) == 2 {
+			return f(args[0], args[1]), true, nil
+		}
+	case func(bool) bool:
+		if len(args) == 1 {
+			x, err := ConvertToBool(args[0])
+			if err != nil {
+				return nil, false, err
+			}
+			return f(x), true, nil
+		}
+	case func(float32) float32:
+		if len(args) == 1 {
+			x, err := ConvertToFloat32(args[0])
+			if err != nil {
+				return nil, false, err
+			}
+			return f(x), true, nil
+		}
+	}
+
+	if reflect.TypeOf(function).Kind() != reflect.Func {
+		return nil, false, fmt.Errorf("%w: function for %q not actually a function [type %T]", ErrWrongType, funcname, function)
+	}
+	if err := checkFuncSignature(function, len(args)); err != nil {
+		return nil, false, err
+	}
+	params, err := convertArgs(funcname, function, args)
+	if err != nil {
+		return nil, false, err
+	}
+	return invokeFunc(function, params)
+}
+
+func convert2[T any](conv func(any) (T, error), args []any) (x, y T, err error) {
+	if x, err = conv(args[0]); err != nil {
+		return x, y, err
+	}
+	y, err = conv(args[1])
+	return x, y, err
+}
+
+// convertString converts like convertArgs: nil becomes "".
+func convertString(x any) string {
+	if x == nil {
+		return ""
+	}
+	return ConvertToString(x)
+}
+
+// execPrecompiled evaluates a precompiled condition, if there is one at the
+// current pc, and moves pc to the end of it. It reports whether it did.
+func (vm *VirtualMachine) execPrecompiled(conds []*compiledCond) (bool, error) {
+	if vm.state.pc >= len(conds) {
+		return false, nil
+	}
+	c := conds[vm.state.pc]
+	if c == nil {
+		return false, nil
+	}
+	v, err := c.eval(vm)
+	if err != nil {
+		return true, err
+	}
+	vm.state.push(v)
+	vm.state.pc = c.end
+	return true, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+func TestPrecompiledTestPlans(t *testing.T) {
+	bases := []string{
+		"DecimalNumbers",
+		"Expressions",
+		"IfStatements",
+		"Indentation",
+		"ShortcutOptions",
+		"Types",
+		"VariableStorage",
+		"VisitCount",
+		"Visited",
+	}
+	total := 0
+	for _, base := range bases {
+		t.Run(base, func(t *testing.T) {
+			testplan, err := LoadTestPlanFile("testdata/" + base + ".testplan")
+			if err != nil {
+				t.Fatalf("LoadTestPlanFile = %v", err)
+			}
+			prog, st, err := LoadFiles("testdata/"+base+".yarnc", "en")
+			if err != nil {
+				t.Fatalf("LoadFiles = %v", err)
+			}
+			testplan.StringTable = st
+			pre := Precompile(prog)
+			total += pre.Len()
+			vm := &VirtualMachine{
+				Program:     prog,
+				Handler:     testplan,
+				Vars:        NewMapVariableStorage(),
+				Precompiled: pre,
+				FuncMap: FuncMap{
+					"assert": func(x bool) error {
+						if !x {
+							return errors.New("assertion failed")
+						}
+						return nil
+					},
+				},
+			}
+			if err := vm.Run("Start"); err != nil {
+				t.Errorf("vm.Run(Start) = %v", err)
+			}
+			if err := testplan.Complete(); err != nil {
+				t.Errorf("testplan incomplete: %v", err)
+			}
+		})
+	}
+	if total == 0 {
+		t.Error("no conditions were precompiled")
+	}
+}
+
+// condProgram returns a program that evaluates a condition (emitted by cond)
+// and runs line "yes" or "no" depending on the result.
+func condProgram(cond func(*NodeBuilder)) *yarnpb.Program {
+	pb := NewProgramBuilder("Cond")
+	start := pb.Node("Start")
+	cond(start)
+	start.
+		JumpIfFalse("no").
+		Pop().
+		Line("yes", 0).
+		Stop().
+		Label("no").
+		Pop().
+		Line("no", 0).
+		Stop()
+	return pb.Program()
+}
+
+// lineRecorder records the IDs of lines, and commands.
+type lineRecorder struct {
+	FakeDialogueHandler
+	ids      []string
+	commands []string
+}
+
+func (r *lineRecorder) Line(line Line) error {
+	r.ids = append(r.ids, line.ID)
+	return nil
+}
+
+func (r *lineRecorder) Command(command string) error {
+	r.commands = append(r.commands, command)
+	return nil
+}
+
+func TestPrecompile(t *testing.T) {
+	// $gold >= 10 and custom($name)
+	prog := condProgram(func(b *NodeBuilder) {
+		b.PushVariable("$gold").
+			PushFloat(10).
+			Call("Number.GreaterThanOrEqualTo", 2).
+			PushVariable("$name").
+			Call("custom", 1).
+			Call("Bool.And", 2)
+	})
+	pre := Precompile(prog)
+	if got, want := pre.Len(), 1; got != want {
+		t.Fatalf("Precompile(prog).Len() = %d, want %d", got, want)
+	}
+
+	tests := []struct {
+		gold float32
+		name string
+		want string
+	}{
+		{gold: 5, name: "Alice", want: "no"},
+		{gold: 15, name: "Alice", want: "yes"},
+		{gold: 15, name: "Bob", want: "no"},
+	}
+	for _, test := range tests {
+		for _, pre := range []*Precompiled{nil, pre} {
+			vars := NewMapVariableStorage()
+			vars.SetValue("$gold", test.gold)
+			vars.SetValue("$name", test.name)
+			rec := &lineRecorder{}
+			vm := &VirtualMachine{
+				Program:     prog,
+				Handler:     rec,
+				Vars:        vars,
+				Precompiled: pre,
+				FuncMap: FuncMap{
+					"custom": func(s string) bool { return s == "Alice" },
+				},
+			}
+			if err := vm.Run("Start"); err != nil {
+				t.Fatalf("vm.Run(Start) = %v", err)
+			}
+			if len(rec.ids) != 1 || rec.ids[0] != test.want {
+				t.Errorf("gold=%v name=%q precompiled=%t: lines = %v, want [%s]", test.gold, test.name, pre != nil, rec.ids, test.want)
+			}
+		}
+	}
+}
+
+func TestPrecompileErrors(t *testing.T) {
+	prog := condProgram(func(b *NodeBuilder) {
+		b.Call("missing", 0)
+	})
+	vm := &VirtualMachine{
+		Program:     prog,
+		Handler:     &lineRecorder{},
+		Vars:        NewMapVariableStorage(),
+		Precompiled: Precompile(prog),
+	}
+	if err := vm.Run("Start"); !errors.Is(err, ErrFunctionNotFound) {
+		t.Errorf("vm.Run(Start) = %v, want %v", err, ErrFunctionNotFound)
+	}
+}
+
+func TestPrecompileSkips(t *testing.T) {
+	tests := []struct {
+		desc string
+		cond func(*NodeBuilder)
+	}{
+		{
+			desc: "no function calls",
+			cond: func(b *NodeBuilder) {
+				b.PushVariable("$x")
+			},
+		},
+		{
+			desc: "non-constant argument count",
+			cond: func(b *NodeBuilder) {
+				b.PushVariable("$x").
+					Inst(yarnpb.Instruction_CALL_FUNC, stringOperand("f"))
+			},
+		},
+		{
+			desc: "stack underflow",
+			cond: func(b *NodeBuilder) {
+				b.Call("Bool.And", 2)
+			},
+		},
+	}
+	for _, test := range tests {
+		if got := Precompile(condProgram(test.cond)).Len(); got != 0 {
+			t.Errorf("%s: Precompile(prog).Len() = %d, want 0", test.desc, got)
+		}
+	}
+}
+
+func BenchmarkPrecompiled(b *testing.B) {
+	prog := condProgram(func(b *NodeBuilder) {
+		b.PushVariable("$gold").
+			PushFloat(10).
+			Call("Number.GreaterThanOrEqualTo", 2).
+			PushBool(true).
+			Call("Bool.And", 2)
+	})
+	for _, pre := range []*Precompiled{nil, Precompile(prog)} {
+		name := "interpreted"
+		if pre != nil {
+			name = "precompiled"
+		}
+		b.Run(name, func(b *testing.B) {
+			vars := NewMapVariableStorage()
+			vars.SetValue("$gold", float32(15))
+			vm := &VirtualMachine{
+				Program:     prog,
+				Handler:     FakeDialogueHandler{},
+				Vars:        vars,
+				Precompiled: pre,
+			}
+			for i := 0; i < b.N; i++ {
+				if err := vm.Run("Start"); err != nil {
+					b.Fatalf("vm.Run(Start) = %v", err)
+				}
+			}
+		})
+	}
+}
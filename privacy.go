@@ -0,0 +1,154 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+)
+
+// RedactedValue replaces redacted values.
+const RedactedValue = "[redacted]"
+
+// PrivacyPolicy controls what the VM includes in the telemetry it records:
+// analytics events (VirtualMachine.Analytics), error reports
+// (VirtualMachine.ErrorReports), and handler events logged to
+// VirtualMachine.Logger.
+//
+// All recording is opt-in: nothing is recorded unless one of those fields is
+// set, and the seen-line and chosen-option history (see History) is only
+// recorded while VirtualMachine.RecordHistory is set. The policy then lets
+// studios keep using transcripts and metrics while complying with privacy
+// policies, e.g. when substitutions can contain text the player entered
+// (such as their name). The program's own content (node names, and the
+// disassembly in error reports) is not scrubbed, and neither are trace-level
+// instruction logs. The history holds only line IDs, and isn't scrubbed, as
+// the VM matches them against the program (e.g. for SkipSeenOnly).
+type PrivacyPolicy struct {
+	// HashLineIDs replaces line IDs with salted hashes (see LineID).
+	HashLineIDs bool
+
+	// Salt is hashed with each line ID. Keep it secret to stop line IDs from
+	// being recovered by hashing every line in the (shipped) program.
+	Salt string
+
+	// DropSubstitutions removes the substitutions of lines and options, and
+	// strings on the stack in error reports.
+	DropSubstitutions bool
+
+	// RedactCommands records only the name of each command (its first
+	// field), since commands can also contain substitutions.
+	RedactCommands bool
+
+	// DropVariables removes variables from error reports.
+	DropVariables bool
+}
+
+// LineID returns the line ID as it will be recorded: a salted hash of the
+// ID if HashLineIDs is set, or the ID unchanged otherwise. Studios can use it
+// to map hashed IDs back to lines.
+func (p *PrivacyPolicy) LineID(id string) string {
+	if p == nil || !p.HashLineIDs || id == "" {
+		return id
+	}
+	sum := sha256.Sum256([]byte(p.Salt + "\x00" + id))
+	return "h:" + hex.EncodeToString(sum[:8])
+}
+
+// lineIDs applies LineID to each ID.
+func (p *PrivacyPolicy) lineIDs(ids []string) []string {
+	if p == nil || !p.HashLineIDs || ids == nil {
+		return ids
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = p.LineID(id)
+	}
+	return out
+}
+
+// command returns the command as it will be recorded.
+func (p *PrivacyPolicy) command(cmd string) string {
+	if p == nil || !p.RedactCommands {
+		return cmd
+	}
+	name, _, _ := strings.Cut(strings.TrimSpace(cmd), " ")
+	return name
+}
+
+// scrubAttrs scrubs the attributes of a handler event.
+func (p *PrivacyPolicy) scrubAttrs(attrs []slog.Attr) []slog.Attr {
+	if p == nil {
+		return attrs
+	}
+	out := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		switch a.Key {
+		case "line_id":
+			a = slog.String(a.Key, p.LineID(a.Value.String()))
+		case "line_ids":
+			if ids, ok := a.Value.Any().([]string); ok {
+				a = slog.Any(a.Key, p.lineIDs(ids))
+			}
+		case "substitutions":
+			if p.DropSubstitutions {
+				continue
+			}
+		case "command":
+			a = slog.String(a.Key, p.command(a.Value.String()))
+		}
+		out = append(out, a)
+	}
+	return out
+}
+
+// scrubAnalytics scrubs an analytics event.
+func (p *PrivacyPolicy) scrubAnalytics(ev *AnalyticsEvent) {
+	if p == nil {
+		return
+	}
+	ev.LineID = p.LineID(ev.LineID)
+	ev.Choice = p.LineID(ev.Choice)
+	ev.Options = p.lineIDs(ev.Options)
+	if p.DropSubstitutions {
+		ev.Substitutions = nil
+	}
+}
+
+// scrubReport scrubs an error report.
+func (p *PrivacyPolicy) scrubReport(r *ErrorReport) {
+	if p == nil {
+		return
+	}
+	for i := range r.Options {
+		o := &r.Options[i]
+		o.Line.ID = p.LineID(o.Line.ID)
+		if p.DropSubstitutions {
+			o.Line.Substitutions = nil
+		}
+	}
+	if p.DropSubstitutions {
+		for i, x := range r.Stack {
+			if _, ok := x.(string); ok {
+				r.Stack[i] = RedactedValue
+			}
+		}
+	}
+	if p.DropVariables {
+		r.Vars = nil
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestPrivacyPolicy(t *testing.T) {
+	pb := NewProgramBuilder("Private")
+	pb.Node("Start").
+		PushString("Bea").
+		Line("line:1", 1).
+		PushString("Bea").
+		Command("wave {0}", 1).
+		PushString("Bea").
+		Inst(yarnpb.Instruction_CALL_FUNC, stringOperand("missing")).
+		Stop()
+	prog := pb.Program()
+	vars := NewMapVariableStorage()
+	vars.SetValue("$name", "Bea")
+
+	p := &PrivacyPolicy{
+		HashLineIDs:       true,
+		Salt:              "pepper",
+		DropSubstitutions: true,
+		RedactCommands:    true,
+		DropVariables:     true,
+	}
+	hashed := p.LineID("line:1")
+	if hashed == "line:1" || hashed != p.LineID("line:1") {
+		t.Errorf("p.LineID(line:1) = %q, want a consistent hash", hashed)
+	}
+	if other := (&PrivacyPolicy{HashLineIDs: true, Salt: "salt"}).LineID("line:1"); other == hashed {
+		t.Errorf("LineID with a different salt = %q, want a different hash", other)
+	}
+
+	var report *ErrorReport
+	var events []AnalyticsEvent
+	vm := &VirtualMachine{
+		Program:      prog,
+		Handler:      FakeDialogueHandler{},
+		Vars:         vars,
+		Privacy:      p,
+		ErrorReports: func(r *ErrorReport) { report = r },
+		Analytics:    func(ev AnalyticsEvent) { events = append(events, ev) },
+	}
+	if err := vm.Run("Start"); !errors.Is(err, ErrFunctionNotFound) {
+		t.Fatalf("vm.Run(Start) = %v, want %v", err, ErrFunctionNotFound)
+	}
+	if report == nil {
+		t.Fatal("ErrorReports was not called")
+	}
+	wantTranscript := []TranscriptEvent{
+		{Node: "Start", PC: 0, Event: "NodeStart"},
+		{Node: "Start", PC: 0, Event: "PrepareForLines", Attrs: map[string]any{"line_ids": []string{hashed}}},
+		{Node: "Start", PC: 2, Event: "Line", Attrs: map[string]any{"line_id": hashed}},
+		{Node: "Start", PC: 4, Event: "Command", Attrs: map[string]any{"command": "wave"}},
+	}
+	if diff := cmp.Diff(report.Transcript, wantTranscript); diff != "" {
+		t.Errorf("transcript diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(report.Stack, []any{RedactedValue}); diff != "" {
+		t.Errorf("stack diff (-got +want):\n%s", diff)
+	}
+	if report.Vars != nil {
+		t.Errorf("report.Vars = %v, want nil", report.Vars)
+	}
+
+	wantEvents := []AnalyticsEvent{
+		{Kind: AnalyticsNode, Node: "Start"},
+		{Kind: AnalyticsLine, Node: "Start", LineID: hashed},
+	}
+	if diff := cmp.Diff(events, wantEvents, cmpopts.IgnoreFields(AnalyticsEvent{}, "Time")); diff != "" {
+		t.Errorf("analytics diff (-got +want):\n%s", diff)
+	}
+}
+
+func TestHistoryOptIn(t *testing.T) {
+	pb := NewProgramBuilder("History")
+	pb.Node("Start").
+		Line("line:hello", 0).
+		Option("line:yes", "yes", 0, false).
+		Option("line:no", "no", 0, false).
+		ShowOptions().Jump().
+		Label("yes").Line("line:yay", 0).Stop().
+		Label("no").Stop()
+	prog := pb.Program()
+
+	for _, record := range []bool{false, true} {
+		vm := &VirtualMachine{
+			Program:       prog,
+			Handler:       &choosingHandler{&lineRecorder{}},
+			Vars:          NewMapVariableStorage(),
+			RecordHistory: record,
+		}
+		if err := vm.Run("Start"); err != nil {
+			t.Fatalf("vm.Run(Start) = %v", err)
+		}
+		want := History{}
+		if record {
+			want = History{
+				SeenLines:     []string{"line:hello", "line:no", "line:yay", "line:yes"},
+				ChosenOptions: []string{"line:yes"},
+			}
+		}
+		if diff := cmp.Diff(vm.History(), want); diff != "" {
+			t.Errorf("RecordHistory = %t: vm.History() diff (-got +want):\n%s", record, diff)
+		}
+		if got := vm.LineSeen("line:hello"); got != record {
+			t.Errorf("RecordHistory = %t: vm.LineSeen(line:hello) = %t, want %t", record, got, record)
+		}
+	}
+}
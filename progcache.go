@@ -0,0 +1,153 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// DefaultProgramCache is a process-wide ProgramCache.
+var DefaultProgramCache = new(ProgramCache)
+
+// ProgramCache remembers loaded programs, keyed by a hash of the compiled
+// program's contents, so that tools and servers that load the same yarnc
+// many times (for each test or session, say) parse and check it only once.
+// Programs that fail to load are not remembered. The zero value is ready to
+// use, and it is safe for concurrent use.
+//
+// Programs from the cache are shared, so they must not be modified. To change
+// one (e.g. with DedupeLines), work on a copy (proto.Clone); functions such
+// as Optimize and FilterProgram already return copies.
+type ProgramCache struct {
+	mu      sync.Mutex
+	entries map[programCacheKey]*CachedProgram
+}
+
+// programCacheKey identifies a cached program. Since OptimizeOnLoad changes
+// the loaded program, programs loaded with and without it are kept apart.
+type programCacheKey struct {
+	hash      [sha256.Size]byte
+	optimized bool
+}
+
+// CachedProgram is a program loaded through a ProgramCache, along with the
+// results of the work commonly done after loading, computed once and shared.
+type CachedProgram struct {
+	// Hash is the SHA-256 hash of the compiled program.
+	Hash [sha256.Size]byte
+
+	// Program is the loaded program. It must not be modified.
+	Program *yarnpb.Program
+
+	loadOnce sync.Once
+	err      error
+
+	validateOnce sync.Once
+	diags        []Diagnostic
+
+	precompileOnce sync.Once
+	precompiled    *Precompiled
+}
+
+// Validate returns the diagnostics from ValidateProgram, computing them the
+// first time it is called.
+func (cp *CachedProgram) Validate() []Diagnostic {
+	cp.validateOnce.Do(func() { cp.diags = ValidateProgram(cp.Program) })
+	return cp.diags
+}
+
+// Precompiled returns the program's precompiled conditions (see Precompile),
+// compiling them the first time it is called. Since VMs using the cache run
+// the same shared program, they can share these too.
+func (cp *CachedProgram) Precompiled() *Precompiled {
+	cp.precompileOnce.Do(func() { cp.precompiled = Precompile(cp.Program) })
+	return cp.precompiled
+}
+
+// Load returns the program compiled into yarnc, loading it only if a program
+// with the same contents isn't already in the cache. Concurrent loads of the
+// same program wait for the first one to finish.
+func (c *ProgramCache) Load(yarnc []byte) (*CachedProgram, error) {
+	key := programCacheKey{
+		hash:      sha256.Sum256(yarnc),
+		optimized: optimizeOnLoad.Load(),
+	}
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = make(map[programCacheKey]*CachedProgram)
+	}
+	cp := c.entries[key]
+	if cp == nil {
+		cp = &CachedProgram{Hash: key.hash}
+		c.entries[key] = cp
+	}
+	c.mu.Unlock()
+
+	cp.loadOnce.Do(func() {
+		cp.Program, cp.err = unmarshalBytes(yarnc)
+		if cp.err == nil {
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		if c.entries[key] == cp {
+			delete(c.entries, key)
+		}
+	})
+	if cp.err != nil {
+		return nil, cp.err
+	}
+	return cp, nil
+}
+
+// LoadFile reads a compiled program file and loads it through the cache.
+// The file is read each time (to hash it), but only parsed once.
+func (c *ProgramCache) LoadFile(programPath string) (*CachedProgram, error) {
+	yarnc, err := os.ReadFile(programPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading program file: %w", err)
+	}
+	return c.Load(yarnc)
+}
+
+// LoadFileFS is like LoadFile, but reads from fsys.
+func (c *ProgramCache) LoadFileFS(fsys fs.FS, programPath string) (*CachedProgram, error) {
+	yarnc, err := fs.ReadFile(fsys, programPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading program file: %w", err)
+	}
+	return c.Load(yarnc)
+}
+
+// Len returns the number of programs in the cache.
+func (c *ProgramCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+// Clear forgets all the programs in the cache. Programs already returned
+// remain usable.
+func (c *ProgramCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
@@ -0,0 +1,113 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestProgramCache(t *testing.T) {
+	yarnc, err := os.ReadFile("testdata/Example.yarnc")
+	if err != nil {
+		t.Fatalf("ReadFile = %v", err)
+	}
+	want, err := LoadProgramBytes(yarnc)
+	if err != nil {
+		t.Fatalf("LoadProgramBytes = %v", err)
+	}
+
+	var c ProgramCache
+	cps := make([]*CachedProgram, 8)
+	var wg sync.WaitGroup
+	for i := range cps {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cp, err := c.Load(yarnc)
+			if err != nil {
+				t.Errorf("Load = %v", err)
+			}
+			cps[i] = cp
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	for i, cp := range cps {
+		if cp != cps[0] {
+			t.Errorf("Load #%d = %p, want shared %p", i, cp, cps[0])
+		}
+	}
+	if !proto.Equal(cps[0].Program, want) {
+		t.Errorf("cached program differs from LoadProgramBytes program")
+	}
+
+	cp, err := c.LoadFile("testdata/Example.yarnc")
+	if err != nil {
+		t.Fatalf("LoadFile = %v", err)
+	}
+	if cp != cps[0] {
+		t.Errorf("LoadFile = %p, want cached %p", cp, cps[0])
+	}
+	if diff := cmp.Diff(cp.Validate(), ValidateProgram(want)); diff != "" {
+		t.Errorf("Validate() diff (-got +want):\n%s", diff)
+	}
+	if cp.Precompiled() != cp.Precompiled() {
+		t.Errorf("Precompiled() not remembered")
+	}
+
+	// Optimized programs are kept separately.
+	OptimizeOnLoad(true)
+	opt, err := c.Load(yarnc)
+	OptimizeOnLoad(false)
+	if err != nil {
+		t.Fatalf("Load (optimized) = %v", err)
+	}
+	if opt == cp {
+		t.Errorf("Load with OptimizeOnLoad returned the unoptimized program")
+	}
+	if got, want := c.Len(), 2; got != want {
+		t.Errorf("Len() = %d, want %d", got, want)
+	}
+
+	// Failures are not remembered.
+	if _, err := c.Load([]byte("not a program")); err == nil {
+		t.Errorf("Load(garbage) error = nil, want error")
+	}
+	if _, err := c.LoadFile("testdata/missing.yarnc"); err == nil {
+		t.Errorf("LoadFile(missing) error = nil, want error")
+	}
+	if got, want := c.Len(), 2; got != want {
+		t.Errorf("Len() after failures = %d, want %d", got, want)
+	}
+
+	c.Clear()
+	if got, want := c.Len(), 0; got != want {
+		t.Errorf("Len() after Clear = %d, want %d", got, want)
+	}
+	again, err := c.Load(yarnc)
+	if err != nil {
+		t.Fatalf("Load after Clear = %v", err)
+	}
+	if again == cp {
+		t.Errorf("Load after Clear returned the forgotten program")
+	}
+}
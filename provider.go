@@ -0,0 +1,162 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ProgramProvider provides programs on demand. The VM consults its
+// ProgramProvider (if any) when asked to enter a node that isn't in the
+// current program.
+type ProgramProvider interface {
+	// ProgramFor returns the program containing the node. If no program
+	// contains the node, the error should wrap ErrNodeNotFound.
+	ProgramFor(node string) (*yarnpb.Program, error)
+}
+
+// NodeIndex maps the name of each node in the programs to the key of the
+// program containing it, e.g. for ChapterProvider.Chapters. It is usually
+// built ahead of time (when the chapters are compiled) and shipped with the
+// game, so that the programs themselves can be loaded lazily.
+func NodeIndex(programs map[string]*yarnpb.Program) map[string]string {
+	index := make(map[string]string)
+	for key, prog := range programs {
+		for name := range prog.GetNodes() {
+			index[name] = key
+		}
+	}
+	return index
+}
+
+var _ ProgramProvider = &ChapterProvider{}
+
+// ChapterProvider is a ProgramProvider for games that keep each chapter in a
+// separate program (e.g. a separate .yarnc file). It finds the chapter
+// containing a node with an index, and loads chapters when they are first
+// needed. Loaded chapters are cached, and the least recently used chapters
+// are evicted when there are more than MaxLoaded. It is safe for concurrent
+// use.
+//
+// Load and OnEvict are called without holding the provider's lock, so a slow
+// load doesn't hold up chapters that are already loaded, and they may use the
+// provider (though Load may not get the chapter it is loading). Concurrent
+// requests for the same chapter share one call to Load.
+type ChapterProvider struct {
+	// Chapters maps node names to chapter keys (see NodeIndex).
+	Chapters map[string]string
+
+	// Load loads a chapter, for example from disk, the network, or an asset
+	// bundle.
+	Load func(chapter string) (*yarnpb.Program, error)
+
+	// MaxLoaded is the maximum number of chapters to keep loaded. If zero,
+	// loaded chapters are never evicted.
+	MaxLoaded int
+
+	// OnEvict, if not nil, is called when a chapter is evicted, e.g. to
+	// unload its assets. (A VM that is running the chapter keeps it.)
+	OnEvict func(chapter string, prog *yarnpb.Program)
+
+	mu      sync.Mutex
+	lru     list.List // of *loadedChapter, most recently used at front
+	loaded  map[string]*list.Element
+	loading map[string]*programLoad
+}
+
+type loadedChapter struct {
+	key  string
+	prog *yarnpb.Program
+}
+
+// ProgramFor returns the chapter containing the node, loading it if
+// necessary.
+func (p *ChapterProvider) ProgramFor(node string) (*yarnpb.Program, error) {
+	key, ok := p.Chapters[node]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not in any chapter", ErrNodeNotFound, node)
+	}
+	return p.Chapter(key)
+}
+
+// Chapter returns the chapter with the key, loading it if necessary.
+func (p *ChapterProvider) Chapter(key string) (*yarnpb.Program, error) {
+	p.mu.Lock()
+	if e := p.loaded[key]; e != nil {
+		p.lru.MoveToFront(e)
+		p.mu.Unlock()
+		return e.Value.(*loadedChapter).prog, nil
+	}
+	if p.Load == nil {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("%w: %q", ErrProgramNotFound, key)
+	}
+	if l := p.loading[key]; l != nil {
+		// Another goroutine is loading it.
+		p.mu.Unlock()
+		<-l.done
+		return l.prog, l.err
+	}
+	l := &programLoad{done: make(chan struct{})}
+	if p.loading == nil {
+		p.loading = make(map[string]*programLoad)
+	}
+	p.loading[key] = l
+	p.mu.Unlock()
+
+	l.prog, l.err = p.Load(key)
+	if l.err != nil {
+		l.prog, l.err = nil, fmt.Errorf("loading chapter %q: %w", key, l.err)
+	}
+
+	p.mu.Lock()
+	delete(p.loading, key)
+	var evicted []*loadedChapter
+	if l.err == nil {
+		if p.loaded == nil {
+			p.loaded = make(map[string]*list.Element)
+		}
+		p.loaded[key] = p.lru.PushFront(&loadedChapter{key: key, prog: l.prog})
+		for p.MaxLoaded > 0 && p.lru.Len() > p.MaxLoaded {
+			lc := p.lru.Remove(p.lru.Back()).(*loadedChapter)
+			delete(p.loaded, lc.key)
+			evicted = append(evicted, lc)
+		}
+	}
+	p.mu.Unlock()
+	close(l.done)
+
+	if p.OnEvict != nil {
+		for _, lc := range evicted {
+			p.OnEvict(lc.key, lc.prog)
+		}
+	}
+	return l.prog, l.err
+}
+
+// Loaded returns the keys of the loaded chapters, most recently used first.
+func (p *ChapterProvider) Loaded() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	keys := make([]string, 0, p.lru.Len())
+	for e := p.lru.Front(); e != nil; e = e.Next() {
+		keys = append(keys, e.Value.(*loadedChapter).key)
+	}
+	return keys
+}
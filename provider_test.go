@@ -0,0 +1,140 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestChapterProvider(t *testing.T) {
+	ch1 := NewProgramBuilder("Chapter1")
+	ch1.Node("Start").Line("line:start", 0).RunNode("Intro")
+	ch1.Node("End").Line("line:end", 0)
+	ch2 := NewProgramBuilder("Chapter2")
+	ch2.Node("Intro").Line("line:intro", 0).RunNode("Finale")
+	ch3 := NewProgramBuilder("Chapter3")
+	ch3.Node("Finale").Line("line:finale", 0).RunNode("End")
+	chapters := map[string]*yarnpb.Program{
+		"ch1": ch1.Program(),
+		"ch2": ch2.Program(),
+		"ch3": ch3.Program(),
+	}
+
+	var loads, evicted []string
+	p := &ChapterProvider{
+		Chapters: NodeIndex(chapters),
+		Load: func(key string) (*yarnpb.Program, error) {
+			loads = append(loads, key)
+			return chapters[key], nil
+		},
+		MaxLoaded: 2,
+		OnEvict:   func(key string, _ *yarnpb.Program) { evicted = append(evicted, key) },
+	}
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Handler:  rec,
+		Vars:     NewMapVariableStorage(),
+		Programs: p,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:start", "line:intro", "line:finale", "line:end"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(loads, []string{"ch1", "ch2", "ch3", "ch1"}); diff != "" {
+		t.Errorf("loads diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(evicted, []string{"ch1", "ch2"}); diff != "" {
+		t.Errorf("evicted diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(p.Loaded(), []string{"ch1", "ch3"}); diff != "" {
+		t.Errorf("p.Loaded() diff (-got +want):\n%s", diff)
+	}
+	if vm.Program != chapters["ch1"] {
+		t.Errorf("vm.Program = %q, want Chapter1", vm.Program.GetName())
+	}
+
+	if err := vm.Run("Nowhere"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("vm.Run(Nowhere) = %v, want %v", err, ErrNodeNotFound)
+	}
+}
+
+func TestChapterProviderLoadWithoutLock(t *testing.T) {
+	ch1 := NewProgramBuilder("Chapter1").Program()
+	ch2 := NewProgramBuilder("Chapter2").Program()
+
+	var p *ChapterProvider
+	release := make(chan struct{})
+	var loads int32
+	var evicted []string
+	p = &ChapterProvider{
+		Load: func(key string) (*yarnpb.Program, error) {
+			if key == "ch1" {
+				return ch1, nil
+			}
+			atomic.AddInt32(&loads, 1)
+			<-release
+			return ch2, nil
+		},
+		MaxLoaded: 1,
+		OnEvict: func(key string, _ *yarnpb.Program) {
+			// OnEvict can use the provider.
+			evicted = append(evicted, key)
+			if diff := cmp.Diff(p.Loaded(), []string{"ch2"}); diff != "" {
+				t.Errorf("p.Loaded() in OnEvict diff (-got +want):\n%s", diff)
+			}
+		},
+	}
+	if _, err := p.Chapter("ch1"); err != nil {
+		t.Fatalf("p.Chapter(ch1) = %v", err)
+	}
+
+	// Two concurrent requests for ch2 share one (blocked) load.
+	type result struct {
+		prog *yarnpb.Program
+		err  error
+	}
+	results := make(chan result)
+	for i := 0; i < 2; i++ {
+		go func() {
+			prog, err := p.Chapter("ch2")
+			results <- result{prog, err}
+		}()
+	}
+
+	// Meanwhile, loaded chapters are available.
+	if got, err := p.Chapter("ch1"); err != nil || got != ch1 {
+		t.Errorf("p.Chapter(ch1) = %p, %v, want %p, nil", got, err, ch1)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if r := <-results; r.err != nil || r.prog != ch2 {
+			t.Errorf("p.Chapter(ch2) = %p, %v, want %p, nil", r.prog, r.err, ch2)
+		}
+	}
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Errorf("Load called %d times for ch2, want 1", got)
+	}
+	if diff := cmp.Diff(evicted, []string{"ch1"}); diff != "" {
+		t.Errorf("evicted diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package quests maps Yarn variables onto quest states.
+//
+// By convention, each quest is a number variable declared in the Yarn
+// project with the prefix VarPrefix:
+//
+//	<<declare $quest_FindTheCat = 0>>
+//
+// The value is the quest's stage. Negative values mean the quest has failed;
+// otherwise the stage is compared with thresholds (by default, 1 for Active
+// and 100 for Complete). Dialogue advances a quest by setting the variable:
+//
+//	<<set $quest_FindTheCat to 1>>
+//
+// Tracker wraps the variable storage, so that it can report transitions
+// between states as they happen, and provides functions for querying quests
+// from dialogue.
+package quests // import "github.com/DrJosh9000/yarn/quests"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// VarPrefix is the prefix of quest variables.
+const VarPrefix = "$quest_"
+
+// State is the state of a quest.
+type State string
+
+// Quest states.
+const (
+	NotStarted State = "not_started"
+	Active     State = "active"
+	Complete   State = "complete"
+	Failed     State = "failed"
+)
+
+// Threshold maps quest stages at or above Stage to State.
+type Threshold struct {
+	Stage float32
+	State State
+}
+
+// DefaultThresholds are the thresholds used when Tracker.Thresholds is nil.
+var DefaultThresholds = []Threshold{
+	{Stage: 1, State: Active},
+	{Stage: 100, State: Complete},
+}
+
+// Transition describes a quest changing state.
+type Transition struct {
+	Quest              string
+	From, To           State
+	OldStage, NewStage float32
+}
+
+// Tracker is a VariableStorage that reports quest transitions. Use it as (or
+// in place of) the VM's Vars.
+type Tracker struct {
+	// Vars is the underlying storage.
+	Vars yarn.VariableStorage
+
+	// Thresholds, sorted by increasing Stage, determine the state of quests
+	// with non-negative stages. Stages below the first threshold are
+	// NotStarted. If nil, DefaultThresholds is used.
+	Thresholds []Threshold
+
+	// OnTransition, if not nil, is called after a store changes the state of
+	// a quest. It is called from the goroutine storing the value (typically
+	// the VM's goroutine).
+	OnTransition func(Transition)
+
+	mu       sync.Mutex
+	quests   map[string]string // quest name -> variable name
+	initial  map[string]float32
+	declared bool
+}
+
+// NewTracker returns a Tracker for the quests declared in prog (variables with
+// the prefix VarPrefix and a number as their initial value).
+func NewTracker(prog *yarnpb.Program, vars yarn.VariableStorage) *Tracker {
+	t := &Tracker{
+		Vars:     vars,
+		quests:   make(map[string]string),
+		initial:  make(map[string]float32),
+		declared: true,
+	}
+	for name, op := range prog.GetInitialValues() {
+		if !strings.HasPrefix(name, VarPrefix) {
+			continue
+		}
+		v, ok := op.GetValue().(*yarnpb.Operand_FloatValue)
+		if !ok {
+			continue
+		}
+		quest := strings.TrimPrefix(name, VarPrefix)
+		t.quests[quest] = name
+		t.initial[quest] = v.FloatValue
+	}
+	return t
+}
+
+// Quests returns the names of the known quests, sorted. These are the declared
+// quests, or, for a Tracker not created with NewTracker, the quests stored so
+// far.
+func (t *Tracker) Quests() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.quests))
+	for q := range t.quests {
+		names = append(names, q)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// questName returns the quest for a variable, if it is a quest variable.
+func (t *Tracker) questName(varName string) (string, bool) {
+	if !strings.HasPrefix(varName, VarPrefix) {
+		return "", false
+	}
+	quest := strings.TrimPrefix(varName, VarPrefix)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.quests[quest]; ok {
+		return quest, true
+	}
+	if t.declared {
+		return "", false
+	}
+	if t.quests == nil {
+		t.quests = make(map[string]string)
+	}
+	t.quests[quest] = varName
+	return quest, true
+}
+
+// GetValue gets a value from the underlying storage.
+func (t *Tracker) GetValue(name string) (any, bool) {
+	return t.Vars.GetValue(name)
+}
+
+// SetValue sets a value in the underlying storage, and reports a transition
+// if it changes the state of a quest.
+func (t *Tracker) SetValue(name string, value any) {
+	quest, ok := t.questName(name)
+	if !ok {
+		t.Vars.SetValue(name, value)
+		return
+	}
+	old := t.Stage(quest)
+	t.Vars.SetValue(name, value)
+	if t.OnTransition == nil {
+		return
+	}
+	stage := t.Stage(quest)
+	from, to := t.stateOf(old), t.stateOf(stage)
+	if from == to {
+		return
+	}
+	t.OnTransition(Transition{
+		Quest:    quest,
+		From:     from,
+		To:       to,
+		OldStage: old,
+		NewStage: stage,
+	})
+}
+
+// Stage returns the stage of a quest: the value of its variable, or its
+// declared initial value, or 0.
+func (t *Tracker) Stage(quest string) float32 {
+	if v, ok := t.Vars.GetValue(VarPrefix + quest); ok {
+		if f, err := yarn.ConvertToFloat32(v); err == nil {
+			return f
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.initial[quest]
+}
+
+// State returns the state of a quest.
+func (t *Tracker) State(quest string) State {
+	return t.stateOf(t.Stage(quest))
+}
+
+func (t *Tracker) stateOf(stage float32) State {
+	if stage < 0 {
+		return Failed
+	}
+	th := t.Thresholds
+	if th == nil {
+		th = DefaultThresholds
+	}
+	s := NotStarted
+	for _, x := range th {
+		if stage < x.Stage {
+			break
+		}
+		s = x.State
+	}
+	return s
+}
+
+// Library returns functions for querying quests from dialogue:
+//
+//	quest_state(name) -> string     e.g. "active"
+//	quest_stage(name) -> number
+//	quest_active(name) -> bool
+//	quest_complete(name) -> bool
+//	quest_failed(name) -> bool
+//
+// The functions return an error for quests that aren't known.
+func (t *Tracker) Library() yarn.FuncMap {
+	is := func(want State) func(string) (bool, error) {
+		return func(quest string) (bool, error) {
+			if err := t.check(quest); err != nil {
+				return false, err
+			}
+			return t.State(quest) == want, nil
+		}
+	}
+	return yarn.FuncMap{
+		"quest_state": func(quest string) (string, error) {
+			if err := t.check(quest); err != nil {
+				return "", err
+			}
+			return string(t.State(quest)), nil
+		},
+		"quest_stage": func(quest string) (float32, error) {
+			if err := t.check(quest); err != nil {
+				return 0, err
+			}
+			return t.Stage(quest), nil
+		},
+		"quest_active":   is(Active),
+		"quest_complete": is(Complete),
+		"quest_failed":   is(Failed),
+	}
+}
+
+// check returns an error if the quest is not known.
+func (t *Tracker) check(quest string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.quests[quest]; ok || !t.declared {
+		return nil
+	}
+	return fmt.Errorf("unknown quest %q", quest)
+}
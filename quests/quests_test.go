@@ -0,0 +1,117 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quests
+
+import (
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func questProgram() *yarnpb.Program {
+	return yarn.NewProgramBuilder("Quests").
+		InitialValue("$quest_FindTheCat", float32(0)).
+		InitialValue("$quest_Smuggler", float32(1)).
+		InitialValue("$gold", float32(10)).
+		InitialValue("$quest_Label", "not a quest").
+		Program()
+}
+
+func TestTracker(t *testing.T) {
+	prog := questProgram()
+	var got []Transition
+	tr := NewTracker(prog, yarn.NewMapVariableStorage())
+	tr.OnTransition = func(x Transition) { got = append(got, x) }
+
+	if diff := cmp.Diff(tr.Quests(), []string{"FindTheCat", "Smuggler"}); diff != "" {
+		t.Errorf("Quests() diff (-got +want):\n%s", diff)
+	}
+	if got, want := tr.State("Smuggler"), Active; got != want {
+		t.Errorf("State(Smuggler) = %q, want %q", got, want)
+	}
+
+	tr.SetValue("$quest_FindTheCat", float32(1))
+	tr.SetValue("$quest_FindTheCat", float32(2))
+	tr.SetValue("$gold", float32(20))
+	tr.SetValue("$quest_FindTheCat", float32(100))
+	tr.SetValue("$quest_Smuggler", float32(-1))
+
+	want := []Transition{
+		{Quest: "FindTheCat", From: NotStarted, To: Active, OldStage: 0, NewStage: 1},
+		{Quest: "FindTheCat", From: Active, To: Complete, OldStage: 2, NewStage: 100},
+		{Quest: "Smuggler", From: Active, To: Failed, OldStage: 1, NewStage: -1},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("transitions diff (-got +want):\n%s", diff)
+	}
+	if v, _ := tr.GetValue("$gold"); v != float32(20) {
+		t.Errorf("GetValue($gold) = %v, want 20", v)
+	}
+}
+
+func TestTrackerLibrary(t *testing.T) {
+	prog := questProgram()
+	tr := NewTracker(prog, yarn.NewMapVariableStorage())
+	tr.Thresholds = []Threshold{
+		{Stage: 1, State: Active},
+		{Stage: 3, State: Complete},
+	}
+	tr.SetValue("$quest_FindTheCat", float32(3))
+	vm := &yarn.VirtualMachine{
+		Program: prog,
+		Vars:    tr,
+		FuncMap: tr.Library(),
+	}
+
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{`quest_state("FindTheCat")`, "complete"},
+		{`quest_complete("FindTheCat")`, true},
+		{`quest_active("Smuggler") and quest_stage("Smuggler") == 1`, true},
+		{`quest_failed("Smuggler")`, false},
+	}
+	for _, test := range tests {
+		got, err := vm.Evaluate(yarn.MustCompileExpression(test.expr))
+		if err != nil {
+			t.Fatalf("Evaluate(%s) error = %v", test.expr, err)
+		}
+		if got != test.want {
+			t.Errorf("Evaluate(%s) = %v, want %v", test.expr, got, test.want)
+		}
+	}
+
+	if _, err := vm.Evaluate(yarn.MustCompileExpression(`quest_state("Nope")`)); err == nil {
+		t.Errorf("Evaluate(quest_state(Nope)) error = nil, want error")
+	}
+}
+
+func TestTrackerUndeclared(t *testing.T) {
+	var got []Transition
+	tr := &Tracker{
+		Vars:         yarn.NewMapVariableStorage(),
+		OnTransition: func(x Transition) { got = append(got, x) },
+	}
+	tr.SetValue("$quest_Anything", float32(1))
+	if len(got) != 1 || got[0].Quest != "Anything" || got[0].To != Active {
+		t.Errorf("transitions = %v, want Anything becoming active", got)
+	}
+	if diff := cmp.Diff(tr.Quests(), []string{"Anything"}); diff != "" {
+		t.Errorf("Quests() diff (-got +want):\n%s", diff)
+	}
+}
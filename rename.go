@@ -0,0 +1,308 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// ErrNameInUse is returned by RenameVariable and RenameNode when the new name
+// is already used in the program.
+const ErrNameInUse = virtualMachineError("name already in use")
+
+// RenameEdit is a change made by a rename.
+type RenameEdit struct {
+	// Node is the node that was changed, if any.
+	Node string `json:"node,omitempty"`
+
+	// PC is the index of the changed instruction within the node, or -1 if
+	// the change was not to an instruction.
+	PC int `json:"pc"`
+
+	// LineID is the ID of the changed string table row, if any.
+	LineID string `json:"line_id,omitempty"`
+
+	// Before and After describe the changed item.
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// String formats the edit as a small diff.
+func (e RenameEdit) String() string {
+	var loc string
+	switch {
+	case e.LineID != "":
+		loc = "string table row " + e.LineID
+	case e.PC >= 0:
+		loc = fmt.Sprintf("%s:%06d", e.Node, e.PC)
+	case e.Node != "":
+		loc = "node " + e.Node
+	default:
+		loc = "program"
+	}
+	return fmt.Sprintf("%s\n- %s\n+ %s", loc, e.Before, e.After)
+}
+
+// RenameResult is the result of a rename. The inputs to the rename are not
+// modified, so a dry run is a rename whose Program and Strings are discarded.
+type RenameResult struct {
+	Program *yarnpb.Program
+	Strings *StringTable // nil if the rename was not given a string table
+	Edits   []RenameEdit
+}
+
+// RenameVariable renames a variable throughout a program: its initial value,
+// every instruction that reads or stores it, and node parameter and locals
+// headers (see NodeParams and NodeLocals). Renaming a variable that isn't used
+// results in no edits. The string table (which may be nil) is copied to the
+// result unchanged, as compiled lines refer to variables only through
+// substitutions.
+//
+// Since .yarn sources can't be parsed by this package, they must be renamed
+// separately.
+func RenameVariable(prog *yarnpb.Program, st *StringTable, oldName, newName string) (*RenameResult, error) {
+	for _, name := range []string{oldName, newName} {
+		if !strings.HasPrefix(name, "$") || strings.HasPrefix(name, "$"+InternalPrefix) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+	}
+	if oldName != newName && programVariables(prog)[newName] {
+		return nil, fmt.Errorf("renaming variable %q to %q: %w", oldName, newName, ErrNameInUse)
+	}
+	r := newRenamer(prog, st)
+	if oldName != newName {
+		r.renameVariable(oldName, newName)
+	}
+	return r.result(), nil
+}
+
+// RenameNode renames a node throughout a program and string table (which may
+// be nil): the node itself and its title header, jumps to it (including jump
+// commands), calls to visited and visited_count with it, its visit count
+// variable, variant_of headers naming it (see VariantSelector), and the node
+// column of its string table rows. References namespaced with the program's
+// name (such as "prog:Node", see Router) are renamed too, but references from
+// other programs are not. Only jumps and calls with constant node names can be
+// renamed.
+//
+// Since .yarn sources can't be parsed by this package, they must be renamed
+// separately.
+func RenameNode(prog *yarnpb.Program, st *StringTable, oldName, newName string) (*RenameResult, error) {
+	if prog.Nodes[oldName] == nil {
+		return nil, fmt.Errorf("renaming node %q: %w", oldName, ErrNodeNotFound)
+	}
+	if newName == "" || strings.Contains(newName, ProgramSeparator) {
+		return nil, fmt.Errorf("invalid node name %q", newName)
+	}
+	if oldName != newName && prog.Nodes[newName] != nil {
+		return nil, fmt.Errorf("renaming node %q to %q: %w", oldName, newName, ErrNameInUse)
+	}
+	r := newRenamer(prog, st)
+	if oldName != newName {
+		r.renameNode(oldName, newName)
+	}
+	return r.result(), nil
+}
+
+// renamer applies a rename to copies of a program and string table.
+type renamer struct {
+	prog  *yarnpb.Program
+	st    *StringTable
+	edits []RenameEdit
+}
+
+func newRenamer(prog *yarnpb.Program, st *StringTable) *renamer {
+	r := &renamer{prog: proto.Clone(prog).(*yarnpb.Program)}
+	if st != nil {
+		copied := *st
+		copied.Table = make(map[string]*StringTableRow, len(st.Table))
+		r.st = &copied
+		for id, row := range st.Table {
+			r.st.Table[id] = row
+		}
+	}
+	return r
+}
+
+func (r *renamer) result() *RenameResult {
+	return &RenameResult{Program: r.prog, Strings: r.st, Edits: r.edits}
+}
+
+// setOperand replaces a string operand of an instruction.
+func (r *renamer) setOperand(node *yarnpb.Node, pc, i int, value string) {
+	inst := node.Instructions[pc]
+	before := FormatInstruction(inst)
+	inst.Operands[i] = stringOperand(value)
+	r.edits = append(r.edits, RenameEdit{Node: node.Name, PC: pc, Before: before, After: FormatInstruction(inst)})
+}
+
+// setHeader replaces the value of a node header.
+func (r *renamer) setHeader(node *yarnpb.Node, h *yarnpb.Header, value string) {
+	before := h.Key + ": " + h.Value
+	h.Value = value
+	r.edits = append(r.edits, RenameEdit{Node: node.Name, PC: -1, Before: before, After: h.Key + ": " + h.Value})
+}
+
+func (r *renamer) renameVariable(oldName, newName string) {
+	if v, ok := r.prog.InitialValues[oldName]; ok {
+		delete(r.prog.InitialValues, oldName)
+		r.prog.InitialValues[newName] = v
+		r.edits = append(r.edits, RenameEdit{PC: -1, Before: "initial value " + oldName, After: "initial value " + newName})
+	}
+	for _, name := range sortedNodeNames(r.prog) {
+		node := r.prog.Nodes[name]
+		for _, h := range node.Headers {
+			if h.Key != ParamsHeader && h.Key != LocalsHeader {
+				continue
+			}
+			list := strings.Split(h.Value, ",")
+			changed := false
+			for i, v := range list {
+				if strings.TrimSpace(v) == oldName {
+					list[i] = strings.Replace(v, oldName, newName, 1)
+					changed = true
+				}
+			}
+			if changed {
+				r.setHeader(node, h, strings.Join(list, ","))
+			}
+		}
+		for pc, inst := range node.Instructions {
+			switch inst.GetOpcode() {
+			case yarnpb.Instruction_PUSH_VARIABLE, yarnpb.Instruction_STORE_VARIABLE:
+				if len(inst.Operands) > 0 && inst.Operands[0].GetStringValue() == oldName {
+					r.setOperand(node, pc, 0, newName)
+				}
+			}
+		}
+	}
+}
+
+func (r *renamer) renameNode(oldName, newName string) {
+	node := r.prog.Nodes[oldName]
+	delete(r.prog.Nodes, oldName)
+	r.prog.Nodes[newName] = node
+	node.Name = newName
+	r.edits = append(r.edits, RenameEdit{Node: newName, PC: -1, Before: "node " + oldName, After: "node " + newName})
+
+	for _, name := range sortedNodeNames(r.prog) {
+		n := r.prog.Nodes[name]
+		for _, h := range n.Headers {
+			switch {
+			case h.Key == "title" && n == node, h.Key == VariantOfHeader:
+				if strings.TrimSpace(h.Value) == oldName {
+					r.setHeader(n, h, newName)
+				}
+			}
+		}
+		for pc, inst := range n.Instructions {
+			if len(inst.Operands) == 0 {
+				continue
+			}
+			switch inst.GetOpcode() {
+			case yarnpb.Instruction_PUSH_STRING:
+				if !isNodeReference(n.Instructions, pc) {
+					continue
+				}
+				if ref, ok := r.renameNodeRef(inst.Operands[0].GetStringValue(), oldName, newName); ok {
+					r.setOperand(n, pc, 0, ref)
+				}
+			case yarnpb.Instruction_RUN_COMMAND:
+				if cmd, ok := r.renameJump(inst.Operands[0].GetStringValue(), oldName, newName); ok {
+					r.setOperand(n, pc, 0, cmd)
+				}
+			}
+		}
+	}
+
+	r.renameVariable(visitingVar(oldName), visitingVar(newName))
+
+	if r.st == nil {
+		return
+	}
+	for _, id := range sortedKeys(r.st.Table) {
+		row := r.st.Table[id]
+		if row == nil || row.Node != oldName {
+			continue
+		}
+		renamed := *row
+		renamed.Node = newName
+		r.st.Table[id] = &renamed
+		r.edits = append(r.edits, RenameEdit{LineID: id, PC: -1, Before: "node: " + oldName, After: "node: " + newName})
+	}
+}
+
+// renameNodeRef returns the renamed form of a node reference, which may be
+// namespaced with the program name (see Router).
+func (r *renamer) renameNodeRef(ref, oldName, newName string) (string, bool) {
+	switch prefix := r.prog.Name + ProgramSeparator; ref {
+	case oldName:
+		return newName, true
+	case prefix + oldName:
+		return prefix + newName, true
+	}
+	return "", false
+}
+
+// renameJump returns the renamed form of a jump command (either "jump Node" or
+// "jump Node(args)", as run by execInternalCommand) that jumps to oldName.
+func (r *renamer) renameJump(cmd, oldName, newName string) (string, bool) {
+	fields := strings.Fields(cmd)
+	if len(fields) < 2 || fields[0] != "jump" {
+		return "", false
+	}
+	// Find where the node name starts, after "jump" and any spaces.
+	start := strings.Index(cmd, "jump") + len("jump")
+	rest := cmd[start:]
+	start += len(rest) - len(strings.TrimLeftFunc(rest, unicode.IsSpace))
+	name, _, ok := parseNodeCall(rest)
+	if !ok {
+		if len(fields) != 2 {
+			return "", false
+		}
+		name = fields[1]
+	}
+	ref, ok := r.renameNodeRef(name, oldName, newName)
+	if !ok {
+		return "", false
+	}
+	return cmd[:start] + ref + cmd[start+len(name):], true
+}
+
+// isNodeReference reports whether the PUSH_STRING instruction at pc pushes a
+// node name: either it is followed by RUN_NODE, or it is the only argument to
+// visited or visited_count.
+func isNodeReference(insts []*yarnpb.Instruction, pc int) bool {
+	if pc+1 < len(insts) && insts[pc+1].GetOpcode() == yarnpb.Instruction_RUN_NODE {
+		return true
+	}
+	if pc+2 >= len(insts) || insts[pc+1].GetOpcode() != yarnpb.Instruction_PUSH_FLOAT {
+		return false
+	}
+	call := insts[pc+2]
+	if call.GetOpcode() != yarnpb.Instruction_CALL_FUNC || len(call.Operands) == 0 {
+		return false
+	}
+	switch strings.TrimPrefix(call.Operands[0].GetStringValue(), InternalPrefix) {
+	case "visited", "visited_count":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+)
+
+func renameProgram() (*yarnpb.Program, *StringTable) {
+	pb := NewProgramBuilder("Rename")
+	pb.InitialValue("$gold", float32(0))
+	pb.Node("Start").
+		Header("title", "Start").
+		PushString("Shop").
+		Call("visited", 1).
+		Pop().
+		PushVariable("$gold").
+		Line("line:hi", 1).
+		RunNode("Shop")
+	pb.Node("Shop").
+		Header("title", "Shop").
+		Header(LocalsHeader, "$gold, $_x").
+		PushFloat(1).
+		StoreVariable("$gold").
+		Pop().
+		PushString("Shop").
+		Line("line:shop", 1)
+	pb.Node("Shop2").
+		Header(VariantOfHeader, "Shop")
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:hi":   {ID: "line:hi", Text: "Hi, you have {0} gold.", File: "a.yarn", Node: "Start", LineNumber: 2},
+		"line:shop": {ID: "line:shop", Text: "Welcome to {0}.", File: "a.yarn", Node: "Shop", LineNumber: 8},
+	}}
+	return pb.Program(), st
+}
+
+func TestRenameVariable(t *testing.T) {
+	prog, st := renameProgram()
+	orig := proto.Clone(prog)
+
+	res, err := RenameVariable(prog, st, "$gold", "$coins")
+	if err != nil {
+		t.Fatalf("RenameVariable = %v", err)
+	}
+	if !proto.Equal(prog, orig) {
+		t.Errorf("RenameVariable modified its input")
+	}
+	var got []string
+	for _, e := range res.Edits {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"program\n- initial value $gold\n+ initial value $coins",
+		"node Shop\n- locals: $gold, $_x\n+ locals: $coins, $_x",
+		"Shop:000001\n- STORE_VARIABLE \"$gold\"\n+ STORE_VARIABLE \"$coins\"",
+		"Start:000004\n- PUSH_VARIABLE \"$gold\"\n+ PUSH_VARIABLE \"$coins\"",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("edits diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(sortedKeys(programVariables(res.Program)), []string{"$coins"}); diff != "" {
+		t.Errorf("variables diff (-got +want):\n%s", diff)
+	}
+
+	if res, err := RenameVariable(prog, st, "$silver", "$coins"); err != nil || len(res.Edits) != 0 {
+		t.Errorf("RenameVariable(unused) = %v, %v, want no edits, nil", res, err)
+	}
+	pb := NewProgramBuilder("Conflict")
+	pb.Node("Start").PushVariable("$a").PushVariable("$b")
+	if _, err := RenameVariable(pb.Program(), nil, "$a", "$b"); !errors.Is(err, ErrNameInUse) {
+		t.Errorf("RenameVariable($a, $b) = %v, want %v", err, ErrNameInUse)
+	}
+	if _, err := RenameVariable(prog, nil, "$gold", "coins"); err == nil {
+		t.Errorf("RenameVariable($gold, coins) = nil, want error")
+	}
+}
+
+func TestRenameNode(t *testing.T) {
+	prog, st := renameProgram()
+	prog.InitialValues[visitingVar("Shop")] = &yarnpb.Operand{Value: &yarnpb.Operand_FloatValue{FloatValue: 2}}
+	orig := proto.Clone(prog)
+
+	res, err := RenameNode(prog, st, "Shop", "Store")
+	if err != nil {
+		t.Fatalf("RenameNode = %v", err)
+	}
+	if !proto.Equal(prog, orig) {
+		t.Errorf("RenameNode modified its input")
+	}
+	if st.Table["line:shop"].Node != "Shop" {
+		t.Errorf("RenameNode modified its input string table")
+	}
+	var got []string
+	for _, e := range res.Edits {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"node Store\n- node Shop\n+ node Store",
+		"node Shop2\n- variant_of: Shop\n+ variant_of: Store",
+		"Start:000000\n- PUSH_STRING \"Shop\"\n+ PUSH_STRING \"Store\"",
+		"Start:000006\n- PUSH_STRING \"Shop\"\n+ PUSH_STRING \"Store\"",
+		"node Store\n- title: Shop\n+ title: Store",
+		"program\n- initial value $Yarn.Internal.Visiting.Shop\n+ initial value $Yarn.Internal.Visiting.Store",
+		"string table row line:shop\n- node: Shop\n+ node: Store",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("edits diff (-got +want):\n%s", diff)
+	}
+	if diags := ValidateProgram(res.Program); HasErrors(diags) {
+		t.Errorf("ValidateProgram(renamed) = %v", diags)
+	}
+
+	// The string "Shop" that is a line substitution is not a node reference.
+	store := res.Program.Nodes["Store"]
+	if got := store.Instructions[3].Operands[0].GetStringValue(); got != "Shop" {
+		t.Errorf("substitution = %q, want %q", got, "Shop")
+	}
+
+	var buf strings.Builder
+	if err := WriteStringTable(&buf, res.Strings); err != nil {
+		t.Fatalf("WriteStringTable = %v", err)
+	}
+	wantCSV := "id,text,file,node,lineNumber\n" +
+		"line:hi,\"Hi, you have {0} gold.\",a.yarn,Start,2\n" +
+		"line:shop,Welcome to {0}.,a.yarn,Store,8\n"
+	if diff := cmp.Diff(buf.String(), wantCSV); diff != "" {
+		t.Errorf("WriteStringTable diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := RenameNode(prog, st, "Nope", "Store"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("RenameNode(Nope) = %v, want %v", err, ErrNodeNotFound)
+	}
+	if _, err := RenameNode(prog, st, "Shop", "Start"); !errors.Is(err, ErrNameInUse) {
+		t.Errorf("RenameNode(Shop, Start) = %v, want %v", err, ErrNameInUse)
+	}
+}
+
+func TestRenameNodeJumps(t *testing.T) {
+	pb := NewProgramBuilder("Rename")
+	pb.Node("Start").
+		Command("jump Shop", 0).
+		Command("jump Shop(1, \"x\")", 0).
+		Command("jump  Shopkeeper", 0).
+		Command("jumping Shop", 0).
+		Command("jump Rename:Shop", 0).
+		RunNode("Rename:Shop").
+		RunNode("Other:Shop")
+	pb.Node("Shop")
+	pb.Node("Shopkeeper")
+
+	res, err := RenameNode(pb.Program(), nil, "Shop", "Store")
+	if err != nil {
+		t.Fatalf("RenameNode = %v", err)
+	}
+	var got []string
+	for _, e := range res.Edits {
+		got = append(got, e.String())
+	}
+	want := []string{
+		"node Store\n- node Shop\n+ node Store",
+		"Start:000000\n- RUN_COMMAND \"jump Shop\" 0\n+ RUN_COMMAND \"jump Store\" 0",
+		"Start:000001\n- RUN_COMMAND \"jump Shop(1, \\\"x\\\")\" 0\n+ RUN_COMMAND \"jump Store(1, \\\"x\\\")\" 0",
+		"Start:000004\n- RUN_COMMAND \"jump Rename:Shop\" 0\n+ RUN_COMMAND \"jump Rename:Store\" 0",
+		"Start:000005\n- PUSH_STRING \"Rename:Shop\"\n+ PUSH_STRING \"Rename:Store\"",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("edits diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"google.golang.org/protobuf/proto"
+)
+
+// NewInstruction makes an instruction, e.g. for inserting with an
+// InstructionCursor. Operands may be strings, bools, or numbers (which are
+// stored as float32, like all numeric operands). It panics if an operand has
+// any other type.
+func NewInstruction(op yarnpb.Instruction_OpCode, operands ...any) *yarnpb.Instruction {
+	inst := &yarnpb.Instruction{Opcode: op}
+	for _, x := range operands {
+		switch x := x.(type) {
+		case string:
+			inst.Operands = append(inst.Operands, stringOperand(x))
+		case bool:
+			inst.Operands = append(inst.Operands, boolOperand(x))
+		default:
+			f, err := ConvertToFloat32(x)
+			if err != nil {
+				panic(fmt.Sprintf("NewInstruction: operand %v (%T): %v", x, x, err))
+			}
+			inst.Operands = append(inst.Operands, floatOperand(f))
+		}
+	}
+	return inst
+}
+
+// InstructionCursor points at an instruction during WalkInstructions or
+// RewriteInstructions. During a rewrite, the instruction can be changed in
+// place (e.g. its operands), replaced, deleted, or surrounded with new
+// instructions.
+type InstructionCursor struct {
+	// Node is the node containing the instruction.
+	Node *yarnpb.Node
+
+	// PC is the index of the instruction within the node, before any
+	// rewriting.
+	PC int
+
+	// Inst is the instruction.
+	Inst *yarnpb.Instruction
+
+	// Labels are the labels (in the original node) that refer to the
+	// instruction.
+	Labels []string
+
+	rewriting     bool
+	before, after []*yarnpb.Instruction
+	replacement   []*yarnpb.Instruction
+	replaced      bool
+	newLabels     []string
+}
+
+// Opcode returns the instruction's opcode.
+func (c *InstructionCursor) Opcode() yarnpb.Instruction_OpCode { return c.Inst.GetOpcode() }
+
+// NumOperands returns the number of operands of the instruction.
+func (c *InstructionCursor) NumOperands() int { return len(c.Inst.GetOperands()) }
+
+// StringOperand returns operand i, if it exists and is a string.
+func (c *InstructionCursor) StringOperand(i int) (string, bool) {
+	op := c.operand(i)
+	v, ok := op.GetValue().(*yarnpb.Operand_StringValue)
+	if !ok {
+		return "", false
+	}
+	return v.StringValue, true
+}
+
+// FloatOperand returns operand i, if it exists and is a number.
+func (c *InstructionCursor) FloatOperand(i int) (float32, bool) {
+	op := c.operand(i)
+	v, ok := op.GetValue().(*yarnpb.Operand_FloatValue)
+	if !ok {
+		return 0, false
+	}
+	return v.FloatValue, true
+}
+
+// BoolOperand returns operand i, if it exists and is a bool.
+func (c *InstructionCursor) BoolOperand(i int) (bool, bool) {
+	op := c.operand(i)
+	v, ok := op.GetValue().(*yarnpb.Operand_BoolValue)
+	if !ok {
+		return false, false
+	}
+	return v.BoolValue, true
+}
+
+// SetOperand replaces operand i (which must exist) with a string, bool, or
+// number, as for NewInstruction. It can only be used while rewriting.
+func (c *InstructionCursor) SetOperand(i int, x any) {
+	c.mustRewrite("SetOperand")
+	c.Inst.Operands[i] = NewInstruction(0, x).Operands[0]
+}
+
+func (c *InstructionCursor) operand(i int) *yarnpb.Operand {
+	ops := c.Inst.GetOperands()
+	if i < 0 || i >= len(ops) {
+		return nil
+	}
+	return ops[i]
+}
+
+// InsertBefore inserts instructions before the instruction. Jumps to the
+// instruction (via its labels) jump to the first inserted instruction, so
+// that inserted instructions run whenever the instruction would. It can only
+// be used while rewriting.
+func (c *InstructionCursor) InsertBefore(insts ...*yarnpb.Instruction) {
+	c.mustRewrite("InsertBefore")
+	c.before = append(c.before, insts...)
+}
+
+// InsertAfter inserts instructions after the instruction. It can only be
+// used while rewriting.
+func (c *InstructionCursor) InsertAfter(insts ...*yarnpb.Instruction) {
+	c.mustRewrite("InsertAfter")
+	c.after = append(c.after, insts...)
+}
+
+// Replace replaces the instruction with zero or more instructions. It can
+// only be used while rewriting.
+func (c *InstructionCursor) Replace(insts ...*yarnpb.Instruction) {
+	c.mustRewrite("Replace")
+	c.replacement = insts
+	c.replaced = true
+}
+
+// Delete deletes the instruction. Labels referring to it then refer to the
+// next instruction. It can only be used while rewriting.
+func (c *InstructionCursor) Delete() { c.Replace() }
+
+// AddLabel adds a label that refers to the same place as the instruction's
+// labels (before any inserted instructions), e.g. for use by jumps inserted
+// elsewhere in the node. It can only be used while rewriting.
+func (c *InstructionCursor) AddLabel(name string) {
+	c.mustRewrite("AddLabel")
+	c.newLabels = append(c.newLabels, name)
+}
+
+func (c *InstructionCursor) mustRewrite(method string) {
+	if !c.rewriting {
+		panic("InstructionCursor." + method + " called outside RewriteInstructions")
+	}
+}
+
+// WalkInstructions calls visit for each instruction in the program, in node
+// name order, then instruction order. The cursor must not be modified or used
+// after visit returns. If visit returns an error, the walk stops and returns
+// it.
+func WalkInstructions(prog *yarnpb.Program, visit func(*InstructionCursor) error) error {
+	for _, name := range sortedNodeNames(prog) {
+		node := prog.Nodes[name]
+		labels := labelsByPC(node)
+		for pc, inst := range node.Instructions {
+			c := &InstructionCursor{Node: node, PC: pc, Inst: inst, Labels: labels[pc]}
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// RewriteInstructions rewrites a copy of the program: it calls rewrite for
+// each instruction (in the same order as WalkInstructions), then rebuilds
+// each node from the edits made with the cursor, adjusting its label table
+// so that jumps and options still reach the same instructions. The original
+// program is not modified. If rewrite returns an error, the rewrite stops and
+// returns it.
+//
+// Rewrites can be used to write optimization, obfuscation, or
+// instrumentation passes. For example, this counts how many times each line
+// is delivered, using a custom function:
+//
+//	prog, err := yarn.RewriteInstructions(prog, func(c *yarn.InstructionCursor) error {
+//		if c.Opcode() != yarnpb.Instruction_RUN_LINE {
+//			return nil
+//		}
+//		id, _ := c.StringOperand(0)
+//		c.InsertBefore(
+//			yarn.NewInstruction(yarnpb.Instruction_PUSH_STRING, id),
+//			yarn.NewInstruction(yarnpb.Instruction_PUSH_FLOAT, 1),
+//			yarn.NewInstruction(yarnpb.Instruction_CALL_FUNC, "count_line"),
+//			yarn.NewInstruction(yarnpb.Instruction_POP),
+//		)
+//		return nil
+//	})
+func RewriteInstructions(prog *yarnpb.Program, rewrite func(*InstructionCursor) error) (*yarnpb.Program, error) {
+	out := proto.Clone(prog).(*yarnpb.Program)
+	for _, name := range sortedNodeNames(out) {
+		node := out.Nodes[name]
+		labels := labelsByPC(node)
+		newPC := make([]int32, len(node.Instructions)+1)
+		var insts []*yarnpb.Instruction
+		newLabels := make(map[string]int32)
+		for pc, inst := range node.Instructions {
+			c := &InstructionCursor{Node: node, PC: pc, Inst: inst, Labels: labels[pc], rewriting: true}
+			if err := rewrite(c); err != nil {
+				return nil, fmt.Errorf("rewriting %s:%06d: %w", name, pc, err)
+			}
+			newPC[pc] = int32(len(insts))
+			for _, l := range c.newLabels {
+				newLabels[l] = newPC[pc]
+			}
+			insts = append(insts, c.before...)
+			if c.replaced {
+				insts = append(insts, c.replacement...)
+			} else {
+				insts = append(insts, c.Inst)
+			}
+			insts = append(insts, c.after...)
+		}
+		newPC[len(node.Instructions)] = int32(len(insts))
+
+		for l, pc := range node.Labels {
+			if pc >= 0 && int(pc) < len(newPC) {
+				node.Labels[l] = newPC[pc]
+			}
+		}
+		if node.Labels == nil && len(newLabels) > 0 {
+			node.Labels = make(map[string]int32, len(newLabels))
+		}
+		for l, pc := range newLabels {
+			if _, exists := node.Labels[l]; exists {
+				return nil, fmt.Errorf("rewriting node %q: label %q already exists", name, l)
+			}
+			node.Labels[l] = pc
+		}
+		node.Instructions = insts
+	}
+	return out, nil
+}
+
+// labelsByPC inverts the label table of a node.
+func labelsByPC(node *yarnpb.Node) map[int][]string {
+	labels := make(map[int][]string)
+	for _, l := range sortedKeys(node.Labels) {
+		pc := int(node.Labels[l])
+		labels[pc] = append(labels[pc], l)
+	}
+	return labels
+}
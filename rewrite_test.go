@@ -0,0 +1,110 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRewriteInstructions(t *testing.T) {
+	pb := NewProgramBuilder("Rewrite")
+	pb.Node("Start").
+		PushBool(false).
+		JumpIfFalse("skip").
+		Line("line:a", 0).
+		Label("skip").
+		Line("line:b", 0).
+		Stop()
+	prog := pb.Program()
+
+	var lines []string
+	err := WalkInstructions(prog, func(c *InstructionCursor) error {
+		if c.Opcode() == yarnpb.Instruction_RUN_LINE {
+			id, _ := c.StringOperand(0)
+			lines = append(lines, id)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("WalkInstructions = %v", err)
+	}
+	if diff := cmp.Diff(lines, []string{"line:a", "line:b"}); diff != "" {
+		t.Errorf("walked lines diff (-got +want):\n%s", diff)
+	}
+
+	// Count lines with a custom function, and change line:b to line:c.
+	rewritten, err := RewriteInstructions(prog, func(c *InstructionCursor) error {
+		if c.Opcode() != yarnpb.Instruction_RUN_LINE {
+			return nil
+		}
+		id, _ := c.StringOperand(0)
+		c.InsertBefore(
+			NewInstruction(yarnpb.Instruction_PUSH_STRING, id),
+			NewInstruction(yarnpb.Instruction_PUSH_FLOAT, 1),
+			NewInstruction(yarnpb.Instruction_CALL_FUNC, "count_line"),
+			NewInstruction(yarnpb.Instruction_POP),
+		)
+		if id == "line:b" {
+			c.SetOperand(0, "line:c")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RewriteInstructions = %v", err)
+	}
+	if got, want := len(prog.Nodes["Start"].Instructions), 5; got != want {
+		t.Errorf("original program has %d instructions, want %d", got, want)
+	}
+
+	var counted []string
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Program: rewritten,
+		Handler: rec,
+		Vars:    NewMapVariableStorage(),
+		FuncMap: FuncMap{
+			"count_line": func(id string) bool {
+				counted = append(counted, id)
+				return true
+			},
+		},
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:c"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(counted, []string{"line:b"}); diff != "" {
+		t.Errorf("counted diff (-got +want):\n%s", diff)
+	}
+
+	// Deleting line:a moves the skip label back.
+	rewritten, err = RewriteInstructions(prog, func(c *InstructionCursor) error {
+		if id, _ := c.StringOperand(0); id == "line:a" {
+			c.Delete()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RewriteInstructions = %v", err)
+	}
+	if got, want := rewritten.Nodes["Start"].Labels["skip"], int32(2); got != want {
+		t.Errorf("skip label = %d, want %d", got, want)
+	}
+}
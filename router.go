@@ -0,0 +1,214 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ProgramSeparator separates the program name from the node name in a
+// namespaced node name, e.g. "chapter2:Intro".
+const ProgramSeparator = ":"
+
+// ErrProgramNotFound is returned when a namespaced node name refers to a
+// program that the Router doesn't have and can't load.
+const ErrProgramNotFound = virtualMachineError("program not found")
+
+// SplitNodeName splits a namespaced node name ("program:Node") into the
+// program name and node name. If the name is not namespaced, program is
+// empty.
+func SplitNodeName(name string) (program, node string) {
+	program, node, found := strings.Cut(name, ProgramSeparator)
+	if !found {
+		return "", name
+	}
+	return program, node
+}
+
+// Router holds several programs by name, so that dialogue can jump between
+// them, e.g. so that each chapter of a large game can be compiled to its own
+// .yarnc file and loaded only when it is needed. Set VirtualMachine.Router to
+// use it. Then a node in another program can be addressed as "program:Node"
+// (in Run, SetNode, jumps, and option destinations), and entering it
+// switches the VM's Program. Node names without a program refer to nodes in
+// the current program, so to jump back to the first program, it must also be
+// added to the router. Router is safe for concurrent use, and can be shared
+// between VMs.
+type Router struct {
+	// Load, if not nil, is called to load programs that have not been added,
+	// for example:
+	//
+	//	Load: func(name string) (*yarnpb.Program, error) {
+	//		return yarn.LoadProgramFile(filepath.Join("dialogue", name+".yarnc"))
+	//	}
+	//
+	// It is called without holding the Router's lock, so a slow load doesn't
+	// hold up other programs, and Load may use the Router (but not to get
+	// the program it is loading). Concurrent requests for the same program
+	// share one call.
+	Load func(name string) (*yarnpb.Program, error)
+
+	mu       sync.Mutex
+	programs map[string]*yarnpb.Program
+	loading  map[string]*programLoad
+}
+
+// programLoad is a call to load a program in progress (see Router.Load and
+// ChapterProvider.Load).
+type programLoad struct {
+	done chan struct{} // closed when prog and err are set
+	prog *yarnpb.Program
+	err  error
+}
+
+// Add adds (or replaces) a program.
+func (r *Router) Add(name string, prog *yarnpb.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.programs == nil {
+		r.programs = make(map[string]*yarnpb.Program)
+	}
+	r.programs[name] = prog
+}
+
+// Replace adds (or replaces) several programs at once, so that a VM entering
+// nodes in them never sees a mixture of old and new programs (e.g. when
+// applying a content update). VMs already running a replaced program keep
+// running it until they next enter a node with a namespaced name.
+func (r *Router) Replace(progs map[string]*yarnpb.Program) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.programs == nil {
+		r.programs = make(map[string]*yarnpb.Program)
+	}
+	for name, prog := range progs {
+		r.programs[name] = prog
+	}
+}
+
+// Program returns the program with the name, loading it with Load if
+// necessary.
+func (r *Router) Program(name string) (*yarnpb.Program, error) {
+	r.mu.Lock()
+	if prog := r.programs[name]; prog != nil {
+		r.mu.Unlock()
+		return prog, nil
+	}
+	if r.Load == nil {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("%w: %q", ErrProgramNotFound, name)
+	}
+	if l := r.loading[name]; l != nil {
+		// Another goroutine is loading it.
+		r.mu.Unlock()
+		<-l.done
+		return l.prog, l.err
+	}
+	l := &programLoad{done: make(chan struct{})}
+	if r.loading == nil {
+		r.loading = make(map[string]*programLoad)
+	}
+	r.loading[name] = l
+	r.mu.Unlock()
+
+	l.prog, l.err = r.load(name)
+
+	r.mu.Lock()
+	delete(r.loading, name)
+	if prog := r.programs[name]; prog != nil {
+		// Added while loading, so it is newer.
+		l.prog, l.err = prog, nil
+	} else if l.err == nil {
+		if r.programs == nil {
+			r.programs = make(map[string]*yarnpb.Program)
+		}
+		r.programs[name] = l.prog
+	}
+	r.mu.Unlock()
+	close(l.done)
+	return l.prog, l.err
+}
+
+// load calls Load.
+func (r *Router) load(name string) (*yarnpb.Program, error) {
+	prog, err := r.Load(name)
+	if err != nil {
+		return nil, fmt.Errorf("loading program %q: %w", name, err)
+	}
+	if prog == nil {
+		return nil, fmt.Errorf("%w: %q", ErrProgramNotFound, name)
+	}
+	return prog, nil
+}
+
+// Names returns the names of the programs that have been added or loaded.
+func (r *Router) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return sortedKeys(r.programs)
+}
+
+// nameOf returns the name of the program, or "" if the router doesn't have
+// it.
+func (r *Router) nameOf(prog *yarnpb.Program) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for name, p := range r.programs {
+		if p == prog {
+			return name
+		}
+	}
+	return ""
+}
+
+// resolveNode finds the program and node for a (possibly namespaced) node
+// name, consulting Programs for nodes that aren't in the current program.
+func (vm *VirtualMachine) resolveNode(name string) (*yarnpb.Program, *yarnpb.Node, error) {
+	progName, nodeName := SplitNodeName(name)
+	prog := vm.Program
+	if progName != "" {
+		if vm.Router == nil {
+			return nil, nil, fmt.Errorf("%w: %q (no router)", ErrProgramNotFound, progName)
+		}
+		p, err := vm.Router.Program(progName)
+		if err != nil {
+			return nil, nil, err
+		}
+		prog = p
+	}
+	if node, found := prog.GetNodes()[nodeName]; found {
+		return prog, node, nil
+	}
+	if progName != "" || vm.Programs == nil {
+		if prog == nil {
+			return nil, nil, ErrMissingProgram
+		}
+		return nil, nil, ErrNodeNotFound
+	}
+	// Not in the current program, so ask the provider.
+	prog, err := vm.Programs.ProgramFor(nodeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	node, found := prog.GetNodes()[nodeName]
+	if !found {
+		return nil, nil, fmt.Errorf("%w: %q not in program %q", ErrNodeNotFound, nodeName, prog.GetName())
+	}
+	return prog, node, nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+// snapshotRecorder records line IDs, and takes a snapshot at one line.
+type snapshotRecorder struct {
+	FakeDialogueHandler
+	vm    *VirtualMachine
+	ids   []string
+	nodes []string
+	at    string
+	snap  *Snapshot
+}
+
+func (r *snapshotRecorder) NodeStart(name string) error {
+	r.nodes = append(r.nodes, name)
+	return nil
+}
+
+func (r *snapshotRecorder) Line(line Line) error {
+	r.ids = append(r.ids, line.ID)
+	if line.ID == r.at {
+		r.snap = r.vm.Snapshot()
+	}
+	return nil
+}
+
+func TestRouter(t *testing.T) {
+	main := NewProgramBuilder("Main")
+	main.Node("Start").Line("line:start", 0).RunNode("chapter2:Intro")
+	main.Node("End").Line("line:end", 0)
+	ch2 := NewProgramBuilder("Chapter2")
+	ch2.Node("Intro").Line("line:intro", 0).RunNode("Outro")
+	ch2.Node("Outro").Line("line:outro", 0).RunNode("main:End")
+	mainProg := main.Program()
+
+	if diags := ValidateProgram(mainProg); len(diags) != 0 {
+		t.Errorf("ValidateProgram(main) = %v, want no diagnostics", diags)
+	}
+
+	loads := 0
+	router := &Router{
+		Load: func(name string) (*yarnpb.Program, error) {
+			if name != "chapter2" {
+				return nil, errors.New("no such file")
+			}
+			loads++
+			return ch2.Program(), nil
+		},
+	}
+	router.Add("main", mainProg)
+
+	rec := &snapshotRecorder{at: "line:outro"}
+	vm := &VirtualMachine{
+		Program: mainProg,
+		Handler: rec,
+		Vars:    NewMapVariableStorage(),
+		Router:  router,
+	}
+	rec.vm = vm
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:start", "line:intro", "line:outro", "line:end"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.nodes, []string{"Start", "Intro", "Outro", "End"}); diff != "" {
+		t.Errorf("nodes diff (-got +want):\n%s", diff)
+	}
+	if vm.Program != mainProg {
+		t.Errorf("vm.Program = %q, want Main", vm.Program.Name)
+	}
+	if diff := cmp.Diff(router.Names(), []string{"chapter2", "main"}); diff != "" {
+		t.Errorf("router.Names() diff (-got +want):\n%s", diff)
+	}
+
+	// The snapshot taken in chapter 2 restores into chapter 2.
+	if rec.snap == nil || rec.snap.Program != "chapter2" || rec.snap.Node != "Outro" {
+		t.Fatalf("snapshot = %+v, want program chapter2, node Outro", rec.snap)
+	}
+	rec2 := &snapshotRecorder{}
+	vm2 := &VirtualMachine{Program: mainProg, Handler: rec2, Vars: NewMapVariableStorage(), Router: router}
+	if err := vm2.Restore(rec.snap); err != nil {
+		t.Fatalf("vm2.Restore(snapshot) = %v", err)
+	}
+	if err := vm2.Resume(); err != nil {
+		t.Fatalf("vm2.Resume() = %v", err)
+	}
+	if diff := cmp.Diff(rec2.ids, []string{"line:end"}); diff != "" {
+		t.Errorf("resumed lines diff (-got +want):\n%s", diff)
+	}
+	if loads != 1 {
+		t.Errorf("loads = %d, want 1", loads)
+	}
+
+	if err := vm.Run("chapter3:Start"); err == nil {
+		t.Errorf("vm.Run(chapter3:Start) = nil, want error")
+	}
+	if err := vm.Run("chapter2:Nope"); !errors.Is(err, ErrNodeNotFound) {
+		t.Errorf("vm.Run(chapter2:Nope) = %v, want %v", err, ErrNodeNotFound)
+	}
+	vm.Router = nil
+	if err := vm.Run("chapter2:Intro"); !errors.Is(err, ErrProgramNotFound) {
+		t.Errorf("vm.Run(chapter2:Intro) without router = %v, want %v", err, ErrProgramNotFound)
+	}
+}
+
+func TestRouterLoadWithoutLock(t *testing.T) {
+	mainProg := NewProgramBuilder("main").Program()
+	ch2 := NewProgramBuilder("chapter2").Program()
+
+	var router *Router
+	release := make(chan struct{})
+	var loads int32
+	router = &Router{
+		Load: func(name string) (*yarnpb.Program, error) {
+			atomic.AddInt32(&loads, 1)
+			// Load can use the router.
+			if _, err := router.Program("main"); err != nil {
+				return nil, err
+			}
+			<-release
+			return ch2, nil
+		},
+	}
+	router.Add("main", mainProg)
+
+	// Two concurrent requests for chapter2 share one (blocked) load.
+	type result struct {
+		prog *yarnpb.Program
+		err  error
+	}
+	results := make(chan result)
+	for i := 0; i < 2; i++ {
+		go func() {
+			prog, err := router.Program("chapter2")
+			results <- result{prog, err}
+		}()
+	}
+
+	// Meanwhile, other programs are available.
+	if got, err := router.Program("main"); err != nil || got != mainProg {
+		t.Errorf("router.Program(main) = %p, %v, want %p, nil", got, err, mainProg)
+	}
+	router.Add("chapter3", ch2)
+	if diff := cmp.Diff(router.Names(), []string{"chapter3", "main"}); diff != "" {
+		t.Errorf("router.Names() diff (-got +want):\n%s", diff)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if r := <-results; r.err != nil || r.prog != ch2 {
+			t.Errorf("router.Program(chapter2) = %p, %v, want %p, nil", r.prog, r.err, ch2)
+		}
+	}
+	if got := atomic.LoadInt32(&loads); got != 1 {
+		t.Errorf("Load called %d times, want 1", got)
+	}
+}
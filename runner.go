@@ -0,0 +1,272 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// Errors returned by Runner.
+const (
+	// ErrChoiceRequired is returned by Runner.AdvanceToChoice when the last
+	// exchange ended with options, but Choose hasn't been called.
+	ErrChoiceRequired = virtualMachineError("choice required")
+
+	// ErrRunnerNotRunning is returned by Runner methods when the dialogue
+	// hasn't been started, or has finished.
+	ErrRunnerNotRunning = virtualMachineError("runner not running")
+)
+
+// Exchange is the content delivered by Runner.AdvanceToChoice: the lines up
+// to the next options, command, or the end of the dialogue.
+type Exchange struct {
+	// Lines are the lines delivered, in order.
+	Lines []Line
+
+	// Options are the options to choose from, if the exchange ended with
+	// options. Choose one with Runner.Choose.
+	Options []Option
+
+	// Command is the command, if the exchange ended with a command. The
+	// dialogue continues after it with the next AdvanceToChoice.
+	Command string
+
+	// Done is true if the exchange ended with the end of the dialogue.
+	Done bool
+}
+
+// Runner runs dialogue in larger steps than a DialogueHandler sees: each
+// call to AdvanceToChoice runs through lines (collecting them) until the next
+// options, command, or the end of the dialogue, and returns them all at once.
+// This suits chat-style UIs that show a whole exchange for each tap:
+//
+//	r := yarn.NewRunner(vm)
+//	r.Start("Start")
+//	for {
+//		ex, err := r.AdvanceToChoice()
+//		if err != nil { ... }
+//		show(ex.Lines)
+//		if ex.Done {
+//			break
+//		}
+//		if ex.Options != nil {
+//			r.Choose(ask(ex.Options))
+//		}
+//	}
+//
+// The VM runs in another goroutine, which waits for AdvanceToChoice before
+// delivering each event. Runner methods must not be called concurrently.
+type Runner struct {
+	vm *VirtualMachine
+
+	events   chan runnerEvent
+	resume   chan int // choice, or -1 to continue after a command
+	finished chan struct{}
+	err      error // set before finished is closed
+
+	pending  *Exchange // the last exchange, if it is awaiting Choose or resume
+	choice   int       // -1 until Choose is called
+	done     bool      // the end of the dialogue has been returned
+	stopping atomic.Bool
+}
+
+// runnerEvent is sent by runnerHandler.
+type runnerEvent struct {
+	kind    runnerEventKind
+	line    Line
+	options []Option
+	command string
+}
+
+type runnerEventKind int
+
+const (
+	runnerLine runnerEventKind = iota
+	runnerOptions
+	runnerCommand
+	runnerDone
+)
+
+// NewRunner returns a Runner for the VM, which replaces the VM's Handler.
+func NewRunner(vm *VirtualMachine) *Runner {
+	r := &Runner{vm: vm}
+	vm.Handler = runnerHandler{r}
+	return r
+}
+
+// Start starts running the dialogue at a node, in a new goroutine. Content is
+// delivered by AdvanceToChoice. Any error from the VM (e.g. if the node
+// doesn't exist) is returned from AdvanceToChoice.
+func (r *Runner) Start(node string) error {
+	if r.finished != nil {
+		select {
+		case <-r.finished:
+		default:
+			return errors.New("runner already running")
+		}
+	}
+	r.events = make(chan runnerEvent)
+	r.resume = make(chan int)
+	r.finished = make(chan struct{})
+	r.err = nil
+	r.pending = nil
+	r.choice = -1
+	r.done = false
+	r.stopping.Store(false)
+	go func() {
+		r.err = r.vm.Run(node)
+		close(r.finished)
+	}()
+	return nil
+}
+
+// Choose chooses an option from the last exchange. The dialogue continues
+// with the next AdvanceToChoice.
+func (r *Runner) Choose(id int) error {
+	if r.pending == nil || r.pending.Options == nil {
+		return errors.New("no options to choose from")
+	}
+	for _, opt := range r.pending.Options {
+		if opt.ID == id {
+			r.choice = id
+			return nil
+		}
+	}
+	return fmt.Errorf("option %d not offered", id)
+}
+
+// AdvanceToChoice continues the dialogue, and returns the lines delivered up
+// to the next options, command, or the end of the dialogue. If the last
+// exchange ended with options, one must be chosen (with Choose) first. If the
+// VM stops with an error, the error is returned along with the lines
+// delivered before it.
+func (r *Runner) AdvanceToChoice() (*Exchange, error) {
+	if r.finished == nil || r.done {
+		return nil, ErrRunnerNotRunning
+	}
+	if p := r.pending; p != nil {
+		if p.Options != nil && r.choice < 0 {
+			return nil, ErrChoiceRequired
+		}
+		r.resume <- r.choice
+		r.pending, r.choice = nil, -1
+	}
+	ex := new(Exchange)
+	for {
+		select {
+		case ev := <-r.events:
+			switch ev.kind {
+			case runnerLine:
+				ex.Lines = append(ex.Lines, ev.line)
+			case runnerOptions:
+				ex.Options = ev.options
+				r.pending = ex
+				return ex, nil
+			case runnerCommand:
+				ex.Command = ev.command
+				r.pending = ex
+				return ex, nil
+			case runnerDone:
+				<-r.finished
+				ex.Done, r.done = true, true
+				return ex, r.err
+			}
+		case <-r.finished:
+			// The VM stopped with an error, before DialogueComplete.
+			ex.Done, r.done = true, true
+			return ex, r.err
+		}
+	}
+}
+
+// Stop stops the dialogue, if it is running, and waits for the VM to finish.
+func (r *Runner) Stop() error {
+	if r.finished == nil {
+		return ErrRunnerNotRunning
+	}
+	r.stopping.Store(true)
+	r.done = true
+	if r.pending != nil {
+		r.pending = nil
+		select {
+		case r.resume <- -1:
+		case <-r.finished:
+		}
+	}
+	for {
+		select {
+		case <-r.events:
+			// Delivered before Stop was called.
+		case <-r.finished:
+			if errors.Is(r.err, Stop) {
+				return nil
+			}
+			return r.err
+		}
+	}
+}
+
+// runnerHandler delivers events to a Runner.
+type runnerHandler struct {
+	r *Runner
+}
+
+// send delivers an event, unless the Runner is stopping.
+func (h runnerHandler) send(ev runnerEvent) error {
+	if h.r.stopping.Load() {
+		return Stop
+	}
+	h.r.events <- ev
+	return nil
+}
+
+// wait waits for AdvanceToChoice to continue the dialogue.
+func (h runnerHandler) wait() (int, error) {
+	id := <-h.r.resume
+	if h.r.stopping.Load() {
+		return -1, Stop
+	}
+	return id, nil
+}
+
+func (h runnerHandler) NodeStart(string) error         { return nil }
+func (h runnerHandler) PrepareForLines([]string) error { return nil }
+func (h runnerHandler) NodeComplete(string) error      { return nil }
+
+func (h runnerHandler) Line(line Line) error {
+	return h.send(runnerEvent{kind: runnerLine, line: line.Clone()})
+}
+
+func (h runnerHandler) Options(options []Option) (int, error) {
+	if err := h.send(runnerEvent{kind: runnerOptions, options: CloneOptions(options)}); err != nil {
+		return -1, err
+	}
+	return h.wait()
+}
+
+func (h runnerHandler) Command(command string) error {
+	if err := h.send(runnerEvent{kind: runnerCommand, command: command}); err != nil {
+		return err
+	}
+	_, err := h.wait()
+	return err
+}
+
+func (h runnerHandler) DialogueComplete() error {
+	h.send(runnerEvent{kind: runnerDone}) // if stopping, the dialogue is complete anyway
+	return nil
+}
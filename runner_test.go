@@ -0,0 +1,146 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func exchangeLines(ex *Exchange) []string {
+	var ids []string
+	for _, l := range ex.Lines {
+		ids = append(ids, l.ID)
+	}
+	return ids
+}
+
+func TestRunnerAdvanceToChoice(t *testing.T) {
+	pb := NewProgramBuilder("Runner")
+	pb.Node("Start").
+		Line("line:a", 0).
+		Line("line:b", 0).
+		Command("wave", 0).
+		Line("line:c", 0).
+		Option("line:x", "X", 0, false).
+		Option("line:y", "Y", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("X").Line("line:x1", 0).Line("line:x2", 0)
+	pb.Node("Y").Line("line:y1", 0)
+
+	r := NewRunner(&VirtualMachine{
+		Program: pb.Program(),
+		Vars:    NewMapVariableStorage(),
+	})
+	if _, err := r.AdvanceToChoice(); !errors.Is(err, ErrRunnerNotRunning) {
+		t.Errorf("r.AdvanceToChoice() before Start = %v, want %v", err, ErrRunnerNotRunning)
+	}
+	if err := r.Start("Start"); err != nil {
+		t.Fatalf("r.Start(Start) = %v", err)
+	}
+
+	ex, err := r.AdvanceToChoice()
+	if err != nil {
+		t.Fatalf("r.AdvanceToChoice() = %v", err)
+	}
+	if diff := cmp.Diff(exchangeLines(ex), []string{"line:a", "line:b"}); diff != "" {
+		t.Errorf("exchange 1 lines diff (-got +want):\n%s", diff)
+	}
+	if ex.Command != "wave" {
+		t.Errorf("exchange 1 command = %q, want %q", ex.Command, "wave")
+	}
+
+	ex, err = r.AdvanceToChoice()
+	if err != nil {
+		t.Fatalf("r.AdvanceToChoice() = %v", err)
+	}
+	if diff := cmp.Diff(exchangeLines(ex), []string{"line:c"}); diff != "" {
+		t.Errorf("exchange 2 lines diff (-got +want):\n%s", diff)
+	}
+	if got, want := len(ex.Options), 2; got != want {
+		t.Fatalf("exchange 2 has %d options, want %d", got, want)
+	}
+
+	if _, err := r.AdvanceToChoice(); !errors.Is(err, ErrChoiceRequired) {
+		t.Errorf("r.AdvanceToChoice() without choice = %v, want %v", err, ErrChoiceRequired)
+	}
+	if err := r.Choose(7); err == nil {
+		t.Error("r.Choose(7) = nil, want error")
+	}
+	if err := r.Choose(ex.Options[0].ID); err != nil {
+		t.Fatalf("r.Choose(%d) = %v", ex.Options[0].ID, err)
+	}
+
+	ex, err = r.AdvanceToChoice()
+	if err != nil {
+		t.Fatalf("r.AdvanceToChoice() = %v", err)
+	}
+	if diff := cmp.Diff(exchangeLines(ex), []string{"line:x1", "line:x2"}); diff != "" {
+		t.Errorf("exchange 3 lines diff (-got +want):\n%s", diff)
+	}
+	if !ex.Done {
+		t.Error("exchange 3 Done = false, want true")
+	}
+	if _, err := r.AdvanceToChoice(); !errors.Is(err, ErrRunnerNotRunning) {
+		t.Errorf("r.AdvanceToChoice() after Done = %v, want %v", err, ErrRunnerNotRunning)
+	}
+}
+
+func TestRunnerStop(t *testing.T) {
+	pb := NewProgramBuilder("Runner")
+	pb.Node("Start").
+		Line("line:a", 0).
+		Option("line:y", "Y", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Y").Line("line:y1", 0)
+
+	r := NewRunner(&VirtualMachine{
+		Program: pb.Program(),
+		Vars:    NewMapVariableStorage(),
+	})
+	if err := r.Start("Start"); err != nil {
+		t.Fatalf("r.Start(Start) = %v", err)
+	}
+	if _, err := r.AdvanceToChoice(); err != nil {
+		t.Fatalf("r.AdvanceToChoice() = %v", err)
+	}
+	if err := r.Stop(); err != nil {
+		t.Errorf("r.Stop() = %v", err)
+	}
+	if _, err := r.AdvanceToChoice(); !errors.Is(err, ErrRunnerNotRunning) {
+		t.Errorf("r.AdvanceToChoice() after Stop = %v, want %v", err, ErrRunnerNotRunning)
+	}
+
+	// Stopping before the first exchange, and restarting.
+	if err := r.Start("Y"); err != nil {
+		t.Fatalf("r.Start(Y) = %v", err)
+	}
+	if err := r.Stop(); err != nil {
+		t.Errorf("r.Stop() = %v", err)
+	}
+	if err := r.Start("Y"); err != nil {
+		t.Fatalf("r.Start(Y) = %v", err)
+	}
+	ex, err := r.AdvanceToChoice()
+	if err != nil {
+		t.Fatalf("r.AdvanceToChoice() = %v", err)
+	}
+	if diff := cmp.Diff(exchangeLines(ex), []string{"line:y1"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+}
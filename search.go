@@ -0,0 +1,175 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// SearchMatch is a match found by Search.
+type SearchMatch struct {
+	// Node is the node containing the match.
+	Node string `json:"node"`
+
+	// PC is the index of the matching instruction in the node, or -1 if the
+	// match is the node itself (e.g. for a node tag).
+	PC int `json:"pc"`
+
+	// LineID is the ID of the matching line or option, if the match is a
+	// line.
+	LineID string `json:"line_id,omitempty"`
+
+	// Context describes the match: the text of the line, the command, or
+	// the use of the variable or tag.
+	Context string `json:"context"`
+}
+
+// Search searches a program and its string table, e.g. to find where a line
+// or variable is used. st may be nil, in which case only line IDs, commands,
+// variables, and node tags can be searched. The query is one of:
+//
+//	tag:name     nodes, and lines, tagged with name
+//	$name        instructions that read or set the variable $name
+//	var:$name    (the same)
+//	re:pattern   lines and commands whose text matches the regular expression
+//	/pattern/    (the same)
+//	text         lines and commands containing the text (ignoring case), and
+//	             lines whose ID contains the text
+//
+// Matches are in order of node name, then position within the node.
+func Search(prog *yarnpb.Program, st *StringTable, query string) ([]SearchMatch, error) {
+	var match func(node *yarnpb.Node) []SearchMatch
+	switch {
+	case strings.HasPrefix(query, "tag:"):
+		match = searchTag(st, strings.TrimPrefix(query, "tag:"))
+
+	case strings.HasPrefix(query, "$"), strings.HasPrefix(query, "var:"):
+		match = searchVariable(strings.TrimPrefix(query, "var:"))
+
+	case strings.HasPrefix(query, "re:"), len(query) > 1 && strings.HasPrefix(query, "/") && strings.HasSuffix(query, "/"):
+		pattern := strings.TrimPrefix(query, "re:")
+		if pattern == query {
+			pattern = query[1 : len(query)-1]
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("search query %q: %w", query, err)
+		}
+		match = searchText(st, func(id, text string) bool { return re.MatchString(text) })
+
+	default:
+		lower := strings.ToLower(query)
+		match = searchText(st, func(id, text string) bool {
+			return strings.Contains(strings.ToLower(text), lower) || strings.Contains(id, query)
+		})
+	}
+
+	var matches []SearchMatch
+	for _, name := range sortedNodeNames(prog) {
+		matches = append(matches, match(prog.Nodes[name])...)
+	}
+	return matches, nil
+}
+
+// searchText returns a matcher for lines and commands. For commands, id is
+// empty.
+func searchText(st *StringTable, matches func(id, text string) bool) func(*yarnpb.Node) []SearchMatch {
+	return func(node *yarnpb.Node) []SearchMatch {
+		var out []SearchMatch
+		for pc, inst := range node.Instructions {
+			switch id := lineIDOf(inst); {
+			case id != "":
+				text := ""
+				if row := st.row(id); row != nil {
+					text = row.Text
+				}
+				if matches(id, text) {
+					out = append(out, SearchMatch{Node: node.Name, PC: pc, LineID: id, Context: text})
+				}
+			case inst.GetOpcode() == yarnpb.Instruction_RUN_COMMAND && len(inst.Operands) > 0:
+				cmd := inst.Operands[0].GetStringValue()
+				if matches("", cmd) {
+					out = append(out, SearchMatch{Node: node.Name, PC: pc, Context: "<<" + cmd + ">>"})
+				}
+			}
+		}
+		return out
+	}
+}
+
+// searchVariable returns a matcher for uses of a variable.
+func searchVariable(name string) func(*yarnpb.Node) []SearchMatch {
+	return func(node *yarnpb.Node) []SearchMatch {
+		var out []SearchMatch
+		for pc, inst := range node.Instructions {
+			if len(inst.Operands) == 0 || inst.Operands[0].GetStringValue() != name {
+				continue
+			}
+			switch inst.GetOpcode() {
+			case yarnpb.Instruction_PUSH_VARIABLE:
+				out = append(out, SearchMatch{Node: node.Name, PC: pc, Context: "reads " + name})
+			case yarnpb.Instruction_STORE_VARIABLE:
+				out = append(out, SearchMatch{Node: node.Name, PC: pc, Context: "sets " + name})
+			}
+		}
+		return out
+	}
+}
+
+// searchTag returns a matcher for nodes and lines with a tag.
+func searchTag(st *StringTable, tag string) func(*yarnpb.Node) []SearchMatch {
+	return func(node *yarnpb.Node) []SearchMatch {
+		var out []SearchMatch
+		if slices.Contains(node.Tags, tag) {
+			out = append(out, SearchMatch{Node: node.Name, PC: -1, Context: "node tags: " + strings.Join(node.Tags, " ")})
+		}
+		for pc, inst := range node.Instructions {
+			id := lineIDOf(inst)
+			if id == "" {
+				continue
+			}
+			if row := st.row(id); row != nil && slices.Contains(row.Tags, tag) {
+				out = append(out, SearchMatch{Node: node.Name, PC: pc, LineID: id, Context: row.Text})
+			}
+		}
+		return out
+	}
+}
+
+// lineIDOf returns the line ID used by a RUN_LINE or ADD_OPTION
+// instruction, or "" for other instructions.
+func lineIDOf(inst *yarnpb.Instruction) string {
+	switch inst.GetOpcode() {
+	case yarnpb.Instruction_RUN_LINE, yarnpb.Instruction_ADD_OPTION:
+		if len(inst.Operands) > 0 {
+			return inst.Operands[0].GetStringValue()
+		}
+	}
+	return ""
+}
+
+// row returns the row for a line ID, or nil if the table (which may be nil)
+// doesn't have it.
+func (t *StringTable) row(id string) *StringTableRow {
+	if t == nil {
+		return nil
+	}
+	return t.Table[id]
+}
@@ -0,0 +1,129 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearch(t *testing.T) {
+	pb := NewProgramBuilder("Search")
+	pb.Node("Start").
+		Tags("intro").
+		PushVariable("$gold").
+		StoreVariable("$seen_gold").
+		Pop().
+		Line("line:greet", 0).
+		Command("play_sound coins", 0).
+		Option("line:buy", "Shop", 0, false).
+		ShowOptions().
+		RunNode("Shop")
+	pb.Node("Shop").
+		PushFloat(10).
+		StoreVariable("$gold").
+		Pop().
+		Line("line:thanks", 0)
+	prog := pb.Program()
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:greet":  {ID: "line:greet", Text: "Ava: Welcome, traveller!", Tags: []string{"intro"}},
+		"line:buy":    {ID: "line:buy", Text: "Buy some coins"},
+		"line:thanks": {ID: "line:thanks", Text: "Ava: Thanks for your gold."},
+	}}
+
+	tests := []struct {
+		query string
+		want  []SearchMatch
+	}{
+		{
+			query: "ava:",
+			want: []SearchMatch{
+				{Node: "Shop", PC: 3, LineID: "line:thanks", Context: "Ava: Thanks for your gold."},
+				{Node: "Start", PC: 3, LineID: "line:greet", Context: "Ava: Welcome, traveller!"},
+			},
+		},
+		{
+			query: "coins",
+			want: []SearchMatch{
+				{Node: "Start", PC: 4, Context: "<<play_sound coins>>"},
+				{Node: "Start", PC: 5, LineID: "line:buy", Context: "Buy some coins"},
+			},
+		},
+		{
+			query: "line:thanks",
+			want: []SearchMatch{
+				{Node: "Shop", PC: 3, LineID: "line:thanks", Context: "Ava: Thanks for your gold."},
+			},
+		},
+		{
+			query: `/^Ava: W\w+/`,
+			want: []SearchMatch{
+				{Node: "Start", PC: 3, LineID: "line:greet", Context: "Ava: Welcome, traveller!"},
+			},
+		},
+		{
+			query: "re:gold\\.$",
+			want: []SearchMatch{
+				{Node: "Shop", PC: 3, LineID: "line:thanks", Context: "Ava: Thanks for your gold."},
+			},
+		},
+		{
+			query: "$gold",
+			want: []SearchMatch{
+				{Node: "Shop", PC: 1, Context: "sets $gold"},
+				{Node: "Start", PC: 0, Context: "reads $gold"},
+			},
+		},
+		{
+			query: "var:$seen_gold",
+			want: []SearchMatch{
+				{Node: "Start", PC: 1, Context: "sets $seen_gold"},
+			},
+		},
+		{
+			query: "tag:intro",
+			want: []SearchMatch{
+				{Node: "Start", PC: -1, Context: "node tags: intro"},
+				{Node: "Start", PC: 3, LineID: "line:greet", Context: "Ava: Welcome, traveller!"},
+			},
+		},
+		{query: "dragons"},
+	}
+	for _, test := range tests {
+		got, err := Search(prog, st, test.query)
+		if err != nil {
+			t.Errorf("Search(%q) = %v", test.query, err)
+			continue
+		}
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("Search(%q) diff (-got +want):\n%s", test.query, diff)
+		}
+	}
+
+	// Without a string table, only IDs can be searched.
+	got, err := Search(prog, nil, "thanks")
+	if err != nil {
+		t.Fatalf("Search(thanks) with nil table = %v", err)
+	}
+	want := []SearchMatch{{Node: "Shop", PC: 3, LineID: "line:thanks"}}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("Search(thanks) with nil table diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := Search(prog, st, "re:("); err == nil {
+		t.Errorf("Search(re:() = nil error, want error")
+	}
+}
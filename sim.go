@@ -0,0 +1,244 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// ChoicePolicy chooses one option from a set of options, on behalf of a
+// simulated player.
+type ChoicePolicy interface {
+	// Choose returns the index (within options, not the Option.ID) of the
+	// chosen option. options contains only available options, and is never
+	// empty.
+	Choose(options []Option) (int, error)
+}
+
+// PolicyResetter is optionally implemented by stateful policies. Reset is
+// called before each playthrough.
+type PolicyResetter interface {
+	Reset()
+}
+
+// availableOptions returns the options that have IsAvailable set.
+func availableOptions(options []Option) []Option {
+	avail := make([]Option, 0, len(options))
+	for _, opt := range options {
+		if opt.IsAvailable {
+			avail = append(avail, opt)
+		}
+	}
+	return avail
+}
+
+// UniformPolicy chooses options uniformly at random.
+type UniformPolicy struct {
+	Rand *rand.Rand
+}
+
+// Choose chooses an option uniformly at random.
+func (p UniformPolicy) Choose(options []Option) (int, error) {
+	return p.Rand.Intn(len(options)), nil
+}
+
+// TagWeightedPolicy chooses options at random, weighted according to the tags
+// on each option's line (from the string table metadata). For example, with
+// Weights {"rude": 0.1, "nice": 3}, options tagged #nice are chosen 30 times
+// more often than options tagged #rude. When an option has several weighted
+// tags, the weights are multiplied.
+type TagWeightedPolicy struct {
+	Rand        *rand.Rand
+	StringTable *StringTable
+	Weights     map[string]float64
+
+	// Default is the weight of options that have no weighted tags. If zero, 1
+	// is used.
+	Default float64
+}
+
+func (p *TagWeightedPolicy) weight(opt Option) float64 {
+	w, found := 1.0, false
+	if row := p.StringTable.Table[opt.Line.ID]; row != nil {
+		for _, tag := range row.Tags {
+			if tw, ok := p.Weights[tag]; ok {
+				w *= tw
+				found = true
+			}
+		}
+	}
+	if found {
+		return w
+	}
+	if p.Default == 0 {
+		return 1
+	}
+	return p.Default
+}
+
+// Choose chooses an option at random according to the weights.
+func (p *TagWeightedPolicy) Choose(options []Option) (int, error) {
+	weights := make([]float64, len(options))
+	total := 0.0
+	for i, opt := range options {
+		w := p.weight(opt)
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return -1, fmt.Errorf("invalid weight %v for option line %q", w, opt.Line.ID)
+		}
+		weights[i] = w
+		total += w
+	}
+	if total == 0 {
+		// Everything is weighted zero. Fall back to uniform.
+		return p.Rand.Intn(len(options)), nil
+	}
+	x := p.Rand.Float64() * total
+	for i, w := range weights {
+		if x < w {
+			return i, nil
+		}
+		x -= w
+	}
+	return len(options) - 1, nil
+}
+
+// ScriptedPolicy chooses options according to a script of option IDs, in
+// order. Once the script is exhausted, it uses Fallback (or returns an error,
+// if Fallback is nil).
+type ScriptedPolicy struct {
+	Script   []int
+	Fallback ChoicePolicy
+
+	step int
+}
+
+// Reset restarts the script from the beginning.
+func (p *ScriptedPolicy) Reset() { p.step = 0 }
+
+// Choose chooses the option with the next ID in the script.
+func (p *ScriptedPolicy) Choose(options []Option) (int, error) {
+	if p.step >= len(p.Script) {
+		if p.Fallback == nil {
+			return -1, fmt.Errorf("script exhausted after %d choices", len(p.Script))
+		}
+		return p.Fallback.Choose(options)
+	}
+	id := p.Script[p.step]
+	p.step++
+	for i, opt := range options {
+		if opt.ID == id {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("scripted choice %d (step %d) is not an available option", id, p.step-1)
+}
+
+// Simulator plays a program many times using a choice policy, and aggregates
+// statistics about the playthroughs. It is intended for balancing branching
+// narratives: how often is each ending reached, how long are conversations,
+// which options get picked.
+type Simulator struct {
+	// Program is the program to play.
+	Program *yarnpb.Program
+
+	// FuncMap provides any custom functions the program needs.
+	FuncMap FuncMap
+
+	// Policy chooses options. If nil, options are chosen uniformly at random.
+	Policy ChoicePolicy
+
+	// MaxEvents limits the length of each playthrough. If zero,
+	// DefaultMaxWalkEvents is used.
+	MaxEvents int
+
+	// NewVars, if not nil, is called to create the variable storage for each
+	// playthrough. Otherwise each gets a new empty MapVariableStorage.
+	NewVars func() VariableStorage
+}
+
+// SimulationStats aggregates the results of a simulation.
+type SimulationStats struct {
+	Runs   int            `json:"runs"`
+	Errors map[string]int `json:"errors,omitempty"` // error message -> count
+
+	// Endings counts the node that was running when each playthrough
+	// finished without error.
+	Endings map[string]int `json:"endings"`
+
+	// NodeVisits counts the number of times each node was started.
+	NodeVisits map[string]int `json:"node_visits"`
+
+	// OptionPicks counts the number of times each option (by line ID) was
+	// chosen.
+	OptionPicks map[string]int `json:"option_picks"`
+
+	// Number of lines delivered per playthrough.
+	MinLines  int     `json:"min_lines"`
+	MaxLines  int     `json:"max_lines"`
+	MeanLines float64 `json:"mean_lines"`
+}
+
+// Simulate plays the program n times starting at startNode.
+func (s *Simulator) Simulate(startNode string, n int) *SimulationStats {
+	stats := &SimulationStats{
+		Errors:      make(map[string]int),
+		Endings:     make(map[string]int),
+		NodeVisits:  make(map[string]int),
+		OptionPicks: make(map[string]int),
+	}
+	policy := s.Policy
+	if policy == nil {
+		policy = UniformPolicy{Rand: rand.New(rand.NewSource(1))}
+	}
+	w := &RandomWalker{
+		Program:   s.Program,
+		FuncMap:   s.FuncMap,
+		Policy:    policy,
+		MaxEvents: s.MaxEvents,
+		NewVars:   s.NewVars,
+	}
+	totalLines := 0
+	for i := 0; i < n; i++ {
+		res := w.Walk(startNode)
+		stats.Runs++
+		if res.Err != nil {
+			stats.Errors[res.Err.Error()]++
+		} else {
+			stats.Endings[res.Ending()]++
+		}
+		for _, node := range res.Nodes {
+			stats.NodeVisits[node]++
+		}
+		for _, opt := range res.Choices {
+			stats.OptionPicks[opt.Line.ID]++
+		}
+		lines := len(res.Lines)
+		totalLines += lines
+		if i == 0 || lines < stats.MinLines {
+			stats.MinLines = lines
+		}
+		if lines > stats.MaxLines {
+			stats.MaxLines = lines
+		}
+	}
+	if stats.Runs > 0 {
+		stats.MeanLines = float64(totalLines) / float64(stats.Runs)
+	}
+	return stats
+}
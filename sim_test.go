@@ -0,0 +1,58 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/ShortcutOptions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+
+	uniform := &Simulator{
+		Program: prog,
+		Policy:  UniformPolicy{Rand: rand.New(rand.NewSource(1))},
+	}
+	stats := uniform.Simulate("Start", 50)
+	if stats.Runs != 50 {
+		t.Errorf("uniform stats.Runs = %d, want 50", stats.Runs)
+	}
+	if len(stats.Errors) != 0 {
+		t.Errorf("uniform stats.Errors = %v, want none", stats.Errors)
+	}
+	if got, want := stats.Endings["Start"], 50; got != want {
+		t.Errorf("uniform stats.Endings[Start] = %d, want %d", got, want)
+	}
+
+	// Always choosing the first option (ID 0) follows the path in the test
+	// plan, which has 11 lines.
+	scripted := &Simulator{
+		Program: prog,
+		Policy: &ScriptedPolicy{
+			Script: []int{0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+	}
+	stats = scripted.Simulate("Start", 3)
+	if len(stats.Errors) != 0 {
+		t.Fatalf("scripted stats.Errors = %v, want none", stats.Errors)
+	}
+	if stats.MinLines != 11 || stats.MaxLines != 11 || stats.MeanLines != 11 {
+		t.Errorf("scripted stats lines (min, max, mean) = (%d, %d, %f), want all 11", stats.MinLines, stats.MaxLines, stats.MeanLines)
+	}
+}
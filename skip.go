@@ -0,0 +1,87 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "log/slog"
+
+// SkipHandler is an optional interface for dialogue handlers. While the VM is
+// in skip mode, lines are delivered to SkipLine instead of Line, so the game
+// can flash them past without waiting for the player. If the handler does
+// not implement SkipHandler, lines are not delivered at all in skip mode. The
+// handlers in this package that wrap another DialogueHandler forward SkipLine
+// to the wrapped handler.
+type SkipHandler interface {
+	SkipLine(line Line) error
+}
+
+// SetSkipMode turns skip mode on or off. It is safe to call from any
+// goroutine, including from within a handler method (e.g. when the player
+// presses the skip button while a line is shown).
+//
+// In skip mode, lines are acknowledged automatically (see SkipHandler). If
+// SkipChoices is also set, options are chosen automatically where the player
+// has chosen one of them before. If SkipSeenOnly is set, skip mode turns
+// itself off when it reaches a line the player hasn't seen. Other events
+// (including commands) are delivered as usual, though a WaitHandler with its
+// VM set doesn't wait.
+func (vm *VirtualMachine) SetSkipMode(skip bool) { vm.skip.Store(skip) }
+
+// SkipMode reports whether skip mode is on.
+func (vm *VirtualMachine) SkipMode() bool { return vm.skip.Load() }
+
+// deliverLine passes the line to the handler, respecting skip mode, and
+// marks it as seen.
+func (vm *VirtualMachine) deliverLine(line Line) error {
+	if vm.SkipMode() && vm.SkipSeenOnly && !vm.history.lineSeen(line.ID) {
+		// Reached unread text, so stop skipping.
+		vm.SetSkipMode(false)
+	}
+	if !vm.SkipMode() {
+		vm.markSeen(line.ID)
+		vm.logEvent("Line", slog.String("line_id", line.ID), slog.Any("substitutions", line.Substitutions))
+		vm.recordLine(line)
+		return vm.Handler.Line(line)
+	}
+	sh, ok := vm.Handler.(SkipHandler)
+	if !ok {
+		return nil
+	}
+	vm.markSeen(line.ID)
+	vm.logEvent("SkipLine", slog.String("line_id", line.ID), slog.Any("substitutions", line.Substitutions))
+	return sh.SkipLine(line)
+}
+
+// forwardSkipLine passes the line to h's SkipLine method, if h implements
+// SkipHandler. Handlers that wrap another use it to forward SkipHandler.
+func forwardSkipLine(h DialogueHandler, line Line) error {
+	if sh, ok := h.(SkipHandler); ok {
+		return sh.SkipLine(line)
+	}
+	return nil
+}
+
+// autoChoose returns the index of the option to choose automatically in skip
+// mode, or -1 if the handler should be asked.
+func (vm *VirtualMachine) autoChoose(options []Option) int {
+	if !vm.SkipChoices || !vm.SkipMode() {
+		return -1
+	}
+	for i, o := range options {
+		if o.IsAvailable && vm.history.optionChosen(o.Line.ID) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "fmt"
+
+// ErrNothingToResume is returned by Resume when no node has been set (by Run,
+// SetNode, or Restore).
+const ErrNothingToResume = virtualMachineError("no current node to resume")
+
+// Snapshot is a serializable copy of the VM's execution state: the current
+// node, program counter, stack, and pending options. It does not include
+// variables or history.
+//
+// A snapshot taken within a handler method resumes from the point after that
+// event: after the line for Line, after the command for Command, and at the
+// start of the node for NodeStart. A snapshot taken during Options resumes by
+// delivering the same options again.
+type Snapshot struct {
+	// Program is the name of the current program in the VM's Router, if the
+	// VM has a Router that has the program.
+	Program string `json:"program,omitempty"`
+
+	Node    string   `json:"node"`
+	PC      int      `json:"pc"`
+	Stack   []any    `json:"stack,omitempty"`
+	Options []Option `json:"options,omitempty"`
+
+	// Locals are the current node's parameters (see SetNodeWithArgs).
+	Locals map[string]any `json:"locals,omitempty"`
+}
+
+// Snapshot returns a copy of the current execution state. It can be called
+// from within handler methods, or while the VM is not running.
+func (vm *VirtualMachine) Snapshot() *Snapshot {
+	s := &Snapshot{
+		PC:      vm.state.pc,
+		Stack:   append([]any(nil), vm.state.stack...),
+		Options: CloneOptions(vm.state.options),
+	}
+	if vm.state.locals != nil {
+		s.Locals = copyMap(vm.state.locals)
+	}
+	if vm.state.node != nil {
+		s.Node = vm.state.node.Name
+	}
+	if vm.Router != nil {
+		s.Program = vm.Router.nameOf(vm.Program)
+	}
+	return s
+}
+
+// Restore replaces the execution state with a snapshot. The VM must not be
+// running. Use Resume to continue execution from the restored state. No
+// handler events are delivered by Restore. If the snapshot names a program,
+// Program is replaced with that program from the Router.
+func (vm *VirtualMachine) Restore(s *Snapshot) error {
+	name := s.Node
+	if s.Program != "" {
+		name = s.Program + ProgramSeparator + s.Node
+	}
+	prog, node, err := vm.resolveNode(name)
+	if err != nil {
+		return fmt.Errorf("%w: %q", err, name)
+	}
+	node, err = vm.generateNode(node)
+	if err != nil {
+		return err
+	}
+	if s.PC < 0 || s.PC > len(node.Instructions) {
+		return fmt.Errorf("snapshot pc %d out of range [0, %d]", s.PC, len(node.Instructions))
+	}
+	stack := make([]any, len(s.Stack))
+	for i, x := range s.Stack {
+		stack[i] = normalizeValue(x)
+	}
+	var locals map[string]any
+	if s.Locals != nil {
+		locals = make(map[string]any, len(s.Locals))
+		for k, x := range s.Locals {
+			locals[k] = normalizeValue(x)
+		}
+	}
+	vm.Program = prog
+	vm.state = state{
+		node:     node,
+		pc:       s.PC,
+		stack:    stack,
+		options:  CloneOptions(s.Options),
+		locals:   locals,
+		declared: declaredLocals(node),
+	}
+	return nil
+}
+
+// Resume continues executing the program from the current state (usually
+// set by Restore), rather than starting a node afresh.
+func (vm *VirtualMachine) Resume() error {
+	return vm.run(func() error {
+		if vm.state.node == nil {
+			return ErrNothingToResume
+		}
+		return nil
+	})
+}
+
+// normalizeValue converts numbers decoded from JSON (float64) back into the
+// type used by the VM (float32).
+func normalizeValue(x any) any {
+	if f, ok := x.(float64); ok {
+		return float32(f)
+	}
+	return x
+}
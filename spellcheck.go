@@ -0,0 +1,201 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// SpellChecker checks the spelling of words, e.g. with hunspell bindings or a
+// WordList.
+type SpellChecker interface {
+	// Correct reports whether the word is spelled correctly.
+	Correct(word string) bool
+}
+
+// SpellSuggester is an optional interface for SpellCheckers that can suggest
+// corrections.
+type SpellSuggester interface {
+	Suggest(word string) []string
+}
+
+// Misspelling is a word that a SpellChecker reported as misspelled.
+type Misspelling struct {
+	LineID string `json:"line_id"`
+	Node   string `json:"node,omitempty"`
+	Word   string `json:"word"`
+
+	// Offset is the byte offset of the word within the rendered text of the
+	// line (without markup or the speaker's name).
+	Offset int `json:"offset"`
+
+	// Suggestions are from the SpellChecker, if it is a SpellSuggester.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// SpellCheck checks the spelling of every line in the string table. Lines are
+// rendered first, so that markup is skipped, and substitutions are rendered
+// as digits, which are not words. The speaker's name is skipped too. Lines
+// that can't be rendered are skipped (CheckStringTable reports those).
+// Misspellings are sorted by line ID, then offset.
+func SpellCheck(st *StringTable, checker SpellChecker) []Misspelling {
+	suggester, _ := checker.(SpellSuggester)
+	var found []Misspelling
+	for _, id := range sortedKeys(st.Table) {
+		row := st.Table[id]
+		if row == nil {
+			continue
+		}
+		as, err := row.render(spellCheckSubsts, st.Language, st.FormSelectors)
+		if err != nil {
+			continue
+		}
+		_, text := findSpeaker(as)
+		for _, w := range splitWords(text) {
+			if checker.Correct(w.word) {
+				continue
+			}
+			m := Misspelling{LineID: id, Node: row.Node, Word: w.word, Offset: w.offset}
+			if suggester != nil {
+				m.Suggestions = suggester.Suggest(w.word)
+			}
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// SpellCheckLocales checks the spelling of the string table of each locale
+// that has a checker, keyed by language code.
+func SpellCheckLocales(locales *LocaleSet, checkers map[string]SpellChecker) map[string][]Misspelling {
+	found := make(map[string][]Misspelling)
+	for _, lang := range locales.Locales() {
+		checker := checkers[lang]
+		st := locales.Table(lang)
+		if checker == nil || st == nil {
+			continue
+		}
+		if ms := SpellCheck(st, checker); len(ms) > 0 {
+			found[lang] = ms
+		}
+	}
+	return found
+}
+
+// spellCheckSubsts are the substitutions used when rendering lines to check.
+// Digits aren't words, and work with plural and ordinal format functions.
+var spellCheckSubsts = func() []string {
+	s := make([]string, 10)
+	for i := range s {
+		s[i] = "0"
+	}
+	return s
+}()
+
+// word is a word within a text.
+type word struct {
+	word   string
+	offset int
+}
+
+// splitWords splits text into words: runs of letters and marks, possibly
+// joined by apostrophes or hyphens. Runs containing digits are skipped.
+func splitWords(text string) []word {
+	var words []word
+	start, digits := -1, false
+	flush := func(end int) {
+		if start >= 0 && !digits {
+			w := strings.TrimRight(text[start:end], "'’-")
+			words = append(words, word{word: w, offset: start})
+		}
+		start, digits = -1, false
+	}
+	for i, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsMark(r):
+			if start < 0 {
+				start = i
+			}
+		case unicode.IsDigit(r):
+			if start < 0 {
+				start = i
+			}
+			digits = true
+		case start >= 0 && (r == '\'' || r == '’' || r == '-'):
+			// Part of the word if followed by a letter (checked by trimming
+			// when the word ends).
+		default:
+			flush(i)
+		}
+	}
+	flush(len(text))
+	return words
+}
+
+// WordList is a SpellChecker that accepts the words in a set. Words are
+// accepted if they are in the list as given, or in lower case.
+type WordList map[string]bool
+
+// ReadWordList reads a word list with one word per line. It also accepts
+// hunspell .dic files: a leading word count is ignored, as are affix flags
+// (after "/") and comments (lines starting with "#").
+func ReadWordList(r io.Reader) (WordList, error) {
+	wl := make(WordList)
+	sc := bufio.NewScanner(r)
+	first := true
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if first {
+			first = false
+			if _, err := strconv.Atoi(line); err == nil {
+				continue
+			}
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		w, _, _ := strings.Cut(line, "/")
+		wl[w] = true
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading word list: %w", err)
+	}
+	return wl, nil
+}
+
+// Add adds words to the list, e.g. character and place names.
+func (wl WordList) Add(words ...string) {
+	for _, w := range words {
+		wl[w] = true
+	}
+}
+
+// Correct reports whether the word, or its lower case form, is in the list.
+func (wl WordList) Correct(word string) bool {
+	if wl[word] {
+		return true
+	}
+	// Normalise curly apostrophes, which word lists rarely contain.
+	word = strings.ReplaceAll(word, "’", "'")
+	if wl[word] {
+		return true
+	}
+	return wl[strings.ToLower(word)]
+}
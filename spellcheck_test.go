@@ -0,0 +1,64 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+// suggestingWordList suggests the same correction for everything.
+type suggestingWordList struct{ WordList }
+
+func (suggestingWordList) Suggest(string) []string { return []string{"apple"} }
+
+func TestSpellCheck(t *testing.T) {
+	wl, err := ReadWordList(strings.NewReader("6\n# comment\nI/X\nhave\napple/S\napples\nit's\nthe\n"))
+	if err != nil {
+		t.Fatalf("ReadWordList = %v", err)
+	}
+	wl.Add("Hello")
+
+	en := &StringTable{
+		Language: language.English,
+		Table: map[string]*StringTableRow{
+			"line:1": {ID: "line:1", Node: "Start", Text: "Ava: Hello {0}, I have {0} [plural value={0} one=\"% apple\" other=\"% apples\"/]."},
+			"line:2": {ID: "line:2", Node: "Start", Text: "[b]It’s[/b] the aple-tree, 4ever."},
+			"line:3": {ID: "line:3", Node: "Shop", Text: "Hello Hello"},
+		},
+	}
+	got := SpellCheck(en, suggestingWordList{wl})
+	want := []Misspelling{
+		{LineID: "line:2", Node: "Start", Word: "aple-tree", Offset: 11, Suggestions: []string{"apple"}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("SpellCheck diff (-got +want):\n%s", diff)
+	}
+
+	locales := &LocaleSet{}
+	locales.Add("en", en)
+	locales.Add("fr", &StringTable{Language: language.French, Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: "Bonjour"},
+	}})
+	all := SpellCheckLocales(locales, map[string]SpellChecker{"en": wl})
+	if diff := cmp.Diff(all, map[string][]Misspelling{
+		"en": {{LineID: "line:2", Node: "Start", Word: "aple-tree", Offset: 11}},
+	}); diff != "" {
+		t.Errorf("SpellCheckLocales diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,498 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+const (
+	// ErrStageBusy is returned by Stage.Start when a conversation with equal
+	// or higher priority holds the floor.
+	ErrStageBusy = virtualMachineError("stage busy with a conversation of equal or higher priority")
+
+	// ErrInterrupted is returned by Conversation.Wait when the conversation
+	// was interrupted by one with a higher priority.
+	ErrInterrupted = virtualMachineError("conversation interrupted")
+)
+
+// Aborter is implemented by handlers that can be told to stop waiting, such
+// as AsyncAdapter. When a conversation is interrupted, Stage calls Abort on
+// its handler (if it implements Aborter), so that the interruption takes
+// effect even if the handler is waiting for the player.
+//
+// Note that a conversation with the InterruptPause policy receives events
+// again once it resumes, so its handler must be able to continue after Abort.
+// AsyncAdapter cannot.
+type Aborter interface {
+	Abort(err error) error
+}
+
+// InterruptPolicy says what happens to the conversation holding the floor
+// when a conversation with a higher priority starts.
+type InterruptPolicy int
+
+// Interruption policies.
+const (
+	// InterruptAbort ends the conversation; Wait returns ErrInterrupted.
+	InterruptAbort InterruptPolicy = iota
+
+	// InterruptPause stops the conversation and snapshots it. It resumes
+	// when it can hold the floor again. The event that was interrupted (e.g.
+	// a line the player was reading) is delivered again on resumption.
+	InterruptPause
+
+	// InterruptQueue lets the conversation finish; the higher priority
+	// conversation waits for its turn.
+	InterruptQueue
+)
+
+func (p InterruptPolicy) String() string {
+	switch p {
+	case InterruptAbort:
+		return "abort"
+	case InterruptPause:
+		return "pause"
+	case InterruptQueue:
+		return "queue"
+	}
+	return fmt.Sprintf("(invalid InterruptPolicy %d)", int(p))
+}
+
+// StageEventKind enumerates things that happen to conversations on a Stage.
+type StageEventKind int
+
+// Kinds of stage events.
+const (
+	StageStarted  StageEventKind = iota // began running
+	StageFinished                       // finished (see Conversation.Wait for the error)
+	StageAborted                        // interrupted and ended
+	StagePaused                         // interrupted and snapshotted
+	StageResumed                        // resumed after pausing
+	StageQueued                         // waiting for the floor
+)
+
+func (k StageEventKind) String() string {
+	switch k {
+	case StageStarted:
+		return "started"
+	case StageFinished:
+		return "finished"
+	case StageAborted:
+		return "aborted"
+	case StagePaused:
+		return "paused"
+	case StageResumed:
+		return "resumed"
+	case StageQueued:
+		return "queued"
+	}
+	return fmt.Sprintf("(invalid StageEventKind %d)", int(k))
+}
+
+// StageEvent tells the game what happened to a conversation.
+type StageEvent struct {
+	Kind         StageEventKind
+	Conversation *Conversation
+
+	// Cause is the conversation that caused an abort, pause, or queueing.
+	Cause *Conversation
+}
+
+// Stage coordinates several conversations running at once - for example,
+// ambient barks from NPCs alongside the main conversation - so that they
+// don't trample each other. Each conversation has its own VM and handler,
+// but they share the Stage's program, variable storage, and functions.
+//
+// At most one non-concurrent conversation holds the "floor" at a time. A
+// conversation with a higher priority than the current holder interrupts it
+// (according to the holder's OnInterrupt policy); one with equal or lower
+// priority is rejected with ErrStageBusy. When the floor becomes free, the
+// waiting (paused or queued) conversation with the highest priority takes
+// it. Concurrent conversations don't contend for the floor.
+type Stage struct {
+	Program *yarnpb.Program
+	Vars    VariableStorage
+	FuncMap FuncMap
+
+	// OnEvent, if not nil, is called with each stage event. It is called from
+	// various goroutines, but never while the Stage is locked, so it may call
+	// Stage methods.
+	OnEvent func(StageEvent)
+
+	mu      sync.Mutex
+	floor   *Conversation
+	waiting []*Conversation // paused or queued, in order of arrival
+	active  map[*Conversation]struct{}
+}
+
+// Conversation is one source of dialogue on a Stage. Set the exported fields
+// and pass it to Stage.Start.
+type Conversation struct {
+	// Name identifies the conversation (e.g. by speaker), for the game's
+	// benefit.
+	Name string
+
+	// StartNode is the node to run.
+	StartNode string
+
+	// Priority determines which conversation holds the floor.
+	Priority int
+
+	// Concurrent conversations (e.g. barks) run alongside others, without
+	// interrupting or being interrupted.
+	Concurrent bool
+
+	// OnInterrupt says what happens when this conversation holds the floor
+	// and a higher priority conversation starts.
+	OnInterrupt InterruptPolicy
+
+	// Handler receives this conversation's events.
+	Handler DialogueHandler
+
+	vm          *VirtualMachine
+	interrupted atomic.Bool
+	forceAbort  atomic.Bool // set by Stage.Interrupt
+	started     bool
+	stopped     chan struct{} // closed when the current run segment ends
+	done        chan struct{}
+	err         error
+
+	// Set while pausing, on the VM goroutine.
+	snap   *Snapshot
+	replay func() error // re-delivers the interrupted event, if any
+}
+
+// Done returns a channel that is closed when the conversation has finished.
+func (c *Conversation) Done() <-chan struct{} { return c.done }
+
+// Wait waits for the conversation to finish, and returns the error from
+// running it. The error satisfies errors.Is(err, ErrInterrupted) if it was
+// interrupted and aborted.
+func (c *Conversation) Wait() error {
+	<-c.done
+	return c.err
+}
+
+// VM returns the virtual machine running the conversation.
+func (c *Conversation) VM() *VirtualMachine { return c.vm }
+
+// interrupt marks the conversation as interrupted, and aborts its handler if
+// possible.
+func (c *Conversation) interrupt() {
+	c.interrupted.Store(true)
+	if a, ok := c.Handler.(Aborter); ok {
+		a.Abort(ErrInterrupted)
+	}
+}
+
+// Start starts running a conversation in a new goroutine. If it interrupts
+// another conversation, it begins once the other has stopped.
+func (s *Stage) Start(c *Conversation) error {
+	var events []StageEvent
+	defer func() { s.emit(events) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c.vm = &VirtualMachine{
+		Program: s.Program,
+		Handler: stageHandler{c},
+		Vars:    s.Vars,
+		FuncMap: s.FuncMap,
+	}
+	c.done = make(chan struct{})
+	if s.active == nil {
+		s.active = make(map[*Conversation]struct{})
+	}
+
+	if c.Concurrent || s.floor == nil {
+		if !c.Concurrent {
+			s.floor = c
+		}
+		s.active[c] = struct{}{}
+		events = append(events, s.launch(c, nil))
+		return nil
+	}
+
+	holder := s.floor
+	if c.Priority <= holder.Priority {
+		return ErrStageBusy
+	}
+	s.active[c] = struct{}{}
+	switch holder.OnInterrupt {
+	case InterruptQueue:
+		s.waiting = append(s.waiting, c)
+		events = append(events, StageEvent{Kind: StageQueued, Conversation: c, Cause: holder})
+	case InterruptPause:
+		holder.interrupt()
+		s.floor = c
+		events = append(events,
+			StageEvent{Kind: StagePaused, Conversation: holder, Cause: c},
+			s.launch(c, holder.stopped),
+		)
+	default:
+		holder.interrupt()
+		s.floor = c
+		events = append(events,
+			StageEvent{Kind: StageAborted, Conversation: holder, Cause: c},
+			s.launch(c, holder.stopped),
+		)
+	}
+	return nil
+}
+
+// launch starts (or resumes) a run segment for c, after prev is closed.
+// s.mu must be held.
+func (s *Stage) launch(c *Conversation, prev <-chan struct{}) StageEvent {
+	c.interrupted.Store(false)
+	c.stopped = make(chan struct{})
+	kind := StageStarted
+	if c.started {
+		kind = StageResumed
+	}
+	go s.run(c, prev, c.started, c.stopped)
+	c.started = true
+	return StageEvent{Kind: kind, Conversation: c}
+}
+
+func (s *Stage) run(c *Conversation, prev <-chan struct{}, resume bool, stopped chan struct{}) {
+	if prev != nil {
+		<-prev
+	}
+	var err error
+	if resume {
+		err = c.resume()
+	} else {
+		err = c.vm.Run(c.StartNode)
+	}
+
+	var events []StageEvent
+	s.mu.Lock()
+	paused := c.OnInterrupt == InterruptPause && c.snap != nil && errors.Is(err, ErrInterrupted)
+	if paused {
+		s.waiting = append(s.waiting, c)
+	} else {
+		delete(s.active, c)
+		c.err = err
+		if !errors.Is(err, ErrInterrupted) {
+			// If it was aborted, StageAborted was already emitted.
+			events = append(events, StageEvent{Kind: StageFinished, Conversation: c})
+		}
+	}
+	if s.floor == c {
+		s.floor = nil
+		if next := s.nextWaiting(); next != nil {
+			s.floor = next
+			events = append(events, s.launch(next, stopped))
+		}
+	}
+	s.mu.Unlock()
+
+	close(stopped)
+	if !paused {
+		close(c.done)
+	}
+	s.emit(events)
+}
+
+// resume re-delivers the interrupted event, if any, then resumes the VM from
+// the snapshot taken when pausing.
+func (c *Conversation) resume() error {
+	snap, replay := c.snap, c.replay
+	c.snap, c.replay = nil, nil
+	if replay != nil {
+		if err := replay(); err != nil {
+			return err
+		}
+	}
+	if err := c.vm.Restore(snap); err != nil {
+		return err
+	}
+	return c.vm.Resume()
+}
+
+// nextWaiting removes and returns the waiting conversation with the highest
+// priority (the earliest, among equals). s.mu must be held.
+func (s *Stage) nextWaiting() *Conversation {
+	best := -1
+	for i, c := range s.waiting {
+		if best < 0 || c.Priority > s.waiting[best].Priority {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil
+	}
+	c := s.waiting[best]
+	s.waiting = append(s.waiting[:best:best], s.waiting[best+1:]...)
+	return c
+}
+
+func (s *Stage) emit(events []StageEvent) {
+	if s.OnEvent == nil {
+		return
+	}
+	for _, e := range events {
+		s.OnEvent(e)
+	}
+}
+
+// Floor returns the conversation currently holding the floor, or nil.
+func (s *Stage) Floor() *Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.floor
+}
+
+// Active returns all the conversations that have started and not finished
+// (including those paused or queued), in no particular order.
+func (s *Stage) Active() []*Conversation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cs := make([]*Conversation, 0, len(s.active))
+	for c := range s.active {
+		cs = append(cs, c)
+	}
+	return cs
+}
+
+// Interrupt ends a conversation (whether running, paused, or queued),
+// regardless of its OnInterrupt policy.
+func (s *Stage) Interrupt(c *Conversation) {
+	var events []StageEvent
+	defer func() { s.emit(events) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.active[c]; !ok {
+		return
+	}
+	events = append(events, StageEvent{Kind: StageAborted, Conversation: c})
+	for i, w := range s.waiting {
+		if w == c {
+			// Not running, so finish it here.
+			s.waiting = append(s.waiting[:i:i], s.waiting[i+1:]...)
+			delete(s.active, c)
+			c.err = ErrInterrupted
+			close(c.done)
+			return
+		}
+	}
+	c.forceAbort.Store(true)
+	c.interrupt()
+}
+
+// stageHandler stops delivering events to the conversation's handler once it
+// has been interrupted, snapshotting the VM if the conversation is to be
+// paused.
+type stageHandler struct {
+	c *Conversation
+}
+
+// wrap calls f unless interrupted, and checks again afterwards. If f is
+// interrupted before or during the call, and the conversation is to be
+// paused, replay is arranged to deliver the event again on resumption.
+// NodeComplete and DialogueComplete are not wrapped: they are brief
+// notifications, and snapshots taken during them (e.g. while moving between
+// nodes) could not be resumed.
+func (h stageHandler) wrap(f func() error, replayable bool) error {
+	if h.c.interrupted.Load() {
+		return h.pause(f, replayable)
+	}
+	err := f()
+	if h.c.interrupted.Load() {
+		if err != nil {
+			// Most likely aborted during the event.
+			return h.pause(f, replayable)
+		}
+		return h.pause(nil, false)
+	}
+	return err
+}
+
+// pause snapshots the VM if the conversation is to be paused, and returns
+// ErrInterrupted.
+func (h stageHandler) pause(f func() error, replayable bool) error {
+	if h.c.OnInterrupt == InterruptPause && !h.c.forceAbort.Load() {
+		h.c.snap = h.c.vm.Snapshot()
+		if replayable {
+			h.c.replay = f
+		}
+	}
+	return ErrInterrupted
+}
+
+func (h stageHandler) NodeStart(nodeName string) error {
+	return h.wrap(func() error { return h.c.Handler.NodeStart(nodeName) }, true)
+}
+
+func (h stageHandler) PrepareForLines(lineIDs []string) error {
+	return h.wrap(func() error { return h.c.Handler.PrepareForLines(lineIDs) }, true)
+}
+
+func (h stageHandler) Line(line Line) error {
+	line = line.Clone() // in case it is replayed
+	return h.wrap(func() error { return h.c.Handler.Line(line) }, true)
+}
+
+func (h stageHandler) Options(options []Option) (int, error) {
+	// The snapshot includes the options, so they don't need replaying.
+	var id int
+	err := h.wrap(func() (err error) {
+		id, err = h.c.Handler.Options(options)
+		return err
+	}, false)
+	return id, err
+}
+
+func (h stageHandler) SkipLine(line Line) error {
+	line = line.Clone() // in case it is replayed
+	return h.wrap(func() error { return forwardSkipLine(h.c.Handler, line) }, true)
+}
+
+func (h stageHandler) Command(command string) error {
+	return h.wrap(func() error { return h.c.Handler.Command(command) }, true)
+}
+
+// CommandResult stores the result itself when the command is replayed, since
+// the VM is no longer waiting for it.
+func (h stageHandler) CommandResult(command string) (any, error) {
+	vm, variable := h.c.vm, h.c.vm.state.resultVariable
+	var result any
+	replaying := false
+	err := h.wrap(func() (err error) {
+		result, err = forwardCommandResult(h.c.Handler, command)
+		if err != nil || !replaying {
+			return err
+		}
+		return vm.storeCommandResult(command, variable, result)
+	}, true)
+	replaying = true
+	return result, err
+}
+
+func (h stageHandler) NodeComplete(nodeName string) error {
+	return h.c.Handler.NodeComplete(nodeName)
+}
+
+func (h stageHandler) DialogueComplete() error {
+	return h.c.Handler.DialogueComplete()
+}
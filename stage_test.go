@@ -0,0 +1,219 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// blockingHandler blocks in Line until released or aborted.
+type blockingHandler struct {
+	FakeDialogueHandler
+	lines   chan string
+	release chan error
+}
+
+func newBlockingHandler() *blockingHandler {
+	return &blockingHandler{
+		lines:   make(chan string),
+		release: make(chan error, 1),
+	}
+}
+
+func (h *blockingHandler) Line(line Line) error {
+	h.lines <- line.ID
+	return <-h.release
+}
+
+func (h *blockingHandler) Options([]Option) (int, error) { return 0, nil }
+
+func (h *blockingHandler) Abort(err error) error {
+	select {
+	case h.release <- err:
+	default:
+	}
+	return nil
+}
+
+func TestStage(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/ShortcutOptions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+	stage := &Stage{
+		Program: prog,
+		Vars:    NewMapVariableStorage(),
+	}
+
+	lowH := newBlockingHandler()
+	low := &Conversation{Name: "low", StartNode: "Start", Handler: lowH}
+	if err := stage.Start(low); err != nil {
+		t.Fatalf("stage.Start(low) = %v", err)
+	}
+	<-lowH.lines
+
+	// A concurrent conversation runs alongside.
+	bark := &Conversation{Name: "bark", StartNode: "Start", Concurrent: true, Handler: FakeDialogueHandler{}}
+	if err := stage.Start(bark); err != nil {
+		t.Fatalf("stage.Start(bark) = %v", err)
+	}
+	if err := bark.Wait(); err != nil {
+		t.Errorf("bark.Wait() = %v", err)
+	}
+
+	// A higher-priority conversation interrupts.
+	highH := newBlockingHandler()
+	high := &Conversation{Name: "high", StartNode: "Start", Priority: 1, Handler: highH}
+	if err := stage.Start(high); err != nil {
+		t.Fatalf("stage.Start(high) = %v", err)
+	}
+	if err := low.Wait(); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("low.Wait() = %v, want ErrInterrupted", err)
+	}
+	<-highH.lines
+
+	// Equal priority is rejected.
+	other := &Conversation{Name: "other", StartNode: "Start", Priority: 1, Handler: FakeDialogueHandler{}}
+	if err := stage.Start(other); !errors.Is(err, ErrStageBusy) {
+		t.Errorf("stage.Start(other) = %v, want ErrStageBusy", err)
+	}
+	if got := stage.Floor(); got != high {
+		t.Errorf("stage.Floor() = %v, want high", got)
+	}
+
+	go func() {
+		highH.release <- nil
+		for range highH.lines {
+			highH.release <- nil
+		}
+	}()
+	if err := high.Wait(); err != nil {
+		t.Errorf("high.Wait() = %v", err)
+	}
+	if got := stage.Active(); len(got) != 0 {
+		t.Errorf("len(stage.Active()) = %d, want 0", len(got))
+	}
+}
+
+// drain releases every line the handler delivers, recording them.
+func drain(h *blockingHandler, rec chan<- string) {
+	for id := range h.lines {
+		rec <- id
+		h.release <- nil
+	}
+}
+
+func TestStagePolicies(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/ShortcutOptions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+
+	// The lines from an uninterrupted run.
+	var full []string
+	{
+		h := newBlockingHandler()
+		vm := &VirtualMachine{Program: prog, Handler: h, Vars: NewMapVariableStorage()}
+		done := make(chan struct{})
+		go func() {
+			for id := range h.lines {
+				full = append(full, id)
+				h.release <- nil
+			}
+		}()
+		go func() {
+			vm.Run("Start")
+			close(done)
+		}()
+		<-done
+	}
+
+	for _, policy := range []InterruptPolicy{InterruptPause, InterruptQueue} {
+		t.Run(policy.String(), func(t *testing.T) {
+			var mu sync.Mutex
+			var events []string
+			stage := &Stage{
+				Program: prog,
+				Vars:    NewMapVariableStorage(),
+				OnEvent: func(e StageEvent) {
+					mu.Lock()
+					defer mu.Unlock()
+					events = append(events, e.Kind.String()+" "+e.Conversation.Name)
+				},
+			}
+
+			lowH := newBlockingHandler()
+			low := &Conversation{Name: "low", StartNode: "Start", OnInterrupt: policy, Handler: lowH}
+			if err := stage.Start(low); err != nil {
+				t.Fatalf("stage.Start(low) = %v", err)
+			}
+			lowLines := []string{<-lowH.lines}
+
+			highH := newBlockingHandler()
+			high := &Conversation{Name: "high", StartNode: "Start", Priority: 1, Handler: highH}
+			if err := stage.Start(high); err != nil {
+				t.Fatalf("stage.Start(high) = %v", err)
+			}
+
+			rec := make(chan string)
+			var want, wantEvents []string
+			switch policy {
+			case InterruptPause:
+				// High runs to completion, then low resumes by showing the
+				// interrupted line again.
+				go drain(highH, make(chan string, 100))
+				if err := high.Wait(); err != nil {
+					t.Errorf("high.Wait() = %v", err)
+				}
+				want = append([]string{full[0]}, full...)
+				wantEvents = []string{"started low", "paused low", "started high", "finished high", "resumed low", "finished low"}
+
+			case InterruptQueue:
+				// Low finishes, then high starts.
+				want = full
+				wantEvents = []string{"started low", "queued high", "finished low", "started high", "finished high"}
+				lowH.release <- nil
+				go drain(highH, make(chan string, 100))
+			}
+
+			go drain(lowH, rec)
+			go func() {
+				low.Wait()
+				close(rec)
+			}()
+			for id := range rec {
+				lowLines = append(lowLines, id)
+			}
+			if err := low.Wait(); err != nil {
+				t.Errorf("low.Wait() = %v", err)
+			}
+			if err := high.Wait(); err != nil {
+				t.Errorf("high.Wait() = %v", err)
+			}
+			if diff := cmp.Diff(lowLines, want); diff != "" {
+				t.Errorf("low lines diff (-got +want):\n%s", diff)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if diff := cmp.Diff(events, wantEvents); diff != "" {
+				t.Errorf("events diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
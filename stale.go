@@ -0,0 +1,68 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "sort"
+
+// MarkStale compares the string table, as a translation, against the base
+// string table, and sets Stale on the rows whose source text has changed
+// since they were translated (their SourceHash doesn't match the base text).
+// Stale is cleared on the other rows. It returns the IDs of the stale rows,
+// sorted by file, line number, and ID.
+func (t *StringTable) MarkStale(base *StringTable) []string {
+	for _, row := range t.Table {
+		if row != nil {
+			row.Stale = false
+		}
+	}
+	stale := t.Coverage(base).OutdatedIDs
+	for _, id := range stale {
+		t.Table[id].Stale = true
+	}
+	return stale
+}
+
+// CheckTranslation checks a translated string table against the base string
+// table, and returns a warning for each line whose translation is stale (see
+// MarkStale), and each line that is missing from the base table.
+func CheckTranslation(base, tr *StringTable) []Diagnostic {
+	var diags []Diagnostic
+	for _, id := range tr.Coverage(base).OutdatedIDs {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Node:     base.Table[id].Node,
+			PC:       -1,
+			LineID:   id,
+			Message:  "translation is stale: the source text has changed since it was translated",
+		})
+	}
+	var extra []*StringTableRow
+	for _, row := range tr.Table {
+		if row != nil && base.row(row.ID) == nil {
+			extra = append(extra, row)
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return rowLess(extra[i], extra[j]) })
+	for _, row := range extra {
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Node:     row.Node,
+			PC:       -1,
+			LineID:   row.ID,
+			Message:  "translated line not found in base string table",
+		})
+	}
+	return diags
+}
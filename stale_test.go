@@ -0,0 +1,101 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// A localized string table, as written by Yarn Spinner.
+const ysLocalizedCSV = "language,id,text,file,node,lineNumber,lock,comment\n" +
+	"fr,line:a,Bonjour,a.yarn,Start,1,185f8db3,\n" +
+	"fr,line:b,Au revoir,a.yarn,Start,2,00000000,\"Speaker: Ava\"\n" +
+	"fr,line:old,Vieux,a.yarn,Start,5,00000000,\n"
+
+func staleBase() *StringTable {
+	return &StringTable{Table: map[string]*StringTableRow{
+		"line:a": {ID: "line:a", Text: "Hello", File: "a.yarn", Node: "Start", LineNumber: 1},
+		"line:b": {ID: "line:b", Text: "Goodbye", File: "a.yarn", Node: "Start", LineNumber: 2},
+	}}
+}
+
+func TestMarkStale(t *testing.T) {
+	fr, err := ReadStringTable(strings.NewReader(ysLocalizedCSV), "fr")
+	if err != nil {
+		t.Fatalf("ReadStringTable = %v", err)
+	}
+	if got, want := fr.Table["line:b"].Text, "Au revoir"; got != want {
+		t.Errorf("fr.Table[line:b].Text = %q, want %q", got, want)
+	}
+
+	base := staleBase()
+	if diff := cmp.Diff(fr.MarkStale(base), []string{"line:b"}); diff != "" {
+		t.Errorf("fr.MarkStale(base) diff (-got +want):\n%s", diff)
+	}
+	if fr.Table["line:a"].Stale || !fr.Table["line:b"].Stale {
+		t.Errorf("Stale = %t, %t, want false, true", fr.Table["line:a"].Stale, fr.Table["line:b"].Stale)
+	}
+
+	want := []Diagnostic{
+		{Severity: SeverityWarning, Node: "Start", PC: -1, LineID: "line:b", Message: "translation is stale: the source text has changed since it was translated"},
+		{Severity: SeverityWarning, Node: "Start", PC: -1, LineID: "line:old", Message: "translated line not found in base string table"},
+	}
+	if diff := cmp.Diff(CheckTranslation(base, fr), want); diff != "" {
+		t.Errorf("CheckTranslation diff (-got +want):\n%s", diff)
+	}
+
+	// Updating the translation clears the flag.
+	fr.Table["line:b"].SourceHash = LineHash("Goodbye")
+	if got := fr.MarkStale(base); len(got) != 0 {
+		t.Errorf("fr.MarkStale(base) = %v, want none", got)
+	}
+	if fr.Table["line:b"].Stale {
+		t.Error("fr.Table[line:b].Stale = true, want false")
+	}
+}
+
+func TestLocaleSetStale(t *testing.T) {
+	base := staleBase()
+	var gotLang string
+	var gotIDs []string
+	ls := &LocaleSet{
+		Base: base,
+		OnStale: func(langCode string, ids []string) {
+			gotLang, gotIDs = langCode, ids
+		},
+		Load: func(langCode string) (*StringTable, error) {
+			return ReadStringTable(strings.NewReader(ysLocalizedCSV), langCode)
+		},
+	}
+	ls.Add("en", base)
+	if gotIDs != nil {
+		t.Errorf("OnStale called for base table with %v", gotIDs)
+	}
+	if err := ls.SetLocale("fr"); err != nil {
+		t.Fatalf("ls.SetLocale(fr) = %v", err)
+	}
+	if gotLang != "fr" {
+		t.Errorf("OnStale langCode = %q, want %q", gotLang, "fr")
+	}
+	if diff := cmp.Diff(gotIDs, []string{"line:b"}); diff != "" {
+		t.Errorf("OnStale ids diff (-got +want):\n%s", diff)
+	}
+	if !ls.Table("fr").Table["line:b"].Stale {
+		t.Error("ls.Table(fr).Table[line:b].Stale = false, want true")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// StateHash is a hash of dialogue state: the current node, program counter,
+// stack, pending options, node parameters, and variables (including visit
+// counts). History is not included.
+//
+// Two VMs (or one VM at two times) with equal hashes will behave identically
+// from that point, given the same choices and deterministic functions. This
+// is useful for detecting branches that converge, deduplicating states when
+// exploring, and checking that a replay reached the same state as the
+// original run.
+//
+// The hash is stable across processes and releases, as long as the state is
+// made of the usual variable types (bool, float32, and string). Numbers
+// decoded from JSON (float64) hash the same as the equivalent float32.
+type StateHash [sha256.Size]byte
+
+// String returns the hash in hexadecimal.
+func (h StateHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+// HashState hashes a snapshot of the execution state together with the
+// contents of variable storage.
+func HashState(snap *Snapshot, vars map[string]any) StateHash {
+	w := sha256.New()
+	writeState(w, snap, vars)
+	var h StateHash
+	w.Sum(h[:0])
+	return h
+}
+
+// StateHash hashes the current state of the VM. It can be called from within
+// handler methods, or while the VM is not running. Vars must support
+// Contents (like MapVariableStorage).
+func (vm *VirtualMachine) StateHash() (StateHash, error) {
+	cs, ok := vm.Vars.(contentsStorage)
+	if !ok {
+		return StateHash{}, ErrVarsNotSnapshottable
+	}
+	return HashState(vm.Snapshot(), cs.Contents()), nil
+}
+
+// writeState writes a canonical encoding of the state.
+func writeState(w io.Writer, snap *Snapshot, vars map[string]any) {
+	fmt.Fprintf(w, "%q %q %d\n", snap.Program, snap.Node, snap.PC)
+	for _, x := range snap.Stack {
+		writeStateValue(w, "stack", "", x)
+	}
+	for _, opt := range snap.Options {
+		fmt.Fprintf(w, "option %d %q %q %t %q\n", opt.ID, opt.Line.ID, opt.Line.Substitutions, opt.IsAvailable, opt.DestinationNode)
+	}
+	for _, name := range sortedKeys(snap.Locals) {
+		writeStateValue(w, "local", name, snap.Locals[name])
+	}
+	for _, name := range sortedKeys(vars) {
+		writeStateValue(w, "var", name, vars[name])
+	}
+}
+
+func writeStateValue(w io.Writer, kind, name string, x any) {
+	x = normalizeValue(x)
+	fmt.Fprintf(w, "%s %q %T %#v\n", kind, name, x, x)
+}
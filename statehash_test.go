@@ -0,0 +1,104 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"testing"
+)
+
+// hashingHandler records the state hash at each line.
+type hashingHandler struct {
+	FakeDialogueHandler
+	vm     *VirtualMachine
+	hashes map[string]StateHash
+}
+
+func (h *hashingHandler) Line(line Line) error {
+	sh, err := h.vm.StateHash()
+	if err != nil {
+		return err
+	}
+	h.hashes[line.ID] = sh
+	return nil
+}
+
+func TestStateHash(t *testing.T) {
+	snap := &Snapshot{Node: "Start", PC: 3, Stack: []any{float32(1), "x"}}
+	vars := map[string]any{"$a": float32(2), "$b": true}
+	want := HashState(snap, vars)
+
+	// Numbers decoded from JSON hash the same.
+	snap2 := &Snapshot{Node: "Start", PC: 3, Stack: []any{float64(1), "x"}}
+	vars2 := map[string]any{"$b": true, "$a": float64(2)}
+	if got := HashState(snap2, vars2); got != want {
+		t.Errorf("HashState(float64 state) = %v, want %v", got, want)
+	}
+
+	vars2["$a"] = float32(3)
+	if got := HashState(snap2, vars2); got == want {
+		t.Errorf("HashState(changed var) = %v, want different hash", got)
+	}
+	snap2.PC = 4
+	if got := HashState(snap2, vars); got == want {
+		t.Errorf("HashState(changed pc) = %v, want different hash", got)
+	}
+}
+
+func TestVirtualMachineStateHash(t *testing.T) {
+	pb := NewProgramBuilder("Converge")
+	pb.Node("Start").
+		Line("line:a", 0).
+		PushBool(true).StoreVariable("$x").Pop().
+		Line("line:b", 0).
+		PushBool(false).StoreVariable("$x").Pop().
+		Line("line:c", 0)
+	prog := pb.Program()
+
+	h := &hashingHandler{hashes: make(map[string]StateHash)}
+	vm := &VirtualMachine{
+		Program: prog,
+		Handler: h,
+		Vars:    NewMapVariableStorageFromMap(map[string]any{"$x": false}),
+	}
+	h.vm = vm
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if h.hashes["line:a"] == h.hashes["line:b"] {
+		t.Errorf("hash at line:a == hash at line:b, want different")
+	}
+	if h.hashes["line:b"] == h.hashes["line:c"] {
+		t.Errorf("hash at line:b == hash at line:c, want different")
+	}
+
+	// A replay reaches the same states.
+	first := h.hashes
+	h.hashes = make(map[string]StateHash)
+	vm.Vars = NewMapVariableStorageFromMap(map[string]any{"$x": false})
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	for id, sh := range first {
+		if h.hashes[id] != sh {
+			t.Errorf("replay hash at %s = %v, want %v", id, h.hashes[id], sh)
+		}
+	}
+
+	vm.Vars = struct{ VariableStorage }{NewMapVariableStorage()}
+	if _, err := vm.StateHash(); !errors.Is(err, ErrVarsNotSnapshottable) {
+		t.Errorf("vm.StateHash() = %v, want %v", err, ErrVarsNotSnapshottable)
+	}
+}
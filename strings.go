@@ -23,6 +23,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"text/template"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -38,7 +39,35 @@ import (
 type StringTable struct {
 	Language language.Tag
 	Table    map[string]*StringTableRow
-}
+
+	// TemplateData, if not nil, enables template rendering: after
+	// substitutions, format functions, and markup are processed, Render
+	// executes each line as a Go text/template with TemplateData as the data
+	// (see AttributedString.ExecuteTemplate). TemplateFuncs are made available
+	// to the template.
+	TemplateData  any
+	TemplateFuncs template.FuncMap
+
+	// FormSelectors overrides or extends the format functions available to
+	// lines, keyed by format function name. This allows language-specific
+	// agreement beyond CLDR plurals, such as Slavic case selection or gendered
+	// adjective forms. An entry named "plural", "ordinal", or "select"
+	// replaces the built-in function; any other name adds a new one, e.g.
+	//
+	//	[case value={0} nom="книга" gen="книги" /]
+	FormSelectors map[string]FormSelector
+
+	// PostProcessors are applied in order to every rendered line and option,
+	// after templates (e.g. a TextFilter for content rating).
+	PostProcessors []LineProcessor
+}
+
+// FormSelector chooses which property of a format function to render. It is
+// passed the evaluated "value" property, the language, and the keys of all
+// the properties of the format function (including "value"), and returns the
+// key of the property to render. As with the built-in functions, % within the
+// chosen property is replaced with the value.
+type FormSelector func(value string, lang language.Tag, keys []string) (string, error)
 
 // LoadStringTableFile is a convenient function for loading a CSV string table
 // given a file path. If stringTablePath is foo/bar/file-Lines.csv then it expects
@@ -90,17 +119,24 @@ func LoadStringTableFileFS(fsys fs.FS, stringTablePath, langCode string) (*Strin
 // first row is a header. langCode must be a valid BCP 47 language tag.
 // In addition to checking the CSV structure as it is parsed, each lineNumber
 // is parsed as an int, and each text is also parsed. Any malformed substitution
-// tokens or markup tags will cause an error.
+// tokens or markup tags will cause an error. Translated string tables may have
+// a sourceHash (or "lock") column (see StringTableRow.SourceHash). Columns are
+// found by name, so Yarn Spinner's localized string tables can also be read.
 func ReadStringTable(r io.Reader, langCode string) (*StringTable, error) {
+	return readStringTable(r, langCode, true)
+}
+
+// readStringTable reads a CSV string table. If parse is false, parsing each
+// text is deferred until it is first rendered.
+func readStringTable(r io.Reader, langCode string, parse bool) (*StringTable, error) {
 	lang, err := language.Parse(langCode)
 	if err != nil {
 		return nil, fmt.Errorf("invalid lang code: %w", err)
 	}
 
 	st := make(map[string]*StringTableRow)
-	header := true
+	var cols *stringTableColumns
 	cr := csv.NewReader(r)
-	cr.FieldsPerRecord = 5
 	for {
 		rec, err := cr.Read()
 		if err == io.EOF {
@@ -109,26 +145,33 @@ func ReadStringTable(r io.Reader, langCode string) (*StringTable, error) {
 		if err != nil {
 			return nil, fmt.Errorf("csv read: %w", err)
 		}
-		if header {
-			header = false
+		if cols == nil {
+			if cols, err = parseStringTableHeader(rec); err != nil {
+				return nil, err
+			}
 			continue
 		}
 		// Line number must be an int
-		ln, err := strconv.Atoi(rec[4])
+		ln, err := strconv.Atoi(rec[cols.lineNumber])
 		if err != nil {
 			return nil, fmt.Errorf("line number not an int: %w", err)
 		}
-		id := rec[0]
+		id := rec[cols.id]
 		row := &StringTableRow{
 			ID:         id,
-			Text:       rec[1],
-			File:       rec[2],
-			Node:       rec[3],
+			Text:       rec[cols.text],
+			File:       rec[cols.file],
+			Node:       rec[cols.node],
 			LineNumber: ln,
 		}
+		if cols.sourceHash >= 0 {
+			row.SourceHash = rec[cols.sourceHash]
+		}
 		// Text must be parseable - parse it now to catch errors sooner
-		if err := row.parseIfNeeded(); err != nil {
-			return nil, fmt.Errorf("text for id %s could not be parsed: %w", id, err)
+		if parse {
+			if err := row.parseIfNeeded(); err != nil {
+				return nil, fmt.Errorf("text for id %s could not be parsed: %w", id, err)
+			}
 		}
 		st[id] = row
 	}
@@ -138,6 +181,93 @@ func ReadStringTable(r io.Reader, langCode string) (*StringTable, error) {
 	}, nil
 }
 
+// stringTableColumns are the indexes of the columns of a string table.
+type stringTableColumns struct {
+	id, text, file, node, lineNumber int
+	sourceHash                       int // -1 if absent
+}
+
+// parseStringTableHeader finds the columns by name. Yarn Spinner's localized
+// string tables have extra columns (language, comment) and call the source
+// hash "lock". Headers without the usual names are assumed to be in the
+// order id, text, file, node, lineNumber, and optionally sourceHash.
+func parseStringTableHeader(header []string) (*stringTableColumns, error) {
+	cols := &stringTableColumns{id: -1, text: -1, file: -1, node: -1, lineNumber: -1, sourceHash: -1}
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case "id":
+			cols.id = i
+		case "text":
+			cols.text = i
+		case "file":
+			cols.file = i
+		case "node":
+			cols.node = i
+		case "lineNumber":
+			cols.lineNumber = i
+		case "sourceHash", "lock":
+			cols.sourceHash = i
+		}
+	}
+	if cols.id >= 0 && cols.text >= 0 && cols.file >= 0 && cols.node >= 0 && cols.lineNumber >= 0 {
+		return cols, nil
+	}
+	switch len(header) {
+	case 5:
+		return &stringTableColumns{0, 1, 2, 3, 4, -1}, nil
+	case 6:
+		return &stringTableColumns{0, 1, 2, 3, 4, 5}, nil
+	}
+	return nil, fmt.Errorf("csv read: header has %d fields, want 5 or 6", len(header))
+}
+
+// WriteStringTable writes the rows of a string table in the CSV format read
+// by ReadStringTable, sorted by file, line number, and ID. Tags are not
+// written, since they belong in the metadata table. The sourceHash column is
+// only written if some row has a SourceHash.
+func WriteStringTable(w io.Writer, st *StringTable) error {
+	rows := make([]*StringTableRow, 0, len(st.Table))
+	for _, row := range st.Table {
+		if row != nil {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rowLess(rows[i], rows[j]) })
+	hashes := false
+	for _, row := range rows {
+		hashes = hashes || row.SourceHash != ""
+	}
+	cw := csv.NewWriter(w)
+	header := []string{"id", "text", "file", "node", "lineNumber"}
+	if hashes {
+		header = append(header, "sourceHash")
+	}
+	cw.Write(header)
+	for _, row := range rows {
+		rec := []string{row.ID, row.Text, row.File, row.Node, strconv.Itoa(row.LineNumber)}
+		if hashes {
+			rec = append(rec, row.SourceHash)
+		}
+		cw.Write(rec)
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// rowLess orders rows by file, line number, and ID.
+func rowLess(a, b *StringTableRow) bool {
+	if a.File != b.File {
+		return a.File < b.File
+	}
+	if a.LineNumber != b.LineNumber {
+		return a.LineNumber < b.LineNumber
+	}
+	return a.ID < b.ID
+}
+
 // readMetadata extracts tags from the metadata table.
 func (t *StringTable) readMetadata(r io.Reader) error {
 	header := true
@@ -170,13 +300,28 @@ func (t *StringTable) readMetadata(r io.Reader) error {
 
 // Render looks up the row corresponding to line.ID, interpolates substitutions
 // (from line.Substitutions), applies format functions, and processes style
-// tags into attributes.
+// tags into attributes. If TemplateData is set, it also executes the result
+// as a template. Finally it applies PostProcessors.
 func (t *StringTable) Render(line Line) (*AttributedString, error) {
 	row := t.Table[line.ID]
 	if row == nil {
 		return nil, fmt.Errorf("string table row for id %q not found or nil", line.ID)
 	}
-	return row.Render(line.Substitutions, t.Language)
+	as, err := row.render(line.Substitutions, t.Language, t.FormSelectors)
+	if err != nil {
+		return nil, err
+	}
+	if t.TemplateData != nil {
+		if err := as.ExecuteTemplate(t.TemplateData, t.TemplateFuncs); err != nil {
+			return nil, fmt.Errorf("line %q: %w", line.ID, err)
+		}
+	}
+	for _, p := range t.PostProcessors {
+		if err := p(line, as); err != nil {
+			return nil, fmt.Errorf("line %q: %w", line.ID, err)
+		}
+	}
+	return as, nil
 }
 
 // StringTableRow contains all the information from one row in a string table.
@@ -188,17 +333,32 @@ type StringTableRow struct {
 	parsedText *parsedString
 
 	Tags []string // Tags are set in the metadata table.
+
+	// SourceHash is the LineHash of the source text a translation was made
+	// from. It is empty for source string tables, and for translations that
+	// don't record it. It is used to find outdated translations (see
+	// StringTable.Coverage).
+	SourceHash string
+
+	// Stale is set by StringTable.MarkStale if the source text has changed
+	// since the row was translated.
+	Stale bool
 }
 
 // Render interpolates substitutions, applies format functions, and processes
 // style tags into attributes.
 func (r *StringTableRow) Render(substs []string, lang language.Tag) (*AttributedString, error) {
+	return r.render(substs, lang, nil)
+}
+
+func (r *StringTableRow) render(substs []string, lang language.Tag, forms map[string]FormSelector) (*AttributedString, error) {
 	if err := r.parseIfNeeded(); err != nil {
 		return nil, err
 	}
 	lr := lineRenderer{
 		substs: substs,
 		lang:   lang,
+		forms:  forms,
 	}
 	if err := lr.renderString(r.parsedText); err != nil {
 		return nil, err
@@ -326,19 +486,29 @@ type fragment struct {
 
 // stringOrSubst appears inside markup tags. The value={0} prop is emitted
 // without quoting the substitution token. Other props are usually of the form
-// key="value".
+// key="value", but simple values may be unquoted (key=value).
 type stringOrSubst struct {
 	String *parsedString `parser:"String @@ StringEnd"`
 	Subst  string        `parser:" | Subst @Index SubstEnd"`
+	Bare   string        `parser:" | @Ident"`
 }
 
 // parsedMarkupTag is used for both format functions (select, plural, ordinal) and
 // BBCode-esque markup tags ([b]Bold!?[/b]).
 type parsedMarkupTag struct {
-	OpeningSlash string        `parser:"@Slash?"` // indicates closing tag of a pair
-	Name         string        `parser:"@Ident?"` // used for all except close-all tag [/]
-	Props        []*parsedProp `parser:"@@*"`     // optional key="value" or value={0} properties
-	ClosingSlash string        `parser:"@Slash?"` // indicates self-closing tag
+	OpeningSlash string         `parser:"@Slash?"`      // indicates closing tag of a pair
+	Name         string         `parser:"@Ident?"`      // used for all except close-all tag [/]
+	Value        *stringOrSubst `parser:"(Equals @@)?"` // [name=value] is short for [name name=value]
+	Props        []*parsedProp  `parser:"@@*"`          // optional key="value" or value={0} properties
+	ClosingSlash string         `parser:"@Slash?"`      // indicates self-closing tag
+}
+
+// props returns the tag's properties, including the [name=value] shorthand.
+func (f *parsedMarkupTag) props() []*parsedProp {
+	if f.Value == nil {
+		return f.Props
+	}
+	return append([]*parsedProp{{Key: f.Name, Value: f.Value}}, f.Props...)
 }
 
 // parsedProp is used for key="value" properties of format funcs and markup
@@ -354,6 +524,7 @@ type lineRenderer struct {
 	open    map[string][]*Attribute // lazily created; name -> stack of tags currently open
 	substs  []string
 	lang    language.Tag
+	forms   map[string]FormSelector
 }
 
 func (b *lineRenderer) attStr() *AttributedString {
@@ -470,6 +641,10 @@ var formKeyTable = []string{
 
 func (b *lineRenderer) renderMarkupTag(f *parsedMarkupTag) error {
 	switch {
+	case f.OpeningSlash == "" && b.forms[f.Name] != nil:
+		// Custom format function [case value={0} nom="..." gen="..." /]
+		return b.renderCustomFormatFunc(f, b.forms[f.Name])
+
 	case f.Name == "select":
 		// [select value={0} m="bro" f="sis" nb="doc" /]
 		return b.renderSelectFormatFunc(f)
@@ -493,14 +668,14 @@ func (b *lineRenderer) renderMarkupTag(f *parsedMarkupTag) error {
 
 	case f.ClosingSlash == "/":
 		// Self-closing tag [foo/]
-		if err := b.openTag(f.Name, f.Props); err != nil {
+		if err := b.openTag(f.Name, f.props()); err != nil {
 			return err
 		}
 		return b.closeTag(f.Name)
 
 	case f.Name != "":
 		// Open tag [foo]
-		return b.openTag(f.Name, f.Props)
+		return b.openTag(f.Name, f.props())
 
 	default:
 		// Uhhhhhh... [] ?
@@ -521,6 +696,22 @@ func (b *lineRenderer) evalValueValue(f *parsedMarkupTag) (string, error) {
 	return b.evalStringOrSubst(val)
 }
 
+// PluralForm returns the CLDR plural form key ("zero", "one", "two", "few",
+// "many", or "other") for the number in value, using the rules (plural.Cardinal
+// or plural.Ordinal) for lang. It is useful for FormSelectors that only need
+// to handle some cases specially.
+func PluralForm(value string, lang language.Tag, rules *plural.Rules) (string, error) {
+	ops, err := cldr.NewOperands(value)
+	if err != nil {
+		return "", err
+	}
+	form := rules.MatchPlural(lang, int(ops.I), int(ops.V), int(ops.W), int(ops.F), int(ops.T))
+	if int(form) >= len(formKeyTable) {
+		return "", fmt.Errorf("plural form %v not supported", form)
+	}
+	return formKeyTable[form], nil
+}
+
 func (b *lineRenderer) renderSelectFormatFunc(f *parsedMarkupTag) error {
 	// Get the value of the "value" property.
 	input, err := b.evalValueValue(f)
@@ -543,16 +734,35 @@ func (b *lineRenderer) renderPluralFormatFunc(f *parsedMarkupTag, rules *plural.
 		return err
 	}
 	// Use that value to match the cardinal form.
-	ops, err := cldr.NewOperands(input)
+	key, err := PluralForm(input, b.lang, rules)
 	if err != nil {
 		return err
 	}
-	form := rules.MatchPlural(b.lang, int(ops.I), int(ops.V), int(ops.W), int(ops.F), int(ops.T))
-	if int(form) > len(formKeyTable) {
-		return fmt.Errorf("plural form %v not supported", form)
-	}
 	// Find the plural form in the properties.
-	val, err := b.propValueForKey(f, formKeyTable[form])
+	val, err := b.propValueForKey(f, key)
+	if err != nil {
+		return err
+	}
+	// Render that value to the output!
+	return b.renderFormatFuncValue(val, input)
+}
+
+func (b *lineRenderer) renderCustomFormatFunc(f *parsedMarkupTag, sel FormSelector) error {
+	// Get the value of the "value" property.
+	input, err := b.evalValueValue(f)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, len(f.Props))
+	for i, p := range f.Props {
+		keys[i] = p.Key
+	}
+	// Let the selector choose the property.
+	key, err := sel(input, b.lang, keys)
+	if err != nil {
+		return fmt.Errorf("format function %q: %w", f.Name, err)
+	}
+	val, err := b.propValueForKey(f, key)
 	if err != nil {
 		return err
 	}
@@ -564,9 +774,13 @@ func (b *lineRenderer) evalStringOrSubst(s *stringOrSubst) (string, error) {
 	if s.Subst != "" {
 		return b.evalSubst(s.Subst), nil
 	}
+	if s.String == nil {
+		return s.Bare, nil
+	}
 	inb := &lineRenderer{
 		substs: b.substs,
 		lang:   b.lang,
+		forms:  b.forms,
 	}
 	if err := inb.renderString(s.String); err != nil {
 		return "", err
@@ -592,6 +806,10 @@ func (b *lineRenderer) renderFormatFuncValue(s *stringOrSubst, input string) err
 		b.builder.WriteString(b.evalSubst(s.Subst))
 		return nil
 	}
+	if s.String == nil {
+		b.builder.WriteString(s.Bare)
+		return nil
+	}
 	for _, v := range s.String.Fragments {
 		if v.Text == "%" {
 			b.builder.WriteString(input)
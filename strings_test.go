@@ -15,9 +15,13 @@
 package yarn
 
 import (
+	"strings"
 	"testing"
+	"text/template"
 
 	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
 )
 
 func TestScanAttribEvents(t *testing.T) {
@@ -86,3 +90,145 @@ func TestScanAttribEvents(t *testing.T) {
 		t.Errorf("ScanAttribEvents scan order diff:\n%s", diff)
 	}
 }
+
+func TestRenderTemplate(t *testing.T) {
+	st := &StringTable{
+		Table: map[string]*StringTableRow{
+			"line:1": {
+				ID:   "line:1",
+				Text: `[b]\{\{.Name\}\}[/b] has {0} \{\{plural .Gold\}\}.`,
+			},
+		},
+		TemplateData: struct {
+			Name string
+			Gold int
+		}{"Bea", 3},
+		TemplateFuncs: template.FuncMap{
+			"plural": func(n int) string {
+				if n == 1 {
+					return "coin"
+				}
+				return "coins"
+			},
+		},
+	}
+	as, err := st.Render(Line{ID: "line:1", Substitutions: []string{"3"}})
+	if err != nil {
+		t.Fatalf("st.Render = %v", err)
+	}
+	if got, want := as.String(), "Bea has 3 coins."; got != want {
+		t.Errorf("as.String() = %q, want %q", got, want)
+	}
+	attB := &Attribute{Start: 0, End: 3, Name: "b"}
+	if diff := cmp.Diff(as.atts, map[int][]*Attribute{0: {attB}, 3: {attB}}); diff != "" {
+		t.Errorf("as.atts diff:\n%s", diff)
+	}
+
+	st.TemplateData = map[string]any{}
+	if _, err := st.Render(Line{ID: "line:1"}); err == nil {
+		t.Errorf("st.Render with missing key = nil error, want error")
+	}
+}
+
+func TestFormSelectors(t *testing.T) {
+	st := &StringTable{
+		Language: language.Russian,
+		Table: map[string]*StringTableRow{
+			"line:1": {
+				ID:   "line:1",
+				Text: `[case value={0} nom="книга" gen="книги" /], [plural value={1} zero="ни одного дня" one="% день" few="% дня" many="% дней" other="% дня" /]`,
+			},
+		},
+		FormSelectors: map[string]FormSelector{
+			"case": func(value string, lang language.Tag, keys []string) (string, error) {
+				return value, nil
+			},
+			// Override plural to use a special form for zero, and otherwise
+			// fall back to CLDR.
+			"plural": func(value string, lang language.Tag, keys []string) (string, error) {
+				if value == "0" {
+					return "zero", nil
+				}
+				return PluralForm(value, lang, plural.Cardinal)
+			},
+		},
+	}
+	tests := []struct {
+		substs []string
+		want   string
+	}{
+		{[]string{"nom", "1"}, "книга, 1 день"},
+		{[]string{"gen", "3"}, "книги, 3 дня"},
+		{[]string{"gen", "5"}, "книги, 5 дней"},
+		{[]string{"nom", "11"}, "книга, 11 дней"},
+		{[]string{"nom", "0"}, "книга, ни одного дня"},
+	}
+	for _, test := range tests {
+		as, err := st.Render(Line{ID: "line:1", Substitutions: test.substs})
+		if err != nil {
+			t.Fatalf("st.Render(%v) = %v", test.substs, err)
+		}
+		if got := as.String(); got != test.want {
+			t.Errorf("st.Render(%v) = %q, want %q", test.substs, got, test.want)
+		}
+	}
+}
+
+func TestTextFilter(t *testing.T) {
+	filter := &TextFilter{
+		Words:        []string{"Frak"},
+		Replacements: map[string]string{"damn": "darn"},
+		Func: func(word string) (string, bool) {
+			if strings.HasPrefix(word, "smeg") {
+				return "smeg", true
+			}
+			return "", false
+		},
+	}
+	st := &StringTable{
+		Table: map[string]*StringTableRow{
+			"line:1": {ID: "line:1", Text: `[b]Damn[/b], that {0} [i]frakking[/i] frak!`},
+		},
+		PostProcessors: []LineProcessor{filter.Process},
+	}
+	as, err := st.Render(Line{ID: "line:1", Substitutions: []string{"smeghead"}})
+	if err != nil {
+		t.Fatalf("st.Render = %v", err)
+	}
+	if got, want := as.String(), "Darn, that smeg frakking ****!"; got != want {
+		t.Errorf("as.String() = %q, want %q", got, want)
+	}
+	attB := &Attribute{Start: 0, End: 4, Name: "b"}
+	attI := &Attribute{Start: 16, End: 24, Name: "i"}
+	if diff := cmp.Diff(as.atts, map[int][]*Attribute{0: {attB}, 4: {attB}, 16: {attI}, 24: {attI}}); diff != "" {
+		t.Errorf("as.atts diff:\n%s", diff)
+	}
+}
+
+func TestMarkupShorthand(t *testing.T) {
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: `[emotion=angry/]Get out! [wave size=5 speed="2"]Now![/wave]`},
+	}}
+	as, err := st.Render(Line{ID: "line:1"})
+	if err != nil {
+		t.Fatalf("Render(line:1) = %v", err)
+	}
+	if got, want := as.String(), "Get out! Now!"; got != want {
+		t.Errorf("as.String() = %q, want %q", got, want)
+	}
+	var got []*Attribute
+	as.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if a.Start == pos {
+				got = append(got, a)
+			}
+		}
+	})
+	want := []*Attribute{
+		{Start: 0, End: 0, Name: "emotion", Props: map[string]string{"emotion": "angry"}},
+		{Start: 9, End: 13, Name: "wave", Props: map[string]string{"size": "5", "speed": "2"}},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("attributes diff (-got +want):\n%s", diff)
+	}
+}
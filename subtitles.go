@@ -0,0 +1,137 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// DefaultCueDuration is how long a subtitle cue is shown when the transcript
+// gives no other way of knowing.
+const DefaultCueDuration = 2 * time.Second
+
+// Cue is a line shown as a subtitle from Start to End.
+type Cue struct {
+	Start, End time.Duration
+	Line       Line
+}
+
+// TimelineCues returns subtitle cues for the lines in a timeline. Each cue
+// lasts for the line's Duration; lines without a duration last until the
+// next line (or the end of the timeline), or DefaultCueDuration if that is
+// no later than the line.
+func TimelineCues(tl *Timeline) []Cue {
+	var cues []Cue
+	for i, ev := range tl.Events {
+		if ev.LineID == "" {
+			continue
+		}
+		end := ev.Time + ev.Duration
+		if ev.Duration == 0 {
+			end = tl.Duration
+			for _, next := range tl.Events[i+1:] {
+				if next.LineID != "" {
+					end = next.Time
+					break
+				}
+			}
+		}
+		cue := Cue{
+			Start: secondsToDuration(ev.Time),
+			End:   secondsToDuration(end),
+			Line:  Line{ID: ev.LineID, Substitutions: ev.Substitutions},
+		}
+		if cue.End <= cue.Start {
+			cue.End = cue.Start + DefaultCueDuration
+		}
+		cues = append(cues, cue)
+	}
+	return cues
+}
+
+func secondsToDuration(secs float64) time.Duration {
+	return time.Duration(secs*float64(time.Second) + 0.5)
+}
+
+// WriteSRT renders the cues with the string table and writes them in SubRip
+// (.srt) format. To produce subtitles for each locale, call it once per
+// string table.
+func WriteSRT(w io.Writer, cues []Cue, st *StringTable) error {
+	bw := bufio.NewWriter(w)
+	for i, cue := range cues {
+		as, err := st.Render(cue.Line)
+		if err != nil {
+			return fmt.Errorf("cue %d: %w", i+1, err)
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatCueTime(cue.Start, ','),
+			formatCueTime(cue.End, ','),
+			cueText(as.String()),
+		)
+	}
+	return bw.Flush()
+}
+
+// WriteWebVTT renders the cues with the string table and writes them in
+// WebVTT (.vtt) format. Speakers (see Line.Accessible) are written as voice
+// spans (<v Ava>). To produce subtitles for each locale, call it once per
+// string table.
+func WriteWebVTT(w io.Writer, cues []Cue, st *StringTable) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for i, cue := range cues {
+		as, err := st.Render(cue.Line)
+		if err != nil {
+			return fmt.Errorf("cue %d: %w", i+1, err)
+		}
+		speaker, text := findSpeaker(as)
+		text = vttEscaper.Replace(cueText(text))
+		if speaker != "" {
+			text = "<v " + vttEscaper.Replace(speaker) + ">" + text
+		}
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n",
+			i+1,
+			formatCueTime(cue.Start, '.'),
+			formatCueTime(cue.End, '.'),
+			text,
+		)
+	}
+	return bw.Flush()
+}
+
+var vttEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// cueText removes blank lines, which would end the cue early.
+func cueText(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	out := lines[:0]
+	for _, l := range lines {
+		if strings.TrimSpace(l) != "" {
+			out = append(out, l)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+// formatCueTime formats d as HH:MM:SS,mmm (SRT) or HH:MM:SS.mmm (WebVTT).
+func formatCueTime(d time.Duration, sep byte) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSubtitles(t *testing.T) {
+	tl := &Timeline{
+		Duration: 3725.5,
+		Events: []TimelineEvent{
+			{Time: 0, LineID: "line:1", Substitutions: []string{"Bo"}, Duration: 1.25},
+			{Time: 1.25, Command: "camera shake 1", Name: "camera", Args: []string{"shake", "1"}},
+			{Time: 2, LineID: "line:2"},
+			{Time: 3600, LineID: "line:3"},
+			{Time: 3725.5, LineID: "line:4"},
+		},
+	}
+	cues := TimelineCues(tl)
+	want := []Cue{
+		{Start: 0, End: 1250 * time.Millisecond, Line: Line{ID: "line:1", Substitutions: []string{"Bo"}}},
+		{Start: 2 * time.Second, End: time.Hour, Line: Line{ID: "line:2"}},
+		{Start: time.Hour, End: 3725500 * time.Millisecond, Line: Line{ID: "line:3"}},
+		{Start: 3725500 * time.Millisecond, End: 3727500 * time.Millisecond, Line: Line{ID: "line:4"}},
+	}
+	if diff := cmp.Diff(cues, want); diff != "" {
+		t.Fatalf("TimelineCues diff (-got +want):\n%s", diff)
+	}
+
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: "Ava: Hello, {0}."},
+		"line:2": {ID: "line:2", Text: "Bo: Fish & <chips>?"},
+		"line:3": {ID: "line:3", Text: "The end.\n\nOr is it?"},
+		"line:4": {ID: "line:4", Text: "Yes."},
+	}}
+
+	var srt strings.Builder
+	if err := WriteSRT(&srt, cues, st); err != nil {
+		t.Fatalf("WriteSRT = %v", err)
+	}
+	wantSRT := `1
+00:00:00,000 --> 00:00:01,250
+Ava: Hello, Bo.
+
+2
+00:00:02,000 --> 01:00:00,000
+Bo: Fish & <chips>?
+
+3
+01:00:00,000 --> 01:02:05,500
+The end.
+Or is it?
+
+4
+01:02:05,500 --> 01:02:07,500
+Yes.
+
+`
+	if diff := cmp.Diff(srt.String(), wantSRT); diff != "" {
+		t.Errorf("WriteSRT diff (-got +want):\n%s", diff)
+	}
+
+	var vtt strings.Builder
+	if err := WriteWebVTT(&vtt, cues[:2], st); err != nil {
+		t.Fatalf("WriteWebVTT = %v", err)
+	}
+	wantVTT := `WEBVTT
+
+1
+00:00:00.000 --> 00:00:01.250
+<v Ava>Hello, Bo.
+
+2
+00:00:02.000 --> 01:00:00.000
+<v Bo>Fish &amp; &lt;chips&gt;?
+
+`
+	if diff := cmp.Diff(vtt.String(), wantVTT); diff != "" {
+		t.Errorf("WriteWebVTT diff (-got +want):\n%s", diff)
+	}
+
+	if err := WriteSRT(&srt, []Cue{{Line: Line{ID: "line:nope"}}}, st); err == nil {
+		t.Errorf("WriteSRT(line:nope) = nil, want error")
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// ExecuteTemplate evaluates the string as a Go text/template with the given
+// data and functions, replacing the string in place. Because Yarn Spinner
+// uses braces for substitutions, template actions should be written with
+// escaped braces in Yarn source (\{\{.PlayerName\}\}); after substitution
+// expansion these become ordinary template actions.
+//
+// Attributes are preserved and adjusted to the new positions. To make this
+// possible, each run of text between attribute positions is executed as a
+// separate template, so an action cannot span the start or end of a markup
+// tag. Missing map keys are an error.
+func (s *AttributedString) ExecuteTemplate(data any, funcs template.FuncMap) error {
+	if !strings.Contains(s.str, "{{") {
+		return nil
+	}
+
+	// Split the string at every attribute position.
+	cuts := make([]int, 0, len(s.atts)+2)
+	cuts = append(cuts, 0)
+	for pos := range s.atts {
+		cuts = append(cuts, pos)
+	}
+	cuts = append(cuts, len(s.str))
+	sort.Ints(cuts)
+
+	var sb strings.Builder
+	newPos := make(map[int]int, len(cuts))
+	for i, start := range cuts {
+		newPos[start] = sb.Len()
+		if i == len(cuts)-1 || start == cuts[i+1] {
+			continue
+		}
+		seg := s.str[start:cuts[i+1]]
+		if !strings.Contains(seg, "{{") {
+			sb.WriteString(seg)
+			continue
+		}
+		tmpl, err := template.New("line").Funcs(funcs).Option("missingkey=error").Parse(seg)
+		if err != nil {
+			return fmt.Errorf("parsing template at byte %d: %w", start, err)
+		}
+		if err := tmpl.Execute(&sb, data); err != nil {
+			return fmt.Errorf("executing template at byte %d: %w", start, err)
+		}
+	}
+
+	// Each attribute appears in atts once or twice; move each one once.
+	atts := make(map[int][]*Attribute, len(s.atts))
+	moved := make(map[*Attribute]bool)
+	for pos, as := range s.atts {
+		for _, a := range as {
+			if !moved[a] {
+				a.Start, a.End = newPos[a.Start], newPos[a.End]
+				moved[a] = true
+			}
+		}
+		atts[newPos[pos]] = as
+	}
+	s.str, s.atts = sb.String(), atts
+	return nil
+}
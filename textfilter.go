@@ -0,0 +1,164 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LineProcessor is a stage in the line post-processing pipeline (see
+// StringTable.PostProcessors). It is passed the line being rendered and the
+// rendered text, and may modify the text in place.
+type LineProcessor func(line Line, s *AttributedString) error
+
+// TextFilter masks or substitutes words, for content rating or regional
+// requirements. Use its Process method as a LineProcessor, e.g.
+//
+//	st.PostProcessors = append(st.PostProcessors, filter.Process)
+//
+// Words are runs of letters, digits, and apostrophes, and are matched case
+// insensitively. To apply different filters for different regions or rating
+// settings, change the StringTable's PostProcessors when the setting changes.
+type TextFilter struct {
+	// Words are masked with Mask.
+	Words []string
+
+	// Replacements substitutes whole words, e.g. {"damn": "darn"}. Keys
+	// should be lower case. If the word begins with an upper case letter, so
+	// does the replacement.
+	Replacements map[string]string
+
+	// Func, if not nil, is called for words not in Words or Replacements. If
+	// it returns true, the word is replaced with the returned string.
+	Func func(word string) (string, bool)
+
+	// Mask replaces each rune of a word in Words. If zero, '*' is used.
+	Mask rune
+
+	once  sync.Once
+	words map[string]bool
+}
+
+// Process applies the filter to s. It never returns an error; the signature
+// matches LineProcessor.
+func (f *TextFilter) Process(_ Line, s *AttributedString) error {
+	f.once.Do(func() {
+		f.words = make(map[string]bool, len(f.Words))
+		for _, w := range f.Words {
+			f.words[strings.ToLower(w)] = true
+		}
+	})
+	mask := f.Mask
+	if mask == 0 {
+		mask = '*'
+	}
+
+	var edits []textEdit
+	start := -1
+	for i, r := range s.str + " " {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '\'' {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start < 0 {
+			continue
+		}
+		word := s.str[start:i]
+		lower := strings.ToLower(word)
+		switch {
+		case f.words[lower]:
+			edits = append(edits, textEdit{start, i, strings.Repeat(string(mask), utf8.RuneCountInString(word))})
+		case f.Replacements[lower] != "":
+			repl := f.Replacements[lower]
+			if first, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(first) {
+				r, n := utf8.DecodeRuneInString(repl)
+				repl = string(unicode.ToUpper(r)) + repl[n:]
+			}
+			edits = append(edits, textEdit{start, i, repl})
+		case f.Func != nil:
+			if repl, ok := f.Func(word); ok {
+				edits = append(edits, textEdit{start, i, repl})
+			}
+		}
+		start = -1
+	}
+	s.applyEdits(edits)
+	return nil
+}
+
+// textEdit replaces the bytes [start, end) of a string with repl.
+type textEdit struct {
+	start, end int
+	repl       string
+}
+
+// applyEdits applies non-overlapping edits to the string, and moves the
+// attributes to match. Positions within an edited span are clamped to the
+// replacement.
+func (s *AttributedString) applyEdits(edits []textEdit) {
+	if len(edits) == 0 {
+		return
+	}
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start < edits[j].start })
+
+	var sb strings.Builder
+	last := 0
+	for _, e := range edits {
+		sb.WriteString(s.str[last:e.start])
+		sb.WriteString(e.repl)
+		last = e.end
+	}
+	sb.WriteString(s.str[last:])
+
+	newPos := func(p int) int {
+		delta := 0
+		for _, e := range edits {
+			switch {
+			case p <= e.start:
+				return p + delta
+			case p < e.end:
+				return e.start + delta + min(p-e.start, len(e.repl))
+			}
+			delta += len(e.repl) - (e.end - e.start)
+		}
+		return p + delta
+	}
+
+	// Each attribute appears in atts once or twice; move each one once.
+	atts := make(map[int][]*Attribute, len(s.atts))
+	moved := make(map[*Attribute]bool)
+	for pos, as := range s.atts {
+		for _, a := range as {
+			if !moved[a] {
+				a.Start, a.End = newPos(a.Start), newPos(a.End)
+				moved[a] = true
+			}
+		}
+		np := newPos(pos)
+		for _, a := range as {
+			if !slices.Contains(atts[np], a) {
+				atts[np] = append(atts[np], a)
+			}
+		}
+	}
+	s.str, s.atts = sb.String(), atts
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// DefaultWaitCommand is the command that advances the timeline when
+// TimelineExporter.WaitCommand is empty. Its single argument is a duration in
+// seconds, e.g. <<wait 1.5>>.
+const DefaultWaitCommand = "wait"
+
+// Timeline is a sequence of commands (and lines) with start times, suitable
+// for baking into an engine-native cutscene timeline.
+type Timeline struct {
+	// Node is the node the timeline was exported from.
+	Node string `json:"node"`
+
+	// Duration is the total time, in seconds.
+	Duration float64 `json:"duration"`
+
+	// Events are in the order they were delivered (and therefore ascending
+	// order of Time).
+	Events []TimelineEvent `json:"events"`
+}
+
+// TimelineEvent is either a command or a line in a Timeline. Wait commands
+// are not included as events; they only advance the time of later events.
+type TimelineEvent struct {
+	// Time is the start time of the event, in seconds.
+	Time float64 `json:"time"`
+
+	// Command, Name, and Args are set for commands. Name and Args are the
+	// whitespace-separated fields of Command.
+	Command string   `json:"command,omitempty"`
+	Name    string   `json:"name,omitempty"`
+	Args    []string `json:"args,omitempty"`
+
+	// LineID, Substitutions, and Text are set for lines. Text is only set if
+	// the exporter has a StringTable.
+	LineID        string   `json:"line_id,omitempty"`
+	Substitutions []string `json:"substitutions,omitempty"`
+	Text          string   `json:"text,omitempty"`
+
+	// Duration is the duration of a line, from TimelineExporter.LineDuration.
+	Duration float64 `json:"duration,omitempty"`
+}
+
+// TimelineExporter executes a node in dry-run mode and records the resulting
+// commands as a Timeline. Variables are read from Vars, but any changes made
+// by the node are discarded.
+type TimelineExporter struct {
+	// Program contains the node to export.
+	Program *yarnpb.Program
+
+	// Vars provides variable values. If nil, an empty storage is used. It is
+	// never modified.
+	Vars VariableStorage
+
+	// FuncMap provides any custom functions the program needs.
+	FuncMap FuncMap
+
+	// StringTable, if not nil, is used to fill in TimelineEvent.Text.
+	StringTable *StringTable
+
+	// WaitCommand is the name of the command that advances time. If empty,
+	// DefaultWaitCommand is used.
+	WaitCommand string
+
+	// LineDuration, if not nil, returns how long each line takes to display,
+	// in seconds. Otherwise lines take no time.
+	LineDuration func(Line) float64
+
+	// Policy chooses options, for nodes that have them. If nil, the first
+	// available option is chosen.
+	Policy ChoicePolicy
+
+	// MaxEvents limits the number of events, to guard against nodes that loop.
+	// If zero, DefaultMaxWalkEvents is used.
+	MaxEvents int
+}
+
+// Export runs the node and returns the timeline.
+func (e *TimelineExporter) Export(node string) (*Timeline, error) {
+	vars := e.Vars
+	if vars == nil {
+		vars = NewMapVariableStorage()
+	}
+	policy := e.Policy
+	if policy == nil {
+		policy = firstPolicy{}
+	}
+	if r, ok := policy.(PolicyResetter); ok {
+		r.Reset()
+	}
+	h := &timelineHandler{
+		e:      e,
+		policy: policy,
+		wait:   e.WaitCommand,
+		max:    e.MaxEvents,
+		tl:     &Timeline{Node: node},
+	}
+	if h.wait == "" {
+		h.wait = DefaultWaitCommand
+	}
+	if h.max <= 0 {
+		h.max = DefaultMaxWalkEvents
+	}
+	fm := make(FuncMap, len(e.FuncMap))
+	fm.merge(e.FuncMap)
+	vm := &VirtualMachine{
+		Program: e.Program,
+		Handler: h,
+		Vars:    newOverlayStorage(vars),
+		FuncMap: fm,
+	}
+	if err := vm.Run(node); err != nil {
+		return nil, err
+	}
+	h.tl.Duration = h.now
+	return h.tl, nil
+}
+
+// firstPolicy chooses the first option.
+type firstPolicy struct{}
+
+func (firstPolicy) Choose([]Option) (int, error) { return 0, nil }
+
+// timelineHandler is the DialogueHandler used by TimelineExporter.
+type timelineHandler struct {
+	FakeDialogueHandler
+
+	e      *TimelineExporter
+	policy ChoicePolicy
+	wait   string
+	max    int
+	events int
+	now    float64
+	tl     *Timeline
+}
+
+func (h *timelineHandler) event() error {
+	h.events++
+	if h.events > h.max {
+		return ErrWalkLimit
+	}
+	return nil
+}
+
+func (h *timelineHandler) Line(line Line) error {
+	if err := h.event(); err != nil {
+		return err
+	}
+	ev := TimelineEvent{Time: h.now, LineID: line.ID, Substitutions: line.Substitutions}
+	if h.e.StringTable != nil {
+		text, err := h.e.StringTable.Render(line)
+		if err != nil {
+			return err
+		}
+		ev.Text = text.String()
+	}
+	if h.e.LineDuration != nil {
+		ev.Duration = h.e.LineDuration(line)
+		h.now += ev.Duration
+	}
+	h.tl.Events = append(h.tl.Events, ev)
+	return nil
+}
+
+func (h *timelineHandler) Command(command string) error {
+	if err := h.event(); err != nil {
+		return err
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil
+	}
+	if fields[0] == h.wait {
+		if len(fields) != 2 {
+			return fmt.Errorf("%s command %q: want exactly 1 argument", h.wait, command)
+		}
+		d, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%s command %q: invalid duration", h.wait, command)
+		}
+		h.now += d
+		return nil
+	}
+	ev := TimelineEvent{Time: h.now, Command: command, Name: fields[0]}
+	if len(fields) > 1 {
+		ev.Args = fields[1:]
+	}
+	h.tl.Events = append(h.tl.Events, ev)
+	return nil
+}
+
+func (h *timelineHandler) Options(options []Option) (int, error) {
+	if err := h.event(); err != nil {
+		return -1, err
+	}
+	avail := availableOptions(options)
+	if len(avail) == 0 {
+		return -1, ErrNoAvailableOptions
+	}
+	i, err := h.policy.Choose(avail)
+	if err != nil {
+		return -1, err
+	}
+	if i < 0 || i >= len(avail) {
+		return -1, fmt.Errorf("policy chose option %d out of bounds [0, %d)", i, len(avail))
+	}
+	return avail[i].ID, nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTimelineExporter(t *testing.T) {
+	pb := NewProgramBuilder("Cutscene")
+	pb.Node("Intro").
+		Command("camera pan castle", 0).
+		Command("wait 1.5", 0).
+		Line("line:1", 0).
+		Command("wait 0.5", 0).
+		Command("shake", 0).
+		PushBool(true).
+		StoreVariable("$seen_intro").
+		Pop().
+		Stop()
+	prog := pb.Program()
+
+	vars := NewMapVariableStorage()
+	e := &TimelineExporter{
+		Program:      prog,
+		Vars:         vars,
+		LineDuration: func(Line) float64 { return 2 },
+	}
+	got, err := e.Export("Intro")
+	if err != nil {
+		t.Fatalf("e.Export(Intro) = %v", err)
+	}
+	want := &Timeline{
+		Node:     "Intro",
+		Duration: 4,
+		Events: []TimelineEvent{
+			{Time: 0, Command: "camera pan castle", Name: "camera", Args: []string{"pan", "castle"}},
+			{Time: 1.5, LineID: "line:1", Duration: 2},
+			{Time: 4, Command: "shake", Name: "shake"},
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("e.Export(Intro) diff (-got +want):\n%s", diff)
+	}
+	if _, ok := vars.GetValue("$seen_intro"); ok {
+		t.Error("vars.GetValue($seen_intro) ok = true after dry run, want false")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CommentTagPrefix marks line tags (in the metadata table) that are notes
+// for translators, e.g. #comment:sarcastic. Since tags can't contain spaces,
+// underscores in comments are exported as spaces.
+const CommentTagPrefix = "comment:"
+
+// TranslatorRow is a line of a string table, with the context translators
+// need.
+type TranslatorRow struct {
+	ID string
+
+	// Character is the speaker of the line, if known (see
+	// Line.Accessible).
+	Character string
+
+	// Text is the source text, including markup and substitutions.
+	Text string
+
+	// Context is the text of the preceding line in the same node, if any.
+	Context string
+
+	File, Node string
+	LineNumber int
+
+	// Tags are the line's tags, other than comments.
+	Tags []string
+
+	// Comments are from tags starting with CommentTagPrefix.
+	Comments []string
+}
+
+// TranslatorRows returns the rows of a string table with context for
+// translators, sorted by file, line number, and ID.
+func TranslatorRows(st *StringTable) []TranslatorRow {
+	rows := make([]*StringTableRow, 0, len(st.Table))
+	for _, row := range st.Table {
+		if row != nil {
+			rows = append(rows, row)
+		}
+	}
+	sort.Slice(rows, func(i, j int) bool { return rowLess(rows[i], rows[j]) })
+
+	out := make([]TranslatorRow, 0, len(rows))
+	var prev *StringTableRow
+	for _, row := range rows {
+		tr := TranslatorRow{
+			ID:         row.ID,
+			Text:       row.Text,
+			File:       row.File,
+			Node:       row.Node,
+			LineNumber: row.LineNumber,
+		}
+		if prev != nil && prev.File == row.File && prev.Node == row.Node {
+			tr.Context = prev.Text
+		}
+		if as, err := row.render(nil, st.Language, st.FormSelectors); err == nil {
+			tr.Character, _ = findSpeaker(as)
+		}
+		for _, tag := range row.Tags {
+			if c, ok := strings.CutPrefix(tag, CommentTagPrefix); ok {
+				tr.Comments = append(tr.Comments, strings.ReplaceAll(c, "_", " "))
+				continue
+			}
+			tr.Tags = append(tr.Tags, tag)
+		}
+		out = append(out, tr)
+		prev = row
+	}
+	return out
+}
+
+// WriteTranslatorCSV writes rows as CSV for translators, with a header row
+// and the columns id, character, text, context, node, file, lineNumber, tags,
+// and comments. Tags are separated by spaces, and comments by newlines.
+//
+// Spreadsheet programs such as Excel only detect UTF-8 if the file starts
+// with a byte order mark, so if bom is true, one is written first.
+func WriteTranslatorCSV(w io.Writer, rows []TranslatorRow, bom bool) error {
+	if bom {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return fmt.Errorf("writing csv: %w", err)
+		}
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "character", "text", "context", "node", "file", "lineNumber", "tags", "comments"})
+	for _, r := range rows {
+		cw.Write([]string{
+			r.ID,
+			r.Character,
+			r.Text,
+			r.Context,
+			r.Node,
+			r.File,
+			strconv.Itoa(r.LineNumber),
+			strings.Join(r.Tags, " "),
+			strings.Join(r.Comments, "\n"),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func translatorTable() *StringTable {
+	return &StringTable{Table: map[string]*StringTableRow{
+		"line:a1": {ID: "line:a1", Text: "Ava: Hello there!", File: "a.yarn", Node: "Start", LineNumber: 3, Tags: []string{"comment:warm_greeting", "lastline"}},
+		"line:a2": {ID: "line:a2", Text: "Bo: You have {0} gold, Ava.", File: "a.yarn", Node: "Start", LineNumber: 4},
+		"line:a3": {ID: "line:a3", Text: "[b]Run![/b]", File: "a.yarn", Node: "Chase", LineNumber: 9},
+	}}
+}
+
+func TestTranslatorRows(t *testing.T) {
+	got := TranslatorRows(translatorTable())
+	want := []TranslatorRow{
+		{
+			ID:         "line:a1",
+			Character:  "Ava",
+			Text:       "Ava: Hello there!",
+			File:       "a.yarn",
+			Node:       "Start",
+			LineNumber: 3,
+			Tags:       []string{"lastline"},
+			Comments:   []string{"warm greeting"},
+		},
+		{
+			ID:         "line:a2",
+			Character:  "Bo",
+			Text:       "Bo: You have {0} gold, Ava.",
+			Context:    "Ava: Hello there!",
+			File:       "a.yarn",
+			Node:       "Start",
+			LineNumber: 4,
+		},
+		{
+			ID:         "line:a3",
+			Text:       "[b]Run![/b]",
+			File:       "a.yarn",
+			Node:       "Chase",
+			LineNumber: 9,
+		},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Fatalf("TranslatorRows diff (-got +want):\n%s", diff)
+	}
+
+	var buf strings.Builder
+	if err := WriteTranslatorCSV(&buf, got, true); err != nil {
+		t.Fatalf("WriteTranslatorCSV = %v", err)
+	}
+	wantCSV := "\ufeffid,character,text,context,node,file,lineNumber,tags,comments\n" +
+		"line:a1,Ava,Ava: Hello there!,,Start,a.yarn,3,lastline,warm greeting\n" +
+		"line:a2,Bo,\"Bo: You have {0} gold, Ava.\",Ava: Hello there!,Start,a.yarn,4,,\n" +
+		"line:a3,,[b]Run![/b],,Chase,a.yarn,9,,\n"
+	if diff := cmp.Diff(buf.String(), wantCSV); diff != "" {
+		t.Errorf("WriteTranslatorCSV diff (-got +want):\n%s", diff)
+	}
+}
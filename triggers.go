@@ -0,0 +1,236 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+)
+
+// Trigger maps a story beat to a named trigger, such as an achievement or a
+// codex unlock. Exactly one of LineID, NodeComplete, or Variable should be
+// set.
+type Trigger struct {
+	// Name is passed to TriggerHandler.Fire. Several triggers may have the
+	// same name (e.g. if either of two lines unlocks an achievement).
+	Name string `json:"name"`
+
+	// LineID fires the trigger when the line is delivered.
+	LineID string `json:"line_id,omitempty"`
+
+	// NodeComplete fires the trigger when the node completes.
+	NodeComplete string `json:"node_complete,omitempty"`
+
+	// Variable fires the trigger when the variable (e.g. "$gold") is at
+	// least AtLeast. Booleans count as 0 or 1.
+	Variable string  `json:"variable,omitempty"`
+	AtLeast  float64 `json:"at_least,omitempty"`
+}
+
+// validate checks that exactly one condition is set.
+func (t *Trigger) validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("trigger has no name")
+	}
+	n := 0
+	for _, s := range []string{t.LineID, t.NodeComplete, t.Variable} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("trigger %q: want exactly one of line_id, node_complete, or variable", t.Name)
+	}
+	return nil
+}
+
+// ReadTriggersJSON reads triggers from a JSON array, e.g.
+//
+//	[
+//		{"name": "ACH_MET_QUEEN", "line_id": "line:queen_intro"},
+//		{"name": "ACH_CHAPTER_1", "node_complete": "Chapter1_End"},
+//		{"name": "ACH_RICH", "variable": "$gold", "at_least": 1000}
+//	]
+func ReadTriggersJSON(r io.Reader) ([]Trigger, error) {
+	var trigs []Trigger
+	if err := json.NewDecoder(r).Decode(&trigs); err != nil {
+		return nil, fmt.Errorf("decoding triggers: %w", err)
+	}
+	for i := range trigs {
+		if err := trigs[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+	return trigs, nil
+}
+
+var (
+	_ DialogueHandler      = &TriggerHandler{}
+	_ CommandResultHandler = &TriggerHandler{}
+	_ SkipHandler          = &TriggerHandler{}
+)
+
+// TriggerHandler is a DialogueHandler that fires named triggers when story
+// beats are reached, so that achievements and unlocks don't require checks
+// scattered through the game's handlers. Each name fires at most once (until
+// Reset). Line and node triggers fire before the event is passed to the
+// embedded handler. Variable triggers are checked before every event, so they
+// fire at the first event after the variable reaches the threshold.
+type TriggerHandler struct {
+	DialogueHandler
+
+	// Triggers are the triggers to fire.
+	Triggers []Trigger
+
+	// Vars is used to check variable triggers. It should be the same storage
+	// the VM uses.
+	Vars VariableStorage
+
+	// Fire is called, from the VM's goroutine, with the name of each trigger
+	// as it fires.
+	Fire func(name string)
+
+	mu    sync.Mutex
+	fired map[string]bool
+}
+
+// Fired returns the names of the triggers that have fired, sorted. This can
+// be saved, and restored with MarkFired.
+func (h *TriggerHandler) Fired() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	names := make([]string, 0, len(h.fired))
+	for name := range h.fired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MarkFired records that the triggers have already fired, so that they won't
+// fire again.
+func (h *TriggerHandler) MarkFired(names ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fired == nil {
+		h.fired = make(map[string]bool)
+	}
+	for _, name := range names {
+		h.fired[name] = true
+	}
+}
+
+// Reset forgets which triggers have fired.
+func (h *TriggerHandler) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fired = nil
+}
+
+// check fires the triggers that match, and any variable triggers whose
+// thresholds have been reached.
+func (h *TriggerHandler) check(match func(*Trigger) bool) {
+	var fire []string
+	h.mu.Lock()
+	for i := range h.Triggers {
+		t := &h.Triggers[i]
+		if h.fired[t.Name] {
+			continue
+		}
+		if !match(t) && !h.reached(t) {
+			continue
+		}
+		if h.fired == nil {
+			h.fired = make(map[string]bool)
+		}
+		h.fired[t.Name] = true
+		fire = append(fire, t.Name)
+	}
+	h.mu.Unlock()
+	if h.Fire == nil {
+		return
+	}
+	for _, name := range fire {
+		h.Fire(name)
+	}
+}
+
+// reached reports whether a variable trigger's threshold has been reached.
+func (h *TriggerHandler) reached(t *Trigger) bool {
+	if t.Variable == "" || h.Vars == nil {
+		return false
+	}
+	v, ok := h.Vars.GetValue(t.Variable)
+	if !ok {
+		return false
+	}
+	f, err := ConvertToFloat64(v)
+	return err == nil && f >= t.AtLeast
+}
+
+func noTrigger(*Trigger) bool { return false }
+
+// NodeStart checks variable triggers, then calls the embedded handler.
+func (h *TriggerHandler) NodeStart(nodeName string) error {
+	h.check(noTrigger)
+	return h.DialogueHandler.NodeStart(nodeName)
+}
+
+// Line fires triggers for the line, then calls the embedded handler.
+func (h *TriggerHandler) Line(line Line) error {
+	h.check(func(t *Trigger) bool { return t.LineID != "" && t.LineID == line.ID })
+	return h.DialogueHandler.Line(line)
+}
+
+// Options checks variable triggers, then calls the embedded handler.
+func (h *TriggerHandler) Options(options []Option) (int, error) {
+	h.check(noTrigger)
+	return h.DialogueHandler.Options(options)
+}
+
+// SkipLine fires triggers for the line, then calls the embedded handler's
+// SkipLine method, if it has one.
+func (h *TriggerHandler) SkipLine(line Line) error {
+	h.check(func(t *Trigger) bool { return t.LineID != "" && t.LineID == line.ID })
+	return forwardSkipLine(h.DialogueHandler, line)
+}
+
+// Command checks variable triggers, then calls the embedded handler.
+func (h *TriggerHandler) Command(command string) error {
+	h.check(noTrigger)
+	return h.DialogueHandler.Command(command)
+}
+
+// CommandResult checks variable triggers, then calls the embedded handler's
+// CommandResult method, if it has one.
+func (h *TriggerHandler) CommandResult(command string) (any, error) {
+	h.check(noTrigger)
+	return forwardCommandResult(h.DialogueHandler, command)
+}
+
+// NodeComplete fires triggers for the node, then calls the embedded handler.
+func (h *TriggerHandler) NodeComplete(nodeName string) error {
+	h.check(func(t *Trigger) bool { return t.NodeComplete != "" && t.NodeComplete == nodeName })
+	return h.DialogueHandler.NodeComplete(nodeName)
+}
+
+// DialogueComplete checks variable triggers, then calls the embedded handler.
+func (h *TriggerHandler) DialogueComplete() error {
+	h.check(noTrigger)
+	return h.DialogueHandler.DialogueComplete()
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTriggerHandler(t *testing.T) {
+	trigs, err := ReadTriggersJSON(strings.NewReader(`[
+		{"name": "ACH_TALK", "line_id": "line:a"},
+		{"name": "ACH_MET_QUEEN", "line_id": "line:queen"},
+		{"name": "ACH_RICH", "variable": "$gold", "at_least": 1000},
+		{"name": "ACH_TALK", "line_id": "line:queen"},
+		{"name": "ACH_CHAPTER", "node_complete": "Start"},
+		{"name": "ACH_SAVED", "line_id": "line:a"}
+	]`))
+	if err != nil {
+		t.Fatalf("ReadTriggersJSON = %v", err)
+	}
+
+	pb := NewProgramBuilder("Triggers")
+	pb.Node("Start").
+		PushFloat(500).
+		StoreVariable("$gold").
+		Line("line:a", 0).
+		PushFloat(1500).
+		StoreVariable("$gold").
+		Line("line:queen", 0).
+		Stop()
+
+	vars := NewMapVariableStorage()
+	var fired []string
+	h := &TriggerHandler{
+		DialogueHandler: FakeDialogueHandler{},
+		Triggers:        trigs,
+		Vars:            vars,
+		Fire:            func(name string) { fired = append(fired, name) },
+	}
+	h.MarkFired("ACH_SAVED")
+	vm := &VirtualMachine{Program: pb.Program(), Handler: h, Vars: vars}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(fired, []string{"ACH_TALK", "ACH_MET_QUEEN", "ACH_RICH", "ACH_CHAPTER"}); diff != "" {
+		t.Errorf("fired diff (-got +want):\n%s", diff)
+	}
+	want := []string{"ACH_CHAPTER", "ACH_MET_QUEEN", "ACH_RICH", "ACH_SAVED", "ACH_TALK"}
+	if diff := cmp.Diff(h.Fired(), want); diff != "" {
+		t.Errorf("h.Fired() diff (-got +want):\n%s", diff)
+	}
+
+	// Nothing fires twice.
+	fired = nil
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) again = %v", err)
+	}
+	if len(fired) != 0 {
+		t.Errorf("fired on second run = %q, want none", fired)
+	}
+
+	for _, bad := range []string{
+		`[{"line_id": "line:a"}]`,
+		`[{"name": "X"}]`,
+		`[{"name": "X", "line_id": "line:a", "variable": "$gold"}]`,
+		`{"name": "X"}`,
+	} {
+		if _, err := ReadTriggersJSON(strings.NewReader(bad)); err == nil {
+			t.Errorf("ReadTriggersJSON(%s) = nil error, want error", bad)
+		}
+	}
+}
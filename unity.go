@@ -0,0 +1,151 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// UnityVariables is the format that Yarn Spinner for Unity uses to save
+// variables (DialogueRunner.SerializeAllVariablesToJSON, and the save files
+// written by DialogueRunner.SaveStateToPersistentStorage): parallel arrays of
+// keys and values for each type, as written by Unity's JsonUtility:
+//
+//	{"floatKeys":["$gold"],"floatValues":[5.0],
+//	 "stringKeys":["$name"],"stringValues":["Ava"],
+//	 "boolKeys":["$met_ava"],"boolValues":[true]}
+//
+// Node visit counts are ordinary variables in both implementations (named
+// "$Yarn.Internal.Visiting.<node>"), so they are included, and a save can
+// move between a Unity client and a Go server running the same story.
+type UnityVariables struct {
+	FloatKeys    []string  `json:"floatKeys"`
+	FloatValues  []float32 `json:"floatValues"`
+	StringKeys   []string  `json:"stringKeys"`
+	StringValues []string  `json:"stringValues"`
+	BoolKeys     []string  `json:"boolKeys"`
+	BoolValues   []bool    `json:"boolValues"`
+}
+
+// NewUnityVariables converts variables into UnityVariables, sorted by name.
+// Numbers of any Go numeric type are converted to float32. Other types are an
+// error.
+func NewUnityVariables(vars map[string]any) (*UnityVariables, error) {
+	u := &UnityVariables{
+		FloatKeys:    []string{},
+		FloatValues:  []float32{},
+		StringKeys:   []string{},
+		StringValues: []string{},
+		BoolKeys:     []string{},
+		BoolValues:   []bool{},
+	}
+	for _, k := range sortedKeys(vars) {
+		switch v := vars[k].(type) {
+		case bool:
+			u.BoolKeys = append(u.BoolKeys, k)
+			u.BoolValues = append(u.BoolValues, v)
+		case string:
+			u.StringKeys = append(u.StringKeys, k)
+			u.StringValues = append(u.StringValues, v)
+		default:
+			f, err := ConvertToFloat32(v)
+			if err != nil {
+				return nil, fmt.Errorf("variable %q: %w", k, err)
+			}
+			u.FloatKeys = append(u.FloatKeys, k)
+			u.FloatValues = append(u.FloatValues, f)
+		}
+	}
+	return u, nil
+}
+
+// Map converts the variables into a map.
+func (u *UnityVariables) Map() (map[string]any, error) {
+	if len(u.FloatKeys) != len(u.FloatValues) || len(u.StringKeys) != len(u.StringValues) || len(u.BoolKeys) != len(u.BoolValues) {
+		return nil, fmt.Errorf("%w: mismatched key and value counts", ErrWrongType)
+	}
+	m := make(map[string]any, len(u.FloatKeys)+len(u.StringKeys)+len(u.BoolKeys))
+	for i, k := range u.FloatKeys {
+		m[k] = u.FloatValues[i]
+	}
+	for i, k := range u.StringKeys {
+		m[k] = u.StringValues[i]
+	}
+	for i, k := range u.BoolKeys {
+		m[k] = u.BoolValues[i]
+	}
+	return m, nil
+}
+
+// VisitCounts returns the visit count of each node that has been visited.
+func (u *UnityVariables) VisitCounts() map[string]int {
+	prefix := visitingVar("")
+	counts := make(map[string]int)
+	for i, k := range u.FloatKeys {
+		if node, ok := strings.CutPrefix(k, prefix); ok && i < len(u.FloatValues) {
+			counts[node] = int(u.FloatValues[i])
+		}
+	}
+	return counts
+}
+
+// WriteUnityJSON writes the contents of the variable storage (which must
+// support copying its contents, like MapVariableStorage) in the Unity format
+// (see UnityVariables).
+func WriteUnityJSON(w io.Writer, vars VariableStorage) error {
+	cs, ok := vars.(contentsStorage)
+	if !ok {
+		return ErrVarsNotSnapshottable
+	}
+	u, err := NewUnityVariables(cs.Contents())
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(w).Encode(u); err != nil {
+		return fmt.Errorf("encoding variables: %w", err)
+	}
+	return nil
+}
+
+// ReadUnityJSON reads variables in the Unity format (see UnityVariables) into
+// the variable storage. If replace is true (as in Unity's
+// VariableStorageBehaviour.SetAllVariables), the storage must support
+// replacing its contents (like MapVariableStorage), and existing variables
+// are removed; otherwise each variable is set in turn.
+func ReadUnityJSON(r io.Reader, vars VariableStorage, replace bool) error {
+	var u UnityVariables
+	if err := json.NewDecoder(r).Decode(&u); err != nil {
+		return fmt.Errorf("decoding variables: %w", err)
+	}
+	m, err := u.Map()
+	if err != nil {
+		return err
+	}
+	if replace {
+		cs, ok := vars.(contentsStorage)
+		if !ok {
+			return ErrVarsNotSnapshottable
+		}
+		cs.ReplaceContents(m)
+		return nil
+	}
+	for _, k := range sortedKeys(m) {
+		vars.SetValue(k, m[k])
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestUnityJSON(t *testing.T) {
+	// As written by Yarn Spinner for Unity.
+	const unity = `{"floatKeys":["$gold","$Yarn.Internal.Visiting.Start"],"floatValues":[5.5,2.0],` +
+		`"stringKeys":["$name"],"stringValues":["Ava"],"boolKeys":["$met_ava"],"boolValues":[true]}`
+
+	vars := NewMapVariableStorage()
+	vars.SetValue("$stale", true)
+	if err := ReadUnityJSON(strings.NewReader(unity), vars, true); err != nil {
+		t.Fatalf("ReadUnityJSON = %v", err)
+	}
+	want := map[string]any{
+		"$gold":                         float32(5.5),
+		"$Yarn.Internal.Visiting.Start": float32(2),
+		"$name":                         "Ava",
+		"$met_ava":                      true,
+	}
+	if diff := cmp.Diff(vars.Contents(), want); diff != "" {
+		t.Errorf("vars diff (-got +want):\n%s", diff)
+	}
+
+	// The VM sees the visit count.
+	vm := &VirtualMachine{Vars: vars}
+	if got := vm.visitedCount("Start"); got != 2 {
+		t.Errorf("visitedCount(Start) = %v, want 2", got)
+	}
+
+	vars.SetValue("$level", 3)
+	var buf bytes.Buffer
+	if err := WriteUnityJSON(&buf, vars); err != nil {
+		t.Fatalf("WriteUnityJSON = %v", err)
+	}
+	wantJSON := `{"floatKeys":["$Yarn.Internal.Visiting.Start","$gold","$level"],"floatValues":[2,5.5,3],` +
+		`"stringKeys":["$name"],"stringValues":["Ava"],"boolKeys":["$met_ava"],"boolValues":[true]}` + "\n"
+	if diff := cmp.Diff(buf.String(), wantJSON); diff != "" {
+		t.Errorf("WriteUnityJSON diff (-got +want):\n%s", diff)
+	}
+
+	var u UnityVariables
+	u.FloatKeys, u.FloatValues = []string{"$Yarn.Internal.Visiting.Shop", "$gold"}, []float32{4, 1}
+	if diff := cmp.Diff(u.VisitCounts(), map[string]int{"Shop": 4}); diff != "" {
+		t.Errorf("u.VisitCounts() diff (-got +want):\n%s", diff)
+	}
+
+	// Without replacing, existing variables are kept.
+	if err := ReadUnityJSON(strings.NewReader(`{"boolKeys":["$new"],"boolValues":[false]}`), vars, false); err != nil {
+		t.Fatalf("ReadUnityJSON(replace = false) = %v", err)
+	}
+	if got, _ := vars.GetValue("$name"); got != "Ava" {
+		t.Errorf("vars.GetValue($name) = %v, want Ava", got)
+	}
+	if err := ReadUnityJSON(strings.NewReader(`{"boolKeys":["$x"],"boolValues":[]}`), vars, false); err == nil {
+		t.Errorf("ReadUnityJSON(mismatched) = nil, want error")
+	}
+	if _, err := NewUnityVariables(map[string]any{"$bad": []int{1}}); err == nil {
+		t.Errorf("NewUnityVariables(slice) = nil error, want error")
+	}
+}
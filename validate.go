@@ -0,0 +1,298 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"sort"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// operandKind is used to describe the operands expected by each opcode.
+type operandKind int
+
+const (
+	opString operandKind = iota
+	opFloat
+	opBool
+)
+
+func (k operandKind) matches(op *yarnpb.Operand) bool {
+	if op == nil {
+		return false
+	}
+	switch op.Value.(type) {
+	case *yarnpb.Operand_StringValue:
+		return k == opString
+	case *yarnpb.Operand_FloatValue:
+		return k == opFloat
+	case *yarnpb.Operand_BoolValue:
+		return k == opBool
+	}
+	return false
+}
+
+func (k operandKind) String() string {
+	switch k {
+	case opString:
+		return "string"
+	case opFloat:
+		return "float"
+	case opBool:
+		return "bool"
+	}
+	return "?"
+}
+
+// operandSpec describes the operands for an opcode: the first required are
+// mandatory, the rest are optional.
+type operandSpec struct {
+	kinds    []operandKind
+	required int
+}
+
+var operandSpecs = []operandSpec{
+	yarnpb.Instruction_JUMP_TO:        {[]operandKind{opString}, 1},
+	yarnpb.Instruction_JUMP:           {nil, 0},
+	yarnpb.Instruction_RUN_LINE:       {[]operandKind{opString, opFloat}, 1},
+	yarnpb.Instruction_RUN_COMMAND:    {[]operandKind{opString, opFloat}, 1},
+	yarnpb.Instruction_ADD_OPTION:     {[]operandKind{opString, opString, opFloat, opBool}, 2},
+	yarnpb.Instruction_SHOW_OPTIONS:   {nil, 0},
+	yarnpb.Instruction_PUSH_STRING:    {[]operandKind{opString}, 1},
+	yarnpb.Instruction_PUSH_FLOAT:     {[]operandKind{opFloat}, 1},
+	yarnpb.Instruction_PUSH_BOOL:      {[]operandKind{opBool}, 1},
+	yarnpb.Instruction_PUSH_NULL:      {nil, 0},
+	yarnpb.Instruction_JUMP_IF_FALSE:  {[]operandKind{opString}, 1},
+	yarnpb.Instruction_POP:            {nil, 0},
+	yarnpb.Instruction_CALL_FUNC:      {[]operandKind{opString}, 1},
+	yarnpb.Instruction_PUSH_VARIABLE:  {[]operandKind{opString}, 1},
+	yarnpb.Instruction_STORE_VARIABLE: {[]operandKind{opString}, 1},
+	yarnpb.Instruction_STOP:           {nil, 0},
+	yarnpb.Instruction_RUN_NODE:       {nil, 0},
+}
+
+// sortedNodeNames returns the names of the nodes in the program, sorted.
+func sortedNodeNames(prog *yarnpb.Program) []string {
+	names := make([]string, 0, len(prog.Nodes))
+	for name := range prog.Nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateProgram checks a program for structural problems that would cause
+// the VM to fail part-way through a conversation: unknown opcodes, missing or
+// mistyped operands, jumps to labels that don't exist, labels that point
+// outside the node, and jumps to constant node names that aren't in the
+// program. The diagnostics are sorted by node name and then by pc.
+func ValidateProgram(prog *yarnpb.Program) []Diagnostic {
+	if prog == nil || len(prog.Nodes) == 0 {
+		return []Diagnostic{{Severity: SeverityError, PC: -1, Message: ErrMissingProgram.Error()}}
+	}
+	var diags []Diagnostic
+	for _, name := range sortedNodeNames(prog) {
+		node := prog.Nodes[name]
+		errorf := func(pc int, format string, args ...any) {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Node:     name,
+				PC:       pc,
+				Message:  fmt.Sprintf(format, args...),
+			})
+		}
+		if node == nil {
+			errorf(-1, "node is nil")
+			continue
+		}
+		if node.Name != name {
+			errorf(-1, "node name %q doesn't match key in program", node.Name)
+		}
+		for label, pc := range node.Labels {
+			if pc < 0 || int(pc) >= len(node.Instructions) {
+				errorf(-1, "label %q points outside the node [%d ∉ [0, %d)]", label, pc, len(node.Instructions))
+			}
+		}
+		checkLabel := func(pc int, label string) {
+			if _, ok := node.Labels[label]; !ok {
+				errorf(pc, "%q %v", label, ErrLabelNotFound)
+			}
+		}
+
+		for pc, inst := range node.Instructions {
+			if inst == nil {
+				errorf(pc, "instruction is nil")
+				continue
+			}
+			if inst.Opcode < 0 || int(inst.Opcode) >= len(operandSpecs) {
+				errorf(pc, "invalid opcode %v", inst.Opcode)
+				continue
+			}
+			spec := operandSpecs[inst.Opcode]
+			if len(inst.Operands) < spec.required || len(inst.Operands) > len(spec.kinds) {
+				errorf(pc, "%v has %d operands, want between %d and %d", inst.Opcode, len(inst.Operands), spec.required, len(spec.kinds))
+				continue
+			}
+			ok := true
+			for i, op := range inst.Operands {
+				if !spec.kinds[i].matches(op) {
+					errorf(pc, "%v operand %d has wrong type, want %v", inst.Opcode, i, spec.kinds[i])
+					ok = false
+				}
+			}
+			if !ok {
+				continue
+			}
+
+			switch inst.Opcode {
+			case yarnpb.Instruction_JUMP_TO, yarnpb.Instruction_JUMP_IF_FALSE:
+				checkLabel(pc, inst.Operands[0].GetStringValue())
+
+			case yarnpb.Instruction_ADD_OPTION:
+				checkLabel(pc, inst.Operands[1].GetStringValue())
+
+			case yarnpb.Instruction_RUN_NODE:
+				// Only jumps to constant node names can be checked.
+				if pc == 0 {
+					break
+				}
+				prev := node.Instructions[pc-1]
+				if prev.GetOpcode() != yarnpb.Instruction_PUSH_STRING || len(prev.Operands) == 0 {
+					break
+				}
+				// Nodes in other programs (see Router) can't be checked
+				// either.
+				dest := prev.Operands[0].GetStringValue()
+				if p, _ := SplitNodeName(dest); p == "" && prog.Nodes[dest] == nil {
+					errorf(pc, "jump to %q: %v", dest, ErrNodeNotFound)
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// lineIDs returns the IDs of lines (including options) used by a node, in
+// order of appearance.
+func lineIDs(node *yarnpb.Node) []string {
+	var ids []string
+	for _, inst := range node.Instructions {
+		if id := lineIDOf(inst); id != "" {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// CheckStringTable checks that every line and option used by the program has
+// a row in the string table, and that each node's source text (if any) is
+// present. It also checks the text of each row parses.
+func CheckStringTable(prog *yarnpb.Program, st *StringTable) []Diagnostic {
+	if st == nil {
+		return []Diagnostic{{Severity: SeverityError, PC: -1, Message: "missing string table"}}
+	}
+	var diags []Diagnostic
+	for _, name := range sortedNodeNames(prog) {
+		node := prog.Nodes[name]
+		if node == nil {
+			continue
+		}
+		for pc, inst := range node.Instructions {
+			switch inst.GetOpcode() {
+			case yarnpb.Instruction_RUN_LINE, yarnpb.Instruction_ADD_OPTION:
+			default:
+				continue
+			}
+			if len(inst.Operands) == 0 {
+				continue
+			}
+			id := inst.Operands[0].GetStringValue()
+			if st.Table[id] == nil {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Node:     name,
+					PC:       pc,
+					LineID:   id,
+					Message:  "line not found in string table",
+				})
+			}
+		}
+		if id := node.SourceTextStringID; id != "" && st.Table[id] == nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Node:     name,
+				PC:       -1,
+				LineID:   id,
+				Message:  "source text not found in string table",
+			})
+		}
+	}
+
+	ids := make([]string, 0, len(st.Table))
+	for id := range st.Table {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		row := st.Table[id]
+		if row == nil {
+			continue
+		}
+		if err := row.parseIfNeeded(); err != nil {
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Node:     row.Node,
+				PC:       -1,
+				LineID:   id,
+				Message:  fmt.Sprintf("text could not be parsed: %v", err),
+			})
+		}
+	}
+	return diags
+}
+
+// UndefinedFunctions returns the sorted names of functions called by the
+// program that are not provided by the VM (the standard library, visited,
+// visited_count, and internal functions) or by lib. lib may be nil.
+func UndefinedFunctions(prog *yarnpb.Program, lib Library) []string {
+	vm := new(VirtualMachine)
+	builtin := vm.defaultFuncMap().merge(vm.internalFuncMap())
+	seen := make(map[string]bool)
+	var names []string
+	for _, node := range prog.Nodes {
+		for _, inst := range node.GetInstructions() {
+			if inst.GetOpcode() != yarnpb.Instruction_CALL_FUNC || len(inst.Operands) == 0 {
+				continue
+			}
+			name := inst.Operands[0].GetStringValue()
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			if _, ok := builtin[name]; ok {
+				continue
+			}
+			if lib != nil {
+				if _, ok := lib.Function(name); ok {
+					continue
+				}
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,103 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateTestdata(t *testing.T) {
+	yarncs, err := filepath.Glob("testdata/*.yarnc")
+	if err != nil {
+		t.Fatalf("Glob: %v", err)
+	}
+	for _, yarnc := range yarncs {
+		t.Run(yarnc, func(t *testing.T) {
+			prog, st, err := LoadFiles(yarnc, "en")
+			if err != nil {
+				t.Fatalf("LoadFiles(%q, en) = error %v", yarnc, err)
+			}
+			if diags := ValidateProgram(prog); len(diags) > 0 {
+				t.Errorf("ValidateProgram(%q) = %v", yarnc, diags)
+			}
+			if diags := CheckStringTable(prog, st); len(diags) > 0 {
+				t.Errorf("CheckStringTable(%q) = %v", yarnc, diags)
+			}
+		})
+	}
+}
+
+func TestValidateProgramErrors(t *testing.T) {
+	prog := &yarnpb.Program{
+		Nodes: map[string]*yarnpb.Node{
+			"Start": {
+				Name:   "Start",
+				Labels: map[string]int32{"L0": 0, "L9": 9},
+				Instructions: []*yarnpb.Instruction{
+					{Opcode: yarnpb.Instruction_JUMP_TO, Operands: []*yarnpb.Operand{
+						{Value: &yarnpb.Operand_StringValue{StringValue: "nope"}},
+					}},
+					{Opcode: yarnpb.Instruction_RUN_LINE},
+					{Opcode: yarnpb.Instruction_PUSH_STRING, Operands: []*yarnpb.Operand{
+						{Value: &yarnpb.Operand_StringValue{StringValue: "Elsewhere"}},
+					}},
+					{Opcode: yarnpb.Instruction_RUN_NODE},
+					{Opcode: 42},
+				},
+			},
+		},
+	}
+	var got []string
+	for _, d := range ValidateProgram(prog) {
+		got = append(got, d.String())
+	}
+	want := []string{
+		"Start: error: label \"L9\" points outside the node [9 ∉ [0, 5)]",
+		"Start:000000: error: \"nope\" label not found",
+		"Start:000001: error: RUN_LINE has 0 operands, want between 1 and 2",
+		"Start:000003: error: jump to \"Elsewhere\": node not found",
+		"Start:000004: error: invalid opcode 42",
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("ValidateProgram diff:\n%s", diff)
+	}
+}
+
+func TestRandomWalk(t *testing.T) {
+	prog, err := LoadProgramFile("testdata/ShortcutOptions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		w := &RandomWalker{
+			Program: prog,
+			Rand:    rand.New(rand.NewSource(int64(i))),
+		}
+		res := w.Walk("Start")
+		if res.Err != nil {
+			t.Errorf("Walk(Start) = %v", res.Err)
+		}
+		for _, c := range res.Choices {
+			if !c.IsAvailable {
+				t.Errorf("Walk(Start) chose unavailable option %v", c)
+			}
+		}
+	}
+}
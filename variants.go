@@ -0,0 +1,151 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"hash/fnv"
+	"sync"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// Node headers for experiment variants. A variant of a node declares the
+// node it replaces, and (optionally) its arm of the experiment:
+//
+//	title: Greeting_Warm
+//	variant_of: Greeting
+//	variant: warm
+//	---
+//	Shopkeeper: Oh, it's you! Come in, come in!
+//	===
+//
+// The experiment is named after the original node, which is the ControlArm.
+// If the variant header is missing, the arm is named after the variant node.
+const (
+	VariantOfHeader = "variant_of"
+	VariantHeader   = "variant"
+
+	// ControlArm is the arm of the original node in each experiment.
+	ControlArm = "control"
+)
+
+// VariantSelector selects which variant of a node plays, for narrative A/B
+// testing. Set VirtualMachine.Variants to use it. Whenever the VM enters a
+// node that has variants, the selector picks an arm of the experiment, the VM
+// plays the node for that arm instead, and the selection is recorded as an
+// AnalyticsVariant event.
+//
+// Arms are chosen by hashing PlayerID with the experiment name, so each
+// player consistently gets the same arm of each experiment (across sessions
+// and devices), and players are spread evenly across arms. It is safe for
+// concurrent use.
+type VariantSelector struct {
+	// PlayerID identifies the player.
+	PlayerID string
+
+	// Overrides, if not nil, forces the arm of some experiments (e.g. for
+	// QA), by experiment name.
+	Overrides map[string]string
+
+	mu      sync.Mutex
+	program *yarnpb.Program
+	index   map[string]map[string]string // experiment -> arm -> node
+}
+
+// Arms returns the arms of the experiment on the node in the program,
+// sorted, or nil if the node has no variants.
+func (s *VariantSelector) Arms(prog *yarnpb.Program, node string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	arms := s.arms(prog, node)
+	if arms == nil {
+		return nil
+	}
+	return sortedKeys(arms)
+}
+
+// Select returns the arm of the experiment for the player.
+func (s *VariantSelector) Select(experiment string, arms []string) string {
+	if arm, ok := s.Overrides[experiment]; ok {
+		return arm
+	}
+	if len(arms) == 0 {
+		return ControlArm
+	}
+	h := fnv.New64a()
+	h.Write([]byte(s.PlayerID))
+	h.Write([]byte{0})
+	h.Write([]byte(experiment))
+	return arms[h.Sum64()%uint64(len(arms))]
+}
+
+// arms returns the arms of the experiment on a node, indexing the program if
+// it is not the last one indexed. s.mu must be held.
+func (s *VariantSelector) arms(prog *yarnpb.Program, node string) map[string]string {
+	if s.program != prog {
+		s.program, s.index = prog, make(map[string]map[string]string)
+		for name, n := range prog.GetNodes() {
+			exp := nodeHeader(n, VariantOfHeader)
+			if exp == "" {
+				continue
+			}
+			arm := nodeHeader(n, VariantHeader)
+			if arm == "" {
+				arm = name
+			}
+			if s.index[exp] == nil {
+				s.index[exp] = map[string]string{ControlArm: exp}
+			}
+			s.index[exp][arm] = name
+		}
+	}
+	return s.index[node]
+}
+
+// selectVariant returns the node to play instead of the node, and records
+// the selection.
+func (vm *VirtualMachine) selectVariant(prog *yarnpb.Program, node *yarnpb.Node) *yarnpb.Node {
+	s := vm.Variants
+	s.mu.Lock()
+	arms := s.arms(prog, node.Name)
+	s.mu.Unlock()
+	if arms == nil {
+		return node
+	}
+	arm := s.Select(node.Name, sortedKeys(arms))
+	variant := prog.Nodes[arms[arm]]
+	if variant == nil {
+		// Overridden with an unknown arm.
+		arm, variant = ControlArm, node
+	}
+	vm.recordAnalytics(AnalyticsEvent{
+		Kind:       AnalyticsVariant,
+		Node:       variant.Name,
+		Experiment: node.Name,
+		Variant:    arm,
+	})
+	return variant
+}
+
+// nodeHeader returns the value of the first header in the node with the key,
+// or "".
+func nodeHeader(node *yarnpb.Node, key string) string {
+	for _, h := range node.GetHeaders() {
+		if h.Key == key {
+			return h.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"testing"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestVariantSelector(t *testing.T) {
+	pb := NewProgramBuilder("Variants")
+	pb.Node("Start").
+		Option("line:buy", "Greeting", 0, false).
+		Option("line:leave", "End", 0, false).
+		ShowOptions().Inst(yarnpb.Instruction_RUN_NODE)
+	pb.Node("Greeting").Line("line:control", 0)
+	pb.Node("Greeting_Warm").Header(VariantOfHeader, "Greeting").Header(VariantHeader, "warm").
+		Line("line:warm", 0)
+	pb.Node("Greeting_Cold").Header(VariantOfHeader, "Greeting").
+		Line("line:cold", 0)
+	pb.Node("End")
+	prog := pb.Program()
+
+	s := &VariantSelector{}
+	if diff := cmp.Diff(s.Arms(prog, "Greeting"), []string{"Greeting_Cold", ControlArm, "warm"}); diff != "" {
+		t.Errorf("s.Arms(Greeting) diff (-got +want):\n%s", diff)
+	}
+	if got := s.Arms(prog, "Start"); got != nil {
+		t.Errorf("s.Arms(Start) = %v, want nil", got)
+	}
+
+	// Players are spread across arms, consistently.
+	counts := make(map[string]int)
+	for i := 0; i < 300; i++ {
+		s := &VariantSelector{PlayerID: fmt.Sprintf("player-%d", i)}
+		arm := s.Select("Greeting", s.Arms(prog, "Greeting"))
+		if again := s.Select("Greeting", s.Arms(prog, "Greeting")); again != arm {
+			t.Errorf("player-%d: Select = %q then %q", i, arm, again)
+		}
+		counts[arm]++
+	}
+	for arm, n := range counts {
+		if n < 70 {
+			t.Errorf("arm %q selected %d/300 times, want about 100", arm, n)
+		}
+	}
+
+	var events []AnalyticsEvent
+	rec := &lineRecorder{}
+	vm := &VirtualMachine{
+		Program:   prog,
+		Handler:   &choosingHandler{lineRecorder: rec},
+		Vars:      NewMapVariableStorage(),
+		Variants:  &VariantSelector{PlayerID: "ava", Overrides: map[string]string{"Greeting": "warm"}},
+		Analytics: func(ev AnalyticsEvent) { events = append(events, ev) },
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.ids, []string{"line:warm"}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	want := []AnalyticsEvent{
+		{Kind: AnalyticsNode, Node: "Start"},
+		{Kind: AnalyticsChoice, Node: "Start", Options: []string{"line:buy", "line:leave"}, Choice: "line:buy"},
+		{Kind: AnalyticsVariant, Node: "Greeting_Warm", Experiment: "Greeting", Variant: "warm"},
+		{Kind: AnalyticsNode, Node: "Greeting_Warm"},
+		{Kind: AnalyticsLine, Node: "Greeting_Warm", LineID: "line:warm"},
+	}
+	if diff := cmp.Diff(events, want, cmpopts.IgnoreFields(AnalyticsEvent{}, "Time")); diff != "" {
+		t.Errorf("analytics diff (-got +want):\n%s", diff)
+	}
+}
+
+// choosingHandler records lines and always chooses the first option.
+type choosingHandler struct {
+	*lineRecorder
+}
+
+func (h *choosingHandler) Options([]Option) (int, error) { return 0, nil }
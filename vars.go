@@ -14,9 +14,19 @@
 
 package yarn
 
-import "sync"
+import (
+	"strings"
+	"sync"
+)
 
 // VariableStorage stores values of any kind.
+//
+// The VM calls GetValue and SetValue from the goroutine running it. If other
+// goroutines use the storage while the VM runs (e.g. game UI code reading
+// $gold every frame), the implementation must be safe for concurrent use.
+// MapVariableStorage and SyncMapVariableStorage are. Storages that wrap
+// another storage (such as quests.Tracker) are safe if the wrapped storage
+// is.
 type VariableStorage interface {
 	GetValue(name string) (value any, ok bool)
 	SetValue(name string, value any)
@@ -24,7 +34,8 @@ type VariableStorage interface {
 
 // MapVariableStorage implements VariableStorage, in memory, using a map.
 // In addition to the core VariableStorage functionality, there are methods for
-// accessing the contents as an ordinary map[string]any.
+// accessing the contents as an ordinary map[string]any. It is safe for
+// concurrent use, guarded by a mutex.
 type MapVariableStorage struct {
 	mu sync.RWMutex
 	m  map[string]any
@@ -106,6 +117,140 @@ func (m *MapVariableStorage) ReplaceContents(src map[string]any) {
 	m.m = m2
 }
 
+// Export returns a copy of the variables whose names start with prefix
+// (e.g. "$quest_"), as a regular map, so that part of the state can be saved
+// or synced separately. See Import.
+func (m *MapVariableStorage) Export(prefix string) map[string]any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return exportPrefix(m.m, prefix)
+}
+
+// Import sets the variables in src (e.g. from Export), leaving other
+// variables unchanged. It does not keep a reference to src.
+func (m *MapVariableStorage) Import(src map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for name, val := range src {
+		m.m[name] = val
+	}
+}
+
+// SyncMapVariableStorage implements VariableStorage, in memory, using a
+// sync.Map. Like MapVariableStorage it is safe for concurrent use, but reads
+// don't take a lock, so it suits storages that are read far more often than
+// written, such as when game threads read variables every frame while the
+// VM runs. It has the same methods as MapVariableStorage. The zero value is
+// an empty storage.
+type SyncMapVariableStorage struct {
+	m sync.Map
+}
+
+// NewSyncMapVariableStorage creates a new empty SyncMapVariableStorage.
+func NewSyncMapVariableStorage() *SyncMapVariableStorage {
+	return new(SyncMapVariableStorage)
+}
+
+// NewSyncMapVariableStorageFromMap creates a new SyncMapVariableStorage with
+// initial contents copied from src. It does not keep a reference to src.
+func NewSyncMapVariableStorageFromMap(src map[string]any) *SyncMapVariableStorage {
+	s := new(SyncMapVariableStorage)
+	for name, val := range src {
+		s.m.Store(name, val)
+	}
+	return s
+}
+
+// Clear empties the storage of all values.
+func (s *SyncMapVariableStorage) Clear() {
+	s.m.Range(func(name, _ any) bool {
+		s.m.Delete(name)
+		return true
+	})
+}
+
+// GetValue fetches a value from the storage, returning (nil, false) if not present.
+func (s *SyncMapVariableStorage) GetValue(name string) (value any, found bool) {
+	return s.m.Load(name)
+}
+
+// SetValue sets a value in the storage.
+func (s *SyncMapVariableStorage) SetValue(name string, value any) {
+	s.m.Store(name, value)
+}
+
+// Delete deletes values from the storage.
+func (s *SyncMapVariableStorage) Delete(names ...string) {
+	for _, name := range names {
+		s.m.Delete(name)
+	}
+}
+
+// Contents returns a copy of the contents of the storage, as a regular map.
+// If values are set concurrently, the copy may include some of them and not
+// others.
+func (s *SyncMapVariableStorage) Contents() map[string]any {
+	m := make(map[string]any)
+	s.m.Range(func(name, val any) bool {
+		m[name.(string)] = val
+		return true
+	})
+	return m
+}
+
+// Clone returns a new SyncMapVariableStorage that is a copy of the receiver.
+func (s *SyncMapVariableStorage) Clone() *SyncMapVariableStorage {
+	return NewSyncMapVariableStorageFromMap(s.Contents())
+}
+
+// ReplaceContents replaces the contents of the storage with values from a
+// regular map. It does not keep a reference to src. Unlike
+// MapVariableStorage, the replacement is not atomic: concurrent readers may
+// observe a mix of old and new values while it happens.
+func (s *SyncMapVariableStorage) ReplaceContents(src map[string]any) {
+	s.m.Range(func(name, _ any) bool {
+		if _, keep := src[name.(string)]; !keep {
+			s.m.Delete(name)
+		}
+		return true
+	})
+	for name, val := range src {
+		s.m.Store(name, val)
+	}
+}
+
+// Export returns a copy of the variables whose names start with prefix
+// (e.g. "$quest_"), as a regular map. See MapVariableStorage.Export.
+func (s *SyncMapVariableStorage) Export(prefix string) map[string]any {
+	m := make(map[string]any)
+	s.m.Range(func(name, val any) bool {
+		if n := name.(string); strings.HasPrefix(n, prefix) {
+			m[n] = val
+		}
+		return true
+	})
+	return m
+}
+
+// Import sets the variables in src (e.g. from Export), leaving other
+// variables unchanged. It does not keep a reference to src.
+func (s *SyncMapVariableStorage) Import(src map[string]any) {
+	for name, val := range src {
+		s.m.Store(name, val)
+	}
+}
+
+// exportPrefix copies the entries of src with keys starting with prefix.
+func exportPrefix(src map[string]any, prefix string) map[string]any {
+	m := make(map[string]any)
+	for name, val := range src {
+		if strings.HasPrefix(name, prefix) {
+			m[name] = val
+		}
+	}
+	return m
+}
+
 func copyMap[K comparable, V any](src map[K]V) map[K]V {
 	m := make(map[K]V, len(src))
 	for name, val := range src {
@@ -113,3 +258,48 @@ func copyMap[K comparable, V any](src map[K]V) map[K]V {
 	}
 	return m
 }
+
+// overlayStorage is a copy-on-write view of another VariableStorage: reads
+// fall through to the base storage unless the variable has been written, and
+// writes are kept in the overlay until committed. It also records which
+// variables were read.
+type overlayStorage struct {
+	base   VariableStorage
+	writes map[string]any
+	reads  map[string]struct{}
+}
+
+func newOverlayStorage(base VariableStorage) *overlayStorage {
+	return &overlayStorage{
+		base:   base,
+		writes: make(map[string]any),
+		reads:  make(map[string]struct{}),
+	}
+}
+
+func (o *overlayStorage) GetValue(name string) (any, bool) {
+	o.reads[name] = struct{}{}
+	if v, ok := o.writes[name]; ok {
+		return v, true
+	}
+	return o.base.GetValue(name)
+}
+
+func (o *overlayStorage) SetValue(name string, value any) {
+	o.writes[name] = value
+}
+
+// commit writes the overlay's writes to the base storage.
+func (o *overlayStorage) commit() {
+	for name, value := range o.writes {
+		o.base.SetValue(name, value)
+	}
+}
+
+// readOnlyStorage is a view of another VariableStorage that discards writes.
+type readOnlyStorage struct {
+	base VariableStorage
+}
+
+func (r readOnlyStorage) GetValue(name string) (any, bool) { return r.base.GetValue(name) }
+func (readOnlyStorage) SetValue(string, any)               {}
@@ -0,0 +1,137 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSyncMapVariableStorage(t *testing.T) {
+	s := NewSyncMapVariableStorageFromMap(map[string]any{"$gold": float32(1), "$name": "Ava"})
+	s.SetValue("$gold", float32(2))
+	s.Delete("$name")
+	if got, ok := s.GetValue("$gold"); !ok || got != float32(2) {
+		t.Errorf("s.GetValue($gold) = %v, %t, want 2, true", got, ok)
+	}
+	if got, ok := s.GetValue("$name"); ok {
+		t.Errorf("s.GetValue($name) = %v, %t, want nil, false", got, ok)
+	}
+
+	clone := s.Clone()
+	s.ReplaceContents(map[string]any{"$hp": float32(10)})
+	if diff := cmp.Diff(s.Contents(), map[string]any{"$hp": float32(10)}); diff != "" {
+		t.Errorf("s.Contents() diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(clone.Contents(), map[string]any{"$gold": float32(2)}); diff != "" {
+		t.Errorf("clone.Contents() diff (-got +want):\n%s", diff)
+	}
+	s.Clear()
+	if got := s.Contents(); len(got) != 0 {
+		t.Errorf("s.Contents() after Clear = %v, want empty", got)
+	}
+}
+
+func TestSyncMapVariableStorageConcurrentReads(t *testing.T) {
+	// The VM writes while the "UI" reads every "frame".
+	s := new(SyncMapVariableStorage)
+	pb := NewProgramBuilder("Gold")
+	n := pb.Node("Start")
+	for i := 1; i <= 100; i++ {
+		n.PushFloat(float32(i)).StoreVariable("$gold").Pop()
+	}
+	vm := &VirtualMachine{
+		Program: pb.Program(),
+		Handler: &FakeDialogueHandler{},
+		Vars:    s,
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		last := float32(0)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			v, ok := s.GetValue("$gold")
+			if !ok {
+				continue
+			}
+			g := v.(float32)
+			if g < last {
+				t.Errorf("$gold went backwards: %v then %v", last, g)
+				return
+			}
+			last = g
+		}
+	}()
+	err := vm.Run("Start")
+	close(done)
+	wg.Wait()
+	if err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if got, _ := s.GetValue("$gold"); got != float32(100) {
+		t.Errorf("$gold = %v, want 100", got)
+	}
+}
+
+func TestVariableStorageExportImport(t *testing.T) {
+	initial := map[string]any{
+		"$quest_ring":   float32(2),
+		"$quest_sword":  true,
+		"$gold":         float32(100),
+		"$questionable": "yes",
+	}
+	for _, tc := range []struct {
+		name string
+		vars interface {
+			VariableStorage
+			Export(string) map[string]any
+			Import(map[string]any)
+			Contents() map[string]any
+		}
+	}{
+		{"MapVariableStorage", NewMapVariableStorageFromMap(initial)},
+		{"SyncMapVariableStorage", NewSyncMapVariableStorageFromMap(initial)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.vars.Export("$quest_")
+			want := map[string]any{"$quest_ring": float32(2), "$quest_sword": true}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("Export($quest_) diff (-got +want):\n%s", diff)
+			}
+
+			tc.vars.Import(map[string]any{"$quest_ring": float32(3), "$quest_key": false})
+			want = map[string]any{
+				"$quest_ring":   float32(3),
+				"$quest_sword":  true,
+				"$quest_key":    false,
+				"$gold":         float32(100),
+				"$questionable": "yes",
+			}
+			if diff := cmp.Diff(tc.vars.Contents(), want); diff != "" {
+				t.Errorf("Contents after Import diff (-got +want):\n%s", diff)
+			}
+		})
+	}
+}
@@ -18,10 +18,13 @@
 package yarn // import "github.com/DrJosh9000/yarn"
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"reflect"
 	"strings"
+	"sync/atomic"
 
 	yarnpb "github.com/DrJosh9000/yarn/bytecode"
 )
@@ -113,9 +116,169 @@ type VirtualMachine struct {
 
 	// TraceLogf, if not nil, is called before each instruction to log the
 	// current stack, options, and the instruction about to be executed.
+	//
+	// Deprecated: Use Logger, which logs the same information as structured
+	// records at LevelTrace.
 	TraceLogf func(string, ...interface{})
 
-	state state
+	// Logger, if not nil, receives structured log records: each instruction
+	// (at LevelTrace), each handler event (at slog.LevelDebug), and errors that
+	// stop the VM (at slog.LevelError). Records include the current node and
+	// pc as attributes.
+	Logger *slog.Logger
+
+	// Debugger, if not nil, can pause execution before each instruction. See
+	// Debugger for details.
+	Debugger *Debugger
+
+	// ReuseEvents reduces garbage collector pressure in dialogue-heavy scenes
+	// (e.g. when skipping through hundreds of lines per second) by reusing
+	// memory between events, instead of allocating it anew for each event.
+	// When it is true, the handler does not own the Substitutions slice of
+	// each Line, or the options slice passed to Options: they are only valid
+	// until the handler method returns. Handlers that keep them for longer
+	// (including AsyncAdapter users that read them after the event) must copy
+	// them, e.g. with Line.Clone or CloneOptions.
+	ReuseEvents bool
+
+	// RecordHistory, if true, records the IDs of the lines delivered to the
+	// handler and the options chosen (see History). It is off by default, so
+	// that nothing about what the player has seen is kept unless the game
+	// opts in. SkipChoices and SkipSeenOnly use the history, so they need it
+	// (or a history restored with SetHistory).
+	RecordHistory bool
+
+	// SkipChoices, if true, makes skip mode (see SetSkipMode) also choose
+	// options automatically, where the player has chosen one of the available
+	// options before. Otherwise options are always delivered to the handler.
+	SkipChoices bool
+
+	// SkipSeenOnly, if true, limits skip mode to lines the player has seen
+	// before (see LineSeen): when an unseen line is reached, skip mode is
+	// turned off and the line is delivered to the handler as usual.
+	SkipSeenOnly bool
+
+	// ErrorReports, if not nil, is called with a diagnostic bundle when Run
+	// or Resume returns an error, e.g. to attach to bug reports. While it is
+	// set, the VM also keeps a transcript of recent handler events to include
+	// in the report.
+	ErrorReports func(*ErrorReport)
+
+	// Asserts controls what happens when an <<assert expr "message">>
+	// command fails. The default is AssertFail.
+	Asserts AssertPolicy
+
+	// HandlerCommands names commands that the VM would otherwise run itself
+	// ("assert", "jump", and "stop"), that are delivered to the handler
+	// instead, e.g. for games that already have commands with those names.
+	// Nodes with parameters can't be jumped to with arguments while "jump"
+	// is one of them.
+	HandlerCommands []string
+
+	// Precompiled, if not nil, provides precompiled conditions for Program
+	// (see Precompile). They are not used while TraceLogf, Debugger, or trace
+	// logging are enabled, so that every instruction can be observed.
+	Precompiled *Precompiled
+
+	// NodeGate, if not nil, is consulted before entering any node, and can
+	// deny entry or redirect to a fallback node (see NodeGate).
+	NodeGate NodeGate
+
+	// Autosave, if not nil, saves automatically at the boundaries it
+	// configures (see Autosave and RestoreAutosave).
+	Autosave *Autosave
+
+	// Limits, if not nil, enforces node cooldown and frequency constraints:
+	// entering a node that is cooling down (with Run, SetNode, or a jump
+	// within the dialogue) fails with an error wrapping ErrNodeCoolingDown.
+	Limits *NodeLimits
+
+	// CommandResultVariable is the variable that receives the results of
+	// commands, if the handler implements CommandResultHandler and the
+	// command doesn't name its own variable. If empty,
+	// DefaultCommandResultVariable is used.
+	CommandResultVariable string
+
+	// Generator, if not nil, builds the content of generated nodes (see
+	// GeneratedTag) each time they are entered.
+	Generator NodeGenerator
+
+	// GeneratedStrings, if not nil, receives the text of lines in generated
+	// nodes (recorded with NodeBuilder.Text), so that they can be rendered.
+	// It is usually the string table used by the handler. It is modified
+	// when a generated node is entered, so if the handler renders lines on
+	// another goroutine, the handler must not be rendering at that time.
+	GeneratedStrings *StringTable
+
+	// Router, if not nil, provides other programs, so that nodes in them can
+	// be entered using namespaced node names ("program:Node"). Entering such
+	// a node replaces Program. Handler events use node names without the
+	// program.
+	Router *Router
+
+	// Programs, if not nil, is consulted when entering a node that isn't in
+	// Program (and isn't namespaced, see Router), to provide the program
+	// containing it. Entering the node replaces Program.
+	Programs ProgramProvider
+
+	// Variants, if not nil, selects which variant of a node to play when a
+	// node with variants is entered (see VariantSelector).
+	Variants *VariantSelector
+
+	// Analytics, if not nil, is called with analytics events: entering
+	// nodes, delivering lines, choosing options, and selecting variants.
+	Analytics func(AnalyticsEvent)
+
+	// Privacy, if not nil, controls what is included in analytics events,
+	// error reports, and logged handler events (see PrivacyPolicy).
+	Privacy *PrivacyPolicy
+
+	// FixedPoint, if positive, makes numbers deterministic across platforms
+	// (e.g. for lockstep multiplayer games), by rounding every number
+	// returned by a function (including operators such as + and /) or
+	// stored in a variable by the dialogue to FixedPoint decimal places (see
+	// Quantize). Small differences in floating-point results between
+	// platforms (e.g. from fused multiply-add, or math library functions)
+	// are then rounded away rather than accumulated. Variables set by the
+	// game are not rounded.
+	FixedPoint int
+
+	// MaxNodeChain is the number of nodes that can be entered in a row
+	// (with Run, SetNode, or jumps within the dialogue) without delivering a
+	// line, options, or a command to the handler. Entering one more fails
+	// with a NodeCycleError reporting the cycle, instead of looping forever.
+	// If zero, DefaultMaxNodeChain is used. If negative, there is no limit.
+	MaxNodeChain int
+
+	// MemoScope controls how long the results of pure functions (see Pure)
+	// are remembered. The default is MemoPerNode.
+	MemoScope MemoScope
+
+	// HandlerErrors controls what happens when a handler method returns an
+	// error, e.g. to continue after cosmetic failures such as a missing
+	// portrait. The default is HandlerErrorFail.
+	HandlerErrors HandlerErrorPolicy
+
+	// ErrorHandler is called with handler errors when HandlerErrors is
+	// HandlerErrorCallback. If it returns nil, the VM continues as though
+	// the handler method succeeded. Otherwise the VM stops, returning the
+	// error (or without error, if it is Stop).
+	ErrorHandler func(*HandlerError) error
+
+	ctx        context.Context // see RunContext
+	ctxDone    <-chan struct{}
+	skip       atomic.Bool
+	transcript errorTranscript
+	history    history
+	bookmarks  []*Bookmark
+	watches    []*watch
+	chain      []string
+
+	state         state
+	internalFuncs FuncMap
+	candidates    *Candidates
+	memo          map[string]memoResult
+	opcodes       map[yarnpb.Instruction_OpCode]OpcodeFunc
 }
 
 // SetNode sets the VM to begin a node. If a node is already selected,
@@ -123,46 +286,104 @@ type VirtualMachine struct {
 // will be called (for the newly selected node). Passing the current node is one
 // way to reset to the start of the node.
 func (vm *VirtualMachine) SetNode(name string) error {
-	if vm.Program == nil {
-		return ErrMissingProgram
+	return vm.setNode(name, nil)
+}
+
+// setNode implements SetNode and SetNodeWithArgs. args is nil if the node
+// was not given arguments.
+func (vm *VirtualMachine) setNode(name string, args []any) error {
+	name, err := vm.gateNode(name)
+	if err != nil {
+		return err
 	}
-	node, found := vm.Program.Nodes[name]
-	if !found {
-		return ErrNodeNotFound
+	prog, node, err := vm.resolveNode(name)
+	if err != nil {
+		return err
+	}
+	if vm.Variants != nil {
+		node = vm.selectVariant(prog, node)
+	}
+	if err := vm.enterChain(name); err != nil {
+		return err
+	}
+	node, err = vm.generateNode(node)
+	if err != nil {
+		return err
+	}
+	locals, err := bindParams(node, args)
+	if err != nil {
+		return err
+	}
+	if vm.Limits != nil {
+		if err := vm.Limits.Enter(name); err != nil {
+			return err
+		}
 	}
 
 	// Designate the current node complete.
 	if vm.state.node != nil {
-		if err := vm.Handler.NodeComplete(vm.state.node.Name); err != nil {
-			return fmt.Errorf("handler.NodeComplete: %w", err)
+		vm.logEvent("NodeComplete")
+		if err := vm.handlerError("NodeComplete", vm.Handler.NodeComplete(vm.state.node.Name)); err != nil {
+			return err
 		}
 	}
 
+	if vm.MemoScope == MemoPerNode {
+		vm.ClearMemo()
+	}
+
 	// Reset the state and start at this node.
+	if vm.state.bufs != nil {
+		vm.state.bufs.clear()
+	}
+	vm.Program = prog
 	vm.state = state{
-		node: node,
+		node:     node,
+		bufs:     vm.state.bufs,
+		locals:   locals,
+		declared: declaredLocals(node),
 	}
 
-	if err := vm.Handler.NodeStart(name); err != nil {
-		return fmt.Errorf("handler.NodeStart: %w", err)
+	vm.logEvent("NodeStart")
+	vm.recordAnalytics(AnalyticsEvent{Kind: AnalyticsNode, Node: node.Name})
+	if err := vm.handlerError("NodeStart", vm.Handler.NodeStart(node.Name)); err != nil {
+		return err
 	}
 
 	// Find all lines in the node and pass them to PrepareForLines.
-	var ids []string
-	for _, inst := range node.Instructions {
-		switch inst.Opcode {
-		case yarnpb.Instruction_RUN_LINE, yarnpb.Instruction_ADD_OPTION:
-			ids = append(ids, inst.Operands[0].GetStringValue())
-		}
+	ids := lineIDs(node)
+	vm.logEvent("PrepareForLines", slog.Any("line_ids", ids))
+	if err := vm.handlerError("PrepareForLines", vm.Handler.PrepareForLines(ids)); err != nil {
+		return err
 	}
-	if err := vm.Handler.PrepareForLines(ids); err != nil {
-		return fmt.Errorf("handler.PrepareForLines: %w", err)
+	if vm.Autosave != nil && vm.Autosave.NodeStart {
+		vm.autosave()
 	}
 	return nil
 }
 
 // Run executes the program, starting at a particular node.
 func (vm *VirtualMachine) Run(startNode string) error {
+	return vm.run(func() error { return vm.SetNode(startNode) })
+}
+
+// run executes the program, after calling start to choose where to begin.
+// Errors are logged and reported (see ErrorReports) before it returns.
+func (vm *VirtualMachine) run(start func() error) (err error) {
+	var bufs *eventBuffers
+	defer func() {
+		if err != nil {
+			// Report before releasing the event buffers below, which hold
+			// the pending options (see ReuseEvents).
+			vm.logError(err)
+			vm.reportError(err)
+		}
+		if bufs != nil {
+			vm.state.bufs, vm.state.options = nil, nil
+			bufs.clear()
+			eventBufferPool.Put(bufs)
+		}
+	}()
 	if vm.Handler == nil {
 		return ErrNilDialogueHandler
 	}
@@ -171,18 +392,55 @@ func (vm *VirtualMachine) Run(startNode string) error {
 	}
 	// Provide default funcs, merge provided funcmap to allow overrides.
 	vm.FuncMap = vm.defaultFuncMap().merge(vm.FuncMap)
+	vm.internalFuncs = vm.internalFuncMap()
+	vm.ClearMemo()
+	vm.resetChain()
+	if vm.ReuseEvents && vm.state.bufs == nil {
+		bufs = eventBufferPool.Get().(*eventBuffers)
+		vm.state.bufs = bufs
+	}
+	if err := vm.checkContext(); err != nil {
+		return err
+	}
 	// Set start node
-	if err := vm.SetNode(startNode); err != nil {
+	if err := start(); err != nil {
 		return err
 	}
 	// Run! This is the instruction loop.
+	usePrecompiled := vm.Precompiled != nil && vm.TraceLogf == nil && vm.Debugger == nil && !vm.logEnabled(LevelTrace)
+	var condsNode *yarnpb.Node
+	var conds []*compiledCond
 instructionLoop:
 	for vm.state.pc < len(vm.state.node.Instructions) {
+		if err := vm.checkContext(); err != nil {
+			return err
+		}
+		if usePrecompiled {
+			if condsNode != vm.state.node {
+				condsNode, conds = vm.state.node, vm.Precompiled.nodes[vm.state.node]
+			}
+			pc := vm.state.pc
+			switch ok, err := vm.execPrecompiled(conds); {
+			case err != nil:
+				return fmt.Errorf("%s %06d %s: %w", vm.state.node.Name, pc, FormatInstruction(vm.state.node.Instructions[pc]), err)
+			case ok:
+				continue
+			}
+		}
 		inst := vm.state.node.Instructions[vm.state.pc]
 		if vm.TraceLogf != nil {
 			vm.TraceLogf("stack %v; options %v", vm.state.stack, vm.state.options)
 			vm.TraceLogf("% 15s %06d %s", vm.state.node.Name, vm.state.pc, FormatInstruction(inst))
 		}
+		vm.logInstruction(inst)
+		if vm.Debugger != nil {
+			switch err := vm.Debugger.before(vm); {
+			case errors.Is(err, Stop):
+				break instructionLoop
+			case err != nil:
+				return fmt.Errorf("debugger: %w", err)
+			}
+		}
 		switch err := vm.execute(inst); {
 		case errors.Is(err, Stop): // machine has stopped
 			break instructionLoop
@@ -190,11 +448,13 @@ instructionLoop:
 			return fmt.Errorf("%s %06d %s: %w", vm.state.node.Name, vm.state.pc, FormatInstruction(inst), err)
 		}
 	}
-	if err := vm.Handler.NodeComplete(vm.state.node.Name); err != nil && !errors.Is(err, Stop) {
-		return fmt.Errorf("handler.NodeComplete: %w", err)
+	vm.logEvent("NodeComplete")
+	if err := vm.handlerError("NodeComplete", vm.Handler.NodeComplete(vm.state.node.Name)); err != nil && !errors.Is(err, Stop) {
+		return err
 	}
-	if err := vm.Handler.DialogueComplete(); err != nil && !errors.Is(err, Stop) {
-		return fmt.Errorf("handler.DialogueComplete: %w", err)
+	vm.logEvent("DialogueComplete")
+	if err := vm.handlerError("DialogueComplete", vm.Handler.DialogueComplete()); err != nil && !errors.Is(err, Stop) {
+		return err
 	}
 	return nil
 }
@@ -203,27 +463,19 @@ instructionLoop:
 func (vm *VirtualMachine) defaultFuncMap() FuncMap {
 	result := defaultFuncMap()
 	result.merge(map[string]interface{}{
-		"visited": func(nodeName string) bool {
-			_, ok := vm.Vars.GetValue(fmt.Sprintf("$Yarn.Internal.Visiting.%s", nodeName))
-			return ok
-		},
-		"visited_count": func(nodeName string) int {
-			if count, ok := vm.Vars.GetValue(fmt.Sprintf("$Yarn.Internal.Visiting.%s", nodeName)); ok {
-				return int(count.(float32))
-			}
-			return 0
-		},
+		"visited":       vm.visited,
+		"visited_count": vm.visitedCount,
 	})
 	return result
 }
 
 func (vm *VirtualMachine) execute(inst *yarnpb.Instruction) error {
 	if inst.Opcode < 0 || int(inst.Opcode) >= len(dispatchTable) {
-		return fmt.Errorf("invalid opcode %v", inst.Opcode)
+		return vm.execCustom(inst)
 	}
 	exec := dispatchTable[inst.Opcode]
 	if exec == nil {
-		return fmt.Errorf("invalid opcode %v", inst.Opcode)
+		return vm.execCustom(inst)
 	}
 	return exec(vm, inst.Operands)
 }
@@ -295,10 +547,17 @@ func (vm *VirtualMachine) execRunLine(operands []*yarnpb.Operand) error {
 		}
 		line.Substitutions = ss
 	}
-	if err := vm.Handler.Line(line); err != nil {
-		return fmt.Errorf("handler.Line: %w", err)
-	}
+	// So that a Snapshot taken during the Line event resumes after the line
+	// (the substitutions have already been popped), increment PC first.
 	vm.state.pc++
+	vm.resetChain()
+	if vm.Autosave != nil && vm.Autosave.countLine() {
+		vm.autosave()
+	}
+	if err := vm.handlerError("Line", vm.deliverLine(line)); err != nil {
+		return err
+	}
+	vm.state.releaseStrings()
 	return nil
 }
 
@@ -320,13 +579,21 @@ func (vm *VirtualMachine) execRunCommand(operands []*yarnpb.Operand) error {
 		for i, s := range ss {
 			cmd = strings.ReplaceAll(cmd, fmt.Sprintf("{%d}", i), s)
 		}
+		vm.state.releaseStrings()
 	}
 	// To allow the command to overwrite PC, increment it first
 	vm.state.pc++
-	if err := vm.Handler.Command(cmd); err != nil {
-		return fmt.Errorf("handler.Command: %w", err)
+	// Some commands are really built-in statements, and shouldn't be
+	// delivered to the handler.
+	if handled, err := vm.execInternalCommand(cmd); handled {
+		return err
 	}
-	return nil
+	vm.resetChain()
+	if crh, ok := vm.Handler.(CommandResultHandler); ok {
+		return vm.runCommandWithResult(crh, cmd)
+	}
+	vm.logEvent("Command", slog.String("command", cmd))
+	return vm.handlerError("Command", vm.Handler.Command(cmd))
 }
 
 func (vm *VirtualMachine) execAddOption(operands []*yarnpb.Operand) error {
@@ -361,7 +628,7 @@ func (vm *VirtualMachine) execAddOption(operands []*yarnpb.Operand) error {
 		}
 		avail = cp
 	}
-	vm.state.options = append(vm.state.options, Option{
+	vm.state.addOption(Option{
 		ID:              len(vm.state.options),
 		Line:            line,
 		DestinationNode: operands[1].GetStringValue(),
@@ -378,18 +645,35 @@ func (vm *VirtualMachine) execShowOptions([]*yarnpb.Operand) error {
 	// No operands.
 	if len(vm.state.options) == 0 {
 		// NOTE: jon implements this as a machine stop instead of an exception
+		vm.logEvent("DialogueComplete")
 		vm.Handler.DialogueComplete()
 		return ErrNoOptions
 	}
-	index, err := vm.Handler.Options(vm.state.options)
-	if err != nil {
-		return fmt.Errorf("handler.Options: %w", err)
+	vm.resetChain()
+	index := vm.autoChoose(vm.state.options)
+	if index >= 0 {
+		vm.logEvent("AutoChoose", slog.Int("count", len(vm.state.options)), slog.Int("index", index))
+	} else {
+		if vm.Autosave != nil && vm.Autosave.Options {
+			vm.autosave()
+		}
+		for _, o := range vm.state.options {
+			vm.markSeen(o.Line.ID)
+		}
+		vm.logEvent("Options", slog.Int("count", len(vm.state.options)))
+		i, err := vm.Handler.Options(vm.state.options)
+		if err := vm.handlerError("Options", err); err != nil {
+			return err
+		}
+		index = i
 	}
 	if optslen := len(vm.state.options); index < 0 || index >= optslen {
 		return fmt.Errorf("selected option %d out of bounds [0, %d)", index, optslen)
 	}
+	vm.recordChoice(vm.state.options, index)
+	vm.markChosen(vm.state.options[index].Line.ID)
 	vm.state.push(vm.state.options[index].DestinationNode)
-	vm.state.options = nil
+	vm.state.releaseOptions()
 	vm.state.pc++
 	return nil
 }
@@ -471,12 +755,15 @@ func (vm *VirtualMachine) execCallFunc(operands []*yarnpb.Operand) error {
 	// TODO: typecheck FuncMap during preprocessing
 	// TODO: a lot of this is very forgiving...
 	funcname := operands[0].GetStringValue()
-	function, found := vm.FuncMap[funcname]
+	function, found := vm.lookupFunc(funcname)
 	if !found {
 		return fmt.Errorf("%q %w", funcname, ErrFunctionNotFound)
 	}
-	functype := reflect.TypeOf(function)
-	if functype.Kind() != reflect.Func {
+	pure, isPure := function.(PureFunc)
+	if isPure {
+		function = pure.Func
+	}
+	if reflect.TypeOf(function).Kind() != reflect.Func {
 		return fmt.Errorf("%w: function for %q not actually a function [type %T]", ErrWrongType, funcname, function)
 	}
 	// Compiler puts number of args on top of stack
@@ -488,6 +775,50 @@ func (vm *VirtualMachine) execCallFunc(operands []*yarnpb.Operand) error {
 	if err != nil {
 		return fmt.Errorf("convertToInt: %w", err)
 	}
+	if err := checkFuncSignature(function, gotArgc); err != nil {
+		return err
+	}
+	if gotArgc > len(vm.state.stack) {
+		return fmt.Errorf("pop: %w", ErrStackUnderflow)
+	}
+	args := vm.state.stack[len(vm.state.stack)-gotArgc:]
+	var key string
+	if isPure {
+		key = memoKey(funcname, args)
+		if r, hit := vm.memo[key]; hit {
+			vm.state.stack = vm.state.stack[:len(vm.state.stack)-gotArgc]
+			vm.state.pc++
+			if r.ok {
+				vm.state.push(r.value)
+			}
+			return nil
+		}
+	}
+	params, err := convertArgs(funcname, function, args)
+	if err != nil {
+		return err
+	}
+	vm.state.stack = vm.state.stack[:len(vm.state.stack)-gotArgc]
+
+	// Because the func could overwrite PC, increment first
+	vm.state.pc++
+
+	result, ok, err := invokeFunc(function, params)
+	if err != nil {
+		return err
+	}
+	result = vm.quantize(result)
+	if isPure {
+		vm.remember(key, result, ok)
+	}
+	if ok {
+		vm.state.push(result)
+	}
+	return nil
+}
+
+func checkFuncSignature(function any, gotArgc int) error {
+	functype := reflect.TypeOf(function)
 	// Check that we have enough args to call the func
 	switch wantArgc := functype.NumIn(); {
 	case functype.IsVariadic() && gotArgc < wantArgc-1:
@@ -511,15 +842,16 @@ func (vm *VirtualMachine) execCallFunc(operands []*yarnpb.Operand) error {
 	default:
 		return fmt.Errorf("%w: unsupported number of return args [got %d, want in {0,1,2}]", ErrFunctionArgMismatch, functype.NumOut())
 	}
+	return nil
+}
 
-	arg := gotArgc
-	params := make([]reflect.Value, arg)
-	for arg > 0 {
-		arg--
-		param, err := vm.state.pop()
-		if err != nil {
-			return fmt.Errorf("pop: %w", err)
-		}
+// convertArgs converts the args into values that can be passed to the
+// function. The signature must already have been checked with
+// checkFuncSignature.
+func convertArgs(funcname string, function any, args []any) ([]reflect.Value, error) {
+	functype := reflect.TypeOf(function)
+	params := make([]reflect.Value, len(args))
+	for arg, param := range args {
 		var argtype reflect.Type
 		if functype.IsVariadic() && arg >= functype.NumIn()-1 {
 			// last arg is reported by reflect as a slice type
@@ -545,79 +877,88 @@ func (vm *VirtualMachine) execCallFunc(operands []*yarnpb.Operand) error {
 			case float32Type:
 				p, err := ConvertToFloat32(param)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				param = p
 			case float64Type:
 				p, err := ConvertToFloat64(param)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				param = p
 			case intType:
 				p, err := ConvertToInt(param)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				param = p
 			case boolType:
 				p, err := ConvertToBool(param)
 				if err != nil {
-					return err
+					return nil, err
 				}
 				param = p
 			default:
-				return fmt.Errorf("%w: value %v [type %T] not assignable or convertible to argument %d of %q [type %v]", ErrFunctionArgMismatch, param, param, arg, funcname, argtype)
+				return nil, fmt.Errorf("%w: value %v [type %T] not assignable or convertible to argument %d of %q [type %v]", ErrFunctionArgMismatch, param, param, arg, funcname, argtype)
 			}
 		}
 		params[arg] = reflect.ValueOf(param)
 	}
+	return params, nil
+}
 
-	// Because the func could overwrite PC, increment first
-	vm.state.pc++
-
+// invokeFunc calls the function with params (from convertArgs), and returns
+// the result (and whether there is one).
+func invokeFunc(function any, params []reflect.Value) (any, bool, error) {
+	functype := reflect.TypeOf(function)
 	result := reflect.ValueOf(function).Call(params)
 
 	// Error?
 	if last := functype.NumOut() - 1; last >= 0 && functype.Out(last) == errorType && !result[last].IsNil() {
-		return result[last].Interface().(error)
+		return nil, false, result[last].Interface().(error)
 	}
 
 	// A return value?
 	if len(result) > 0 && functype.Out(0) != errorType {
-		vm.state.push(result[0].Interface())
+		return result[0].Interface(), true, nil
 	}
-	return nil
+	return nil, false, nil
 }
 
 func (vm *VirtualMachine) execPushVariable(operands []*yarnpb.Operand) error {
 	// Pushes the contents of a variable onto the stack.
 	// opA = name of variable
-	k := operands[0].GetStringValue()
-	v, ok := vm.Vars.GetValue(k)
-	if ok {
-		vm.state.push(v)
-		vm.state.pc++
-		return nil
+	vm.state.push(vm.variableValue(operands[0].GetStringValue()))
+	vm.state.pc++
+	return nil
+}
+
+// variableValue returns the value of a variable from the node's locals, or
+// Vars (unless the variable is local), or the program's initial value, or nil.
+func (vm *VirtualMachine) variableValue(k string) any {
+	if v, ok := vm.state.locals[k]; ok {
+		return v
+	}
+	if !vm.isLocal(k) {
+		if v, ok := vm.Vars.GetValue(k); ok {
+			return v
+		}
 	}
 	// Is it provided as an initial value?
 	w, ok := vm.Program.InitialValues[k]
 	if !ok {
 		// Neither a known nor initial value.
 		// Yarn Spinner pushes null.
-		vm.state.push(nil)
-		vm.state.pc++
 		return nil
 	}
 	switch x := w.Value.(type) {
 	case *yarnpb.Operand_BoolValue:
-		vm.state.push(x.BoolValue)
+		return x.BoolValue
 	case *yarnpb.Operand_FloatValue:
-		vm.state.push(x.FloatValue)
+		return x.FloatValue
 	case *yarnpb.Operand_StringValue:
-		vm.state.push(x.StringValue)
+		return x.StringValue
 	}
-	vm.state.pc++
 	return nil
 }
 
@@ -630,8 +971,9 @@ func (vm *VirtualMachine) execStoreVariable(operands []*yarnpb.Operand) error {
 	if err != nil {
 		return fmt.Errorf("peek: %w", err)
 	}
-	vm.Vars.SetValue(k, v)
+	vm.setVariable(k, v)
 	vm.state.pc++
+	vm.checkWatches()
 	return nil
 }
 
@@ -656,10 +998,17 @@ func (vm *VirtualMachine) execRunNode([]*yarnpb.Operand) error {
 }
 
 type state struct {
-	node    *yarnpb.Node // current node
-	pc      int          // program counter
-	stack   []interface{}
-	options []Option
+	node     *yarnpb.Node // current node
+	pc       int          // program counter
+	stack    []interface{}
+	options  []Option
+	bufs     *eventBuffers       // nil unless ReuseEvents is set
+	locals   map[string]any      // node parameters and local variables
+	declared map[string]struct{} // variables declared by the locals header
+
+	// resultVariable receives the result of the command being delivered to
+	// CommandResult.
+	resultVariable string
 }
 
 // push pushes a value onto the state's stack.
@@ -713,7 +1062,7 @@ func (s *state) popNStrings(n int) ([]string, error) {
 		return nil, fmt.Errorf("%w [%d > %d]", ErrStackUnderflow, n, len(s.stack))
 	}
 	rem := len(s.stack) - n
-	ss := make([]string, n)
+	ss := s.allocStrings(n)
 	for i, x := range s.stack[rem:] {
 		ss[i] = ConvertToString(x)
 	}
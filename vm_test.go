@@ -15,6 +15,7 @@
 package yarn
 
 import (
+	"encoding/json"
 	"errors"
 	"path/filepath"
 	"strings"
@@ -96,3 +97,180 @@ func TestAllTestPlans(t *testing.T) {
 		})
 	}
 }
+
+// optionsRecorder records the address of the first option each time Options
+// is called.
+type optionsRecorder struct {
+	*TestPlan
+	firsts []*Option
+}
+
+func (r *optionsRecorder) Options(opts []Option) (int, error) {
+	r.firsts = append(r.firsts, &opts[0])
+	return r.TestPlan.Options(opts)
+}
+
+func TestReuseEvents(t *testing.T) {
+	for _, base := range []string{"ShortcutOptions", "InlineExpressions", "FormatFunctions"} {
+		t.Run(base, func(t *testing.T) {
+			testplan, err := LoadTestPlanFile("testdata/" + base + ".testplan")
+			if err != nil {
+				t.Fatalf("LoadTestPlanFile = %v", err)
+			}
+			prog, st, err := LoadFiles("testdata/"+base+".yarnc", "en")
+			if err != nil {
+				t.Fatalf("LoadFiles = %v", err)
+			}
+			testplan.StringTable = st
+			rec := &optionsRecorder{TestPlan: testplan}
+			vm := &VirtualMachine{
+				Program:     prog,
+				Handler:     rec,
+				Vars:        NewMapVariableStorage(),
+				ReuseEvents: true,
+			}
+			if err := vm.Run("Start"); err != nil {
+				t.Errorf("vm.Run(Start) = %v", err)
+			}
+			if err := testplan.Complete(); err != nil {
+				t.Errorf("testplan incomplete: %v", err)
+			}
+			for i := 1; i < len(rec.firsts); i++ {
+				if rec.firsts[i] != rec.firsts[0] {
+					t.Errorf("options slice %d not reused", i)
+				}
+			}
+		})
+	}
+}
+
+// skipCounter counts the events delivered in skip mode.
+type skipCounter struct {
+	FakeDialogueHandler
+	lines, skipped, options int
+}
+
+func (c *skipCounter) Line(Line) error     { c.lines++; return nil }
+func (c *skipCounter) SkipLine(Line) error { c.skipped++; return nil }
+func (c *skipCounter) Options([]Option) (int, error) {
+	c.options++
+	return 0, nil
+}
+
+func TestSkipMode(t *testing.T) {
+	testplan, err := LoadTestPlanFile("testdata/ShortcutOptions.testplan")
+	if err != nil {
+		t.Fatalf("LoadTestPlanFile = %v", err)
+	}
+	prog, st, err := LoadFiles("testdata/ShortcutOptions.yarnc", "en")
+	if err != nil {
+		t.Fatalf("LoadFiles = %v", err)
+	}
+	testplan.StringTable = st
+	vm := &VirtualMachine{
+		Program:       prog,
+		Handler:       testplan,
+		Vars:          NewMapVariableStorage(),
+		RecordHistory: true,
+		SkipChoices:   true,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+
+	// Replaying with skip mode on should skip every line and repeat every
+	// choice.
+	c := new(skipCounter)
+	vm.Handler = c
+	vm.Vars = NewMapVariableStorage()
+	vm.SetSkipMode(true)
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) in skip mode = %v", err)
+	}
+	if c.lines != 0 || c.options != 0 || c.skipped != 11 {
+		t.Errorf("(lines, options, skipped) = (%d, %d, %d), want (0, 0, 11)", c.lines, c.options, c.skipped)
+	}
+
+	// Without skip mode, everything is delivered.
+	c = new(skipCounter)
+	vm.Handler = c
+	vm.Vars = NewMapVariableStorage()
+	vm.SetSkipMode(false)
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if c.lines != 11 || c.options == 0 || c.skipped != 0 {
+		t.Errorf("(lines, options, skipped) = (%d, %d, %d), want (11, >0, 0)", c.lines, c.options, c.skipped)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	testplan, err := LoadTestPlanFile("testdata/ShortcutOptions.testplan")
+	if err != nil {
+		t.Fatalf("LoadTestPlanFile = %v", err)
+	}
+	prog, st, err := LoadFiles("testdata/ShortcutOptions.yarnc", "en")
+	if err != nil {
+		t.Fatalf("LoadFiles = %v", err)
+	}
+	testplan.StringTable = st
+	vm := &VirtualMachine{
+		Program:       prog,
+		Handler:       testplan,
+		Vars:          NewMapVariableStorage(),
+		RecordHistory: true,
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	h := vm.History()
+	if len(h.SeenLines) == 0 || len(h.ChosenOptions) == 0 {
+		t.Fatalf("vm.History() = %+v, want some seen lines and chosen options", h)
+	}
+	for _, id := range h.SeenLines {
+		if !vm.LineSeen(id) {
+			t.Errorf("vm.LineSeen(%q) = false, want true", id)
+		}
+	}
+	if seen, total := h.Progress(prog); seen != len(h.SeenLines) || total <= seen {
+		t.Errorf("h.Progress(prog) = (%d, %d), want (%d, > %d)", seen, total, len(h.SeenLines), seen)
+	}
+
+	// Save and restore into a new VM.
+	saved, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("json.Marshal(history) = %v", err)
+	}
+	var restored History
+	if err := json.Unmarshal(saved, &restored); err != nil {
+		t.Fatalf("json.Unmarshal(history) = %v", err)
+	}
+	c := new(skipCounter)
+	vm = &VirtualMachine{
+		Program:      prog,
+		Handler:      c,
+		Vars:         NewMapVariableStorage(),
+		SkipChoices:  true,
+		SkipSeenOnly: true,
+	}
+	vm.SetHistory(restored)
+	vm.SetSkipMode(true)
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) in skip mode = %v", err)
+	}
+	if c.lines != 0 || c.options != 0 || c.skipped != 11 {
+		t.Errorf("restored (lines, options, skipped) = (%d, %d, %d), want (0, 0, 11)", c.lines, c.options, c.skipped)
+	}
+
+	// With no history, skip mode stops at the first unseen line.
+	c = new(skipCounter)
+	vm.Handler = c
+	vm.SetHistory(History{})
+	vm.SetSkipMode(true)
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if vm.SkipMode() || c.skipped != 0 {
+		t.Errorf("SkipMode, skipped = %t, %d, want false, 0", vm.SkipMode(), c.skipped)
+	}
+}
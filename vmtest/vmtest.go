@@ -0,0 +1,260 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package vmtest provides helpers for testing code that uses the yarn VM
+// (custom functions, handlers, and the VM itself) without compiled fixtures.
+// Programs are built instruction by instruction with yarn.ProgramBuilder, run
+// with Run, and checked with the Assert functions, which report readable
+// diffs:
+//
+//	b := yarn.NewProgramBuilder("test")
+//	b.Node("Start").
+//		PushFloat(3).StoreVariable("$gold").Pop().
+//		PushVariable("$gold").Line("line:gold", 1)
+//	res := vmtest.Run(b.Program(), "Start")
+//	if res.Err != nil { t.Fatal(res.Err) }
+//	vmtest.AssertEvents(t, res.Recorder,
+//		"NodeStart Start",
+//		`Line line:gold ["3"]`,
+//		"NodeComplete Start",
+//		"DialogueComplete",
+//	)
+//	vmtest.AssertMutations(t, res.Vars, vmtest.Mutation{Name: "$gold", Value: float32(3)})
+package vmtest // import "github.com/DrJosh9000/yarn/vmtest"
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+	"github.com/google/go-cmp/cmp"
+)
+
+var _ yarn.DialogueHandler = &Recorder{}
+
+// Recorder is a DialogueHandler that records events as strings:
+//
+//	NodeStart Start
+//	Line line:1
+//	Line line:2 ["Ava" "3"]
+//	Options [line:a -line:b line:c]    (unavailable options are marked -)
+//	Choose line:c
+//	Command wave
+//	NodeComplete Start
+//	DialogueComplete
+//
+// PrepareForLines is not recorded.
+type Recorder struct {
+	// Choices are the indexes (into each list of options delivered) of the
+	// options to choose, in order. Once they are exhausted, Options returns
+	// an error.
+	Choices []int
+
+	// Events are the recorded events.
+	Events []string
+
+	// OnEvent, if not nil, is called after each event is recorded, e.g. to
+	// check the stack with AssertStack at that point. If it returns an
+	// error, the handler method returns it.
+	OnEvent func(event string) error
+
+	choice int
+}
+
+func (r *Recorder) record(format string, args ...any) error {
+	ev := fmt.Sprintf(format, args...)
+	r.Events = append(r.Events, ev)
+	if r.OnEvent != nil {
+		return r.OnEvent(ev)
+	}
+	return nil
+}
+
+// NodeStart records the event.
+func (r *Recorder) NodeStart(nodeName string) error {
+	return r.record("NodeStart %s", nodeName)
+}
+
+// PrepareForLines does nothing.
+func (r *Recorder) PrepareForLines([]string) error { return nil }
+
+// Line records the event.
+func (r *Recorder) Line(line yarn.Line) error {
+	return r.record("Line %s", lineString(line))
+}
+
+// Options records the options, and chooses the next of Choices.
+func (r *Recorder) Options(options []yarn.Option) (int, error) {
+	strs := make([]string, len(options))
+	for i, opt := range options {
+		strs[i] = lineString(opt.Line)
+		if !opt.IsAvailable {
+			strs[i] = "-" + strs[i]
+		}
+	}
+	if err := r.record("Options [%s]", strings.Join(strs, " ")); err != nil {
+		return -1, err
+	}
+	if r.choice >= len(r.Choices) {
+		return -1, fmt.Errorf("vmtest: no choice for options %d", r.choice)
+	}
+	i := r.Choices[r.choice]
+	r.choice++
+	if i < 0 || i >= len(options) {
+		return -1, fmt.Errorf("vmtest: choice %d out of bounds [0, %d)", i, len(options))
+	}
+	if err := r.record("Choose %s", options[i].Line.ID); err != nil {
+		return -1, err
+	}
+	return options[i].ID, nil
+}
+
+// Command records the event.
+func (r *Recorder) Command(command string) error {
+	return r.record("Command %s", command)
+}
+
+// NodeComplete records the event.
+func (r *Recorder) NodeComplete(nodeName string) error {
+	return r.record("NodeComplete %s", nodeName)
+}
+
+// DialogueComplete records the event.
+func (r *Recorder) DialogueComplete() error {
+	return r.record("DialogueComplete")
+}
+
+func lineString(line yarn.Line) string {
+	if len(line.Substitutions) == 0 {
+		return line.ID
+	}
+	return fmt.Sprintf("%s %q", line.ID, line.Substitutions)
+}
+
+// Mutation is a variable being set.
+type Mutation struct {
+	Name  string
+	Value any
+}
+
+var _ yarn.VariableStorage = &Storage{}
+
+// Storage is a MapVariableStorage that records each variable set, other than
+// the VM's internal variables (such as visit counts).
+type Storage struct {
+	*yarn.MapVariableStorage
+
+	mu        sync.Mutex
+	mutations []Mutation
+}
+
+// NewStorage returns a Storage with initial values (which are not recorded
+// as mutations).
+func NewStorage(initial map[string]any) *Storage {
+	return &Storage{MapVariableStorage: yarn.NewMapVariableStorageFromMap(initial)}
+}
+
+// SetValue sets the value and records the mutation.
+func (s *Storage) SetValue(name string, value any) {
+	s.MapVariableStorage.SetValue(name, value)
+	if strings.HasPrefix(name, "$"+yarn.InternalPrefix) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mutations = append(s.mutations, Mutation{Name: name, Value: value})
+}
+
+// Mutations returns the recorded mutations, in order.
+func (s *Storage) Mutations() []Mutation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Mutation(nil), s.mutations...)
+}
+
+// Result is the result of Run.
+type Result struct {
+	VM       *yarn.VirtualMachine
+	Recorder *Recorder
+	Vars     *Storage
+	Err      error // from VM.Run
+}
+
+// Run runs the program from node, with a new Recorder (choosing options
+// according to choices) and a new empty Storage. For more control (e.g.
+// custom functions or initial variables), set up a VirtualMachine with a
+// Recorder and Storage directly.
+func Run(prog *yarnpb.Program, node string, choices ...int) *Result {
+	res := &Result{
+		Recorder: &Recorder{Choices: choices},
+		Vars:     NewStorage(nil),
+	}
+	res.VM = &yarn.VirtualMachine{
+		Program: prog,
+		Handler: res.Recorder,
+		Vars:    res.Vars,
+	}
+	res.Err = res.VM.Run(node)
+	return res
+}
+
+// AssertEvents checks the events recorded by r.
+func AssertEvents(t testing.TB, r *Recorder, want ...string) {
+	t.Helper()
+	if diff := cmp.Diff(r.Events, want); diff != "" {
+		t.Errorf("events diff (-got +want):\n%s", diff)
+	}
+}
+
+// AssertStack checks the VM's stack, bottom first. It can be called after
+// the VM has stopped (to check what was left on the stack) or from within a
+// handler method (see Recorder.OnEvent).
+func AssertStack(t testing.TB, vm *yarn.VirtualMachine, want ...any) {
+	t.Helper()
+	got := vm.Snapshot().Stack
+	if len(got) == 0 && len(want) == 0 {
+		return
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("stack diff (-got +want):\n%s", diff)
+	}
+}
+
+// AssertMutations checks the mutations recorded by s.
+func AssertMutations(t testing.TB, s *Storage, want ...Mutation) {
+	t.Helper()
+	if diff := cmp.Diff(s.Mutations(), want); diff != "" {
+		t.Errorf("mutations diff (-got +want):\n%s", diff)
+	}
+}
+
+// AssertVars checks the values of the given variables. A nil value means the
+// variable must not be set. Other variables are ignored.
+func AssertVars(t testing.TB, vars yarn.VariableStorage, want map[string]any) {
+	t.Helper()
+	got := make(map[string]any, len(want))
+	for name := range want {
+		if v, ok := vars.GetValue(name); ok {
+			got[name] = v
+		} else {
+			got[name] = nil
+		}
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("vars diff (-got +want):\n%s", diff)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vmtest
+
+import (
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+func TestRun(t *testing.T) {
+	b := yarn.NewProgramBuilder("test")
+	b.Node("Start").
+		PushFloat(3).StoreVariable("$gold").Pop().
+		PushVariable("$gold").Line("line:gold", 1).
+		PushVariable("$gold").PushFloat(10).Call("Number.GreaterThanOrEqualTo", 2).
+		Option("line:buy", "buy", 0, true).
+		Option("line:leave", "leave", 0, false).
+		ShowOptions().Jump().
+		Label("buy").Command("give_item sword", 0).Stop().
+		Label("leave").RunNode("End")
+	b.Node("End").
+		PushString("left over").
+		Command("wave", 0)
+	prog := b.Program()
+
+	res := Run(prog, "Start", 1)
+	if res.Err != nil {
+		t.Fatalf("Run(Start) = %v", res.Err)
+	}
+	AssertEvents(t, res.Recorder,
+		"NodeStart Start",
+		`Line line:gold ["3"]`,
+		"Options [-line:buy line:leave]",
+		"Choose line:leave",
+		"NodeComplete Start",
+		"NodeStart End",
+		"Command wave",
+		"NodeComplete End",
+		"DialogueComplete",
+	)
+	AssertStack(t, res.VM, "left over")
+	AssertMutations(t, res.Vars, Mutation{Name: "$gold", Value: float32(3)})
+	AssertVars(t, res.Vars, map[string]any{"$gold": float32(3), "$silver": nil})
+
+	// Check the stack at each command.
+	rec := &Recorder{Choices: []int{1}}
+	vm := &yarn.VirtualMachine{Program: prog, Handler: rec, Vars: NewStorage(nil)}
+	rec.OnEvent = func(event string) error {
+		if event == "Command wave" {
+			AssertStack(t, vm, "left over")
+		}
+		return nil
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+
+	if res := Run(prog, "Start"); res.Err == nil {
+		t.Errorf("Run(Start) without choices: Err = nil, want error")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WaitHandler implements the wait command, e.g. <<wait 1.5>>, by blocking
+// the VM for that long on a Clock. Other commands, and all other events, are
+// passed to the embedded DialogueHandler. The embedded handler can be an
+// AsyncAdapter:
+//
+//	clock := &yarn.GameClock{}
+//	aa := yarn.NewAsyncAdapter(h)
+//	aa.Clock = clock
+//	vm.Handler = &yarn.WaitHandler{DialogueHandler: aa, Clock: clock}
+//
+// so that pausing the game clock pauses waits along with everything else.
+type WaitHandler struct {
+	DialogueHandler
+
+	// Clock times the waits. If nil, SystemClock is used.
+	Clock Clock
+
+	// WaitCommand is the name of the wait command. If empty,
+	// DefaultWaitCommand is used.
+	WaitCommand string
+
+	// VM, if not nil, is checked for skip mode: waits return immediately
+	// while it is skipping (see VirtualMachine.SetSkipMode).
+	VM *VirtualMachine
+}
+
+// Command waits if the command is a wait command, and otherwise passes it to
+// the embedded handler. Durations are a number of seconds or a Go duration
+// (e.g. "500ms").
+func (h *WaitHandler) Command(command string) error {
+	_, err := h.CommandResult(command)
+	return err
+}
+
+// CommandResult is like Command, but passes other commands to the embedded
+// handler's CommandResult method, if it has one.
+func (h *WaitHandler) CommandResult(command string) (any, error) {
+	name := h.WaitCommand
+	if name == "" {
+		name = DefaultWaitCommand
+	}
+	fields := strings.Fields(command)
+	if len(fields) == 0 || fields[0] != name {
+		return forwardCommandResult(h.DialogueHandler, command)
+	}
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("%s command %q: want exactly 1 argument", name, command)
+	}
+	d, err := parseSeconds(fields[1])
+	if err != nil || d < 0 {
+		return nil, fmt.Errorf("%s command %q: invalid duration", name, command)
+	}
+	if h.VM != nil && h.VM.SkipMode() {
+		return nil, nil
+	}
+	done, _ := after(clockOrSystem(h.Clock), d)
+	<-done
+	return nil, nil
+}
+
+// SkipLine passes the line to the embedded handler's SkipLine method, if it
+// has one.
+func (h *WaitHandler) SkipLine(line Line) error {
+	return forwardSkipLine(h.DialogueHandler, line)
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+
+	yarnpb "github.com/DrJosh9000/yarn/bytecode"
+)
+
+// DefaultMaxWalkEvents is the default limit on the number of events in a
+// single random walk.
+const DefaultMaxWalkEvents = 10000
+
+// ErrWalkLimit is returned from a walk that delivered more events than
+// allowed. This doesn't necessarily indicate a bug: a dialogue with a "hub"
+// menu can legitimately loop forever.
+const ErrWalkLimit = virtualMachineError("walk event limit reached")
+
+// ErrNoAvailableOptions is returned from a walk when the program delivered
+// options, but none of them were available to choose.
+const ErrNoAvailableOptions = virtualMachineError("no available options")
+
+// RandomWalker plays a program, choosing among available options at random,
+// in order to find paths through the dialogue that cause errors.
+type RandomWalker struct {
+	// Program is the program to walk.
+	Program *yarnpb.Program
+
+	// FuncMap provides any custom functions the program needs.
+	FuncMap FuncMap
+
+	// Rand is used to choose options. If nil, a new source seeded with 1 is
+	// created for each walk. It is ignored if Policy is set.
+	Rand *rand.Rand
+
+	// Policy chooses options. If nil, options are chosen uniformly at random
+	// (from the available options) using Rand.
+	Policy ChoicePolicy
+
+	// MaxEvents limits the number of lines, options, and commands that can be
+	// delivered in a single walk. If zero, DefaultMaxWalkEvents is used.
+	MaxEvents int
+
+	// NewVars, if not nil, is called to create the variable storage for each
+	// walk. Otherwise each walk gets a new empty MapVariableStorage.
+	NewVars func() VariableStorage
+}
+
+// WalkResult records what happened during a walk.
+type WalkResult struct {
+	Nodes    []string // names of nodes started, in order (the last is the ending)
+	Lines    []string // IDs of lines delivered, in order
+	Choices  []Option // options chosen, in order
+	Commands []string // commands delivered, in order
+	Err      error    // the error returned by the VM, if any
+}
+
+// Walk performs a single random walk starting at startNode.
+func (w *RandomWalker) Walk(startNode string) *WalkResult {
+	policy := w.Policy
+	if policy == nil {
+		rng := w.Rand
+		if rng == nil {
+			rng = rand.New(rand.NewSource(1))
+		}
+		policy = UniformPolicy{Rand: rng}
+	}
+	if r, ok := policy.(PolicyResetter); ok {
+		r.Reset()
+	}
+	max := w.MaxEvents
+	if max <= 0 {
+		max = DefaultMaxWalkEvents
+	}
+	var vars VariableStorage
+	if w.NewVars != nil {
+		vars = w.NewVars()
+	} else {
+		vars = NewMapVariableStorage()
+	}
+	h := &walkHandler{
+		policy: policy,
+		max:    max,
+		res:    new(WalkResult),
+	}
+	fm := make(FuncMap, len(w.FuncMap))
+	fm.merge(w.FuncMap)
+	vm := &VirtualMachine{
+		Program: w.Program,
+		Handler: h,
+		Vars:    vars,
+		FuncMap: fm,
+	}
+	h.res.Err = vm.Run(startNode)
+	return h.res
+}
+
+// walkHandler is the DialogueHandler used by RandomWalker.
+type walkHandler struct {
+	policy ChoicePolicy
+	max    int
+	events int
+	res    *WalkResult
+
+	FakeDialogueHandler
+}
+
+func (h *walkHandler) event() error {
+	h.events++
+	if h.events > h.max {
+		return ErrWalkLimit
+	}
+	return nil
+}
+
+func (h *walkHandler) NodeStart(nodeName string) error {
+	h.res.Nodes = append(h.res.Nodes, nodeName)
+	return nil
+}
+
+func (h *walkHandler) Line(line Line) error {
+	h.res.Lines = append(h.res.Lines, line.ID)
+	return h.event()
+}
+
+func (h *walkHandler) Command(command string) error {
+	h.res.Commands = append(h.res.Commands, command)
+	return h.event()
+}
+
+func (h *walkHandler) Options(options []Option) (int, error) {
+	if err := h.event(); err != nil {
+		return -1, err
+	}
+	avail := availableOptions(options)
+	if len(avail) == 0 {
+		return -1, ErrNoAvailableOptions
+	}
+	i, err := h.policy.Choose(avail)
+	if err != nil {
+		return -1, err
+	}
+	if i < 0 || i >= len(avail) {
+		return -1, fmt.Errorf("policy chose option %d out of bounds [0, %d)", i, len(avail))
+	}
+	choice := avail[i]
+	h.res.Choices = append(h.res.Choices, choice)
+	return choice.ID, nil
+}
+
+// Ending returns the name of the last node started during the walk, or "" if
+// no nodes were started.
+func (r *WalkResult) Ending() string {
+	if len(r.Nodes) == 0 {
+		return ""
+	}
+	return r.Nodes[len(r.Nodes)-1]
+}
+
+// IsWalkLimit reports whether the walk ended only because it reached the
+// event limit.
+func (r *WalkResult) IsWalkLimit() bool { return errors.Is(r.Err, ErrWalkLimit) }
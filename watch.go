@@ -0,0 +1,90 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import "reflect"
+
+// WatchEvent describes a change in the value of a watched expression.
+type WatchEvent struct {
+	// Expr is the source of the watched expression.
+	Expr string
+
+	// Old and New are the values before and after the change. If the
+	// expression could not be evaluated, the value is nil and the
+	// corresponding error is set.
+	Old, New       any
+	OldErr, NewErr error
+}
+
+// watch is a registered watch expression.
+type watch struct {
+	expr   *Expression
+	notify func(WatchEvent)
+	val    any
+	err    error
+}
+
+// Watch registers an expression (see CompileExpression) to be re-evaluated
+// after every STORE_VARIABLE instruction. notify is called, from within the
+// VM's goroutine, whenever the value changes (or whether it can be evaluated
+// changes). This is intended for debug tools, such as a live quest state
+// panel.
+//
+// The expression is first evaluated when Watch is called; notify is not
+// called for this initial value. The returned function removes the watch.
+func (vm *VirtualMachine) Watch(expr string, notify func(WatchEvent)) (unwatch func(), err error) {
+	e, err := CompileExpression(expr)
+	if err != nil {
+		return nil, err
+	}
+	w := &watch{expr: e, notify: notify}
+	w.val, w.err = vm.Evaluate(e)
+	vm.watches = append(vm.watches, w)
+	return func() {
+		for i, x := range vm.watches {
+			if x == w {
+				vm.watches = append(vm.watches[:i:i], vm.watches[i+1:]...)
+				return
+			}
+		}
+	}, nil
+}
+
+// checkWatches re-evaluates the watch expressions, and notifies any that
+// changed.
+func (vm *VirtualMachine) checkWatches() {
+	for _, w := range vm.watches {
+		val, err := vm.Evaluate(w.expr)
+		if reflect.DeepEqual(val, w.val) && errString(err) == errString(w.err) {
+			continue
+		}
+		ev := WatchEvent{
+			Expr:   w.expr.Source,
+			Old:    w.val,
+			New:    val,
+			OldErr: w.err,
+			NewErr: err,
+		}
+		w.val, w.err = val, err
+		w.notify(ev)
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatch(t *testing.T) {
+	pb := NewProgramBuilder("Watch")
+	start := pb.Node("Start")
+	store := func(name string, value float32) {
+		start.PushFloat(value).StoreVariable(name).Pop()
+	}
+	store("$gold", 5)
+	store("$gold", 12)
+	store("$keys", 1)
+	store("$gold", 15)
+	start.Stop()
+	prog := pb.Program()
+
+	vars := NewMapVariableStorage()
+	vars.SetValue("$gold", float32(0))
+	vm := &VirtualMachine{Program: prog, Handler: FakeDialogueHandler{}, Vars: vars}
+
+	var got []WatchEvent
+	if _, err := vm.Watch(`$gold >= 10`, func(e WatchEvent) { got = append(got, e) }); err != nil {
+		t.Fatalf("vm.Watch($gold >= 10) = %v", err)
+	}
+	unwatch, err := vm.Watch(`$keys`, func(e WatchEvent) { got = append(got, e) })
+	if err != nil {
+		t.Fatalf("vm.Watch($keys) = %v", err)
+	}
+	unwatch()
+	if _, err := vm.Watch(`$gold +`, nil); err == nil {
+		t.Error("vm.Watch($gold +) = nil error, want error")
+	}
+
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	want := []WatchEvent{
+		{Expr: "$gold >= 10", Old: false, New: true},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("watch events diff (-got +want):\n%s", diff)
+	}
+}
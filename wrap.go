@@ -0,0 +1,207 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/width"
+)
+
+// NoBreakAttribute marks text that Wrap keeps on one line if it fits, e.g.
+// [nobr]Captain Ava Reyes[/nobr].
+const NoBreakAttribute = "nobr"
+
+// RuneWidth returns the number of columns r occupies on a fixed-width
+// display: 0 for combining marks and control characters, 2 for East Asian
+// wide and fullwidth characters, and 1 otherwise.
+func RuneWidth(r rune) int {
+	switch {
+	case r == 0, unicode.Is(unicode.Mn, r), unicode.Is(unicode.Me, r), unicode.Is(unicode.Cf, r), unicode.IsControl(r):
+		return 0
+	}
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 2
+	}
+	return 1
+}
+
+// TextWidth returns the number of columns s occupies on a fixed-width
+// display, summing the widths of its grapheme clusters (see Graphemes).
+func TextWidth(s string) int {
+	w := 0
+	for _, g := range Graphemes(s) {
+		w += g.Width
+	}
+	return w
+}
+
+// Wrap splits the string into lines at most width columns wide (measured
+// with TextWidth), for terminals and fixed-width UIs. Lines are broken at
+// spaces (which are removed) and at existing newlines. Words wider than
+// width are broken between grapheme clusters, so combining marks, emoji
+// sequences, and the like are never split. Spaces within a NoBreakAttribute
+// are not broken unless its text is wider than width.
+//
+// Each line has the attributes that overlap it, clipped to the line, so an
+// attribute spanning a break (e.g. [b] around several words) applies to the
+// parts on each line. If width is not positive, the string is only split at
+// newlines.
+func (s *AttributedString) Wrap(width int) []*AttributedString {
+	str := s.str
+	noBreak := s.noBreakRanges(width)
+
+	var lines []*AttributedString
+	start, w := 0, 0
+	brk, brkNext := -1, -1 // the last place the line can end, and where the next would start
+	emit := func(end, next int) {
+		end = start + len(strings.TrimRightFunc(str[start:end], unicode.IsSpace))
+		lines = append(lines, s.slice(start, end, next))
+		start, w, brk = next, 0, -1
+	}
+	gs := Graphemes(str)
+	for k := 0; k < len(gs); {
+		i := gs[k].Start
+		if isNewline(gs[k].Text) {
+			emit(i, gs[k].End)
+			k++
+			continue
+		}
+		if isSpace(gs[k].Text) && !inRanges(noBreak, i) {
+			j := k + 1
+			for j < len(gs) && isSpace(gs[j].Text) && !isNewline(gs[j].Text) {
+				j++
+			}
+			brk, brkNext = i, gs[j-1].End
+			w += TextWidth(str[i:brkNext])
+			k = j
+			continue
+		}
+		gw := gs[k].Width
+		for width > 0 && w > 0 && w+gw > width {
+			if brk > start {
+				emit(brk, brkNext)
+				w = TextWidth(str[start:i])
+				continue
+			}
+			emit(i, i)
+		}
+		w += gw
+		k++
+	}
+	emit(len(str), len(str)+1)
+	return lines
+}
+
+// Truncate returns the string shortened to at most width columns (measured
+// with TextWidth), ending with ellipsis (e.g. "…") if anything was removed.
+// The ellipsis counts towards the width. Attributes are clipped to the text
+// that remains, and don't include the ellipsis.
+func (s *AttributedString) Truncate(width int, ellipsis string) *AttributedString {
+	if TextWidth(s.str) <= width {
+		return s.slice(0, len(s.str), len(s.str)+1)
+	}
+	avail := max(0, width-TextWidth(ellipsis))
+	end, w := 0, 0
+	for _, g := range s.Graphemes() {
+		if isNewline(g.Text) || w+g.Width > avail {
+			break
+		}
+		w += g.Width
+		end = g.End
+	}
+	end = len(strings.TrimRightFunc(s.str[:end], unicode.IsSpace))
+	out := s.slice(0, end, end)
+	out.str += ellipsis
+	return out
+}
+
+// noBreakRanges returns the byte ranges of NoBreakAttributes that fit within
+// width.
+func (s *AttributedString) noBreakRanges(width int) [][2]int {
+	var ranges [][2]int
+	s.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if a.Name != NoBreakAttribute || a.Start != pos || a.End <= a.Start {
+				continue
+			}
+			if width > 0 && TextWidth(s.str[a.Start:a.End]) > width {
+				continue
+			}
+			ranges = append(ranges, [2]int{a.Start, a.End})
+		}
+	})
+	return ranges
+}
+
+// inRanges reports whether i is within one of the ranges.
+func inRanges(ranges [][2]int, i int) bool {
+	for _, r := range ranges {
+		if i >= r[0] && i < r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// isNewline reports whether a grapheme cluster is a line break ("\r\n" is a
+// single cluster).
+func isNewline(g string) bool { return g == "\n" || g == "\r\n" }
+
+// isSpace reports whether a grapheme cluster is whitespace.
+func isSpace(g string) bool {
+	r, _ := utf8.DecodeRuneInString(g)
+	return unicode.IsSpace(r)
+}
+
+// slice returns the bytes [start, end) of the string, with copies of the
+// attributes that overlap them, clipped to fit. Attributes that mark up
+// nothing are included if they are within [start, pointEnd).
+func (s *AttributedString) slice(start, end, pointEnd int) *AttributedString {
+	out := &AttributedString{str: s.str[start:end]}
+	seen := make(map[*Attribute]bool)
+	s.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if seen[a] {
+				continue
+			}
+			seen[a] = true
+			if a.Start == a.End {
+				if a.Start < start || a.Start >= pointEnd {
+					continue
+				}
+			} else if a.End <= start || a.Start >= end {
+				continue
+			}
+			c := &Attribute{
+				Start: min(max(a.Start, start), end) - start,
+				End:   min(max(a.End, start), end) - start,
+				Name:  a.Name,
+				Props: a.Props,
+			}
+			if out.atts == nil {
+				out.atts = make(map[int][]*Attribute)
+			}
+			out.atts[c.Start] = append(out.atts[c.Start], c)
+			if c.End != c.Start {
+				out.atts[c.End] = append(out.atts[c.End], c)
+			}
+		}
+	})
+	return out
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// wrappedLine is a line of wrapped text, with its attributes.
+type wrappedLine struct {
+	Text  string
+	Attrs []Attribute
+}
+
+func renderMarkup(t *testing.T, text string) *AttributedString {
+	t.Helper()
+	st := &StringTable{Table: map[string]*StringTableRow{
+		"line:1": {ID: "line:1", Text: text},
+	}}
+	as, err := st.Render(Line{ID: "line:1"})
+	if err != nil {
+		t.Fatalf("Render(%q) = %v", text, err)
+	}
+	return as
+}
+
+func toWrappedLine(as *AttributedString) wrappedLine {
+	wl := wrappedLine{Text: as.String()}
+	as.ScanAttribEvents(func(pos int, atts []*Attribute) {
+		for _, a := range atts {
+			if a.Start == pos {
+				wl.Attrs = append(wl.Attrs, *a)
+			}
+		}
+	})
+	return wl
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text  string
+		width int
+		want  []wrappedLine
+	}{
+		{
+			text:  "The quick brown fox jumps",
+			width: 10,
+			want: []wrappedLine{
+				{Text: "The quick"},
+				{Text: "brown fox"},
+				{Text: "jumps"},
+			},
+		},
+		{
+			text:  "Say [b]hello there[/b] friend",
+			width: 9,
+			want: []wrappedLine{
+				{Text: "Say hello", Attrs: []Attribute{{Start: 4, End: 9, Name: "b"}}},
+				{Text: "there", Attrs: []Attribute{{Start: 0, End: 5, Name: "b"}}},
+				{Text: "friend"},
+			},
+		},
+		{
+			text:  "Hail [nobr]Captain Ava[/nobr]!",
+			width: 14,
+			want: []wrappedLine{
+				{Text: "Hail"},
+				{Text: "Captain Ava!", Attrs: []Attribute{{Start: 0, End: 11, Name: "nobr"}}},
+			},
+		},
+		{
+			// Too wide to keep together.
+			text:  "[nobr]Captain Ava Reyes[/nobr]",
+			width: 11,
+			want: []wrappedLine{
+				{Text: "Captain Ava", Attrs: []Attribute{{Start: 0, End: 11, Name: "nobr"}}},
+				{Text: "Reyes", Attrs: []Attribute{{Start: 0, End: 5, Name: "nobr"}}},
+			},
+		},
+		{
+			text:  "Supercalifragilistic",
+			width: 8,
+			want: []wrappedLine{
+				{Text: "Supercal"},
+				{Text: "ifragili"},
+				{Text: "stic"},
+			},
+		},
+		{
+			text:  "一二三四五",
+			width: 4,
+			want: []wrappedLine{
+				{Text: "一二"},
+				{Text: "三四"},
+				{Text: "五"},
+			},
+		},
+		{
+			// Combining marks stay with their base character.
+			text:  "cafés",
+			width: 4,
+			want: []wrappedLine{
+				{Text: "café"},
+				{Text: "s"},
+			},
+		},
+		{
+			text:  "One\nTwo [wave/]three",
+			width: 0,
+			want: []wrappedLine{
+				{Text: "One"},
+				{Text: "Two three", Attrs: []Attribute{{Start: 4, End: 4, Name: "wave"}}},
+			},
+		},
+		{
+			text:  "Go[wave/] now",
+			width: 3,
+			want: []wrappedLine{
+				{Text: "Go", Attrs: []Attribute{{Start: 2, End: 2, Name: "wave"}}},
+				{Text: "now"},
+			},
+		},
+	}
+	for _, test := range tests {
+		var got []wrappedLine
+		for _, line := range renderMarkup(t, test.text).Wrap(test.width) {
+			got = append(got, toWrappedLine(line))
+		}
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("Wrap(%q, %d) diff (-got +want):\n%s", test.text, test.width, diff)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		text     string
+		width    int
+		ellipsis string
+		want     wrappedLine
+	}{
+		{
+			text:     "Short",
+			width:    10,
+			ellipsis: "…",
+			want:     wrappedLine{Text: "Short"},
+		},
+		{
+			text:     "I'll have the [b]fish[/b], please",
+			width:    17,
+			ellipsis: "…",
+			want: wrappedLine{
+				Text:  "I'll have the fi…",
+				Attrs: []Attribute{{Start: 14, End: 16, Name: "b"}},
+			},
+		},
+		{
+			text:     "Go north [b]now[/b]",
+			width:    11,
+			ellipsis: "...",
+			want:     wrappedLine{Text: "Go north..."},
+		},
+		{
+			text:     "一二三",
+			width:    5,
+			ellipsis: "…",
+			want:     wrappedLine{Text: "一二…"},
+		},
+	}
+	for _, test := range tests {
+		got := toWrappedLine(renderMarkup(t, test.text).Truncate(test.width, test.ellipsis))
+		if diff := cmp.Diff(got, test.want); diff != "" {
+			t.Errorf("Truncate(%q, %d, %q) diff (-got +want):\n%s", test.text, test.width, test.ellipsis, diff)
+		}
+	}
+}
+
+func TestTextWidth(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want int
+	}{
+		{"hello", 5},
+		{"一二", 4},
+		{"é", 1},
+		{"ｈｉ", 4},
+		{"", 0},
+	} {
+		if got := TextWidth(test.s); got != test.want {
+			t.Errorf("TextWidth(%q) = %d, want %d", test.s, got, test.want)
+		}
+	}
+}
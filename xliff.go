@@ -0,0 +1,459 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"golang.org/x/text/language"
+)
+
+// XLIFF namespaces.
+const (
+	xliff12Namespace = "urn:oasis:names:tc:xliff:document:1.2"
+	xliff20Namespace = "urn:oasis:names:tc:xliff:document:2.0"
+)
+
+// XLIFFVersion is a version of the XLIFF format.
+type XLIFFVersion int
+
+const (
+	// XLIFF12 is XLIFF 1.2, the version most widely supported by CAT tools.
+	XLIFF12 XLIFFVersion = iota
+
+	// XLIFF20 is XLIFF 2.0.
+	XLIFF20
+)
+
+func (v XLIFFVersion) String() string {
+	switch v {
+	case XLIFF12:
+		return "1.2"
+	case XLIFF20:
+		return "2.0"
+	}
+	return fmt.Sprintf("(invalid XLIFFVersion %d)", v)
+}
+
+// TranslationState is the progress of translating a unit. States are ordered,
+// so a state can be compared against a minimum (e.g. to import only reviewed
+// translations).
+type TranslationState int
+
+const (
+	// TranslationNew means the unit has not been translated. It is "new" in
+	// XLIFF 1.2 and "initial" in XLIFF 2.0.
+	TranslationNew TranslationState = iota
+
+	// TranslationTranslated means the unit has been translated, but not
+	// reviewed.
+	TranslationTranslated
+
+	// TranslationReviewed means the translation has been reviewed. It is
+	// "signed-off" in XLIFF 1.2.
+	TranslationReviewed
+
+	// TranslationFinal means the translation is finished.
+	TranslationFinal
+)
+
+func (s TranslationState) String() string {
+	switch s {
+	case TranslationNew:
+		return "new"
+	case TranslationTranslated:
+		return "translated"
+	case TranslationReviewed:
+		return "reviewed"
+	case TranslationFinal:
+		return "final"
+	}
+	return fmt.Sprintf("(invalid TranslationState %d)", s)
+}
+
+// xliffState returns the state attribute value for the version.
+func (s TranslationState) xliffState(v XLIFFVersion) string {
+	switch {
+	case s == TranslationNew && v == XLIFF12:
+		return "new"
+	case s == TranslationNew:
+		return "initial"
+	case s == TranslationReviewed && v == XLIFF12:
+		return "signed-off"
+	}
+	return s.String()
+}
+
+// parseXLIFFState parses a state attribute from either version. Unknown
+// states (such as the many XLIFF 1.2 "needs-" states) are treated as new if
+// there is no target, and translated otherwise.
+func parseXLIFFState(s string, hasTarget bool) TranslationState {
+	switch s {
+	case "new", "initial":
+		return TranslationNew
+	case "translated":
+		return TranslationTranslated
+	case "reviewed", "signed-off":
+		return TranslationReviewed
+	case "final":
+		return TranslationFinal
+	}
+	if hasTarget {
+		return TranslationTranslated
+	}
+	return TranslationNew
+}
+
+// XLIFFUnit is a line to translate.
+type XLIFFUnit struct {
+	ID     string
+	Source string
+	Target string
+	State  TranslationState
+
+	// File and Node are where the line comes from. Units are grouped into
+	// XLIFF files by File.
+	File, Node string
+
+	// Notes are for translators: the character, the preceding line, and
+	// any comments (see TranslatorRow). They are written by Write, but
+	// ignored when importing.
+	Notes []string
+}
+
+// XLIFF is a document for exchanging translations with CAT tools.
+type XLIFF struct {
+	Version        XLIFFVersion
+	SourceLanguage string
+	TargetLanguage string
+	Units          []XLIFFUnit
+}
+
+// NewXLIFF creates a document for translating the source string table into
+// the target string table's language. target may be nil (or missing rows),
+// in which case the units are new. Units with a translation are marked as
+// translated, unless it is outdated or an untranslated copy of the source
+// (see StringTable.Coverage), in which case they are new.
+func NewXLIFF(version XLIFFVersion, source, target *StringTable) *XLIFF {
+	x := &XLIFF{
+		Version:        version,
+		SourceLanguage: source.Language.String(),
+	}
+	if target != nil {
+		x.TargetLanguage = target.Language.String()
+	}
+	for _, tr := range TranslatorRows(source) {
+		u := XLIFFUnit{
+			ID:     tr.ID,
+			Source: tr.Text,
+			File:   tr.File,
+			Node:   tr.Node,
+		}
+		if row := target.row(tr.ID); row != nil && row.Text != "" && (row.SourceHash != "" || row.Text != tr.Text) {
+			u.Target = row.Text
+			u.State = TranslationTranslated
+			if row.SourceHash != "" && row.SourceHash != LineHash(tr.Text) {
+				// Outdated: keep the old translation as a starting point.
+				u.State = TranslationNew
+			}
+		}
+		if tr.Character != "" {
+			u.Notes = append(u.Notes, "Character: "+tr.Character)
+		}
+		if tr.Context != "" {
+			u.Notes = append(u.Notes, "Previous line: "+tr.Context)
+		}
+		u.Notes = append(u.Notes, tr.Comments...)
+		x.Units = append(x.Units, u)
+	}
+	return x
+}
+
+// StringTable creates a string table in the target language from the units
+// that are at least min. Rows are copied from source (which may be nil),
+// with their text replaced by the translation; rows without a translation
+// (or not yet at min) keep the source text. Translated rows record the
+// LineHash of the source they were translated from, so that they can be
+// found when outdated (see StringTable.Coverage).
+func (x *XLIFF) StringTable(source *StringTable, min TranslationState) (*StringTable, error) {
+	lang, err := language.Parse(x.TargetLanguage)
+	if err != nil {
+		return nil, fmt.Errorf("xliff target language: %w", err)
+	}
+	st := &StringTable{
+		Language: lang,
+		Table:    make(map[string]*StringTableRow),
+	}
+	if source != nil {
+		for id, row := range source.Table {
+			if row == nil {
+				continue
+			}
+			st.Table[id] = &StringTableRow{
+				ID:         row.ID,
+				Text:       row.Text,
+				File:       row.File,
+				Node:       row.Node,
+				LineNumber: row.LineNumber,
+				Tags:       row.Tags,
+			}
+		}
+	}
+	for _, u := range x.Units {
+		row := st.Table[u.ID]
+		if row == nil {
+			row = &StringTableRow{ID: u.ID, Text: u.Source, File: u.File, Node: u.Node}
+			st.Table[u.ID] = row
+		}
+		if u.State >= min && u.Target != "" {
+			row.Text = u.Target
+			row.SourceHash = LineHash(u.Source)
+		}
+	}
+	return st, nil
+}
+
+// Write writes the document as XLIFF.
+func (x *XLIFF) Write(w io.Writer) error {
+	var doc any
+	switch x.Version {
+	case XLIFF12:
+		doc = x.doc12()
+	case XLIFF20:
+		doc = x.doc20()
+	default:
+		return fmt.Errorf("writing xliff: unsupported version %v", x.Version)
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("writing xliff: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("writing xliff: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("writing xliff: %w", err)
+	}
+	return nil
+}
+
+// ReadXLIFF reads an XLIFF 1.2 or 2.0 document.
+func ReadXLIFF(r io.Reader) (*XLIFF, error) {
+	var doc xliffAny
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("reading xliff: %w", err)
+	}
+	x := new(XLIFF)
+	switch doc.Version {
+	case "1.2":
+		x.Version = XLIFF12
+		for _, f := range doc.Files {
+			if x.SourceLanguage == "" {
+				x.SourceLanguage, x.TargetLanguage = f.SourceLanguage, f.TargetLanguage
+			}
+			for _, u := range f.Body.Units {
+				var target string
+				var state TranslationState
+				if u.Target != nil {
+					target = u.Target.Text
+					state = parseXLIFFState(u.Target.State, target != "")
+				}
+				x.Units = append(x.Units, XLIFFUnit{ID: u.ID, Source: u.Source, Target: target, State: state, File: f.Original, Node: u.ResName})
+			}
+		}
+	case "2.0":
+		x.Version = XLIFF20
+		x.SourceLanguage, x.TargetLanguage = doc.SrcLang, doc.TrgLang
+		for _, f := range doc.Files {
+			for _, u := range f.Units {
+				xu := XLIFFUnit{ID: u.ID, File: f.Original, Node: u.Name}
+				for _, s := range u.Segments {
+					// Lines are written as one segment, but tools may split
+					// them.
+					xu.Source += s.Source
+					xu.Target += s.Target
+					xu.State = parseXLIFFState(s.State, s.Target != "")
+				}
+				x.Units = append(x.Units, xu)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("reading xliff: unsupported version %q", doc.Version)
+	}
+	return x, nil
+}
+
+// groupUnits groups units by file, in order of first appearance.
+func (x *XLIFF) groupUnits() (files []string, units map[string][]XLIFFUnit) {
+	units = make(map[string][]XLIFFUnit)
+	for _, u := range x.Units {
+		if _, ok := units[u.File]; !ok {
+			files = append(files, u.File)
+		}
+		units[u.File] = append(units[u.File], u)
+	}
+	return files, units
+}
+
+func (x *XLIFF) doc12() *xliff12 {
+	doc := &xliff12{Xmlns: xliff12Namespace, Version: "1.2"}
+	files, units := x.groupUnits()
+	for _, file := range files {
+		f := xliff12File{
+			Original:       file,
+			SourceLanguage: x.SourceLanguage,
+			TargetLanguage: x.TargetLanguage,
+			Datatype:       "plaintext",
+		}
+		for _, u := range units[file] {
+			tu := xliff12Unit{ID: u.ID, ResName: u.Node, Source: u.Source}
+			if u.Target != "" || u.State != TranslationNew {
+				tu.Target = &xliff12Target{Text: u.Target, State: u.State.xliffState(XLIFF12)}
+			}
+			for _, n := range u.Notes {
+				tu.Notes = append(tu.Notes, xliffNote{Text: n})
+			}
+			f.Body.Units = append(f.Body.Units, tu)
+		}
+		doc.Files = append(doc.Files, f)
+	}
+	return doc
+}
+
+func (x *XLIFF) doc20() *xliff20 {
+	doc := &xliff20{
+		Xmlns:   xliff20Namespace,
+		Version: "2.0",
+		SrcLang: x.SourceLanguage,
+		TrgLang: x.TargetLanguage,
+	}
+	files, units := x.groupUnits()
+	for i, file := range files {
+		// File IDs must be NMTOKENs, which paths usually aren't.
+		f := xliff20File{ID: "f" + strconv.Itoa(i+1), Original: file}
+		for _, u := range units[file] {
+			xu := xliff20Unit{
+				ID:   u.ID,
+				Name: u.Node,
+				Segments: []xliff20Segment{{
+					State:  u.State.xliffState(XLIFF20),
+					Source: u.Source,
+					Target: u.Target,
+				}},
+			}
+			if len(u.Notes) > 0 {
+				xu.Notes = &xliff20Notes{}
+				for _, n := range u.Notes {
+					xu.Notes.Notes = append(xu.Notes.Notes, xliffNote{Text: n})
+				}
+			}
+			f.Units = append(f.Units, xu)
+		}
+		doc.Files = append(doc.Files, f)
+	}
+	return doc
+}
+
+// XML structures for XLIFF 1.2.
+type (
+	xliff12 struct {
+		XMLName xml.Name      `xml:"xliff"`
+		Xmlns   string        `xml:"xmlns,attr"`
+		Version string        `xml:"version,attr"`
+		Files   []xliff12File `xml:"file"`
+	}
+
+	xliff12File struct {
+		Original       string `xml:"original,attr"`
+		SourceLanguage string `xml:"source-language,attr"`
+		TargetLanguage string `xml:"target-language,attr,omitempty"`
+		Datatype       string `xml:"datatype,attr"`
+		Body           struct {
+			Units []xliff12Unit `xml:"trans-unit"`
+		} `xml:"body"`
+	}
+
+	xliff12Unit struct {
+		ID      string         `xml:"id,attr"`
+		ResName string         `xml:"resname,attr,omitempty"`
+		Source  string         `xml:"source"`
+		Target  *xliff12Target `xml:"target"`
+		Notes   []xliffNote    `xml:"note"`
+	}
+
+	xliff12Target struct {
+		Text  string `xml:",chardata"`
+		State string `xml:"state,attr,omitempty"`
+	}
+)
+
+// XML structures for XLIFF 2.0.
+type (
+	xliff20 struct {
+		XMLName xml.Name      `xml:"xliff"`
+		Xmlns   string        `xml:"xmlns,attr"`
+		Version string        `xml:"version,attr"`
+		SrcLang string        `xml:"srcLang,attr"`
+		TrgLang string        `xml:"trgLang,attr,omitempty"`
+		Files   []xliff20File `xml:"file"`
+	}
+
+	xliff20File struct {
+		ID       string        `xml:"id,attr"`
+		Original string        `xml:"original,attr,omitempty"`
+		Units    []xliff20Unit `xml:"unit"`
+	}
+
+	xliff20Unit struct {
+		ID       string           `xml:"id,attr"`
+		Name     string           `xml:"name,attr,omitempty"`
+		Notes    *xliff20Notes    `xml:"notes"`
+		Segments []xliff20Segment `xml:"segment"`
+	}
+
+	xliff20Notes struct {
+		Notes []xliffNote `xml:"note"`
+	}
+
+	xliff20Segment struct {
+		State  string `xml:"state,attr,omitempty"`
+		Source string `xml:"source"`
+		Target string `xml:"target,omitempty"`
+	}
+)
+
+type xliffNote struct {
+	Text string `xml:",chardata"`
+}
+
+// xliffAny is enough of either version to read it. Namespaces are ignored.
+type xliffAny struct {
+	Version string `xml:"version,attr"`
+	SrcLang string `xml:"srcLang,attr"` // 2.0
+	TrgLang string `xml:"trgLang,attr"` // 2.0
+	Files   []struct {
+		Original       string `xml:"original,attr"`
+		SourceLanguage string `xml:"source-language,attr"` // 1.2
+		TargetLanguage string `xml:"target-language,attr"` // 1.2
+		Body           struct {
+			Units []xliff12Unit `xml:"trans-unit"`
+		} `xml:"body"` // 1.2
+		Units []xliff20Unit `xml:"unit"` // 2.0
+	} `xml:"file"`
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/text/language"
+)
+
+func TestXLIFFRoundTrip(t *testing.T) {
+	source := translatorTable()
+	source.Language = language.English
+	target := &StringTable{
+		Language: language.French,
+		Table: map[string]*StringTableRow{
+			"line:a1": {ID: "line:a1", Text: "Ava : Bonjour !"},
+		},
+	}
+	for _, version := range []XLIFFVersion{XLIFF12, XLIFF20} {
+		t.Run(version.String(), func(t *testing.T) {
+			x := NewXLIFF(version, source, target)
+			x.Units[2].Target = "[b]Cours ![/b]"
+			x.Units[2].State = TranslationReviewed
+
+			var buf strings.Builder
+			if err := x.Write(&buf); err != nil {
+				t.Fatalf("x.Write = %v", err)
+			}
+			got, err := ReadXLIFF(strings.NewReader(buf.String()))
+			if err != nil {
+				t.Fatalf("ReadXLIFF = %v", err)
+			}
+			want := &XLIFF{
+				Version:        version,
+				SourceLanguage: "en",
+				TargetLanguage: "fr",
+				Units: []XLIFFUnit{
+					{ID: "line:a1", Source: "Ava: Hello there!", Target: "Ava : Bonjour !", State: TranslationTranslated, File: "a.yarn", Node: "Start"},
+					{ID: "line:a2", Source: "Bo: You have {0} gold, Ava.", File: "a.yarn", Node: "Start"},
+					{ID: "line:a3", Source: "[b]Run![/b]", Target: "[b]Cours ![/b]", State: TranslationReviewed, File: "a.yarn", Node: "Chase"},
+				},
+			}
+			if diff := cmp.Diff(got, want); diff != "" {
+				t.Errorf("ReadXLIFF diff (-got +want):\n%s\nxliff:\n%s", diff, buf.String())
+			}
+			if !strings.Contains(buf.String(), "Character: Ava") {
+				t.Errorf("xliff doesn't contain character note:\n%s", buf.String())
+			}
+
+			st, err := got.StringTable(source, TranslationReviewed)
+			if err != nil {
+				t.Fatalf("got.StringTable = %v", err)
+			}
+			if st.Language != language.French {
+				t.Errorf("st.Language = %v, want %v", st.Language, language.French)
+			}
+			texts := make(map[string]string)
+			for id, row := range st.Table {
+				texts[id] = row.Text
+			}
+			wantTexts := map[string]string{
+				"line:a1": "Ava: Hello there!", // only translated, not reviewed
+				"line:a2": "Bo: You have {0} gold, Ava.",
+				"line:a3": "[b]Cours ![/b]",
+			}
+			if diff := cmp.Diff(texts, wantTexts); diff != "" {
+				t.Errorf("st texts diff (-got +want):\n%s", diff)
+			}
+			if got, want := st.Table["line:a1"].LineNumber, 3; got != want {
+				t.Errorf("st.Table[line:a1].LineNumber = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestReadXLIFFStates(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<xliff xmlns="urn:oasis:names:tc:xliff:document:1.2" version="1.2">
+  <file original="a.yarn" source-language="en" target-language="de" datatype="plaintext">
+    <body>
+      <trans-unit id="line:1"><source>One</source><target state="final">Eins</target></trans-unit>
+      <trans-unit id="line:2"><source>Two</source><target state="needs-review-translation">Zwei</target></trans-unit>
+      <trans-unit id="line:3"><source>Three</source><target state="needs-translation"></target></trans-unit>
+      <trans-unit id="line:4"><source>Four</source></trans-unit>
+    </body>
+  </file>
+</xliff>`
+	x, err := ReadXLIFF(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("ReadXLIFF = %v", err)
+	}
+	var got []TranslationState
+	for _, u := range x.Units {
+		got = append(got, u.State)
+	}
+	want := []TranslationState{TranslationFinal, TranslationTranslated, TranslationNew, TranslationNew}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Errorf("states diff (-got +want):\n%s", diff)
+	}
+
+	if _, err := ReadXLIFF(strings.NewReader(`<xliff version="3.0"></xliff>`)); err == nil {
+		t.Error("ReadXLIFF(version 3.0) = nil error, want error")
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package example contains a command interface and the handler generated
+// from it by yarngen, to test the generated code.
+package example
+
+//go:generate go run -tags example github.com/DrJosh9000/yarn/cmd/yarngen -type=Commands
+
+// Commands are the commands of an imaginary game.
+type Commands interface {
+	Wave(direction string) error
+	Give(item string, count int) error
+	PlaySound(name string, volume float32, loop bool)
+	Wait(seconds float64, frames int64) error
+	Say(speaker string, words ...string)
+	HTTPGet(string) error
+}
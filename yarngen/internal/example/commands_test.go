@@ -0,0 +1,117 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package example
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/google/go-cmp/cmp"
+)
+
+// recorder records calls.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) record(format string, args ...any) {
+	r.calls = append(r.calls, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Wave(direction string) error {
+	r.record("Wave(%q)", direction)
+	return nil
+}
+
+func (r *recorder) Give(item string, count int) error {
+	r.record("Give(%q, %d)", item, count)
+	return nil
+}
+
+func (r *recorder) PlaySound(name string, volume float32, loop bool) {
+	r.record("PlaySound(%q, %v, %t)", name, volume, loop)
+}
+
+func (r *recorder) Wait(seconds float64, frames int64) error {
+	r.record("Wait(%v, %d)", seconds, frames)
+	return nil
+}
+
+func (r *recorder) Say(speaker string, words ...string) {
+	r.record("Say(%q, %q)", speaker, words)
+}
+
+func (r *recorder) HTTPGet(url string) error {
+	return errors.New("no network in tests")
+}
+
+// passthrough records commands that aren't dispatched.
+type passthrough struct {
+	yarn.FakeDialogueHandler
+	commands []string
+}
+
+func (p *passthrough) Command(command string) error {
+	p.commands = append(p.commands, command)
+	return nil
+}
+
+func TestCommandsHandler(t *testing.T) {
+	rec := &recorder{}
+	pass := &passthrough{}
+	h := &CommandsHandler{DialogueHandler: pass, Commands: rec}
+
+	for _, cmd := range []string{
+		"wave left",
+		"give gold 5",
+		"play_sound ding 0.5 true",
+		"wait 1.5 30",
+		`say Ava "Hello there" friend`,
+		"say Bo",
+		"dance",
+	} {
+		if err := h.Command(cmd); err != nil {
+			t.Errorf("Command(%q) = %v", cmd, err)
+		}
+	}
+	want := []string{
+		`Wave("left")`,
+		`Give("gold", 5)`,
+		`PlaySound("ding", 0.5, true)`,
+		`Wait(1.5, 30)`,
+		`Say("Ava", ["Hello there" "friend"])`,
+		`Say("Bo", [])`,
+	}
+	if diff := cmp.Diff(rec.calls, want); diff != "" {
+		t.Errorf("calls diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(pass.commands, []string{"dance"}); diff != "" {
+		t.Errorf("passed-through commands diff (-got +want):\n%s", diff)
+	}
+
+	for _, cmd := range []string{"give gold", "give gold lots", "play_sound ding loud true", "say"} {
+		if err := h.Command(cmd); !errors.Is(err, yarn.ErrCommandArgs) {
+			t.Errorf("Command(%q) = %v, want %v", cmd, err, yarn.ErrCommandArgs)
+		}
+	}
+	if err := h.Command("http_get example.com"); err == nil {
+		t.Errorf("Command(http_get) = nil, want error")
+	}
+	if diff := cmp.Diff(h.CommandNames(), []string{"give", "http_get", "play_sound", "say", "wait", "wave"}); diff != "" {
+		t.Errorf("CommandNames diff (-got +want):\n%s", diff)
+	}
+}
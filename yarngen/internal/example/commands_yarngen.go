@@ -0,0 +1,95 @@
+// Code generated by yarngen from Commands; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/DrJosh9000/yarn"
+)
+
+var _ yarn.DialogueHandler = &CommandsHandler{}
+
+// CommandsHandler is a yarn.DialogueHandler that dispatches commands to the
+// methods of Commands. Other commands, and all other events, are passed to
+// the embedded handler.
+type CommandsHandler struct {
+	yarn.DialogueHandler
+	Commands Commands
+}
+
+// CommandNames returns the names of the commands dispatched to Commands.
+func (*CommandsHandler) CommandNames() []string {
+	return []string{"give", "http_get", "play_sound", "say", "wait", "wave"}
+}
+
+// Command parses the command and calls the matching method of Commands, or
+// passes it to the embedded handler.
+func (h *CommandsHandler) Command(command string) error {
+	args := yarn.SplitCommand(command)
+	if len(args) == 0 {
+		return h.DialogueHandler.Command(command)
+	}
+	switch args[0] {
+	case "give":
+		if len(args) != 3 {
+			return fmt.Errorf("%w: %q takes 2 arguments, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0 := args[1]
+		a1, err := strconv.Atoi(args[2])
+		if err != nil {
+			return fmt.Errorf("%w: %q argument 2 (count): %v", yarn.ErrCommandArgs, args[0], err)
+		}
+		return h.Commands.Give(a0, a1)
+	case "http_get":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: %q takes 1 argument, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0 := args[1]
+		return h.Commands.HTTPGet(a0)
+	case "play_sound":
+		if len(args) != 4 {
+			return fmt.Errorf("%w: %q takes 3 arguments, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0 := args[1]
+		a1, err := strconv.ParseFloat(args[2], 32)
+		if err != nil {
+			return fmt.Errorf("%w: %q argument 2 (volume): %v", yarn.ErrCommandArgs, args[0], err)
+		}
+		a2, err := strconv.ParseBool(args[3])
+		if err != nil {
+			return fmt.Errorf("%w: %q argument 3 (loop): %v", yarn.ErrCommandArgs, args[0], err)
+		}
+		h.Commands.PlaySound(a0, float32(a1), a2)
+		return nil
+	case "say":
+		if len(args) < 2 {
+			return fmt.Errorf("%w: %q takes at least 1 argument, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0 := args[1]
+		a1 := args[2:]
+		h.Commands.Say(a0, a1...)
+		return nil
+	case "wait":
+		if len(args) != 3 {
+			return fmt.Errorf("%w: %q takes 2 arguments, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0, err := strconv.ParseFloat(args[1], 64)
+		if err != nil {
+			return fmt.Errorf("%w: %q argument 1 (seconds): %v", yarn.ErrCommandArgs, args[0], err)
+		}
+		a1, err := strconv.ParseInt(args[2], 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %q argument 2 (frames): %v", yarn.ErrCommandArgs, args[0], err)
+		}
+		return h.Commands.Wait(a0, a1)
+	case "wave":
+		if len(args) != 2 {
+			return fmt.Errorf("%w: %q takes 1 argument, got %d", yarn.ErrCommandArgs, args[0], len(args)-1)
+		}
+		a0 := args[1]
+		return h.Commands.Wave(a0)
+	}
+	return h.DialogueHandler.Command(command)
+}
@@ -0,0 +1,304 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yarngen generates command dispatch code from a Go interface, so
+// that commands are parsed and dispatched without reflection, and a typo in
+// a command name or argument type is a compile error rather than a runtime
+// surprise. It is used by cmd/yarngen.
+//
+// Each method of the interface is a command. The command name is the method
+// name in snake_case (PlaySound is play_sound). Parameters may be string,
+// bool, int, int64, float32, or float64, and the last may be ...string to
+// collect the remaining arguments. Methods may return nothing or an error.
+// For example:
+//
+//	type Commands interface {
+//		Wave(direction string) error
+//		Give(item string, count int) error
+//		Say(speaker string, words ...string)
+//	}
+//
+// generates a CommandsHandler type: a yarn.DialogueHandler that embeds
+// another, parses commands with yarn.SplitCommand, and calls the matching
+// method of its Commands field. Other commands are passed to the embedded
+// handler. Arguments that can't be parsed are reported as errors wrapping
+// yarn.ErrCommandArgs.
+package yarngen // import "github.com/DrJosh9000/yarn/yarngen"
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ErrUnsupported is returned when the interface has a method that can't be
+// used as a command.
+var ErrUnsupported = errors.New("unsupported command method")
+
+// Command describes a command generated from a method.
+type Command struct {
+	Name     string  // command name, e.g. play_sound
+	Method   string  // method name, e.g. PlaySound
+	Params   []Param // parameters, in order
+	Variadic bool    // whether the last parameter is ...string
+	HasError bool    // whether the method returns an error
+}
+
+// Param is a parameter of a command method.
+type Param struct {
+	Name string // parameter name, or argN if unnamed
+	Type string // Go type name; for a variadic parameter, string
+}
+
+// parsers are the calls that parse each supported parameter type (other than
+// string) from an argument. float32 arguments are parsed as float64, and
+// converted when passed to the method.
+var parsers = map[string]string{
+	"string":  "",
+	"bool":    "strconv.ParseBool(%s)",
+	"int":     "strconv.Atoi(%s)",
+	"int64":   "strconv.ParseInt(%s, 10, 64)",
+	"float32": "strconv.ParseFloat(%s, 32)",
+	"float64": "strconv.ParseFloat(%s, 64)",
+}
+
+// Commands finds the interface called typeName in the package source files
+// in dir (excluding tests), and returns its commands sorted by name, along
+// with the package name.
+func Commands(dir, typeName string) (pkg string, cmds []Command, err error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi fs.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return "", nil, err
+	}
+	for name, p := range pkgs {
+		for _, f := range p.Files {
+			obj := f.Scope.Lookup(typeName)
+			if obj == nil || obj.Kind != ast.Typ {
+				continue
+			}
+			spec, ok := obj.Decl.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			it, ok := spec.Type.(*ast.InterfaceType)
+			if !ok {
+				return "", nil, fmt.Errorf("%s is not an interface", typeName)
+			}
+			cmds, err := interfaceCommands(it)
+			return name, cmds, err
+		}
+	}
+	return "", nil, fmt.Errorf("interface %s not found in %s", typeName, dir)
+}
+
+// interfaceCommands converts the methods of an interface into commands.
+func interfaceCommands(it *ast.InterfaceType) ([]Command, error) {
+	var cmds []Command
+	seen := make(map[string]string)
+	for _, m := range it.Methods.List {
+		ft, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return nil, fmt.Errorf("%w: embedded interfaces are not supported", ErrUnsupported)
+		}
+		method := m.Names[0].Name
+		cmd := Command{Name: SnakeCase(method), Method: method}
+		if other, dup := seen[cmd.Name]; dup {
+			return nil, fmt.Errorf("%w: %s and %s are both command %q", ErrUnsupported, other, method, cmd.Name)
+		}
+		seen[cmd.Name] = method
+
+		for _, field := range ft.Params.List {
+			typ := field.Type
+			if e, ok := typ.(*ast.Ellipsis); ok {
+				if id, ok := e.Elt.(*ast.Ident); !ok || id.Name != "string" {
+					return nil, fmt.Errorf("%w: %s: only ...string is supported for variadic parameters", ErrUnsupported, method)
+				}
+				cmd.Variadic = true
+				typ = e.Elt
+			}
+			id, ok := typ.(*ast.Ident)
+			if ok {
+				_, ok = parsers[id.Name]
+			}
+			if !ok {
+				return nil, fmt.Errorf("%w: %s: parameter type %s", ErrUnsupported, method, types(typ))
+			}
+			names := field.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{{Name: fmt.Sprintf("arg%d", len(cmd.Params))}}
+			}
+			for _, n := range names {
+				cmd.Params = append(cmd.Params, Param{Name: n.Name, Type: id.Name})
+			}
+		}
+
+		if res := ft.Results; res != nil && len(res.List) > 0 {
+			id, ok := res.List[0].Type.(*ast.Ident)
+			if len(res.List) > 1 || len(res.List[0].Names) > 1 || !ok || id.Name != "error" {
+				return nil, fmt.Errorf("%w: %s: methods may only return an error", ErrUnsupported, method)
+			}
+			cmd.HasError = true
+		}
+		cmds = append(cmds, cmd)
+	}
+	sort.Slice(cmds, func(i, j int) bool { return cmds[i].Name < cmds[j].Name })
+	return cmds, nil
+}
+
+// types formats a type expression for error messages.
+func types(e ast.Expr) string {
+	switch e := e.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return "*" + types(e.X)
+	case *ast.SelectorExpr:
+		return types(e.X) + "." + e.Sel.Name
+	case *ast.ArrayType:
+		return "[]" + types(e.Elt)
+	}
+	return fmt.Sprintf("%T", e)
+}
+
+// SnakeCase converts a Go method name to a command name, e.g. PlaySound to
+// play_sound, and HTTPGet to http_get.
+func SnakeCase(name string) string {
+	rs := []rune(name)
+	var b strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			// Start a new word at an upper case letter that follows a lower
+			// case letter or digit, or that starts a word after an acronym.
+			if i > 0 && (!unicode.IsUpper(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Generate generates the source of a file in package pkg, containing a
+// handler type called typeName+"Handler" that dispatches the commands to a
+// field of type typeName.
+func Generate(pkg, typeName string, cmds []Command) ([]byte, error) {
+	handler := typeName + "Handler"
+	needStrconv := false
+	for _, c := range cmds {
+		for _, p := range c.Params {
+			needStrconv = needStrconv || p.Type != "string"
+		}
+	}
+
+	b := new(strings.Builder)
+	fmt.Fprintf(b, "// Code generated by yarngen from %s; DO NOT EDIT.\n\n", typeName)
+	fmt.Fprintf(b, "package %s\n\n", pkg)
+	b.WriteString("import (\n\t\"fmt\"\n")
+	if needStrconv {
+		b.WriteString("\t\"strconv\"\n")
+	}
+	b.WriteString("\n\t\"github.com/DrJosh9000/yarn\"\n)\n\n")
+
+	fmt.Fprintf(b, "var _ yarn.DialogueHandler = &%s{}\n\n", handler)
+	fmt.Fprintf(b, "// %s is a yarn.DialogueHandler that dispatches commands to the\n", handler)
+	fmt.Fprintf(b, "// methods of %s. Other commands, and all other events, are passed to\n", typeName)
+	b.WriteString("// the embedded handler.\n")
+	fmt.Fprintf(b, "type %s struct {\n\tyarn.DialogueHandler\n\t%s %s\n}\n\n", handler, typeName, typeName)
+
+	fmt.Fprintf(b, "// CommandNames returns the names of the commands dispatched to %s.\n", typeName)
+	fmt.Fprintf(b, "func (*%s) CommandNames() []string {\n\treturn []string{", handler)
+	for i, c := range cmds {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(b, "%q", c.Name)
+	}
+	b.WriteString("}\n}\n\n")
+
+	fmt.Fprintf(b, "// Command parses the command and calls the matching method of %s, or\n", typeName)
+	b.WriteString("// passes it to the embedded handler.\n")
+	fmt.Fprintf(b, "func (h *%s) Command(command string) error {\n", handler)
+	b.WriteString("\targs := yarn.SplitCommand(command)\n")
+	b.WriteString("\tif len(args) == 0 {\n\t\treturn h.DialogueHandler.Command(command)\n\t}\n")
+	b.WriteString("\tswitch args[0] {\n")
+	for _, c := range cmds {
+		genCase(b, typeName, c)
+	}
+	b.WriteString("\t}\n\treturn h.DialogueHandler.Command(command)\n}\n")
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return src, nil
+}
+
+// genCase generates the switch case for a command.
+func genCase(b *strings.Builder, typeName string, c Command) {
+	fixed := len(c.Params)
+	if c.Variadic {
+		fixed--
+	}
+	want := fmt.Sprintf("%d arguments", fixed)
+	if fixed == 1 {
+		want = "1 argument"
+	}
+	fmt.Fprintf(b, "\tcase %q:\n", c.Name)
+	if c.Variadic {
+		fmt.Fprintf(b, "\t\tif len(args) < %d {\n", fixed+1)
+		want = "at least " + want
+	} else {
+		fmt.Fprintf(b, "\t\tif len(args) != %d {\n", fixed+1)
+	}
+	fmt.Fprintf(b, "\t\t\treturn fmt.Errorf(\"%%w: %%q takes %s, got %%d\", yarn.ErrCommandArgs, args[0], len(args)-1)\n", want)
+	b.WriteString("\t\t}\n")
+
+	callArgs := make([]string, len(c.Params))
+	for i, p := range c.Params {
+		arg := fmt.Sprintf("a%d", i)
+		callArgs[i] = arg
+		switch {
+		case c.Variadic && i == fixed:
+			fmt.Fprintf(b, "\t\t%s := args[%d:]\n", arg, i+1)
+			callArgs[i] += "..."
+		case p.Type == "string":
+			fmt.Fprintf(b, "\t\t%s := args[%d]\n", arg, i+1)
+		default:
+			fmt.Fprintf(b, "\t\t%s, err := "+parsers[p.Type]+"\n", arg, fmt.Sprintf("args[%d]", i+1))
+			fmt.Fprintf(b, "\t\tif err != nil {\n")
+			fmt.Fprintf(b, "\t\t\treturn fmt.Errorf(\"%%w: %%q argument %d (%s): %%v\", yarn.ErrCommandArgs, args[0], err)\n", i+1, p.Name)
+			b.WriteString("\t\t}\n")
+			if p.Type == "float32" {
+				callArgs[i] = "float32(" + arg + ")"
+			}
+		}
+	}
+	call := fmt.Sprintf("h.%s.%s(%s)", typeName, c.Method, strings.Join(callArgs, ", "))
+	if c.HasError {
+		fmt.Fprintf(b, "\t\treturn %s\n", call)
+	} else {
+		fmt.Fprintf(b, "\t\t%s\n\t\treturn nil\n", call)
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarngen
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestGenerateIsUpToDate(t *testing.T) {
+	pkg, cmds, err := Commands("internal/example", "Commands")
+	if err != nil {
+		t.Fatalf("Commands = %v", err)
+	}
+	got, err := Generate(pkg, "Commands", cmds)
+	if err != nil {
+		t.Fatalf("Generate = %v", err)
+	}
+	want, err := os.ReadFile("internal/example/commands_yarngen.go")
+	if err != nil {
+		t.Fatalf("ReadFile = %v", err)
+	}
+	if diff := cmp.Diff(string(got), string(want)); diff != "" {
+		t.Errorf("generated code diff (-got +want):\n%s\nRun go generate ./... to update.", diff)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"Wave":      "wave",
+		"PlaySound": "play_sound",
+		"HTTPGet":   "http_get",
+		"GetHTTP":   "get_http",
+		"Play2D":    "play2_d",
+		"Áfonya":    "áfonya",
+	} {
+		if got := SnakeCase(in); got != want {
+			t.Errorf("SnakeCase(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCommandsUnsupported(t *testing.T) {
+	for _, src := range []string{
+		"type C interface { fmt.Stringer }",
+		"type C interface { Give(items []string) }",
+		"type C interface { Give(n ...int) }",
+		"type C interface { Give() int }",
+		"type C interface { Give() (error, error) }",
+		"type C interface { PlaySound(); Play_sound() }",
+	} {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "c.go"), []byte("package p\n"+src+"\n"), 0o644); err != nil {
+			t.Fatalf("WriteFile = %v", err)
+		}
+		if _, _, err := Commands(dir, "C"); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Commands(%q) = %v, want %v", src, err, ErrUnsupported)
+		}
+	}
+
+	if _, _, err := Commands("internal/example", "Nope"); err == nil {
+		t.Errorf("Commands(Nope) = nil error, want error")
+	}
+}
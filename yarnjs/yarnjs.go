@@ -0,0 +1,201 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yarnjs lets dialogue functions be written in JavaScript (using
+// goja), e.g. by teams more at home on the web. Scripts register functions
+// with the yarn object:
+//
+//	yarn.func("greeting", (name, n) => "Hello, " + name + "!".repeat(n));
+//
+// A Runtime is a yarn.Library of the functions:
+//
+//	r := yarnjs.New()
+//	if err := r.RunScript("dialogue.js", src); err != nil { ... }
+//	vm.FuncMap = yarn.CombineLibraries(gameFuncs, r)
+//
+// Scripts are sandboxed: there is no require, console, or any access to files,
+// the network, or the host. Each call (including running a script) is
+// interrupted if it runs longer than the runtime's Timeout.
+//
+// It is a separate module, so that programs that don't use JavaScript don't
+// have to depend on it.
+package yarnjs // import "github.com/DrJosh9000/yarn/yarnjs"
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/dop251/goja"
+)
+
+// DefaultTimeout is the time limit for each call, if Runtime.Timeout is zero.
+const DefaultTimeout = 100 * time.Millisecond
+
+// ErrTimeout is returned when a call is interrupted for running too long.
+var ErrTimeout = errors.New("javascript timed out")
+
+// Runtime is a JavaScript runtime containing dialogue functions. It is safe
+// for concurrent use, although calls into JavaScript are serialised.
+type Runtime struct {
+	// Timeout limits how long each call may run. If zero, DefaultTimeout is
+	// used.
+	Timeout time.Duration
+
+	mu    sync.Mutex
+	vm    *goja.Runtime
+	funcs map[string]goja.Callable
+}
+
+var _ yarn.Library = &Runtime{}
+
+// New returns a new Runtime.
+func New() *Runtime {
+	r := &Runtime{
+		vm:    goja.New(),
+		funcs: make(map[string]goja.Callable),
+	}
+	mod := r.vm.NewObject()
+	mod.Set("func", func(call goja.FunctionCall) goja.Value {
+		name := call.Argument(0).String()
+		fn, ok := goja.AssertFunction(call.Argument(1))
+		if !ok {
+			panic(r.vm.NewTypeError("yarn.func: %q is not a function", name))
+		}
+		r.funcs[name] = fn
+		return goja.Undefined()
+	})
+	r.vm.Set("yarn", mod)
+	return r
+}
+
+// RunScript runs a script, e.g. to register functions. name is used in error
+// messages. Running a script again replaces the functions it registers.
+func (r *Runtime) RunScript(name, src string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.withTimeout(name, func() error {
+		_, err := r.vm.RunScript(name, src)
+		return err
+	})
+}
+
+// Function returns a Go function that calls the named JavaScript function.
+func (r *Runtime) Function(name string) (any, bool) {
+	r.mu.Lock()
+	fn := r.funcs[name]
+	r.mu.Unlock()
+	if fn == nil {
+		return nil, false
+	}
+	return func(args ...any) (any, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		vals := make([]goja.Value, len(args))
+		for i, a := range args {
+			vals[i] = r.toValue(a)
+		}
+		var ret goja.Value
+		err := r.withTimeout(name, func() error {
+			v, err := fn(goja.Undefined(), vals...)
+			ret = v
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		return fromValue(name, ret)
+	}, true
+}
+
+// Names returns the names of the functions, sorted.
+func (r *Runtime) Names() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	names := make([]string, 0, len(r.funcs))
+	for n := range r.funcs {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// afterFunc is time.AfterFunc, returning the timer's Stop method. Tests
+// replace it to control when timeouts fire.
+var afterFunc = func(d time.Duration, f func()) (stop func() bool) {
+	return time.AfterFunc(d, f).Stop
+}
+
+// withTimeout runs f, interrupting the runtime if it takes too long. r.mu must
+// be held.
+func (r *Runtime) withTimeout(name string, f func() error) error {
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	fired := make(chan struct{})
+	stop := afterFunc(timeout, func() {
+		r.vm.Interrupt(ErrTimeout)
+		close(fired)
+	})
+	err := f()
+	if !stop() {
+		// The interrupt may still be on its way: wait for it, so that it
+		// is cleared below rather than interrupting the next call.
+		<-fired
+	}
+	r.vm.ClearInterrupt()
+
+	var ie *goja.InterruptedError
+	if errors.As(err, &ie) {
+		return fmt.Errorf("javascript %q: %w after %v", name, ErrTimeout, timeout)
+	}
+	if err != nil {
+		return fmt.Errorf("javascript %q: %w", name, err)
+	}
+	return nil
+}
+
+// toValue converts a Yarn value to a JavaScript value.
+func (r *Runtime) toValue(x any) goja.Value {
+	switch x := x.(type) {
+	case nil:
+		return goja.Null()
+	case bool, string:
+		return r.vm.ToValue(x)
+	}
+	if f, err := yarn.ConvertToFloat32(x); err == nil {
+		return r.vm.ToValue(float64(f))
+	}
+	return r.vm.ToValue(fmt.Sprint(x))
+}
+
+// fromValue converts a JavaScript value to a Yarn value.
+func fromValue(name string, v goja.Value) (any, error) {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return nil, nil
+	}
+	switch x := v.Export().(type) {
+	case bool, string:
+		return x, nil
+	case int64:
+		return float32(x), nil
+	case float64:
+		return float32(x), nil
+	}
+	return nil, fmt.Errorf("javascript %q: %s values can't be used in dialogue", name, v.ExportType())
+}
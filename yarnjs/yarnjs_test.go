@@ -0,0 +1,136 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarnjs
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/google/go-cmp/cmp"
+)
+
+const script = `
+yarn.func("greeting", (name, n) => "Hello, " + name + "!".repeat(n));
+yarn.func("double", x => x * 2);
+yarn.func("forever", () => { for (;;) {} });
+yarn.func("object", () => ({}));
+yarn.func("env", () => typeof require + " " + typeof console);
+`
+
+// recorder records lines.
+type recorder struct {
+	yarn.FakeDialogueHandler
+	lines []yarn.Line
+}
+
+func (r *recorder) Line(line yarn.Line) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func TestJS(t *testing.T) {
+	r := New()
+	r.Timeout = 20 * time.Millisecond
+	if err := r.RunScript("script.js", script); err != nil {
+		t.Fatalf("RunScript = %v", err)
+	}
+	if diff := cmp.Diff(r.Names(), []string{"double", "env", "forever", "greeting", "object"}); diff != "" {
+		t.Errorf("Names diff (-got +want):\n%s", diff)
+	}
+
+	pb := yarn.NewProgramBuilder("JS")
+	pb.Node("Start").
+		PushString("Ava").
+		PushFloat(2).
+		Call("greeting", 2).
+		Line("line:greet", 1).
+		PushFloat(1.5).
+		Call("double", 1).
+		StoreVariable("$x").
+		Pop()
+	vars := yarn.NewMapVariableStorage()
+	rec := &recorder{}
+	vm := &yarn.VirtualMachine{
+		Program: pb.Program(),
+		Handler: rec,
+		Vars:    vars,
+		FuncMap: yarn.CombineLibraries(r),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.lines, []yarn.Line{{ID: "line:greet", Substitutions: []string{"Hello, Ava!!"}}}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if got, _ := vars.GetValue("$x"); got != float32(3) {
+		t.Errorf("$x = %v, want 3", got)
+	}
+
+	call := func(name string) (any, error) {
+		t.Helper()
+		fn, ok := r.Function(name)
+		if !ok {
+			t.Fatalf("Function(%q) = _, false", name)
+		}
+		return fn.(func(...any) (any, error))()
+	}
+	if _, err := call("forever"); !errors.Is(err, ErrTimeout) {
+		t.Errorf("forever() = %v, want %v", err, ErrTimeout)
+	}
+	// The runtime is usable after an interrupted call.
+	if got, err := call("env"); err != nil || got != "undefined undefined" {
+		t.Errorf("env() = %v, %v, want %q, nil", got, err, "undefined undefined")
+	}
+	if _, err := call("object"); err == nil {
+		t.Errorf("object() = nil error, want error")
+	}
+	if err := r.RunScript("bad.js", "yarn.func('x', 3)"); err == nil {
+		t.Errorf("RunScript(bad.js) = nil, want error")
+	}
+}
+
+func TestLateTimeoutDoesNotLeak(t *testing.T) {
+	// Simulate a timer that fires just as the call finishes: Stop reports
+	// that it has fired, but the callback hasn't interrupted the runtime yet.
+	defer func(orig func(time.Duration, func()) func() bool) { afterFunc = orig }(afterFunc)
+	afterFunc = func(_ time.Duration, f func()) func() bool {
+		return func() bool {
+			go func() {
+				time.Sleep(10 * time.Millisecond)
+				f()
+			}()
+			return false
+		}
+	}
+
+	r := New()
+	if err := r.RunScript("script.js", script); err != nil {
+		t.Fatalf("RunScript = %v", err)
+	}
+	fn, ok := r.Function("double")
+	if !ok {
+		t.Fatal(`Function("double") = _, false`)
+	}
+	double := fn.(func(...any) (any, error))
+	if _, err := double(float32(1)); err != nil {
+		t.Fatalf("double(1) = %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := double(float32(2)); err != nil {
+		t.Errorf("double(2) after a late timeout = %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+module github.com/DrJosh9000/yarn/yarnlua
+
+go 1.21
+
+require (
+	github.com/DrJosh9000/yarn v0.0.0
+	github.com/google/go-cmp v0.6.0
+	github.com/yuin/gopher-lua v1.1.1
+)
+
+require (
+	github.com/alecthomas/participle/v2 v2.0.0 // indirect
+	github.com/razor-1/localizer-cldr v0.2.0 // indirect
+	github.com/rivo/uniseg v0.2.0 // indirect
+	golang.org/x/text v0.9.0 // indirect
+	google.golang.org/protobuf v1.30.0 // indirect
+)
+
+replace github.com/DrJosh9000/yarn => ..
@@ -0,0 +1,295 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yarnlua lets dialogue functions and commands be written in Lua, so
+// that designers can add them without recompiling the game. Scripts register
+// them with the yarn module:
+//
+//	yarn.func("greeting", function(name)
+//	    return "Hello, " .. name
+//	end)
+//
+//	yarn.command("give", function(item, count)
+//	    yarn.set("$" .. item, (yarn.get("$" .. item) or 0) + tonumber(count))
+//	end)
+//
+// A State is a yarn.Library of the functions, and Handler runs the commands:
+//
+//	s := yarnlua.New()
+//	defer s.Close()
+//	if err := s.DoFile("dialogue.lua"); err != nil { ... }
+//	s.Vars = vars
+//	vm := &yarn.VirtualMachine{
+//		Program: prog,
+//		Handler: &yarnlua.Handler{DialogueHandler: h, State: s},
+//		Vars:    vars,
+//		FuncMap: yarn.CombineLibraries(gameFuncs, s),
+//	}
+//
+// Only the base, table, string, and math Lua libraries are available to
+// scripts.
+//
+// It is a separate module, so that programs that don't use Lua don't have to
+// depend on it.
+package yarnlua // import "github.com/DrJosh9000/yarn/yarnlua"
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DrJosh9000/yarn"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// State is a Lua state containing dialogue functions and commands. It is safe
+// for concurrent use, although calls into Lua are serialised.
+type State struct {
+	// Vars, if not nil, is made available to scripts through yarn.get and
+	// yarn.set.
+	Vars yarn.VariableStorage
+
+	mu    sync.Mutex
+	l     *lua.LState
+	funcs map[string]*lua.LFunction
+	cmds  map[string]*lua.LFunction
+}
+
+var _ yarn.Library = &State{}
+
+// New returns a new State.
+func New() *State {
+	s := &State{
+		l:     lua.NewState(lua.Options{SkipOpenLibs: true}),
+		funcs: make(map[string]*lua.LFunction),
+		cmds:  make(map[string]*lua.LFunction),
+	}
+	for _, lib := range []struct {
+		name string
+		open lua.LGFunction
+	}{
+		{lua.BaseLibName, lua.OpenBase},
+		{lua.TabLibName, lua.OpenTable},
+		{lua.StringLibName, lua.OpenString},
+		{lua.MathLibName, lua.OpenMath},
+	} {
+		s.l.Push(s.l.NewFunction(lib.open))
+		s.l.Push(lua.LString(lib.name))
+		s.l.Call(1, 0)
+	}
+	mod := s.l.SetFuncs(s.l.NewTable(), map[string]lua.LGFunction{
+		"func":    s.register(s.funcs),
+		"command": s.register(s.cmds),
+		"get":     s.get,
+		"set":     s.set,
+	})
+	s.l.SetGlobal("yarn", mod)
+	return s
+}
+
+// Close closes the Lua state.
+func (s *State) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.l.Close()
+}
+
+// DoString runs a Lua script, e.g. to register functions and commands.
+func (s *State) DoString(src string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.l.DoString(src)
+}
+
+// DoFile runs a Lua script file. Running a script again replaces the
+// functions and commands it registers, e.g. to reload scripts during
+// development.
+func (s *State) DoFile(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.l.DoFile(path)
+}
+
+// Function returns a Go function that calls the named Lua function.
+func (s *State) Function(name string) (any, bool) {
+	s.mu.Lock()
+	fn := s.funcs[name]
+	s.mu.Unlock()
+	if fn == nil {
+		return nil, false
+	}
+	return func(args ...any) (any, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.call(fn, name, toLua(args))
+	}, true
+}
+
+// Names returns the names of the functions, sorted.
+func (s *State) Names() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedNames(s.funcs)
+}
+
+// Commands returns the names of the commands, sorted.
+func (s *State) Commands() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return sortedNames(s.cmds)
+}
+
+// RunCommand runs a command if its name (the first word) is a Lua command.
+// The remaining words are passed to the command as strings. ok is false if
+// there is no such command.
+func (s *State) RunCommand(command string) (result any, ok bool, err error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn := s.cmds[fields[0]]
+	if fn == nil {
+		return nil, false, nil
+	}
+	args := make([]lua.LValue, len(fields)-1)
+	for i, f := range fields[1:] {
+		args[i] = lua.LString(f)
+	}
+	result, err = s.call(fn, fields[0], args)
+	return result, true, err
+}
+
+// call calls a Lua function with s.mu held.
+func (s *State) call(fn *lua.LFunction, name string, args []lua.LValue) (any, error) {
+	if err := s.l.CallByParam(lua.P{Fn: fn, NRet: 1, Protect: true}, args...); err != nil {
+		return nil, fmt.Errorf("lua %q: %w", name, err)
+	}
+	ret := s.l.Get(-1)
+	s.l.Pop(1)
+	return fromLua(ret)
+}
+
+// register returns the implementation of yarn.func or yarn.command.
+func (s *State) register(into map[string]*lua.LFunction) lua.LGFunction {
+	return func(l *lua.LState) int {
+		into[l.CheckString(1)] = l.CheckFunction(2)
+		return 0
+	}
+}
+
+// get implements yarn.get.
+func (s *State) get(l *lua.LState) int {
+	name := l.CheckString(1)
+	if s.Vars == nil {
+		l.RaiseError("no variable storage")
+	}
+	v, _ := s.Vars.GetValue(name)
+	l.Push(toLua([]any{v})[0])
+	return 1
+}
+
+// set implements yarn.set.
+func (s *State) set(l *lua.LState) int {
+	name := l.CheckString(1)
+	if s.Vars == nil {
+		l.RaiseError("no variable storage")
+	}
+	v, err := fromLua(l.Get(2))
+	if err != nil {
+		l.ArgError(2, err.Error())
+	}
+	s.Vars.SetValue(name, v)
+	return 0
+}
+
+// toLua converts Yarn values to Lua values.
+func toLua(args []any) []lua.LValue {
+	vals := make([]lua.LValue, len(args))
+	for i, a := range args {
+		switch x := a.(type) {
+		case nil:
+			vals[i] = lua.LNil
+		case bool:
+			vals[i] = lua.LBool(x)
+		case string:
+			vals[i] = lua.LString(x)
+		default:
+			if f, err := yarn.ConvertToFloat32(x); err == nil {
+				vals[i] = lua.LNumber(f)
+			} else {
+				vals[i] = lua.LString(fmt.Sprint(x))
+			}
+		}
+	}
+	return vals
+}
+
+// fromLua converts a Lua value to a Yarn value.
+func fromLua(v lua.LValue) (any, error) {
+	switch x := v.(type) {
+	case *lua.LNilType:
+		return nil, nil
+	case lua.LBool:
+		return bool(x), nil
+	case lua.LNumber:
+		return float32(x), nil
+	case lua.LString:
+		return string(x), nil
+	}
+	return nil, fmt.Errorf("lua %s values can't be used in dialogue", v.Type())
+}
+
+func sortedNames(m map[string]*lua.LFunction) []string {
+	names := make([]string, 0, len(m))
+	for n := range m {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var (
+	_ yarn.DialogueHandler      = &Handler{}
+	_ yarn.CommandResultHandler = &Handler{}
+)
+
+// Handler is a yarn.DialogueHandler that runs Lua commands, and passes other
+// commands and all other events to the embedded handler. Results returned by
+// Lua commands are stored in a variable (see yarn.CommandResultHandler).
+type Handler struct {
+	yarn.DialogueHandler
+	State *State
+}
+
+// Command runs a Lua command, or passes it to the embedded handler.
+func (h *Handler) Command(command string) error {
+	_, err := h.CommandResult(command)
+	return err
+}
+
+// CommandResult runs a Lua command and returns its result, or passes it to
+// the embedded handler.
+func (h *Handler) CommandResult(command string) (any, error) {
+	result, ok, err := h.State.RunCommand(command)
+	if ok {
+		return result, err
+	}
+	if crh, ok := h.DialogueHandler.(yarn.CommandResultHandler); ok {
+		return crh.CommandResult(command)
+	}
+	return nil, h.DialogueHandler.Command(command)
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarnlua
+
+import (
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/google/go-cmp/cmp"
+)
+
+const script = `
+yarn.func("greeting", function(name, n)
+    return "Hello, " .. name .. string.rep("!", n)
+end)
+
+yarn.command("give", function(item, count)
+    local name = "$" .. item
+    yarn.set(name, (yarn.get(name) or 0) + tonumber(count))
+    return yarn.get(name)
+end)
+
+yarn.func("table", function() return {} end)
+`
+
+// recorder records lines and commands.
+type recorder struct {
+	yarn.FakeDialogueHandler
+	lines    []yarn.Line
+	commands []string
+}
+
+func (r *recorder) Line(line yarn.Line) error {
+	r.lines = append(r.lines, line)
+	return nil
+}
+
+func (r *recorder) Command(command string) error {
+	r.commands = append(r.commands, command)
+	return nil
+}
+
+func TestLua(t *testing.T) {
+	s := New()
+	defer s.Close()
+	if err := s.DoString(script); err != nil {
+		t.Fatalf("DoString = %v", err)
+	}
+	if diff := cmp.Diff(s.Names(), []string{"greeting", "table"}); diff != "" {
+		t.Errorf("Names diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(s.Commands(), []string{"give"}); diff != "" {
+		t.Errorf("Commands diff (-got +want):\n%s", diff)
+	}
+
+	pb := yarn.NewProgramBuilder("Lua")
+	pb.Node("Start").
+		PushString("Ava").
+		PushFloat(2).
+		Call("greeting", 2).
+		Line("line:greet", 1).
+		Command("give gold 5", 0).
+		Command("give gold 3 -> $total", 0).
+		Command("wave", 0)
+
+	vars := yarn.NewMapVariableStorage()
+	s.Vars = vars
+	rec := &recorder{}
+	vm := &yarn.VirtualMachine{
+		Program: pb.Program(),
+		Handler: &Handler{DialogueHandler: rec, State: s},
+		Vars:    vars,
+		FuncMap: yarn.CombineLibraries(s),
+	}
+	if err := vm.Run("Start"); err != nil {
+		t.Fatalf("vm.Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(rec.lines, []yarn.Line{{ID: "line:greet", Substitutions: []string{"Hello, Ava!!"}}}); diff != "" {
+		t.Errorf("lines diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(rec.commands, []string{"wave"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+	for name, want := range map[string]any{"$gold": float32(8), "$total": float32(8)} {
+		if got, _ := vars.GetValue(name); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	fn, ok := s.Function("table")
+	if !ok {
+		t.Fatalf("Function(table) = _, false")
+	}
+	if _, err := fn.(func(...any) (any, error))(); err == nil {
+		t.Errorf("table() = nil error, want error")
+	}
+	if _, ok, err := s.RunCommand("give gold nope"); !ok || err == nil {
+		t.Errorf("RunCommand(give gold nope) = %t, %v, want true, error", ok, err)
+	}
+	if _, ok := s.Function("nope"); ok {
+		t.Errorf("Function(nope) = _, true")
+	}
+}
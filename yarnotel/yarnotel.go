@@ -0,0 +1,269 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package yarnotel provides optional OpenTelemetry tracing for the yarn
+// virtual machine. It creates spans for each Run, each node executed within
+// the run, each command delivered to the handler, and each function called by
+// the program.
+//
+// It is a separate module, so that programs that don't use OpenTelemetry
+// don't have to depend on it.
+package yarnotel // import "github.com/DrJosh9000/yarn/yarnotel"
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/DrJosh9000/yarn"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName is the name of the default tracer.
+const InstrumentationName = "github.com/DrJosh9000/yarn/yarnotel"
+
+// Span attribute keys.
+const (
+	AttrNode     = attribute.Key("yarn.node")
+	AttrStart    = attribute.Key("yarn.start_node")
+	AttrCommand  = attribute.Key("yarn.command")
+	AttrFunction = attribute.Key("yarn.function")
+	AttrLineID   = attribute.Key("yarn.line_id")
+	AttrOptions  = attribute.Key("yarn.options")
+	AttrChoice   = attribute.Key("yarn.choice")
+)
+
+var (
+	_ yarn.DialogueHandler      = &Handler{}
+	_ yarn.CommandResultHandler = &Handler{}
+	_ yarn.SkipHandler          = &Handler{}
+)
+
+// Handler is a yarn.DialogueHandler that wraps another, creating spans for
+// nodes and commands. Lines and options are recorded as span events on the
+// node span.
+//
+// Handler forwards the optional interfaces that the VM looks for
+// (yarn.CommandResultHandler and yarn.SkipHandler), behaving as the VM would
+// if the wrapped handler doesn't implement them. Optional interfaces that
+// other wrapping handlers look for (such as yarn.ParagraphLineHandler) are
+// not forwarded, so Handler should wrap those handlers rather than be
+// wrapped by them (as Run does).
+type Handler struct {
+	tracer  trace.Tracer
+	handler yarn.DialogueHandler
+
+	runCtx   context.Context // context containing the run span
+	nodeCtx  context.Context // context containing the current node span
+	nodeSpan trace.Span
+}
+
+// NewHandler wraps h. Node spans are created as children of the span in ctx.
+// If tracer is nil, the tracer from the global provider is used.
+func NewHandler(ctx context.Context, tracer trace.Tracer, h yarn.DialogueHandler) *Handler {
+	if tracer == nil {
+		tracer = otel.Tracer(InstrumentationName)
+	}
+	return &Handler{
+		tracer:  tracer,
+		handler: h,
+		runCtx:  ctx,
+		nodeCtx: ctx,
+	}
+}
+
+// Context returns the context for the current node (or the run, outside of
+// any node). It can be used to parent spans created by the game.
+func (h *Handler) Context() context.Context { return h.nodeCtx }
+
+// endNode ends the current node span, if there is one.
+func (h *Handler) endNode(err error) {
+	if h.nodeSpan == nil {
+		return
+	}
+	recordErr(h.nodeSpan, err)
+	h.nodeSpan.End()
+	h.nodeSpan = nil
+	h.nodeCtx = h.runCtx
+}
+
+// NodeStart starts a span for the node, then calls the wrapped handler.
+func (h *Handler) NodeStart(nodeName string) error {
+	h.endNode(nil)
+	h.nodeCtx, h.nodeSpan = h.tracer.Start(h.runCtx, "yarn.node "+nodeName,
+		trace.WithAttributes(AttrNode.String(nodeName)))
+	err := h.handler.NodeStart(nodeName)
+	recordErr(h.nodeSpan, err)
+	return err
+}
+
+// PrepareForLines calls the wrapped handler.
+func (h *Handler) PrepareForLines(lineIDs []string) error {
+	return h.handler.PrepareForLines(lineIDs)
+}
+
+// Line adds an event to the node span, then calls the wrapped handler.
+func (h *Handler) Line(line yarn.Line) error {
+	trace.SpanFromContext(h.nodeCtx).AddEvent("yarn.line",
+		trace.WithAttributes(AttrLineID.String(line.ID)))
+	return h.handler.Line(line)
+}
+
+// Options adds an event to the node span, then calls the wrapped handler.
+func (h *Handler) Options(options []yarn.Option) (int, error) {
+	span := trace.SpanFromContext(h.nodeCtx)
+	span.AddEvent("yarn.options",
+		trace.WithAttributes(AttrOptions.Int(len(options))))
+	choice, err := h.handler.Options(options)
+	if err == nil {
+		span.AddEvent("yarn.choice",
+			trace.WithAttributes(AttrChoice.Int(choice)))
+	}
+	return choice, err
+}
+
+// Command creates a span wrapping the call to the wrapped handler.
+func (h *Handler) Command(command string) error {
+	_, span := h.tracer.Start(h.nodeCtx, "yarn.command",
+		trace.WithAttributes(AttrCommand.String(command)))
+	defer span.End()
+	err := h.handler.Command(command)
+	recordErr(span, err)
+	return err
+}
+
+// CommandResult creates a span wrapping the call to the wrapped handler's
+// CommandResult method, or its Command method if it doesn't implement
+// yarn.CommandResultHandler (in which case there is no result).
+func (h *Handler) CommandResult(command string) (any, error) {
+	_, span := h.tracer.Start(h.nodeCtx, "yarn.command",
+		trace.WithAttributes(AttrCommand.String(command)))
+	defer span.End()
+	var result any
+	var err error
+	if crh, ok := h.handler.(yarn.CommandResultHandler); ok {
+		result, err = crh.CommandResult(command)
+	} else {
+		err = h.handler.Command(command)
+	}
+	recordErr(span, err)
+	return result, err
+}
+
+// SkipLine adds an event to the node span, then calls the wrapped handler's
+// SkipLine method, if it implements yarn.SkipHandler.
+func (h *Handler) SkipLine(line yarn.Line) error {
+	trace.SpanFromContext(h.nodeCtx).AddEvent("yarn.skip_line",
+		trace.WithAttributes(AttrLineID.String(line.ID)))
+	if sh, ok := h.handler.(yarn.SkipHandler); ok {
+		return sh.SkipLine(line)
+	}
+	return nil
+}
+
+// NodeComplete calls the wrapped handler, then ends the node span.
+func (h *Handler) NodeComplete(nodeName string) error {
+	err := h.handler.NodeComplete(nodeName)
+	h.endNode(err)
+	return err
+}
+
+// DialogueComplete calls the wrapped handler.
+func (h *Handler) DialogueComplete() error {
+	return h.handler.DialogueComplete()
+}
+
+// WrapFuncMap returns a new FuncMap where each function creates a span (as a
+// child of the current node span in h) around each call. Function types are
+// preserved, so argument conversions by the VM work as before. Only the
+// functions in fm are wrapped, so the built-in operators (which would be
+// very noisy) are not traced.
+func WrapFuncMap(h *Handler, fm yarn.FuncMap) yarn.FuncMap {
+	out := make(yarn.FuncMap, len(fm))
+	for name, f := range fm {
+		out[name] = wrapFunc(h, name, f)
+	}
+	return out
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+func wrapFunc(h *Handler, name string, f any) any {
+	if pf, ok := f.(yarn.PureFunc); ok {
+		return yarn.Pure(wrapFunc(h, name, pf.Func))
+	}
+	fv := reflect.ValueOf(f)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		// Leave it for the VM to complain about.
+		return f
+	}
+	return reflect.MakeFunc(ft, func(args []reflect.Value) []reflect.Value {
+		_, span := h.tracer.Start(h.nodeCtx, "yarn.func "+name,
+			trace.WithAttributes(AttrFunction.String(name)))
+		defer span.End()
+		var out []reflect.Value
+		if ft.IsVariadic() {
+			out = fv.CallSlice(args)
+		} else {
+			out = fv.Call(args)
+		}
+		if n := ft.NumOut(); n > 0 && ft.Out(n-1) == errorType && !out[n-1].IsNil() {
+			recordErr(span, out[n-1].Interface().(error))
+		}
+		return out
+	}).Interface()
+}
+
+// Run runs vm from startNode inside a new span. vm.Handler and vm.FuncMap are
+// wrapped for the duration of the run, and restored afterwards.
+func Run(ctx context.Context, tracer trace.Tracer, vm *yarn.VirtualMachine, startNode string) (err error) {
+	if tracer == nil {
+		tracer = otel.Tracer(InstrumentationName)
+	}
+	ctx, span := tracer.Start(ctx, "yarn.Run",
+		trace.WithAttributes(AttrStart.String(startNode)))
+	defer func() {
+		recordErr(span, err)
+		span.End()
+	}()
+
+	origHandler, origFuncMap := vm.Handler, vm.FuncMap
+	defer func() {
+		vm.Handler, vm.FuncMap = origHandler, origFuncMap
+	}()
+	if origHandler == nil {
+		return yarn.ErrNilDialogueHandler
+	}
+	h := NewHandler(ctx, tracer, origHandler)
+	vm.Handler = h
+	vm.FuncMap = WrapFuncMap(h, origFuncMap)
+	defer h.endNode(nil)
+	if err := vm.Run(startNode); err != nil {
+		return fmt.Errorf("vm.Run(%q): %w", startNode, err)
+	}
+	return nil
+}
+
+func recordErr(span trace.Span, err error) {
+	if err == nil || span == nil || errors.Is(err, yarn.Stop) {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
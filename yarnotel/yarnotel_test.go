@@ -0,0 +1,151 @@
+// Copyright 2026 Josh Deprez
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package yarnotel
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DrJosh9000/yarn"
+	"github.com/google/go-cmp/cmp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestRun(t *testing.T) {
+	prog, err := yarn.LoadProgramFile("../testdata/Functions.yarnc")
+	if err != nil {
+		t.Fatalf("LoadProgramFile(Functions.yarnc) = %v", err)
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+
+	vm := &yarn.VirtualMachine{
+		Program: prog,
+		Handler: yarn.FakeDialogueHandler{},
+		Vars:    yarn.NewMapVariableStorage(),
+		FuncMap: yarn.FuncMap{
+			"assert": func(x bool) {},
+			"add_three_operands": func(x, y, z float32) float32 {
+				return x + y + z
+			},
+		},
+	}
+	if err := Run(context.Background(), tracer, vm, "Start"); err != nil {
+		t.Fatalf("Run(Start) = %v", err)
+	}
+
+	parents := make(map[string]string)
+	for _, s := range rec.Ended() {
+		parent := ""
+		for _, p := range rec.Ended() {
+			if p.SpanContext().SpanID() == s.Parent().SpanID() {
+				parent = p.Name()
+			}
+		}
+		parents[s.Name()] = parent
+	}
+	want := map[string]string{
+		"yarn.Run":                     "",
+		"yarn.node Start":              "yarn.Run",
+		"yarn.func assert":             "yarn.node Start",
+		"yarn.func add_three_operands": "yarn.node Start",
+	}
+	if diff := cmp.Diff(parents, want); diff != "" {
+		t.Errorf("span parents diff:\n%s", diff)
+	}
+}
+
+// resultHandler implements the optional interfaces that Handler forwards.
+type resultHandler struct {
+	yarn.FakeDialogueHandler
+	commands []string
+	skipped  []string
+}
+
+func (h *resultHandler) CommandResult(command string) (any, error) {
+	h.commands = append(h.commands, command)
+	return float32(17), nil
+}
+
+func (h *resultHandler) SkipLine(line yarn.Line) error {
+	h.skipped = append(h.skipped, line.ID)
+	return nil
+}
+
+func TestRunForwardsOptionalInterfaces(t *testing.T) {
+	pb := yarn.NewProgramBuilder("Optional")
+	pb.Node("Start").
+		Line("line:skipped", 0).
+		Command("roll_dice 20 -> $roll", 0).
+		Stop()
+
+	rec := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)).Tracer("test")
+
+	h := &resultHandler{}
+	vars := yarn.NewMapVariableStorage()
+	vm := &yarn.VirtualMachine{
+		Program: pb.Program(),
+		Handler: h,
+		Vars:    vars,
+	}
+	vm.SetSkipMode(true)
+	if err := Run(context.Background(), tracer, vm, "Start"); err != nil {
+		t.Fatalf("Run(Start) = %v", err)
+	}
+	if diff := cmp.Diff(h.commands, []string{"roll_dice 20"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+	if diff := cmp.Diff(h.skipped, []string{"line:skipped"}); diff != "" {
+		t.Errorf("skipped lines diff (-got +want):\n%s", diff)
+	}
+	if got, _ := vars.GetValue("$roll"); got != float32(17) {
+		t.Errorf("$roll = %v, want 17", got)
+	}
+	found := false
+	for _, s := range rec.Ended() {
+		found = found || s.Name() == "yarn.command"
+	}
+	if !found {
+		t.Error("no yarn.command span")
+	}
+}
+
+func TestHandlerCommandResultFallback(t *testing.T) {
+	h := NewHandler(context.Background(), nil, &commandRecorder{})
+	result, err := h.CommandResult("wave")
+	if result != nil || err != nil {
+		t.Errorf("h.CommandResult(wave) = (%v, %v), want (nil, nil)", result, err)
+	}
+	if err := h.SkipLine(yarn.Line{ID: "line:1"}); err != nil {
+		t.Errorf("h.SkipLine(line:1) = %v, want nil", err)
+	}
+	if diff := cmp.Diff(h.handler.(*commandRecorder).commands, []string{"wave"}); diff != "" {
+		t.Errorf("commands diff (-got +want):\n%s", diff)
+	}
+}
+
+// commandRecorder records commands, and implements no optional interfaces.
+type commandRecorder struct {
+	yarn.FakeDialogueHandler
+	commands []string
+}
+
+func (r *commandRecorder) Command(command string) error {
+	r.commands = append(r.commands, command)
+	return nil
+}